@@ -0,0 +1,178 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// BlockOp is one read or write in an AccessBatch. Data is the block's new
+// contents, or nil to read it.
+type BlockOp struct {
+	BlockID int
+	Data    []byte
+}
+
+// AccessBatch performs ops as one atomic step. Every op is validated and
+// authorized before any I/O, and the lock is held throughout, so no other
+// access observes part of the batch and an invalid op leaves the ORAM
+// untouched. Ops apply in order, so a read sees earlier writes to the same
+// block; results[i] is what Access would return for ops[i] (the current
+// value for a read, the previous value for a write).
+//
+// What the storage server observes is described on AccessBatchCtx;
+// AccessBatch does not dedupe.
+func (o *PathORAM) AccessBatch(ops []BlockOp) ([][]byte, error) {
+	return o.AccessBatchCtx(context.Background(), ops, false)
+}
+
+// AccessBatchCtx is like AccessBatch but propagates ctx to the storage
+// backend; cancellation is honored until the ops are applied.
+//
+// Without dedupe, one path is chosen per op: the block's current path for
+// the first op on a block, and a uniformly random one for each repeat. The
+// server sees the buckets of the union of those len(ops) paths read once,
+// followed by len(ops) path evictions, one per chosen path, written back to
+// back (or deferred, with Config.BackgroundEviction). It learns len(ops), and that the accesses came as a batch, but not
+// which blocks they touched or whether any repeat. Access time padding
+// (Config.UniformAccessTime) covers len(ops) accesses.
+//
+// With dedupe, one path is chosen per distinct block. The server sees the
+// buckets of their union read once and then written once, in a single
+// eviction over all of them, as with WriteBatch. That saves I/O, but the
+// number of paths reveals the number of distinct blocks rather than
+// len(ops), so batches with repeats can be told from those without, and no
+// access time padding is applied.
+//
+// If eviction fails after the ops are applied, the blocks stay in the stash
+// and the batch has still taken effect.
+func (o *PathORAM) AccessBatchCtx(ctx context.Context, ops []BlockOp, dedupe bool) ([][]byte, error) {
+	for _, op := range ops {
+		if op.BlockID < 0 || op.BlockID >= o.cfg.NumBlocks {
+			return nil, ErrInvalidBlockID
+		}
+		if op.Data != nil && len(op.Data) != o.cfg.BlockSize {
+			return nil, ErrInvalidDataSize
+		}
+		kind := OpWrite
+		if op.Data == nil {
+			kind = OpRead
+		}
+		if err := o.authorize(ctx, kind, op.BlockID); err != nil {
+			return nil, err
+		}
+	}
+	if len(ops) == 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	if !dedupe {
+		defer o.padAccess(ctx, start, len(ops))
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var results [][]byte
+	var err error
+	if dedupe {
+		results, err = o.accessBatchShared(ctx, ops)
+	} else {
+		results, err = o.accessBatch(ctx, ops)
+	}
+	o.finishAccess(start, err)
+	return results, err
+}
+
+// accessBatch implements AccessBatchCtx with one eviction per op.
+func (o *PathORAM) accessBatch(ctx context.Context, ops []BlockOp) ([][]byte, error) {
+	if o.closed {
+		return nil, ErrClosed
+	}
+	if err := o.takeBackgroundErr(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(ops))
+	for i, op := range ops {
+		ids[i] = op.BlockID
+	}
+	paths, _, err := o.readGroupPaths(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	results := o.applyOps(ops)
+
+	// As in accessGroup, only the last eviction checks the stash limit
+	for i, path := range paths {
+		err := o.evictGroupPath(path)
+		if errors.Is(err, ErrStashOverflow) && i < len(paths)-1 {
+			err = nil
+		}
+		if err == nil {
+			err = o.recordAccess()
+		}
+		if err != nil {
+			return nil, err
+		}
+		o.noteNamespace(ctx, false)
+	}
+	return results, nil
+}
+
+// accessBatchShared implements AccessBatchCtx with shared eviction.
+func (o *PathORAM) accessBatchShared(ctx context.Context, ops []BlockOp) ([][]byte, error) {
+	if o.closed {
+		return nil, ErrClosed
+	}
+	if err := o.takeBackgroundErr(); err != nil {
+		return nil, err
+	}
+
+	var blocks []int
+	var paths [][]int
+	seen := make(map[int]bool, len(ops))
+	for _, op := range ops {
+		if seen[op.BlockID] {
+			continue
+		}
+		seen[op.BlockID] = true
+		leaf, exists := o.posMap.Get(op.BlockID)
+		if !exists {
+			leaf = o.randomLeaf()
+		}
+		blocks = append(blocks, op.BlockID)
+		paths = append(paths, o.storedPath(leaf))
+	}
+	bucketData, err := o.readBatchPaths(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range blocks {
+		o.remap(id)
+	}
+	results := o.applyOps(ops)
+
+	ctx = context.WithoutCancel(ctx)
+	if o.cfg.ConstantTime {
+		err = o.evictMultiPathCT(ctx, paths, bucketData)
+	} else {
+		err = o.evictMultiPathWithStrategy(ctx, paths, bucketData)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := o.recordAccess(); err != nil {
+		return nil, err
+	}
+	o.noteNamespace(ctx, false)
+	return results, nil
+}
+
+// applyOps applies ops in order to blocks already in the stash.
+func (o *PathORAM) applyOps(ops []BlockOp) [][]byte {
+	results := make([][]byte, len(ops))
+	for i, op := range ops {
+		results[i] = o.stashAccess(op.BlockID, op.Data, nil)
+	}
+	return results
+}
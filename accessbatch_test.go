@@ -0,0 +1,86 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAccessBatch_ReadsSeeEarlierWrites(t *testing.T) {
+	for _, dedupe := range []bool{false, true} {
+		cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, StashLimit: 200}
+		oram, err := NewInMemory(cfg)
+		if err != nil {
+			t.Fatalf("NewInMemory: %v", err)
+		}
+		a := bytes.Repeat([]byte{0xAA}, 16)
+		b := bytes.Repeat([]byte{0xBB}, 16)
+		if _, err := oram.Write(3, a); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+
+		results, err := oram.AccessBatchCtx(context.Background(), []BlockOp{
+			{BlockID: 3},
+			{BlockID: 3, Data: b},
+			{BlockID: 3},
+			{BlockID: 7, Data: a},
+		}, dedupe)
+		if err != nil {
+			t.Fatalf("dedupe=%v: AccessBatch: %v", dedupe, err)
+		}
+		want := [][]byte{a, a, b, make([]byte, 16)}
+		for i := range want {
+			if !bytes.Equal(results[i], want[i]) {
+				t.Errorf("dedupe=%v: results[%d] = %x, want %x", dedupe, i, results[i], want[i])
+			}
+		}
+		for id, w := range map[int][]byte{3: b, 7: a} {
+			got, err := oram.Read(id)
+			if err != nil || !bytes.Equal(got, w) {
+				t.Errorf("dedupe=%v: Read(%d) = %x, %v; want %x", dedupe, id, got, err, w)
+			}
+		}
+	}
+}
+
+func TestAccessBatch_InvalidOpAppliesNothing(t *testing.T) {
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4, StashLimit: 100}
+	oram, err := NewInMemory(cfg)
+	if err != nil {
+		t.Fatalf("NewInMemory: %v", err)
+	}
+	data := bytes.Repeat([]byte{1}, 16)
+	_, err = oram.AccessBatch([]BlockOp{{BlockID: 1, Data: data}, {BlockID: 2, Data: []byte{1}}})
+	if !errors.Is(err, ErrInvalidDataSize) {
+		t.Fatalf("AccessBatch = %v, want ErrInvalidDataSize", err)
+	}
+	if got := oram.Stats().Accesses; got != 0 {
+		t.Errorf("Accesses = %d, want 0", got)
+	}
+	got, err := oram.Read(1)
+	if err != nil || !bytes.Equal(got, make([]byte, 16)) {
+		t.Errorf("Read(1) = %x, %v; want zeros", got, err)
+	}
+}
+
+func TestAccessBatch_AccessCount(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, StashLimit: 200}
+	ops := []BlockOp{{BlockID: 1}, {BlockID: 2}, {BlockID: 1}}
+
+	oram, _ := NewInMemory(cfg)
+	if _, err := oram.AccessBatch(ops); err != nil {
+		t.Fatalf("AccessBatch: %v", err)
+	}
+	if got := oram.Stats().Accesses; got != 3 {
+		t.Errorf("Accesses = %d, want one per op", got)
+	}
+
+	oram, _ = NewInMemory(cfg)
+	if _, err := oram.AccessBatchCtx(context.Background(), ops, true); err != nil {
+		t.Fatalf("AccessBatchCtx: %v", err)
+	}
+	if got := oram.Stats().Accesses; got != 1 {
+		t.Errorf("dedupe: Accesses = %d, want 1", got)
+	}
+}
@@ -0,0 +1,76 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Op identifies the kind of logical operation passed to an Authorizer.
+type Op int
+
+const (
+	OpRead  Op = iota // Read, or Access with nil data
+	OpWrite           // Write, Access with data, WriteBatch and BulkLoad items
+)
+
+// String returns "read" or "write".
+func (op Op) String() string {
+	switch op {
+	case OpRead:
+		return "read"
+	case OpWrite:
+		return "write"
+	default:
+		return fmt.Sprintf("Op(%d)", int(op))
+	}
+}
+
+// Authorizer decides whether the principal carried by ctx may perform op on
+// blockID. A non-nil error denies the operation; it is returned to the caller
+// wrapped with ErrAccessDenied.
+//
+// The hook runs before any storage access, so a denied operation is not
+// visible to the storage server. Batch operations are checked item by item
+// and fail as a whole if any item is denied. ExportCtx and ExportCanonical,
+// which reveal every block, check every block ID as OpRead.
+type Authorizer func(ctx context.Context, op Op, blockID int) error
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying principal for use by an Authorizer.
+func WithPrincipal(ctx context.Context, principal any) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal stored by WithPrincipal.
+func PrincipalFrom(ctx context.Context) (any, bool) {
+	p := ctx.Value(principalKey{})
+	return p, p != nil
+}
+
+// authorize runs the configured Authorizer, if any.
+func (o *PathORAM) authorize(ctx context.Context, op Op, blockID int) error {
+	if o.cfg.Authorizer == nil {
+		return nil
+	}
+	err := o.cfg.Authorizer(ctx, op, blockID)
+	if err == nil || errors.Is(err, ErrAccessDenied) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrAccessDenied, err)
+}
+
+// authorizeAll runs the configured Authorizer for op on every block ID, for
+// operations that touch the whole ORAM.
+func (o *PathORAM) authorizeAll(ctx context.Context, op Op) error {
+	if o.cfg.Authorizer == nil {
+		return nil
+	}
+	for id := range o.cfg.NumBlocks {
+		if err := o.authorize(ctx, op, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
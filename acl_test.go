@@ -0,0 +1,97 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+// tenantAuthorizer lets principal "tenantA" use blocks 0-9 and "tenantB" 10-19.
+func tenantAuthorizer(ctx context.Context, op Op, blockID int) error {
+	p, _ := PrincipalFrom(ctx)
+	switch {
+	case p == "tenantA" && blockID < 10:
+		return nil
+	case p == "tenantB" && blockID >= 10:
+		if op == OpWrite && blockID == 19 {
+			return errors.New("block 19 is read-only")
+		}
+		return nil
+	}
+	return ErrAccessDenied
+}
+
+func TestAuthorizer(t *testing.T) {
+	cfg := Config{NumBlocks: 20, BlockSize: 16, Authorizer: tenantAuthorizer}
+	oram, _ := NewInMemory(cfg)
+
+	ctxA := WithPrincipal(context.Background(), "tenantA")
+	ctxB := WithPrincipal(context.Background(), "tenantB")
+
+	if _, err := oram.WriteCtx(ctxA, 3, make([]byte, 16)); err != nil {
+		t.Errorf("tenantA write to own block failed: %v", err)
+	}
+	if _, err := oram.ReadCtx(ctxB, 3); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("tenantB read of tenantA block error = %v, want ErrAccessDenied", err)
+	}
+	if _, err := oram.AccessCtx(ctxB, 19, nil); err != nil {
+		t.Errorf("tenantB read of block 19 failed: %v", err)
+	}
+	if _, err := oram.AccessCtx(ctxB, 19, make([]byte, 16)); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("tenantB write of read-only block error = %v, want ErrAccessDenied", err)
+	}
+	if _, err := oram.Read(0); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Read without principal error = %v, want ErrAccessDenied", err)
+	}
+
+	accesses := oram.Stats().Accesses
+	err := oram.WriteBatchCtx(ctxA, []BatchItem{
+		{BlockID: 1, Data: make([]byte, 16)},
+		{BlockID: 12, Data: make([]byte, 16)},
+	})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("mixed-tenant batch error = %v, want ErrAccessDenied", err)
+	}
+	if oram.Stats().Accesses != accesses {
+		t.Error("denied batch performed an access")
+	}
+}
+
+func TestAuthorizer_BulkLoad(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 20, BlockSize: 16, Authorizer: tenantAuthorizer})
+
+	ctx := WithPrincipal(context.Background(), "tenantA")
+	err := oram.BulkLoadCtx(ctx, map[int][]byte{1: make([]byte, 16), 15: make([]byte, 16)})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("BulkLoadCtx error = %v, want ErrAccessDenied", err)
+	}
+	if oram.Size() != 0 {
+		t.Errorf("denied BulkLoad allocated %d blocks", oram.Size())
+	}
+}
+
+func TestAuthorizer_Export(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 20, BlockSize: 16, Authorizer: tenantAuthorizer})
+	ctxA := WithPrincipal(context.Background(), "tenantA")
+	if _, err := oram.WriteCtx(ctxA, 3, make([]byte, 16)); err != nil {
+		t.Fatalf("WriteCtx failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := oram.ExportCtx(ctxA, &buf); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("ExportCtx error = %v, want ErrAccessDenied", err)
+	}
+	if _, err := oram.ExportCanonical(ctxA, &buf); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("ExportCanonical error = %v, want ErrAccessDenied", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("denied exports wrote %d bytes", buf.Len())
+	}
+
+	all := func(ctx context.Context, op Op, blockID int) error { return nil }
+	oram, _ = NewInMemory(Config{NumBlocks: 20, BlockSize: 16, Authorizer: all})
+	if err := oram.ExportCtx(ctxA, &buf); err != nil {
+		t.Errorf("ExportCtx with full read access failed: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+)
+
+// Client talks to an admin Server over its unix socket.
+type Client struct {
+	conn net.Conn
+	sc   *bufio.Scanner
+	enc  *json.Encoder
+}
+
+// Dial connects to the admin socket at path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, sc: bufio.NewScanner(conn), enc: json.NewEncoder(conn)}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Do sends req and returns the raw result. progress, if non-nil, receives
+// intermediate progress reports.
+func (c *Client) Do(req Request, progress func(Progress)) (json.RawMessage, error) {
+	if err := c.enc.Encode(req); err != nil {
+		return nil, err
+	}
+	for c.sc.Scan() {
+		var resp Response
+		if err := json.Unmarshal(c.sc.Bytes(), &resp); err != nil {
+			return nil, err
+		}
+		if !resp.Done {
+			if resp.Progress != nil && progress != nil {
+				progress(*resp.Progress)
+			}
+			continue
+		}
+		if resp.Error != "" {
+			return nil, errors.New(resp.Error)
+		}
+		return resp.Result, nil
+	}
+	if err := c.sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("admin: connection closed before response")
+}
+
+// DrainStash asks the server to evict until the stash is at most target.
+func (c *Client) DrainStash(target, maxPasses int, progress func(Progress)) (DrainResult, error) {
+	var res DrainResult
+	raw, err := c.Do(Request{Command: CmdDrainStash, Target: target, MaxPasses: maxPasses}, progress)
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(raw, &res)
+	return res, err
+}
+
+// Stats returns the instance's counters.
+func (c *Client) Stats() (StatsResult, error) {
+	var res StatsResult
+	raw, err := c.Do(Request{Command: CmdStats}, nil)
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(raw, &res)
+	return res, err
+}
+
+// Health returns the instance's stash health.
+func (c *Client) Health() (HealthResult, error) {
+	var res HealthResult
+	raw, err := c.Do(Request{Command: CmdHealth}, nil)
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(raw, &res)
+	return res, err
+}
+
+// SetStrategy switches the instance's eviction strategy, named as by
+// pathoram.EvictionStrategy.String.
+func (c *Client) SetStrategy(strategy string) (StrategyResult, error) {
+	var res StrategyResult
+	raw, err := c.Do(Request{Command: CmdSetStrategy, Strategy: strategy}, nil)
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(raw, &res)
+	return res, err
+}
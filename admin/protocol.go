@@ -0,0 +1,89 @@
+// Package admin exposes operational commands for a running, embedded PathORAM
+// over a local unix socket.
+//
+// The protocol is newline-delimited JSON. The client sends one Request per line;
+// the server answers with zero or more progress Responses followed by a final
+// Response with Done set.
+package admin
+
+import "encoding/json"
+
+// Command names understood by the server.
+//
+// reshuffle, rotate-key, and integrity-scan depend on how the host application
+// manages keys and storage, so they are only available once the host registers
+// a handler with Server.Handle.
+const (
+	CmdStats         = "stats"
+	CmdHealth        = "health"
+	CmdDrainStash    = "drain-stash"
+	CmdSetStrategy   = "set-strategy"
+	CmdReshuffle     = "reshuffle"
+	CmdRotateKey     = "rotate-key"
+	CmdIntegrityScan = "integrity-scan"
+)
+
+// Request is a single admin command.
+type Request struct {
+	Command   string          `json:"command"`
+	Target    int             `json:"target,omitempty"`     // drain-stash: stash size to reach
+	MaxPasses int             `json:"max_passes,omitempty"` // drain-stash: eviction pass budget
+	Strategy  string          `json:"strategy,omitempty"`   // set-strategy: e.g. "greedy-by-depth"
+	Args      json.RawMessage `json:"args,omitempty"`       // arguments for registered handlers
+}
+
+// Response is one line of a command's reply.
+type Response struct {
+	Done     bool            `json:"done"`
+	Error    string          `json:"error,omitempty"`
+	Progress *Progress       `json:"progress,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+}
+
+// Progress reports intermediate state of a long-running command.
+type Progress struct {
+	Pass      int `json:"pass"`
+	StashSize int `json:"stash_size"`
+}
+
+// DrainResult is the result of drain-stash.
+type DrainResult struct {
+	Passes    int  `json:"passes"`
+	StashSize int  `json:"stash_size"`
+	Reached   bool `json:"reached"` // StashSize <= target
+}
+
+// StrategyResult is the result of set-strategy.
+type StrategyResult struct {
+	Previous string `json:"previous"`
+	Strategy string `json:"strategy"`
+}
+
+// StatsResult is the result of stats.
+type StatsResult struct {
+	Accesses           uint64 `json:"accesses"`
+	BucketReads        uint64 `json:"bucket_reads"`
+	BucketWrites       uint64 `json:"bucket_writes"`
+	BytesRead          uint64 `json:"bytes_read"`
+	BytesWritten       uint64 `json:"bytes_written"`
+	Evictions          uint64 `json:"evictions"`
+	DecryptionFailures uint64 `json:"decryption_failures"`
+	StashHighWater     int    `json:"stash_high_water"`
+	StashSize          int    `json:"stash_size"`
+}
+
+// Health states reported by health.
+const (
+	HealthOK       = "ok"       // stash below 3/4 of its limit
+	HealthPressure = "pressure" // stash at or above 3/4 of its limit
+	HealthOverflow = "overflow" // stash above its limit; accesses are failing
+)
+
+// HealthResult is the result of health.
+type HealthResult struct {
+	Status     string `json:"status"`
+	StashSize  int    `json:"stash_size"`
+	StashLimit int    `json:"stash_limit"`
+	Size       int    `json:"size"`
+	Capacity   int    `json:"capacity"`
+}
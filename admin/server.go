@@ -0,0 +1,282 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// defaultMaxPasses bounds drain-stash when the request doesn't set a budget.
+const defaultMaxPasses = 1000
+
+// HandlerFunc implements a host-registered command. Its result is sent back
+// as JSON. Handlers for different connections may run concurrently.
+type HandlerFunc func(oram *pathoram.PathORAM, args json.RawMessage) (any, error)
+
+// Server handles admin commands for one PathORAM instance.
+//
+// PathORAM serializes its own operations, so commands interleave with the
+// host application's accesses without further locking. Long-running
+// commands run one step per PathORAM call so application traffic continues.
+type Server struct {
+	oram *pathoram.PathORAM
+
+	handlersMu sync.RWMutex
+	handlers   map[string]HandlerFunc
+
+	connMu sync.Mutex
+	ln     net.Listener
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewServer creates an admin server for oram.
+func NewServer(oram *pathoram.PathORAM) *Server {
+	return &Server{oram: oram, handlers: make(map[string]HandlerFunc), conns: make(map[net.Conn]struct{})}
+}
+
+// Handle registers fn for command, e.g. CmdRotateKey or an application-specific
+// name. Registering a built-in command name replaces the built-in.
+func (s *Server) Handle(command string, fn HandlerFunc) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.handlers[command] = fn
+}
+
+// Listen creates a unix socket at path usable only by the current user,
+// replacing a stale socket file if one exists. The socket is created and
+// restricted inside a private 0700 directory, then renamed into place, so
+// no other user can connect in between.
+func Listen(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".admin-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "sock")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	ln.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return &socketListener{UnixListener: ln, path: path}, nil
+}
+
+// socketListener removes its socket file when closed, since the listener's
+// own unlink would target the temporary name it was created under.
+type socketListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *socketListener) Close() error {
+	err := l.UnixListener.Close()
+	if rmErr := os.Remove(l.path); err == nil && !os.IsNotExist(rmErr) {
+		err = rmErr
+	}
+	return err
+}
+
+// Serve accepts connections on ln until Close is called.
+func (s *Server) Serve(ln net.Listener) error {
+	s.connMu.Lock()
+	if s.closed {
+		s.connMu.Unlock()
+		return ln.Close()
+	}
+	s.ln = ln
+	s.connMu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(conn)
+			s.handleConn(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections, lets in-flight commands finish and
+// send their responses, closes every connection, and waits.
+func (s *Server) Close() error {
+	s.connMu.Lock()
+	s.closed = true
+	ln := s.ln
+	// An expired read deadline ends idle connections at once and busy ones
+	// after their current command
+	for conn := range s.conns {
+		conn.SetReadDeadline(time.Now())
+	}
+	s.connMu.Unlock()
+
+	var err error
+	if ln != nil {
+		err = ln.Close()
+	}
+	s.wg.Wait()
+	return err
+}
+
+// track registers conn, or reports false if the server is closed.
+func (s *Server) track(conn net.Conn) bool {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	delete(s.conns, conn)
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+
+	for sc.Scan() {
+		var req Request
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			enc.Encode(Response{Done: true, Error: "malformed request: " + err.Error()})
+			continue
+		}
+		result, err := s.dispatch(req, func(p Progress) {
+			enc.Encode(Response{Progress: &p})
+		})
+		resp := Response{Done: true}
+		if err != nil {
+			resp.Error = err.Error()
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			resp.Error = err.Error()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) dispatch(req Request, progress func(Progress)) (any, error) {
+	s.handlersMu.RLock()
+	fn, ok := s.handlers[req.Command]
+	s.handlersMu.RUnlock()
+	if ok {
+		return fn(s.oram, req.Args)
+	}
+
+	switch req.Command {
+	case CmdStats:
+		return s.stats(), nil
+	case CmdHealth:
+		return s.health(), nil
+	case CmdDrainStash:
+		return s.drainStash(req, progress)
+	case CmdSetStrategy:
+		return s.setStrategy(req)
+	case CmdReshuffle, CmdRotateKey, CmdIntegrityScan:
+		return nil, fmt.Errorf("command %q has no registered handler", req.Command)
+	default:
+		return nil, fmt.Errorf("unknown command %q", req.Command)
+	}
+}
+
+func (s *Server) stats() StatsResult {
+	st := s.oram.Stats()
+	return StatsResult{
+		Accesses:           st.Accesses,
+		BucketReads:        st.BucketReads,
+		BucketWrites:       st.BucketWrites,
+		BytesRead:          st.BytesRead,
+		BytesWritten:       st.BytesWritten,
+		Evictions:          st.Evictions,
+		DecryptionFailures: st.DecryptionFailures,
+		StashHighWater:     st.StashHighWater,
+		StashSize:          s.oram.StashSize(),
+	}
+}
+
+func (s *Server) health() HealthResult {
+	h := HealthResult{
+		Status:     HealthOK,
+		StashSize:  s.oram.StashSize(),
+		StashLimit: s.oram.StashLimit(),
+		Size:       s.oram.Size(),
+		Capacity:   s.oram.Capacity(),
+	}
+	switch {
+	case h.StashSize > h.StashLimit:
+		h.Status = HealthOverflow
+	case 4*h.StashSize >= 3*h.StashLimit:
+		h.Status = HealthPressure
+	}
+	return h
+}
+
+// drainStash runs one eviction pass per DrainStash call.
+func (s *Server) drainStash(req Request, progress func(Progress)) (DrainResult, error) {
+	maxPasses := req.MaxPasses
+	if maxPasses <= 0 {
+		maxPasses = defaultMaxPasses
+	}
+
+	var res DrainResult
+	for res.Passes < maxPasses {
+		n, err := s.oram.DrainStash(context.Background(), req.Target, 1, nil)
+		res.StashSize = s.oram.StashSize()
+		if err != nil {
+			return res, err
+		}
+		if n == 0 {
+			break
+		}
+		res.Passes++
+		progress(Progress{Pass: res.Passes, StashSize: res.StashSize})
+	}
+	res.Reached = res.StashSize <= req.Target
+	return res, nil
+}
+
+// setStrategy switches the eviction strategy (see PathORAM.SetStrategy).
+func (s *Server) setStrategy(req Request) (StrategyResult, error) {
+	strategy, err := pathoram.ParseEvictionStrategy(req.Strategy)
+	if err != nil {
+		return StrategyResult{}, err
+	}
+	res := StrategyResult{Previous: s.oram.Strategy().String(), Strategy: strategy.String()}
+	return res, s.oram.SetStrategy(context.Background(), strategy)
+}
@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func startServer(t *testing.T, oram *pathoram.PathORAM) *Client {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "admin.sock")
+	ln, err := Listen(sock)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	srv := NewServer(oram)
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	c, err := Dial(sock)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestDrainStash(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4})
+	for i := 0; i < 64; i++ {
+		oram.Write(i, make([]byte, 16))
+	}
+
+	c := startServer(t, oram)
+
+	var reports int
+	res, err := c.DrainStash(0, 500, func(Progress) { reports++ })
+	if err != nil {
+		t.Fatalf("DrainStash failed: %v", err)
+	}
+	if res.Passes != reports {
+		t.Errorf("got %d progress reports, want %d", reports, res.Passes)
+	}
+	if res.StashSize != oram.StashSize() {
+		t.Errorf("StashSize = %d, want %d", res.StashSize, oram.StashSize())
+	}
+	if res.Reached != (res.StashSize == 0) {
+		t.Errorf("Reached = %v with StashSize %d", res.Reached, res.StashSize)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 8, BlockSize: 16})
+	c := startServer(t, oram)
+
+	if _, err := c.Do(Request{Command: "bogus"}, nil); err == nil {
+		t.Error("unknown command should fail")
+	}
+	// Connection stays usable after an error
+	if _, err := c.DrainStash(0, 1, nil); err != nil {
+		t.Errorf("DrainStash after error failed: %v", err)
+	}
+}
+
+func TestStatsAndHealth(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 32, BlockSize: 16, StashLimit: 40})
+	for i := 0; i < 5; i++ {
+		oram.Write(i, make([]byte, 16))
+	}
+	c := startServer(t, oram)
+
+	st, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if st.Accesses != 5 {
+		t.Errorf("Accesses = %d, want 5", st.Accesses)
+	}
+
+	h, err := c.Health()
+	if err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if h.StashLimit != 40 || h.Capacity != 32 || h.Size != 5 {
+		t.Errorf("Health = %+v", h)
+	}
+	if h.Status != HealthOK {
+		t.Errorf("Status = %q, want %q", h.Status, HealthOK)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 8, BlockSize: 16})
+
+	sock := filepath.Join(t.TempDir(), "admin.sock")
+	ln, err := Listen(sock)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	srv := NewServer(oram)
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	c, _ := Dial(sock)
+	defer c.Close()
+
+	if _, err := c.Do(Request{Command: CmdRotateKey}, nil); err == nil {
+		t.Error("rotate-key without a handler should fail")
+	}
+
+	srv.Handle(CmdRotateKey, func(o *pathoram.PathORAM, args json.RawMessage) (any, error) {
+		return map[string]string{"args": string(args)}, nil
+	})
+	raw, err := c.Do(Request{Command: CmdRotateKey, Args: json.RawMessage(`"k2"`)}, nil)
+	if err != nil {
+		t.Fatalf("rotate-key failed: %v", err)
+	}
+	if string(raw) != `{"args":"\"k2\""}` {
+		t.Errorf("result = %s", raw)
+	}
+}
+
+func TestSetStrategy(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 8, BlockSize: 16})
+	c := startServer(t, oram)
+
+	res, err := c.SetStrategy("greedy-by-depth")
+	if err != nil {
+		t.Fatalf("SetStrategy failed: %v", err)
+	}
+	if res.Previous != "level-by-level" || res.Strategy != "greedy-by-depth" {
+		t.Errorf("result = %+v", res)
+	}
+	if got := oram.Strategy(); got != pathoram.EvictGreedyByDepth {
+		t.Errorf("Strategy() = %v", got)
+	}
+	if _, err := c.SetStrategy("fastest"); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
+
+func TestCloseWithIdleConnection(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 8, BlockSize: 16})
+	sock := filepath.Join(t.TempDir(), "admin.sock")
+	ln, err := Listen(sock)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	if fi, err := os.Stat(sock); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, %v; want 0600", fi.Mode().Perm(), err)
+	}
+	srv := NewServer(oram)
+	go srv.Serve(ln)
+
+	c, err := Dial(sock)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer c.Close()
+	if _, err := c.Health(); err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close hung on an idle connection")
+	}
+	if _, err := c.Health(); err == nil {
+		t.Error("connection still served after Close")
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("socket file left after Close: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package pathoram
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// BlockAllocator hands out the block IDs of a PathORAM to the structures
+// stored in it. Structures given the same allocator can share one ORAM
+// without overwriting each other's blocks; see the Allocator method of
+// x/kv's Store.
+// It is safe for concurrent use.
+type BlockAllocator struct {
+	mu   sync.Mutex
+	free []int // unallocated block IDs, popped from the end
+}
+
+// NewBlockAllocator creates an allocator with block IDs 0 to numBlocks-1
+// free, handed out lowest first.
+func NewBlockAllocator(numBlocks int) *BlockAllocator {
+	free := make([]int, numBlocks)
+	for i := range free {
+		free[i] = numBlocks - 1 - i
+	}
+	return &BlockAllocator{free: free}
+}
+
+// Alloc takes n free block IDs. If fewer are free it takes none and returns
+// ErrNoSpace.
+func (a *BlockAllocator) Alloc(n int) ([]int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n > len(a.free) {
+		return nil, fmt.Errorf("%w: need %d blocks, %d free", ErrNoSpace, n, len(a.free))
+	}
+	ids := slices.Clone(a.free[len(a.free)-n:])
+	a.free = a.free[:len(a.free)-n]
+	return ids, nil
+}
+
+// Free returns ids, taken from Alloc, to the allocator. Freeing the IDs of
+// one Alloc call, in order, makes the next Alloc of as many return them
+// again.
+func (a *BlockAllocator) Free(ids ...int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.free = append(a.free, ids...)
+}
+
+// Available returns the number of free block IDs.
+func (a *BlockAllocator) Available() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.free)
+}
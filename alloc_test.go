@@ -0,0 +1,31 @@
+package pathoram
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBlockAllocator(t *testing.T) {
+	a := NewBlockAllocator(4)
+	ids, err := a.Alloc(3)
+	if err != nil || a.Available() != 1 {
+		t.Fatalf("Alloc(3) = %v, %v with %d left", ids, err, a.Available())
+	}
+	if _, err := a.Alloc(2); !errors.Is(err, ErrNoSpace) || a.Available() != 1 {
+		t.Fatalf("Alloc(2) of 1 free: error = %v, %d left", err, a.Available())
+	}
+
+	// Freeing one Alloc's IDs in order hands them out again
+	a.Free(ids...)
+	again, _ := a.Alloc(3)
+	if !slices.Equal(again, ids) {
+		t.Errorf("Alloc after Free = %v, want %v", again, ids)
+	}
+	last, _ := a.Alloc(1)
+	all := append(again, last...)
+	slices.Sort(all)
+	if !slices.Equal(all, []int{0, 1, 2, 3}) {
+		t.Errorf("allocated %v, want every ID once", all)
+	}
+}
@@ -0,0 +1,239 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// maxAsyncGroup is the most queued asynchronous accesses processed together.
+const maxAsyncGroup = 16
+
+// AccessResult is the outcome of an asynchronous access: the block's
+// previous contents, or the error that failed it.
+type AccessResult struct {
+	Data []byte
+	Err  error
+}
+
+// asyncRequest is an access queued by AccessAsync.
+type asyncRequest struct {
+	ctx     context.Context
+	blockID int
+	newData []byte
+	result  chan AccessResult
+}
+
+// AccessAsync queues an access like AccessCtx and returns a channel that
+// receives its result exactly once. The caller may issue many accesses
+// before waiting on any of them.
+//
+// Queued accesses are processed in groups: the paths of up to 16 requests
+// are read from storage together (in one StorageV2.ReadBuckets call, or with
+// Config.FetchParallelism concurrent reads), each request is served from the
+// stash in arrival order, and each request's path is then evicted, so read
+// latency is paid once per group rather than once per access. As in
+// TaoStore, every request reads a fresh path: when several queued requests
+// name the same block, the first reads the block's path and the others read
+// random paths, so the server sees one independent path per request either
+// way. Requests to the same block observe each other's writes in queue
+// order.
+//
+// Conflicts are handled by ordering, not locking. Every path shares the
+// root, so every two in-flight requests conflict; a group is one critical
+// section, so its requests never interleave with other accesses.
+// Requests for a block already requested in the group are counted in
+// Stats.AsyncConflicts. The order is deterministic: requests are grouped,
+// served and answered in the order AccessAsync queued them, and no request
+// is reordered or retried, so none can be overtaken indefinitely. A request
+// waits for at most the groups queued ahead of it, of at most 16 requests
+// each. Between groups the lock is released, and a synchronous access
+// blocked on it for over a millisecond is handed it next (sync.Mutex
+// starvation mode), so a busy queue does not starve Read and Write.
+//
+// Validation and authorization happen before queueing. A request whose
+// context ends while queued fails with the context's error; once its group
+// starts it runs to completion. Storage calls are made with a context
+// carrying no deadline or values from the requests.
+func (o *PathORAM) AccessAsync(ctx context.Context, blockID int, newData []byte) <-chan AccessResult {
+	ch := make(chan AccessResult, 1)
+	op := OpWrite
+	if newData == nil {
+		op = OpRead
+	}
+	var err error
+	switch {
+	case blockID < 0 || blockID >= o.cfg.NumBlocks:
+		err = ErrInvalidBlockID
+	case newData != nil && len(newData) != o.cfg.BlockSize:
+		err = ErrInvalidDataSize
+	default:
+		err = o.authorize(ctx, op, blockID)
+	}
+	if err != nil {
+		ch <- AccessResult{Err: err}
+		return ch
+	}
+
+	o.asyncMu.Lock()
+	o.asyncQueue = append(o.asyncQueue, &asyncRequest{ctx: ctx, blockID: blockID, newData: newData, result: ch})
+	if !o.asyncRunning {
+		o.asyncRunning = true
+		go o.runAsync()
+	}
+	o.asyncMu.Unlock()
+	return ch
+}
+
+// runAsync processes queued groups until the queue is empty.
+func (o *PathORAM) runAsync() {
+	for {
+		o.asyncMu.Lock()
+		n := min(len(o.asyncQueue), maxAsyncGroup)
+		if n == 0 {
+			o.asyncRunning = false
+			o.asyncQueue = nil
+			o.asyncMu.Unlock()
+			return
+		}
+		group := o.asyncQueue[:n:n]
+		o.asyncQueue = o.asyncQueue[n:]
+		o.asyncMu.Unlock()
+
+		// Requests cancelled while queued are dropped before any I/O
+		live := group[:0:0]
+		for _, r := range group {
+			if err := r.ctx.Err(); err != nil {
+				r.result <- AccessResult{Err: err}
+			} else {
+				live = append(live, r)
+			}
+		}
+		if len(live) == 0 {
+			continue
+		}
+		start := time.Now()
+		results := o.accessGroup(live)
+		o.padAccess(context.Background(), start, len(live))
+		for i, r := range live {
+			r.result <- results[i]
+		}
+	}
+}
+
+// accessGroup performs one access per request with the group's path reads
+// issued together.
+func (o *PathORAM) accessGroup(group []*asyncRequest) []AccessResult {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	start := time.Now()
+	results := make([]AccessResult, len(group))
+	fail := func(err error) []AccessResult {
+		for i := range results {
+			results[i].Err = err
+			o.finishAccess(start, err)
+		}
+		return results
+	}
+	if o.closed {
+		return fail(ErrClosed)
+	}
+	if err := o.takeBackgroundErr(); err != nil {
+		return fail(err)
+	}
+
+	ids := make([]int, len(group))
+	for i, r := range group {
+		ids[i] = r.blockID
+	}
+	paths, conflicts, err := o.readGroupPaths(context.Background(), ids)
+	if err != nil {
+		return fail(err)
+	}
+	o.stats.AsyncConflicts += uint64(conflicts)
+
+	// Serve the requests in order
+	for i, r := range group {
+		results[i].Data = o.stashAccess(r.blockID, r.newData, nil)
+	}
+
+	// Evict each request's path, as the equivalent single accesses would.
+	// The stash holds every path's blocks until the last eviction, so only
+	// that one checks the stash limit.
+	for i, r := range group {
+		err := o.evictGroupPath(paths[i])
+		if errors.Is(err, ErrStashOverflow) && i < len(group)-1 {
+			err = nil
+		}
+		if err == nil {
+			err = o.recordAccess()
+		}
+		if err != nil {
+			// The blocks are in the stash; report the error to this and
+			// every later request, as failed single accesses would
+			for j := i; j < len(group); j++ {
+				results[j] = AccessResult{Err: err}
+				o.finishAccess(start, err)
+			}
+			break
+		}
+		o.finishAccess(start, nil)
+		o.noteNamespace(r.ctx, false)
+	}
+	return results
+}
+
+// readGroupPaths reads one path per access to ids, as the equivalent single
+// accesses would, and remaps each distinct block once. The first access to a
+// block reads its path; repeats read a random path, as they would if the
+// block had just been remapped. Every bucket of the union is read once: one
+// ReadBuckets call, or up to FetchParallelism concurrent reads. Returns the
+// paths in order and the number of repeats.
+func (o *PathORAM) readGroupPaths(ctx context.Context, ids []int) ([][]int, int, error) {
+	var blocks []int // distinct block IDs in order
+	requested := make(map[int]bool, len(ids))
+	paths := make([][]int, len(ids))
+	var union []int
+	seen := make(map[int]bool)
+	conflicts := 0
+	for i, id := range ids {
+		leaf, exists := o.posMap.Get(id)
+		if !exists || requested[id] {
+			leaf = o.randomLeaf()
+		}
+		if !requested[id] {
+			requested[id] = true
+			blocks = append(blocks, id)
+		} else {
+			conflicts++
+		}
+		paths[i] = o.storedPath(leaf)
+		for _, idx := range paths[i] {
+			if !seen[idx] {
+				seen[idx] = true
+				union = append(union, idx)
+			}
+		}
+	}
+
+	o.recycleSealed()
+	fetched, err := o.fetchBuckets(ctx, union, o.cfg.FetchParallelism)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := o.moveIntoStash(ctx, union, fetched); err != nil {
+		return nil, 0, err
+	}
+	for _, id := range blocks {
+		o.remap(id)
+	}
+	return paths, conflicts, nil
+}
+
+// evictGroupPath evicts path now or, with BackgroundEviction, defers it.
+func (o *PathORAM) evictGroupPath(path []int) error {
+	if o.cfg.BackgroundEviction {
+		return o.deferEviction(path)
+	}
+	return o.evictPath(context.Background(), path)
+}
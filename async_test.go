@@ -0,0 +1,171 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAccessAsync(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4})
+	ctx := context.Background()
+
+	// Queue writes and reads, with repeats of the same block, before
+	// waiting on any of them
+	var chans []<-chan AccessResult
+	for i := 0; i < 40; i++ {
+		id := i % 20
+		chans = append(chans, oram.AccessAsync(ctx, id, bytes.Repeat([]byte{byte(i)}, 16)))
+	}
+	for i, ch := range chans {
+		res := <-ch
+		if res.Err != nil {
+			t.Fatalf("access %d failed: %v", i, res.Err)
+		}
+		// The second write to a block returns the first one's data
+		want := make([]byte, 16)
+		if i >= 20 {
+			want = bytes.Repeat([]byte{byte(i - 20)}, 16)
+		}
+		if !bytes.Equal(res.Data, want) {
+			t.Fatalf("access %d returned %v, want %v", i, res.Data, want)
+		}
+	}
+	for id := 0; id < 20; id++ {
+		res := <-oram.AccessAsync(ctx, id, nil)
+		if want := bytes.Repeat([]byte{byte(id + 20)}, 16); res.Err != nil || !bytes.Equal(res.Data, want) {
+			t.Fatalf("block %d = %v, %v; want %v", id, res.Data, res.Err, want)
+		}
+	}
+	if got := oram.Stats().Accesses; got != 60 {
+		t.Fatalf("Accesses = %d, want one per request (60)", got)
+	}
+}
+
+func TestAccessAsync_GroupsPathReads(t *testing.T) {
+	cfg, _ := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := &v2Storage{mem: NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)}
+	oram, err := NewORAM(WithConfig(cfg), WithStorageV2(storage))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+
+	// Hold the lock while queueing so the requests pile up: the first
+	// group takes one request and blocks, the second takes the rest.
+	const n = maxAsyncGroup + 1
+	oram.mu.Lock()
+	var chans []<-chan AccessResult
+	for i := 0; i < n; i++ {
+		chans = append(chans, oram.AccessAsync(context.Background(), i, make([]byte, 16)))
+	}
+	oram.mu.Unlock()
+	for _, ch := range chans {
+		if res := <-ch; res.Err != nil {
+			t.Fatalf("access failed: %v", res.Err)
+		}
+	}
+	if storage.batchReads != 2 {
+		t.Fatalf("ReadBuckets calls = %d, want 2 for %d requests", storage.batchReads, n)
+	}
+	if got := oram.Stats().Accesses; got != n {
+		t.Fatalf("Accesses = %d, want %d", got, n)
+	}
+}
+
+func TestAccessAsync_Errors(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 8, BlockSize: 16})
+	if res := <-oram.AccessAsync(context.Background(), 8, nil); !errors.Is(res.Err, ErrInvalidBlockID) {
+		t.Fatalf("err = %v, want ErrInvalidBlockID", res.Err)
+	}
+	if res := <-oram.AccessAsync(context.Background(), 0, []byte("short")); !errors.Is(res.Err, ErrInvalidDataSize) {
+		t.Fatalf("err = %v, want ErrInvalidDataSize", res.Err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if res := <-oram.AccessAsync(ctx, 0, nil); !errors.Is(res.Err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", res.Err)
+	}
+	if got := oram.Stats().Accesses; got != 0 {
+		t.Fatalf("Accesses = %d, want 0", got)
+	}
+}
+
+// TestAccessAsync_ConflictingIDs hammers a few blocks with asynchronous
+// writes from many goroutines, alongside synchronous reads. Each write
+// stores a unique value and returns the previous one, so the writes to a
+// block must chain into one sequence: every value is replaced exactly once,
+// except the last, which the block holds.
+func TestAccessAsync_ConflictingIDs(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 64, BlockSize: 16, StashLimit: 500})
+	ctx := context.Background()
+	const workers, perWorker, hotIDs = 8, 40, 3
+
+	type write struct{ prev, next uint16 }
+	var mu sync.Mutex
+	writes := make([][]write, hotIDs)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var chans []<-chan AccessResult
+			var values []uint16
+			for i := range perWorker {
+				v := uint16(w*perWorker + i + 1)
+				data := make([]byte, 16)
+				binary.LittleEndian.PutUint16(data, v)
+				chans = append(chans, oram.AccessAsync(ctx, i%hotIDs, data))
+				values = append(values, v)
+				if i%10 == 0 {
+					if _, err := oram.Read(i % hotIDs); err != nil {
+						t.Errorf("Read failed: %v", err)
+					}
+				}
+			}
+			for i, ch := range chans {
+				res := <-ch
+				if res.Err != nil {
+					t.Errorf("access failed: %v", res.Err)
+					return
+				}
+				mu.Lock()
+				writes[i%hotIDs] = append(writes[i%hotIDs], write{binary.LittleEndian.Uint16(res.Data), values[i]})
+				mu.Unlock()
+			}
+		}()
+	}
+	finished := make(chan struct{})
+	go func() { wg.Wait(); close(finished) }()
+	select {
+	case <-finished:
+	case <-time.After(30 * time.Second):
+		t.Fatal("asynchronous accesses did not complete")
+	}
+
+	for id, ws := range writes {
+		replaced := make(map[uint16]uint16, len(ws))
+		for _, w := range ws {
+			if _, dup := replaced[w.prev]; dup {
+				t.Fatalf("block %d: value %d replaced twice", id, w.prev)
+			}
+			replaced[w.prev] = w.next
+		}
+		// Follow the chain from the initial zero value
+		v, n := uint16(0), 0
+		for next, ok := replaced[v]; ok; next, ok = replaced[v] {
+			v, n = next, n+1
+		}
+		got, _ := oram.Read(id)
+		if n != len(ws) || binary.LittleEndian.Uint16(got) != v {
+			t.Fatalf("block %d: chain of %d writes ends at %d, block holds %d; want %d writes", id, n, v, binary.LittleEndian.Uint16(got), len(ws))
+		}
+	}
+	if oram.Stats().AsyncConflicts == 0 {
+		t.Error("no conflicts counted for repeated blocks")
+	}
+}
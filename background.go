@@ -0,0 +1,115 @@
+//go:build !pathoram_minimal
+
+package pathoram
+
+import (
+	"context"
+	"errors"
+)
+
+// backgroundEvictionAvailable reports whether Config.BackgroundEviction is
+// supported; it is compiled out of pathoram_minimal builds.
+const backgroundEvictionAvailable = true
+
+// maxPendingEvictions bounds the deferred-eviction backlog. When it is full,
+// the oldest pending eviction runs inline before the access returns, so the
+// stash cannot grow without bound under sustained load.
+const maxPendingEvictions = 16
+
+// deferEviction queues path for later eviction instead of evicting now.
+func (o *PathORAM) deferEviction(path []int) error {
+	var err error
+	if len(o.pending) >= maxPendingEvictions {
+		err = o.evictOnePending()
+	}
+	o.pending = append(o.pending, path)
+	if o.wake != nil {
+		select {
+		case o.wake <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// evictOnePending evicts the oldest pending path.
+func (o *PathORAM) evictOnePending() error {
+	path := o.pending[0]
+	o.pending = o.pending[1:]
+	return o.evictPath(context.Background(), path)
+}
+
+// PendingEvictions returns the number of accesses whose eviction has been
+// deferred. It is always 0 unless Config.BackgroundEviction is set.
+func (o *PathORAM) PendingEvictions() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// EvictPending runs all deferred evictions now and returns how many ran.
+// Use it to move eviction work to idle periods when BackgroundEviction is set.
+func (o *PathORAM) EvictPending(ctx context.Context) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for len(o.pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		if err := o.evictOnePending(); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// takeBackgroundErr returns and clears the last background eviction error.
+func (o *PathORAM) takeBackgroundErr() error {
+	err := o.bgErr
+	o.bgErr = nil
+	return errors.Join(err, o.takeWriteBackErr())
+}
+
+// startBackgroundEviction launches the eviction goroutine.
+func (o *PathORAM) startBackgroundEviction() {
+	o.wake = make(chan struct{}, 1)
+	o.stop = make(chan struct{})
+	o.stopped = make(chan struct{})
+	go o.backgroundEvictionLoop()
+}
+
+// backgroundEvictionLoop evicts one pending path at a time, releasing the
+// lock in between so accesses can interleave with eviction work.
+func (o *PathORAM) backgroundEvictionLoop() {
+	defer close(o.stopped)
+	for {
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		}
+		for {
+			o.mu.Lock()
+			if len(o.pending) == 0 {
+				o.mu.Unlock()
+				break
+			}
+			if err := o.evictOnePending(); err != nil {
+				o.bgErr = err
+			}
+			o.mu.Unlock()
+		}
+	}
+}
+
+// stopBackgroundEviction stops the eviction goroutine, if running. Pending
+// evictions stay queued.
+func (o *PathORAM) stopBackgroundEviction() {
+	if o.stop != nil {
+		close(o.stop)
+		<-o.stopped
+		o.stop = nil
+	}
+}
@@ -0,0 +1,27 @@
+//go:build pathoram_minimal
+
+package pathoram
+
+import "context"
+
+// Background eviction is compiled out of pathoram_minimal builds; Validate
+// rejects Config.BackgroundEviction, so the stubs below never defer work.
+const backgroundEvictionAvailable = false
+
+func (o *PathORAM) deferEviction(path []int) error {
+	return o.evictPath(context.Background(), path)
+}
+
+func (o *PathORAM) evictOnePending() error { return nil }
+
+func (o *PathORAM) takeBackgroundErr() error { return o.takeWriteBackErr() }
+
+func (o *PathORAM) startBackgroundEviction() {}
+
+// PendingEvictions always returns 0 in pathoram_minimal builds.
+func (o *PathORAM) PendingEvictions() int { return 0 }
+
+// EvictPending is a no-op in pathoram_minimal builds.
+func (o *PathORAM) EvictPending(ctx context.Context) (int, error) { return 0, nil }
+
+func (o *PathORAM) stopBackgroundEviction() {}
@@ -0,0 +1,89 @@
+//go:build !pathoram_minimal
+
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestBackgroundEviction_Correctness(t *testing.T) {
+	cfg := Config{NumBlocks: 128, BlockSize: 16, BucketSize: 4, StashLimit: 200, BackgroundEviction: true}
+	oram, err := NewInMemory(cfg)
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g * 32; i < (g+1)*32; i++ {
+				if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+					t.Errorf("Write(%d) failed: %v", i, err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if err := oram.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if oram.PendingEvictions() != 0 {
+		t.Errorf("PendingEvictions() = %d after Flush, want 0", oram.PendingEvictions())
+	}
+
+	for i := 0; i < 128; i++ {
+		got, err := oram.Read(i)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Errorf("Read(%d) mismatch", i)
+		}
+	}
+}
+
+func TestEvictPending(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, StashLimit: 200, BackgroundEviction: true}
+	oram, _ := NewInMemory(cfg)
+	// Stop the goroutine so deferred evictions stay queued
+	close(oram.stop)
+	<-oram.stopped
+	oram.stop = nil
+
+	for i := 0; i < 10; i++ {
+		oram.Write(i, make([]byte, 16))
+	}
+	if got := oram.PendingEvictions(); got != 10 {
+		t.Fatalf("PendingEvictions() = %d, want 10", got)
+	}
+
+	n, err := oram.EvictPending(context.Background())
+	if err != nil || n != 10 {
+		t.Errorf("EvictPending() = (%d, %v), want (10, nil)", n, err)
+	}
+
+	// The backlog is bounded even if nobody evicts
+	for i := 0; i < 3*maxPendingEvictions; i++ {
+		oram.Read(i % 64)
+	}
+	if got := oram.PendingEvictions(); got > maxPendingEvictions {
+		t.Errorf("PendingEvictions() = %d, want <= %d", got, maxPendingEvictions)
+	}
+	if err := oram.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestClose_WithoutBackgroundEviction(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 8, BlockSize: 16})
+	oram.Write(1, make([]byte, 16))
+	if err := oram.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
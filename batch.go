@@ -1,6 +1,10 @@
 package pathoram
 
-import "crypto/subtle"
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+)
 
 // BatchItem represents a single block write in a batch operation.
 type BatchItem struct {
@@ -37,6 +41,25 @@ func deduplicateBatchItems(items []BatchItem) []BatchItem {
 // Note: this operation is NOT access-pattern oblivious — an observer can distinguish
 // a batch from N independent accesses. Use sequential Write() for obliviousness.
 func (o *PathORAM) WriteBatch(items []BatchItem) error {
+	return o.WriteBatchCtx(context.Background(), items)
+}
+
+// WriteBatchCtx is like WriteBatch but propagates ctx to the storage backend.
+// As with AccessCtx, cancellation is only honored before eviction starts.
+func (o *PathORAM) WriteBatchCtx(ctx context.Context, items []BatchItem) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	start := time.Now()
+	err := o.writeBatch(ctx, items)
+	o.finishAccess(start, err)
+	return err
+}
+
+// writeBatch implements WriteBatchCtx.
+func (o *PathORAM) writeBatch(ctx context.Context, items []BatchItem) error {
+	if o.closed {
+		return ErrClosed
+	}
 	if len(items) == 0 {
 		return nil
 	}
@@ -49,23 +72,64 @@ func (o *PathORAM) WriteBatch(items []BatchItem) error {
 		if len(item.Data) != o.cfg.BlockSize {
 			return ErrInvalidDataSize
 		}
+		if err := o.authorize(ctx, OpWrite, item.BlockID); err != nil {
+			return err
+		}
 	}
 
 	items = deduplicateBatchItems(items)
+	if o.cache != nil {
+		// Batches update the stash directly rather than through stashAccess
+		o.cache.purge()
+	}
 
-	// Phase 1: Remap all blocks and collect old paths
+	// Phase 1: Collect old paths
 	paths := make([][]int, len(items))
 	for i, item := range items {
 		oldLeaf, exists := o.posMap.Get(item.BlockID)
 		if !exists {
 			oldLeaf = o.randomLeaf()
 		}
-		o.posMap.Set(item.BlockID, o.randomLeaf())
-		paths[i] = o.Path(oldLeaf)
+		paths[i] = o.storedPath(oldLeaf)
+	}
+
+	// Phase 2: Read all unique buckets into stash
+	bucketData, err := o.readBatchPaths(ctx, paths)
+	if err != nil {
+		return err
+	}
+
+	// Remap all blocks only once their old paths are safely in the stash
+	for _, item := range items {
+		o.remap(item.BlockID)
+	}
+
+	// Phase 3: Update/insert all batch blocks in stash
+	if o.cfg.ConstantTime {
+		o.updateStashBatchCT(items)
+	} else {
+		o.updateStashBatch(items)
+	}
+	o.noteStash()
+
+	// Phase 4: Eviction — respects configured strategy and ConstantTime mode
+	ctx = context.WithoutCancel(ctx)
+	if o.cfg.ConstantTime {
+		err = o.evictMultiPathCT(ctx, paths, bucketData)
+	} else {
+		err = o.evictMultiPathWithStrategy(ctx, paths, bucketData)
+	}
+	if err != nil {
+		return err
 	}
+	return o.recordAccess()
+}
 
-	// Phase 2: Read all unique buckets into stash.
-	// Retain emptied bucket data for direct reuse in eviction (no double-read).
+// readBatchPaths reads every distinct bucket of paths into the stash once.
+// The emptied buckets are returned for direct reuse in eviction (no
+// double-read).
+func (o *PathORAM) readBatchPaths(ctx context.Context, paths [][]int) (map[int][]Block, error) {
+	o.recycleSealed()
 	bucketData := make(map[int][]Block)
 	for _, path := range paths {
 		for _, bucketIdx := range path {
@@ -73,15 +137,16 @@ func (o *PathORAM) WriteBatch(items []BatchItem) error {
 				continue
 			}
 
-			bucket, err := o.storage.ReadBucket(bucketIdx)
+			bucket, err := o.readBucket(ctx, bucketIdx)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			for j := range bucket {
 				if bucket[j].ID != EmptyBlockID {
-					plaintext, err := o.encrypt.Decrypt(bucket[j].ID, bucket[j].Leaf, bucket[j].Data)
+					plaintext, err := o.decryptBlock(bucket[j])
 					if err != nil {
-						return err
+						o.noteDecryptionFailure()
+						return nil, err
 					}
 					o.stash = append(o.stash, block{
 						id:   bucket[j].ID,
@@ -98,19 +163,7 @@ func (o *PathORAM) WriteBatch(items []BatchItem) error {
 			bucketData[bucketIdx] = bucket
 		}
 	}
-
-	// Phase 3: Update/insert all batch blocks in stash
-	if o.cfg.ConstantTime {
-		o.updateStashBatchCT(items)
-	} else {
-		o.updateStashBatch(items)
-	}
-
-	// Phase 4: Eviction — respects configured strategy and ConstantTime mode
-	if o.cfg.ConstantTime {
-		return o.evictMultiPathCT(paths, bucketData)
-	}
-	return o.evictMultiPathWithStrategy(paths, bucketData)
+	return bucketData, nil
 }
 
 // updateStashBatch updates stash with batch items using O(1) hash lookup.
@@ -122,6 +175,7 @@ func (o *PathORAM) updateStashBatch(items []BatchItem) {
 
 	for _, item := range items {
 		newLeaf, _ := o.posMap.Get(item.BlockID)
+		o.stashChanged(item.BlockID)
 		if idx, found := stashIdx[item.BlockID]; found {
 			o.stash[idx].leaf = newLeaf
 			copy(o.stash[idx].data, item.Data)
@@ -129,7 +183,7 @@ func (o *PathORAM) updateStashBatch(items []BatchItem) {
 			newBlock := block{
 				id:   item.BlockID,
 				leaf: newLeaf,
-				data: make([]byte, o.cfg.BlockSize),
+				data: o.newBlockData(),
 			}
 			copy(newBlock.data, item.Data)
 			stashIdx[item.BlockID] = len(o.stash)
@@ -144,6 +198,7 @@ func (o *PathORAM) updateStashBatch(items []BatchItem) {
 func (o *PathORAM) updateStashBatchCT(items []BatchItem) {
 	for _, item := range items {
 		newLeaf, _ := o.posMap.Get(item.BlockID)
+		o.stashChanged(item.BlockID)
 
 		foundIdx := -1
 		for j := range o.stash {
@@ -158,7 +213,7 @@ func (o *PathORAM) updateStashBatchCT(items []BatchItem) {
 			newBlock := block{
 				id:   item.BlockID,
 				leaf: newLeaf,
-				data: make([]byte, o.cfg.BlockSize),
+				data: o.newBlockData(),
 			}
 			copy(newBlock.data, item.Data)
 			o.stash = append(o.stash, newBlock)
@@ -183,22 +238,22 @@ func (o *PathORAM) buildStashPathSets() []map[int]bool {
 
 // evictMultiPathWithStrategy dispatches to the configured eviction strategy
 // for multi-path batch eviction, mirroring evictWithStrategy for single-path.
-func (o *PathORAM) evictMultiPathWithStrategy(paths [][]int, bucketData map[int][]Block) error {
+func (o *PathORAM) evictMultiPathWithStrategy(ctx context.Context, paths [][]int, bucketData map[int][]Block) error {
 	switch o.cfg.EvictionStrategy {
 	case EvictGreedyByDepth:
-		return o.evictMultiPath(paths, bucketData)
+		return o.evictMultiPath(ctx, paths, bucketData)
 	case EvictDeterministicTwoPath:
-		if err := o.evictMultiPath(paths, bucketData); err != nil {
+		if err := o.evictMultiPath(ctx, paths, bucketData); err != nil {
 			return err
 		}
 		// Background eviction on a random second path (same as single-access two-path)
-		secondPath := o.Path(o.randomLeaf())
-		if err := o.readPathIntoStash(secondPath); err != nil {
+		secondPath := o.storedPath(o.randomLeaf())
+		if err := o.readPathIntoStash(ctx, secondPath); err != nil {
 			return err
 		}
-		return o.evictGreedyByDepth(secondPath)
+		return o.evictGreedyByDepth(ctx, secondPath)
 	default: // EvictLevelByLevel
-		return o.evictMultiPathLevelByLevel(paths, bucketData)
+		return o.evictMultiPathLevelByLevel(ctx, paths, bucketData)
 	}
 }
 
@@ -221,10 +276,11 @@ func canPlaceBatch(o *PathORAM, pathSets []map[int]bool, idx int, leaf, bucketId
 // For each stash block, tries the deepest valid bucket across any path.
 // Precomputes path sets for O(1) placement checks when stash is large enough
 // to amortize the allocation cost.
-func (o *PathORAM) evictMultiPath(paths [][]int, bucketData map[int][]Block) error {
+func (o *PathORAM) evictMultiPath(ctx context.Context, paths [][]int, bucketData map[int][]Block) error {
 	if len(paths) == 0 {
 		return nil
 	}
+	o.noteEviction()
 	height := len(paths[0])
 
 	var pathSets []map[int]bool
@@ -245,7 +301,7 @@ func (o *PathORAM) evictMultiPath(paths [][]int, bucketData map[int][]Block) err
 				bucket := bucketData[bucketIdx]
 				for slot := range bucket {
 					if bucket[slot].ID == EmptyBlockID {
-						bucket[slot] = o.blockToStorage(o.stash[i])
+						o.seal(&bucket[slot], o.stash[i])
 						last := len(o.stash) - 1
 						o.stash[i] = o.stash[last]
 						o.stash = o.stash[:last]
@@ -267,17 +323,18 @@ func (o *PathORAM) evictMultiPath(paths [][]int, bucketData map[int][]Block) err
 		}
 	}
 
-	return o.writeBackAndCheckStash(bucketData)
+	return o.writeBackAndCheckStash(ctx, bucketData)
 }
 
 // evictMultiPathLevelByLevel performs level-by-level eviction across the union of
 // multiple paths. For each level (deepest first), fills all available slots across
 // all paths before moving to the next level. This prioritizes blocks with the most
 // constrained placement, reducing stash pressure compared to per-block greedy.
-func (o *PathORAM) evictMultiPathLevelByLevel(paths [][]int, bucketData map[int][]Block) error {
+func (o *PathORAM) evictMultiPathLevelByLevel(ctx context.Context, paths [][]int, bucketData map[int][]Block) error {
 	if len(paths) == 0 {
 		return nil
 	}
+	o.noteEviction()
 	height := len(paths[0])
 
 	var pathSets []map[int]bool
@@ -301,7 +358,7 @@ func (o *PathORAM) evictMultiPathLevelByLevel(paths [][]int, bucketData map[int]
 				}
 				for i := 0; i < len(o.stash); i++ {
 					if canPlaceBatch(o, pathSets, i, o.stash[i].leaf, bucketIdx) {
-						bucket[slot] = o.blockToStorage(o.stash[i])
+						o.seal(&bucket[slot], o.stash[i])
 						last := len(o.stash) - 1
 						o.stash[i] = o.stash[last]
 						o.stash = o.stash[:last]
@@ -316,7 +373,7 @@ func (o *PathORAM) evictMultiPathLevelByLevel(paths [][]int, bucketData map[int]
 		}
 	}
 
-	return o.writeBackAndCheckStash(bucketData)
+	return o.writeBackAndCheckStash(ctx, bucketData)
 }
 
 // evictMultiPathCT performs constant-time multi-path eviction.
@@ -324,12 +381,13 @@ func (o *PathORAM) evictMultiPathLevelByLevel(paths [][]int, bucketData map[int]
 // Uses precomputed path slices for O(H) placement checks (vs O(H²) with
 // canPlaceAtConstantTime).
 //
-// Known limitation (consistent with evictConstantTime): the blockToStorage call
-// inside the shouldPlace branch involves encryption, which is not constant-time.
-func (o *PathORAM) evictMultiPathCT(paths [][]int, bucketData map[int][]Block) error {
+// Known limitation (consistent with evictConstantTime): the seal call inside
+// the shouldPlace branch involves encryption, which is not constant-time.
+func (o *PathORAM) evictMultiPathCT(ctx context.Context, paths [][]int, bucketData map[int][]Block) error {
 	if len(paths) == 0 {
 		return nil
 	}
+	o.noteEviction()
 	height := len(paths[0])
 
 	// Precompute each stash block's path as a slice (CT-safe: linear scan, no hash map)
@@ -360,7 +418,7 @@ func (o *PathORAM) evictMultiPathCT(paths [][]int, bucketData map[int][]Block) e
 					shouldPlace := canPlace & isEmpty & (1 ^ placed)
 
 					if shouldPlace == 1 {
-						bucket[slot] = o.blockToStorage(*b)
+						o.seal(&bucket[slot], *b)
 						placed = 1
 					}
 				}
@@ -373,20 +431,21 @@ func (o *PathORAM) evictMultiPathCT(paths [][]int, bucketData map[int][]Block) e
 	}
 
 	o.stash = newStash
-	return o.writeBackAndCheckStash(bucketData)
+	return o.writeBackAndCheckStash(ctx, bucketData)
 }
 
 // writeBackAndCheckStash writes all buckets in bucketData to storage
 // and checks for stash overflow.
-func (o *PathORAM) writeBackAndCheckStash(bucketData map[int][]Block) error {
+func (o *PathORAM) writeBackAndCheckStash(ctx context.Context, bucketData map[int][]Block) error {
+	idxs := make([]int, 0, len(bucketData))
+	buckets := make([][]Block, 0, len(bucketData))
 	for bucketIdx, bucket := range bucketData {
-		if err := o.storage.WriteBucket(bucketIdx, bucket); err != nil {
-			return err
-		}
+		idxs = append(idxs, bucketIdx)
+		buckets = append(buckets, bucket)
 	}
-
-	if len(o.stash) > o.cfg.StashLimit {
-		return ErrStashOverflow
+	if err := o.writeBuckets(ctx, idxs, buckets); err != nil {
+		return err
 	}
-	return nil
+
+	return o.checkStash()
 }
@@ -0,0 +1,57 @@
+package pathoram
+
+import (
+	"context"
+	"iter"
+)
+
+// Blocks returns the IDs of all allocated blocks, in ascending order. The
+// IDs are collected from the position map when iteration starts, without
+// accessing storage, so the loop body may call the ORAM, and blocks it
+// allocates or deletes do not change the iteration.
+func (o *PathORAM) Blocks() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for _, id := range o.allocated() {
+			if !yield(id) {
+				return
+			}
+		}
+	}
+}
+
+// allocated returns the IDs with a position, in ascending order.
+func (o *PathORAM) allocated() []int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var ids []int
+	for id := range o.cfg.NumBlocks {
+		if _, ok := o.posMap.Get(id); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ForEach reads every allocated block and calls fn with its ID and data.
+// See ForEachCtx.
+func (o *PathORAM) ForEach(fn func(id int, data []byte) error) error {
+	return o.ForEachCtx(context.Background(), fn)
+}
+
+// ForEachCtx reads every block Blocks yields with an ordinary access and
+// calls fn with its ID and data, stopping at the first error from the read
+// or from fn. The server sees one access per allocated block, which reveals
+// how many there are but not which. Other callers' accesses may interleave,
+// so the blocks are not a consistent snapshot; use Export for that.
+func (o *PathORAM) ForEachCtx(ctx context.Context, fn func(id int, data []byte) error) error {
+	for id := range o.Blocks() {
+		data, err := o.ReadCtx(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(id, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBlocks(t *testing.T) {
+	oram, err := NewInMemory(Config{NumBlocks: 64, BlockSize: 16})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	written := []int{3, 7, 8, 40, 63}
+	for _, id := range written {
+		if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", id, err)
+		}
+	}
+	if got := slices.Collect(oram.Blocks()); !slices.Equal(got, written) {
+		t.Errorf("Blocks() = %v, want %v", got, written)
+	}
+
+	// The loop body may access the ORAM
+	for id := range oram.Blocks() {
+		if _, err := oram.Write(id+1, make([]byte, 16)); err != nil {
+			t.Fatalf("Write(%d) during Blocks failed: %v", id+1, err)
+		}
+		break
+	}
+
+	accesses := oram.Stats().Accesses
+	var seen []int
+	err = oram.ForEach(func(id int, data []byte) error {
+		seen = append(seen, id)
+		if id != 4 && !bytes.Equal(data, bytes.Repeat([]byte{byte(id)}, 16)) {
+			t.Errorf("ForEach: block %d = %x", id, data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEach failed: %v", err)
+	}
+	if want := []int{3, 4, 7, 8, 40, 63}; !slices.Equal(seen, want) {
+		t.Errorf("ForEach visited %v, want %v", seen, want)
+	}
+	if got := oram.Stats().Accesses - accesses; got != 6 {
+		t.Errorf("ForEach made %d accesses, want 6", got)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = oram.ForEach(func(int, []byte) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("ForEach returned %v after %d calls, want stop after 1", err, calls)
+	}
+}
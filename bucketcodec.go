@@ -0,0 +1,269 @@
+//go:build !pathoram_minimal
+
+package pathoram
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// BucketCodec serializes a whole bucket, including each slot's block ID and
+// leaf, into one opaque blob and back. PackedStorage uses it so that the
+// storage server sees one blob per bucket instead of per-slot Blocks whose
+// ID and Leaf are in the clear.
+type BucketCodec interface {
+	// EncodeBucket returns the blob for the bucket at idx.
+	EncodeBucket(idx int, blocks []Block) ([]byte, error)
+
+	// DecodeBucket parses a blob EncodeBucket returned for the bucket at idx.
+	DecodeBucket(idx int, blob []byte, bucketSize int) ([]Block, error)
+
+	// Overhead returns how many bytes a blob adds to the bucket's plain
+	// encoding, which is 20+BlockSize bytes per slot.
+	Overhead() int
+}
+
+// AESGCMBucketCodec is a BucketCodec that encrypts each bucket's plain
+// encoding with AES-256-GCM under a random nonce, with the bucket index as
+// additional data, so the server cannot read slot metadata or move a blob to
+// another bucket. It does not detect a blob replaced by an older version of
+// the same bucket; use Config.VersionKey for that.
+type AESGCMBucketCodec struct {
+	aead cipher.AEAD
+	rand io.Reader // nonce source; nil means crypto/rand
+}
+
+var _ BucketCodec = (*AESGCMBucketCodec)(nil)
+
+// NewAESGCMBucketCodec creates an AESGCMBucketCodec with the given 32-byte
+// key. Use a different key from the ORAM's Encryptor.
+func NewAESGCMBucketCodec(key []byte) (*AESGCMBucketCodec, error) {
+	if len(key) != aesKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", aesKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+	return &AESGCMBucketCodec{aead: aead}, nil
+}
+
+// EncodeBucket encrypts the bucket.
+// Output format: nonce (12 bytes) || ciphertext || tag (16 bytes)
+func (c *AESGCMBucketCodec) EncodeBucket(idx int, blocks []Block) ([]byte, error) {
+	plain := encodeBucket(blocks)
+	out := make([]byte, aesNonceSize, aesNonceSize+len(plain)+c.aead.Overhead())
+	if _, err := io.ReadFull(randReader(c.rand), out); err != nil {
+		return nil, ErrEncryptionFailed
+	}
+	return c.aead.Seal(out, out, plain, bucketAAD(idx)), nil
+}
+
+// DecodeBucket authenticates and decrypts a blob. It returns
+// ErrDecryptionFailed if the blob was modified or belongs to another bucket.
+func (c *AESGCMBucketCodec) DecodeBucket(idx int, blob []byte, bucketSize int) ([]Block, error) {
+	if len(blob) < aesNonceSize+aesTagSize {
+		return nil, ErrDecryptionFailed
+	}
+	plain, err := c.aead.Open(nil, blob[:aesNonceSize], blob[aesNonceSize:], bucketAAD(idx))
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return decodeBucket(plain, bucketSize)
+}
+
+// Overhead returns the nonce and tag size, 28 bytes.
+func (c *AESGCMBucketCodec) Overhead() int {
+	return aesNonceSize + aesTagSize
+}
+
+func (c *AESGCMBucketCodec) setRand(r io.Reader) { c.rand = r }
+
+// bucketAAD returns the additional data binding a blob to bucket idx.
+func bucketAAD(idx int) []byte {
+	return binary.BigEndian.AppendUint64([]byte("bucket"), uint64(idx))
+}
+
+// packedHeader is the size of the blob length stored before each blob.
+const packedHeader = 4
+
+// PackedStorage implements Storage by storing each bucket as one blob from a
+// BucketCodec, split evenly across the slots of a bucket in an inner
+// Storage. The inner backend, such as filestorage or httpstorage, sees only
+// blocks with ID EmptyBlockID and Leaf -1 and equal-length opaque data, so
+// it cannot tell real slots from dummies or learn their leaves.
+//
+// Create the inner backend with the ORAM's bucket count and bucket size and
+// a block size of PackedBlockSize. Buckets that have never been written read
+// as empty, so a fresh backend needs no initialization pass. Every blob has
+// the same length: dummy slots whose data is not BlockSize bytes are stored
+// as zeros.
+type PackedStorage struct {
+	inner     StorageV2
+	storage   Storage
+	codec     BucketCodec
+	blockSize int
+}
+
+var _ BatchStorage = (*PackedStorage)(nil)
+var _ StorageCtx = (*PackedStorage)(nil)
+
+// PackedBlockSize returns the inner block size PackedStorage needs to store
+// buckets of bucketSize blocks of blockSize bytes with codec.
+func PackedBlockSize(bucketSize, blockSize int, codec BucketCodec) int {
+	blob := packedHeader + bucketSize*(20+blockSize) + codec.Overhead()
+	return (blob + bucketSize - 1) / bucketSize
+}
+
+// NewPackedStorage returns a PackedStorage presenting blocks of blockSize
+// bytes over inner. It returns ErrInvalidConfig if inner's block size is not
+// PackedBlockSize(inner.BucketSize(), blockSize, codec).
+func NewPackedStorage(inner Storage, codec BucketCodec, blockSize int) (*PackedStorage, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("%w: block size %d", ErrInvalidConfig, blockSize)
+	}
+	if want := PackedBlockSize(inner.BucketSize(), blockSize, codec); inner.BlockSize() != want {
+		return nil, fmt.Errorf("%w: inner block size %d, want %d", ErrInvalidConfig, inner.BlockSize(), want)
+	}
+	return &PackedStorage{inner: AdaptStorage(inner), storage: inner, codec: codec, blockSize: blockSize}, nil
+}
+
+// ReadBucket reads and decodes the bucket at idx.
+func (s *PackedStorage) ReadBucket(idx int) ([]Block, error) {
+	return s.ReadBucketCtx(context.Background(), idx)
+}
+
+// WriteBucket encodes the bucket and writes it to idx.
+func (s *PackedStorage) WriteBucket(idx int, blocks []Block) error {
+	return s.WriteBucketCtx(context.Background(), idx, blocks)
+}
+
+// ReadBucketCtx is like ReadBucket but honors ctx.
+func (s *PackedStorage) ReadBucketCtx(ctx context.Context, idx int) ([]Block, error) {
+	slots, err := s.inner.ReadBucket(ctx, idx)
+	if err != nil {
+		return nil, err
+	}
+	return s.unpack(idx, slots)
+}
+
+// WriteBucketCtx is like WriteBucket but honors ctx.
+func (s *PackedStorage) WriteBucketCtx(ctx context.Context, idx int, blocks []Block) error {
+	slots, err := s.pack(idx, blocks)
+	if err != nil {
+		return err
+	}
+	return s.inner.WriteBucket(ctx, idx, slots)
+}
+
+// ReadBuckets reads and decodes the buckets at idxs in one inner batch.
+func (s *PackedStorage) ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error) {
+	all, err := s.inner.ReadBuckets(ctx, idxs)
+	if err != nil {
+		return nil, err
+	}
+	for i, idx := range idxs {
+		if all[i], err = s.unpack(idx, all[i]); err != nil {
+			return nil, err
+		}
+	}
+	return all, nil
+}
+
+// WriteBuckets encodes the buckets and writes them in one inner batch.
+func (s *PackedStorage) WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error {
+	all := make([][]Block, len(idxs))
+	for i, idx := range idxs {
+		var err error
+		if all[i], err = s.pack(idx, buckets[i]); err != nil {
+			return err
+		}
+	}
+	return s.inner.WriteBuckets(ctx, idxs, all)
+}
+
+// NumBuckets returns the inner storage's bucket count.
+func (s *PackedStorage) NumBuckets() int {
+	return s.inner.NumBuckets()
+}
+
+// BucketSize returns the inner storage's slots per bucket.
+func (s *PackedStorage) BucketSize() int {
+	return s.inner.BucketSize()
+}
+
+// BlockSize returns the block size presented to the ORAM.
+func (s *PackedStorage) BlockSize() int {
+	return s.blockSize
+}
+
+// Close closes the inner storage if it implements io.Closer.
+func (s *PackedStorage) Close() error {
+	if c, ok := s.storage.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// pack encodes blocks and splits the blob, prefixed with its length, across
+// the inner bucket's slots.
+func (s *PackedStorage) pack(idx int, blocks []Block) ([]Block, error) {
+	z := s.inner.BucketSize()
+	if len(blocks) != z {
+		return nil, ErrInvalidConfig
+	}
+	fixed := make([]Block, z)
+	for i, b := range blocks {
+		if len(b.Data) != s.blockSize {
+			if b.ID != EmptyBlockID {
+				return nil, ErrInvalidDataSize
+			}
+			b.Data = make([]byte, s.blockSize)
+		}
+		fixed[i] = b
+	}
+	blob, err := s.codec.EncodeBucket(idx, fixed)
+	if err != nil {
+		return nil, err
+	}
+	size := s.inner.BlockSize()
+	if packedHeader+len(blob) > z*size {
+		return nil, fmt.Errorf("%w: %d-byte blob exceeds bucket", ErrInvalidConfig, len(blob))
+	}
+	buf := make([]byte, z*size)
+	binary.BigEndian.PutUint32(buf, uint32(len(blob)))
+	copy(buf[packedHeader:], blob)
+	slots := make([]Block, z)
+	for i := range slots {
+		slots[i] = Block{ID: EmptyBlockID, Leaf: -1, Data: buf[i*size : (i+1)*size]}
+	}
+	return slots, nil
+}
+
+// unpack joins the inner bucket's slots and decodes the blob they hold. A
+// bucket with no blob reads as empty.
+func (s *PackedStorage) unpack(idx int, slots []Block) ([]Block, error) {
+	var buf []byte
+	for _, b := range slots {
+		buf = append(buf, b.Data...)
+	}
+	if len(buf) < packedHeader {
+		return nil, ErrCorruptObject
+	}
+	n := int(binary.BigEndian.Uint32(buf))
+	if n == 0 {
+		return emptyBucket(len(slots), s.blockSize), nil
+	}
+	if n > len(buf)-packedHeader {
+		return nil, ErrCorruptObject
+	}
+	return s.codec.DecodeBucket(idx, buf[packedHeader:packedHeader+n], len(slots))
+}
@@ -0,0 +1,100 @@
+//go:build !pathoram_minimal
+
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newTestPackedStorage(t *testing.T, cfg Config, enc Encryptor) (*PackedStorage, *InMemoryStorage) {
+	t.Helper()
+	codec, err := NewAESGCMBucketCodec(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewAESGCMBucketCodec failed: %v", err)
+	}
+	_, _, total := cfg.ComputeTreeParams()
+	blockSize := cfg.BlockSize + enc.Overhead()
+	inner := NewInMemoryStorage(total, cfg.BucketSize, PackedBlockSize(cfg.BucketSize, blockSize, codec))
+	s, err := NewPackedStorage(inner, codec, blockSize)
+	if err != nil {
+		t.Fatalf("NewPackedStorage failed: %v", err)
+	}
+	return s, inner
+}
+
+func TestPackedStorage_ORAM(t *testing.T) {
+	cfg, _ := Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4}.Validate()
+	enc := NoOpEncryptor{}
+	s, inner := newTestPackedStorage(t, cfg, enc)
+	oram, err := New(cfg, s, NewInMemoryPositionMap(), enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{0xA0 | byte(i%16)}, 32)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	for i := range cfg.NumBlocks {
+		got, err := oram.Read(i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{0xA0 | byte(i%16)}, 32)) {
+			t.Fatalf("Read(%d) = %x, %v", i, got, err)
+		}
+	}
+
+	// The inner backend holds no metadata and, even without an Encryptor,
+	// no plaintext.
+	for idx := range inner.NumBuckets() {
+		bucket, _ := inner.ReadBucket(idx)
+		for _, b := range bucket {
+			if b.ID != EmptyBlockID || b.Leaf != -1 {
+				t.Fatalf("bucket %d stores ID %d, leaf %d in the clear", idx, b.ID, b.Leaf)
+			}
+			if bytes.Contains(b.Data, bytes.Repeat([]byte{0xA5}, 8)) {
+				t.Fatalf("bucket %d stores plaintext", idx)
+			}
+		}
+	}
+}
+
+func TestPackedStorage_Tampering(t *testing.T) {
+	cfg, _ := Config{NumBlocks: 16, BlockSize: 16, BucketSize: 4}.Validate()
+	s, inner := newTestPackedStorage(t, cfg, NoOpEncryptor{})
+
+	bucket, err := s.ReadBucket(2)
+	if err != nil {
+		t.Fatalf("ReadBucket of unwritten bucket failed: %v", err)
+	}
+	bucket[1] = Block{ID: 5, Leaf: 3, Data: bytes.Repeat([]byte{1}, 16)}
+	bucket[2].Data = nil // dummies of any length are accepted
+	if err := s.WriteBucket(2, bucket); err != nil {
+		t.Fatalf("WriteBucket failed: %v", err)
+	}
+	got, err := s.ReadBucket(2)
+	if err != nil || got[1].ID != 5 || got[1].Leaf != 3 || got[2].ID != EmptyBlockID {
+		t.Fatalf("ReadBucket = %+v, %v", got, err)
+	}
+
+	bucket[0] = Block{ID: 6, Leaf: 0, Data: []byte("short")}
+	if err := s.WriteBucket(2, bucket); !errors.Is(err, ErrInvalidDataSize) {
+		t.Errorf("short real block: error = %v, want ErrInvalidDataSize", err)
+	}
+
+	// A blob moved to another bucket fails authentication
+	raw, _ := inner.ReadBucket(2)
+	inner.WriteBucket(3, raw)
+	if _, err := s.ReadBucket(3); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("moved blob: error = %v, want ErrDecryptionFailed", err)
+	}
+	raw[1].Data[0] ^= 1
+	inner.WriteBucket(2, raw)
+	if _, err := s.ReadBucket(2); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("modified blob: error = %v, want ErrDecryptionFailed", err)
+	}
+
+	if _, err := NewPackedStorage(inner, &AESGCMBucketCodec{}, 8); err == nil {
+		t.Error("NewPackedStorage with mismatched inner block size succeeded")
+	}
+}
@@ -0,0 +1,75 @@
+package pathoram
+
+import "sync"
+
+// bufferPool recycles byte slices of at least size bytes (Config.ReuseBuffers).
+type bufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+func newBufferPool(size int) *bufferPool {
+	return &bufferPool{size: size}
+}
+
+// get returns a slice of length size with unspecified contents.
+func (p *bufferPool) get() []byte {
+	if b, ok := p.pool.Get().(*[]byte); ok {
+		return (*b)[:p.size]
+	}
+	return make([]byte, p.size)
+}
+
+// put makes b available to get. Slices that are too small are dropped.
+func (p *bufferPool) put(b []byte) {
+	if cap(b) < p.size {
+		return
+	}
+	p.pool.Put(&b)
+}
+
+// newBlockData returns a zeroed buffer for stash data or results, of
+// BlockSize plus MetadataSize bytes.
+func (o *PathORAM) newBlockData() []byte {
+	if o.plainBufs == nil {
+		return make([]byte, o.cfg.plainSize())
+	}
+	b := o.plainBufs.get()
+	clear(b)
+	return b
+}
+
+// decryptBlock decrypts a stored block, into a pooled buffer if possible.
+func (o *PathORAM) decryptBlock(b Block) ([]byte, error) {
+	if be, ok := o.encrypt.(BufferedEncryptor); ok && o.plainBufs != nil {
+		return be.DecryptAppend(o.plainBufs.get()[:0], b.ID, b.Leaf, b.Data)
+	}
+	return o.encrypt.Decrypt(b.ID, b.Leaf, b.Data)
+}
+
+// recycleSealed returns the ciphertext buffers of the previous eviction to
+// the pool. It runs at the start of each path read, when every bucket that
+// eviction produced has been written (or abandoned on error), and also drops
+// blocks an abandoned eviction left awaiting encryption.
+func (o *PathORAM) recycleSealed() {
+	clear(o.sealJobs)
+	o.sealJobs = o.sealJobs[:0]
+	for _, b := range o.sealed {
+		o.cipherBufs.put(b)
+	}
+	clear(o.sealed)
+	o.sealed = o.sealed[:0]
+}
+
+// ReleaseBuffer hands a slice returned by Read, Write or Access back to the
+// ORAM for reuse when Config.ReuseBuffers is set, zeroizing it first with
+// Config.SecureDelete.
+// The caller must not use b afterwards.
+func (o *PathORAM) ReleaseBuffer(b []byte) {
+	if o.cfg.SecureDelete {
+		clear(b)
+	}
+	if o.plainBufs != nil {
+		o.plainBufs.put(b)
+	}
+}
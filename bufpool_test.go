@@ -0,0 +1,105 @@
+package pathoram
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"runtime"
+	"testing"
+)
+
+func newReuseTestORAM(t testing.TB, reuse bool) *PathORAM {
+	t.Helper()
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{1}, 32))
+	opts := []Option{WithCapacity(64, 4096), WithStashLimit(200), WithEncryptor(enc)}
+	if reuse {
+		opts = append(opts, WithReuseBuffers())
+	}
+	oram, err := NewORAM(opts...)
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	return oram
+}
+
+func TestReuseBuffers_Correctness(t *testing.T) {
+	oram := newReuseTestORAM(t, true)
+	shadow := make(map[int][]byte)
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 2000; i++ {
+		id := r.IntN(64)
+		if r.IntN(2) == 0 {
+			data := bytes.Repeat([]byte{byte(i)}, 4096)
+			old, err := oram.Write(id, data)
+			if err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			oram.ReleaseBuffer(old)
+			shadow[id] = data
+			continue
+		}
+		got, err := oram.Read(id)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		want := shadow[id]
+		if want == nil {
+			want = make([]byte, 4096)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("op %d: Read(%d) returned wrong data", i, id)
+		}
+		oram.ReleaseBuffer(got)
+	}
+}
+
+func TestReuseBuffers_FewerBytesAllocated(t *testing.T) {
+	// InMemoryStorage copies every bucket in and out, so only the ORAM's own
+	// plaintext and ciphertext buffers can be saved.
+	measure := func(reuse bool) uint64 {
+		oram := newReuseTestORAM(t, reuse)
+		for id := range 64 {
+			oram.Write(id, make([]byte, 4096))
+		}
+		return bytesAllocated(func() {
+			for i := range 500 {
+				data, _ := oram.Read(i % 64)
+				oram.ReleaseBuffer(data)
+			}
+		})
+	}
+	plain, pooled := measure(false), measure(true)
+	if pooled > plain*9/10 {
+		t.Errorf("bytes allocated: %d with ReuseBuffers, %d without", pooled, plain)
+	}
+}
+
+// bytesAllocated returns the bytes allocated on the heap while f runs.
+func bytesAllocated(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func BenchmarkAccess_ReuseBuffers(b *testing.B) {
+	for _, reuse := range []bool{false, true} {
+		name := "Alloc"
+		if reuse {
+			name = "Pooled"
+		}
+		b.Run(name, func(b *testing.B) {
+			oram := newReuseTestORAM(b, reuse)
+			data := make([]byte, 4096)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				old, err := oram.Write(i%64, data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				oram.ReleaseBuffer(old)
+			}
+		})
+	}
+}
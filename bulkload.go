@@ -0,0 +1,154 @@
+package pathoram
+
+import (
+	"context"
+	"iter"
+	"maps"
+)
+
+// BulkLoad initializes an empty ORAM with the given blocks in a single pass.
+// See BulkLoadSeq.
+func (o *PathORAM) BulkLoad(data map[int][]byte) error {
+	return o.BulkLoadSeq(maps.All(data))
+}
+
+// BulkLoadCtx is like BulkLoad but passes ctx to the storage backend and Authorizer.
+func (o *PathORAM) BulkLoadCtx(ctx context.Context, data map[int][]byte) error {
+	return o.BulkLoadSeqCtx(ctx, maps.All(data))
+}
+
+// BulkLoadSeq initializes an empty ORAM from a sequence of (blockID, data) pairs.
+// Each block is assigned a random leaf, and the tree is then packed bottom-up:
+// every bucket is filled with up to Z blocks whose leaves lie in its subtree,
+// and the rest move up to the parent. Blocks that don't fit below the root stay
+// in the stash. Each bucket is written exactly once, instead of the N full path
+// reads and evictions that N Write calls would cost.
+//
+// The ORAM must be empty (no allocated blocks). All IDs and data sizes are
+// validated before any bucket is written. Duplicate IDs keep the last value.
+//
+// With a DefaultPositionMap such as PRFPositionMap, blocks go to their
+// default leaves instead, and every ID below NumBlocks is stored, zero-filled
+// if seq does not give it, so the map need not record any position.
+func (o *PathORAM) BulkLoadSeq(seq iter.Seq2[int, []byte]) error {
+	return o.BulkLoadSeqCtx(context.Background(), seq)
+}
+
+// BulkLoadSeqCtx is like BulkLoadSeq but passes ctx to the storage backend
+// and Authorizer. Once bucket writes start, they run to completion.
+func (o *PathORAM) BulkLoadSeqCtx(ctx context.Context, seq iter.Seq2[int, []byte]) error {
+	return o.bulkLoad(ctx, seq, o.cfg.BlockSize, nil)
+}
+
+// bulkLoad is BulkLoadSeqCtx for data of size bytes: BlockSize, or
+// plainSize to include metadata. If seqErr is set once seq is exhausted,
+// bulkLoad returns it without writing anything.
+func (o *PathORAM) bulkLoad(ctx context.Context, seq iter.Seq2[int, []byte], size int, seqErr *error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return ErrClosed
+	}
+	if o.posMap.Size() != 0 || len(o.stash) != 0 {
+		return ErrNotEmpty
+	}
+
+	dpm, _ := o.posMap.(DefaultPositionMap)
+	type loc struct{ leaf, pos int }
+	byLeaf := make([][]block, o.numLeaves)
+	seen := make(map[int]loc)
+	for id, data := range seq {
+		if id < 0 || id >= o.cfg.NumBlocks {
+			return ErrInvalidBlockID
+		}
+		if len(data) != size {
+			return ErrInvalidDataSize
+		}
+		if err := o.authorize(ctx, OpWrite, id); err != nil {
+			return err
+		}
+		if l, dup := seen[id]; dup {
+			copy(byLeaf[l.leaf][l.pos].data, data)
+			continue
+		}
+		b := block{id: id, leaf: o.loadLeaf(dpm, id), data: make([]byte, o.cfg.plainSize())}
+		copy(b.data, data)
+		seen[id] = loc{leaf: b.leaf, pos: len(byLeaf[b.leaf])}
+		byLeaf[b.leaf] = append(byLeaf[b.leaf], b)
+	}
+	if seqErr != nil && *seqErr != nil {
+		return *seqErr
+	}
+	if dpm != nil {
+		// Every ID will map to its default leaf, so every block must exist
+		for id := range o.cfg.NumBlocks {
+			if _, ok := seen[id]; !ok {
+				leaf := o.loadLeaf(dpm, id)
+				seen[id] = loc{leaf: leaf, pos: len(byLeaf[leaf])}
+				byLeaf[leaf] = append(byLeaf[leaf], block{id: id, leaf: leaf, data: make([]byte, o.cfg.plainSize())})
+			}
+		}
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	totalBuckets := 2*o.numLeaves - 1
+	firstLeaf := o.numLeaves - 1
+	pending := make([][]block, totalBuckets) // blocks passed up from each bucket
+
+	// Children have higher indices than parents, so a reverse sweep is bottom-up.
+	for idx := totalBuckets - 1; idx >= 0; idx-- {
+		var candidates []block
+		if idx >= firstLeaf {
+			candidates = byLeaf[idx-firstLeaf]
+			byLeaf[idx-firstLeaf] = nil
+		} else {
+			left, right := 2*idx+1, 2*idx+2
+			candidates = append(pending[left], pending[right]...)
+			pending[left], pending[right] = nil, nil
+		}
+		if idx < o.cachedBuckets() {
+			// Cached levels live in the stash
+			pending[idx] = candidates
+			continue
+		}
+
+		bucket := make([]Block, o.cfg.BucketSize)
+		for slot := range bucket {
+			if slot < len(candidates) {
+				bucket[slot] = o.blockToStorage(candidates[slot])
+			} else {
+				bucket[slot] = Block{ID: EmptyBlockID, Leaf: -1, Data: make([]byte, o.storage.BlockSize())}
+			}
+		}
+		if err := o.writeBucket(ctx, idx, bucket); err != nil {
+			return err
+		}
+		if len(candidates) > o.cfg.BucketSize {
+			pending[idx] = candidates[o.cfg.BucketSize:]
+		}
+	}
+
+	if dpm != nil {
+		dpm.UseDefaults(o.cfg.NumBlocks, o.numLeaves)
+	}
+	for id, l := range seen {
+		o.setPosition(id, l.leaf)
+	}
+	o.stash = append(o.stash, pending[0]...)
+	if err := o.checkStash(); err != nil {
+		return err
+	}
+	if err := o.syncStash(); err != nil {
+		return err
+	}
+	return o.replicate()
+}
+
+// loadLeaf returns the leaf BulkLoad assigns to blockID: its default leaf
+// if the position map has defaults, otherwise an assigned one.
+func (o *PathORAM) loadLeaf(dpm DefaultPositionMap, blockID int) int {
+	if dpm != nil {
+		return dpm.DefaultLeaf(blockID, o.numLeaves)
+	}
+	return o.assignLeaf(blockID)
+}
@@ -0,0 +1,114 @@
+package pathoram
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBulkLoad_Correctness(t *testing.T) {
+	strategies := []EvictionStrategy{EvictLevelByLevel, EvictGreedyByDepth, EvictDeterministicTwoPath}
+
+	for _, strategy := range strategies {
+		t.Run(fmt.Sprintf("strategy=%d", strategy), func(t *testing.T) {
+			cfg := Config{NumBlocks: 200, BlockSize: 32, BucketSize: 4, StashLimit: 200, EvictionStrategy: strategy}
+			oram, _ := NewInMemory(cfg)
+
+			data := make(map[int][]byte)
+			for i := 0; i < 150; i++ {
+				data[i] = bytes.Repeat([]byte{byte(i)}, 32)
+			}
+			if err := oram.BulkLoad(data); err != nil {
+				t.Fatalf("BulkLoad failed: %v", err)
+			}
+			if oram.Size() != 150 {
+				t.Errorf("Size() = %d, want 150", oram.Size())
+			}
+
+			for i := 0; i < 150; i++ {
+				got, err := oram.Read(i)
+				if err != nil {
+					t.Fatalf("Read(%d) failed: %v", i, err)
+				}
+				if !bytes.Equal(got, data[i]) {
+					t.Errorf("Read(%d) mismatch", i)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkLoad_BlocksOnAssignedPath(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4}
+	cfg, _ = cfg.Validate()
+	_, _, totalBuckets := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.BlockSize)
+	posMap := NewInMemoryPositionMap()
+	oram, _ := New(cfg, storage, posMap, NoOpEncryptor{})
+
+	data := make(map[int][]byte)
+	for i := 0; i < 64; i++ {
+		data[i] = make([]byte, 16)
+	}
+	if err := oram.BulkLoad(data); err != nil {
+		t.Fatalf("BulkLoad failed: %v", err)
+	}
+
+	for idx := 0; idx < totalBuckets; idx++ {
+		bucket, _ := storage.ReadBucket(idx)
+		for _, b := range bucket {
+			if b.ID == EmptyBlockID {
+				continue
+			}
+			leaf, _ := posMap.Get(b.ID)
+			if leaf != b.Leaf {
+				t.Errorf("block %d stored with leaf %d, posMap has %d", b.ID, b.Leaf, leaf)
+			}
+			if !oram.canPlaceAt(b.Leaf, idx) {
+				t.Errorf("block %d in bucket %d is not on path of leaf %d", b.ID, idx, b.Leaf)
+			}
+		}
+	}
+}
+
+func TestBulkLoad_Errors(t *testing.T) {
+	cfg := Config{NumBlocks: 10, BlockSize: 16, BucketSize: 4}
+
+	oram, _ := NewInMemory(cfg)
+	if err := oram.BulkLoad(map[int][]byte{10: make([]byte, 16)}); err != ErrInvalidBlockID {
+		t.Errorf("invalid ID error = %v, want ErrInvalidBlockID", err)
+	}
+	if err := oram.BulkLoad(map[int][]byte{0: make([]byte, 8)}); err != ErrInvalidDataSize {
+		t.Errorf("wrong size error = %v, want ErrInvalidDataSize", err)
+	}
+	if oram.Size() != 0 {
+		t.Errorf("failed BulkLoad allocated %d blocks", oram.Size())
+	}
+
+	oram.Write(0, make([]byte, 16))
+	if err := oram.BulkLoad(map[int][]byte{1: make([]byte, 16)}); err != ErrNotEmpty {
+		t.Errorf("non-empty ORAM error = %v, want ErrNotEmpty", err)
+	}
+}
+
+// BenchmarkBulkLoad compares BulkLoad against sequential writes.
+func BenchmarkBulkLoad(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		cfg := Config{NumBlocks: n, BlockSize: 256, BucketSize: 5, StashLimit: n}
+		data := make(map[int][]byte, n)
+		for i := 0; i < n; i++ {
+			data[i] = make([]byte, 256)
+		}
+
+		b.Run(fmt.Sprintf("N=%d", n), func(b *testing.B) {
+			for iter := 0; iter < b.N; iter++ {
+				b.StopTimer()
+				oram, _ := NewInMemory(cfg)
+				b.StartTimer()
+				if err := oram.BulkLoad(data); err != nil {
+					b.Fatalf("BulkLoad: %v", err)
+				}
+			}
+		})
+	}
+}
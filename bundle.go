@@ -0,0 +1,386 @@
+package pathoram
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// bundleMagic starts every distribution bundle.
+const bundleMagic = "PORAMBN1"
+
+const (
+	bundleHeaderSize = 80
+	bundleSlotHeader = 16 // block ID and leaf
+	bundleStateID    = -2 // block ID the client state is encrypted under
+)
+
+// WriteBundle writes o's storage tree and client state to w as a read-only
+// distribution bundle signed with key. Publishers build a dataset in an ORAM,
+// write a bundle, and ship it with the public key; readers open it with
+// OpenBundle and query it obliviously without loading it.
+//
+// Format (big-endian): an 80-byte header (magic "PORAMBN1", NumBlocks,
+// BlockSize, BucketSize, bucket count, stored block size, state length,
+// access count, key fingerprint), every bucket's slots at fixed offsets
+// (ID, leaf, ciphertext), a SHA-256 hash per bucket, the client state
+// (positions and stash) encrypted with o's encryptor, and an Ed25519
+// signature over the SHA-256 of header, hashes and state.
+//
+// The client state is only as confidential as the encryptor makes it; use a
+// keyed encryptor for bundles leaving the trust boundary.
+func (o *PathORAM) WriteBundle(ctx context.Context, w io.Writer, key ed25519.PrivateKey) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	state, err := o.encrypt.Encrypt(bundleStateID, 0, o.bundleState())
+	if err != nil {
+		return err
+	}
+	_, _, numBuckets := o.cfg.ComputeTreeParams()
+	stored := o.cfg.plainSize() + o.encrypt.Overhead()
+
+	var hdr [bundleHeaderSize]byte
+	copy(hdr[:], bundleMagic)
+	binary.BigEndian.PutUint64(hdr[8:], uint64(o.cfg.NumBlocks))
+	binary.BigEndian.PutUint32(hdr[16:], uint32(o.cfg.BlockSize))
+	binary.BigEndian.PutUint32(hdr[20:], uint32(o.cfg.BucketSize))
+	binary.BigEndian.PutUint64(hdr[24:], uint64(numBuckets))
+	binary.BigEndian.PutUint32(hdr[32:], uint32(stored))
+	binary.BigEndian.PutUint64(hdr[40:], uint64(len(state)))
+	binary.BigEndian.PutUint64(hdr[48:], o.accessCount)
+	if kf, ok := o.encrypt.(KeyFingerprinter); ok {
+		copy(hdr[56:72], kf.KeyFingerprint())
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write(hdr[:])
+	hashes := make([]byte, 0, numBuckets*sha256.Size)
+	slot := make([]byte, bundleSlotHeader+stored)
+	for idx := 0; idx < numBuckets; idx++ {
+		bucket, err := o.readBucket(ctx, idx)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		for _, b := range bucket {
+			clear(slot)
+			binary.BigEndian.PutUint64(slot[0:], uint64(b.ID))
+			binary.BigEndian.PutUint64(slot[8:], uint64(b.Leaf))
+			if b.ID != EmptyBlockID {
+				if len(b.Data) != stored {
+					return fmt.Errorf("%w: bucket %d holds a %d-byte block, want %d", ErrCorruptObject, idx, len(b.Data), stored)
+				}
+				copy(slot[bundleSlotHeader:], b.Data)
+			}
+			h.Write(slot)
+			bw.Write(slot)
+		}
+		hashes = h.Sum(hashes)
+	}
+	bw.Write(hashes)
+	bw.Write(state)
+	bw.Write(ed25519.Sign(key, bundleDigest(hdr[:], hashes, state)))
+	return bw.Flush()
+}
+
+// bundleState serializes the position map and stash.
+func (o *PathORAM) bundleState() []byte {
+	var buf []byte
+	var n uint64
+	buf = binary.BigEndian.AppendUint64(buf, 0) // position count, patched below
+	for id := 0; id < o.cfg.NumBlocks; id++ {
+		if leaf, ok := o.posMap.Get(id); ok {
+			buf = binary.BigEndian.AppendUint64(buf, uint64(id))
+			buf = binary.BigEndian.AppendUint64(buf, uint64(leaf))
+			n++
+		}
+	}
+	binary.BigEndian.PutUint64(buf, n)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(o.stash)))
+	for _, b := range o.stash {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(b.id))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(b.leaf))
+		buf = append(buf, b.data...)
+	}
+	return buf
+}
+
+// bundleDigest is the signed digest of a bundle.
+func bundleDigest(hdr, hashes, state []byte) []byte {
+	h := sha256.New()
+	h.Write(hdr)
+	h.Write(hashes)
+	h.Write(state)
+	return h.Sum(nil)
+}
+
+// Bundle is an open distribution bundle. The file is memory-mapped where
+// the platform supports it, so opening costs one signature check over the
+// bucket hashes regardless of the dataset size, and buckets are paged in,
+// verified and decrypted only when an access touches them.
+type Bundle struct {
+	data  []byte // whole file
+	unmap func() error
+
+	numBlocks, blockSize, bucketSize int
+	numBuckets, stored               int
+	accessCount                      uint64
+	keyFP                            []byte // nil if the writer's encryptor had none
+	hashes, state                    []byte
+}
+
+// OpenBundle maps the bundle at path and verifies its signature with pub.
+// It returns ErrInvalidSignature if the bundle was not signed by pub's key
+// and ErrCorruptObject if it is malformed. Call Close when done with it and
+// every ORAM opened from it.
+func OpenBundle(path string, pub ed25519.PublicKey) (*Bundle, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseBundle(data, pub)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	b.unmap = unmap
+	return b, nil
+}
+
+// parseBundle checks a bundle's layout and signature.
+func parseBundle(data []byte, pub ed25519.PublicKey) (*Bundle, error) {
+	if len(data) < bundleHeaderSize+ed25519.SignatureSize || string(data[:8]) != bundleMagic {
+		return nil, fmt.Errorf("%w: not a bundle", ErrCorruptObject)
+	}
+	hdr := data[:bundleHeaderSize]
+	b := &Bundle{
+		data:        data,
+		numBlocks:   int(binary.BigEndian.Uint64(hdr[8:])),
+		blockSize:   int(binary.BigEndian.Uint32(hdr[16:])),
+		bucketSize:  int(binary.BigEndian.Uint32(hdr[20:])),
+		numBuckets:  int(binary.BigEndian.Uint64(hdr[24:])),
+		stored:      int(binary.BigEndian.Uint32(hdr[32:])),
+		accessCount: binary.BigEndian.Uint64(hdr[48:]),
+	}
+	stateLen := binary.BigEndian.Uint64(hdr[40:])
+	if fp := hdr[56:72]; !bytes.Equal(fp, make([]byte, keyFingerprintSize)) {
+		b.keyFP = bytes.Clone(fp)
+	}
+
+	// Check sizes in uint64 so a hostile header cannot overflow them
+	rest := uint64(len(data) - bundleHeaderSize - ed25519.SignatureSize)
+	perBucket := uint64(b.bucketSize)*uint64(bundleSlotHeader+b.stored) + sha256.Size
+	if b.numBlocks <= 0 || b.bucketSize <= 0 || b.numBuckets <= 0 ||
+		uint64(b.numBuckets) > rest/perBucket || stateLen != rest-uint64(b.numBuckets)*perBucket {
+		return nil, fmt.Errorf("%w: bundle size does not match its header", ErrCorruptObject)
+	}
+	hashesAt := bundleHeaderSize + b.numBuckets*b.bucketBytes()
+	b.hashes = data[hashesAt : hashesAt+b.numBuckets*sha256.Size]
+	b.state = data[hashesAt+len(b.hashes) : len(data)-ed25519.SignatureSize]
+
+	if !ed25519.Verify(pub, bundleDigest(hdr, b.hashes, b.state), data[len(data)-ed25519.SignatureSize:]) {
+		return nil, ErrInvalidSignature
+	}
+	return b, nil
+}
+
+func (b *Bundle) bucketBytes() int {
+	return b.bucketSize * (bundleSlotHeader + b.stored)
+}
+
+// Config returns the tree parameters the bundle was written with.
+func (b *Bundle) Config() Config {
+	return Config{NumBlocks: b.numBlocks, BlockSize: b.blockSize, BucketSize: b.bucketSize}
+}
+
+// Close unmaps the bundle. ORAMs opened from it must not be used afterwards.
+func (b *Bundle) Close() error {
+	if b.unmap == nil {
+		return nil
+	}
+	err := b.unmap()
+	b.unmap, b.data = nil, nil
+	return err
+}
+
+// ORAM opens the bundle as a PathORAM. cfg's tree parameters are taken from
+// the bundle (zero values are filled in; others must match) and enc must use
+// the writer's key, or New fails with ErrWrongKey.
+//
+// The bundle is never modified: buckets the ORAM writes back are kept in
+// memory, so resident memory grows with the number of distinct buckets
+// accessed, up to the size of the tree. Each ORAM opened from the same
+// bundle starts from the published state.
+func (b *Bundle) ORAM(cfg Config, enc Encryptor) (*PathORAM, error) {
+	fill := func(got *int, want int) bool {
+		if *got == 0 {
+			*got = want
+		}
+		return *got == want
+	}
+	if !fill(&cfg.NumBlocks, b.numBlocks) || !fill(&cfg.BlockSize, b.blockSize) || !fill(&cfg.BucketSize, b.bucketSize) {
+		return nil, fmt.Errorf("%w: config does not match bundle tree parameters", ErrInvalidConfig)
+	}
+	if stored := cfg.plainSize() + enc.Overhead(); stored != b.stored {
+		return nil, fmt.Errorf("%w: encryptor stores %d-byte blocks, bundle has %d", ErrInvalidConfig, stored, b.stored)
+	}
+	if c, err := cfg.Validate(); err != nil {
+		return nil, err
+	} else if _, _, n := c.ComputeTreeParams(); n != b.numBuckets {
+		return nil, fmt.Errorf("%w: bundle has %d buckets, config needs %d", ErrCorruptObject, b.numBuckets, n)
+	}
+
+	posMap := NewInMemoryPositionMap()
+	o, err := New(cfg, &bundleStorage{b: b, keyFP: b.keyFP}, posMap, enc)
+	if err != nil {
+		return nil, err
+	}
+	state, err := enc.Decrypt(bundleStateID, 0, b.state)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.restoreBundleState(state); err != nil {
+		return nil, err
+	}
+	o.accessCount = b.accessCount
+	return o, nil
+}
+
+// restoreBundleState loads a bundleState into a new ORAM.
+func (o *PathORAM) restoreBundleState(state []byte) error {
+	bad := fmt.Errorf("%w: malformed bundle state", ErrCorruptObject)
+	next := func() (int, bool) {
+		if len(state) < 8 {
+			return 0, false
+		}
+		v := int(binary.BigEndian.Uint64(state))
+		state = state[8:]
+		return v, true
+	}
+	n, ok := next()
+	if !ok || n < 0 || n > len(state)/16 {
+		return bad
+	}
+	for range n {
+		id, _ := next()
+		leaf, _ := next()
+		if id < 0 || id >= o.cfg.NumBlocks || leaf < 0 || leaf >= o.numLeaves {
+			return bad
+		}
+		o.posMap.Set(id, leaf)
+	}
+	n, ok = next()
+	if !ok || n < 0 || n > len(state)/(16+o.cfg.plainSize()) {
+		return bad
+	}
+	for range n {
+		id, _ := next()
+		leaf, _ := next()
+		if id < 0 || id >= o.cfg.NumBlocks || leaf < 0 || leaf >= o.numLeaves {
+			return bad
+		}
+		o.stash = append(o.stash, block{id: id, leaf: leaf, data: bytes.Clone(state[:o.cfg.plainSize()])})
+		state = state[o.cfg.plainSize():]
+	}
+	if len(state) != 0 {
+		return bad
+	}
+	return nil
+}
+
+// bundleStorage serves buckets from a mapped bundle, verifying each against
+// its signed hash on first read, with written buckets kept in an overlay.
+// The lock makes it safe for concurrent fetches (Config.FetchParallelism).
+type bundleStorage struct {
+	b *Bundle
+
+	mu       sync.Mutex
+	overlay  map[int][]Block
+	verified []bool
+	keyFP    []byte
+}
+
+// ReadBucket returns a copy of the bucket at idx.
+func (s *bundleStorage) ReadBucket(idx int) ([]Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if blocks, ok := s.overlay[idx]; ok {
+		return cloneBlocks(blocks), nil
+	}
+	b := s.b
+	if b.data == nil {
+		return nil, fmt.Errorf("%w: bundle closed", ErrInvalidConfig)
+	}
+	raw := b.data[bundleHeaderSize+idx*b.bucketBytes():][:b.bucketBytes()]
+	if s.verified == nil {
+		s.verified = make([]bool, b.numBuckets)
+	}
+	if !s.verified[idx] {
+		sum := sha256.Sum256(raw)
+		if !bytes.Equal(sum[:], b.hashes[idx*sha256.Size:][:sha256.Size]) {
+			return nil, fmt.Errorf("%w: bundle bucket %d", ErrIntegrityViolation, idx)
+		}
+		s.verified[idx] = true
+	}
+
+	blocks := make([]Block, b.bucketSize)
+	data := make([]byte, b.bucketSize*b.stored)
+	for i := range blocks {
+		slot := raw[i*(bundleSlotHeader+b.stored):]
+		d := data[i*b.stored : (i+1)*b.stored : (i+1)*b.stored]
+		copy(d, slot[bundleSlotHeader:bundleSlotHeader+b.stored])
+		blocks[i] = Block{
+			ID:   int(int64(binary.BigEndian.Uint64(slot))),
+			Leaf: int(int64(binary.BigEndian.Uint64(slot[8:]))),
+			Data: d,
+		}
+	}
+	return blocks, nil
+}
+
+// WriteBucket stores a copy of blocks in the overlay.
+func (s *bundleStorage) WriteBucket(idx int, blocks []Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overlay == nil {
+		s.overlay = make(map[int][]Block)
+	}
+	s.overlay[idx] = cloneBlocks(blocks)
+	return nil
+}
+
+// NumBuckets returns the number of buckets in the bundle.
+func (s *bundleStorage) NumBuckets() int { return s.b.numBuckets }
+
+// BucketSize returns the bundle's blocks per bucket.
+func (s *bundleStorage) BucketSize() int { return s.b.bucketSize }
+
+// BlockSize returns the bundle's stored block size.
+func (s *bundleStorage) BlockSize() int { return s.b.stored }
+
+// ReadFingerprint returns the writer's key fingerprint, or nil if none.
+func (s *bundleStorage) ReadFingerprint() ([]byte, error) {
+	return bytes.Clone(s.keyFP), nil
+}
+
+// WriteFingerprint records fp for this ORAM; the bundle is not modified.
+func (s *bundleStorage) WriteFingerprint(fp []byte) error {
+	s.keyFP = bytes.Clone(fp)
+	return nil
+}
+
+// cloneBlocks deep-copies a bucket.
+func cloneBlocks(blocks []Block) []Block {
+	out := make([]Block, len(blocks))
+	for i, b := range blocks {
+		out[i] = Block{ID: b.ID, Leaf: b.Leaf, Data: bytes.Clone(b.Data)}
+	}
+	return out
+}
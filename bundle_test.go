@@ -0,0 +1,142 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestBundle fills an encrypted ORAM and writes it to a bundle file.
+func writeTestBundle(t *testing.T, key []byte, priv ed25519.PrivateKey) (string, map[int][]byte) {
+	t.Helper()
+	cfg := Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4}
+	_, _, buckets := cfg.ComputeTreeParams()
+	enc, _ := NewAESGCMEncryptor(key)
+	oram, err := New(cfg, NewInMemoryStorage(buckets, 4, 32+enc.Overhead()), NewInMemoryPositionMap(), enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	want := make(map[int][]byte)
+	for id := 0; id < 64; id += 3 {
+		want[id] = bytes.Repeat([]byte{byte(id)}, 32)
+		if _, err := oram.Write(id, want[id]); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "data.bundle")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oram.WriteBundle(context.Background(), f, priv); err != nil {
+		t.Fatalf("WriteBundle failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path, want
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{9}, 32)
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	path, want := writeTestBundle(t, key, priv)
+
+	b, err := OpenBundle(path, pub)
+	if err != nil {
+		t.Fatalf("OpenBundle failed: %v", err)
+	}
+	defer b.Close()
+	if cfg := b.Config(); cfg.NumBlocks != 64 || cfg.BlockSize != 32 || cfg.BucketSize != 4 {
+		t.Fatalf("Config = %+v", cfg)
+	}
+
+	enc, _ := NewAESGCMEncryptor(key)
+	oram, err := b.ORAM(Config{}, enc)
+	if err != nil {
+		t.Fatalf("ORAM failed: %v", err)
+	}
+	for id := 0; id < 64; id++ {
+		got, err := oram.Read(id)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", id, err)
+		}
+		exp := want[id]
+		if exp == nil {
+			exp = make([]byte, 32)
+		}
+		if !bytes.Equal(got, exp) {
+			t.Fatalf("block %d = %v, want %v", id, got, exp)
+		}
+	}
+
+	// Writes go to the overlay; the file and fresh ORAMs are unchanged.
+	before, _ := os.ReadFile(path)
+	if _, err := oram.Write(3, make([]byte, 32)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+		t.Fatal("bundle file modified")
+	}
+	enc2, _ := NewAESGCMEncryptor(key)
+	fresh, _ := b.ORAM(Config{}, enc2)
+	if got, _ := fresh.Read(3); !bytes.Equal(got, want[3]) {
+		t.Fatalf("fresh ORAM block 3 = %v, want published value", got)
+	}
+}
+
+func TestBundleVerification(t *testing.T) {
+	key := bytes.Repeat([]byte{9}, 32)
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	path, _ := writeTestBundle(t, key, priv)
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	if _, err := OpenBundle(path, otherPub); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("wrong signer: err = %v, want ErrInvalidSignature", err)
+	}
+
+	b, err := OpenBundle(path, pub)
+	if err != nil {
+		t.Fatalf("OpenBundle failed: %v", err)
+	}
+	wrong, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{8}, 32))
+	if _, err := b.ORAM(Config{}, wrong); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("wrong key: err = %v, want ErrWrongKey", err)
+	}
+	if _, err := b.ORAM(Config{NumBlocks: 65}, wrong); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("mismatched config: err = %v, want ErrInvalidConfig", err)
+	}
+	b.Close()
+
+	// Flipping a bucket byte leaves the signature valid (it covers the
+	// hashes), but reading that bucket fails verification.
+	data, _ := os.ReadFile(path)
+	data[bundleHeaderSize+bundleSlotHeader] ^= 1
+	os.WriteFile(path, data, 0o600)
+	b, err = OpenBundle(path, pub)
+	if err != nil {
+		t.Fatalf("OpenBundle after bucket tamper: %v", err)
+	}
+	defer b.Close()
+	enc, _ := NewAESGCMEncryptor(key)
+	oram, _ := b.ORAM(Config{}, enc)
+	var failed bool
+	for i := 0; i < 64 && !failed; i++ {
+		_, err := oram.Read(i)
+		failed = errors.Is(err, ErrIntegrityViolation)
+	}
+	if !failed {
+		t.Fatal("tampered root bucket never detected")
+	}
+
+	// Truncation is caught at open.
+	os.WriteFile(path, data[:len(data)-1], 0o600)
+	if _, err := OpenBundle(path, pub); !errors.Is(err, ErrCorruptObject) && !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("truncated: err = %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package pathoram
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"sync"
+)
+
+// BlockCacheStats reports Config.CacheBlocks effectiveness.
+type BlockCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRate returns Hits / (Hits + Misses), or 0 before any lookup.
+func (s BlockCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// blockCache is the plaintext LRU of Config.CacheBlocks. It has its own
+// lock so hits do not wait for in-flight accesses; entries are updated by
+// stashAccess while o.mu is held, so a hit never returns data older than
+// the last completed access.
+type blockCache struct {
+	mu      sync.Mutex
+	cap     int
+	lru     *list.List            // of *cacheEntry, most recently used at front
+	entries map[int]*list.Element // by block ID
+	stats   BlockCacheStats
+	closed  bool
+	zeroize bool // Config.SecureDelete: clear data as entries are dropped
+
+	// Dummy accesses owed for hits, performed by payCacheDummies
+	owed   int
+	paying bool
+	idle   chan struct{} // closed when paying stops
+	err    error         // first failed dummy access, returned by the next hit
+}
+
+type cacheEntry struct {
+	id   int
+	data []byte
+}
+
+func newBlockCache(n int, zeroize bool) *blockCache {
+	return &blockCache{cap: n, lru: list.New(), entries: make(map[int]*list.Element), zeroize: zeroize}
+}
+
+// get returns a copy of blockID's cached data.
+func (c *blockCache) get(blockID int) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[blockID]
+	if !ok || c.closed {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(e)
+	return bytes.Clone(e.Value.(*cacheEntry).data), true
+}
+
+// put records data as blockID's current contents.
+func (c *blockCache) put(blockID int, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[blockID]; ok {
+		copy(e.Value.(*cacheEntry).data, data)
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[blockID] = c.lru.PushFront(&cacheEntry{id: blockID, data: bytes.Clone(data)})
+	if c.lru.Len() > c.cap {
+		c.drop(c.lru.Back())
+	}
+}
+
+// remove drops blockID's entry, if any.
+func (c *blockCache) remove(blockID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[blockID]; ok {
+		c.drop(e)
+	}
+}
+
+// purge drops every entry, for operations that change blocks without
+// going through stashAccess.
+func (c *blockCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropAll()
+}
+
+// drop removes entry e, zeroizing its data if required; c.mu must be held.
+func (c *blockCache) drop(e *list.Element) {
+	entry := c.lru.Remove(e).(*cacheEntry)
+	delete(c.entries, entry.id)
+	if c.zeroize {
+		clear(entry.data)
+	}
+}
+
+// dropAll removes every entry; c.mu must be held.
+func (c *blockCache) dropAll() {
+	for c.lru.Len() > 0 {
+		c.drop(c.lru.Back())
+	}
+}
+
+// cachedRead serves a read of blockID from the block cache. On a hit it
+// owes a dummy access, unless Config.CacheSkipDummy is set, which
+// payCacheDummies performs after the hit returns.
+func (o *PathORAM) cachedRead(blockID int) ([]byte, bool, error) {
+	c := o.cache
+	if c == nil {
+		return nil, false, nil
+	}
+	data, ok := c.get(blockID)
+	if !ok {
+		return nil, false, nil
+	}
+	if o.cfg.CacheSkipDummy {
+		return data, true, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.err; err != nil {
+		c.err = nil
+		return nil, true, err
+	}
+	c.owed++
+	if !c.paying {
+		c.paying = true
+		c.idle = make(chan struct{})
+		go o.payCacheDummies()
+	}
+	return data, true, nil
+}
+
+// payCacheDummies performs the dummy accesses owed for cache hits until
+// none are left.
+func (o *PathORAM) payCacheDummies() {
+	c := o.cache
+	for {
+		c.mu.Lock()
+		if c.owed == 0 {
+			c.paying = false
+			close(c.idle)
+			c.mu.Unlock()
+			return
+		}
+		c.owed--
+		c.mu.Unlock()
+
+		o.mu.Lock()
+		err := o.evictPass(context.Background())
+		o.mu.Unlock()
+		if err != nil {
+			c.mu.Lock()
+			if c.err == nil {
+				c.err = err
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// closeCache stops serving hits and waits for owed dummy accesses, for
+// Close. It returns the first dummy access failure not yet reported.
+func (o *PathORAM) closeCache() error {
+	c := o.cache
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	c.closed = true
+	c.dropAll()
+	idle, paying := c.idle, c.paying
+	c.mu.Unlock()
+	if paying {
+		<-idle
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.err
+	c.err = nil
+	return err
+}
+
+// CacheStats returns block cache hit and miss counts (Config.CacheBlocks).
+func (o *PathORAM) CacheStats() BlockCacheStats {
+	if o.cache == nil {
+		return BlockCacheStats{}
+	}
+	o.cache.mu.Lock()
+	defer o.cache.mu.Unlock()
+	return o.cache.stats
+}
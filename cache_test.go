@@ -0,0 +1,75 @@
+package pathoram
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBlockCache_HitsStillAccess(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		oram, err := NewInMemory(Config{NumBlocks: 64, BlockSize: 8, CacheBlocks: 4, CacheSkipDummy: skip})
+		if err != nil {
+			t.Fatalf("NewInMemory: %v", err)
+		}
+		oram.Write(1, []byte("abcdefgh"))
+		for range 10 {
+			got, err := oram.Read(1)
+			if err != nil || string(got) != "abcdefgh" {
+				t.Fatalf("Read = %q, %v", got, err)
+			}
+		}
+		if s := oram.CacheStats(); s.Hits != 10 || s.HitRate() != 1 {
+			t.Errorf("CacheStats = %+v", s)
+		}
+		// Wait for the owed dummy accesses
+		c := oram.cache
+		c.mu.Lock()
+		idle, paying := c.idle, c.paying
+		c.mu.Unlock()
+		if paying {
+			<-idle
+		}
+		want := uint64(11)
+		if skip {
+			want = 1
+		}
+		if a := oram.Stats().Accesses; a != want {
+			t.Errorf("CacheSkipDummy=%v: %d accesses, want %d", skip, a, want)
+		}
+		if err := oram.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if _, err := oram.Read(1); err != ErrClosed {
+			t.Errorf("Read after Close = %v, want ErrClosed", err)
+		}
+	}
+}
+
+func TestBlockCache_Coherent(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 64, BlockSize: 4, CacheBlocks: 2, CacheSkipDummy: true})
+	defer oram.Close()
+	for id := range 4 {
+		oram.Write(id, bytes.Repeat([]byte{byte(id)}, 4))
+	}
+	// Blocks 0 and 1 were evicted from the cache by 2 and 3
+	before := oram.Stats().Accesses
+	oram.Read(3)
+	oram.Read(0)
+	if a := oram.Stats().Accesses - before; a != 1 {
+		t.Errorf("one hit and one miss performed %d accesses, want 1", a)
+	}
+
+	oram.Write(3, []byte("new!"))
+	if got, _ := oram.Read(3); string(got) != "new!" {
+		t.Errorf("Read after Write = %q", got)
+	}
+	oram.WriteBatch([]BatchItem{{BlockID: 3, Data: []byte("bat!")}})
+	if got, _ := oram.Read(3); string(got) != "bat!" {
+		t.Errorf("Read after WriteBatch = %q", got)
+	}
+	oram.Delete(3)
+	dst := make([]byte, 4)
+	if _, err := oram.ReadInto(3, dst); err != nil || !bytes.Equal(dst, make([]byte, 4)) {
+		t.Errorf("ReadInto after Delete = %x, %v", dst, err)
+	}
+}
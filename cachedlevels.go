@@ -0,0 +1,40 @@
+package pathoram
+
+import "context"
+
+// cachedBuckets returns the number of buckets in the cached top levels (see
+// Config.CachedLevels). Those are the buckets with the lowest indices.
+func (o *PathORAM) cachedBuckets() int {
+	return 1<<o.cfg.CachedLevels - 1
+}
+
+// storedPath returns the part of leaf's path (leaf to root) that is kept in
+// storage: Path without the cached top levels.
+func (o *PathORAM) storedPath(leaf int) []int {
+	path := o.Path(leaf)
+	return path[:len(path)-o.cfg.CachedLevels]
+}
+
+// stashLimit is the stash size past which an eviction reports a
+// StashOverflowError: StashLimit plus room for the cached levels' blocks.
+func (o *PathORAM) stashLimit() int {
+	return o.cfg.StashLimit + o.cachedBuckets()*o.cfg.BucketSize
+}
+
+// loadCachedLevels moves blocks stored in the cached levels, e.g. by a
+// previous session without CachedLevels, into the stash.
+func (o *PathORAM) loadCachedLevels(ctx context.Context) error {
+	n := o.cachedBuckets()
+	if n == 0 {
+		return nil
+	}
+	idxs := make([]int, n)
+	for i := range idxs {
+		idxs[i] = i
+	}
+	fetched, err := o.fetchBuckets(ctx, idxs, o.cfg.FetchParallelism)
+	if err != nil {
+		return err
+	}
+	return o.moveIntoStash(ctx, idxs, fetched)
+}
@@ -0,0 +1,130 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// cachedLevelsEmpty reports whether storage holds no blocks in the top k levels.
+func cachedLevelsEmpty(t *testing.T, s *InMemoryStorage, k int) bool {
+	t.Helper()
+	for idx := 0; idx < 1<<k-1; idx++ {
+		bucket, _ := s.ReadBucket(idx)
+		for _, b := range bucket {
+			if b.ID != EmptyBlockID {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestCachedLevels(t *testing.T) {
+	strategies := []struct {
+		name         string
+		strategy     EvictionStrategy
+		constantTime bool
+	}{
+		{"LevelByLevel", EvictLevelByLevel, false},
+		{"GreedyByDepth", EvictGreedyByDepth, false},
+		{"TwoPath", EvictDeterministicTwoPath, false},
+		{"ConstantTime", EvictLevelByLevel, true},
+	}
+	for _, tc := range strategies {
+		t.Run(tc.name, func(t *testing.T) {
+			const k = 3
+			cfg := Config{NumBlocks: 256, BlockSize: 16, BucketSize: 4, EvictionStrategy: tc.strategy, ConstantTime: tc.constantTime, CachedLevels: k}
+			height, _, total := cfg.ComputeTreeParams()
+			storage := NewInMemoryStorage(total, 4, 16)
+			oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+
+			for id := 0; id < 256; id++ {
+				if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id)}, 16)); err != nil {
+					t.Fatalf("Write(%d) failed: %v", id, err)
+				}
+			}
+			for id := 0; id < 256; id++ {
+				got, err := oram.Read(id)
+				if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(id)}, 16)) {
+					t.Fatalf("Read(%d) = %v, %v", id, got, err)
+				}
+			}
+			if !cachedLevelsEmpty(t, storage, k) {
+				t.Fatal("blocks written to cached levels in storage")
+			}
+
+			// A single-path access reads each stored bucket twice (path read
+			// and eviction) and never touches the cached ones
+			if tc.strategy != EvictDeterministicTwoPath {
+				before := oram.Stats().BucketReads
+				oram.Read(0)
+				if got := oram.Stats().BucketReads - before; got != uint64(2*(height-k)) {
+					t.Fatalf("bucket reads per access = %d, want %d", got, 2*(height-k))
+				}
+			}
+		})
+	}
+}
+
+func TestCachedLevels_LoadsStoredBlocks(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, CachedLevels: 2}
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total, 4, 16)
+	posMap := NewInMemoryPositionMap()
+
+	// A block left in the root by a session without CachedLevels
+	want := bytes.Repeat([]byte{7}, 16)
+	root, _ := storage.ReadBucket(0)
+	root[0] = Block{ID: 5, Leaf: 3, Data: want}
+	storage.WriteBucket(0, root)
+	posMap.Set(5, 3)
+
+	oram, err := New(cfg, storage, posMap, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if oram.StashSize() != 1 || !cachedLevelsEmpty(t, storage, 2) {
+		t.Fatalf("stash = %d after New, want the root block moved into it", oram.StashSize())
+	}
+	if got, err := oram.Read(5); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("Read(5) = %v, %v", got, err)
+	}
+}
+
+func TestCachedLevels_BulkLoad(t *testing.T) {
+	cfg := Config{NumBlocks: 128, BlockSize: 16, BucketSize: 4, CachedLevels: 2}
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total, 4, 16)
+	oram, _ := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+
+	data := make(map[int][]byte)
+	for id := 0; id < 128; id++ {
+		data[id] = bytes.Repeat([]byte{byte(id)}, 16)
+	}
+	if err := oram.BulkLoad(data); err != nil {
+		t.Fatalf("BulkLoad failed: %v", err)
+	}
+	if !cachedLevelsEmpty(t, storage, 2) {
+		t.Fatal("BulkLoad wrote to cached levels")
+	}
+	for id, want := range data {
+		if got, err := oram.Read(id); err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("Read(%d) = %v, %v", id, got, err)
+		}
+	}
+}
+
+func TestCachedLevels_Invalid(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4}
+	height, _, _ := cfg.ComputeTreeParams()
+	for _, k := range []int{-1, height} {
+		cfg.CachedLevels = k
+		if _, err := cfg.Validate(); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("CachedLevels = %d: err = %v, want ErrInvalidConfig", k, err)
+		}
+	}
+}
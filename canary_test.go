@@ -0,0 +1,101 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newCanaryTestORAM(t *testing.T) (*PathORAM, *InMemoryStorage) {
+	t.Helper()
+	cfg, _ := Config{NumBlocks: 32, BlockSize: 16, StashLimit: 200}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return oram, storage
+}
+
+func TestCanary_Check(t *testing.T) {
+	oram, _ := newCanaryTestORAM(t)
+	ctx := context.Background()
+	c, err := NewCanary(ctx, oram, []int{30, 31})
+	if err != nil {
+		t.Fatalf("NewCanary failed: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := oram.Write(i, make([]byte, 16)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := c.Check(ctx); err != nil {
+			t.Fatalf("Check %d failed: %v", i, err)
+		}
+	}
+	if _, err := NewCanary(ctx, oram, nil); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("NewCanary with no IDs: got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestCanary_DetectsWrongValue(t *testing.T) {
+	oram, storage := newCanaryTestORAM(t)
+	ctx := context.Background()
+	c, err := NewCanary(ctx, oram, []int{5})
+	if err != nil {
+		t.Fatalf("NewCanary failed: %v", err)
+	}
+	if _, err := oram.DrainStash(ctx, 0, 100, nil); err != nil {
+		t.Fatalf("DrainStash failed: %v", err)
+	}
+
+	// A backend that silently flips stored bits without breaking decryption.
+	for _, bucket := range storage.buckets {
+		for i := range bucket {
+			if bucket[i].ID == 5 {
+				bucket[i].Data[0] ^= 0xFF
+			}
+		}
+	}
+	if err := c.Check(ctx); !errors.Is(err, ErrCanaryMismatch) {
+		t.Fatalf("Check: got %v, want ErrCanaryMismatch", err)
+	}
+	if err := c.Check(ctx); err != nil {
+		t.Errorf("Check after rewrite failed: %v", err)
+	}
+}
+
+func TestCanary_Run(t *testing.T) {
+	oram, storage := newCanaryTestORAM(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := NewCanary(ctx, oram, []int{0})
+	if err != nil {
+		t.Fatalf("NewCanary failed: %v", err)
+	}
+	storage.buckets = nil // every subsequent read fails
+
+	alerts := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- c.Run(ctx, time.Millisecond, func(err error) {
+			select {
+			case alerts <- err:
+			default:
+			}
+		})
+	}()
+	select {
+	case err := <-alerts:
+		if err == nil {
+			t.Error("alert with nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert from Run")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,236 @@
+//go:build !pathoram_minimal
+
+package pathoram
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+)
+
+// ObjectStore is an immutable, content-addressed object store.
+// Objects are never overwritten or deleted, which makes it suitable for
+// WORM / object-lock storage.
+type ObjectStore interface {
+	// PutObject stores data under key. Storing the same key twice is a no-op.
+	PutObject(key string, data []byte) error
+
+	// GetObject returns the object stored under key.
+	GetObject(key string) ([]byte, error)
+}
+
+// PointerMap is the small mutable index mapping bucket indices to object keys.
+// Each SetPointer must be atomic: readers see either the old or the new key.
+type PointerMap interface {
+	// GetPointer returns the object key for bucket idx, or ("", false) if unset.
+	GetPointer(idx int) (key string, ok bool, err error)
+
+	// SetPointer atomically points bucket idx at key.
+	SetPointer(idx int, key string) error
+}
+
+// CASStorage implements Storage on top of a content-addressed ObjectStore.
+// Every WriteBucket produces a new immutable object keyed by the SHA-256 of its
+// serialized contents, then updates the bucket's pointer. Superseded objects
+// are left in place; reclaiming them is up to the object store's retention policy.
+type CASStorage struct {
+	objects    ObjectStore
+	pointers   PointerMap
+	numBuckets int
+	bucketSize int
+	blockSize  int
+}
+
+// NewCASStorage creates a CAS-backed storage. Buckets without a pointer read
+// as empty, so a fresh PointerMap needs no initialization pass.
+func NewCASStorage(objects ObjectStore, pointers PointerMap, numBuckets, bucketSize, blockSize int) *CASStorage {
+	return &CASStorage{
+		objects:    objects,
+		pointers:   pointers,
+		numBuckets: numBuckets,
+		bucketSize: bucketSize,
+		blockSize:  blockSize,
+	}
+}
+
+// ReadBucket fetches the object the bucket points to and verifies its hash.
+func (s *CASStorage) ReadBucket(idx int) ([]Block, error) {
+	if idx < 0 || idx >= s.numBuckets {
+		return nil, ErrInvalidConfig
+	}
+	key, ok, err := s.pointers.GetPointer(idx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return emptyBucket(s.bucketSize, s.blockSize), nil
+	}
+	data, err := s.objects.GetObject(key)
+	if err != nil {
+		return nil, err
+	}
+	if casKey(data) != key {
+		return nil, ErrCorruptObject
+	}
+	return decodeBucket(data, s.bucketSize)
+}
+
+// WriteBucket stores the bucket as a new object and repoints idx to it.
+func (s *CASStorage) WriteBucket(idx int, blocks []Block) error {
+	if idx < 0 || idx >= s.numBuckets {
+		return ErrInvalidConfig
+	}
+	if len(blocks) != s.bucketSize {
+		return ErrInvalidConfig
+	}
+	data := encodeBucket(blocks)
+	key := casKey(data)
+	if err := s.objects.PutObject(key, data); err != nil {
+		return err
+	}
+	return s.pointers.SetPointer(idx, key)
+}
+
+// NumBuckets returns the total number of buckets.
+func (s *CASStorage) NumBuckets() int {
+	return s.numBuckets
+}
+
+// BucketSize returns slots per bucket.
+func (s *CASStorage) BucketSize() int {
+	return s.bucketSize
+}
+
+// BlockSize returns bytes per block.
+func (s *CASStorage) BlockSize() int {
+	return s.blockSize
+}
+
+// casKey returns the content address of data.
+func casKey(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// emptyBucket returns a bucket of dummy blocks.
+func emptyBucket(bucketSize, blockSize int) []Block {
+	bucket := make([]Block, bucketSize)
+	for i := range bucket {
+		bucket[i] = Block{ID: EmptyBlockID, Leaf: -1, Data: make([]byte, blockSize)}
+	}
+	return bucket
+}
+
+// encodeBucket serializes a bucket as, per block:
+// ID (int64) || Leaf (int64) || len(Data) (uint32) || Data.
+func encodeBucket(blocks []Block) []byte {
+	size := 0
+	for _, b := range blocks {
+		size += 20 + len(b.Data)
+	}
+	buf := make([]byte, 0, size)
+	for _, b := range blocks {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(int64(b.ID)))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(int64(b.Leaf)))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(b.Data)))
+		buf = append(buf, b.Data...)
+	}
+	return buf
+}
+
+// decodeBucket parses the output of encodeBucket.
+func decodeBucket(buf []byte, bucketSize int) ([]Block, error) {
+	blocks := make([]Block, bucketSize)
+	for i := range blocks {
+		if len(buf) < 20 {
+			return nil, ErrCorruptObject
+		}
+		id := int(int64(binary.LittleEndian.Uint64(buf[0:8])))
+		leaf := int(int64(binary.LittleEndian.Uint64(buf[8:16])))
+		n := int(binary.LittleEndian.Uint32(buf[16:20]))
+		buf = buf[20:]
+		if len(buf) < n {
+			return nil, ErrCorruptObject
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		buf = buf[n:]
+		blocks[i] = Block{ID: id, Leaf: leaf, Data: data}
+	}
+	if len(buf) != 0 {
+		return nil, ErrCorruptObject
+	}
+	return blocks, nil
+}
+
+// InMemoryObjectStore implements ObjectStore using a Go map.
+type InMemoryObjectStore struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+// NewInMemoryObjectStore creates an empty in-memory object store.
+func NewInMemoryObjectStore() *InMemoryObjectStore {
+	return &InMemoryObjectStore{objects: make(map[string][]byte)}
+}
+
+// PutObject stores a copy of data under key unless key already exists.
+func (s *InMemoryObjectStore) PutObject(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[key]; ok {
+		return nil
+	}
+	stored := make([]byte, len(data))
+	copy(stored, data)
+	s.objects[key] = stored
+	return nil
+}
+
+// GetObject returns a copy of the object stored under key.
+func (s *InMemoryObjectStore) GetObject(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	result := make([]byte, len(data))
+	copy(result, data)
+	return result, nil
+}
+
+// Len returns the number of stored objects.
+func (s *InMemoryObjectStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.objects)
+}
+
+// InMemoryPointerMap implements PointerMap using a Go map.
+type InMemoryPointerMap struct {
+	mu   sync.RWMutex
+	ptrs map[int]string
+}
+
+// NewInMemoryPointerMap creates an empty pointer map.
+func NewInMemoryPointerMap() *InMemoryPointerMap {
+	return &InMemoryPointerMap{ptrs: make(map[int]string)}
+}
+
+// GetPointer returns the object key for bucket idx.
+func (p *InMemoryPointerMap) GetPointer(idx int) (string, bool, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.ptrs[idx]
+	return key, ok, nil
+}
+
+// SetPointer points bucket idx at key.
+func (p *InMemoryPointerMap) SetPointer(idx int, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ptrs[idx] = key
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build !pathoram_minimal
+
+package pathoram
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCASStorage_ReadWrite(t *testing.T) {
+	objects := NewInMemoryObjectStore()
+	storage := NewCASStorage(objects, NewInMemoryPointerMap(), 7, 4, 16)
+
+	// Unset buckets read as empty
+	bucket, err := storage.ReadBucket(3)
+	if err != nil {
+		t.Fatalf("ReadBucket failed: %v", err)
+	}
+	for i, b := range bucket {
+		if b.ID != EmptyBlockID {
+			t.Errorf("bucket[%d].ID = %d, want %d", i, b.ID, EmptyBlockID)
+		}
+	}
+
+	bucket[0] = Block{ID: 9, Leaf: 2, Data: bytes.Repeat([]byte{0x42}, 16)}
+	if err := storage.WriteBucket(3, bucket); err != nil {
+		t.Fatalf("WriteBucket failed: %v", err)
+	}
+	got, err := storage.ReadBucket(3)
+	if err != nil {
+		t.Fatalf("ReadBucket failed: %v", err)
+	}
+	if got[0].ID != 9 || got[0].Leaf != 2 || !bytes.Equal(got[0].Data, bucket[0].Data) {
+		t.Errorf("bucket[0] = %+v, want %+v", got[0], bucket[0])
+	}
+	if got[1].ID != EmptyBlockID || got[1].Leaf != -1 {
+		t.Errorf("bucket[1] = %+v, want empty", got[1])
+	}
+
+	// Each distinct write produces a new immutable object
+	bucket[1] = Block{ID: 10, Leaf: 3, Data: make([]byte, 16)}
+	if err := storage.WriteBucket(3, bucket); err != nil {
+		t.Fatalf("WriteBucket failed: %v", err)
+	}
+	if objects.Len() != 2 {
+		t.Errorf("object count = %d, want 2", objects.Len())
+	}
+
+	if err := storage.WriteBucket(7, bucket); err != ErrInvalidConfig {
+		t.Errorf("WriteBucket out of range error = %v, want ErrInvalidConfig", err)
+	}
+}
+
+// tamperingObjectStore returns modified data to simulate a corrupt backend.
+type tamperingObjectStore struct {
+	*InMemoryObjectStore
+}
+
+func (s tamperingObjectStore) GetObject(key string) ([]byte, error) {
+	data, err := s.InMemoryObjectStore.GetObject(key)
+	if err == nil && len(data) > 0 {
+		data[len(data)-1] ^= 0xFF
+	}
+	return data, err
+}
+
+func TestCASStorage_DetectsCorruption(t *testing.T) {
+	objects := tamperingObjectStore{NewInMemoryObjectStore()}
+	storage := NewCASStorage(objects, NewInMemoryPointerMap(), 3, 2, 8)
+
+	if err := storage.WriteBucket(0, emptyBucket(2, 8)); err != nil {
+		t.Fatalf("WriteBucket failed: %v", err)
+	}
+	if _, err := storage.ReadBucket(0); err != ErrCorruptObject {
+		t.Errorf("ReadBucket error = %v, want ErrCorruptObject", err)
+	}
+}
+
+func TestCASStorage_WithORAM(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4}
+	cfg, _ = cfg.Validate()
+	_, _, totalBuckets := cfg.ComputeTreeParams()
+
+	storage := NewCASStorage(NewInMemoryObjectStore(), NewInMemoryPointerMap(), totalBuckets, cfg.BucketSize, cfg.BlockSize)
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for i := 0; i < 32; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 32)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	for i := 0; i < 32; i++ {
+		got, err := oram.Read(i)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 32)) {
+			t.Errorf("Read(%d) mismatch", i)
+		}
+	}
+}
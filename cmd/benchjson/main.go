@@ -0,0 +1,107 @@
+// Command benchjson converts `go test -bench` output into machine-readable JSON
+// and compares two such reports, flagging regressions beyond a threshold.
+//
+// Usage:
+//
+//	go test -run=^$ -bench=. -benchmem ./... | benchjson convert > new.json
+//	benchjson compare -threshold 10 old.json new.json
+//
+// compare exits with status 1 when any shared benchmark regresses by more than
+// the threshold (in percent) on a tracked metric.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "convert":
+		err = runConvert(os.Stdin, os.Stdout)
+	case "compare":
+		var regressed bool
+		regressed, err = runCompare(os.Args[2:], os.Stdout)
+		if err == nil && regressed {
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "benchjson:", err)
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: benchjson convert < bench.txt > report.json")
+	fmt.Fprintln(os.Stderr, "       benchjson compare [-threshold pct] old.json new.json")
+}
+
+func runConvert(r io.Reader, w io.Writer) error {
+	report, err := ParseBenchOutput(r)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func runCompare(args []string, w io.Writer) (bool, error) {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	threshold := fs.Float64("threshold", 10, "regression threshold in percent")
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+	if fs.NArg() != 2 {
+		return false, fmt.Errorf("compare needs exactly two report files")
+	}
+
+	oldReport, err := readReport(fs.Arg(0))
+	if err != nil {
+		return false, err
+	}
+	newReport, err := readReport(fs.Arg(1))
+	if err != nil {
+		return false, err
+	}
+
+	regressions := Compare(oldReport, newReport, *threshold)
+	for _, r := range regressions {
+		fmt.Fprintf(w, "REGRESSION %s %s: %.4g -> %.4g (%+.1f%%)\n",
+			r.Name, r.Metric, r.Old, r.New, r.DeltaPct)
+	}
+	if len(regressions) == 0 {
+		fmt.Fprintf(w, "no regressions beyond %.1f%%\n", *threshold)
+	}
+	return len(regressions) > 0, nil
+}
+
+func readReport(path string) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var report Report
+	if err := json.NewDecoder(f).Decode(&report); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if report.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("%s: schema version %d, want %d", path, report.SchemaVersion, SchemaVersion)
+	}
+	return &report, nil
+}
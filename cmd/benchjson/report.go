@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is bumped whenever the JSON layout changes incompatibly.
+const SchemaVersion = 1
+
+// Report is the top-level JSON document.
+type Report struct {
+	SchemaVersion int         `json:"schema_version"`
+	GoOS          string      `json:"goos,omitempty"`
+	GoArch        string      `json:"goarch,omitempty"`
+	CPU           string      `json:"cpu,omitempty"`
+	Benchmarks    []Benchmark `json:"benchmarks"`
+}
+
+// Benchmark holds the results of a single benchmark line.
+type Benchmark struct {
+	Name        string             `json:"name"`
+	Iterations  int64              `json:"iterations"`
+	NsPerOp     float64            `json:"ns_per_op"`
+	OpsPerSec   float64            `json:"ops_per_sec"`
+	BytesPerOp  float64            `json:"bytes_per_op"`  // allocated bytes (-benchmem)
+	AllocsPerOp float64            `json:"allocs_per_op"` // allocations (-benchmem)
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Regression describes a metric that got worse beyond the threshold.
+type Regression struct {
+	Name     string
+	Metric   string
+	Old, New float64
+	DeltaPct float64
+}
+
+// gomaxprocsSuffix matches the "-8" suffix go test appends to benchmark names.
+var gomaxprocsSuffix = regexp.MustCompile(`-\d+$`)
+
+// ParseBenchOutput parses the text output of `go test -bench`.
+// Non-benchmark lines are ignored.
+func ParseBenchOutput(r io.Reader) (*Report, error) {
+	report := &Report{SchemaVersion: SchemaVersion, Benchmarks: []Benchmark{}}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "goos: "):
+			report.GoOS = strings.TrimPrefix(line, "goos: ")
+		case strings.HasPrefix(line, "goarch: "):
+			report.GoArch = strings.TrimPrefix(line, "goarch: ")
+		case strings.HasPrefix(line, "cpu: "):
+			report.CPU = strings.TrimPrefix(line, "cpu: ")
+		case strings.HasPrefix(line, "Benchmark"):
+			if b, ok := parseBenchLine(line); ok {
+				report.Benchmarks = append(report.Benchmarks, b)
+			}
+		}
+	}
+	return report, sc.Err()
+}
+
+// parseBenchLine parses "BenchmarkX-8  100  123 ns/op  45 B/op  2 allocs/op  3 max_stash".
+func parseBenchLine(line string) (Benchmark, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || len(fields)%2 != 0 {
+		return Benchmark{}, false
+	}
+	iters, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return Benchmark{}, false
+	}
+
+	b := Benchmark{
+		Name:       gomaxprocsSuffix.ReplaceAllString(fields[0], ""),
+		Iterations: iters,
+	}
+	for i := 2; i+1 < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return Benchmark{}, false
+		}
+		switch unit := fields[i+1]; unit {
+		case "ns/op":
+			b.NsPerOp = v
+			if v > 0 {
+				b.OpsPerSec = 1e9 / v
+			}
+		case "B/op":
+			b.BytesPerOp = v
+		case "allocs/op":
+			b.AllocsPerOp = v
+		default:
+			if b.Metrics == nil {
+				b.Metrics = make(map[string]float64)
+			}
+			b.Metrics[unit] = v
+		}
+	}
+	return b, true
+}
+
+// lowerIsBetter lists custom metrics where an increase is a regression.
+// Custom metrics not listed here are reported but never flagged.
+var lowerIsBetter = map[string]bool{
+	"max_stash":    true,
+	"final_stash":  true,
+	"bytes/access": true,
+}
+
+// Compare returns regressions of newReport relative to oldReport for
+// benchmarks present in both. thresholdPct is the allowed worsening in percent.
+func Compare(oldReport, newReport *Report, thresholdPct float64) []Regression {
+	old := make(map[string]Benchmark, len(oldReport.Benchmarks))
+	for _, b := range oldReport.Benchmarks {
+		old[b.Name] = b
+	}
+
+	var out []Regression
+	check := func(name, metric string, o, n float64) {
+		if o <= 0 {
+			return
+		}
+		delta := (n - o) / o * 100
+		if delta > thresholdPct {
+			out = append(out, Regression{Name: name, Metric: metric, Old: o, New: n, DeltaPct: delta})
+		}
+	}
+
+	for _, nb := range newReport.Benchmarks {
+		ob, ok := old[nb.Name]
+		if !ok {
+			continue
+		}
+		check(nb.Name, "ns/op", ob.NsPerOp, nb.NsPerOp)
+		check(nb.Name, "B/op", ob.BytesPerOp, nb.BytesPerOp)
+		check(nb.Name, "allocs/op", ob.AllocsPerOp, nb.AllocsPerOp)
+
+		metrics := make([]string, 0, len(nb.Metrics))
+		for m := range nb.Metrics {
+			metrics = append(metrics, m)
+		}
+		sort.Strings(metrics)
+		for _, m := range metrics {
+			if lowerIsBetter[m] {
+				check(nb.Name, m, ob.Metrics[m], nb.Metrics[m])
+			}
+		}
+	}
+	return out
+}
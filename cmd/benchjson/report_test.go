@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleOutput = `goos: linux
+goarch: amd64
+pkg: github.com/etclab/pathoram-go
+cpu: Test CPU
+BenchmarkStashSizeByStrategy/LevelByLevel-8         	   10000	    120000 ns/op	        12.00 max_stash	  4096 B/op	      40 allocs/op
+BenchmarkAccess/blocks=64/blockSize=256/read-8      	   50000	     25000 ns/op
+PASS
+ok  	github.com/etclab/pathoram-go	1.234s
+`
+
+func TestParseBenchOutput(t *testing.T) {
+	report, err := ParseBenchOutput(strings.NewReader(sampleOutput))
+	if err != nil {
+		t.Fatalf("ParseBenchOutput failed: %v", err)
+	}
+	if report.SchemaVersion != SchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", report.SchemaVersion, SchemaVersion)
+	}
+	if report.GoOS != "linux" || report.CPU != "Test CPU" {
+		t.Errorf("header = (%q, %q), want (linux, Test CPU)", report.GoOS, report.CPU)
+	}
+	if len(report.Benchmarks) != 2 {
+		t.Fatalf("got %d benchmarks, want 2", len(report.Benchmarks))
+	}
+
+	b := report.Benchmarks[0]
+	if b.Name != "BenchmarkStashSizeByStrategy/LevelByLevel" {
+		t.Errorf("Name = %q, want suffix stripped", b.Name)
+	}
+	if b.Iterations != 10000 || b.NsPerOp != 120000 || b.BytesPerOp != 4096 || b.AllocsPerOp != 40 {
+		t.Errorf("parsed %+v", b)
+	}
+	if b.Metrics["max_stash"] != 12 {
+		t.Errorf("max_stash = %v, want 12", b.Metrics["max_stash"])
+	}
+	if want := 1e9 / 120000; b.OpsPerSec != want {
+		t.Errorf("OpsPerSec = %v, want %v", b.OpsPerSec, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	oldReport := &Report{Benchmarks: []Benchmark{
+		{Name: "A", NsPerOp: 100, Metrics: map[string]float64{"max_stash": 10}},
+		{Name: "B", NsPerOp: 100},
+	}}
+	newReport := &Report{Benchmarks: []Benchmark{
+		{Name: "A", NsPerOp: 105, Metrics: map[string]float64{"max_stash": 20}},
+		{Name: "B", NsPerOp: 150},
+		{Name: "C", NsPerOp: 1000},
+	}}
+
+	regs := Compare(oldReport, newReport, 10)
+	if len(regs) != 2 {
+		t.Fatalf("got %d regressions, want 2: %+v", len(regs), regs)
+	}
+	if regs[0].Name != "A" || regs[0].Metric != "max_stash" {
+		t.Errorf("regs[0] = %+v, want A max_stash", regs[0])
+	}
+	if regs[1].Name != "B" || regs[1].Metric != "ns/op" {
+		t.Errorf("regs[1] = %+v, want B ns/op", regs[1])
+	}
+}
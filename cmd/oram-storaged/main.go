@@ -0,0 +1,66 @@
+// Command oram-storaged serves an ORAM bucket store from a file over the
+// REST protocol of package httpstorage.
+//
+// Usage:
+//
+//	oram-storaged -file /var/lib/oram/buckets -buckets 255 -bucket-size 4 -block-size 4124 -addr :8080
+//
+// The file holds fixed-size records, one per bucket, and is created on first
+// start; reopening it with other dimensions is an error. -block-size is the
+// stored block size: the ORAM's BlockSize plus its Encryptor's Overhead.
+// Clients connect with httpstorage.Dial. The server sees only ciphertext;
+// put TLS and authentication in front of it as the deployment requires.
+// -compression gzips bucket responses for clients that ask; see package
+// httpstorage for what that reveals on the wire.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/etclab/pathoram-go/filestorage"
+	"github.com/etclab/pathoram-go/httpstorage"
+)
+
+func main() {
+	file := flag.String("file", "", "bucket file (created if missing)")
+	addr := flag.String("addr", ":8080", "listen address")
+	numBuckets := flag.Int("buckets", 0, "number of buckets")
+	bucketSize := flag.Int("bucket-size", 4, "block slots per bucket")
+	blockSize := flag.Int("block-size", 0, "bytes per stored block")
+	noSync := flag.Bool("no-sync", false, "skip fsync after writes")
+	compression := flag.Int("compression", 0, "gzip level for responses to clients that accept it (0 = off, -2 = Huffman only)")
+	flag.Parse()
+
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "oram-storaged: -file is required")
+		os.Exit(2)
+	}
+	s, err := filestorage.Open(*file, *numBuckets, *bucketSize, *blockSize)
+	if err != nil {
+		log.Fatalf("oram-storaged: %v", err)
+	}
+	s.NoSync = *noSync
+	defer s.Close()
+
+	h := httpstorage.NewHandler(s)
+	h.Compression = *compression
+	srv := &http.Server{Addr: *addr, Handler: h}
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		srv.Shutdown(context.Background())
+	}()
+	log.Printf("oram-storaged: serving %d buckets from %s on %s", *numBuckets, *file, *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("oram-storaged: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Command pathoram-bench benchmarks PathORAM configurations.
+//
+// Usage:
+//
+//	pathoram-bench -workload sessions.json -n 65536 -block-size 4096 -z 4 -strategy greedy
+//	pathoram-bench sweep -n 4096,65536 -z 3,4,5 -strategy level,greedy -read-ratio 0.9 -dist zipfian -ops 20000
+//	pathoram-bench sweep -n 65536 -storage memory -rtt 10ms -bandwidth 125000000 -format json
+//	pathoram-bench sweep -n 65536 -block-size 4096 -storage http://localhost:8080
+//	pathoram-bench sweep -n 65536 -z 3,4 -trace accesses.csv
+//
+// Without a subcommand it runs a scripted workload (see package workload)
+// against an in-memory PathORAM and prints per-phase throughput and latency
+// as JSON. Run it once per parameter set to compare configurations under the
+// same workload; fix "seed" in the workload file to replay identical key
+// sequences.
+//
+// The sweep subcommand needs no workload file. -n, -block-size, -z and
+// -strategy take comma-separated lists; every combination is run in turn and
+// printed as one CSV or JSON row: throughput, latency percentiles, stash
+// high-water mark and storage bandwidth per operation. Each configuration is
+// first filled with one sequential write per block (disable with -load=false)
+// and then measured under the -read-ratio (or -mix) and -dist workload, or by
+// replaying the -trace CSV (see workload.ParseTrace); only the measured phase
+// is reported.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/workload"
+)
+
+// Output is the JSON document written to stdout.
+type Output struct {
+	Workload   string            `json:"workload"`
+	NumBlocks  int               `json:"num_blocks"`
+	BlockSize  int               `json:"block_size"`
+	BucketSize int               `json:"bucket_size"`
+	Strategy   string            `json:"strategy"`
+	Encrypted  bool              `json:"encrypted"`
+	Phases     []workload.Result `json:"phases"`
+	Stats      pathoram.Stats    `json:"stats"`
+}
+
+var strategies = map[string]pathoram.EvictionStrategy{
+	"level":    pathoram.EvictLevelByLevel,
+	"greedy":   pathoram.EvictGreedyByDepth,
+	"two-path": pathoram.EvictDeterministicTwoPath,
+}
+
+func main() {
+	run, args := runWorkload, os.Args[1:]
+	if len(args) > 0 && args[0] == "sweep" {
+		run, args = runSweep, args[1:]
+	}
+	if err := run(args); err != nil {
+		fmt.Fprintln(os.Stderr, "pathoram-bench:", err)
+		os.Exit(1)
+	}
+}
+
+// runWorkload runs a workload file once against one configuration.
+func runWorkload(args []string) error {
+	fs := flag.NewFlagSet("pathoram-bench", flag.ExitOnError)
+	path := fs.String("workload", "", "workload JSON file (required)")
+	n := fs.Int("n", 1<<14, "number of blocks")
+	blockSize := fs.Int("block-size", 256, "bytes per block")
+	z := fs.Int("z", 4, "blocks per bucket")
+	stashLimit := fs.Int("stash-limit", 0, "stash limit (0 = library default)")
+	strategy := fs.String("strategy", "level", "eviction strategy: level, greedy, two-path")
+	encrypt := fs.Bool("encrypt", true, "encrypt blocks with AES-256-GCM")
+	fs.Parse(args)
+	if *path == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	spec, err := workload.Load(*path)
+	if err != nil {
+		return err
+	}
+	oram, err := newORAM(*n, *blockSize, *z, *stashLimit, *strategy, *encrypt)
+	if err != nil {
+		return err
+	}
+	defer oram.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := workload.Run(ctx, oram, spec)
+	if err != nil {
+		return err
+	}
+	out := Output{
+		Workload:   spec.Name,
+		NumBlocks:  *n,
+		BlockSize:  *blockSize,
+		BucketSize: *z,
+		Strategy:   *strategy,
+		Encrypted:  *encrypt,
+		Phases:     results,
+		Stats:      oram.Stats(),
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// newORAM builds the instance under test from command-line parameters.
+func newORAM(n, blockSize, z, stashLimit int, strategy string, encrypt bool) (*pathoram.PathORAM, error) {
+	s, ok := strategies[strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q", strategy)
+	}
+	opts := []pathoram.Option{
+		pathoram.WithCapacity(n, blockSize),
+		pathoram.WithBucketSize(z),
+		pathoram.WithStrategy(s),
+	}
+	if stashLimit > 0 {
+		opts = append(opts, pathoram.WithStashLimit(stashLimit))
+	}
+	if encrypt {
+		key := make([]byte, 32)
+		rand.Read(key)
+		enc, err := pathoram.NewAESGCMEncryptor(key)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, pathoram.WithEncryptor(enc))
+	}
+	return pathoram.NewORAM(opts...)
+}
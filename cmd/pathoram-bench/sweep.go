@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/httpstorage"
+	"github.com/etclab/pathoram-go/workload"
+)
+
+// Row is the result for one configuration.
+type Row struct {
+	NumBlocks         int     `json:"num_blocks"`
+	BlockSize         int     `json:"block_size"`
+	BucketSize        int     `json:"bucket_size"`
+	Strategy          string  `json:"strategy"`
+	Storage           string  `json:"storage"`
+	Ops               int     `json:"ops"`
+	Errors            int     `json:"errors"`
+	OpsPerSec         float64 `json:"ops_per_sec"`
+	P50               string  `json:"p50"`
+	P99               string  `json:"p99"`
+	Max               string  `json:"max"`
+	StashHighWater    int     `json:"stash_high_water"`
+	StashFinal        int     `json:"stash_final"`
+	BytesReadPerOp    float64 `json:"bytes_read_per_op"`
+	BytesWrittenPerOp float64 `json:"bytes_written_per_op"`
+}
+
+var csvHeader = []string{
+	"num_blocks", "block_size", "bucket_size", "strategy", "storage", "ops", "errors",
+	"ops_per_sec", "p50", "p99", "max", "stash_high_water", "stash_final",
+	"bytes_read_per_op", "bytes_written_per_op",
+}
+
+func (r Row) csv() []string {
+	return []string{
+		strconv.Itoa(r.NumBlocks), strconv.Itoa(r.BlockSize), strconv.Itoa(r.BucketSize),
+		r.Strategy, r.Storage, strconv.Itoa(r.Ops), strconv.Itoa(r.Errors),
+		strconv.FormatFloat(r.OpsPerSec, 'f', 1, 64), r.P50, r.P99, r.Max,
+		strconv.Itoa(r.StashHighWater), strconv.Itoa(r.StashFinal),
+		strconv.FormatFloat(r.BytesReadPerOp, 'f', 1, 64),
+		strconv.FormatFloat(r.BytesWrittenPerOp, 'f', 1, 64),
+	}
+}
+
+// params is one point of the sweep.
+type params struct {
+	n, blockSize, z int
+	strategy        string
+}
+
+// settings are the flags shared by every point of the sweep.
+type settings struct {
+	storage     string
+	compression int
+	rtt         time.Duration
+	bandwidth   int64
+	stashLimit  int
+	encrypt     bool
+	load        bool
+	phase       workload.Phase
+	seed        uint64
+}
+
+// runSweep implements the sweep subcommand.
+func runSweep(args []string) error {
+	fs := flag.NewFlagSet("pathoram-bench sweep", flag.ExitOnError)
+	ns := fs.String("n", "16384", "number of blocks (comma-separated list)")
+	blockSizes := fs.String("block-size", "256", "bytes per block (comma-separated list)")
+	zs := fs.String("z", "4", "blocks per bucket (comma-separated list)")
+	strats := fs.String("strategy", "level", "eviction strategies: level, greedy, two-path (comma-separated list)")
+	var s settings
+	fs.StringVar(&s.storage, "storage", "memory", `storage backend: "memory" or an oram-storaged URL`)
+	fs.IntVar(&s.compression, "compression", 0, "gzip level for an oram-storaged URL (0 = off, -2 = Huffman only)")
+	fs.DurationVar(&s.rtt, "rtt", 0, "simulated round-trip time added to every storage request")
+	fs.Int64Var(&s.bandwidth, "bandwidth", 0, "simulated storage bandwidth in bytes/second (0 = unlimited)")
+	fs.IntVar(&s.stashLimit, "stash-limit", 0, "stash limit (0 = library default)")
+	fs.BoolVar(&s.encrypt, "encrypt", true, "encrypt blocks with AES-256-GCM")
+	fs.BoolVar(&s.load, "load", true, "write every block once before measuring")
+	fs.Float64Var(&s.phase.ReadRatio, "read-ratio", 0.9, "fraction of operations that are reads")
+	fs.StringVar(&s.phase.Mix, "mix", "", "named read ratio overriding -read-ratio: read-only, read-mostly, update-heavy, write-only")
+	fs.StringVar(&s.phase.Keys.Distribution, "dist", workload.Uniform, "key distribution: uniform, zipfian, sequential")
+	fs.StringVar(&s.phase.Trace, "trace", "", "CSV access trace to replay instead of -read-ratio and -dist")
+	fs.Float64Var(&s.phase.Keys.S, "zipf-s", 1.1, "zipfian skew, > 1")
+	fs.IntVar(&s.phase.Ops, "ops", 10000, "operations to measure (ignored with -duration)")
+	duration := fs.Duration("duration", 0, "measure for this long instead of -ops operations")
+	fs.IntVar(&s.phase.Concurrency, "concurrency", 1, "concurrent workers")
+	fs.Uint64Var(&s.seed, "seed", 0, "workload seed (0 = random)")
+	format := fs.String("format", "csv", "output format: csv or json")
+	fs.Parse(args)
+
+	if *duration > 0 {
+		s.phase.Ops = 0
+		s.phase.Duration = workload.Duration(*duration)
+	}
+	if s.phase.Mix != "" || s.phase.Trace != "" {
+		s.phase.ReadRatio = 0
+	}
+	if s.phase.Trace != "" {
+		s.phase.Keys = workload.Keys{}
+		ops, err := workload.LoadTrace(s.phase.Trace)
+		if err != nil {
+			return err
+		}
+		s.phase.TraceOps = ops
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	points, err := grid(*ns, *blockSizes, *zs, *strats)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var rows []Row
+	for _, p := range points {
+		row, err := benchPoint(ctx, p, s)
+		if err != nil {
+			return fmt.Errorf("n=%d block-size=%d z=%d strategy=%s: %w", p.n, p.blockSize, p.z, p.strategy, err)
+		}
+		rows = append(rows, row)
+	}
+	return write(os.Stdout, *format, rows)
+}
+
+// grid returns the cross product of the list flags.
+func grid(ns, blockSizes, zs, strats string) ([]params, error) {
+	nList, err := ints("n", ns)
+	if err != nil {
+		return nil, err
+	}
+	bList, err := ints("block-size", blockSizes)
+	if err != nil {
+		return nil, err
+	}
+	zList, err := ints("z", zs)
+	if err != nil {
+		return nil, err
+	}
+	var points []params
+	for _, n := range nList {
+		for _, b := range bList {
+			for _, z := range zList {
+				for _, st := range strings.Split(strats, ",") {
+					if _, ok := strategies[st]; !ok {
+						return nil, fmt.Errorf("unknown strategy %q", st)
+					}
+					points = append(points, params{n: n, blockSize: b, z: z, strategy: st})
+				}
+			}
+		}
+	}
+	return points, nil
+}
+
+// ints parses a comma-separated list of positive integers.
+func ints(name, list string) ([]int, error) {
+	var out []int
+	for _, f := range strings.Split(list, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("-%s: %q is not a positive integer", name, f)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// benchPoint builds the instance for p, loads it and measures one phase.
+func benchPoint(ctx context.Context, p params, s settings) (Row, error) {
+	oram, err := newSweepORAM(ctx, p, s)
+	if err != nil {
+		return Row{}, err
+	}
+	defer oram.Close()
+
+	if s.load {
+		load := &workload.Spec{Seed: s.seed, Phases: []workload.Phase{{
+			Name: "load", Ops: p.n, Keys: workload.Keys{Distribution: workload.Sequential},
+		}}}
+		if err := load.Validate(); err != nil {
+			return Row{}, err
+		}
+		if _, err := workload.Run(ctx, oram, load); err != nil {
+			return Row{}, err
+		}
+	}
+
+	phase := s.phase
+	phase.Name = "measure"
+	spec := &workload.Spec{Seed: s.seed, Phases: []workload.Phase{phase}}
+	if err := spec.Validate(); err != nil {
+		return Row{}, err
+	}
+	before := oram.Stats()
+	results, err := workload.Run(ctx, oram, spec)
+	if err != nil {
+		return Row{}, err
+	}
+	after := oram.Stats()
+	res := results[0]
+	ops := max(res.Ops, 1)
+	return Row{
+		NumBlocks:         p.n,
+		BlockSize:         p.blockSize,
+		BucketSize:        p.z,
+		Strategy:          p.strategy,
+		Storage:           s.storage,
+		Ops:               res.Ops,
+		Errors:            res.Errors,
+		OpsPerSec:         res.OpsPerSec,
+		P50:               res.P50.String(),
+		P99:               res.P99.String(),
+		Max:               res.Max.String(),
+		StashHighWater:    after.StashHighWater,
+		StashFinal:        oram.StashSize(),
+		BytesReadPerOp:    float64(after.BytesRead-before.BytesRead) / float64(ops),
+		BytesWrittenPerOp: float64(after.BytesWritten-before.BytesWritten) / float64(ops),
+	}, nil
+}
+
+// newSweepORAM builds the instance under test for p on the -storage backend.
+func newSweepORAM(ctx context.Context, p params, s settings) (*pathoram.PathORAM, error) {
+	cfg, err := pathoram.Config{NumBlocks: p.n, BlockSize: p.blockSize, BucketSize: p.z}.Validate()
+	if err != nil {
+		return nil, err
+	}
+	opts := []pathoram.Option{
+		pathoram.WithCapacity(p.n, p.blockSize),
+		pathoram.WithBucketSize(p.z),
+		pathoram.WithStrategy(strategies[p.strategy]),
+	}
+	if s.stashLimit > 0 {
+		opts = append(opts, pathoram.WithStashLimit(s.stashLimit))
+	}
+	stored := p.blockSize
+	if s.encrypt {
+		key := make([]byte, 32)
+		rand.Read(key)
+		enc, err := pathoram.NewAESGCMEncryptor(key)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, pathoram.WithEncryptor(enc))
+		stored += enc.Overhead()
+	}
+
+	_, _, buckets := cfg.ComputeTreeParams()
+	var storage pathoram.Storage
+	switch {
+	case s.storage == "memory":
+		storage = pathoram.NewInMemoryStorage(buckets, p.z, stored)
+	case strings.HasPrefix(s.storage, "http://"), strings.HasPrefix(s.storage, "https://"):
+		c, err := httpstorage.Dial(ctx, s.storage, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.Compression = s.compression
+		if c.NumBuckets() < buckets || c.BucketSize() != p.z || c.BlockSize() != stored {
+			return nil, fmt.Errorf("%s holds %d buckets of %d x %d bytes; this configuration needs %d of %d x %d",
+				s.storage, c.NumBuckets(), c.BucketSize(), c.BlockSize(), buckets, p.z, stored)
+		}
+		storage = c
+	default:
+		return nil, fmt.Errorf("unknown storage %q", s.storage)
+	}
+	if s.rtt > 0 || s.bandwidth > 0 {
+		storage = pathoram.NewDelayedStorage(storage, s.rtt, s.bandwidth)
+	}
+	return pathoram.NewORAM(append(opts, pathoram.WithStorage(storage))...)
+}
+
+// write prints rows in format.
+func write(w io.Writer, format string, rows []Row) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, r := range rows {
+		cw.Write(r.csv())
+	}
+	cw.Flush()
+	return cw.Error()
+}
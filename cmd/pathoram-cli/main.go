@@ -0,0 +1,236 @@
+// Command pathoram-cli provides operator commands for PathORAM instances
+// and persistent stores.
+//
+// Usage:
+//
+//	pathoram-cli drain-stash -socket /run/app/oram.sock -target 10 -max-passes 5000
+//	pathoram-cli stats -socket /run/app/oram.sock
+//	pathoram-cli health -socket /run/app/oram.sock
+//	pathoram-cli set-strategy -socket /run/app/oram.sock -strategy greedy-by-depth
+//	pathoram-cli call -socket /run/app/oram.sock -command rotate-key -args '{"key_id":"k2"}'
+//
+//	pathoram-cli init -dir /var/lib/oram -n 65536 -block-size 4096 -z 4
+//	pathoram-cli init -dir /var/lib/oram -n 65536 -block-size 4096 -storage http://storage:8080
+//	pathoram-cli put -dir /var/lib/oram -id 7 < block.bin
+//	pathoram-cli get -dir /var/lib/oram -id 7 > block.bin
+//	pathoram-cli del -dir /var/lib/oram -id 7
+//	pathoram-cli export -dir /var/lib/oram -file backup.oram
+//	pathoram-cli import -dir /var/lib/oram -file backup.oram
+//	pathoram-cli fsck -dir /var/lib/oram
+//	pathoram-cli stats -dir /var/lib/oram
+//	pathoram-cli rotate-key -dir /var/lib/oram
+//
+// The first group talks to a live instance's admin socket (see package
+// admin). drain-stash performs eviction passes until the stash is at or
+// below target, printing progress, and exits with status 1 if the target
+// was not reached. health exits with status 1 unless the instance reports
+// "ok".
+//
+// The second group operates a persistent store directly; stats takes either
+// -socket or -dir. A store is a directory holding store.json (dimensions,
+// storage location and KeyMetadata, no secrets), the master key in
+// master.key unless -key names another file, and, for local storage, the
+// bucket file. The buckets live in a local file (package filestorage) or,
+// with -storage, behind an oram-storaged proxy of the dimensions init
+// reports. The position map is saved encrypted in buckets past the end of
+// the tree (see StoredPositionMap), and every command flushes the stash
+// before exiting, so no client state is kept outside the store.
+//
+// Keys are derived from the master key with a KeyManager. rotate-key
+// rebuilds the tree under the next key generation into a new bucket file
+// (or, for remote storage, the empty store named by -new-storage) and then
+// switches store.json to it.
+//
+// Exports are encrypted under the store's bucket key. import loads one into
+// an empty store with the same keys: create it with init -keys-from naming
+// the exporting store and the same master key. Exports taken before a
+// rotation can no longer be imported.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/etclab/pathoram-go/admin"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "drain-stash":
+		err = drainStash(os.Args[2:])
+	case "stats":
+		err = stats(ctx, os.Args[2:])
+	case "health":
+		err = health(os.Args[2:])
+	case "set-strategy":
+		err = setStrategy(os.Args[2:])
+	case "call":
+		err = call(os.Args[2:], "")
+	case "init":
+		err = initStore(ctx, os.Args[2:])
+	case "put":
+		err = put(ctx, os.Args[2:])
+	case "get":
+		err = get(ctx, os.Args[2:])
+	case "del":
+		err = del(ctx, os.Args[2:])
+	case "export":
+		err = export(ctx, os.Args[2:])
+	case "import":
+		err = importStore(ctx, os.Args[2:])
+	case "fsck":
+		err = fsck(ctx, os.Args[2:])
+	case "rotate-key":
+		err = rotateKey(ctx, os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pathoram-cli:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pathoram-cli drain-stash -socket PATH [-target N] [-max-passes N]")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli stats -socket PATH | -dir DIR [-key FILE]")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli health -socket PATH")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli set-strategy -socket PATH -strategy NAME")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli call -socket PATH -command NAME [-args JSON]")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli init -dir DIR -n N -block-size B [-z Z] [-strategy NAME] [-storage URL] [-keys-from DIR] [-key FILE]")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli put|get|del -dir DIR -id ID [-key FILE]")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli export|import -dir DIR -file FILE [-key FILE]")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli fsck -dir DIR [-key FILE]")
+	fmt.Fprintln(os.Stderr, "       pathoram-cli rotate-key -dir DIR [-new-storage URL] [-key FILE]")
+}
+
+// call sends a single command and prints its JSON result. If command is empty,
+// it is taken from the -command flag.
+func call(args []string, command string) error {
+	fs := flag.NewFlagSet("call", flag.ExitOnError)
+	socket := fs.String("socket", "", "admin socket of the running instance")
+	cmdFlag := fs.String("command", "", "command name (call only)")
+	argsFlag := fs.String("args", "", "JSON arguments (call only)")
+	fs.Parse(args)
+
+	if *socket == "" {
+		return fmt.Errorf("-socket is required")
+	}
+	req := admin.Request{Command: command}
+	if command == "" {
+		if *cmdFlag == "" {
+			return fmt.Errorf("-command is required")
+		}
+		req.Command = *cmdFlag
+		if *argsFlag != "" {
+			req.Args = json.RawMessage(*argsFlag)
+		}
+	}
+
+	c, err := admin.Dial(*socket)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	raw, err := c.Do(req, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(raw))
+	return nil
+}
+
+func health(args []string) error {
+	fs := flag.NewFlagSet("health", flag.ExitOnError)
+	socket := fs.String("socket", "", "admin socket of the running instance")
+	fs.Parse(args)
+
+	if *socket == "" {
+		return fmt.Errorf("-socket is required")
+	}
+	c, err := admin.Dial(*socket)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	h, err := c.Health()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("status=%s stash=%d/%d blocks=%d/%d\n", h.Status, h.StashSize, h.StashLimit, h.Size, h.Capacity)
+	if h.Status != admin.HealthOK {
+		return fmt.Errorf("instance is not healthy")
+	}
+	return nil
+}
+
+func setStrategy(args []string) error {
+	fs := flag.NewFlagSet("set-strategy", flag.ExitOnError)
+	socket := fs.String("socket", "", "admin socket of the running instance")
+	strategy := fs.String("strategy", "", "level-by-level, greedy-by-depth or deterministic-two-path")
+	fs.Parse(args)
+
+	if *socket == "" || *strategy == "" {
+		return fmt.Errorf("-socket and -strategy are required")
+	}
+	c, err := admin.Dial(*socket)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	res, err := c.SetStrategy(*strategy)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("strategy %s -> %s\n", res.Previous, res.Strategy)
+	return nil
+}
+
+func drainStash(args []string) error {
+	fs := flag.NewFlagSet("drain-stash", flag.ExitOnError)
+	socket := fs.String("socket", "", "admin socket of the running instance")
+	target := fs.Int("target", 0, "stash size to drain down to")
+	maxPasses := fs.Int("max-passes", 1000, "maximum number of eviction passes")
+	quiet := fs.Bool("q", false, "suppress per-pass progress")
+	fs.Parse(args)
+
+	if *socket == "" {
+		return fmt.Errorf("-socket is required")
+	}
+
+	c, err := admin.Dial(*socket)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	res, err := c.DrainStash(*target, *maxPasses, func(p admin.Progress) {
+		if !*quiet {
+			fmt.Printf("pass %d: stash=%d\n", p.Pass, p.StashSize)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("done: %d passes, stash=%d, target=%d\n", res.Passes, res.StashSize, *target)
+	if !res.Reached {
+		return fmt.Errorf("stash still above target after %d passes", res.Passes)
+	}
+	return nil
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/admin"
+	"github.com/etclab/pathoram-go/filestorage"
+	"github.com/etclab/pathoram-go/httpstorage"
+)
+
+const (
+	storeFile = "store.json"
+	keyFile   = "master.key"
+)
+
+// storeConfig is the content of store.json.
+type storeConfig struct {
+	NumBlocks  int                  `json:"num_blocks"`
+	BlockSize  int                  `json:"block_size"`
+	BucketSize int                  `json:"bucket_size"`
+	Strategy   string               `json:"strategy"`
+	Storage    string               `json:"storage"` // bucket file in the store directory, or URL
+	Keys       pathoram.KeyMetadata `json:"keys"`
+}
+
+// storeFlags registers the flags every command takes.
+func storeFlags(name string) (fs *flag.FlagSet, dir, key *string) {
+	fs = flag.NewFlagSet(name, flag.ExitOnError)
+	dir = fs.String("dir", "", "store directory (required)")
+	key = fs.String("key", "", "master key file (default: DIR/master.key)")
+	return fs, dir, key
+}
+
+// parse parses args and checks that -dir is set.
+func parse(fs *flag.FlagSet, args []string, dir *string) error {
+	fs.Parse(args)
+	if *dir == "" {
+		return errors.New("-dir is required")
+	}
+	return nil
+}
+
+func keyPath(dir, key string) string {
+	if key == "" {
+		return filepath.Join(dir, keyFile)
+	}
+	return key
+}
+
+func initStore(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("init")
+	n := fs.Int("n", 0, "number of blocks (required)")
+	blockSize := fs.Int("block-size", 0, "bytes per block (required)")
+	z := fs.Int("z", 4, "blocks per bucket")
+	strategy := fs.String("strategy", pathoram.EvictLevelByLevel.String(), "eviction strategy")
+	storage := fs.String("storage", "", "oram-storaged URL (default: a bucket file in DIR)")
+	keysFrom := fs.String("keys-from", "", "store directory whose keys to share, so its exports can be imported")
+	if err := parse(fs, args, dir); err != nil {
+		return err
+	}
+	if _, err := pathoram.ParseEvictionStrategy(*strategy); err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(*dir, storeFile)); err == nil {
+		return fmt.Errorf("%s already holds a store", *dir)
+	}
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		return err
+	}
+
+	master, err := os.ReadFile(keyPath(*dir, *key))
+	if errors.Is(err, os.ErrNotExist) {
+		master = make([]byte, 32)
+		rand.Read(master)
+		err = os.WriteFile(keyPath(*dir, *key), master, 0o600)
+	}
+	if err != nil {
+		return err
+	}
+	defer clear(master)
+	var km *pathoram.KeyManager
+	if *keysFrom != "" {
+		var from *storeConfig
+		if from, err = readStoreConfig(*keysFrom); err == nil {
+			km, err = pathoram.OpenKeyManager(master, from.Keys)
+		}
+	} else {
+		km, err = pathoram.NewKeyManager(master, nil)
+	}
+	if err != nil {
+		return err
+	}
+	defer km.Wipe()
+
+	sc := &storeConfig{
+		NumBlocks:  *n,
+		BlockSize:  *blockSize,
+		BucketSize: *z,
+		Strategy:   *strategy,
+		Storage:    *storage,
+		Keys:       km.Metadata(),
+	}
+	if sc.Storage == "" {
+		sc.Storage = bucketFile(sc.Keys.Generation)
+	}
+	o, s, err := open(ctx, *dir, sc, km)
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(s.close(ctx, o), o.Close()); err != nil {
+		return err
+	}
+	if err := writeStoreConfig(*dir, sc); err != nil {
+		return err
+	}
+	fmt.Printf("initialized %s: %d buckets of %d x %d bytes\n", *dir, s.buckets, sc.BucketSize, s.stored)
+	return nil
+}
+
+func put(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("put")
+	id := fs.Int("id", -1, "block ID (required)")
+	if err := parse(fs, args, dir); err != nil {
+		return err
+	}
+	return withStore(ctx, *dir, *key, func(o *pathoram.PathORAM, s *store) error {
+		size := s.sc.BlockSize
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, int64(size)+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > size {
+			return fmt.Errorf("%w: input exceeds the %d-byte block size", pathoram.ErrInvalidDataSize, size)
+		}
+		data = append(data, make([]byte, size-len(data))...)
+		_, err = o.WriteCtx(ctx, *id, data)
+		return err
+	})
+}
+
+func get(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("get")
+	id := fs.Int("id", -1, "block ID (required)")
+	if err := parse(fs, args, dir); err != nil {
+		return err
+	}
+	return withStore(ctx, *dir, *key, func(o *pathoram.PathORAM, s *store) error {
+		data, err := o.ReadCtx(ctx, *id)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	})
+}
+
+func del(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("del")
+	id := fs.Int("id", -1, "block ID (required)")
+	if err := parse(fs, args, dir); err != nil {
+		return err
+	}
+	return withStore(ctx, *dir, *key, func(o *pathoram.PathORAM, s *store) error {
+		return o.DeleteCtx(ctx, *id)
+	})
+}
+
+func export(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("export")
+	file := fs.String("file", "", "export file (required)")
+	if err := parse(fs, args, dir); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+	return withStore(ctx, *dir, *key, func(o *pathoram.PathORAM, s *store) error {
+		f, err := os.OpenFile(*file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		if err := o.ExportCtx(ctx, f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+func importStore(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("import")
+	file := fs.String("file", "", "export file (required)")
+	if err := parse(fs, args, dir); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+	return withStore(ctx, *dir, *key, func(o *pathoram.PathORAM, s *store) error {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return o.ImportCtx(ctx, f)
+	})
+}
+
+func fsck(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("fsck")
+	if err := parse(fs, args, dir); err != nil {
+		return err
+	}
+	return withStore(ctx, *dir, *key, func(o *pathoram.PathORAM, s *store) error {
+		report, err := o.CheckInvariants()
+		if err != nil {
+			return err
+		}
+		for _, p := range report.Problems {
+			fmt.Println(p)
+		}
+		fmt.Printf("%d blocks in tree, %d in stash, %d problems\n", report.TreeBlocks, report.StashBlocks, len(report.Problems))
+		return report.Err()
+	})
+}
+
+// Stats is the JSON document the stats command prints for a store.
+type Stats struct {
+	NumBlocks     int    `json:"num_blocks"`
+	BlockSize     int    `json:"block_size"`
+	BucketSize    int    `json:"bucket_size"`
+	Strategy      string `json:"strategy"`
+	Storage       string `json:"storage"`
+	Height        int    `json:"height"`
+	TreeBuckets   int    `json:"tree_buckets"`
+	StoredBuckets int    `json:"stored_buckets"`
+	Allocated     int    `json:"allocated_blocks"`
+	StashSize     int    `json:"stash_size"`
+	StashLimit    int    `json:"stash_limit"`
+	KeyGeneration uint32 `json:"key_generation"`
+	KeyID         string `json:"key_fingerprint"`
+}
+
+// stats prints a running instance's stats from its admin socket, or a
+// store's from its directory.
+func stats(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("stats")
+	socket := fs.String("socket", "", "admin socket of the running instance")
+	fs.Parse(args)
+	switch {
+	case *socket != "" && *dir != "":
+		return errors.New("-socket and -dir are exclusive")
+	case *socket != "":
+		return call([]string{"-socket", *socket}, admin.CmdStats)
+	case *dir == "":
+		return errors.New("-socket or -dir is required")
+	}
+	return withStore(ctx, *dir, *key, func(o *pathoram.PathORAM, s *store) error {
+		sc := s.sc
+		cfg, _ := sc.config().Validate()
+		height, _, tree := cfg.ComputeTreeParams()
+		allocated := 0
+		for range o.Blocks() {
+			allocated++
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(Stats{
+			NumBlocks:     sc.NumBlocks,
+			BlockSize:     sc.BlockSize,
+			BucketSize:    sc.BucketSize,
+			Strategy:      sc.Strategy,
+			Storage:       sc.Storage,
+			Height:        height,
+			TreeBuckets:   tree,
+			StoredBuckets: s.buckets,
+			Allocated:     allocated,
+			StashSize:     o.StashSize(),
+			StashLimit:    o.StashLimit(),
+			KeyGeneration: sc.Keys.Generation,
+			KeyID:         hex.EncodeToString(sc.Keys.Fingerprint),
+		})
+	})
+}
+
+func rotateKey(ctx context.Context, args []string) error {
+	fs, dir, key := storeFlags("rotate-key")
+	newStorage := fs.String("new-storage", "", "empty oram-storaged URL to rebuild into (remote stores only)")
+	if err := parse(fs, args, dir); err != nil {
+		return err
+	}
+	sc, km, err := load(*dir, *key)
+	if err != nil {
+		return err
+	}
+	defer km.Wipe()
+	o, old, err := open(ctx, *dir, sc, km)
+	if err != nil {
+		return err
+	}
+	defer o.Close()
+
+	next := km.Rotate()
+	defer next.Wipe()
+	nsc := *sc
+	nsc.Keys = next.Metadata()
+	switch {
+	case isURL(sc.Storage) && *newStorage == "":
+		return errors.New("-new-storage is required to rotate the key of a remote store")
+	case *newStorage != "":
+		nsc.Storage = *newStorage
+	default:
+		// Left over by an interrupted rotation; store.json still names the
+		// current file.
+		nsc.Storage = bucketFile(nsc.Keys.Generation)
+		if err := os.Remove(filepath.Join(*dir, nsc.Storage)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	s, err := newStore(ctx, *dir, &nsc, next)
+	if err != nil {
+		return err
+	}
+	if err := o.RebuildCtx(ctx, nsc.config(), s.storage, s.posMap, s.enc); err != nil {
+		closeStorage(s.storage)
+		return err
+	}
+	if err := errors.Join(s.close(ctx, o), o.Close()); err != nil {
+		return err
+	}
+	if err := writeStoreConfig(*dir, &nsc); err != nil {
+		return err
+	}
+	closeStorage(old.storage)
+	if !isURL(sc.Storage) {
+		os.Remove(filepath.Join(*dir, sc.Storage))
+	}
+	fmt.Printf("rotated %s to key generation %d\n", *dir, nsc.Keys.Generation)
+	return nil
+}
+
+// withStore opens the store in dir, calls fn, and saves the store.
+func withStore(ctx context.Context, dir, key string, fn func(*pathoram.PathORAM, *store) error) error {
+	sc, km, err := load(dir, key)
+	if err != nil {
+		return err
+	}
+	defer km.Wipe()
+	o, s, err := open(ctx, dir, sc, km)
+	if err != nil {
+		return err
+	}
+	err = fn(o, s)
+	return errors.Join(err, s.close(ctx, o), o.Close())
+}
+
+// load reads store.json and the master key.
+func load(dir, key string) (*storeConfig, *pathoram.KeyManager, error) {
+	sc, err := readStoreConfig(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	master, err := os.ReadFile(keyPath(dir, key))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer clear(master)
+	km, err := pathoram.OpenKeyManager(master, sc.Keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sc, km, nil
+}
+
+func readStoreConfig(dir string) (*storeConfig, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, storeFile))
+	if err != nil {
+		return nil, err
+	}
+	var sc storeConfig
+	if err := json.Unmarshal(raw, &sc); err != nil {
+		return nil, fmt.Errorf("%s: %w", storeFile, err)
+	}
+	return &sc, nil
+}
+
+func writeStoreConfig(dir string, sc *storeConfig) error {
+	raw, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, storeFile+".tmp")
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, storeFile))
+}
+
+func (sc *storeConfig) config() pathoram.Config {
+	s, _ := pathoram.ParseEvictionStrategy(sc.Strategy)
+	return pathoram.Config{
+		NumBlocks:        sc.NumBlocks,
+		BlockSize:        sc.BlockSize,
+		BucketSize:       sc.BucketSize,
+		EvictionStrategy: s,
+		StrictReads:      true,
+	}
+}
+
+func bucketFile(generation uint32) string {
+	return fmt.Sprintf("buckets.%d", generation)
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// store is an opened bucket store with its position map.
+type store struct {
+	sc      *storeConfig
+	storage pathoram.Storage
+	posMap  *pathoram.StoredPositionMap
+	enc     pathoram.Encryptor
+	buckets int // tree plus position map buckets
+	stored  int // stored block size
+}
+
+// newStore opens the storage sc names and a position map for it, without
+// loading the map.
+func newStore(ctx context.Context, dir string, sc *storeConfig, km *pathoram.KeyManager) (*store, error) {
+	cfg, err := sc.config().Validate()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := km.Encryptor()
+	if err != nil {
+		return nil, err
+	}
+	pmKey, err := km.Key(pathoram.KeyPositionMap)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(pmKey)
+	pmEnc, err := pathoram.NewAESGCMEncryptor(pmKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, tree := cfg.ComputeTreeParams()
+	s := &store{
+		sc:      sc,
+		enc:     enc,
+		buckets: tree + pathoram.PositionMapBuckets(cfg, pmEnc),
+		stored:  cfg.BlockSize + enc.Overhead(),
+		posMap:  pathoram.NewStoredPositionMap(pathoram.NewInMemoryPositionMap(), cfg.NumBlocks, tree, pmEnc),
+	}
+	if isURL(sc.Storage) {
+		c, err := httpstorage.Dial(ctx, sc.Storage, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w (the store needs %d buckets of %d x %d bytes)", err, s.buckets, cfg.BucketSize, s.stored)
+		}
+		if c.NumBuckets() != s.buckets || c.BucketSize() != cfg.BucketSize || c.BlockSize() != s.stored {
+			return nil, fmt.Errorf("%w: %s holds %d buckets of %d x %d bytes; the store needs %d of %d x %d",
+				pathoram.ErrInvalidConfig, sc.Storage, c.NumBuckets(), c.BucketSize(), c.BlockSize(), s.buckets, cfg.BucketSize, s.stored)
+		}
+		s.storage = c
+		return s, nil
+	}
+	fst, err := filestorage.Open(filepath.Join(dir, sc.Storage), s.buckets, cfg.BucketSize, s.stored)
+	if err != nil {
+		return nil, err
+	}
+	s.storage = fst
+	return s, nil
+}
+
+// open opens the store and an ORAM over it with the saved position map.
+func open(ctx context.Context, dir string, sc *storeConfig, km *pathoram.KeyManager) (*pathoram.PathORAM, *store, error) {
+	s, err := newStore(ctx, dir, sc, km)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.posMap.LoadFrom(s.storage); err != nil && !errors.Is(err, pathoram.ErrNoPositionMap) {
+		closeStorage(s.storage)
+		return nil, nil, err
+	}
+	o, err := pathoram.New(sc.config(), s.storage, s.posMap, s.enc)
+	if err != nil {
+		closeStorage(s.storage)
+		return nil, nil, err
+	}
+	return o, s, nil
+}
+
+func closeStorage(s pathoram.Storage) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// close flushes the stash into the tree and saves the position map, so the
+// store holds all client state.
+func (s *store) close(ctx context.Context, o *pathoram.PathORAM) error {
+	if err := o.Flush(ctx); err != nil {
+		return err
+	}
+	return s.posMap.SaveTo(s.storage)
+}
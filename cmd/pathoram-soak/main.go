@@ -0,0 +1,148 @@
+// Command pathoram-soak repeats a scripted workload (see package workload)
+// against an encrypted in-memory PathORAM for a long period, checking every
+// read against a shadow copy of the data.
+//
+// Usage:
+//
+//	pathoram-soak -workload sessions.json -duration 2h -n 65536 -block-size 4096
+//
+// Each pass of the workload prints one JSON line with per-phase results and
+// the current stash size. The command exits with status 1 on the first pass
+// that saw a wrong value or a failed access.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/workload"
+)
+
+// errMismatch reports a read that returned something other than the last
+// value written.
+var errMismatch = errors.New("read returned unexpected data")
+
+// checkingTarget mirrors every write in memory and verifies reads against it.
+// The ORAM serializes accesses anyway, so holding mu across each access does
+// not change throughput.
+type checkingTarget struct {
+	*pathoram.PathORAM
+	mu     sync.Mutex
+	shadow map[int][]byte
+}
+
+func (c *checkingTarget) ReadCtx(ctx context.Context, id int) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	got, err := c.PathORAM.ReadCtx(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	want, ok := c.shadow[id]
+	if !ok {
+		want = make([]byte, c.BlockSize())
+	}
+	if !bytes.Equal(got, want) {
+		return got, fmt.Errorf("%w: block %d", errMismatch, id)
+	}
+	return got, nil
+}
+
+func (c *checkingTarget) WriteCtx(ctx context.Context, id int, data []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old, err := c.PathORAM.WriteCtx(ctx, id, data)
+	if err != nil {
+		// The write may have been applied; stop trusting this block.
+		delete(c.shadow, id)
+		return nil, err
+	}
+	c.shadow[id] = bytes.Clone(data)
+	return old, nil
+}
+
+// Pass is one JSON line of output.
+type Pass struct {
+	Pass      int               `json:"pass"`
+	Elapsed   time.Duration     `json:"elapsed_ns"`
+	StashSize int               `json:"stash_size"`
+	Phases    []workload.Result `json:"phases"`
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "pathoram-soak:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	fs := flag.NewFlagSet("pathoram-soak", flag.ExitOnError)
+	path := fs.String("workload", "", "workload JSON file (required)")
+	duration := fs.Duration("duration", time.Hour, "total soak time")
+	n := fs.Int("n", 1<<14, "number of blocks")
+	blockSize := fs.Int("block-size", 256, "bytes per block")
+	z := fs.Int("z", 4, "blocks per bucket")
+	fs.Parse(os.Args[1:])
+	if *path == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	spec, err := workload.Load(*path)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	enc, err := pathoram.NewAESGCMEncryptor(key)
+	if err != nil {
+		return err
+	}
+	oram, err := pathoram.NewORAM(
+		pathoram.WithCapacity(*n, *blockSize),
+		pathoram.WithBucketSize(*z),
+		pathoram.WithEncryptor(enc),
+	)
+	if err != nil {
+		return err
+	}
+	defer oram.Close()
+	target := &checkingTarget{PathORAM: oram, shadow: make(map[int][]byte)}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	out := json.NewEncoder(os.Stdout)
+	start := time.Now()
+	for pass := 1; ctx.Err() == nil; pass++ {
+		results, err := workload.Run(ctx, target, spec)
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		if err := out.Encode(Pass{Pass: pass, Elapsed: time.Since(start), StashSize: oram.StashSize(), Phases: results}); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			break // the final pass was cut short by the deadline
+		}
+		for _, r := range results {
+			if r.Errors > 0 {
+				return fmt.Errorf("pass %d phase %q: %d failed operations, first: %s", pass, r.Phase, r.Errors, r.FirstError)
+			}
+		}
+	}
+	return nil
+}
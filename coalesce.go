@@ -0,0 +1,68 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// flight is a read in progress that other readers of the same block can join.
+type flight struct {
+	done    chan struct{} // closed once data and err are set
+	data    []byte
+	err     error
+	waiters int // readers that joined; guarded by flightMu
+}
+
+// coalescedRead performs or joins a read of blockID. The leader runs the
+// access and, after publishing its result, owes one dummy access for every
+// reader that joined, keeping the number of accesses equal to the number of
+// requests.
+func (o *PathORAM) coalescedRead(ctx context.Context, blockID int) ([]byte, error) {
+	o.flightMu.Lock()
+	if f, ok := o.flights[blockID]; ok {
+		f.waiters++
+		o.flightMu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			// The leader still pays for this reader's dummy access.
+			return nil, ctx.Err()
+		}
+		if f.err != nil {
+			return nil, f.err
+		}
+		o.noteNamespace(ctx, false)
+		return bytes.Clone(f.data), nil
+	}
+	if o.flights == nil {
+		o.flights = make(map[int]*flight)
+	}
+	f := &flight{done: make(chan struct{})}
+	o.flights[blockID] = f
+	o.flightMu.Unlock()
+
+	f.data, f.err = o.observedAccess(ctx, blockID, nil, nil)
+
+	o.flightMu.Lock()
+	delete(o.flights, blockID)
+	owed := f.waiters
+	o.flightMu.Unlock()
+	close(f.done)
+
+	if owed == 0 {
+		return f.data, f.err
+	}
+	// Later readers start a new flight, so the owed count is final. The
+	// dummies run even if ctx is cancelled: skipping them would reveal that
+	// reads were coalesced.
+	dctx := context.WithoutCancel(ctx)
+	var derr error
+	for range owed {
+		derr = errors.Join(derr, o.dummyAccess(dctx))
+	}
+	if err := errors.Join(f.err, derr); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(f.data), nil
+}
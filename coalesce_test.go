@@ -0,0 +1,82 @@
+package pathoram
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gateStorage blocks ReadBucket calls until gate is closed, once armed.
+type gateStorage struct {
+	*InMemoryStorage
+	armed atomic.Bool
+	gate  chan struct{}
+}
+
+func (s *gateStorage) ReadBucket(idx int) ([]Block, error) {
+	if s.armed.Load() {
+		<-s.gate
+	}
+	return s.InMemoryStorage.ReadBucket(idx)
+}
+
+func TestCoalesceReads(t *testing.T) {
+	cfg, _ := Config{NumBlocks: 32, BlockSize: 16, StashLimit: 200, CoalesceReads: true}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := &gateStorage{InMemoryStorage: NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize), gate: make(chan struct{})}
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	want := bytes.Repeat([]byte{7}, 16)
+	if _, err := oram.Write(3, want); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	storage.armed.Store(true) // the first reader blocks holding the lock
+
+	const readers = 5
+	results := make([][]byte, readers)
+	errs := make([]error, readers)
+	var wg sync.WaitGroup
+	for i := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = oram.Read(3)
+		}()
+	}
+
+	// Wait until one reader leads a flight and the rest have joined it.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		oram.flightMu.Lock()
+		f := oram.flights[3]
+		joined := f != nil && f.waiters == readers-1
+		oram.flightMu.Unlock()
+		if joined {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("readers did not coalesce")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(storage.gate)
+	wg.Wait()
+
+	for i := range readers {
+		if errs[i] != nil || !bytes.Equal(results[i], want) {
+			t.Errorf("reader %d: got %v, %v", i, results[i], errs[i])
+		}
+	}
+	// One write, one real read, and one dummy per joined reader.
+	if got := oram.Stats().Accesses; got != 1+readers {
+		t.Errorf("Accesses = %d, want %d", got, 1+readers)
+	}
+	results[0][0] ^= 0xFF
+	if !bytes.Equal(results[1], want) {
+		t.Error("coalesced readers share a result buffer")
+	}
+}
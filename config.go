@@ -1,17 +1,54 @@
 package pathoram
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/bits"
+	"time"
+)
 
 // EmptyBlockID marks a block slot as empty/dummy.
 const EmptyBlockID = -1
 
+// MaxBlockSize is the largest supported Config.BlockSize (1 GiB). Blocks over
+// a few hundred KiB should use SegmentedEncryptor.
+const MaxBlockSize = 1 << 30
+
+// MaxMetadataSize is the largest supported Config.MetadataSize.
+const MaxMetadataSize = 1024
+
 var (
-	ErrInvalidConfig    = errors.New("invalid PathORAM configuration")
-	ErrInvalidBlockID   = errors.New("invalid block ID")
-	ErrInvalidDataSize  = errors.New("data size doesn't match block size")
-	ErrStashOverflow    = errors.New("stash overflow")
-	ErrEncryptionFailed = errors.New("block encryption failed")
-	ErrDecryptionFailed = errors.New("block decryption failed")
+	ErrInvalidConfig      = errors.New("invalid PathORAM configuration")
+	ErrInvalidBlockID     = errors.New("invalid block ID")
+	ErrInvalidDataSize    = errors.New("data size doesn't match block size")
+	ErrStashOverflow      = errors.New("stash overflow")
+	ErrEncryptionFailed   = errors.New("block encryption failed")
+	ErrDecryptionFailed   = errors.New("block decryption failed")
+	ErrObjectNotFound     = errors.New("object not found")
+	ErrCorruptObject      = errors.New("stored object is corrupt")
+	ErrRollbackDetected   = errors.New("storage rolled back to an earlier state")
+	ErrCounterTampered    = errors.New("access counter authentication failed")
+	ErrNotEmpty           = errors.New("ORAM already contains blocks")
+	ErrAccessDenied       = errors.New("access denied")
+	ErrReplicationGap     = errors.New("replication update out of sequence")
+	ErrKeyNotFound        = errors.New("key not found")
+	ErrNoSpace            = errors.New("not enough free blocks")
+	ErrUnknownSchema      = errors.New("unknown payload schema or version")
+	ErrIntegrityViolation = errors.New("bucket integrity check failed")
+	ErrStaleBucket        = errors.New("storage returned a stale bucket version")
+	ErrCanaryMismatch     = errors.New("canary block returned an unexpected value")
+	ErrNegativeOffset     = errors.New("negative offset")
+	ErrWrongKey           = errors.New("encryption key does not match storage")
+	ErrInvalidSignature   = errors.New("bundle signature verification failed")
+	ErrEmpty              = errors.New("queue or stack is empty")
+	ErrNotFlushed         = errors.New("stash not fully evicted")
+	ErrClosed             = errors.New("ORAM is closed")
+	ErrNoPositionMap      = errors.New("no saved position map")
+	ErrStreamTooLong      = errors.New("stream does not fit in the given blocks")
+	ErrInvariantViolated  = errors.New("ORAM state violates Path ORAM invariants")
+	ErrBlockNotFound      = errors.New("block has never been written")
 )
 
 // EvictionStrategy defines how blocks are evicted from stash to tree.
@@ -31,6 +68,30 @@ const (
 	EvictDeterministicTwoPath
 )
 
+// String returns the strategy's name.
+func (s EvictionStrategy) String() string {
+	switch s {
+	case EvictLevelByLevel:
+		return "level-by-level"
+	case EvictGreedyByDepth:
+		return "greedy-by-depth"
+	case EvictDeterministicTwoPath:
+		return "deterministic-two-path"
+	default:
+		return fmt.Sprintf("EvictionStrategy(%d)", int(s))
+	}
+}
+
+// ParseEvictionStrategy returns the strategy whose String is name.
+func ParseEvictionStrategy(name string) (EvictionStrategy, error) {
+	for s := EvictLevelByLevel; s <= EvictDeterministicTwoPath; s++ {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown eviction strategy %q", ErrInvalidConfig, name)
+}
+
 // Config holds PathORAM configuration parameters.
 type Config struct {
 	NumBlocks        int              // Total number of blocks to support (valid IDs: 0 to NumBlocks-1)
@@ -39,32 +100,331 @@ type Config struct {
 	StashLimit       int              // Maximum stash size before error
 	EvictionStrategy EvictionStrategy // Eviction strategy to use
 	ConstantTime     bool             // Enable constant-time operations for TEE deployments
+	StatsCollector   StatsCollector   // Optional receiver for per-access events
+	Logger           *slog.Logger     // Optional logger for failed accesses (nil = silent)
+	Authorizer       Authorizer       // Optional per-operation access control hook
+	Replicator       Replicator       // Optional receiver for client-state updates (warm standby)
+	Stash            Stash            // Optional store of record for the stash (default: in memory only)
+	NamespaceStats   *NamespaceStats  // Optional per-principal real/dummy access tracking
+	FetchParallelism int              // Max concurrent bucket reads per path read (0 or 1 = sequential)
+	Trace            TraceRecorder    // Optional receiver for each access's bucket reads and writes
+
+	// BackgroundEviction defers each access's eviction to a background
+	// goroutine, so Access returns after the path read and stash update.
+	// Call Close to stop the goroutine and flush pending evictions.
+	BackgroundEviction bool
+
+	// MaxDirtyBuckets buffers up to this many written buckets in client
+	// memory; a background goroutine writes them to storage, many per
+	// StorageV2.WriteBuckets call, while accesses continue. Reads of a
+	// buffered bucket are served from the buffer. A write that would exceed
+	// the bound first flushes the buffer inline. Storage must allow a
+	// WriteBuckets call concurrent with reads of other buckets. A failed
+	// background write keeps its buckets buffered for retry and is returned
+	// by the next access; Flush and Close write everything out. Until then
+	// storage lags the client, so a crash loses the buffered buckets. Not
+	// supported with embedded mode, SingleBlockReads,
+	// VerifyIntegrity, Config.Stash or replay protection, whose state would
+	// reach storage ahead of the buckets (0 = write through).
+	MaxDirtyBuckets int
+
+	// CryptoParallelism is the number of goroutines that encrypt the blocks
+	// an eviction writes back. 0 picks GOMAXPROCS for AESGCMEncryptor and
+	// SegmentedEncryptor with blocks of at least 1 KiB, and 1 (encrypt in
+	// place) otherwise; set it above 1 only for encryptors safe for
+	// concurrent use. Buckets are written in the same order and with the
+	// same contents either way. Ignored when Rand supplies the encryptor's
+	// nonces, since they are drawn from that one stream.
+	CryptoParallelism int
+
+	// VerifyIntegrity maintains a Merkle tree over buckets and checks every
+	// bucket read against it, returning ErrIntegrityViolation if storage
+	// returns tampered or stale data. Storage must implement IntegrityStorage.
+	// IntegrityRoot is the trusted root from a previous session (see
+	// PathORAM.IntegrityRoot); if nil, current storage contents are trusted.
+	VerifyIntegrity bool
+	IntegrityRoot   []byte
+
+	// VersionKey (at least 32 bytes) enables per-bucket version counters:
+	// every stored slot carries a 16-byte tag binding it to its bucket's
+	// write count, and reading an earlier copy of a bucket returns
+	// ErrStaleBucket. The counters live on the client; BucketVersions
+	// restores them from a previous session (see PathORAM.BucketVersions).
+	VersionKey     []byte
+	BucketVersions []uint64
+
+	// EncryptHeaders hides each slot's block ID and leaf from storage. They
+	// are encrypted with the slot's data, which for dummy slots is fresh
+	// random padding, and every stored slot has ID EmptyBlockID and Leaf -1,
+	// so the server cannot tell real blocks from dummies. It costs a second
+	// encryption per slot and headerSize plus the encryptor's overhead per
+	// stored block; size storage with StoredBlockSize. Requires an
+	// authenticated Encryptor. XOR path reads are not supported, and Fsck,
+	// which has no key, cannot check such a tree.
+	EncryptHeaders bool
+
+	// RandomizeDummies rewrites every dummy slot of every bucket written
+	// with a fresh encryption of random padding (see DummyEncryptor),
+	// instead of the stale ciphertext it held or zeros, so each write
+	// re-randomizes all BucketSize slots. Block IDs stay in the clear unless
+	// EncryptHeaders is also set, which implies this. Requires an
+	// authenticated Encryptor; XOR path reads are not supported.
+	RandomizeDummies bool
+
+	// Rand is the source of leaf assignments and, for the built-in
+	// encryptors passed to New, nonces (default: crypto/rand). Set it to
+	// NewSeededRand(seed) for reproducible tests and simulations; never use
+	// a predictable source in production.
+	Rand io.Reader
+
+	// LeafAssigner, if set, chooses the leaf each block is remapped to
+	// instead of a uniform draw from Rand. Non-uniform assignments break
+	// obliviousness; see LeafAssigner. Not allowed with a ThreatModel.
+	LeafAssigner LeafAssigner
+
+	// CoalesceReads serves concurrent reads of the same block from one ORAM
+	// access. Each reader that joins an in-flight read is answered as soon
+	// as that access completes, and the reader that performed it then issues
+	// one dummy access per joined reader, so the server still sees one
+	// access per request.
+	CoalesceReads bool
+
+	// StrictReads makes Read and ReadCtx of a block that was never written
+	// (or was deleted, with a DeletablePositionMap; see PathORAM.DeleteCtx)
+	// return ErrBlockNotFound instead of zeros, without
+	// allocating a position for it. The ORAM still performs a dummy access,
+	// so the server cannot tell the two outcomes apart. Strict reads are not
+	// coalesced.
+	StrictReads bool
+
+	// SingleBlockReads splits each access's path read in two, as Ring ORAM
+	// does: an online phase reads one slot per bucket, the block's own slot
+	// where it is and a uniformly random one elsewhere, and the full path is
+	// read only for the eviction. The bytes needed before the block is known
+	// drop by about BucketSize, at the cost of one extra block per bucket in
+	// total. The client locates slots with its own record of each bucket's
+	// slot IDs, about one int per slot of the tree once every bucket has
+	// been written, and reads a bucket it has no record of (after New) in
+	// full. Buckets are written in a fresh random slot order, so the slots
+	// read online are uniform whichever block is wanted.
+	//
+	// Requires EncryptHeaders, so a slot read reveals nothing of its
+	// contents, and storage implementing SlotStorage; VerifyIntegrity,
+	// VersionKey and Stash are not supported.
+	SingleBlockReads bool
+
+	// XORKey (16, 24 or 32 bytes) turns the online phase of SingleBlockReads
+	// into one block of bandwidth with Ring ORAM's XOR technique: the server
+	// returns the XOR of the slots read rather than the slots, and the
+	// client cancels the dummies among them, which it recomputes from this
+	// key, leaving the wanted block. For that, every bucket read online
+	// needs a dummy slot, so buckets are stored with one spare slot
+	// (StoredBucketSize), and every empty slot holds a dummy derived from
+	// the key, the bucket, the slot and a write counter instead of a random
+	// encryption. Keep the key secret and with the encryption key; a
+	// client reopening the tree needs it to recognize the dummies.
+	//
+	// Requires SingleBlockReads and storage implementing XORCapableStorage
+	// with BucketSize+1 slots per bucket. Off by default (nil), whether or
+	// not the storage implements XORCapableStorage.
+	XORKey []byte
+
+	// ReuseBuffers recycles plaintext and ciphertext block buffers through
+	// internal pools instead of allocating per block per access. Slices
+	// returned by Read, Write and Access still belong to the caller; passing
+	// them to PathORAM.ReleaseBuffer lets the ORAM reuse them, after which
+	// the caller must not touch them. Storage backends must not retain the
+	// Data slices passed to WriteBucket after it returns (InMemoryStorage
+	// copies them). Ciphertexts are pooled only for encryptors implementing
+	// BufferedEncryptor.
+	ReuseBuffers bool
+
+	// ThreatModel selects a security preset (see ThreatModel). It turns on
+	// VerifyIntegrity and ConstantTime as the model requires, and New
+	// rejects encryptors and missing keys that do not meet it.
+	ThreatModel ThreatModel
+
+	// UniformAccessTime pads every access (Read, Write, Access, DummyAccess;
+	// Copy and Move count as two) to this wall-clock duration, so a network
+	// observer of the ORAM client cannot learn from response times. Accesses
+	// that take longer return late and are counted in Stats.PadOverruns.
+	// AutoCalibrate makes New perform dummy accesses and use their 99th
+	// percentile (see CalibrateAccessTime).
+	UniformAccessTime time.Duration
+
+	// CacheBlocks keeps the plaintext of up to CacheBlocks recently
+	// accessed blocks in client memory (LRU). A Read, or Access without
+	// new data, of a cached block returns without waiting for storage; a
+	// dummy access is then performed in the background, so the server still
+	// sees one access per request. CacheSkipDummy omits the dummy access,
+	// saving its bandwidth but letting the server count cache hits, and so
+	// learn about temporal locality. Cache hits also return faster than
+	// UniformAccessTime.
+	CacheBlocks    int
+	CacheSkipDummy bool
+
+	// MetadataSize reserves a fixed-size metadata field, such as a version
+	// number or type tag, stored and encrypted with each block's data but
+	// not counted in BlockSize. ReadMeta, WriteMeta and AccessMeta read and
+	// set it; Read, Write and Access see only the data and keep a block's
+	// metadata. Unwritten metadata reads as zeros. Storage blocks and
+	// SegmentedEncryptor's block size grow by MetadataSize bytes.
+	MetadataSize int
+
+	// CachedLevels keeps the top CachedLevels levels of the tree (they are on
+	// every path) in client memory: their blocks stay in the stash, and path
+	// reads and evictions skip those buckets, saving their storage round trips
+	// and re-encryption. The stash may then hold up to BucketSize blocks per
+	// cached bucket on top of StashLimit. Must be less than the tree height;
+	// New moves any blocks already stored in the cached levels into the stash.
+	CachedLevels int
+
+	// SecureDelete zeroizes a block's plaintext buffer as soon as the block
+	// is evicted from the stash (and buffers passed to ReleaseBuffer), makes
+	// Delete overwrite blocks with random bytes instead of zeros, and makes
+	// Close zeroize the stash and block cache and wipe the encryptor (see
+	// Wiper for what that does and does not erase).
+	SecureDelete bool
+
+	// UtilizationTarget is the largest fraction of the tree's slots
+	// (BucketSize per bucket) that NumBlocks may fill; the tree is the
+	// smallest with NumBlocks ≤ UtilizationTarget × slots. The default,
+	// DefaultUtilization, follows the Path ORAM paper's N ≤ Z·2^L guidance
+	// and keeps stash overflows rare. FullUtilization sizes the tree to just
+	// fit NumBlocks, as releases before this field did: trees and storage
+	// files created then need it to be reopened.
+	UtilizationTarget float64
+
+	// Height and NumLeaves fix the tree's shape instead of deriving it from
+	// NumBlocks, BucketSize and UtilizationTarget, e.g. to match a storage
+	// layout or to leave headroom so Resize can grow NumBlocks in place. Set
+	// either (NumLeaves must be a power of two, 2^(Height-1)) or both
+	// consistently; the tree must hold NumBlocks at FullUtilization.
+	Height    int
+	NumLeaves int
 }
 
+// defaultBucketSize is the BucketSize Validate applies when it is zero.
+const defaultBucketSize = 5
+
+// Tree utilization targets (Config.UtilizationTarget).
+const (
+	DefaultUtilization = 0.5
+	FullUtilization    = 1.0
+)
+
 // Validate checks the configuration for errors and applies defaults.
 // Returns a copy of the config with defaults applied.
 func (c Config) Validate() (Config, error) {
-	if c.NumBlocks <= 0 || c.BlockSize <= 0 {
+	if c.NumBlocks <= 0 || c.BlockSize <= 0 || c.BlockSize > MaxBlockSize {
 		return c, ErrInvalidConfig
 	}
+	if c.MetadataSize < 0 || c.MetadataSize > MaxMetadataSize {
+		return c, fmt.Errorf("%w: MetadataSize must be in [0, %d]", ErrInvalidConfig, MaxMetadataSize)
+	}
+	if c.UniformAccessTime < 0 && c.UniformAccessTime != AutoCalibrate {
+		return c, ErrInvalidConfig
+	}
+	if c.BackgroundEviction && !backgroundEvictionAvailable {
+		return c, ErrInvalidConfig
+	}
+	if c.BucketSize < 0 {
+		return c, fmt.Errorf("%w: BucketSize must not be negative", ErrInvalidConfig)
+	}
 	if c.BucketSize == 0 {
-		c.BucketSize = 5
+		c.BucketSize = defaultBucketSize
 	}
 	if c.StashLimit == 0 {
 		c.StashLimit = 100
 	}
+	if c.UtilizationTarget == 0 {
+		c.UtilizationTarget = DefaultUtilization
+	}
+	if !(c.UtilizationTarget > 0 && c.UtilizationTarget <= 1) {
+		return c, fmt.Errorf("%w: UtilizationTarget must be in (0, 1]", ErrInvalidConfig)
+	}
+	if err := c.validateShape(); err != nil {
+		return c, err
+	}
+	if c.CacheBlocks < 0 {
+		return c, fmt.Errorf("%w: CacheBlocks must not be negative", ErrInvalidConfig)
+	}
+	if c.MaxDirtyBuckets < 0 {
+		return c, fmt.Errorf("%w: MaxDirtyBuckets must not be negative", ErrInvalidConfig)
+	}
+	if c.CryptoParallelism < 0 {
+		return c, fmt.Errorf("%w: CryptoParallelism must not be negative", ErrInvalidConfig)
+	}
+	if height, _, _ := c.ComputeTreeParams(); c.CachedLevels < 0 || c.CachedLevels >= height {
+		return c, fmt.Errorf("%w: CachedLevels must be in [0, %d)", ErrInvalidConfig, height)
+	}
+	if err := c.applyThreatModel(); err != nil {
+		return c, err
+	}
 	return c, nil
 }
 
-// ComputeTreeParams calculates tree dimensions from config.
+// maxHeight bounds Config.Height, keeping bucket indices well inside int.
+const maxHeight = 48
+
+// validateShape checks the Height and NumLeaves overrides, filling in Height
+// from NumLeaves.
+func (c *Config) validateShape() error {
+	if c.NumLeaves != 0 {
+		if c.NumLeaves < 0 || c.NumLeaves&(c.NumLeaves-1) != 0 {
+			return fmt.Errorf("%w: NumLeaves must be a power of two", ErrInvalidConfig)
+		}
+		h := bits.Len(uint(c.NumLeaves))
+		if c.Height != 0 && c.Height != h {
+			return fmt.Errorf("%w: NumLeaves %d implies Height %d, not %d", ErrInvalidConfig, c.NumLeaves, h, c.Height)
+		}
+		c.Height = h
+	}
+	if c.Height == 0 {
+		return nil
+	}
+	if c.Height < 0 || c.Height > maxHeight {
+		return fmt.Errorf("%w: Height must be in [1, %d]", ErrInvalidConfig, maxHeight)
+	}
+	if slots := c.BucketSize * (1<<c.Height - 1); c.NumBlocks > slots {
+		return fmt.Errorf("%w: a tree of height %d holds at most %d blocks", ErrInvalidConfig, c.Height, slots)
+	}
+	return nil
+}
+
+// plainSize returns the size of a block's stored plaintext: its data and
+// metadata.
+func (c Config) plainSize() int {
+	return c.BlockSize + c.MetadataSize
+}
+
+// ComputeTreeParams calculates tree dimensions from config: Height or
+// NumLeaves if set, and otherwise the smallest tree whose slots, filled to
+// UtilizationTarget, hold NumBlocks.
+// Zero or invalid BucketSize and UtilizationTarget count as their defaults,
+// as Validate would set them, and the height is capped at 48.
 // Returns (height, numLeaves, totalBuckets).
 func (c Config) ComputeTreeParams() (height, numLeaves, totalBuckets int) {
-	numBuckets := (c.NumBlocks + c.BucketSize - 1) / c.BucketSize
-	height = 1
-	for (1<<height)-1 < numBuckets {
-		height++
+	u := c.UtilizationTarget
+	if !(u > 0 && u <= 1) {
+		u = DefaultUtilization
+	}
+	z := c.BucketSize
+	if z <= 0 {
+		z = defaultBucketSize
+	}
+	switch {
+	case c.Height > 0:
+		height = c.Height
+	case c.NumLeaves > 0:
+		height = bits.Len(uint(c.NumLeaves))
+	default:
+		height = 1
+		for height < maxHeight && float64(c.NumBlocks) > u*float64(z*((1<<height)-1)) {
+			height++
+		}
 	}
 	numLeaves = 1 << (height - 1)
 	totalBuckets = (1 << height) - 1
 	return
-}
\ No newline at end of file
+}
@@ -1,20 +1,22 @@
 package pathoram
 
-import "crypto/subtle"
+import (
+	"context"
+	"crypto/subtle"
+)
 
-// findInStashConstantTime searches stash without timing leaks.
-// Returns (index, data) where index is -1 if not found.
+// findInStashConstantTime searches stash without timing leaks, copying the
+// matching block's data into dst. Returns the index, or -1 if not found.
 // Always iterates through entire stash regardless of match.
-func (o *PathORAM) findInStashConstantTime(blockID int) (int, []byte) {
+func (o *PathORAM) findInStashConstantTime(blockID int, dst []byte) int {
 	foundIdx := -1
-	result := make([]byte, o.cfg.BlockSize)
 
 	for i := range o.stash {
 		match := subtle.ConstantTimeEq(int32(o.stash[i].id), int32(blockID))
 		foundIdx = subtle.ConstantTimeSelect(match, i, foundIdx)
-		subtle.ConstantTimeCopy(match, result, o.stash[i].data)
+		subtle.ConstantTimeCopy(match, dst, o.stash[i].data[:len(dst)])
 	}
-	return foundIdx, result
+	return foundIdx
 }
 
 // canPlaceAtConstantTime checks placement without early exit.
@@ -34,14 +36,90 @@ func (o *PathORAM) canPlaceAtConstantTime(leaf, bucketIdx int) bool {
 	return found == 1
 }
 
-// evictConstantTime performs eviction without timing leaks.
+// evictConstantTimeWithStrategy dispatches to the constant-time counterpart
+// of the configured eviction strategy.
+func (o *PathORAM) evictConstantTimeWithStrategy(ctx context.Context, path []int) error {
+	switch o.cfg.EvictionStrategy {
+	case EvictGreedyByDepth:
+		return o.evictConstantTime(ctx, path)
+	case EvictDeterministicTwoPath:
+		if err := o.evictConstantTime(ctx, path); err != nil {
+			return err
+		}
+		secondPath := o.storedPath(o.randomLeaf())
+		if err := o.readPathIntoStash(ctx, secondPath); err != nil {
+			return err
+		}
+		return o.evictConstantTime(ctx, secondPath)
+	default: // EvictLevelByLevel
+		return o.evictLevelByLevelConstantTime(ctx, path)
+	}
+}
+
+// evictLevelByLevelConstantTime is the constant-time form of evict: each
+// empty slot, leaf to root, takes the first stash block that fits. Every
+// slot is checked against every stash block, and every bucket on the path is
+// written back whether or not it changed.
+func (o *PathORAM) evictLevelByLevelConstantTime(ctx context.Context, path []int) error {
+	o.noteEviction()
+
+	buckets := make([][]Block, len(path))
+	for i, bucketIdx := range path {
+		var err error
+		buckets[i], err = o.readBucket(ctx, bucketIdx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Each block's own path, for O(1) per-level placement checks: a block
+	// fits at a level iff its path shares that level's bucket
+	stashPaths := make([][]int, len(o.stash))
+	for i := range o.stash {
+		stashPaths[i] = o.Path(o.stash[i].leaf)
+	}
+	placed := make([]int, len(o.stash))
+
+	for level := range path {
+		for slot := range buckets[level] {
+			filled := 1 ^ subtle.ConstantTimeEq(int32(buckets[level][slot].ID), int32(EmptyBlockID))
+			for i := range o.stash {
+				canPlace := subtle.ConstantTimeEq(int32(stashPaths[i][level]), int32(path[level]))
+				shouldPlace := canPlace & (1 ^ filled) & (1 ^ placed[i])
+				if shouldPlace == 1 {
+					o.seal(&buckets[level][slot], o.stash[i])
+				}
+				placed[i] |= shouldPlace
+				filled |= shouldPlace
+			}
+		}
+	}
+
+	newStash := make([]block, 0, len(o.stash))
+	for i := range o.stash {
+		if placed[i] == 0 {
+			newStash = append(newStash, o.stash[i])
+		}
+	}
+	o.stash = newStash
+
+	if err := o.writeBuckets(ctx, path, buckets); err != nil {
+		return err
+	}
+
+	return o.checkStash()
+}
+
+// evictConstantTime performs greedy-by-depth eviction without timing leaks.
 // Always processes all stash blocks and all path buckets.
-func (o *PathORAM) evictConstantTime(path []int) error {
+func (o *PathORAM) evictConstantTime(ctx context.Context, path []int) error {
+	o.noteEviction()
+
 	// Read all buckets on path
 	buckets := make([][]Block, len(path))
 	for i, bucketIdx := range path {
 		var err error
-		buckets[i], err = o.storage.ReadBucket(bucketIdx)
+		buckets[i], err = o.readBucket(ctx, bucketIdx)
 		if err != nil {
 			return err
 		}
@@ -71,7 +149,7 @@ func (o *PathORAM) evictConstantTime(path []int) error {
 
 				// Conditionally write block to slot
 				if shouldPlace == 1 {
-					buckets[level][slot] = o.blockToStorage(*b)
+					o.seal(&buckets[level][slot], *b)
 					placed = 1
 				}
 			}
@@ -86,14 +164,9 @@ func (o *PathORAM) evictConstantTime(path []int) error {
 	o.stash = newStash
 
 	// Write all buckets back
-	for i, bucketIdx := range path {
-		if err := o.storage.WriteBucket(bucketIdx, buckets[i]); err != nil {
-			return err
-		}
+	if err := o.writeBuckets(ctx, path, buckets); err != nil {
+		return err
 	}
 
-	if len(o.stash) > o.cfg.StashLimit {
-		return ErrStashOverflow
-	}
-	return nil
+	return o.checkStash()
 }
@@ -0,0 +1,78 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Copy copies block srcID to dstID.
+// See CopyCtx.
+func (o *PathORAM) Copy(srcID, dstID int) error {
+	return o.CopyCtx(context.Background(), srcID, dstID)
+}
+
+// CopyCtx copies block srcID to dstID in two accesses, one per block, made
+// under a single lock so no other operation observes a partial copy. To the
+// storage server it looks like a Read followed by a Write. The data stays
+// inside the ORAM; only the position map entries of the two blocks change.
+func (o *PathORAM) CopyCtx(ctx context.Context, srcID, dstID int) error {
+	return o.transfer(ctx, srcID, dstID, false)
+}
+
+// Move moves block srcID to dstID.
+// See MoveCtx.
+func (o *PathORAM) Move(srcID, dstID int) error {
+	return o.MoveCtx(context.Background(), srcID, dstID)
+}
+
+// MoveCtx is like CopyCtx but leaves srcID reading as zeros. The first access
+// reads srcID and zeroes it in the same pass, so a move costs the same two
+// accesses as a copy. If the second access fails, MoveCtx tries to restore
+// srcID with a third access and returns both errors.
+func (o *PathORAM) MoveCtx(ctx context.Context, srcID, dstID int) error {
+	return o.transfer(ctx, srcID, dstID, true)
+}
+
+// transfer implements CopyCtx and MoveCtx.
+func (o *PathORAM) transfer(ctx context.Context, srcID, dstID int, move bool) error {
+	for _, id := range []int{srcID, dstID} {
+		if id < 0 || id >= o.cfg.NumBlocks {
+			return ErrInvalidBlockID
+		}
+	}
+	srcOp := OpRead
+	if move {
+		srcOp = OpWrite
+	}
+	if err := o.authorize(ctx, srcOp, srcID); err != nil {
+		return err
+	}
+	if err := o.authorize(ctx, OpWrite, dstID); err != nil {
+		return err
+	}
+
+	defer o.padAccess(ctx, time.Now(), 2)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var zeros []byte
+	if move {
+		zeros = o.newBlockData()
+		defer o.ReleaseBuffer(zeros)
+	}
+	// Read the full block, so its metadata is copied too
+	data, err := o.observe(ctx, srcID, zeros, o.newBlockData())
+	if err != nil {
+		return err
+	}
+	defer o.ReleaseBuffer(data)
+	if _, err := o.observe(ctx, dstID, data, discardPrevious); err != nil {
+		if move {
+			_, rerr := o.observe(ctx, srcID, data, discardPrevious)
+			err = errors.Join(err, rerr)
+		}
+		return err
+	}
+	return nil
+}
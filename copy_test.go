@@ -0,0 +1,63 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCopyMove(t *testing.T) {
+	oram, err := NewORAM(WithCapacity(16, 16), WithStashLimit(200))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	a, b := bytes.Repeat([]byte{0xaa}, 16), bytes.Repeat([]byte{0xbb}, 16)
+	oram.Write(1, a)
+	oram.Write(2, b)
+
+	before := oram.Stats().Accesses
+	if err := oram.Copy(1, 3); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if got := oram.Stats().Accesses - before; got != 2 {
+		t.Errorf("Copy made %d accesses, want 2", got)
+	}
+	if err := oram.Move(2, 4); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if err := oram.Move(4, 4); err != nil {
+		t.Fatalf("Move to self failed: %v", err)
+	}
+
+	want := map[int][]byte{1: a, 2: make([]byte, 16), 3: a, 4: b}
+	for id, w := range want {
+		if got, _ := oram.Read(id); !bytes.Equal(got, w) {
+			t.Errorf("Read(%d) = %x, want %x", id, got, w)
+		}
+	}
+	if err := oram.Copy(0, 16); !errors.Is(err, ErrInvalidBlockID) {
+		t.Errorf("Copy out of range: got %v, want ErrInvalidBlockID", err)
+	}
+}
+
+func TestMove_Authorizer(t *testing.T) {
+	var ops []Op
+	authz := func(ctx context.Context, op Op, blockID int) error {
+		ops = append(ops, op)
+		if blockID == 5 {
+			return ErrAccessDenied
+		}
+		return nil
+	}
+	oram, _ := NewORAM(WithCapacity(8, 16), WithAuthorizer(authz))
+	if err := oram.Move(1, 5); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Move to denied block: got %v, want ErrAccessDenied", err)
+	}
+	if len(ops) != 2 || ops[0] != OpWrite || ops[1] != OpWrite {
+		t.Errorf("Move checked %v, want [write write]", ops)
+	}
+	if oram.Stats().Accesses != 0 {
+		t.Error("denied Move accessed the ORAM")
+	}
+}
@@ -0,0 +1,113 @@
+package pathoram
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DelayedStorage wraps a Storage and delays every call as a remote backend
+// would: one round trip of RTT, plus the time to transfer the call's block
+// data at Bandwidth. A batch call (BatchStorage) costs one round trip for
+// all its buckets, as a pipelined or transactional backend's would. Use it
+// to benchmark configurations against realistic storage latency; with
+// in-memory storage alone, benchmarks measure only CPU cost.
+//
+// Delays honor the context passed to the StorageCtx and batch methods.
+// Concurrent calls are delayed concurrently and reach the wrapped storage
+// concurrently, so it must allow that if the ORAM issues them
+// (FetchParallelism, MaxDirtyBuckets).
+type DelayedStorage struct {
+	Storage
+
+	RTT       time.Duration // latency added to every call
+	Bandwidth int64         // bytes per second; 0 = unlimited
+
+	roundTrips atomic.Uint64
+}
+
+var _ BatchStorage = (*DelayedStorage)(nil)
+var _ StorageCtx = (*DelayedStorage)(nil)
+
+// NewDelayedStorage wraps s with rtt latency per call and bandwidth bytes per
+// second (0 = unlimited).
+func NewDelayedStorage(s Storage, rtt time.Duration, bandwidth int64) *DelayedStorage {
+	return &DelayedStorage{Storage: s, RTT: rtt, Bandwidth: bandwidth}
+}
+
+// RoundTrips returns the number of calls made so far.
+func (d *DelayedStorage) RoundTrips() uint64 {
+	return d.roundTrips.Load()
+}
+
+// delay waits for one round trip carrying n bytes, or until ctx is done.
+func (d *DelayedStorage) delay(ctx context.Context, n int) error {
+	d.roundTrips.Add(1)
+	wait := d.RTT
+	if d.Bandwidth > 0 {
+		wait += time.Duration(int64(n) * int64(time.Second) / d.Bandwidth)
+	}
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (d *DelayedStorage) ReadBucket(idx int) ([]Block, error) {
+	return d.ReadBucketCtx(context.Background(), idx)
+}
+
+func (d *DelayedStorage) WriteBucket(idx int, blocks []Block) error {
+	return d.WriteBucketCtx(context.Background(), idx, blocks)
+}
+
+func (d *DelayedStorage) ReadBucketCtx(ctx context.Context, idx int) ([]Block, error) {
+	blocks, err := AdaptStorage(d.Storage).ReadBucket(ctx, idx)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.delay(ctx, blockBytes(blocks)); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func (d *DelayedStorage) WriteBucketCtx(ctx context.Context, idx int, blocks []Block) error {
+	if err := d.delay(ctx, blockBytes(blocks)); err != nil {
+		return err
+	}
+	return AdaptStorage(d.Storage).WriteBucket(ctx, idx, blocks)
+}
+
+func (d *DelayedStorage) ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error) {
+	buckets, err := AdaptStorage(d.Storage).ReadBuckets(ctx, idxs)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for _, b := range buckets {
+		n += blockBytes(b)
+	}
+	if err := d.delay(ctx, n); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (d *DelayedStorage) WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error {
+	n := 0
+	for _, b := range buckets {
+		n += blockBytes(b)
+	}
+	if err := d.delay(ctx, n); err != nil {
+		return err
+	}
+	return AdaptStorage(d.Storage).WriteBuckets(ctx, idxs, buckets)
+}
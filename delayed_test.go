@@ -0,0 +1,121 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDelayedStorage(t *testing.T) {
+	const rtt = 2 * time.Millisecond
+	s := NewDelayedStorage(NewInMemoryStorage(15, 4, 1000), rtt, 1_000_000)
+	ctx := t.Context()
+
+	// 4000 bytes at 1 MB/s take 4ms on top of the round trip
+	start := time.Now()
+	if _, err := s.ReadBucket(3); err != nil {
+		t.Fatalf("ReadBucket failed: %v", err)
+	}
+	if d := time.Since(start); d < rtt+4*time.Millisecond {
+		t.Errorf("ReadBucket took %v, want at least %v", d, rtt+4*time.Millisecond)
+	}
+
+	// A batch is one round trip
+	s.Bandwidth = 0
+	start = time.Now()
+	if _, err := s.ReadBuckets(ctx, []int{0, 1, 3, 7}); err != nil {
+		t.Fatalf("ReadBuckets failed: %v", err)
+	}
+	if d := time.Since(start); d < rtt || d > 4*rtt+50*time.Millisecond {
+		t.Errorf("ReadBuckets of 4 buckets took %v, want about one round trip (%v)", d, rtt)
+	}
+	if got := s.RoundTrips(); got != 2 {
+		t.Errorf("RoundTrips() = %d, want 2", got)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := s.WriteBucketCtx(cancelled, 0, make([]Block, 4)); !errors.Is(err, context.Canceled) {
+		t.Errorf("WriteBucketCtx with cancelled ctx: error = %v, want context.Canceled", err)
+	}
+
+	// The ORAM works through it
+	cfg := Config{NumBlocks: 16, BlockSize: 16, BucketSize: 4}
+	_, _, total := cfg.ComputeTreeParams()
+	s = NewDelayedStorage(NewInMemoryStorage(total, 4, 16), 0, 0)
+	oram, err := New(cfg, s, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	want := bytes.Repeat([]byte{7}, 16)
+	if _, err := oram.Write(3, want); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got, err := oram.Read(3); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Read(3) = %x, %v; want %x", got, err, want)
+	}
+	if got := s.RoundTrips(); got < 4 {
+		t.Errorf("RoundTrips() after 2 accesses = %d, want at least a read and a write each", got)
+	}
+}
+
+// BenchmarkRemoteStorage compares configurations over storage with 1, 10
+// and 50ms round trips, where round trips rather than CPU dominate. Run it
+// with a small -benchtime, e.g. -benchtime=20x.
+func BenchmarkRemoteStorage(b *testing.B) {
+	const numBlocks, blockSize = 1024, 1024
+	setups := []struct {
+		name  string
+		batch bool // storage implements BatchStorage
+		cfg   Config
+	}{
+		{"sequential", false, Config{}},
+		{"FetchParallelism", false, Config{FetchParallelism: 16}},
+		{"BatchStorage", true, Config{}},
+		{"BatchStorage/GreedyByDepth", true, Config{EvictionStrategy: EvictGreedyByDepth}},
+		{"BatchStorage/MaxDirtyBuckets", true, Config{MaxDirtyBuckets: 256}},
+		{"BatchStorage/BackgroundEviction", true, Config{BackgroundEviction: true}},
+	}
+	for _, rtt := range []time.Duration{time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond} {
+		for _, setup := range setups {
+			if setup.cfg.BackgroundEviction && !backgroundEvictionAvailable {
+				continue
+			}
+			b.Run(fmt.Sprintf("rtt=%v/%s", rtt, setup.name), func(b *testing.B) {
+				cfg := setup.cfg
+				cfg.NumBlocks, cfg.BlockSize, cfg.BucketSize = numBlocks, blockSize, 4
+				cfg.StashLimit = numBlocks // background eviction may fall behind
+				cfg, _ = cfg.Validate()
+				_, _, total := cfg.ComputeTreeParams()
+				delayed := NewDelayedStorage(NewInMemoryStorage(total, cfg.BucketSize, blockSize), 0, 0)
+				var s Storage = delayed
+				if !setup.batch {
+					s = struct{ StorageCtx }{delayed}
+				}
+				oram, err := New(cfg, s, NewInMemoryPositionMap(), NoOpEncryptor{})
+				if err != nil {
+					b.Fatalf("New failed: %v", err)
+				}
+				defer oram.Close()
+				data := make([]byte, blockSize)
+				for id := range numBlocks {
+					oram.Write(id, data)
+				}
+
+				delayed.RTT = rtt
+				trips := delayed.RoundTrips()
+				i := 0
+				for b.Loop() {
+					if _, err := oram.Read(i % numBlocks); err != nil {
+						b.Fatalf("Read failed: %v", err)
+					}
+					i++
+				}
+				b.ReportMetric(float64(delayed.RoundTrips()-trips)/float64(i), "round_trips/access")
+			})
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DrainStash performs dummy accesses (read a random path, evict along it)
+// until StashSize() <= target or maxPasses passes have run. It is the
+// remediation for stash-pressure incidents: each pass looks like a regular
+// access to the storage server, so draining is oblivious.
+//
+// progress, if non-nil, is called after every pass. ctx is checked between
+// passes. Returns the number of passes performed; the caller compares
+// StashSize() against target to see whether draining succeeded.
+func (o *PathORAM) DrainStash(ctx context.Context, target, maxPasses int, progress func(pass, stashSize int)) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	passes := 0
+	for passes < maxPasses && len(o.stash) > target {
+		if err := ctx.Err(); err != nil {
+			return passes, err
+		}
+		if err := o.evictPass(ctx); err != nil {
+			return passes, err
+		}
+		passes++
+		if progress != nil {
+			progress(passes, len(o.stash))
+		}
+	}
+	return passes, nil
+}
+
+// DummyAccess performs an access that reads and evicts a random path without
+// touching any block. To the storage server it is indistinguishable from a
+// Read or Write, so callers can use it to pad the number of accesses.
+//
+// With Config.NamespaceStats, the access counts as padding for the principal
+// in ctx.
+func (o *PathORAM) DummyAccess(ctx context.Context) error {
+	defer o.padAccess(ctx, time.Now(), 1)
+	if err := o.dummyAccess(ctx); err != nil {
+		return err
+	}
+	o.noteNamespace(ctx, true)
+	return nil
+}
+
+// dummyAccess is DummyAccess without namespace accounting.
+func (o *PathORAM) dummyAccess(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.evictPass(ctx)
+}
+
+// evictPass performs one dummy access: a random path is read into the stash
+// and evicted with the configured strategy. A stash still above the limit is
+// not an error here, since draining is expected to start from that state.
+func (o *PathORAM) evictPass(ctx context.Context) error {
+	if o.closed {
+		return ErrClosed
+	}
+	path := o.storedPath(o.randomLeaf())
+	if err := o.readPathIntoStash(ctx, path); err != nil {
+		return err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if err := o.evictPath(ctx, path); err != nil && !errors.Is(err, ErrStashOverflow) {
+		return err
+	}
+	return o.recordAccess()
+}
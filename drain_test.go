@@ -0,0 +1,64 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestDrainStash(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, StashLimit: 10}
+	oram, _ := NewInMemory(cfg)
+
+	// Simulate a stash-pressure incident by parking blocks in the stash
+	for i := 0; i < 30; i++ {
+		leaf := oram.randomLeaf()
+		oram.posMap.Set(i, leaf)
+		oram.stash = append(oram.stash, block{id: i, leaf: leaf, data: bytes.Repeat([]byte{byte(i)}, 16)})
+	}
+
+	var calls int
+	passes, err := oram.DrainStash(context.Background(), 2, 1000, func(pass, stashSize int) {
+		calls++
+		if pass != calls {
+			t.Errorf("progress pass = %d, want %d", pass, calls)
+		}
+	})
+	if err != nil {
+		t.Fatalf("DrainStash failed: %v", err)
+	}
+	if oram.StashSize() > 2 {
+		t.Errorf("StashSize() = %d after %d passes, want <= 2", oram.StashSize(), passes)
+	}
+	if calls != passes {
+		t.Errorf("progress called %d times, want %d", calls, passes)
+	}
+
+	for i := 0; i < 30; i++ {
+		got, err := oram.Read(i)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Errorf("Read(%d) mismatch after drain", i)
+		}
+	}
+}
+
+func TestDrainStash_Bounds(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4}
+	oram, _ := NewInMemory(cfg)
+
+	passes, err := oram.DrainStash(context.Background(), 0, 10, nil)
+	if err != nil || passes != 0 {
+		t.Errorf("DrainStash on empty stash = (%d, %v), want (0, nil)", passes, err)
+	}
+
+	oram.stash = append(oram.stash, block{id: 1, leaf: 0, data: make([]byte, 16)})
+	oram.posMap.Set(1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := oram.DrainStash(ctx, 0, 10, nil); err != context.Canceled {
+		t.Errorf("DrainStash with canceled ctx error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,59 @@
+package pathoram
+
+import (
+	"fmt"
+	"io"
+)
+
+// checkRandomizeDummies reports whether enc can randomize dummy slots.
+func checkRandomizeDummies(cfg Config, enc Encryptor) error {
+	if !cfg.RandomizeDummies {
+		return nil
+	}
+	if _, ok := enc.(NoOpEncryptor); ok || enc.Overhead() == 0 {
+		return fmt.Errorf("%w: RandomizeDummies requires an authenticated encryptor", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// randomizeDummies replaces the data of every dummy slot in blocks, in
+// place, with a fresh encryption of random padding, so a written bucket
+// carries no stale ciphertext the server has seen before.
+func (o *PathORAM) randomizeDummies(blocks []Block) error {
+	for i := range blocks {
+		if blocks[i].ID != EmptyBlockID {
+			continue
+		}
+		data, err := o.encryptDummy()
+		if err != nil {
+			return err
+		}
+		blocks[i].Leaf = -1
+		blocks[i].Data = data
+	}
+	return nil
+}
+
+// encryptDummy returns a fresh dummy ciphertext, from EncryptDummy if the
+// encryptor implements DummyEncryptor and otherwise by encrypting random
+// padding drawn from Config.Rand.
+func (o *PathORAM) encryptDummy() ([]byte, error) {
+	size := o.cfg.plainSize()
+	if de, ok := o.encrypt.(DummyEncryptor); ok {
+		return de.EncryptDummy(size)
+	}
+	padding, err := randomPadding(o.rng, size)
+	if err != nil {
+		return nil, err
+	}
+	return o.encrypt.Encrypt(EmptyBlockID, -1, padding)
+}
+
+// randomPadding returns size bytes from r, or crypto/rand if r is nil.
+func randomPadding(r io.Reader, size int) ([]byte, error) {
+	padding := make([]byte, size)
+	if _, err := io.ReadFull(randReader(r), padding); err != nil {
+		return nil, ErrEncryptionFailed
+	}
+	return padding, nil
+}
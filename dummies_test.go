@@ -0,0 +1,81 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// rootDummies returns the stored data of the root bucket's dummy slots.
+func rootDummies(t *testing.T, storage Storage) [][]byte {
+	t.Helper()
+	root, err := storage.ReadBucket(0)
+	if err != nil {
+		t.Fatalf("ReadBucket failed: %v", err)
+	}
+	var dummies [][]byte
+	for _, b := range root {
+		if b.ID == EmptyBlockID {
+			dummies = append(dummies, b.Data)
+		}
+	}
+	return dummies
+}
+
+func TestRandomizeDummies(t *testing.T) {
+	for _, randomize := range []bool{false, true} {
+		enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{5}, 32))
+		cfg, _ := Config{NumBlocks: 16, BlockSize: 16, RandomizeDummies: randomize}.Validate()
+		_, _, total := cfg.ComputeTreeParams()
+		storage := NewInMemoryStorage(total, cfg.BucketSize, StoredBlockSize(cfg, enc))
+		oram, err := New(cfg, storage, NewInMemoryPositionMap(), enc)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		oram.DummyAccess(t.Context())
+		first := rootDummies(t, storage)
+		oram.DummyAccess(t.Context())
+		second := rootDummies(t, storage)
+
+		unchanged := 0
+		for i := range min(len(first), len(second)) {
+			if bytes.Equal(first[i], second[i]) {
+				unchanged++
+			}
+		}
+		switch {
+		case !randomize && unchanged == 0:
+			t.Error("without RandomizeDummies, stale dummies were expected to persist")
+		case randomize && unchanged != 0:
+			t.Errorf("RandomizeDummies left %d root dummies unchanged", unchanged)
+		}
+		if randomize {
+			for _, d := range second {
+				if len(d) != StoredBlockSize(cfg, enc) {
+					t.Fatalf("dummy of %d bytes, want %d", len(d), StoredBlockSize(cfg, enc))
+				}
+				if _, err := enc.Decrypt(EmptyBlockID, -1, d); err != nil {
+					t.Fatalf("dummy does not decrypt: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func TestRandomizeDummies_FallbackEncryptor(t *testing.T) {
+	enc, _ := NewSegmentedEncryptor(bytes.Repeat([]byte{5}, 32), 16, 8)
+	oram, err := NewORAM(WithCapacity(16, 16), WithEncryptor(enc), WithRandomizedDummies())
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for i := range 16 {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	checkBlocks(t, oram, 16)
+
+	if _, err := NewORAM(WithCapacity(16, 16), WithRandomizedDummies()); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("without an encryptor: error = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,159 @@
+package pathoram
+
+import "bytes"
+
+// embeddedMaxBlocks is the largest NumBlocks for which NewInMemory uses the
+// embedded fast path: arena storage and an array position map.
+const embeddedMaxBlocks = 1 << 16
+
+// useEmbedded reports whether NewInMemory should build cfg in embedded mode.
+// Integrity verification needs IntegrityStorage, which the arena omits.
+func useEmbedded(cfg Config) bool {
+	return cfg.NumBlocks <= embeddedMaxBlocks && !cfg.VerifyIntegrity && cfg.MaxDirtyBuckets == 0
+}
+
+// arenaStorage is the storage behind embedded-mode ORAMs. Every slot's data
+// lives in one contiguous allocation, and PathORAM calls it directly instead
+// of through the Storage interface. ReadBucket copies a bucket's data into a
+// single allocation rather than one per block; path reads and evictions use
+// viewPath and copy nothing.
+type arenaStorage struct {
+	data       []byte // numBuckets * bucketSize * blockSize
+	ids        []int  // per slot
+	leaves     []int  // per slot
+	bucketSize int
+	blockSize  int
+	counter    []byte // sealed access counter (see CounterStorage)
+	keyFP      []byte // key fingerprint (see FingerprintStorage)
+
+	// Reused by viewPath
+	view    []Block
+	buckets [][]Block
+}
+
+func newArenaStorage(numBuckets, bucketSize, blockSize int) *arenaStorage {
+	s := &arenaStorage{bucketSize: bucketSize, blockSize: blockSize}
+	s.Resize(numBuckets)
+	return s
+}
+
+// ReadBucket returns a copy of all blocks in the bucket at idx.
+func (s *arenaStorage) ReadBucket(idx int) ([]Block, error) {
+	if idx < 0 || idx >= s.NumBuckets() {
+		return nil, ErrInvalidConfig
+	}
+	first := idx * s.bucketSize
+	data := bytes.Clone(s.data[first*s.blockSize : (first+s.bucketSize)*s.blockSize])
+	result := make([]Block, s.bucketSize)
+	for i := range result {
+		result[i] = Block{
+			ID:   s.ids[first+i],
+			Leaf: s.leaves[first+i],
+			Data: data[i*s.blockSize : (i+1)*s.blockSize : (i+1)*s.blockSize],
+		}
+	}
+	return result, nil
+}
+
+// viewPath returns the buckets at idxs without copying: their blocks' Data
+// alias the arena, and the slices are reused by the next call, so a path
+// read allocates nothing. A viewed bucket must not be used once it has been
+// written, other than as the blocks written back.
+func (s *arenaStorage) viewPath(idxs []int) ([][]Block, error) {
+	n := len(idxs) * s.bucketSize
+	if cap(s.view) < n {
+		s.view = make([]Block, n)
+		s.buckets = make([][]Block, len(idxs))
+	}
+	buckets := s.buckets[:len(idxs)]
+	for j, idx := range idxs {
+		if idx < 0 || idx >= s.NumBuckets() {
+			return nil, ErrInvalidConfig
+		}
+		first := idx * s.bucketSize
+		bucket := s.view[j*s.bucketSize : (j+1)*s.bucketSize : (j+1)*s.bucketSize]
+		for i := range bucket {
+			off := (first + i) * s.blockSize
+			bucket[i] = Block{
+				ID:   s.ids[first+i],
+				Leaf: s.leaves[first+i],
+				Data: s.data[off : off+s.blockSize : off+s.blockSize],
+			}
+		}
+		buckets[j] = bucket
+	}
+	return buckets, nil
+}
+
+// WriteBucket copies blocks into the bucket at idx.
+func (s *arenaStorage) WriteBucket(idx int, blocks []Block) error {
+	if idx < 0 || idx >= s.NumBuckets() || len(blocks) != s.bucketSize {
+		return ErrInvalidConfig
+	}
+	first := idx * s.bucketSize
+	for i, b := range blocks {
+		if len(b.Data) != s.blockSize {
+			return ErrInvalidDataSize
+		}
+		s.ids[first+i] = b.ID
+		s.leaves[first+i] = b.Leaf
+		copy(s.data[(first+i)*s.blockSize:], b.Data)
+	}
+	return nil
+}
+
+// NumBuckets returns the total number of buckets.
+func (s *arenaStorage) NumBuckets() int {
+	return len(s.ids) / s.bucketSize
+}
+
+// BucketSize returns slots per bucket.
+func (s *arenaStorage) BucketSize() int {
+	return s.bucketSize
+}
+
+// BlockSize returns bytes per block.
+func (s *arenaStorage) BlockSize() int {
+	return s.blockSize
+}
+
+// Resize grows or shrinks storage to numBuckets. New buckets are empty.
+func (s *arenaStorage) Resize(numBuckets int) error {
+	if numBuckets <= 0 {
+		return ErrInvalidConfig
+	}
+	slots := numBuckets * s.bucketSize
+	for len(s.ids) < slots {
+		s.ids = append(s.ids, EmptyBlockID)
+		s.leaves = append(s.leaves, -1)
+	}
+	s.ids, s.leaves = s.ids[:slots], s.leaves[:slots]
+	if n := slots * s.blockSize; n > len(s.data) {
+		s.data = append(s.data, make([]byte, n-len(s.data))...)
+	} else {
+		s.data = s.data[:n]
+	}
+	return nil
+}
+
+// ReadCounter returns a copy of the sealed access counter, or nil if unset.
+func (s *arenaStorage) ReadCounter() ([]byte, error) {
+	return bytes.Clone(s.counter), nil
+}
+
+// WriteCounter stores a copy of the sealed access counter.
+func (s *arenaStorage) WriteCounter(sealed []byte) error {
+	s.counter = bytes.Clone(sealed)
+	return nil
+}
+
+// ReadFingerprint returns a copy of the key fingerprint, or nil if unset.
+func (s *arenaStorage) ReadFingerprint() ([]byte, error) {
+	return bytes.Clone(s.keyFP), nil
+}
+
+// WriteFingerprint stores a copy of the key fingerprint.
+func (s *arenaStorage) WriteFingerprint(fp []byte) error {
+	s.keyFP = bytes.Clone(fp)
+	return nil
+}
@@ -0,0 +1,127 @@
+package pathoram
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestNewInMemory_Embedded(t *testing.T) {
+	oram, err := NewInMemory(Config{NumBlocks: 64, BlockSize: 16, StashLimit: 200})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	if oram.arena == nil {
+		t.Fatal("small NewInMemory did not use embedded mode")
+	}
+	for i := 0; i < 64; i++ {
+		oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16))
+	}
+	if err := oram.Resize(256); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	oram.Write(200, bytes.Repeat([]byte{200}, 16))
+	for _, i := range []int{0, 31, 63, 200} {
+		if got, _ := oram.Read(i); !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Errorf("Read(%d) = %x", i, got)
+		}
+	}
+
+	large, _ := NewInMemory(Config{NumBlocks: embeddedMaxBlocks + 1, BlockSize: 8})
+	verified, _ := NewInMemory(Config{NumBlocks: 8, BlockSize: 8, VerifyIntegrity: true})
+	if large.arena != nil || verified.arena != nil {
+		t.Error("embedded mode used above the threshold or with VerifyIntegrity")
+	}
+}
+
+func TestArenaStorage_MatchesInMemory(t *testing.T) {
+	// The same seeded workload must leave the arena and InMemoryStorage
+	// with identical bucket contents.
+	run := func(storage Storage) {
+		cfg := Config{NumBlocks: 32, BlockSize: 16, StashLimit: 200, Rand: NewSeededRand([32]byte{9})}
+		oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		for i := 0; i < 200; i++ {
+			oram.Write(i%32, bytes.Repeat([]byte{byte(i)}, 16))
+		}
+	}
+	cfg, _ := Config{NumBlocks: 32, BlockSize: 16}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	arena := newArenaStorage(total, cfg.BucketSize, cfg.BlockSize)
+	mem := NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)
+	run(arena)
+	run(mem)
+	for idx := 0; idx < total; idx++ {
+		a, _ := arena.ReadBucket(idx)
+		m, _ := mem.ReadBucket(idx)
+		if !reflect.DeepEqual(a, m) {
+			t.Fatalf("bucket %d differs: %v vs %v", idx, a, m)
+		}
+	}
+}
+
+// newEmbeddedPair returns an embedded-mode ORAM and one with the same
+// config built through the Storage and PositionMap interfaces.
+func newEmbeddedPair(tb testing.TB, cfg Config) (embedded, generic *PathORAM) {
+	tb.Helper()
+	embedded, err := NewInMemory(cfg)
+	if err != nil {
+		tb.Fatalf("NewInMemory failed: %v", err)
+	}
+	vcfg, _ := cfg.Validate()
+	_, _, total := vcfg.ComputeTreeParams()
+	generic, err = New(cfg, NewInMemoryStorage(total, vcfg.BucketSize, vcfg.BlockSize),
+		NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		tb.Fatalf("New failed: %v", err)
+	}
+	if embedded.arena == nil || embedded.leaves == nil || generic.arena != nil {
+		tb.Fatal("embedded mode not used as expected")
+	}
+	return embedded, generic
+}
+
+func TestNewInMemory_EmbeddedAllocs(t *testing.T) {
+	embedded, generic := newEmbeddedPair(t, Config{NumBlocks: 1024, BlockSize: 64})
+	data := make([]byte, 64)
+	allocs := func(oram *PathORAM) float64 {
+		i := 0
+		return testing.AllocsPerRun(200, func() {
+			oram.Write(i%1024, data)
+			oram.Read(i % 1024)
+			i++
+		})
+	}
+	// Only the per-block plaintext and ciphertext copies remain
+	if e, g := allocs(embedded), allocs(generic); e > g/2 {
+		t.Errorf("embedded mode: %.0f allocations per write and read, generic %.0f", e, g)
+	}
+}
+
+func BenchmarkNewInMemory_Embedded(b *testing.B) {
+	embedded, generic := newEmbeddedPair(b, Config{NumBlocks: 1024, BlockSize: 64})
+	data := make([]byte, 64)
+	for _, bc := range []struct {
+		name string
+		oram *PathORAM
+	}{{"Embedded", embedded}, {"Generic", generic}} {
+		b.Run(bc.name+"/Write", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.oram.Write(i%1024, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(bc.name+"/Read", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.oram.Read(i % 1024); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package pathoram
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"slices"
 )
 
 // Encryptor provides block encryption and decryption.
@@ -24,6 +26,24 @@ type Encryptor interface {
 	Overhead() int
 }
 
+// BufferedEncryptor is an optional Encryptor extension that appends its
+// output to dst, as cipher.AEAD does, so Config.ReuseBuffers can supply
+// pooled buffers. dst and the input must not overlap.
+type BufferedEncryptor interface {
+	Encryptor
+	EncryptAppend(dst []byte, blockID, leaf int, plaintext []byte) ([]byte, error)
+	DecryptAppend(dst []byte, blockID, leaf int, ciphertext []byte) ([]byte, error)
+}
+
+// DummyEncryptor is an optional Encryptor extension for
+// Config.RandomizeDummies: EncryptDummy returns a fresh encryption of size
+// bytes of random padding, the same length as Encrypt's output for a
+// size-byte block and indistinguishable from it without the key.
+type DummyEncryptor interface {
+	Encryptor
+	EncryptDummy(size int) ([]byte, error)
+}
+
 // NoOpEncryptor passes data through without encryption.
 // Use only for testing or when encryption is handled externally.
 type NoOpEncryptor struct{}
@@ -42,6 +62,16 @@ func (NoOpEncryptor) Decrypt(blockID, leaf int, ciphertext []byte) ([]byte, erro
 	return result, nil
 }
 
+// EncryptAppend appends plaintext to dst.
+func (NoOpEncryptor) EncryptAppend(dst []byte, blockID, leaf int, plaintext []byte) ([]byte, error) {
+	return append(dst, plaintext...), nil
+}
+
+// DecryptAppend appends ciphertext to dst.
+func (NoOpEncryptor) DecryptAppend(dst []byte, blockID, leaf int, ciphertext []byte) ([]byte, error) {
+	return append(dst, ciphertext...), nil
+}
+
 // Overhead returns 0 for NoOpEncryptor.
 func (NoOpEncryptor) Overhead() int {
 	return 0
@@ -50,11 +80,14 @@ func (NoOpEncryptor) Overhead() int {
 // AESGCMEncryptor provides AES-256-GCM encryption with random nonces.
 type AESGCMEncryptor struct {
 	aead cipher.AEAD
+	fp   []byte    // KeyFingerprint of the key
+	rand io.Reader // nonce source; nil means crypto/rand
 }
 
 const (
 	aesKeySize   = 32 // AES-256
 	aesNonceSize = 12 // Standard GCM nonce size
+	aesTagSize   = 16 // GCM tag size
 )
 
 // NewAESGCMEncryptor creates a new AES-GCM encryptor with the given 32-byte key.
@@ -73,29 +106,52 @@ func NewAESGCMEncryptor(key []byte) (*AESGCMEncryptor, error) {
 		return nil, fmt.Errorf("create GCM: %w", err)
 	}
 
-	return &AESGCMEncryptor{aead: aead}, nil
+	return &AESGCMEncryptor{aead: aead, fp: KeyFingerprint(key)}, nil
 }
 
 // Encrypt encrypts plaintext using AES-GCM with a random nonce.
 // Output format: nonce (12 bytes) || ciphertext || tag (16 bytes)
 func (e *AESGCMEncryptor) Encrypt(blockID, leaf int, plaintext []byte) ([]byte, error) {
-	nonce := make([]byte, aesNonceSize)
-	if _, err := rand.Read(nonce); err != nil {
+	return e.EncryptAppend(nil, blockID, leaf, plaintext)
+}
+
+// EncryptAppend is like Encrypt but appends the output to dst.
+func (e *AESGCMEncryptor) EncryptAppend(dst []byte, blockID, leaf int, plaintext []byte) ([]byte, error) {
+	if e.aead == nil {
+		return nil, ErrEncryptionFailed // wiped
+	}
+	n := len(dst)
+	dst = slices.Grow(dst, aesNonceSize+len(plaintext)+e.aead.Overhead())[:n+aesNonceSize]
+	nonce := dst[n:]
+	if _, err := io.ReadFull(randReader(e.rand), nonce); err != nil {
 		return nil, ErrEncryptionFailed
 	}
 
 	// Use blockID and leaf as additional authenticated data
 	aad := makeAAD(blockID, leaf)
 
-	// Seal appends ciphertext+tag to nonce
-	ciphertext := e.aead.Seal(nonce, nonce, plaintext, aad)
-	return ciphertext, nil
+	// Seal appends ciphertext+tag after the nonce
+	return e.aead.Seal(dst, nonce, plaintext, aad), nil
+}
+
+// EncryptDummy encrypts size random bytes as block EmptyBlockID at leaf -1.
+func (e *AESGCMEncryptor) EncryptDummy(size int) ([]byte, error) {
+	padding, err := randomPadding(e.rand, size)
+	if err != nil {
+		return nil, err
+	}
+	return e.EncryptAppend(nil, EmptyBlockID, -1, padding)
 }
 
 // Decrypt decrypts ciphertext using AES-GCM.
 // Input format: nonce (12 bytes) || ciphertext || tag (16 bytes)
 func (e *AESGCMEncryptor) Decrypt(blockID, leaf int, ciphertext []byte) ([]byte, error) {
-	if len(ciphertext) < aesNonceSize+e.aead.Overhead() {
+	return e.DecryptAppend(nil, blockID, leaf, ciphertext)
+}
+
+// DecryptAppend is like Decrypt but appends the plaintext to dst.
+func (e *AESGCMEncryptor) DecryptAppend(dst []byte, blockID, leaf int, ciphertext []byte) ([]byte, error) {
+	if e.aead == nil || len(ciphertext) < aesNonceSize+aesTagSize {
 		return nil, ErrDecryptionFailed
 	}
 
@@ -104,7 +160,7 @@ func (e *AESGCMEncryptor) Decrypt(blockID, leaf int, ciphertext []byte) ([]byte,
 
 	aad := makeAAD(blockID, leaf)
 
-	plaintext, err := e.aead.Open(nil, nonce, ct, aad)
+	plaintext, err := e.aead.Open(dst, nonce, ct, aad)
 	if err != nil {
 		return nil, ErrDecryptionFailed
 	}
@@ -114,7 +170,24 @@ func (e *AESGCMEncryptor) Decrypt(blockID, leaf int, ciphertext []byte) ([]byte,
 
 // Overhead returns nonce size + GCM tag size.
 func (e *AESGCMEncryptor) Overhead() int {
-	return aesNonceSize + e.aead.Overhead()
+	return aesNonceSize + aesTagSize
+}
+
+// Wipe drops the cipher and makes every later call fail. The expanded key
+// is not zeroed (see Wiper).
+func (e *AESGCMEncryptor) Wipe() { e.aead = nil }
+
+// KeyFingerprint returns the fingerprint of the key.
+func (e *AESGCMEncryptor) KeyFingerprint() []byte { return bytes.Clone(e.fp) }
+
+func (e *AESGCMEncryptor) setRand(r io.Reader) { e.rand = r }
+
+// BlockAAD returns the additional authenticated data the built-in encryptors
+// bind to each block: blockID and leaf as little-endian uint64s. External
+// Encryptor implementations should use it so ciphertexts cannot be moved
+// between blocks or positions.
+func BlockAAD(blockID, leaf int) []byte {
+	return makeAAD(blockID, leaf)
 }
 
 // makeAAD creates additional authenticated data from blockID and leaf.
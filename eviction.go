@@ -1,80 +1,172 @@
 package pathoram
 
+import (
+	"context"
+	"fmt"
+	"math/bits"
+)
+
+// evictPath evicts along path with the configured strategy, using its
+// constant-time counterpart when Config.ConstantTime is set.
+func (o *PathORAM) evictPath(ctx context.Context, path []int) error {
+	if o.cfg.ConstantTime {
+		return o.evictConstantTimeWithStrategy(ctx, path)
+	}
+	return o.evictWithStrategy(ctx, path)
+}
+
+// SetStrategy switches a live ORAM to eviction strategy s, for example to
+// EvictGreedyByDepth during a stash-pressure incident. The switch waits for
+// in-flight accesses and first runs all deferred evictions
+// (BackgroundEviction) with the old strategy, so every path is evicted by
+// exactly one strategy; accesses after it returns use s. Blocks already in
+// the stash are placed by s on later evictions; call DrainStash afterwards
+// to reduce the stash right away. Strategies never change the storage
+// layout, so switching is safe in both directions and is not visible to
+// the storage server beyond the strategies' own access patterns
+// (EvictDeterministicTwoPath reads a second path).
+func (o *PathORAM) SetStrategy(ctx context.Context, s EvictionStrategy) error {
+	if s < EvictLevelByLevel || s > EvictDeterministicTwoPath {
+		return fmt.Errorf("%w: unknown eviction strategy %d", ErrInvalidConfig, s)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for len(o.pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := o.evictOnePending(); err != nil {
+			return err
+		}
+	}
+	if o.cfg.Logger != nil && s != o.cfg.EvictionStrategy {
+		o.cfg.Logger.Info("pathoram: eviction strategy changed",
+			"from", o.cfg.EvictionStrategy, "to", s, "stash", len(o.stash))
+	}
+	o.cfg.EvictionStrategy = s
+	return nil
+}
+
+// Strategy returns the eviction strategy in use.
+func (o *PathORAM) Strategy() EvictionStrategy {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.cfg.EvictionStrategy
+}
+
 // evictWithStrategy dispatches to the configured eviction strategy.
-func (o *PathORAM) evictWithStrategy(path []int) error {
+func (o *PathORAM) evictWithStrategy(ctx context.Context, path []int) error {
 	switch o.cfg.EvictionStrategy {
 	case EvictGreedyByDepth:
-		return o.evictGreedyByDepth(path)
+		return o.evictGreedyByDepth(ctx, path)
 	case EvictDeterministicTwoPath:
-		if err := o.evictGreedyByDepth(path); err != nil {
+		if err := o.evictGreedyByDepth(ctx, path); err != nil {
 			return err
 		}
 		// Read second path into stash, then evict along it
-		secondPath := o.Path(o.randomLeaf())
-		if err := o.readPathIntoStash(secondPath); err != nil {
+		secondPath := o.storedPath(o.randomLeaf())
+		if err := o.readPathIntoStash(ctx, secondPath); err != nil {
 			return err
 		}
-		return o.evictGreedyByDepth(secondPath)
+		return o.evictGreedyByDepth(ctx, secondPath)
 	default: // EvictLevelByLevel
-		return o.evict(path)
+		return o.evict(ctx, path)
 	}
 }
 
 // evict writes blocks from stash back to the path using level-by-level strategy.
-func (o *PathORAM) evict(path []int) error {
-	// For each level from leaf to root, try to place blocks
-	for level := 0; level < len(path); level++ {
-		bucketIdx := path[level]
+func (o *PathORAM) evict(ctx context.Context, path []int) error {
+	o.noteEviction()
+
+	// Index the stash by the lowest level each block can occupy, so filling
+	// a slot takes the next eligible block instead of scanning the stash
+	byLevel := make([][]int, len(path))
+	for i := range o.stash {
+		if d := o.evictionDepth(o.stash[i].leaf, path); d < len(path) {
+			byLevel[d] = append(byLevel[d], i)
+		}
+	}
+	placed := make([]bool, len(o.stash))
+	err := o.fillLevels(ctx, path, byLevel, placed)
+	o.stash = removePlaced(o.stash, placed)
+	if err != nil {
+		return err
+	}
+
+	return o.checkStash()
+}
+
+// fillLevels fills the empty slots of path, leaf to root, each with the
+// first block in stash order that fits there, and marks the blocks placed.
+func (o *PathORAM) fillLevels(ctx context.Context, path []int, byLevel [][]int, placed []bool) error {
+	// eligible holds the unplaced blocks that fit at the current level, in
+	// stash order; each level adds the blocks whose paths join it there
+	var eligible []int
+	for level, bucketIdx := range path {
+		eligible = mergeIndices(eligible, byLevel[level])
 
-		bucket, err := o.storage.ReadBucket(bucketIdx)
+		bucket, err := o.readEvictionBucket(ctx, bucketIdx)
 		if err != nil {
 			return err
 		}
 
 		modified := false
-		// Find blocks in stash that can go to this bucket
-		for slot := 0; slot < o.cfg.BucketSize; slot++ {
-			if bucket[slot].ID != EmptyBlockID {
-				continue // slot occupied
-			}
-			// Find a block whose path contains this bucket
-			for i := 0; i < len(o.stash); i++ {
-				b := &o.stash[i]
-				if o.canPlaceAt(b.leaf, bucketIdx) {
-					bucket[slot] = o.blockToStorage(*b)
-					// Remove from stash
-					o.stash = append(o.stash[:i], o.stash[i+1:]...)
-					modified = true
-					break
-				}
+		for slot := range bucket {
+			if bucket[slot].ID != EmptyBlockID || len(eligible) == 0 {
+				continue
 			}
+			i := eligible[0]
+			eligible = eligible[1:]
+			o.seal(&bucket[slot], o.stash[i])
+			placed[i] = true
+			modified = true
 		}
 
 		if modified {
-			if err := o.storage.WriteBucket(bucketIdx, bucket); err != nil {
+			if err := o.writeBucket(ctx, bucketIdx, bucket); err != nil {
 				return err
 			}
 		}
 	}
+	return nil
+}
 
-	// Check stash overflow
-	if len(o.stash) > o.cfg.StashLimit {
-		return ErrStashOverflow
+// readEvictionBucket reads a bucket of the path being evicted, which is
+// written back, if at all, before the next is read. In embedded mode it is a
+// view into the arena rather than a copy.
+func (o *PathORAM) readEvictionBucket(ctx context.Context, idx int) ([]Block, error) {
+	if o.arena == nil {
+		return o.readBucket(ctx, idx)
 	}
-	return nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fetched, err := o.arena.viewPath([]int{idx})
+	if err != nil {
+		return nil, err
+	}
+	return o.openBucket(idx, fetched[0])
 }
 
 // evictGreedyByDepth places each stash block at its deepest possible level.
 // This minimizes stash pressure by keeping blocks as close to leaves as possible.
-func (o *PathORAM) evictGreedyByDepth(path []int) error {
-	// Read all buckets on path
+func (o *PathORAM) evictGreedyByDepth(ctx context.Context, path []int) error {
+	o.noteEviction()
+
+	// Read all buckets on path, noting their empty slots in order
 	buckets := make([][]Block, len(path))
+	free := make([][]int, len(path))
 	for i, bucketIdx := range path {
 		var err error
-		buckets[i], err = o.storage.ReadBucket(bucketIdx)
+		buckets[i], err = o.readBucket(ctx, bucketIdx)
 		if err != nil {
 			return err
 		}
+		for slot := range buckets[i] {
+			if buckets[i][slot].ID == EmptyBlockID {
+				free[i] = append(free[i], slot)
+			}
+		}
 	}
 
 	i := 0
@@ -82,26 +174,20 @@ func (o *PathORAM) evictGreedyByDepth(path []int) error {
 		b := &o.stash[i]
 		placed := false
 
-		// Try deepest level first (leaf = path[0], root = path[len-1])
-		for level := 0; level < len(path); level++ {
-			bucketIdx := path[level]
-			if !o.canPlaceAt(b.leaf, bucketIdx) {
+		// Try deepest level first (leaf = path[0], root = path[len-1]),
+		// starting where the block's path joins this one
+		for level := o.evictionDepth(b.leaf, path); level < len(path); level++ {
+			if len(free[level]) == 0 {
 				continue
 			}
-			// Find empty slot in this bucket
-			for slot := range buckets[level] {
-				if buckets[level][slot].ID == EmptyBlockID {
-					buckets[level][slot] = o.blockToStorage(*b)
-					// Remove from stash (swap with last, shrink)
-					o.stash[i] = o.stash[len(o.stash)-1]
-					o.stash = o.stash[:len(o.stash)-1]
-					placed = true
-					break
-				}
-			}
-			if placed {
-				break
-			}
+			slot := free[level][0]
+			free[level] = free[level][1:]
+			o.seal(&buckets[level][slot], *b)
+			// Remove from stash (swap with last, shrink)
+			o.stash[i] = o.stash[len(o.stash)-1]
+			o.stash = o.stash[:len(o.stash)-1]
+			placed = true
+			break
 		}
 		if !placed {
 			i++
@@ -109,14 +195,51 @@ func (o *PathORAM) evictGreedyByDepth(path []int) error {
 	}
 
 	// Write all buckets back
-	for i, bucketIdx := range path {
-		if err := o.storage.WriteBucket(bucketIdx, buckets[i]); err != nil {
-			return err
+	if err := o.writeBuckets(ctx, path, buckets); err != nil {
+		return err
+	}
+
+	return o.checkStash()
+}
+
+// evictionDepth returns the lowest level of path (0 = leaf) that a block
+// assigned to leaf can occupy: the level where the block's path joins path.
+// Every level from there to the root is on both paths, so one XOR replaces
+// a canPlaceAt walk per level. path must start at a leaf bucket.
+func (o *PathORAM) evictionDepth(leaf int, path []int) int {
+	return bits.Len(uint(leaf ^ (path[0] - (o.numLeaves - 1))))
+}
+
+// mergeIndices merges two ascending index lists.
+func mergeIndices(a, b []int) []int {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+	merged := make([]int, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0] < b[0] {
+			merged, a = append(merged, a[0]), a[1:]
+		} else {
+			merged, b = append(merged, b[0]), b[1:]
 		}
 	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
 
-	if len(o.stash) > o.cfg.StashLimit {
-		return ErrStashOverflow
+// removePlaced drops the placed blocks from stash, keeping the order of the
+// rest.
+func removePlaced(stash []block, placed []bool) []block {
+	n := 0
+	for i := range stash {
+		if !placed[i] {
+			stash[n] = stash[i]
+			n++
+		}
 	}
-	return nil
+	clear(stash[n:])
+	return stash[:n]
 }
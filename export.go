@@ -0,0 +1,305 @@
+package pathoram
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// canonicalExportMagic starts every canonical export.
+const canonicalExportMagic = "PORAMEX1"
+
+// ExportCanonical writes every allocated block to w in ascending block-ID
+// order, decrypted, in a canonical format: two exports of the same logical
+// contents are byte-identical regardless of tree layout, stash contents, or
+// encryption, so backups can be deduplicated and diffed. It returns the
+// SHA-256 digest of the bytes written, for signing.
+//
+// Format (big-endian): magic "PORAMEX1", NumBlocks (uint64), BlockSize
+// (uint32, plus MetadataSize if set), block count (uint64), then for each
+// block its ID (uint64) and that many bytes of data and metadata.
+//
+// The export scans every bucket once without remapping any block, so the
+// physical access pattern is independent of the contents. With an
+// Authorizer, every block ID must be readable by the principal in ctx.
+func (o *PathORAM) ExportCanonical(ctx context.Context, w io.Writer) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil, ErrClosed
+	}
+	if err := o.authorizeAll(ctx, OpRead); err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[int][]byte, o.posMap.Size())
+	for _, b := range o.stash {
+		blocks[b.id] = b.data
+	}
+	_, _, totalBuckets := o.cfg.ComputeTreeParams()
+	for idx := 0; idx < totalBuckets; idx++ {
+		bucket, err := o.readBucket(ctx, idx)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bucket {
+			if b.ID == EmptyBlockID {
+				continue
+			}
+			data, err := o.encrypt.Decrypt(b.ID, b.Leaf, b.Data)
+			if err != nil {
+				o.noteDecryptionFailure()
+				return nil, err
+			}
+			blocks[b.ID] = data
+		}
+	}
+	ids := slices.Sorted(maps.Keys(blocks))
+
+	h := sha256.New()
+	bw := bufio.NewWriter(io.MultiWriter(w, h))
+	var hdr [28]byte
+	copy(hdr[:], canonicalExportMagic)
+	binary.BigEndian.PutUint64(hdr[8:], uint64(o.cfg.NumBlocks))
+	binary.BigEndian.PutUint32(hdr[16:], uint32(o.cfg.plainSize()))
+	binary.BigEndian.PutUint64(hdr[20:], uint64(len(ids)))
+	bw.Write(hdr[:])
+	var idBuf [8]byte
+	for _, id := range ids {
+		binary.BigEndian.PutUint64(idBuf[:], uint64(id))
+		bw.Write(idBuf[:])
+		bw.Write(blocks[id])
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// exportMagic starts every Export stream.
+const exportMagic = "PORAMEN1"
+
+// exportLeaf is the leaf passed to the encryptor for Export records, so a
+// record cannot be passed off as a tree block (see storedPosMapLeaf).
+const exportLeaf = -3
+
+// exportHeaderSize is the size of an Export stream's first record.
+const exportHeaderSize = 16
+
+// Export writes every allocated block to w, encrypted, for Import into
+// another ORAM. See ExportCtx.
+func (o *PathORAM) Export(w io.Writer) error {
+	return o.ExportCtx(context.Background(), w)
+}
+
+// ExportCtx writes every allocated block to w in a portable format that
+// Import reads into an ORAM with any Z, height, eviction strategy or block
+// size, as long as it uses the same key. Unlike a bundle or a copy of the
+// storage, the stream holds logical blocks, not tree buckets.
+//
+// Format (big-endian): magic "PORAMEN1", then records, each a uint32
+// length followed by that many bytes encrypted with the ORAM's Encryptor,
+// bound to the record's position in the stream. The first record holds
+// NumBlocks (uint64), BlockSize and MetadataSize (uint32 each); each block
+// record holds the block ID (uint64), its data and its metadata; the last
+// record holds the block count (uint64), so truncation is detected. With
+// NoOpEncryptor the records are in the clear.
+//
+// Like ExportCanonical, the export scans every bucket once without
+// remapping any block, and requires read access to every block ID.
+func (o *PathORAM) ExportCtx(ctx context.Context, w io.Writer) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return ErrClosed
+	}
+	if err := o.authorizeAll(ctx, OpRead); err != nil {
+		return err
+	}
+
+	ew := &exportWriter{w: bufio.NewWriter(w), enc: o.encrypt}
+	ew.w.WriteString(exportMagic)
+	hdr := make([]byte, exportHeaderSize)
+	binary.BigEndian.PutUint64(hdr, uint64(o.cfg.NumBlocks))
+	binary.BigEndian.PutUint32(hdr[8:], uint32(o.cfg.BlockSize))
+	binary.BigEndian.PutUint32(hdr[12:], uint32(o.cfg.MetadataSize))
+	if err := ew.record(hdr); err != nil {
+		return err
+	}
+	var count uint64
+	rec := make([]byte, 8+o.cfg.plainSize())
+	put := func(id int, data []byte) error {
+		binary.BigEndian.PutUint64(rec, uint64(id))
+		copy(rec[8:], data)
+		count++
+		return ew.record(rec)
+	}
+	for _, b := range o.stash {
+		if err := put(b.id, b.data); err != nil {
+			return err
+		}
+	}
+	for idx := range 2*o.numLeaves - 1 {
+		bucket, err := o.readBucket(ctx, idx)
+		if err != nil {
+			return err
+		}
+		for _, b := range bucket {
+			if b.ID == EmptyBlockID {
+				continue
+			}
+			data, err := o.encrypt.Decrypt(b.ID, b.Leaf, b.Data)
+			if err != nil {
+				o.noteDecryptionFailure()
+				return err
+			}
+			if err := put(b.ID, data); err != nil {
+				return err
+			}
+		}
+	}
+	binary.BigEndian.PutUint64(rec, count)
+	if err := ew.record(rec[:8]); err != nil {
+		return err
+	}
+	return ew.w.Flush()
+}
+
+// Import loads an Export stream into an empty ORAM. See ImportCtx.
+func (o *PathORAM) Import(r io.Reader) error {
+	return o.ImportCtx(context.Background(), r)
+}
+
+// ImportCtx loads an Export stream into an empty ORAM, packing the tree as
+// BulkLoad does. The ORAM's Encryptor must decrypt what the exporting
+// ORAM's encrypted. Blocks from an ORAM with a smaller BlockSize or
+// MetadataSize are padded with zeros; from a larger one, the excess must be
+// zeros, or ImportCtx fails with ErrInvalidDataSize. Block IDs must be
+// below NumBlocks. The whole stream is read and checked before any bucket
+// is written.
+func (o *PathORAM) ImportCtx(ctx context.Context, r io.Reader) error {
+	er := &exportReader{r: bufio.NewReader(r), enc: o.encrypt}
+	var magic [len(exportMagic)]byte
+	if _, err := io.ReadFull(er.r, magic[:]); err != nil || string(magic[:]) != exportMagic {
+		return fmt.Errorf("%w: not an export stream", ErrCorruptObject)
+	}
+	hdr, err := er.record(exportHeaderSize)
+	if err != nil {
+		return err
+	}
+	if len(hdr) != exportHeaderSize {
+		return fmt.Errorf("%w: export header", ErrCorruptObject)
+	}
+	srcBlock := int(binary.BigEndian.Uint32(hdr[8:]))
+	srcMeta := int(binary.BigEndian.Uint32(hdr[12:]))
+	if srcBlock > MaxBlockSize || srcMeta > MaxMetadataSize {
+		return fmt.Errorf("%w: export header", ErrCorruptObject)
+	}
+
+	var seqErr error
+	seq := func(yield func(int, []byte) bool) {
+		buf := make([]byte, o.cfg.plainSize())
+		var count uint64
+		for {
+			rec, err := er.record(8 + srcBlock + srcMeta)
+			if err != nil {
+				seqErr = err
+				return
+			}
+			if len(rec) == 8 {
+				if binary.BigEndian.Uint64(rec) != count {
+					seqErr = fmt.Errorf("%w: export block count", ErrCorruptObject)
+				} else if _, err := er.r.ReadByte(); err != io.EOF {
+					seqErr = fmt.Errorf("%w: data after export trailer", ErrCorruptObject)
+				}
+				return
+			}
+			if len(rec) != 8+srcBlock+srcMeta {
+				seqErr = fmt.Errorf("%w: export record size", ErrCorruptObject)
+				return
+			}
+			id := binary.BigEndian.Uint64(rec)
+			if id >= uint64(o.cfg.NumBlocks) {
+				seqErr = ErrInvalidBlockID
+				return
+			}
+			if !fitZeros(buf[:o.cfg.BlockSize], rec[8:8+srcBlock]) || !fitZeros(buf[o.cfg.BlockSize:], rec[8+srcBlock:]) {
+				seqErr = ErrInvalidDataSize
+				return
+			}
+			count++
+			if !yield(int(id), buf) {
+				return
+			}
+		}
+	}
+	return o.bulkLoad(ctx, seq, o.cfg.plainSize(), &seqErr)
+}
+
+// fitZeros copies src into dst, zero-padding it, and reports whether the
+// part of src that does not fit is all zeros.
+func fitZeros(dst, src []byte) bool {
+	n := copy(dst, src)
+	clear(dst[n:])
+	for _, c := range src[n:] {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// exportWriter writes Export records.
+type exportWriter struct {
+	w   *bufio.Writer
+	enc Encryptor
+	seq int
+}
+
+func (w *exportWriter) record(plain []byte) error {
+	ct, err := w.enc.Encrypt(w.seq, exportLeaf, plain)
+	if err != nil {
+		return err
+	}
+	w.seq++
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(ct)))
+	w.w.Write(n[:])
+	_, err = w.w.Write(ct)
+	return err
+}
+
+// exportReader reads Export records.
+type exportReader struct {
+	r   *bufio.Reader
+	enc Encryptor
+	seq int
+}
+
+// record reads and decrypts the next record, whose plaintext is at most
+// limit bytes.
+func (r *exportReader) record(limit int) ([]byte, error) {
+	var n [4]byte
+	if _, err := io.ReadFull(r.r, n[:]); err != nil {
+		return nil, fmt.Errorf("%w: export stream truncated", ErrCorruptObject)
+	}
+	size := int(binary.BigEndian.Uint32(n[:]))
+	if size > limit+r.enc.Overhead() {
+		return nil, fmt.Errorf("%w: export record too long", ErrCorruptObject)
+	}
+	ct := make([]byte, size)
+	if _, err := io.ReadFull(r.r, ct); err != nil {
+		return nil, fmt.Errorf("%w: export stream truncated", ErrCorruptObject)
+	}
+	plain, err := r.enc.Decrypt(r.seq, exportLeaf, ct)
+	if err != nil {
+		return nil, err
+	}
+	r.seq++
+	return plain, nil
+}
@@ -0,0 +1,140 @@
+package pathoram
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestExportCanonical_Deterministic(t *testing.T) {
+	export := func(order []int, enc Encryptor) ([]byte, []byte) {
+		oram, err := NewORAM(WithCapacity(64, 8), WithStashLimit(200), WithEncryptor(enc))
+		if err != nil {
+			t.Fatalf("NewORAM failed: %v", err)
+		}
+		for _, id := range order {
+			// Earlier values are overwritten so only the final state matters
+			oram.Write(id, make([]byte, 8))
+			if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id)}, 8)); err != nil {
+				t.Fatalf("Write(%d) failed: %v", id, err)
+			}
+		}
+		var buf bytes.Buffer
+		digest, err := oram.ExportCanonical(t.Context(), &buf)
+		if err != nil {
+			t.Fatalf("ExportCanonical failed: %v", err)
+		}
+		return buf.Bytes(), digest
+	}
+
+	aes, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{1}, 32))
+	a, digestA := export([]int{5, 1, 40, 3, 63}, NoOpEncryptor{})
+	b, digestB := export([]int{63, 40, 3, 1, 5}, aes)
+	if !bytes.Equal(a, b) || !bytes.Equal(digestA, digestB) {
+		t.Fatal("exports of the same logical state differ")
+	}
+	if sum := sha256.Sum256(a); !bytes.Equal(sum[:], digestA) {
+		t.Error("digest is not SHA-256 of the export")
+	}
+
+	if string(a[:8]) != canonicalExportMagic {
+		t.Errorf("magic = %q", a[:8])
+	}
+	if n := binary.BigEndian.Uint64(a[20:]); n != 5 {
+		t.Fatalf("block count = %d, want 5", n)
+	}
+	var prev int64 = -1
+	for off := 28; off < len(a); off += 16 {
+		id := int64(binary.BigEndian.Uint64(a[off:]))
+		if id <= prev {
+			t.Errorf("block %d after %d: not ascending", id, prev)
+		}
+		if !bytes.Equal(a[off+8:off+16], bytes.Repeat([]byte{byte(id)}, 8)) {
+			t.Errorf("block %d data = %x", id, a[off+8:off+16])
+		}
+		prev = id
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	key := bytes.Repeat([]byte{2}, 32)
+	src, err := NewORAM(WithConfig(Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, MetadataSize: 4}),
+		WithEncryptor(mustAES(t, key)))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for id := range 40 {
+		if _, _, err := src.WriteMeta(id, bytes.Repeat([]byte{byte(id)}, 16), []byte{byte(id), 0, 0, 1}); err != nil {
+			t.Fatalf("WriteMeta(%d) failed: %v", id, err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := src.Export(&buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), bytes.Repeat([]byte{7}, 16)) {
+		t.Fatal("export holds plaintext")
+	}
+
+	// A larger tree with bigger blocks, another Z and another strategy
+	dst, err := NewORAM(WithConfig(Config{NumBlocks: 256, BlockSize: 32, BucketSize: 5, MetadataSize: 8}),
+		WithStrategy(EvictGreedyByDepth), WithEncryptor(mustAES(t, key)))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	if err := dst.Import(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	for id := range 40 {
+		data, meta, err := dst.ReadMeta(id)
+		wantData := append(bytes.Repeat([]byte{byte(id)}, 16), make([]byte, 16)...)
+		wantMeta := []byte{byte(id), 0, 0, 1, 0, 0, 0, 0}
+		if err != nil || !bytes.Equal(data, wantData) || !bytes.Equal(meta, wantMeta) {
+			t.Fatalf("block %d = %v %v, %v", id, data, meta, err)
+		}
+	}
+	if got := dst.Size(); got != 40 {
+		t.Fatalf("Size = %d, want 40", got)
+	}
+	if err := dst.Import(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrNotEmpty) {
+		t.Fatalf("second Import err = %v, want ErrNotEmpty", err)
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, 32)
+	src, _ := NewORAM(WithCapacity(64, 16), WithEncryptor(mustAES(t, key)))
+	src.Write(50, bytes.Repeat([]byte{9}, 16))
+	var buf bytes.Buffer
+	if err := src.Export(&buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	stream := buf.Bytes()
+
+	for name, tc := range map[string]struct {
+		stream []byte
+		opts   []Option
+		want   error
+	}{
+		"truncated":     {stream[:len(stream)-10], nil, ErrCorruptObject},
+		"no trailer":    {stream[:len(stream)-4-8-28], nil, ErrCorruptObject},
+		"not an export": {[]byte("PORAMEX1"), nil, ErrCorruptObject},
+		"wrong key":     {stream, []Option{WithEncryptor(mustAES(t, bytes.Repeat([]byte{4}, 32)))}, ErrDecryptionFailed},
+		"id too large":  {stream, []Option{WithCapacity(32, 16)}, ErrInvalidBlockID},
+		"data cut off":  {stream, []Option{WithCapacity(64, 8)}, ErrInvalidDataSize},
+	} {
+		opts := append([]Option{WithCapacity(64, 16), WithEncryptor(mustAES(t, key))}, tc.opts...)
+		dst, err := NewORAM(opts...)
+		if err != nil {
+			t.Fatalf("%s: NewORAM failed: %v", name, err)
+		}
+		if err := dst.Import(bytes.NewReader(tc.stream)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Import err = %v, want %v", name, err, tc.want)
+		}
+		if dst.Size() != 0 {
+			t.Errorf("%s: failed Import stored %d blocks", name, dst.Size())
+		}
+	}
+}
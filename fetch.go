@@ -0,0 +1,78 @@
+package pathoram
+
+import (
+	"context"
+	"sync"
+)
+
+// fetchPath reads the buckets of path from storage, in one ReadBuckets call
+// or issuing up to Config.FetchParallelism reads at once. Results are returned in path order,
+// so stash contents do not depend on which read finishes first. The first
+// failure cancels the remaining reads and is returned.
+//
+// In embedded mode the buckets are views into the arena, valid until the
+// next fetchPath: each is moved into the stash and written back first.
+func (o *PathORAM) fetchPath(ctx context.Context, path []int) ([][]Block, error) {
+	if o.arena != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return o.arena.viewPath(path)
+	}
+	return o.fetchBuckets(ctx, path, o.cfg.FetchParallelism)
+}
+
+// fetchBuckets reads the buckets at idxs as fetchPath does, with up to
+// workers reads at once.
+func (o *PathORAM) fetchBuckets(ctx context.Context, idxs []int, workers int) ([][]Block, error) {
+	workers = min(workers, len(idxs))
+	if workers <= 1 && o.arena == nil {
+		return o.store.ReadBuckets(ctx, idxs)
+	}
+	buckets := make([][]Block, len(idxs))
+	if workers <= 1 {
+		for i, idx := range idxs {
+			var err error
+			if buckets[i], err = o.fetchBucket(ctx, idx); err != nil {
+				return nil, err
+			}
+		}
+		return buckets, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var errOnce sync.Once
+	var firstErr error
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				var err error
+				if buckets[i], err = o.fetchBucket(ctx, idxs[i]); err != nil {
+					errOnce.Do(func() { firstErr = err })
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := range idxs {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err // cancelled before every bucket was requested
+	}
+	return buckets, nil
+}
@@ -0,0 +1,75 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowStorage delays reads and records the peak number of concurrent reads.
+type slowStorage struct {
+	*InMemoryStorage
+	inFlight, peak atomic.Int32
+	fail           atomic.Bool
+}
+
+func (s *slowStorage) ReadBucket(idx int) ([]Block, error) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		p := s.peak.Load()
+		if n <= p || s.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(200 * time.Microsecond)
+	if s.fail.Load() && idx != 0 {
+		return nil, errors.New("backend unavailable")
+	}
+	return s.InMemoryStorage.ReadBucket(idx)
+}
+
+func newFetchTestORAM(t *testing.T, parallelism int) (*PathORAM, *slowStorage) {
+	t.Helper()
+	cfg, _ := Config{NumBlocks: 64, BlockSize: 16, StashLimit: 200}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := &slowStorage{InMemoryStorage: NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)}
+	oram, err := NewORAM(WithConfig(cfg), WithStorage(storage), WithEncryptor(NoOpEncryptor{}),
+		WithFetchParallelism(parallelism), WithRand(NewSeededRand([32]byte{9})))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	return oram, storage
+}
+
+func TestFetchParallelism(t *testing.T) {
+	seq, seqStorage := newFetchTestORAM(t, 0)
+	par, parStorage := newFetchTestORAM(t, 4)
+	for _, oram := range []*PathORAM{seq, par} {
+		for i := 0; i < 100; i++ {
+			if _, err := oram.Write(i%64, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+	}
+
+	if p := seqStorage.peak.Load(); p != 1 {
+		t.Errorf("sequential peak concurrency = %d, want 1", p)
+	}
+	if p := parStorage.peak.Load(); p < 2 || p > 4 {
+		t.Errorf("parallel peak concurrency = %d, want 2..4", p)
+	}
+	// Same seed, same operations: parallel fetches must not change the
+	// resulting stash order or tree layout.
+	if !reflect.DeepEqual(seq.stash, par.stash) || !reflect.DeepEqual(seqStorage.buckets, parStorage.buckets) {
+		t.Error("parallel fetch changed stash or storage contents")
+	}
+
+	parStorage.fail.Store(true)
+	if _, err := par.Read(1); err == nil {
+		t.Error("expected error from failing storage")
+	}
+}
@@ -0,0 +1,144 @@
+// Package filestorage provides a pathoram.Storage backend kept in one file
+// of fixed-size records, with no dependencies beyond the standard library.
+// cmd/oram-storaged serves it over HTTP and cmd/pathoram-cli opens it directly.
+package filestorage
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// Storage keeps bucket idx at offset idx*recordSize of one file. Each slot
+// stores the block ID and leaf plus one as 8 bytes each, so the zeroes of a
+// new file read as empty slots, then blockSize bytes of data.
+//
+// Storage implements pathoram.BatchStorage, and io.Closer, so PathORAM.Close
+// closes the file.
+type Storage struct {
+	f          *os.File
+	numBuckets int
+	bucketSize int
+	blockSize  int
+
+	// NoSync skips the fsync after each write. Set it before first use.
+	NoSync bool
+}
+
+var _ pathoram.BatchStorage = (*Storage)(nil)
+
+// Open opens or creates the file at path for numBuckets buckets of
+// bucketSize blocks of blockSize bytes. A new file is sized up front;
+// reopening a file of another size fails with pathoram.ErrInvalidConfig.
+func Open(path string, numBuckets, bucketSize, blockSize int) (*Storage, error) {
+	if numBuckets <= 0 || bucketSize <= 0 || blockSize <= 0 {
+		return nil, fmt.Errorf("%w: bucket count, bucket size and block size must be positive", pathoram.ErrInvalidConfig)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &Storage{f: f, numBuckets: numBuckets, bucketSize: bucketSize, blockSize: blockSize}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	want := int64(numBuckets) * s.recordSize()
+	switch fi.Size() {
+	case want:
+	case 0:
+		if err := f.Truncate(want); err != nil {
+			f.Close()
+			return nil, err
+		}
+	default:
+		f.Close()
+		return nil, fmt.Errorf("%w: %s is %d bytes, want %d for these dimensions", pathoram.ErrInvalidConfig, path, fi.Size(), want)
+	}
+	return s, nil
+}
+
+func (s *Storage) recordSize() int64 {
+	return int64(s.bucketSize) * int64(16+s.blockSize)
+}
+
+// ReadBucket reads the bucket at idx.
+func (s *Storage) ReadBucket(idx int) ([]pathoram.Block, error) {
+	buckets, err := s.ReadBuckets(context.Background(), []int{idx})
+	if err != nil {
+		return nil, err
+	}
+	return buckets[0], nil
+}
+
+// WriteBucket writes the bucket at idx and syncs the file.
+func (s *Storage) WriteBucket(idx int, blocks []pathoram.Block) error {
+	return s.WriteBuckets(context.Background(), []int{idx}, [][]pathoram.Block{blocks})
+}
+
+// ReadBuckets reads the buckets at idxs.
+func (s *Storage) ReadBuckets(ctx context.Context, idxs []int) ([][]pathoram.Block, error) {
+	buckets := make([][]pathoram.Block, len(idxs))
+	for i, idx := range idxs {
+		if idx < 0 || idx >= s.numBuckets {
+			return nil, pathoram.ErrInvalidConfig
+		}
+		rec := make([]byte, s.recordSize())
+		if _, err := s.f.ReadAt(rec, int64(idx)*s.recordSize()); err != nil {
+			return nil, err
+		}
+		blocks := make([]pathoram.Block, s.bucketSize)
+		for j := range blocks {
+			blocks[j] = pathoram.Block{
+				ID:   int(int64(binary.BigEndian.Uint64(rec))) - 1,
+				Leaf: int(int64(binary.BigEndian.Uint64(rec[8:]))) - 1,
+				Data: rec[16 : 16+s.blockSize : 16+s.blockSize],
+			}
+			rec = rec[16+s.blockSize:]
+		}
+		buckets[i] = blocks
+	}
+	return buckets, nil
+}
+
+// WriteBuckets writes each bucket in place, then syncs the file once. A
+// crash mid-batch can leave part of a path written.
+func (s *Storage) WriteBuckets(ctx context.Context, idxs []int, buckets [][]pathoram.Block) error {
+	for i, idx := range idxs {
+		if idx < 0 || idx >= s.numBuckets || len(buckets[i]) != s.bucketSize {
+			return pathoram.ErrInvalidConfig
+		}
+		rec := make([]byte, 0, s.recordSize())
+		for _, b := range buckets[i] {
+			if len(b.Data) != s.blockSize {
+				return pathoram.ErrInvalidDataSize
+			}
+			rec = binary.BigEndian.AppendUint64(rec, uint64(b.ID+1))
+			rec = binary.BigEndian.AppendUint64(rec, uint64(b.Leaf+1))
+			rec = append(rec, b.Data...)
+		}
+		if _, err := s.f.WriteAt(rec, int64(idx)*s.recordSize()); err != nil {
+			return err
+		}
+	}
+	if s.NoSync {
+		return nil
+	}
+	return s.f.Sync()
+}
+
+// NumBuckets returns the number of buckets.
+func (s *Storage) NumBuckets() int { return s.numBuckets }
+
+// BucketSize returns the number of blocks per bucket.
+func (s *Storage) BucketSize() int { return s.bucketSize }
+
+// BlockSize returns the stored size of each block.
+func (s *Storage) BlockSize() int { return s.blockSize }
+
+// Close closes the file.
+func (s *Storage) Close() error { return s.f.Close() }
@@ -0,0 +1,113 @@
+package filestorage
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func TestStorage_ORAMRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buckets")
+	enc, _ := pathoram.NewAESGCMEncryptor(bytes.Repeat([]byte{4}, 32))
+	cfg, _ := pathoram.Config{NumBlocks: 64, BlockSize: 32}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	s, err := Open(path, total, cfg.BucketSize, cfg.BlockSize+enc.Overhead())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	s.NoSync = true
+
+	pm := pathoram.NewInMemoryPositionMap()
+	oram, err := pathoram.New(cfg, s, pm, enc)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 32)); err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+	}
+	if err := oram.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := oram.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// A reopened file serves the same tree to a client with the same
+	// position map.
+	s, err = Open(path, total, cfg.BucketSize, cfg.BlockSize+enc.Overhead())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	oram, err = pathoram.New(cfg, s, pm, enc)
+	if err != nil {
+		t.Fatalf("New after reopen: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		got, err := oram.Read(i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 32)) {
+			t.Fatalf("Read(%d) after reopen = %x, %v", i, got, err)
+		}
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buckets")
+	if _, err := Open(path, 0, 4, 32); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("Open with 0 buckets: error = %v, want ErrInvalidConfig", err)
+	}
+	s, err := Open(path, 7, 4, 32)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	s.Close()
+	if _, err := Open(path, 7, 4, 64); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("reopen with another block size: error = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestStorage_Packed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buckets")
+	codec, _ := pathoram.NewAESGCMBucketCodec(bytes.Repeat([]byte{5}, 32))
+	cfg, _ := pathoram.Config{NumBlocks: 32, BlockSize: 32}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	f, err := Open(path, total, cfg.BucketSize, pathoram.PackedBlockSize(cfg.BucketSize, cfg.BlockSize, codec))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	f.NoSync = true
+	s, err := pathoram.NewPackedStorage(f, codec, cfg.BlockSize)
+	if err != nil {
+		t.Fatalf("NewPackedStorage: %v", err)
+	}
+	defer s.Close()
+	oram, err := pathoram.New(cfg, s, pathoram.NewInMemoryPositionMap(), pathoram.NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 32)); err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+	}
+	for i := range cfg.NumBlocks {
+		got, err := oram.Read(i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 32)) {
+			t.Fatalf("Read(%d) = %x, %v", i, got, err)
+		}
+	}
+	// The file records every slot as empty.
+	for idx := range total {
+		bucket, _ := f.ReadBucket(idx)
+		for _, b := range bucket {
+			if b.ID != pathoram.EmptyBlockID || b.Leaf != -1 {
+				t.Fatalf("bucket %d stores ID %d, leaf %d", idx, b.ID, b.Leaf)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package pathoram
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"fmt"
+)
+
+// keyFingerprintSize is the length of a KeyFingerprint.
+const keyFingerprintSize = 16
+
+// FingerprintStorage is implemented by storage backends that can hold the
+// fingerprint of the key their blocks are encrypted under, alongside the
+// tree.
+type FingerprintStorage interface {
+	// ReadFingerprint returns the stored fingerprint, or nil if none was
+	// written yet.
+	ReadFingerprint() ([]byte, error)
+
+	// WriteFingerprint replaces the stored fingerprint.
+	WriteFingerprint(fp []byte) error
+}
+
+// KeyFingerprinter is implemented by encryptors that can identify their key.
+// The built-in keyed encryptors implement it.
+type KeyFingerprinter interface {
+	// KeyFingerprint returns a short, non-reversible identifier of the key.
+	KeyFingerprint() []byte
+}
+
+// KeyFingerprint derives a 16-byte fingerprint of key with HKDF-SHA256. It
+// identifies the key without revealing it, so it may be stored next to the
+// ciphertexts. External Encryptor implementations can return it from
+// KeyFingerprinter.
+func KeyFingerprint(key []byte) []byte {
+	fp, err := hkdf.Key(sha256.New, key, nil, "pathoram key fingerprint", keyFingerprintSize)
+	if err != nil {
+		panic(err) // only for lengths HKDF-SHA256 cannot produce
+	}
+	return fp
+}
+
+// checkKeyFingerprint binds storage to the encryptor's key. On first use it
+// records the fingerprint; afterwards a different key fails with ErrWrongKey
+// before any bucket is read. It does nothing unless the encryptor implements
+// KeyFingerprinter and the storage implements FingerprintStorage.
+func checkKeyFingerprint(storage Storage, enc Encryptor) error {
+	kf, ok := enc.(KeyFingerprinter)
+	if !ok {
+		return nil
+	}
+	s, ok := storage.(FingerprintStorage)
+	if !ok {
+		return nil
+	}
+	want := kf.KeyFingerprint()
+	stored, err := s.ReadFingerprint()
+	if err != nil {
+		return fmt.Errorf("read key fingerprint: %w", err)
+	}
+	if stored == nil {
+		return s.WriteFingerprint(want)
+	}
+	if !bytes.Equal(stored, want) {
+		return ErrWrongKey
+	}
+	return nil
+}
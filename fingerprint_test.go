@@ -0,0 +1,75 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestKeyFingerprint_WrongKey(t *testing.T) {
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4}
+	_, _, buckets := cfg.ComputeTreeParams()
+	keyA := bytes.Repeat([]byte{0xA}, 32)
+	keyB := bytes.Repeat([]byte{0xB}, 32)
+
+	encA, _ := NewAESGCMEncryptor(keyA)
+	storage := NewInMemoryStorage(buckets, cfg.BucketSize, cfg.BlockSize+encA.Overhead())
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), encA)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	want := bytes.Repeat([]byte{7}, 16)
+	if _, err := oram.Write(3, want); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if fp, _ := storage.ReadFingerprint(); !bytes.Equal(fp, KeyFingerprint(keyA)) {
+		t.Fatalf("stored fingerprint = %x, want %x", fp, KeyFingerprint(keyA))
+	}
+
+	encB, _ := NewAESGCMEncryptor(keyB)
+	if _, err := New(cfg, storage, NewInMemoryPositionMap(), encB); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("New with wrong key: err = %v, want ErrWrongKey", err)
+	}
+
+	// Reopening with the right key works (the position map is lost, so only
+	// check that New accepts it).
+	encA2, _ := NewAESGCMEncryptor(keyA)
+	if _, err := New(cfg, storage, NewInMemoryPositionMap(), encA2); err != nil {
+		t.Fatalf("New with right key: %v", err)
+	}
+}
+
+func TestKeyFingerprint_Properties(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	fp := KeyFingerprint(key)
+	if len(fp) != keyFingerprintSize {
+		t.Fatalf("len = %d, want %d", len(fp), keyFingerprintSize)
+	}
+	if bytes.Contains(key, fp) || bytes.Contains(fp, key[:8]) {
+		t.Fatal("fingerprint exposes key bytes")
+	}
+	if !bytes.Equal(fp, KeyFingerprint(key)) {
+		t.Fatal("fingerprint not deterministic")
+	}
+	if bytes.Equal(fp, KeyFingerprint(bytes.Repeat([]byte{2}, 32))) {
+		t.Fatal("different keys share a fingerprint")
+	}
+
+	seg, _ := NewSegmentedEncryptor(key, 64, 0)
+	if !bytes.Equal(seg.KeyFingerprint(), fp) {
+		t.Fatal("SegmentedEncryptor fingerprint differs from KeyFingerprint")
+	}
+}
+
+func TestKeyFingerprint_Unkeyed(t *testing.T) {
+	// NoOpEncryptor has no key, so nothing is recorded.
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4}
+	_, _, buckets := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(buckets, cfg.BucketSize, cfg.BlockSize)
+	if _, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{}); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if fp, _ := storage.ReadFingerprint(); fp != nil {
+		t.Fatalf("fingerprint recorded for NoOpEncryptor: %x", fp)
+	}
+}
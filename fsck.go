@@ -0,0 +1,290 @@
+package pathoram
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// FsckKind classifies an invariant violation found by CheckInvariants or
+// Fsck.
+type FsckKind int
+
+const (
+	// FsckDuplicate: the block ID appears more than once in the tree and
+	// stash, e.g. after a torn bucket write.
+	FsckDuplicate FsckKind = iota
+
+	// FsckOffPath: a stored block is not on the path of its leaf, so no
+	// access would ever read it.
+	FsckOffPath
+
+	// FsckInvalidLeaf: the block's leaf is outside the tree.
+	FsckInvalidLeaf
+
+	// FsckInvalidID: the block ID is outside [0, NumBlocks).
+	FsckInvalidID
+
+	// FsckUnmapped: the block has no position map entry.
+	FsckUnmapped
+
+	// FsckWrongLeaf: the block's leaf differs from its position map entry.
+	FsckWrongLeaf
+
+	// FsckMissing: the position map has an entry for a block that is in
+	// neither the tree nor the stash. FsckStash, which cannot list the
+	// position map, reports such blocks as one problem with BlockID -1 and
+	// their number in Count.
+	FsckMissing
+)
+
+// String returns the kind's name.
+func (k FsckKind) String() string {
+	switch k {
+	case FsckDuplicate:
+		return "duplicate"
+	case FsckOffPath:
+		return "off-path"
+	case FsckInvalidLeaf:
+		return "invalid-leaf"
+	case FsckInvalidID:
+		return "invalid-id"
+	case FsckUnmapped:
+		return "unmapped"
+	case FsckWrongLeaf:
+		return "wrong-leaf"
+	case FsckMissing:
+		return "missing"
+	default:
+		return fmt.Sprintf("FsckKind(%d)", int(k))
+	}
+}
+
+// FsckProblem is one invariant violation.
+type FsckProblem struct {
+	Kind    FsckKind
+	BlockID int
+	Bucket  int // bucket holding the block; -1 for the stash or FsckMissing
+	Leaf    int // the block's leaf as stored
+	MapLeaf int // the position map's leaf, for FsckWrongLeaf
+	Count   int // blocks missing, for FsckMissing with BlockID -1
+}
+
+func (p FsckProblem) String() string {
+	where := "stash"
+	if p.Bucket >= 0 {
+		where = fmt.Sprintf("bucket %d", p.Bucket)
+	}
+	switch {
+	case p.Kind == FsckMissing && p.BlockID < 0:
+		return fmt.Sprintf("%d mapped blocks: %v", p.Count, p.Kind)
+	case p.Kind == FsckMissing:
+		return fmt.Sprintf("block %d: %v", p.BlockID, p.Kind)
+	case p.Kind == FsckWrongLeaf:
+		return fmt.Sprintf("block %d in %s: %v %d, position map %d", p.BlockID, where, p.Kind, p.Leaf, p.MapLeaf)
+	default:
+		return fmt.Sprintf("block %d in %s: %v (leaf %d)", p.BlockID, where, p.Kind, p.Leaf)
+	}
+}
+
+// FsckReport is the result of CheckInvariants or Fsck.
+type FsckReport struct {
+	Buckets     int // buckets scanned
+	TreeBlocks  int // real blocks found in the tree
+	StashBlocks int // stash entries checked (0 for Fsck)
+	Mapped      int // position map entries (PositionMap.Size)
+	Problems    []FsckProblem
+}
+
+// OK reports whether no violations were found.
+func (r FsckReport) OK() bool { return len(r.Problems) == 0 }
+
+// Err returns nil if the report is OK, and otherwise an error wrapping
+// ErrInvariantViolated that lists the first few problems.
+func (r FsckReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	const shown = 5
+	var b strings.Builder
+	for i, p := range r.Problems[:min(shown, len(r.Problems))] {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(p.String())
+	}
+	if n := len(r.Problems) - shown; n > 0 {
+		fmt.Fprintf(&b, "; and %d more", n)
+	}
+	return fmt.Errorf("%w: %s", ErrInvariantViolated, b.String())
+}
+
+// fsck accumulates a FsckReport over the blocks of a tree and stash.
+type fsck struct {
+	numLeaves int
+	numBlocks int // 0 if unknown
+	posMap    PositionMap
+	seen      map[int]bool
+	report    FsckReport
+}
+
+func newFsck(numLeaves, numBlocks int, posMap PositionMap) *fsck {
+	return &fsck{
+		numLeaves: numLeaves,
+		numBlocks: numBlocks,
+		posMap:    posMap,
+		seen:      make(map[int]bool),
+		report:    FsckReport{Mapped: posMap.Size()},
+	}
+}
+
+// bucket checks the blocks of bucket idx.
+func (f *fsck) bucket(idx int, blocks []Block) {
+	f.report.Buckets++
+	for _, b := range blocks {
+		if b.ID == EmptyBlockID {
+			continue
+		}
+		f.report.TreeBlocks++
+		if f.block(b.ID, b.Leaf, idx) {
+			if !onLeafPath(f.numLeaves, b.Leaf, idx) {
+				f.problem(FsckOffPath, b.ID, idx, b.Leaf, 0)
+			}
+		}
+	}
+}
+
+// block checks one block held in bucket (-1 for the stash) and reports
+// whether its leaf is valid.
+func (f *fsck) block(id, leaf, bucket int) bool {
+	if f.seen[id] {
+		f.problem(FsckDuplicate, id, bucket, leaf, 0)
+	}
+	f.seen[id] = true
+	if id < 0 || (f.numBlocks > 0 && id >= f.numBlocks) {
+		f.problem(FsckInvalidID, id, bucket, leaf, 0)
+	}
+	switch want, ok := f.posMap.Get(id); {
+	case !ok:
+		f.problem(FsckUnmapped, id, bucket, leaf, 0)
+	case want != leaf:
+		f.problem(FsckWrongLeaf, id, bucket, leaf, want)
+	}
+	if leaf < 0 || leaf >= f.numLeaves {
+		f.problem(FsckInvalidLeaf, id, bucket, leaf, 0)
+		return false
+	}
+	return true
+}
+
+// missing reports mapped blocks that were not seen. The position map cannot
+// be listed, so this probes every ID, and only when the counts disagree.
+// Without NumBlocks there is nothing to probe, and only the count is
+// reported.
+func (f *fsck) missing() {
+	if f.report.Mapped <= len(f.seen) {
+		return
+	}
+	if f.numBlocks == 0 {
+		f.report.Problems = append(f.report.Problems, FsckProblem{Kind: FsckMissing, BlockID: -1, Bucket: -1, Count: f.report.Mapped - len(f.seen)})
+		return
+	}
+	for id := range f.numBlocks {
+		if _, ok := f.posMap.Get(id); ok && !f.seen[id] {
+			f.problem(FsckMissing, id, -1, 0, 0)
+		}
+	}
+}
+
+func (f *fsck) problem(k FsckKind, id, bucket, leaf, mapLeaf int) {
+	f.report.Problems = append(f.report.Problems, FsckProblem{Kind: k, BlockID: id, Bucket: bucket, Leaf: leaf, MapLeaf: mapLeaf})
+}
+
+// CheckInvariants checks the Path ORAM invariants over the tree, the stash
+// and the position map: every real block sits on the path of its leaf,
+// which matches the position map; no block ID appears twice; stash entries
+// have valid leaves; and every mapped block is in the tree or the stash. It
+// reads every bucket directly, without decrypting block data (only the
+// slot headers, with Config.EncryptHeaders, after checking and stripping
+// any version tags) or verifying, and holds
+// the ORAM's lock while it does. The error is for storage failures;
+// violations are in the report (see FsckReport.Err).
+//
+// Use it after a crash or while developing an eviction strategy. The scan
+// reads the whole tree, which the storage server sees.
+func (o *PathORAM) CheckInvariants() (FsckReport, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return FsckReport{}, ErrClosed
+	}
+	ctx := context.Background()
+	f := newFsck(o.numLeaves, o.cfg.NumBlocks, o.posMap)
+	for idx := range 2*o.numLeaves - 1 {
+		blocks, err := o.fetchBucket(ctx, idx)
+		if err == nil && o.cfg.EncryptHeaders {
+			// Headers are sealed inside the version tags, as openBucket
+			// opens them
+			if o.versions != nil {
+				err = o.versions.open(idx, blocks)
+			}
+			if err == nil {
+				err = o.openHeaders(idx, blocks)
+			}
+		}
+		if err != nil {
+			return FsckReport{}, fmt.Errorf("bucket %d: %w", idx, err)
+		}
+		f.bucket(idx, blocks)
+	}
+	for _, b := range o.stash {
+		f.report.StashBlocks++
+		f.block(b.id, b.leaf, -1)
+	}
+	f.missing()
+	return f.report, nil
+}
+
+// Fsck checks the invariants CheckInvariants does over storage and a
+// position map without an ORAM, e.g. after a crash. The stash is not
+// consulted, so mapped blocks missing from the tree are not reported: they
+// were in the stash, and are lost if it was. Report.Mapped -
+// Report.TreeBlocks counts them. The storage must hold a complete tree
+// (2^h - 1 buckets).
+func Fsck(storage Storage, posMap PositionMap) (FsckReport, error) {
+	return FsckStash(storage, posMap, nil)
+}
+
+// FsckStash is Fsck that also checks stash, the Stash the ORAM was created
+// with (see Config.Stash), as CheckInvariants checks the live stash, and
+// reports mapped blocks in neither (see FsckMissing). A Stash is brought up
+// to date at the end of each successful access, so after a failed one, run
+// one more access first. A nil stash makes it Fsck.
+func FsckStash(storage Storage, posMap PositionMap, stash Stash) (FsckReport, error) {
+	n := storage.NumBuckets()
+	if n <= 0 || bits.OnesCount(uint(n+1)) != 1 {
+		return FsckReport{}, fmt.Errorf("%w: %d buckets is not a complete tree", ErrInvalidConfig, n)
+	}
+	f := newFsck((n+1)/2, 0, posMap)
+	for idx := range n {
+		blocks, err := storage.ReadBucket(idx)
+		if err != nil {
+			return FsckReport{}, fmt.Errorf("bucket %d: %w", idx, err)
+		}
+		f.bucket(idx, blocks)
+	}
+	if stash == nil {
+		return f.report, nil
+	}
+	err := stash.Iterate(func(e StashEntry) bool {
+		f.report.StashBlocks++
+		f.block(e.ID, e.Leaf, -1)
+		return true
+	})
+	if err != nil {
+		return FsckReport{}, fmt.Errorf("stash: %w", err)
+	}
+	f.missing()
+	return f.report, nil
+}
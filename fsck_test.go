@@ -0,0 +1,167 @@
+package pathoram
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func newFsckTestORAM(t *testing.T, cfg Config) (*PathORAM, *InMemoryStorage, PositionMap) {
+	t.Helper()
+	cfg, err := cfg.Validate()
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)
+	posMap := NewInMemoryPositionMap()
+	oram, err := New(cfg, storage, posMap, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for i := range 200 {
+		if _, err := oram.Write(i*7%cfg.NumBlocks, make([]byte, cfg.BlockSize)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	return oram, storage, posMap
+}
+
+func TestCheckInvariants(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"level-by-level": {NumBlocks: 64, BlockSize: 8},
+		"greedy":         {NumBlocks: 64, BlockSize: 8, EvictionStrategy: EvictGreedyByDepth},
+		"two-path":       {NumBlocks: 64, BlockSize: 8, EvictionStrategy: EvictDeterministicTwoPath},
+		"constant-time":  {NumBlocks: 64, BlockSize: 8, ConstantTime: true},
+		"cached levels":  {NumBlocks: 64, BlockSize: 8, CachedLevels: 2},
+	} {
+		oram, _, _ := newFsckTestORAM(t, cfg)
+		report, err := oram.CheckInvariants()
+		if err != nil || report.Err() != nil {
+			t.Errorf("%s: CheckInvariants = %+v, %v", name, report.Err(), err)
+			continue
+		}
+		if report.TreeBlocks+report.StashBlocks != 64 || report.Mapped != 64 || report.Buckets != 2*oram.NumLeaves()-1 {
+			t.Errorf("%s: report = %+v", name, report)
+		}
+	}
+}
+
+func TestCheckInvariantsDetectsCorruption(t *testing.T) {
+	oram, storage, posMap := newFsckTestORAM(t, Config{NumBlocks: 64, BlockSize: 8, Rand: NewSeededRand([32]byte{3})})
+	leafBucket := oram.NumLeaves() - 1
+
+	// Find a block stored below the root
+	var victim Block
+	var from int
+	for idx := storage.NumBuckets() - 1; idx > 0 && victim.Data == nil; idx-- {
+		bucket, _ := storage.ReadBucket(idx)
+		for _, b := range bucket {
+			if b.ID != EmptyBlockID {
+				victim, from = b, idx
+				break
+			}
+		}
+	}
+	// Move it to a leaf bucket off its path, and duplicate it into the root
+	wrong := leafBucket + (victim.Leaf+1)%oram.NumLeaves()
+	clearBlock(storage, from, victim.ID)
+	if !putBlock(storage, wrong, victim) || !putBlock(storage, 0, victim) {
+		t.Fatal("no free slot for the corrupted copies")
+	}
+	posMap.Set(victim.ID, (victim.Leaf+1)%oram.NumLeaves())
+
+	report, err := oram.CheckInvariants()
+	if err != nil {
+		t.Fatalf("CheckInvariants failed: %v", err)
+	}
+	kinds := make(map[FsckKind]bool)
+	for _, p := range report.Problems {
+		if p.BlockID != victim.ID {
+			t.Errorf("unexpected problem %v", p)
+		}
+		kinds[p.Kind] = true
+	}
+	for _, k := range []FsckKind{FsckDuplicate, FsckOffPath, FsckWrongLeaf} {
+		if !kinds[k] {
+			t.Errorf("no %v problem in %v", k, report.Problems)
+		}
+	}
+	if !errors.Is(report.Err(), ErrInvariantViolated) {
+		t.Fatalf("Err = %v, want ErrInvariantViolated", report.Err())
+	}
+
+	// Drop both copies: the block is missing
+	clearBlock(storage, wrong, victim.ID)
+	clearBlock(storage, 0, victim.ID)
+	report, _ = oram.CheckInvariants()
+	if len(report.Problems) != 1 || report.Problems[0] != (FsckProblem{Kind: FsckMissing, BlockID: victim.ID, Bucket: -1}) {
+		t.Fatalf("problems = %v, want %d missing", report.Problems, victim.ID)
+	}
+}
+
+func TestFsck(t *testing.T) {
+	oram, storage, posMap := newFsckTestORAM(t, Config{NumBlocks: 64, BlockSize: 8})
+	stash := oram.StashSize()
+	report, err := Fsck(storage, posMap)
+	if err != nil || !report.OK() {
+		t.Fatalf("Fsck = %+v, %v", report, err)
+	}
+	if report.Mapped-report.TreeBlocks != stash {
+		t.Fatalf("mapped %d, tree %d, want %d in the stash", report.Mapped, report.TreeBlocks, stash)
+	}
+
+	// A block in the tree the position map does not know
+	posMap2 := NewInMemoryPositionMap()
+	if report, _ := Fsck(storage, posMap2); report.OK() || report.Problems[0].Kind != FsckUnmapped {
+		t.Fatalf("Fsck with an empty position map = %v", report.Problems)
+	}
+	if _, err := Fsck(NewInMemoryStorage(6, 4, 8), posMap); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("Fsck of 6 buckets err = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestFsckStash(t *testing.T) {
+	stash := NewSliceStash()
+	oram, storage, posMap := newFsckTestORAM(t, Config{NumBlocks: 64, BlockSize: 8, Stash: stash})
+	report, err := FsckStash(storage, posMap, stash)
+	if err != nil || !report.OK() || report.StashBlocks != oram.StashSize() {
+		t.Fatalf("FsckStash = %+v, %v", report, err)
+	}
+
+	// The position map's entries cannot be listed, so a lost block is
+	// reported by count
+	for idx := range storage.NumBuckets() {
+		bucket, _ := storage.ReadBucket(idx)
+		if i := slices.IndexFunc(bucket, func(b Block) bool { return b.ID != EmptyBlockID }); i >= 0 {
+			clearBlock(storage, idx, bucket[i].ID)
+			break
+		}
+	}
+	report, _ = FsckStash(storage, posMap, stash)
+	if len(report.Problems) != 1 || report.Problems[0] != (FsckProblem{Kind: FsckMissing, BlockID: -1, Bucket: -1, Count: 1}) {
+		t.Fatalf("problems = %v, want 1 missing", report.Problems)
+	}
+}
+
+// putBlock stores b in a free slot of bucket idx, if there is one.
+func putBlock(s *InMemoryStorage, idx int, b Block) bool {
+	bucket, _ := s.ReadBucket(idx)
+	for i := range bucket {
+		if bucket[i].ID == EmptyBlockID {
+			bucket[i] = b
+			return s.WriteBucket(idx, bucket) == nil
+		}
+	}
+	return false
+}
+
+func clearBlock(s *InMemoryStorage, idx, id int) {
+	bucket, _ := s.ReadBucket(idx)
+	for i := range bucket {
+		if bucket[i].ID == id {
+			bucket[i] = Block{ID: EmptyBlockID, Leaf: -1, Data: make([]byte, len(bucket[i].Data))}
+		}
+	}
+	s.WriteBucket(idx, bucket)
+}
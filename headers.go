@@ -0,0 +1,108 @@
+package pathoram
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// headerSize is the length of the encrypted slot header (block ID and leaf)
+// that Config.EncryptHeaders prepends to each slot's data.
+const headerSize = 16
+
+// StoredBlockSize returns the size of Block.Data that storage created for
+// cfg and enc must hold per slot: the encrypted block, plus the encrypted
+// slot header with Config.EncryptHeaders and the version tag with
+// Config.VersionKey. It returns 0 if cfg is invalid.
+func StoredBlockSize(cfg Config, enc Encryptor) int {
+	cfg, err := cfg.Validate()
+	if err != nil {
+		return 0
+	}
+	return cfg.storedSize(enc)
+}
+
+// storedSize implements StoredBlockSize for a validated cfg.
+func (c Config) storedSize(enc Encryptor) int {
+	size := c.plainSize() + enc.Overhead()
+	if c.EncryptHeaders {
+		size += headerSize + enc.Overhead()
+	}
+	if c.VersionKey != nil {
+		size += versionTagSize
+	}
+	return size
+}
+
+// checkEncryptHeaders reports whether enc can hide slot headers.
+func checkEncryptHeaders(cfg Config, enc Encryptor) error {
+	if !cfg.EncryptHeaders {
+		return nil
+	}
+	if _, ok := enc.(NoOpEncryptor); ok || enc.Overhead() == 0 {
+		return fmt.Errorf("%w: EncryptHeaders requires an authenticated encryptor", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// sealHeaders returns the stored form of bucket idx's blocks under
+// Config.EncryptHeaders: every slot gets ID EmptyBlockID and Leaf -1, and
+// its data is the encryption, bound to idx, of its ID and leaf followed by
+// its data. Dummy slots carry fresh random bytes instead of whatever stale
+// ciphertext they held, so every slot of every write is a new encryption of
+// the same length. With Config.XORKey a dummy slot is instead the
+// recomputable dummy of xorReads, of the same length.
+func (o *PathORAM) sealHeaders(idx int, blocks []Block) ([]Block, error) {
+	size := o.cfg.plainSize() + o.encrypt.Overhead()
+	var epoch uint64
+	if o.xor != nil {
+		epoch = o.xor.seal(idx)
+	}
+	sealed := make([]Block, len(blocks))
+	for i, b := range blocks {
+		if b.ID == EmptyBlockID && o.xor != nil {
+			sealed[i] = Block{ID: EmptyBlockID, Leaf: -1, Data: o.xor.dummy(idx, epoch, i, o.cfg.storedSize(o.encrypt))}
+			continue
+		}
+		plain := make([]byte, headerSize, headerSize+size)
+		binary.LittleEndian.PutUint64(plain[0:], uint64(int64(b.ID)))
+		binary.LittleEndian.PutUint64(plain[8:], uint64(int64(b.Leaf)))
+		if b.ID == EmptyBlockID {
+			plain = plain[:headerSize+size]
+			if _, err := io.ReadFull(o.rng, plain[headerSize:]); err != nil {
+				return nil, ErrEncryptionFailed
+			}
+		} else {
+			plain = append(plain, b.Data...)
+		}
+		data, err := o.encrypt.Encrypt(EmptyBlockID, idx, plain)
+		if err != nil {
+			return nil, err
+		}
+		sealed[i] = Block{ID: EmptyBlockID, Leaf: -1, Data: data}
+	}
+	return sealed, nil
+}
+
+// openHeaders decrypts the slots sealHeaders stored in bucket idx, in place,
+// restoring their IDs and leaves. Slots of all zeros, as in storage that has
+// never been written, and XORKey dummies read as empty.
+func (o *PathORAM) openHeaders(idx int, blocks []Block) error {
+	for i := range blocks {
+		if isZero(blocks[i].Data) || (o.xor != nil && o.xor.isDummy(blocks[i].Data)) {
+			blocks[i] = Block{ID: EmptyBlockID, Leaf: -1, Data: blocks[i].Data[:0]}
+			continue
+		}
+		plain, err := o.encrypt.Decrypt(EmptyBlockID, idx, blocks[i].Data)
+		if err != nil || len(plain) < headerSize {
+			o.noteDecryptionFailure()
+			return fmt.Errorf("%w: bucket %d slot header", ErrDecryptionFailed, idx)
+		}
+		blocks[i] = Block{
+			ID:   int(int64(binary.LittleEndian.Uint64(plain[0:]))),
+			Leaf: int(int64(binary.LittleEndian.Uint64(plain[8:]))),
+			Data: plain[headerSize:],
+		}
+	}
+	return nil
+}
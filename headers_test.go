@@ -0,0 +1,146 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newEncryptedHeadersORAM(t *testing.T, opts ...Option) (*PathORAM, *InMemoryStorage) {
+	t.Helper()
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{3}, 32))
+	cfg := Config{NumBlocks: 32, BlockSize: 16, EncryptHeaders: true}
+	validated, _ := cfg.Validate()
+	_, _, total := validated.ComputeTreeParams()
+	storage := NewInMemoryStorage(total, validated.BucketSize, StoredBlockSize(cfg, enc))
+	opts = append([]Option{WithCapacity(32, 16), WithStashLimit(200), WithEncryptor(enc),
+		WithStorage(storage), WithEncryptedHeaders()}, opts...)
+	oram, err := NewORAM(opts...)
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	return oram, storage
+}
+
+func TestEncryptHeaders_HidesSlots(t *testing.T) {
+	oram, storage := newEncryptedHeadersORAM(t)
+	for i := range 32 {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	checkBlocks(t, oram, 32)
+	if report, err := oram.CheckInvariants(); err != nil || report.Err() != nil {
+		t.Fatalf("CheckInvariants = %v, %v", report.Err(), err)
+	}
+
+	// Every written slot looks alike: no IDs, no leaves, and ciphertexts of
+	// one length whether real or dummy.
+	size := StoredBlockSize(oram.cfg, oram.encrypt)
+	before := make(map[string]bool)
+	for idx := range storage.NumBuckets() {
+		bucket, _ := storage.ReadBucket(idx)
+		for _, b := range bucket {
+			if b.ID != EmptyBlockID || b.Leaf != -1 || len(b.Data) != size {
+				t.Fatalf("bucket %d stores ID %d, leaf %d, %d bytes", idx, b.ID, b.Leaf, len(b.Data))
+			}
+			before[string(b.Data)] = true
+		}
+	}
+
+	// Rewriting a path re-encrypts its dummies too.
+	oram.DummyAccess(t.Context())
+	root, _ := storage.ReadBucket(0)
+	for slot, b := range root {
+		if before[string(b.Data)] {
+			t.Errorf("root slot %d was written back unchanged", slot)
+		}
+	}
+}
+
+func TestEncryptHeaders_Tampering(t *testing.T) {
+	oram, storage := newEncryptedHeadersORAM(t)
+	for i := range 32 {
+		oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16))
+	}
+	root, _ := storage.ReadBucket(0)
+	storage.WriteBucket(1, root) // slots are bound to their bucket
+	var err error
+	for i := range 32 {
+		if _, err = oram.Read(i); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("reading a moved bucket: error = %v, want ErrDecryptionFailed", err)
+	}
+}
+
+func TestEncryptHeaders_Config(t *testing.T) {
+	if _, err := NewORAM(WithCapacity(16, 16), WithEncryptedHeaders()); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("without an encryptor: error = %v, want ErrInvalidConfig", err)
+	}
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{3}, 32))
+	cfg := Config{NumBlocks: 16, BlockSize: 16}
+	plain := StoredBlockSize(cfg, enc)
+	cfg.EncryptHeaders = true
+	if got := StoredBlockSize(cfg, enc); got != plain+headerSize+enc.Overhead() {
+		t.Errorf("StoredBlockSize = %d, want %d", got, plain+headerSize+enc.Overhead())
+	}
+
+	// A restart with a stored position map finds every block
+	cfg, _ = cfg.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total+PositionMapBuckets(cfg, enc), cfg.BucketSize, StoredBlockSize(cfg, enc))
+	pm := NewStoredPositionMap(NewInMemoryPositionMap(), cfg.NumBlocks, total, enc)
+	oram, err := New(cfg, storage, pm, enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16))
+	}
+	if err := oram.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := pm.SaveTo(storage); err != nil {
+		t.Fatalf("SaveTo failed: %v", err)
+	}
+	pm = NewStoredPositionMap(NewInMemoryPositionMap(), cfg.NumBlocks, total, enc)
+	if err := pm.LoadFrom(storage); err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+	if oram, err = New(cfg, storage, pm, enc); err != nil {
+		t.Fatalf("New after restart failed: %v", err)
+	}
+	checkBlocks(t, oram, cfg.NumBlocks)
+}
+
+func TestEncryptHeaders_WithVersions(t *testing.T) {
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{3}, 32))
+	cfg := Config{NumBlocks: 32, BlockSize: 16, EncryptHeaders: true, VersionKey: testVersionKey}
+	validated, _ := cfg.Validate()
+	_, _, total := validated.ComputeTreeParams()
+	storage := &rollbackStorage{InMemoryStorage: NewInMemoryStorage(total, validated.BucketSize, StoredBlockSize(cfg, enc))}
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for i := range 32 {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	checkBlocks(t, oram, 32)
+	report, err := oram.CheckInvariants()
+	if err != nil {
+		t.Fatalf("CheckInvariants failed: %v", err)
+	}
+	if err := report.Err(); err != nil || report.TreeBlocks+report.StashBlocks != 32 {
+		t.Fatalf("CheckInvariants found %d + %d blocks: %v", report.TreeBlocks, report.StashBlocks, err)
+	}
+	storage.rollback(0, 2)
+	if _, err := oram.Read(0); !errors.Is(err, ErrStaleBucket) {
+		t.Errorf("Read after rollback: error = %v, want ErrStaleBucket", err)
+	}
+}
@@ -0,0 +1,218 @@
+package httpstorage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// Client is a pathoram.Storage backed by a storage server speaking this
+// package's protocol. Path reads and write-backs are one POST /paths each.
+//
+// Client implements pathoram.StorageCtx and pathoram.BatchStorage.
+type Client struct {
+	// Compression is the gzip level for request bodies, as in
+	// compress/gzip, and asks the server to gzip its responses. Zero
+	// (gzip.NoCompression) sends and accepts identity bodies only. Set it
+	// before the Client's first use.
+	Compression int
+
+	base string
+	hc   *http.Client
+	info Info
+}
+
+var (
+	_ pathoram.StorageCtx   = (*Client)(nil)
+	_ pathoram.BatchStorage = (*Client)(nil)
+)
+
+// Dial returns a Client for the server at baseURL (e.g.
+// "https://storage.internal:8080"), fetching its dimensions with GET /info.
+// hc may be nil for http.DefaultClient; authentication and TLS settings go
+// in its Transport.
+func Dial(ctx context.Context, baseURL string, hc *http.Client) (*Client, error) {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	c := &Client{base: strings.TrimSuffix(baseURL, "/"), hc: hc}
+	body, err := c.do(ctx, http.MethodGet, "/info", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &c.info); err != nil {
+		return nil, err
+	}
+	if c.info.NumBuckets <= 0 || c.info.BucketSize <= 0 || c.info.BlockSize <= 0 {
+		return nil, fmt.Errorf("%w: server reported %+v", pathoram.ErrInvalidConfig, c.info)
+	}
+	return c, nil
+}
+
+// ReadBucket returns the bucket at idx.
+func (c *Client) ReadBucket(idx int) ([]pathoram.Block, error) {
+	return c.ReadBucketCtx(context.Background(), idx)
+}
+
+// WriteBucket writes the bucket at idx.
+func (c *Client) WriteBucket(idx int, blocks []pathoram.Block) error {
+	return c.WriteBucketCtx(context.Background(), idx, blocks)
+}
+
+// ReadBucketCtx is like ReadBucket but honors ctx.
+func (c *Client) ReadBucketCtx(ctx context.Context, idx int) ([]pathoram.Block, error) {
+	body, err := c.do(ctx, http.MethodGet, "/buckets/"+strconv.Itoa(idx), nil)
+	if err != nil {
+		return nil, err
+	}
+	var b Bucket
+	if err := json.Unmarshal(body, &b); err != nil {
+		return nil, err
+	}
+	return fromWire(b.Blocks), nil
+}
+
+// WriteBucketCtx is like WriteBucket but honors ctx.
+func (c *Client) WriteBucketCtx(ctx context.Context, idx int, blocks []pathoram.Block) error {
+	req, err := json.Marshal(Bucket{Blocks: toWire(blocks)})
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, http.MethodPut, "/buckets/"+strconv.Itoa(idx), req)
+	return err
+}
+
+// ReadBuckets returns the buckets at idxs with one POST /paths.
+func (c *Client) ReadBuckets(ctx context.Context, idxs []int) ([][]pathoram.Block, error) {
+	resp, err := c.paths(ctx, PathRequest{Read: idxs})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Buckets) != len(idxs) {
+		return nil, fmt.Errorf("httpstorage: got %d buckets for %d indices", len(resp.Buckets), len(idxs))
+	}
+	buckets := make([][]pathoram.Block, len(idxs))
+	for i, b := range resp.Buckets {
+		buckets[i] = fromWire(b.Blocks)
+	}
+	return buckets, nil
+}
+
+// WriteBuckets writes buckets[i] to the bucket at idxs[i] with one POST
+// /paths. Whether a failed batch leaves some buckets written depends on the
+// server's backend.
+func (c *Client) WriteBuckets(ctx context.Context, idxs []int, buckets [][]pathoram.Block) error {
+	req := PathRequest{Write: make([]BucketWrite, len(idxs))}
+	for i, idx := range idxs {
+		req.Write[i] = BucketWrite{Index: idx, Blocks: toWire(buckets[i])}
+	}
+	_, err := c.paths(ctx, req)
+	return err
+}
+
+// NumBuckets returns the number of buckets the server reported at Dial.
+func (c *Client) NumBuckets() int { return c.info.NumBuckets }
+
+// BucketSize returns the number of block slots per bucket.
+func (c *Client) BucketSize() int { return c.info.BucketSize }
+
+// BlockSize returns the size of each block's data in bytes.
+func (c *Client) BlockSize() int { return c.info.BlockSize }
+
+func (c *Client) paths(ctx context.Context, req PathRequest) (PathResponse, error) {
+	var resp PathResponse
+	body, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+	if body, err = c.do(ctx, http.MethodPost, "/paths", body); err != nil {
+		return resp, err
+	}
+	err = json.Unmarshal(body, &resp)
+	return resp, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	coding := ""
+	if body != nil && c.Compression != gzip.NoCompression && c.info.offers(Gzip) {
+		var err error
+		if body, err = compress(body, c.Compression); err != nil {
+			return nil, err
+		}
+		coding = Gzip
+	}
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, rd)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if coding != "" {
+		req.Header.Set("Content-Encoding", coding)
+	}
+	// Setting Accept-Encoding also stops http.Transport from asking for
+	// gzip on its own and hiding the coding from us.
+	if c.Compression != gzip.NoCompression {
+		req.Header.Set("Accept-Encoding", Gzip)
+	} else {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	rc, err := decompress(resp.Body, resp.Header.Get("Content-Encoding"), bodyLimit(c.info, maxPathBuckets))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		var e errorResponse
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			return nil, fmt.Errorf("httpstorage: %s", resp.Status)
+		}
+		return nil, remoteError(e.Error)
+	}
+	return data, nil
+}
+
+// remoteErrors are the sentinels a server's error messages are mapped back
+// to.
+var remoteErrors = []error{
+	pathoram.ErrInvalidConfig,
+	pathoram.ErrInvalidDataSize,
+	pathoram.ErrCorruptObject,
+}
+
+// remoteError converts a server error message to an error wrapping the
+// sentinel it starts with, if any.
+func remoteError(msg string) error {
+	for _, err := range remoteErrors {
+		if msg == err.Error() {
+			return err
+		}
+		if rest, ok := strings.CutPrefix(msg, err.Error()+": "); ok {
+			return fmt.Errorf("%w: %s", err, rest)
+		}
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,68 @@
+package httpstorage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// Gzip is the content coding a Handler accepts on request bodies and offers
+// on bucket responses, listed in Info.Encodings.
+const Gzip = "gzip"
+
+// compress returns body gzipped at level.
+func compress(body []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	zw.Write(body)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress wraps body, which carries coding, in a reader of at most limit
+// decoded bytes, so a small gzip body cannot expand without bound.
+func decompress(body io.Reader, coding string, limit int64) (io.ReadCloser, error) {
+	switch coding {
+	case "", "identity":
+		return io.NopCloser(io.LimitReader(body, limit)), nil
+	case Gzip:
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(zr, limit), zr}, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported Content-Encoding %q", pathoram.ErrInvalidConfig, coding)
+}
+
+// acceptsGzip reports whether r lists gzip in Accept-Encoding.
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, c := range strings.Split(v, ",") {
+			name, q, _ := strings.Cut(strings.TrimSpace(c), ";")
+			if strings.TrimSpace(name) == Gzip && strings.ReplaceAll(q, " ", "") != "q=0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// offers reports whether the server listed coding in Info.Encodings.
+func (i Info) offers(coding string) bool {
+	return slices.Contains(i.Encodings, coding)
+}
@@ -0,0 +1,157 @@
+package httpstorage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// wireCounter records the codings and sizes of /paths bodies on the wire.
+type wireCounter struct {
+	requestCodings  []string
+	responseCodings []string
+	bytes           int
+}
+
+func newCompressedServer(t *testing.T, h *Handler, legacy bool) (*httptest.Server, *wireCounter) {
+	t.Helper()
+	wc := &wireCounter{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" && legacy {
+			// A server predating compression lists no encodings.
+			writeJSON(w, http.StatusOK, Info{NumBuckets: h.info.NumBuckets, BucketSize: h.info.BucketSize, BlockSize: h.info.BlockSize})
+			return
+		}
+		if r.URL.Path != "/paths" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		wc.requestCodings = append(wc.requestCodings, r.Header.Get("Content-Encoding"))
+		wc.bytes += len(body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		wc.responseCodings = append(wc.responseCodings, rec.Header().Get("Content-Encoding"))
+		wc.bytes += rec.Body.Len()
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	return srv, wc
+}
+
+// roundTrip writes and reads back every bucket through c.
+func roundTrip(t *testing.T, c *Client) {
+	t.Helper()
+	idxs := make([]int, c.NumBuckets())
+	buckets := make([][]pathoram.Block, len(idxs))
+	for i := range idxs {
+		idxs[i] = i
+		buckets[i] = make([]pathoram.Block, c.BucketSize())
+		for j := range buckets[i] {
+			buckets[i][j] = pathoram.Block{ID: pathoram.EmptyBlockID, Data: bytes.Repeat([]byte{byte(i + j)}, c.BlockSize())}
+		}
+	}
+	if err := c.WriteBuckets(context.Background(), idxs, buckets); err != nil {
+		t.Fatalf("WriteBuckets: %v", err)
+	}
+	got, err := c.ReadBuckets(context.Background(), idxs)
+	if err != nil {
+		t.Fatalf("ReadBuckets: %v", err)
+	}
+	for i := range got {
+		for j := range got[i] {
+			if !bytes.Equal(got[i][j].Data, buckets[i][j].Data) {
+				t.Fatalf("bucket %d slot %d = %x, want %x", i, j, got[i][j].Data, buckets[i][j].Data)
+			}
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	var sizes [2]int
+	for i, level := range []int{gzip.NoCompression, gzip.HuffmanOnly} {
+		h := NewHandler(pathoram.NewInMemoryStorage(15, 4, 64))
+		h.Compression = level
+		srv, wc := newCompressedServer(t, h, false)
+		c, err := Dial(context.Background(), srv.URL, srv.Client())
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		c.Compression = level
+		roundTrip(t, c)
+
+		want := ""
+		if level != gzip.NoCompression {
+			want = Gzip
+		}
+		for _, got := range append(wc.requestCodings, wc.responseCodings...) {
+			if got != want {
+				t.Errorf("level %d: Content-Encoding %q, want %q", level, got, want)
+			}
+		}
+		sizes[i] = wc.bytes
+	}
+	if sizes[1] >= sizes[0] {
+		t.Errorf("compressed /paths traffic %d bytes, uncompressed %d", sizes[1], sizes[0])
+	}
+}
+
+func TestCompression_Negotiated(t *testing.T) {
+	// A client asking for compression sends identity bodies to a server
+	// that does not list gzip.
+	h := NewHandler(pathoram.NewInMemoryStorage(15, 4, 64))
+	srv, wc := newCompressedServer(t, h, true)
+	c, err := Dial(context.Background(), srv.URL, srv.Client())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	c.Compression = gzip.DefaultCompression
+	roundTrip(t, c)
+	for _, got := range append(wc.requestCodings, wc.responseCodings...) {
+		if got != "" {
+			t.Errorf("Content-Encoding %q to a server without gzip", got)
+		}
+	}
+}
+
+func TestCompression_Limits(t *testing.T) {
+	h := NewHandler(pathoram.NewInMemoryStorage(15, 4, 64))
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	post := func(coding string, body []byte) error {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/paths", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", coding)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("POST /paths: %v", err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		var e errorResponse
+		json.Unmarshal(data, &e)
+		return remoteError(e.Error)
+	}
+
+	// A small body that expands past the limit is rejected, not buffered.
+	bomb, _ := compress(append([]byte(`{"read":[`), bytes.Repeat([]byte(" "), 64<<20)...), gzip.BestCompression)
+	if err := post(Gzip, bomb); !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Errorf("gzip bomb: err = %v, want ErrInvalidDataSize", err)
+	}
+	if err := post("br", []byte("{}")); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("unknown coding: err = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,248 @@
+package httpstorage
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// maxPathBuckets bounds the buckets one POST /paths may read or write.
+const maxPathBuckets = 4096
+
+// Handler serves a pathoram.Storage over the protocol in this package.
+// Requests are applied one at a time, so the Storage need not be safe for
+// concurrent use, and a POST /paths write is applied as one
+// pathoram.BatchStorage call when the Storage supports it.
+//
+// A Handler accepts gzipped request bodies and advertises that in Info.
+type Handler struct {
+	// Compression is the gzip level for responses carrying buckets, as in
+	// compress/gzip, used when the client accepts gzip. Zero
+	// (gzip.NoCompression) always responds uncompressed. Set it before
+	// serving.
+	Compression int
+
+	mu    sync.Mutex
+	store pathoram.StorageV2
+	info  Info
+	mux   *http.ServeMux
+}
+
+// NewHandler returns a Handler serving s.
+func NewHandler(s pathoram.Storage) *Handler {
+	h := &Handler{
+		store: pathoram.AdaptStorage(s),
+		info:  Info{NumBuckets: s.NumBuckets(), BucketSize: s.BucketSize(), BlockSize: s.BlockSize(), Encodings: []string{Gzip}},
+		mux:   http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /info", h.handleInfo)
+	h.mux.HandleFunc("GET /buckets/{idx}", h.handleGet)
+	h.mux.HandleFunc("PUT /buckets/{idx}", h.handlePut)
+	h.mux.HandleFunc("POST /paths", h.handlePaths)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handleInfo(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	info := h.info
+	info.NumBuckets = h.store.NumBuckets()
+	h.mu.Unlock()
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (h *Handler) handleGet(w http.ResponseWriter, r *http.Request) {
+	idx, err := strconv.Atoi(r.PathValue("idx"))
+	if err != nil {
+		writeError(w, pathoram.ErrInvalidConfig)
+		return
+	}
+	h.mu.Lock()
+	buckets, err := h.store.ReadBuckets(r.Context(), []int{idx})
+	h.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h.writeBuckets(w, r, Bucket{Blocks: toWire(buckets[0])})
+}
+
+func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) {
+	idx, err := strconv.Atoi(r.PathValue("idx"))
+	if err != nil {
+		writeError(w, pathoram.ErrInvalidConfig)
+		return
+	}
+	var b Bucket
+	if err := h.decode(w, r, 1, &b); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := h.checkBucket(b.Blocks); err != nil {
+		writeError(w, err)
+		return
+	}
+	h.mu.Lock()
+	err = h.store.WriteBuckets(r.Context(), []int{idx}, [][]pathoram.Block{fromWire(b.Blocks)})
+	h.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handlePaths(w http.ResponseWriter, r *http.Request) {
+	var req PathRequest
+	if err := h.decode(w, r, maxPathBuckets, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(req.Read) > maxPathBuckets || len(req.Write) > maxPathBuckets {
+		writeError(w, pathoram.ErrInvalidConfig)
+		return
+	}
+	for _, bw := range req.Write {
+		if err := h.checkBucket(bw.Blocks); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	h.mu.Lock()
+	resp, err := h.applyPaths(r.Context(), req)
+	h.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h.writeBuckets(w, r, resp)
+}
+
+// applyPaths performs req's writes, then its reads. h.mu must be held.
+func (h *Handler) applyPaths(ctx context.Context, req PathRequest) (PathResponse, error) {
+	if len(req.Write) > 0 {
+		idxs := make([]int, len(req.Write))
+		buckets := make([][]pathoram.Block, len(req.Write))
+		for i, bw := range req.Write {
+			idxs[i], buckets[i] = bw.Index, fromWire(bw.Blocks)
+		}
+		if err := h.store.WriteBuckets(ctx, idxs, buckets); err != nil {
+			return PathResponse{}, err
+		}
+	}
+	resp := PathResponse{Buckets: make([]Bucket, 0, len(req.Read))}
+	if len(req.Read) > 0 {
+		buckets, err := h.store.ReadBuckets(ctx, req.Read)
+		if err != nil {
+			return PathResponse{}, err
+		}
+		for _, b := range buckets {
+			resp.Buckets = append(resp.Buckets, Bucket{Blocks: toWire(b)})
+		}
+	}
+	return resp, nil
+}
+
+// checkBucket rejects a bucket of the wrong shape before it reaches the
+// backend, which may not check.
+func (h *Handler) checkBucket(blocks []Block) error {
+	if len(blocks) != h.info.BucketSize {
+		return pathoram.ErrInvalidConfig
+	}
+	for _, b := range blocks {
+		if len(b.Data) != h.info.BlockSize {
+			return pathoram.ErrInvalidDataSize
+		}
+	}
+	return nil
+}
+
+// decode reads a JSON body of at most n buckets into v. The limit applies
+// to the decoded body, so it also bounds what a gzipped body expands to.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, n int, v any) error {
+	limit := bodyLimit(h.info, n)
+	body, err := decompress(http.MaxBytesReader(w, r.Body, limit), r.Header.Get("Content-Encoding"), limit)
+	if err != nil {
+		if errors.Is(err, pathoram.ErrInvalidConfig) {
+			return err
+		}
+		return pathoram.ErrInvalidDataSize
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return pathoram.ErrInvalidDataSize
+	}
+	return nil
+}
+
+// bodyLimit is the largest JSON body carrying n buckets of info's
+// dimensions.
+func bodyLimit(info Info, n int) int64 {
+	// Base64 inflates data by 4/3; allow slack for the JSON framing.
+	return int64(n)*int64(info.BucketSize)*int64(info.BlockSize/3*4+64) + 1024
+}
+
+// writeBuckets writes v, a response carrying buckets, gzipped at
+// h.Compression if the client accepts it.
+func (h *Handler) writeBuckets(w http.ResponseWriter, r *http.Request, v any) {
+	w.Header().Add("Vary", "Accept-Encoding")
+	if h.Compression == gzip.NoCompression || !acceptsGzip(r) {
+		writeJSON(w, http.StatusOK, v)
+		return
+	}
+	body, err := json.Marshal(v)
+	if err == nil {
+		body, err = compress(body, h.Compression)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Encoding", Gzip)
+	w.Write(body)
+}
+
+func toWire(blocks []pathoram.Block) []Block {
+	out := make([]Block, len(blocks))
+	for i, b := range blocks {
+		out[i] = Block{ID: b.ID, Leaf: b.Leaf, Data: b.Data}
+	}
+	return out
+}
+
+func fromWire(blocks []Block) []pathoram.Block {
+	out := make([]pathoram.Block, len(blocks))
+	for i, b := range blocks {
+		out[i] = pathoram.Block{ID: b.ID, Leaf: b.Leaf, Data: b.Data}
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError reports err with a status code matching its cause.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, pathoram.ErrInvalidConfig), errors.Is(err, pathoram.ErrInvalidDataSize):
+		status = http.StatusBadRequest
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusRequestTimeout
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
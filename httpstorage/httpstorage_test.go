@@ -0,0 +1,83 @@
+package httpstorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func newTestServer(t *testing.T, s pathoram.Storage) (*Client, *atomic.Int64) {
+	t.Helper()
+	var paths atomic.Int64
+	h := NewHandler(s)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/paths" {
+			paths.Add(1)
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := Dial(context.Background(), srv.URL+"/", srv.Client())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	return c, &paths
+}
+
+func TestClient_ORAMRoundTrip(t *testing.T) {
+	enc, _ := pathoram.NewAESGCMEncryptor(bytes.Repeat([]byte{4}, 32))
+	cfg, _ := pathoram.Config{NumBlocks: 64, BlockSize: 32, FetchParallelism: 1}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	c, paths := newTestServer(t, pathoram.NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize+enc.Overhead()))
+
+	oram, err := pathoram.New(cfg, c, pathoram.NewInMemoryPositionMap(), enc)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 32)); err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+	}
+	before := paths.Load()
+	for i := range cfg.NumBlocks {
+		got, err := oram.Read(i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 32)) {
+			t.Fatalf("Read(%d) = %x, %v", i, got, err)
+		}
+	}
+	// Each access reads its path and writes it back, both batched.
+	if n := paths.Load() - before; n > int64(4*cfg.NumBlocks) {
+		t.Errorf("%d POST /paths for %d reads; path I/O is not batched", n, cfg.NumBlocks)
+	}
+}
+
+func TestClient_BucketEndpoints(t *testing.T) {
+	c, _ := newTestServer(t, pathoram.NewInMemoryStorage(4, 2, 4))
+	if c.NumBuckets() != 4 || c.BucketSize() != 2 || c.BlockSize() != 4 {
+		t.Fatalf("dims = %d/%d/%d, want 4/2/4", c.NumBuckets(), c.BucketSize(), c.BlockSize())
+	}
+
+	blocks := []pathoram.Block{{ID: 1, Leaf: 0, Data: []byte("abcd")}, {ID: 2, Leaf: 1, Data: []byte("efgh")}}
+	if err := c.WriteBucket(3, blocks); err != nil {
+		t.Fatalf("WriteBucket: %v", err)
+	}
+	got, err := c.ReadBucket(3)
+	if err != nil || got[1].ID != 2 || !bytes.Equal(got[1].Data, []byte("efgh")) {
+		t.Errorf("ReadBucket(3) = %v, %v", got, err)
+	}
+	if _, err := c.ReadBucket(9); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("ReadBucket past end = %v, want ErrInvalidConfig", err)
+	}
+
+	bad := []pathoram.Block{{ID: 1, Leaf: 0, Data: []byte("ab")}, blocks[1]}
+	if err := c.WriteBuckets(context.Background(), []int{0}, [][]pathoram.Block{bad}); !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Errorf("WriteBuckets with short block = %v, want ErrInvalidDataSize", err)
+	}
+}
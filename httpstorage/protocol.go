@@ -0,0 +1,75 @@
+// Package httpstorage carries the ORAM bucket store over a plain REST
+// protocol, for deployments that cannot run gRPC. A Handler serves any
+// pathoram.Storage; a Client is a pathoram.Storage backed by such a server.
+// Every request is JSON, so a server can be inspected with curl.
+//
+// Endpoints:
+//
+//	GET  /info           storage dimensions (Info)
+//	GET  /buckets/{idx}  one bucket (Bucket)
+//	PUT  /buckets/{idx}  replace one bucket (Bucket)
+//	POST /paths          write and read several buckets at once (PathRequest)
+//
+// The server sees exactly what any pathoram.Storage sees — encrypted blocks
+// and their bucket indices — so it needs no trust beyond that.
+//
+// Bodies may be gzipped. A Handler lists the codings it accepts in
+// Info.Encodings, and a Client whose Compression is set gzips its request
+// bodies only when the server listed Gzip, so either side can be upgraded
+// first. Responses carrying buckets are gzipped when the client sends
+// Accept-Encoding: gzip and the Handler's Compression is set. Ciphertext
+// does not compress; what compression recovers is base64's 4/3 inflation
+// and the repeated JSON framing, which is why gzip.HuffmanOnly is usually
+// the right level. Compressed sizes vary with the blocks' IDs and leaves,
+// so an observer of the link learns what the server sees about them
+// (which slots are dummies, unless the ORAM sets EncryptHeaders); leave
+// compression off where that observer is not otherwise trusted as far as
+// the server.
+package httpstorage
+
+// Info is the response to GET /info.
+type Info struct {
+	NumBuckets int `json:"num_buckets"`
+	BucketSize int `json:"bucket_size"` // block slots per bucket
+	BlockSize  int `json:"block_size"`  // bytes per stored block
+
+	// Encodings are the content codings the server accepts on request
+	// bodies, such as Gzip. Servers predating compression omit it.
+	Encodings []string `json:"encodings,omitempty"`
+}
+
+// Block is one slot of a bucket.
+type Block struct {
+	ID   int    `json:"id"`
+	Leaf int    `json:"leaf"`
+	Data []byte `json:"data"`
+}
+
+// Bucket is the body of GET and PUT /buckets/{idx}.
+type Bucket struct {
+	Blocks []Block `json:"blocks"`
+}
+
+// BucketWrite replaces the bucket at Index.
+type BucketWrite struct {
+	Index  int     `json:"index"`
+	Blocks []Block `json:"blocks"`
+}
+
+// PathRequest is the body of POST /paths. The server applies Write first,
+// then returns the buckets at Read in order; either may be empty.
+type PathRequest struct {
+	Read  []int         `json:"read,omitempty"`
+	Write []BucketWrite `json:"write,omitempty"`
+}
+
+// PathResponse is the response to POST /paths, one Bucket per index in
+// PathRequest.Read.
+type PathResponse struct {
+	Buckets []Bucket `json:"buckets"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,230 @@
+package pathoram
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// IntegrityStorage is an optional extension of Storage for backends that can
+// hold the per-bucket hashes used by Config.VerifyIntegrity. The hashes are
+// untrusted; PathORAM checks them against the root hash it keeps in memory.
+type IntegrityStorage interface {
+	Storage
+
+	// ReadBucketHash returns the content and node hashes stored for bucket
+	// idx, or nil slices if none have been written.
+	ReadBucketHash(idx int) (content, node []byte, err error)
+
+	// WriteBucketHash stores the content and node hashes for bucket idx.
+	WriteBucketHash(idx int, content, node []byte) error
+}
+
+// maxTrustedNodes bounds the cache of verified hashes between accesses.
+const maxTrustedNodes = 1 << 12
+
+// merkleTree verifies buckets against a Merkle tree laid over the bucket
+// tree. A bucket's node hash is H(content hash || left node || right node);
+// only the root node hash needs to be trusted.
+type merkleTree struct {
+	store      IntegrityStorage
+	numBuckets int
+	root       []byte
+	trusted    map[int]merkleNode // nodes whose hashes chain to root
+}
+
+type merkleNode struct {
+	content, node []byte
+}
+
+// hashBucket returns the content hash of a bucket's stored blocks.
+func hashBucket(blocks []Block) []byte {
+	h := sha256.New()
+	var hdr [20]byte
+	for _, b := range blocks {
+		binary.BigEndian.PutUint64(hdr[0:], uint64(b.ID))
+		binary.BigEndian.PutUint64(hdr[8:], uint64(b.Leaf))
+		binary.BigEndian.PutUint32(hdr[16:], uint32(len(b.Data)))
+		h.Write(hdr[:])
+		h.Write(b.Data)
+	}
+	return h.Sum(nil)
+}
+
+// hashNode combines a bucket's content hash with its children's node hashes.
+// Missing children (below the leaves) contribute nothing.
+func hashNode(content, left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(content)
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// enableIntegrity attaches a Merkle tree to o. With a nil root the tree is
+// built from current storage contents and trusted as-is.
+func (o *PathORAM) enableIntegrity(root []byte) error {
+	store, ok := o.storage.(IntegrityStorage)
+	if !ok {
+		return fmt.Errorf("%w: VerifyIntegrity requires IntegrityStorage", ErrInvalidConfig)
+	}
+	m := &merkleTree{store: store, numBuckets: store.NumBuckets(), root: root}
+	if root == nil {
+		r, err := m.build(true)
+		if err != nil {
+			return err
+		}
+		m.root = r
+	}
+	o.integrity = m
+	return nil
+}
+
+// IntegrityRoot returns the trusted root hash, or nil if VerifyIntegrity is
+// off. Persist it alongside other client state and pass it back via
+// Config.IntegrityRoot when reopening persistent storage.
+func (o *PathORAM) IntegrityRoot() []byte {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.integrity == nil {
+		return nil
+	}
+	return bytes.Clone(o.integrity.root)
+}
+
+// build computes every bucket's hashes bottom-up from storage and returns the
+// root node hash. If write is set, the hashes are stored.
+func (m *merkleTree) build(write bool) ([]byte, error) {
+	nodes := make([][]byte, m.numBuckets)
+	for idx := m.numBuckets - 1; idx >= 0; idx-- {
+		blocks, err := m.store.ReadBucket(idx)
+		if err != nil {
+			return nil, err
+		}
+		content := hashBucket(blocks)
+		nodes[idx] = hashNode(content, m.child(nodes, 2*idx+1), m.child(nodes, 2*idx+2))
+		if write {
+			if err := m.store.WriteBucketHash(idx, content, nodes[idx]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	m.trusted = nil
+	return nodes[0], nil
+}
+
+func (m *merkleTree) child(nodes [][]byte, idx int) []byte {
+	if idx >= m.numBuckets {
+		return nil
+	}
+	return nodes[idx]
+}
+
+// trust returns the verified hashes of bucket idx, checking the chain of
+// stored hashes from idx up to the trusted root. The bucket tree is complete,
+// so every non-leaf bucket has two children.
+func (m *merkleTree) trust(idx int) (merkleNode, error) {
+	if n, ok := m.trusted[idx]; ok {
+		return n, nil
+	}
+	if idx == 0 {
+		content, node, err := m.store.ReadBucketHash(0)
+		if err != nil {
+			return merkleNode{}, err
+		}
+		if !bytes.Equal(node, m.root) {
+			return merkleNode{}, fmt.Errorf("%w: root hash mismatch", ErrIntegrityViolation)
+		}
+		m.trusted[0] = merkleNode{content, node}
+		return m.trusted[0], nil
+	}
+
+	parent := (idx - 1) / 2
+	p, err := m.trust(parent)
+	if err != nil {
+		return merkleNode{}, err
+	}
+	var kids [2]merkleNode
+	for i := range kids {
+		kids[i].content, kids[i].node, err = m.store.ReadBucketHash(2*parent + 1 + i)
+		if err != nil {
+			return merkleNode{}, err
+		}
+	}
+	if !bytes.Equal(hashNode(p.content, kids[0].node, kids[1].node), p.node) {
+		return merkleNode{}, fmt.Errorf("%w: hashes of bucket %d's children", ErrIntegrityViolation, parent)
+	}
+	m.trusted[2*parent+1] = kids[0]
+	m.trusted[2*parent+2] = kids[1]
+	return m.trusted[idx], nil
+}
+
+// resetCache starts a new operation, dropping cached nodes if there are many.
+func (m *merkleTree) resetCache() {
+	if m.trusted == nil || len(m.trusted) > maxTrustedNodes {
+		m.trusted = make(map[int]merkleNode)
+	}
+}
+
+// verify checks blocks read from bucket idx.
+func (m *merkleTree) verify(idx int, blocks []Block) error {
+	m.resetCache()
+	n, err := m.trust(idx)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hashBucket(blocks), n.content) {
+		return fmt.Errorf("%w: bucket %d", ErrIntegrityViolation, idx)
+	}
+	return nil
+}
+
+// update records new contents for bucket idx and recomputes node hashes up
+// to the root.
+func (m *merkleTree) update(idx int, blocks []Block) error {
+	m.resetCache()
+	// Verify the old chain first so the sibling hashes used below are trusted
+	if _, err := m.trust(idx); err != nil {
+		return err
+	}
+	content := hashBucket(blocks)
+	for {
+		var left, right []byte
+		if c := 2*idx + 1; c < m.numBuckets {
+			l, err := m.trust(c)
+			if err != nil {
+				return err
+			}
+			left, right = l.node, m.trusted[c+1].node
+		}
+		node := hashNode(content, left, right)
+		if err := m.store.WriteBucketHash(idx, content, node); err != nil {
+			return err
+		}
+		m.trusted[idx] = merkleNode{content, node}
+		if idx == 0 {
+			m.root = node
+			return nil
+		}
+		idx = (idx - 1) / 2
+		content = m.trusted[idx].content
+	}
+}
+
+// verifyBucket checks a bucket read from storage when VerifyIntegrity is on.
+func (o *PathORAM) verifyBucket(idx int, blocks []Block) error {
+	if o.integrity == nil {
+		return nil
+	}
+	return o.integrity.verify(idx, blocks)
+}
+
+// updateBucketHash records a bucket written to storage when VerifyIntegrity is on.
+func (o *PathORAM) updateBucketHash(idx int, blocks []Block) error {
+	if o.integrity == nil {
+		return nil
+	}
+	return o.integrity.update(idx, blocks)
+}
@@ -0,0 +1,129 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newIntegrityTestORAM(t *testing.T, cfg Config) (*PathORAM, *InMemoryStorage, PositionMap) {
+	t.Helper()
+	cfg.VerifyIntegrity = true
+	cfg, _ = cfg.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)
+	posMap := NewInMemoryPositionMap()
+	oram, err := New(cfg, storage, posMap, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return oram, storage, posMap
+}
+
+func TestVerifyIntegrity_Correctness(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"LevelByLevel", Config{EvictionStrategy: EvictLevelByLevel}},
+		{"GreedyByDepth", Config{EvictionStrategy: EvictGreedyByDepth}},
+		{"TwoPath", Config{EvictionStrategy: EvictDeterministicTwoPath}},
+		{"ConstantTime", Config{ConstantTime: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.NumBlocks, cfg.BlockSize, cfg.StashLimit = 64, 16, 200
+			oram, _, _ := newIntegrityTestORAM(t, cfg)
+			for i := 0; i < 64; i++ {
+				if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+					t.Fatalf("Write(%d) failed: %v", i, err)
+				}
+			}
+			checkBlocks(t, oram, 64)
+		})
+	}
+}
+
+func TestVerifyIntegrity_DetectsTampering(t *testing.T) {
+	oram, storage, _ := newIntegrityTestORAM(t, Config{NumBlocks: 32, BlockSize: 16})
+	if _, err := oram.Write(1, make([]byte, 16)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// The root bucket is on every path
+	storage.buckets[0][0].Data[0] ^= 1
+	if _, err := oram.Read(1); !errors.Is(err, ErrIntegrityViolation) {
+		t.Errorf("Read after tampering: got %v, want ErrIntegrityViolation", err)
+	}
+}
+
+func TestVerifyIntegrity_DetectsStaleBucket(t *testing.T) {
+	oram, storage, _ := newIntegrityTestORAM(t, Config{NumBlocks: 32, BlockSize: 16, BucketSize: 2})
+	for i := 0; i < 32; i++ {
+		if _, err := oram.Write(i, make([]byte, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+
+	// Replay the root bucket together with its matching old hashes
+	oldBucket, _ := storage.ReadBucket(0)
+	oldContent, oldNode, _ := storage.ReadBucketHash(0)
+	for i := 0; i < 32; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{1}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	storage.WriteBucket(0, oldBucket)
+	storage.WriteBucketHash(0, oldContent, oldNode)
+
+	if _, err := oram.Read(0); !errors.Is(err, ErrIntegrityViolation) {
+		t.Errorf("Read after replay: got %v, want ErrIntegrityViolation", err)
+	}
+}
+
+func TestVerifyIntegrity_Reopen(t *testing.T) {
+	cfg := Config{NumBlocks: 32, BlockSize: 16, VerifyIntegrity: true}
+	oram, storage, posMap := newIntegrityTestORAM(t, cfg)
+	if _, err := oram.Write(3, bytes.Repeat([]byte{3}, 16)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	root := oram.IntegrityRoot()
+
+	cfg.IntegrityRoot = root
+	reopened, err := New(cfg, storage, posMap, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New with IntegrityRoot failed: %v", err)
+	}
+	if got, err := reopened.Read(3); err != nil || got[0] != 3 {
+		t.Errorf("Read after reopen = (%x, %v)", got, err)
+	}
+
+	cfg.IntegrityRoot = bytes.Repeat([]byte{0xFF}, len(root))
+	wrong, _ := New(cfg, storage, posMap, NoOpEncryptor{})
+	if _, err := wrong.Read(3); !errors.Is(err, ErrIntegrityViolation) {
+		t.Errorf("Read with wrong root: got %v, want ErrIntegrityViolation", err)
+	}
+}
+
+func TestVerifyIntegrity_Resize(t *testing.T) {
+	oram, _, _ := newIntegrityTestORAM(t, Config{NumBlocks: 16, BlockSize: 16, StashLimit: 200})
+	for i := 0; i < 16; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	if err := oram.Resize(128); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	checkBlocks(t, oram, 16)
+}
+
+func TestVerifyIntegrity_RequiresIntegrityStorage(t *testing.T) {
+	cfg, _ := Config{NumBlocks: 16, BlockSize: 16, VerifyIntegrity: true}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	plain := struct{ Storage }{NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)}
+	if _, err := New(cfg, plain, NewInMemoryPositionMap(), NoOpEncryptor{}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,96 @@
+package pathoram
+
+import "context"
+
+// discardPrevious asks access to copy the previous value into an internal
+// scratch buffer rather than a new one, for callers that ignore it.
+var discardPrevious = []byte{}
+
+// ReadInto reads the block with the given ID into dst.
+// See ReadIntoCtx.
+func (o *PathORAM) ReadInto(blockID int, dst []byte) (int, error) {
+	return o.ReadIntoCtx(context.Background(), blockID, dst)
+}
+
+// ReadIntoCtx is like ReadCtx but copies the block into dst instead of
+// allocating a result, and returns the number of bytes written (BlockSize).
+// dst must be at least BlockSize bytes; a shorter dst returns
+// ErrInvalidDataSize without accessing the ORAM.
+func (o *PathORAM) ReadIntoCtx(ctx context.Context, blockID int, dst []byte) (int, error) {
+	return o.AccessIntoCtx(ctx, blockID, nil, dst)
+}
+
+// WriteFrom writes data to the block with the given ID.
+// See WriteFromCtx.
+func (o *PathORAM) WriteFrom(blockID int, data []byte) error {
+	return o.WriteFromCtx(context.Background(), blockID, data)
+}
+
+// WriteFromCtx is like WriteCtx but does not return the previous value, so
+// no result buffer is allocated. Use AccessIntoCtx to receive the previous
+// value in a caller-provided buffer.
+func (o *PathORAM) WriteFromCtx(ctx context.Context, blockID int, data []byte) error {
+	if blockID < 0 || blockID >= o.cfg.NumBlocks {
+		return ErrInvalidBlockID
+	}
+	if len(data) != o.cfg.BlockSize {
+		return ErrInvalidDataSize
+	}
+	if err := o.authorize(ctx, OpWrite, blockID); err != nil {
+		return err
+	}
+	_, err := o.observedAccess(ctx, blockID, data, discardPrevious)
+	return err
+}
+
+// AccessInto performs an access like Access, copying the result into dst.
+// See AccessIntoCtx.
+func (o *PathORAM) AccessInto(blockID int, newData, dst []byte) (int, error) {
+	return o.AccessIntoCtx(context.Background(), blockID, newData, dst)
+}
+
+// AccessIntoCtx is like AccessCtx but copies the block's value (the previous
+// value, for a write) into dst and returns the number of bytes written
+// (BlockSize). dst must be at least BlockSize bytes and must not overlap
+// newData.
+func (o *PathORAM) AccessIntoCtx(ctx context.Context, blockID int, newData, dst []byte) (int, error) {
+	if blockID < 0 || blockID >= o.cfg.NumBlocks {
+		return 0, ErrInvalidBlockID
+	}
+	if newData != nil && len(newData) != o.cfg.BlockSize {
+		return 0, ErrInvalidDataSize
+	}
+	if len(dst) < o.cfg.BlockSize {
+		return 0, ErrInvalidDataSize
+	}
+	dst = dst[:o.cfg.BlockSize]
+	op := OpWrite
+	if newData == nil {
+		op = OpRead
+	}
+	if err := o.authorize(ctx, op, blockID); err != nil {
+		return 0, err
+	}
+	if op == OpRead {
+		if data, ok, err := o.cachedRead(blockID); ok {
+			if err != nil {
+				return 0, err
+			}
+			return copy(dst, data), nil
+		}
+	}
+	if op == OpRead && o.cfg.CoalesceReads {
+		// Coalesced results are shared between readers, so copy out
+		data, err := o.coalescedRead(ctx, blockID)
+		if err != nil {
+			return 0, err
+		}
+		n := copy(dst, data)
+		o.ReleaseBuffer(data)
+		return n, nil
+	}
+	if _, err := o.observedAccess(ctx, blockID, newData, dst); err != nil {
+		return 0, err
+	}
+	return len(dst), nil
+}
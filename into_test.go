@@ -0,0 +1,93 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestReadIntoWriteFrom(t *testing.T) {
+	for _, ct := range []bool{false, true} {
+		cfg := Config{NumBlocks: 32, BlockSize: 16, StashLimit: 200, ConstantTime: ct}
+		oram, err := NewInMemory(cfg)
+		if err != nil {
+			t.Fatalf("NewInMemory failed: %v", err)
+		}
+		for i := 0; i < 32; i++ {
+			if err := oram.WriteFrom(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+				t.Fatalf("WriteFrom(%d) failed: %v", i, err)
+			}
+		}
+		dst := make([]byte, 20)
+		for i := 0; i < 32; i++ {
+			n, err := oram.ReadInto(i, dst)
+			if err != nil || n != 16 {
+				t.Fatalf("ReadInto(%d) = %d, %v", i, n, err)
+			}
+			if !bytes.Equal(dst[:n], bytes.Repeat([]byte{byte(i)}, 16)) {
+				t.Errorf("constantTime=%v: ReadInto(%d) = %x", ct, i, dst[:n])
+			}
+		}
+
+		// AccessInto returns the previous value for a write
+		n, err := oram.AccessInto(3, bytes.Repeat([]byte{0xff}, 16), dst)
+		if err != nil || !bytes.Equal(dst[:n], bytes.Repeat([]byte{3}, 16)) {
+			t.Errorf("AccessInto write = %x, %v", dst[:n], err)
+		}
+		// Unwritten blocks read as zeros even when dst held other data
+		oram2, _ := NewInMemory(Config{NumBlocks: 4, BlockSize: 16, ConstantTime: ct})
+		if n, err := oram2.ReadInto(1, dst); err != nil || !bytes.Equal(dst[:n], make([]byte, 16)) {
+			t.Errorf("ReadInto unwritten = %x, %v", dst[:n], err)
+		}
+	}
+}
+
+func TestReadInto_Errors(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 4, BlockSize: 16})
+	if _, err := oram.ReadInto(0, make([]byte, 15)); !errors.Is(err, ErrInvalidDataSize) {
+		t.Errorf("short dst: got %v, want ErrInvalidDataSize", err)
+	}
+	if _, err := oram.ReadInto(4, make([]byte, 16)); !errors.Is(err, ErrInvalidBlockID) {
+		t.Errorf("bad ID: got %v, want ErrInvalidBlockID", err)
+	}
+	if err := oram.WriteFrom(0, make([]byte, 8)); !errors.Is(err, ErrInvalidDataSize) {
+		t.Errorf("short data: got %v, want ErrInvalidDataSize", err)
+	}
+	if oram.Stats().Accesses != 0 {
+		t.Error("rejected calls accessed the ORAM")
+	}
+}
+
+func TestReadInto_Coalesced(t *testing.T) {
+	oram, _ := NewORAM(WithCapacity(8, 16), WithCoalescedReads())
+	oram.Write(2, bytes.Repeat([]byte{2}, 16))
+	dst := make([]byte, 16)
+	if n, err := oram.ReadInto(2, dst); err != nil || !bytes.Equal(dst[:n], bytes.Repeat([]byte{2}, 16)) {
+		t.Errorf("ReadInto = %x, %v", dst[:n], err)
+	}
+}
+
+func TestReadInto_FewerBytesAllocated(t *testing.T) {
+	// Both runs use the same seed so they take the same paths and differ
+	// only in the result buffer.
+	measure := func(into bool) uint64 {
+		oram, _ := NewORAM(WithCapacity(64, 4096), WithStashLimit(200), WithRand(NewSeededRand([32]byte{7})))
+		for id := range 64 {
+			oram.WriteFrom(id, make([]byte, 4096))
+		}
+		dst := make([]byte, 4096)
+		return bytesAllocated(func() {
+			for i := range 500 {
+				if into {
+					oram.ReadInto(i%64, dst)
+				} else {
+					oram.Read(i % 64)
+				}
+			}
+		})
+	}
+	plain, into := measure(false), measure(true)
+	if into+500*4096*9/10 > plain {
+		t.Errorf("bytes allocated: %d with ReadInto, %d with Read", into, plain)
+	}
+}
@@ -0,0 +1,122 @@
+package pathoram
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+)
+
+// KeyPurpose names a key a KeyManager derives. Each purpose gets an
+// independent key, so compromising or misusing one subsystem's key reveals
+// nothing about the others.
+type KeyPurpose string
+
+const (
+	KeyBucketEncryption KeyPurpose = "bucket-encryption" // Encryptor for tree blocks
+	KeyPositionMap      KeyPurpose = "position-map"      // StoredPositionMap encryptor, PRFPositionMap
+	KeyIntegrity        KeyPurpose = "integrity-mac"     // Config.VersionKey
+	KeyReplayCounter    KeyPurpose = "replay-counter"    // ReplayProtector
+	KeyXORDummies       KeyPurpose = "xor-dummies"       // Config.XORKey
+)
+
+// keyKDF identifies the derivation in KeyMetadata.
+const keyKDF = "HKDF-SHA256"
+
+// KeyMetadata describes how a KeyManager derives its keys. It holds no
+// secret and may be stored in the clear, e.g. as JSON next to the tree;
+// OpenKeyManager needs it, with the master key, to derive the same keys.
+type KeyMetadata struct {
+	KDF         string `json:"kdf"`         // always "HKDF-SHA256"
+	Salt        []byte `json:"salt"`        // random, fixed for the deployment
+	Generation  uint32 `json:"generation"`  // bumped by Rotate
+	Fingerprint []byte `json:"fingerprint"` // KeyFingerprint of the master key
+}
+
+// KeyManager derives per-purpose keys from one master key with HKDF-SHA256,
+// so the encryption key, the position map key, the integrity MAC key and the
+// replay counter key are never the same bytes. Derived keys depend on the
+// master key, the salt and the generation; Rotate moves every derived key
+// to a new generation without a new master key.
+type KeyManager struct {
+	master []byte
+	meta   KeyMetadata
+}
+
+// minMasterKeySize is the shortest master key NewKeyManager accepts.
+const minMasterKeySize = 32
+
+// NewKeyManager creates a KeyManager for a master key of at least 32 bytes
+// with a fresh random salt read from r (nil means crypto/rand). Save its
+// Metadata for OpenKeyManager.
+func NewKeyManager(master []byte, r io.Reader) (*KeyManager, error) {
+	if len(master) < minMasterKeySize {
+		return nil, fmt.Errorf("master key must be at least %d bytes, got %d", minMasterKeySize, len(master))
+	}
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(randReader(r), salt); err != nil {
+		return nil, fmt.Errorf("key salt: %w", err)
+	}
+	return &KeyManager{
+		master: append([]byte(nil), master...),
+		meta:   KeyMetadata{KDF: keyKDF, Salt: salt, Fingerprint: KeyFingerprint(master)},
+	}, nil
+}
+
+// OpenKeyManager recreates the KeyManager described by meta. It returns
+// ErrWrongKey if master is not the key meta was created with, and
+// ErrUnknownSchema for a derivation it does not know.
+func OpenKeyManager(master []byte, meta KeyMetadata) (*KeyManager, error) {
+	if meta.KDF != keyKDF {
+		return nil, fmt.Errorf("%w: key derivation %q", ErrUnknownSchema, meta.KDF)
+	}
+	if subtle.ConstantTimeCompare(KeyFingerprint(master), meta.Fingerprint) != 1 {
+		return nil, ErrWrongKey
+	}
+	meta.Salt = append([]byte(nil), meta.Salt...)
+	meta.Fingerprint = append([]byte(nil), meta.Fingerprint...)
+	return &KeyManager{master: append([]byte(nil), master...), meta: meta}, nil
+}
+
+// Metadata returns the KeyManager's metadata.
+func (k *KeyManager) Metadata() KeyMetadata {
+	m := k.meta
+	m.Salt = append([]byte(nil), m.Salt...)
+	m.Fingerprint = append([]byte(nil), m.Fingerprint...)
+	return m
+}
+
+// Key derives the 32-byte key for purpose in the current generation.
+func (k *KeyManager) Key(purpose KeyPurpose) ([]byte, error) {
+	if k.master == nil {
+		return nil, ErrClosed // wiped
+	}
+	info := fmt.Sprintf("pathoram %s generation %d", purpose, k.meta.Generation)
+	return hkdf.Key(sha256.New, k.master, k.meta.Salt, info, 32)
+}
+
+// Encryptor returns an AESGCMEncryptor under the KeyBucketEncryption key.
+func (k *KeyManager) Encryptor() (*AESGCMEncryptor, error) {
+	key, err := k.Key(KeyBucketEncryption)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	return NewAESGCMEncryptor(key)
+}
+
+// Rotate returns a KeyManager for the next generation, whose derived keys
+// are all new. Migrate the ORAM to them (Rebuild with the new Encryptor)
+// and then save the new Metadata.
+func (k *KeyManager) Rotate() *KeyManager {
+	n := &KeyManager{master: append([]byte(nil), k.master...), meta: k.Metadata()}
+	n.meta.Generation++
+	return n
+}
+
+// Wipe zeroes the master key; later derivations fail.
+func (k *KeyManager) Wipe() {
+	clear(k.master)
+	k.master = nil
+}
@@ -0,0 +1,90 @@
+package pathoram
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestKeyManager(t *testing.T) {
+	master := bytes.Repeat([]byte{7}, 32)
+	if _, err := NewKeyManager(master[:16], nil); err == nil {
+		t.Error("expected error for 16-byte master key")
+	}
+	km, err := NewKeyManager(master, nil)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	seen := make(map[string]KeyPurpose)
+	for _, p := range []KeyPurpose{KeyBucketEncryption, KeyPositionMap, KeyIntegrity, KeyReplayCounter} {
+		key, err := km.Key(p)
+		if err != nil || len(key) != 32 {
+			t.Fatalf("Key(%s) = %x, %v", p, key, err)
+		}
+		if bytes.Equal(key, master) {
+			t.Errorf("Key(%s) is the master key", p)
+		}
+		if q, dup := seen[string(key)]; dup {
+			t.Errorf("Key(%s) equals Key(%s)", p, q)
+		}
+		seen[string(key)] = p
+	}
+
+	// The metadata round-trips through JSON and reproduces the keys
+	raw, err := json.Marshal(km.Metadata())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var meta KeyMetadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	reopened, err := OpenKeyManager(master, meta)
+	if err != nil {
+		t.Fatalf("OpenKeyManager failed: %v", err)
+	}
+	a, _ := km.Key(KeyBucketEncryption)
+	b, _ := reopened.Key(KeyBucketEncryption)
+	if !bytes.Equal(a, b) {
+		t.Error("reopened KeyManager derives a different key")
+	}
+	if _, err := OpenKeyManager(bytes.Repeat([]byte{8}, 32), meta); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("OpenKeyManager with another key: error = %v, want ErrWrongKey", err)
+	}
+	meta.KDF = "PBKDF2"
+	if _, err := OpenKeyManager(master, meta); !errors.Is(err, ErrUnknownSchema) {
+		t.Errorf("OpenKeyManager with unknown KDF: error = %v, want ErrUnknownSchema", err)
+	}
+
+	// Another salt gives other keys, as does the next generation
+	other, _ := NewKeyManager(master, nil)
+	if c, _ := other.Key(KeyBucketEncryption); bytes.Equal(a, c) {
+		t.Error("two salts derive the same key")
+	}
+	rotated := km.Rotate()
+	if rotated.Metadata().Generation != 1 {
+		t.Errorf("Generation after Rotate = %d, want 1", rotated.Metadata().Generation)
+	}
+	if c, _ := rotated.Key(KeyBucketEncryption); bytes.Equal(a, c) {
+		t.Error("Rotate kept the bucket encryption key")
+	}
+
+	enc, err := km.Encryptor()
+	if err != nil {
+		t.Fatalf("Encryptor failed: %v", err)
+	}
+	oram, err := NewORAM(WithCapacity(16, 32), WithEncryptor(enc))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	if _, err := oram.Write(1, bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	km.Wipe()
+	if _, err := km.Key(KeyIntegrity); err == nil {
+		t.Error("Key after Wipe succeeded")
+	}
+}
@@ -0,0 +1,54 @@
+package pathoram
+
+import "fmt"
+
+// LeafAssigner chooses the leaf a block is remapped to after each access,
+// in place of the default uniform draw from Config.Rand. It exists for
+// research into biased or locality-aware remapping and for tests that need
+// to place blocks deterministically.
+//
+// Path ORAM's security rests on every remap being uniform and independent:
+// the server sees the leaf a block is remapped to when the block is next
+// accessed, so any bias or dependence on block IDs, old leaves or history
+// lets it link accesses to the same block or tell blocks apart. A custom
+// LeafAssigner therefore voids the obliviousness guarantee, and New rejects
+// one under any ThreatModel. Dummy accesses, eviction paths and the path
+// read for a never-written block stay uniform.
+type LeafAssigner interface {
+	// AssignLeaf returns a leaf in [0, numLeaves) for blockID, whose leaf
+	// until this access was oldLeaf, or -1 if it had none. It is called
+	// with the ORAM's lock held and must not access the ORAM.
+	AssignLeaf(blockID, oldLeaf, numLeaves int) int
+}
+
+// LeafAssignerFunc adapts a function to LeafAssigner.
+type LeafAssignerFunc func(blockID, oldLeaf, numLeaves int) int
+
+// AssignLeaf calls f.
+func (f LeafAssignerFunc) AssignLeaf(blockID, oldLeaf, numLeaves int) int {
+	return f(blockID, oldLeaf, numLeaves)
+}
+
+// assignLeaf returns the leaf blockID moves to: from Config.LeafAssigner if
+// set, otherwise uniformly at random. A leaf out of range is a bug in the
+// assigner and panics, as a failing Config.Rand does.
+func (o *PathORAM) assignLeaf(blockID int) int {
+	a := o.cfg.LeafAssigner
+	if a == nil {
+		return o.randomLeaf()
+	}
+	old, ok := o.position(blockID)
+	if !ok {
+		old = -1
+	}
+	leaf := a.AssignLeaf(blockID, old, o.numLeaves)
+	if leaf < 0 || leaf >= o.numLeaves {
+		panic(fmt.Sprintf("pathoram: LeafAssigner returned leaf %d for block %d, outside [0, %d)", leaf, blockID, o.numLeaves))
+	}
+	return leaf
+}
+
+// remap moves blockID to a newly assigned leaf.
+func (o *PathORAM) remap(blockID int) {
+	o.setPosition(blockID, o.assignLeaf(blockID))
+}
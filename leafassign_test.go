@@ -0,0 +1,59 @@
+package pathoram
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestLeafAssigner(t *testing.T) {
+	// Each block cycles through the leaves in order, starting at its ID.
+	var calls []int // oldLeaf of each call for block 3
+	assign := LeafAssignerFunc(func(blockID, oldLeaf, numLeaves int) int {
+		if blockID == 3 {
+			calls = append(calls, oldLeaf)
+		}
+		if oldLeaf < 0 {
+			return blockID % numLeaves
+		}
+		return (oldLeaf + 1) % numLeaves
+	})
+	oram, err := NewORAM(WithCapacity(32, 16), WithStashLimit(200), WithLeafAssigner(assign))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for id := range 32 {
+		if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", id, err)
+		}
+	}
+	for id := range 32 {
+		if leaf, _ := oram.posMap.Get(id); leaf != id%oram.numLeaves {
+			t.Errorf("block %d at leaf %d, want %d", id, leaf, id%oram.numLeaves)
+		}
+	}
+	for range 2 {
+		if got, err := oram.Read(3); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{3}, 16)) {
+			t.Fatalf("Read(3) = %x, %v", got, err)
+		}
+	}
+	if want := []int{-1, 3, 4}; !slices.Equal(calls, want) {
+		t.Errorf("oldLeaf arguments for block 3 = %v, want %v", calls, want)
+	}
+	if leaf, _ := oram.posMap.Get(3); leaf != 5 {
+		t.Errorf("block 3 at leaf %d after two reads, want 5", leaf)
+	}
+}
+
+func TestLeafAssigner_OutOfRangePanics(t *testing.T) {
+	oram, err := NewORAM(WithCapacity(8, 8), WithLeafAssigner(LeafAssignerFunc(func(_, _, n int) int { return n })))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a leaf out of range")
+		}
+	}()
+	oram.Write(0, make([]byte, 8))
+}
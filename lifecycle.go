@@ -0,0 +1,94 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxFlushPasses bounds the dummy evictions Flush performs.
+const maxFlushPasses = 4096
+
+// Flush evicts the whole stash to storage: it runs pending evictions, then
+// performs dummy accesses (as DrainStash does) until the stash is empty or
+// 4096 passes have run, returning ErrNotFlushed in that case, and finally
+// writes out the buckets buffered by MaxDirtyBuckets. Call it before
+// shutting down a persistent or remote backend so no block lives only in
+// client memory. Position map and other client state are not written.
+func (o *PathORAM) Flush(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return ErrClosed
+	}
+	return o.flush(ctx)
+}
+
+// flush implements Flush; o.mu must be held.
+func (o *PathORAM) flush(ctx context.Context) error {
+	if err := o.takeBackgroundErr(); err != nil {
+		return err
+	}
+	for len(o.pending) > 0 {
+		if err := o.evictOnePending(); err != nil {
+			return err
+		}
+	}
+	for pass := 0; len(o.stash) > 0; pass++ {
+		if pass == maxFlushPasses {
+			return fmt.Errorf("%w: %d blocks remain after %d passes", ErrNotFlushed, len(o.stash), pass)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := o.evictPass(ctx); err != nil {
+			return err
+		}
+	}
+	return o.flushWriteBack()
+}
+
+// Close flushes the stash (see Flush), stops background eviction, wipes
+// client state with Config.SecureDelete, and closes the storage backend if
+// it implements io.Closer. Afterwards every access returns ErrClosed;
+// closing again is a no-op.
+//
+// If the flush fails, Close returns its error and the instance stays open
+// (with background eviction still running), so the caller can retry. The
+// block cache (Config.CacheBlocks) is emptied and stays disabled either way,
+// after the dummy accesses owed for its hits have been performed.
+func (o *PathORAM) Close() error {
+	cacheErr := o.closeCache()
+	o.stopBackgroundEviction()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	if err := o.flush(context.Background()); err != nil {
+		if o.cfg.BackgroundEviction {
+			o.startBackgroundEviction()
+		}
+		return errors.Join(cacheErr, err)
+	}
+	o.wipe()
+	o.closed = true
+	if err := o.stopWriteBack(); err != nil {
+		cacheErr = errors.Join(cacheErr, err)
+	}
+	if c, ok := o.storage.(io.Closer); ok {
+		return errors.Join(cacheErr, c.Close())
+	}
+	if c, ok := o.store.(io.Closer); ok {
+		return errors.Join(cacheErr, c.Close())
+	}
+	return cacheErr
+}
+
+// Closed reports whether Close has completed.
+func (o *PathORAM) Closed() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.closed
+}
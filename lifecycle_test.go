@@ -0,0 +1,69 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+// closingStorage records whether Close was called.
+type closingStorage struct {
+	*InMemoryStorage
+	closed bool
+}
+
+func (s *closingStorage) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestFlush(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 64, BlockSize: 16, BucketSize: 2, StashLimit: 200})
+	for i := 0; i < 64; i++ {
+		oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16))
+	}
+	if err := oram.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if n := oram.StashSize(); n != 0 {
+		t.Fatalf("StashSize = %d after Flush, want 0", n)
+	}
+	for i := 0; i < 64; i++ {
+		if got, _ := oram.Read(i); !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Fatalf("Read(%d) = %v after Flush", i, got)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4}
+	_, _, total := cfg.ComputeTreeParams()
+	storage := &closingStorage{InMemoryStorage: NewInMemoryStorage(total, 4, 16)}
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for i := 0; i < 32; i++ {
+		oram.Write(i, make([]byte, 16))
+	}
+
+	if err := oram.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !storage.closed || !oram.Closed() || oram.StashSize() != 0 {
+		t.Fatalf("after Close: storage closed %v, Closed %v, stash %d", storage.closed, oram.Closed(), oram.StashSize())
+	}
+	if _, err := oram.Read(0); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Read after Close err = %v, want ErrClosed", err)
+	}
+	if err := oram.DummyAccess(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("DummyAccess after Close err = %v, want ErrClosed", err)
+	}
+	if err := oram.Flush(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Flush after Close err = %v, want ErrClosed", err)
+	}
+	if err := oram.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package pathoram
+
+import "context"
+
+// ReadMeta reads the block with the given ID and its metadata
+// (Config.MetadataSize bytes).
+func (o *PathORAM) ReadMeta(blockID int) (data, meta []byte, err error) {
+	return o.AccessMetaCtx(context.Background(), blockID, nil, nil)
+}
+
+// WriteMeta writes data and metadata to the block with the given ID and
+// returns the previous values.
+func (o *PathORAM) WriteMeta(blockID int, data, meta []byte) (prevData, prevMeta []byte, err error) {
+	if data == nil {
+		return nil, nil, ErrInvalidDataSize
+	}
+	return o.AccessMetaCtx(context.Background(), blockID, data, meta)
+}
+
+// AccessMeta performs an access like Access that also reads and sets the
+// block's metadata. See AccessMetaCtx.
+func (o *PathORAM) AccessMeta(blockID int, newData, newMeta []byte) (data, meta []byte, err error) {
+	return o.AccessMetaCtx(context.Background(), blockID, newData, newMeta)
+}
+
+// AccessMetaCtx is like AccessCtx but also returns the block's metadata
+// (the previous metadata, for a write). If newData is non-nil the metadata
+// is replaced with newMeta, which must be MetadataSize bytes or nil for
+// zeros; a read requires newMeta to be nil. The metadata travels inside the
+// block's ciphertext, so the access looks like any other.
+func (o *PathORAM) AccessMetaCtx(ctx context.Context, blockID int, newData, newMeta []byte) (data, meta []byte, err error) {
+	if blockID < 0 || blockID >= o.cfg.NumBlocks {
+		return nil, nil, ErrInvalidBlockID
+	}
+	if newData != nil && len(newData) != o.cfg.BlockSize {
+		return nil, nil, ErrInvalidDataSize
+	}
+	if newMeta != nil && (newData == nil || len(newMeta) != o.cfg.MetadataSize) {
+		return nil, nil, ErrInvalidDataSize
+	}
+	op := OpWrite
+	var full []byte
+	if newData == nil {
+		op = OpRead
+	} else {
+		full = make([]byte, o.cfg.plainSize())
+		copy(full, newData)
+		copy(full[o.cfg.BlockSize:], newMeta)
+	}
+	if err := o.authorize(ctx, op, blockID); err != nil {
+		return nil, nil, err
+	}
+	// Coalesced reads return data only, so metadata reads always access
+	result, err := o.observedAccess(ctx, blockID, full, make([]byte, o.cfg.plainSize()))
+	if err != nil {
+		return nil, nil, err
+	}
+	return result[:o.cfg.BlockSize:o.cfg.BlockSize], result[o.cfg.BlockSize:], nil
+}
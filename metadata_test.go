@@ -0,0 +1,99 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMetadata_RoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"aes", []Option{WithEncryptor(mustAES(t, key))}},
+		{"constant-time", []Option{WithConstantTime()}},
+		{"reuse-buffers", []Option{WithConfig(Config{NumBlocks: 32, BlockSize: 16, MetadataSize: 4, ReuseBuffers: true})}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{WithConfig(Config{NumBlocks: 32, BlockSize: 16, MetadataSize: 4})}, tc.opts...)
+			oram, err := NewORAM(opts...)
+			if err != nil {
+				t.Fatalf("NewORAM: %v", err)
+			}
+			for id := range 32 {
+				data := bytes.Repeat([]byte{byte(id)}, 16)
+				if _, _, err := oram.WriteMeta(id, data, []byte{0, 0, 0, byte(id)}); err != nil {
+					t.Fatalf("WriteMeta(%d): %v", id, err)
+				}
+			}
+			// Write keeps the metadata
+			oram.Write(3, bytes.Repeat([]byte{0xee}, 16))
+			for id := range 32 {
+				data, meta, err := oram.ReadMeta(id)
+				wantData := bytes.Repeat([]byte{byte(id)}, 16)
+				if id == 3 {
+					wantData = bytes.Repeat([]byte{0xee}, 16)
+				}
+				if err != nil || !bytes.Equal(data, wantData) || !bytes.Equal(meta, []byte{0, 0, 0, byte(id)}) {
+					t.Fatalf("ReadMeta(%d) = %x, %x, %v", id, data, meta, err)
+				}
+				if got, _ := oram.Read(id); len(got) != 16 {
+					t.Fatalf("Read(%d) returned %d bytes, want 16", id, len(got))
+				}
+			}
+			prev, prevMeta, err := oram.WriteMeta(5, make([]byte, 16), nil)
+			if err != nil || prev[0] != 5 || prevMeta[3] != 5 {
+				t.Fatalf("WriteMeta returned %x, %x, %v", prev, prevMeta, err)
+			}
+			if _, meta, _ := oram.ReadMeta(5); !bytes.Equal(meta, make([]byte, 4)) {
+				t.Errorf("nil metadata stored %x, want zeros", meta)
+			}
+		})
+	}
+}
+
+func TestMetadata_CopyAndDelete(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 16, BlockSize: 8, MetadataSize: 2})
+	oram.WriteMeta(1, []byte("abcdefgh"), []byte{1, 2})
+	if err := oram.Move(1, 2); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if data, meta, _ := oram.ReadMeta(2); string(data) != "abcdefgh" || !bytes.Equal(meta, []byte{1, 2}) {
+		t.Errorf("moved block = %q, %x", data, meta)
+	}
+	if _, meta, _ := oram.ReadMeta(1); !bytes.Equal(meta, []byte{0, 0}) {
+		t.Errorf("Move left source metadata %x", meta)
+	}
+	oram.Delete(2)
+	if _, meta, _ := oram.ReadMeta(2); !bytes.Equal(meta, []byte{0, 0}) {
+		t.Errorf("Delete left metadata %x", meta)
+	}
+}
+
+func TestMetadata_Validation(t *testing.T) {
+	if _, err := (Config{NumBlocks: 4, BlockSize: 4, MetadataSize: MaxMetadataSize + 1}).Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("oversized MetadataSize: %v", err)
+	}
+	oram, _ := NewInMemory(Config{NumBlocks: 4, BlockSize: 4, MetadataSize: 2})
+	if _, _, err := oram.WriteMeta(0, make([]byte, 4), make([]byte, 3)); !errors.Is(err, ErrInvalidDataSize) {
+		t.Errorf("wrong metadata size: %v", err)
+	}
+	if _, _, err := oram.AccessMeta(0, nil, make([]byte, 2)); !errors.Is(err, ErrInvalidDataSize) {
+		t.Errorf("metadata on a read: %v", err)
+	}
+	if p := oram.Params(); p.MetadataSize != 2 {
+		t.Errorf("Params().MetadataSize = %d", p.MetadataSize)
+	}
+}
+
+func mustAES(t *testing.T, key []byte) *AESGCMEncryptor {
+	t.Helper()
+	enc, err := NewAESGCMEncryptor(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
@@ -0,0 +1,54 @@
+package pathoram
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// minimalForbiddenDeps are packages that only optional subsystems pull in.
+var minimalForbiddenDeps = []string{"encoding/gob"}
+
+// TestMinimalBuild checks that the pathoram_minimal tag drops optional
+// subsystems and does not grow the binary.
+func TestMinimalBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds binaries")
+	}
+
+	out, err := exec.Command("go", "list", "-deps", "-tags", "pathoram_minimal", ".").Output()
+	if err != nil {
+		t.Fatalf("go list: %v", err)
+	}
+	deps := strings.Fields(string(out))
+	for _, pkg := range minimalForbiddenDeps {
+		if slices.Contains(deps, pkg) {
+			t.Errorf("pathoram_minimal build depends on %s", pkg)
+		}
+	}
+
+	dir := t.TempDir()
+	size := func(tags ...string) int64 {
+		bin := filepath.Join(dir, "minimal"+strings.Join(tags, ""))
+		args := append([]string{"build", "-trimpath", "-ldflags=-s -w", "-o", bin}, tags...)
+		cmd := exec.Command("go", append(args, "./testdata/minimal")...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go build %v: %v\n%s", tags, err, out)
+		}
+		fi, err := os.Stat(bin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Size()
+	}
+
+	full := size()
+	minimal := size("-tags=pathoram_minimal")
+	t.Logf("binary size: full %d bytes, minimal %d bytes", full, minimal)
+	if minimal > full {
+		t.Errorf("pathoram_minimal binary (%d bytes) larger than full build (%d bytes)", minimal, full)
+	}
+}
@@ -0,0 +1,123 @@
+package pathoram
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// NamespaceCounts holds one namespace's access counts.
+type NamespaceCounts struct {
+	Real  uint64 // Read, Write and Access calls
+	Dummy uint64 // DummyAccess calls (padding)
+}
+
+// Ratio returns Real/Dummy: the fraction of the namespace's traffic that is
+// real rather than padding. It is +Inf if there are real accesses but no
+// dummies, and 0 if there are none of either.
+func (c NamespaceCounts) Ratio() float64 {
+	if c.Dummy == 0 {
+		if c.Real == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return float64(c.Real) / float64(c.Dummy)
+}
+
+// NamespaceStats tracks real and dummy accesses per namespace, where a
+// namespace is the principal attached to the access's context with
+// WithPrincipal (nil for unlabelled accesses). Principals must be comparable.
+//
+// Operators pad each tenant's traffic by issuing DummyAccess calls under the
+// tenant's principal. A leakage budget caps a namespace's real:dummy ratio;
+// when an access pushes the ratio above the budget, the alert function is
+// called once, and again only after the ratio has dropped back within budget.
+// Set it via Config.NamespaceStats.
+type NamespaceStats struct {
+	mu      sync.Mutex
+	counts  map[any]*namespaceState
+	budgets map[any]float64
+	alert   func(namespace any, c NamespaceCounts, budget float64)
+}
+
+type namespaceState struct {
+	NamespaceCounts
+	over bool // ratio currently exceeds the budget
+}
+
+// NewNamespaceStats creates an empty tracker. alert may be nil; it is
+// called synchronously on the access path and must be cheap.
+func NewNamespaceStats(alert func(namespace any, c NamespaceCounts, budget float64)) *NamespaceStats {
+	return &NamespaceStats{
+		counts:  make(map[any]*namespaceState),
+		budgets: make(map[any]float64),
+		alert:   alert,
+	}
+}
+
+// SetBudget sets the largest acceptable real:dummy ratio for namespace.
+// A budget of 0 removes it.
+func (s *NamespaceStats) SetBudget(namespace any, maxRatio float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maxRatio == 0 {
+		delete(s.budgets, namespace)
+		return
+	}
+	s.budgets[namespace] = maxRatio
+}
+
+// Counts returns the counts for namespace.
+func (s *NamespaceStats) Counts(namespace any) NamespaceCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.counts[namespace]; ok {
+		return st.NamespaceCounts
+	}
+	return NamespaceCounts{}
+}
+
+// Snapshot returns the counts of every namespace seen so far.
+func (s *NamespaceStats) Snapshot() map[any]NamespaceCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[any]NamespaceCounts, len(s.counts))
+	for ns, st := range s.counts {
+		out[ns] = st.NamespaceCounts
+	}
+	return out
+}
+
+// record counts one access by the principal in ctx and checks its budget.
+func (s *NamespaceStats) record(ctx context.Context, dummy bool) {
+	ns, _ := PrincipalFrom(ctx)
+	s.mu.Lock()
+	st, ok := s.counts[ns]
+	if !ok {
+		st = &namespaceState{}
+		s.counts[ns] = st
+	}
+	if dummy {
+		st.Dummy++
+	} else {
+		st.Real++
+	}
+	budget, hasBudget := s.budgets[ns]
+	over := hasBudget && st.Ratio() > budget
+	fire := over && !st.over && s.alert != nil
+	st.over = over
+	counts := st.NamespaceCounts
+	s.mu.Unlock()
+
+	if fire {
+		s.alert(ns, counts, budget)
+	}
+}
+
+// noteNamespace records an access with the configured NamespaceStats, if any.
+func (o *PathORAM) noteNamespace(ctx context.Context, dummy bool) {
+	if o.cfg.NamespaceStats != nil {
+		o.cfg.NamespaceStats.record(ctx, dummy)
+	}
+}
@@ -0,0 +1,64 @@
+package pathoram
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestNamespaceStats(t *testing.T) {
+	type alert struct {
+		ns     any
+		counts NamespaceCounts
+	}
+	var alerts []alert
+	stats := NewNamespaceStats(func(ns any, c NamespaceCounts, budget float64) {
+		alerts = append(alerts, alert{ns, c})
+	})
+	stats.SetBudget("alice", 1) // at most one real access per dummy
+	oram, err := NewORAM(WithCapacity(32, 16), WithNamespaceStats(stats))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+
+	alice := WithPrincipal(context.Background(), "alice")
+	bob := WithPrincipal(context.Background(), "bob")
+	data := make([]byte, 16)
+
+	// alice pads first, then goes over budget at 3:2
+	oram.DummyAccess(alice)
+	oram.DummyAccess(alice)
+	oram.WriteCtx(alice, 1, data)
+	oram.ReadCtx(alice, 1)
+	oram.ReadCtx(alice, 1) // 3 real : 2 dummy
+	oram.ReadCtx(alice, 1)
+	oram.ReadCtx(alice, 1) // still over; no second alert
+	// bob has no budget
+	oram.ReadCtx(bob, 2)
+	oram.Read(3)
+
+	if got := stats.Counts("alice"); got != (NamespaceCounts{Real: 5, Dummy: 2}) {
+		t.Errorf("alice counts = %+v", got)
+	}
+	snap := stats.Snapshot()
+	if snap["bob"].Real != 1 || snap[nil].Real != 1 {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	if len(alerts) != 1 || alerts[0].ns != "alice" || alerts[0].counts != (NamespaceCounts{Real: 3, Dummy: 2}) {
+		t.Errorf("alerts = %+v, want one for alice at 3:2", alerts)
+	}
+
+	// Padding back within budget re-arms the alert
+	for range 3 {
+		oram.DummyAccess(alice)
+	}
+	oram.ReadCtx(alice, 1)
+	oram.ReadCtx(alice, 1) // 7 real : 5 dummy
+	if len(alerts) != 2 {
+		t.Errorf("got %d alerts, want 2 after re-arming", len(alerts))
+	}
+
+	if r := (NamespaceCounts{Real: 1}).Ratio(); !math.IsInf(r, 1) {
+		t.Errorf("Ratio with no dummies = %v, want +Inf", r)
+	}
+}
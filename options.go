@@ -0,0 +1,239 @@
+package pathoram
+
+import (
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Option configures NewORAM.
+type Option func(*options)
+
+// options collects NewORAM settings before construction.
+type options struct {
+	cfg     Config
+	storage Storage
+	posMap  PositionMap
+	enc     Encryptor
+}
+
+// WithConfig sets the base configuration. Options applied after it override
+// individual fields.
+func WithConfig(cfg Config) Option {
+	return func(o *options) { o.cfg = cfg }
+}
+
+// WithCapacity sets the number of blocks and the block size in bytes.
+func WithCapacity(numBlocks, blockSize int) Option {
+	return func(o *options) {
+		o.cfg.NumBlocks = numBlocks
+		o.cfg.BlockSize = blockSize
+	}
+}
+
+// WithBucketSize sets Z, the number of blocks per bucket.
+func WithBucketSize(z int) Option {
+	return func(o *options) { o.cfg.BucketSize = z }
+}
+
+// WithStashLimit sets the maximum stash size.
+func WithStashLimit(n int) Option {
+	return func(o *options) { o.cfg.StashLimit = n }
+}
+
+// WithStrategy sets the eviction strategy.
+func WithStrategy(s EvictionStrategy) Option {
+	return func(o *options) { o.cfg.EvictionStrategy = s }
+}
+
+// WithConstantTime enables constant-time operations.
+func WithConstantTime() Option {
+	return func(o *options) { o.cfg.ConstantTime = true }
+}
+
+// WithBackgroundEviction defers eviction to a background goroutine.
+func WithBackgroundEviction() Option {
+	return func(o *options) { o.cfg.BackgroundEviction = true }
+}
+
+// WithIntegrity enables Merkle verification of buckets. root is the trusted
+// root hash from a previous session, or nil to trust current storage.
+func WithIntegrity(root []byte) Option {
+	return func(o *options) {
+		o.cfg.VerifyIntegrity = true
+		o.cfg.IntegrityRoot = root
+	}
+}
+
+// WithBucketVersions enables per-bucket version counters keyed by key.
+// versions restores counters from a previous session, or nil for new storage.
+func WithBucketVersions(key []byte, versions []uint64) Option {
+	return func(o *options) {
+		o.cfg.VersionKey = key
+		o.cfg.BucketVersions = versions
+	}
+}
+
+// WithEncryptedHeaders hides slot IDs and leaves from storage. See
+// Config.EncryptHeaders.
+func WithEncryptedHeaders() Option {
+	return func(o *options) { o.cfg.EncryptHeaders = true }
+}
+
+// WithRandomizedDummies re-encrypts every dummy slot on each bucket write.
+// See Config.RandomizeDummies.
+func WithRandomizedDummies() Option {
+	return func(o *options) { o.cfg.RandomizeDummies = true }
+}
+
+// WithRand sets the randomness source for leaves and built-in encryptor
+// nonces. See Config.Rand.
+func WithRand(r io.Reader) Option {
+	return func(o *options) { o.cfg.Rand = r }
+}
+
+// WithLeafAssigner sets a custom leaf remapping policy (see LeafAssigner).
+// For research and tests only: it voids the obliviousness guarantee.
+func WithLeafAssigner(a LeafAssigner) Option {
+	return func(o *options) { o.cfg.LeafAssigner = a }
+}
+
+// WithCoalescedReads serves concurrent reads of the same block from a
+// single access. See Config.CoalesceReads.
+func WithCoalescedReads() Option {
+	return func(o *options) { o.cfg.CoalesceReads = true }
+}
+
+// WithStrictReads makes reads of unwritten blocks return ErrBlockNotFound.
+// See Config.StrictReads.
+func WithStrictReads() Option {
+	return func(o *options) { o.cfg.StrictReads = true }
+}
+
+// WithCryptoParallelism encrypts up to n of an eviction's blocks
+// concurrently. The encryptor must be safe for concurrent use.
+func WithCryptoParallelism(n int) Option {
+	return func(o *options) { o.cfg.CryptoParallelism = n }
+}
+
+// WithFetchParallelism reads up to n buckets of a path concurrently. The
+// storage backend must allow concurrent ReadBucket calls.
+func WithFetchParallelism(n int) Option {
+	return func(o *options) { o.cfg.FetchParallelism = n }
+}
+
+// WithReuseBuffers pools block buffers to reduce allocations. See
+// Config.ReuseBuffers for the aliasing rules.
+func WithReuseBuffers() Option {
+	return func(o *options) { o.cfg.ReuseBuffers = true }
+}
+
+// WithThreatModel selects a security preset. See Config.ThreatModel.
+func WithThreatModel(m ThreatModel) Option {
+	return func(o *options) { o.cfg.ThreatModel = m }
+}
+
+// WithNamespaceStats tracks real and dummy accesses per principal.
+func WithNamespaceStats(s *NamespaceStats) Option {
+	return func(o *options) { o.cfg.NamespaceStats = s }
+}
+
+// WithUniformAccessTime pads every access to d, or to a calibrated
+// duration with AutoCalibrate.
+func WithUniformAccessTime(d time.Duration) Option {
+	return func(o *options) { o.cfg.UniformAccessTime = d }
+}
+
+// WithCachedLevels keeps the top k tree levels in client memory.
+func WithCachedLevels(k int) Option {
+	return func(o *options) { o.cfg.CachedLevels = k }
+}
+
+// WithSecureDelete zeroizes evicted plaintext and wipes keys on Close.
+func WithSecureDelete() Option {
+	return func(o *options) { o.cfg.SecureDelete = true }
+}
+
+// WithStorage sets the storage backend. Default: InMemoryStorage sized for
+// the tree and the encryptor's overhead.
+func WithStorage(s Storage) Option {
+	return func(o *options) { o.storage = s }
+}
+
+// WithStorageV2 sets a context-aware storage backend (see StorageV2).
+func WithStorageV2(s StorageV2) Option {
+	return func(o *options) { o.storage = StorageFromV2(s) }
+}
+
+// WithPositionMap sets the position map. Default: InMemoryPositionMap, or
+// ObliviousPositionMap with ConstantTime.
+func WithPositionMap(pm PositionMap) Option {
+	return func(o *options) { o.posMap = pm }
+}
+
+// WithEncryptor sets the block encryptor. Default: NoOpEncryptor.
+func WithEncryptor(e Encryptor) Option {
+	return func(o *options) { o.enc = e }
+}
+
+// WithMetrics sets the StatsCollector receiving per-access events.
+func WithMetrics(c StatsCollector) Option {
+	return func(o *options) { o.cfg.StatsCollector = c }
+}
+
+// WithLogger sets the logger used to report failed accesses.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) { o.cfg.Logger = l }
+}
+
+// WithStash keeps the stash's store of record in s.
+func WithStash(s Stash) Option {
+	return func(o *options) { o.cfg.Stash = s }
+}
+
+// WithTrace passes each access's bucket reads and writes to r.
+func WithTrace(r TraceRecorder) Option {
+	return func(o *options) { o.cfg.Trace = r }
+}
+
+// WithReplicator streams client-state updates to a warm standby.
+func WithReplicator(r Replicator) Option {
+	return func(o *options) { o.cfg.Replicator = r }
+}
+
+// WithAuthorizer sets the per-operation access control hook.
+func WithAuthorizer(a Authorizer) Option {
+	return func(o *options) { o.cfg.Authorizer = a }
+}
+
+// NewORAM creates a PathORAM from functional options. It is the
+// forward-compatible alternative to New: new features add options rather
+// than constructor parameters.
+//
+//	oram, err := pathoram.NewORAM(
+//	    pathoram.WithCapacity(1000, 512),
+//	    pathoram.WithEncryptor(enc),
+//	    pathoram.WithStrategy(pathoram.EvictGreedyByDepth),
+//	)
+func NewORAM(opts ...Option) (*PathORAM, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg, err := o.cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if o.enc == nil {
+		o.enc = NoOpEncryptor{}
+	}
+	if o.posMap == nil {
+		o.posMap = defaultPositionMap(cfg)
+	}
+	if o.storage == nil {
+		_, _, totalBuckets := cfg.ComputeTreeParams()
+		o.storage = NewInMemoryStorage(totalBuckets, cfg.storedBucketSize(), cfg.storedSize(o.enc))
+	}
+	return New(cfg, o.storage, o.posMap, o.enc)
+}
@@ -0,0 +1,88 @@
+package pathoram
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewORAM(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	enc, _ := NewAESGCMEncryptor(key)
+	collector := &recordingCollector{}
+
+	oram, err := NewORAM(
+		WithCapacity(64, 32),
+		WithBucketSize(4),
+		WithStashLimit(50),
+		WithStrategy(EvictGreedyByDepth),
+		WithEncryptor(enc),
+		WithMetrics(collector),
+	)
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	if oram.cfg.BucketSize != 4 || oram.cfg.StashLimit != 50 || oram.cfg.EvictionStrategy != EvictGreedyByDepth {
+		t.Errorf("cfg = %+v", oram.cfg)
+	}
+	// Default storage must account for encryption overhead
+	if got, want := oram.storage.BlockSize(), 32+enc.Overhead(); got != want {
+		t.Errorf("storage BlockSize() = %d, want %d", got, want)
+	}
+
+	data := bytes.Repeat([]byte{0x3C}, 32)
+	if _, err := oram.Write(1, data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	got, _ := oram.Read(1)
+	if !bytes.Equal(got, data) {
+		t.Errorf("Read mismatch")
+	}
+	if collector.accesses != 2 {
+		t.Errorf("collector saw %d accesses, want 2", collector.accesses)
+	}
+}
+
+func TestNewORAM_Defaults(t *testing.T) {
+	oram, err := NewORAM(WithConfig(Config{NumBlocks: 10, BlockSize: 16}), WithConstantTime())
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	if oram.cfg.BucketSize != 5 || oram.cfg.StashLimit != 100 || !oram.cfg.ConstantTime {
+		t.Errorf("cfg = %+v", oram.cfg)
+	}
+
+	if _, err := NewORAM(); err != ErrInvalidConfig {
+		t.Errorf("NewORAM() error = %v, want ErrInvalidConfig", err)
+	}
+}
+
+// failingStorage fails every bucket read.
+type failingStorage struct {
+	*InMemoryStorage
+}
+
+func (failingStorage) ReadBucket(int) ([]Block, error) {
+	return nil, errors.New("backend unavailable")
+}
+
+func TestNewORAM_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	oram, _ := NewORAM(
+		WithCapacity(8, 16),
+		WithStorage(failingStorage{NewInMemoryStorage(3, 5, 16)}),
+		WithLogger(logger),
+	)
+	if _, err := oram.Write(0, make([]byte, 16)); err == nil {
+		t.Fatal("Write should fail")
+	}
+	if !strings.Contains(buf.String(), "backend unavailable") {
+		t.Errorf("log output %q does not mention the failure", buf.String())
+	}
+}
@@ -1,8 +1,12 @@
 package pathoram
 
 import (
-	"crypto/rand"
-	"math/big"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
 )
 
 // block represents a single data block (internal, plaintext).
@@ -13,16 +17,82 @@ type block struct {
 }
 
 // PathORAM implements the Path ORAM protocol.
+// It is safe for concurrent use; operations are serialized internally.
 type PathORAM struct {
+	mu sync.Mutex // guards all mutable state below
+
 	cfg       Config
 	height    int
 	numLeaves int
 
-	storage Storage     // pluggable storage backend
-	posMap  PositionMap // pluggable position map
-	encrypt Encryptor   // pluggable encryption
-
-	stash []block // blocks not yet written back to tree
+	storage Storage               // pluggable storage backend
+	store   StorageV2             // storage as called by the core (AdaptStorage)
+	arena   *arenaStorage         // storage, called directly, in embedded mode
+	posMap  PositionMap           // pluggable position map
+	leaves  *ObliviousPositionMap // posMap, indexed directly, in embedded mode
+	encrypt Encryptor             // pluggable encryption
+	rng     io.Reader             // leaf randomness (Config.Rand or crypto/rand)
+
+	xor *xorReads // XOR online reads (Config.XORKey)
+
+	// Single-slot online reads (Config.SingleBlockReads)
+	slotStore SlotStorage
+	detached  map[int]int   // blocks read into the stash by slot, to their stored copy's bucket
+	slotIDs   map[int][]int // block ID in each slot of each bucket written, by bucket
+
+	stash      []block      // blocks not yet written back to tree
+	stashStore Stash        // Config.Stash, or a SliceStash sharing stash
+	stored     map[int]bool // entries last synced to another Stash
+	changed    map[int]bool // synced entries changed in place since, for syncStash
+	closed     bool         // set by Close
+	unmapping  bool         // the current access is a Delete that unmaps its block
+	compacting bool         // the current access is a step of CompactPositions
+
+	accessCount uint64           // completed accesses
+	replay      *ReplayProtector // optional rollback detection
+	integrity   *merkleTree      // optional bucket verification (Config.VerifyIntegrity)
+	versions    *bucketVersions  // optional bucket rollback detection (Config.VersionKey)
+	stats       Stats            // cumulative counters
+
+	// Warm standby replication (Config.Replicator)
+	replSeq  uint64      // sequence number of the last update sent
+	posDelta map[int]int // position changes not yet sent
+
+	// Deferred eviction (Config.BackgroundEviction)
+	pending [][]int       // paths read but not yet evicted, oldest first
+	bgErr   error         // last background eviction error, returned by the next access
+	wake    chan struct{} // signals the eviction goroutine
+	stop    chan struct{} // closed by Close to stop the goroutine
+	stopped chan struct{} // closed when the goroutine exits
+
+	// Read coalescing (Config.CoalesceReads)
+	flightMu sync.Mutex      // guards flights; never held while taking mu
+	flights  map[int]*flight // in-flight reads by block ID
+
+	// Buffer pooling (Config.ReuseBuffers)
+	plainBufs  *bufferPool // BlockSize plaintext buffers
+	cipherBufs *bufferPool // stored-size ciphertext buffers
+	sealed     [][]byte    // ciphertexts from the current eviction
+
+	// Parallel encryption (Config.CryptoParallelism)
+	sealWorkers int       // goroutines per eviction; 1 encrypts in place
+	sealJobs    []sealJob // placed blocks awaiting encryption
+
+	// Asynchronous accesses (AccessAsync)
+	asyncMu      sync.Mutex      // guards the fields below; never held while taking mu
+	asyncQueue   []*asyncRequest // queued requests, oldest first
+	asyncRunning bool            // a runAsync goroutine is draining the queue
+
+	cache *blockCache // recently accessed plaintext (Config.CacheBlocks)
+
+	writeBack *writeBack // buffered bucket writes (Config.MaxDirtyBuckets); also o.store
+
+	// Access tracing (Config.Trace)
+	traceReads  []int // buckets read by the current access
+	traceWrites []int // buckets written by the current access
+
+	scratch []byte        // previous-value buffer for WriteFrom
+	padTo   time.Duration // access duration target (Config.UniformAccessTime); set once by New
 }
 
 // New creates a new PathORAM instance with explicit dependencies.
@@ -33,21 +103,118 @@ func New(cfg Config, storage Storage, posMap PositionMap, enc Encryptor) (*PathO
 		return nil, err
 	}
 
-	height, numLeaves, _ := cfg.ComputeTreeParams()
+	if err := checkThreatModel(cfg, enc); err != nil {
+		return nil, err
+	}
+	if err := checkEncryptHeaders(cfg, enc); err != nil {
+		return nil, err
+	}
+	if err := checkRandomizeDummies(cfg, enc); err != nil {
+		return nil, err
+	}
+	if err := checkKeyFingerprint(storage, enc); err != nil {
+		return nil, err
+	}
+
+	stored := cfg.storedSize(enc)
+	if limit := Capabilities(storage).MaxBlockSize; limit > 0 && stored > limit {
+		return nil, fmt.Errorf("%w: encrypted block size %d exceeds storage limit %d; use a smaller BlockSize or a segmenting backend",
+			ErrInvalidConfig, stored, limit)
+	}
+
+	height, numLeaves, totalBuckets := cfg.ComputeTreeParams()
 
-	return &PathORAM{
+	o := &PathORAM{
 		cfg:       cfg,
 		height:    height,
 		numLeaves: numLeaves,
 		storage:   storage,
 		posMap:    posMap,
 		encrypt:   enc,
+		rng:       randReader(cfg.Rand),
 		stash:     nil,
-	}, nil
+	}
+	o.store = AdaptStorage(storage)
+	o.arena, _ = storage.(*arenaStorage)
+	if pm, ok := posMap.(*ObliviousPositionMap); ok && o.arena != nil && !pm.scan {
+		o.leaves = pm
+	}
+	if cfg.SingleBlockReads {
+		if err := o.checkSingleBlockReads(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.XORKey != nil {
+		if err := o.checkXORReads(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ReuseBuffers {
+		o.plainBufs = newBufferPool(cfg.plainSize())
+		o.cipherBufs = newBufferPool(cfg.plainSize() + enc.Overhead())
+	}
+	o.sealWorkers = cryptoWorkers(cfg, enc)
+	if cfg.CacheBlocks > 0 {
+		o.cache = newBlockCache(cfg.CacheBlocks, cfg.SecureDelete)
+	}
+	if rs, ok := enc.(randSource); ok && cfg.Rand != nil {
+		rs.setRand(cfg.Rand)
+	}
+	if cfg.VersionKey != nil {
+		if o.versions, err = newBucketVersions(cfg.VersionKey, cfg.BucketVersions, totalBuckets); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.VerifyIntegrity {
+		if err := o.enableIntegrity(cfg.IntegrityRoot); err != nil {
+			return nil, err
+		}
+	}
+	o.stashStore = cfg.Stash
+	if o.stashStore == nil {
+		o.stashStore = NewSliceStash()
+	}
+	if err := o.loadStash(); err != nil {
+		return nil, err
+	}
+	if err := o.loadCachedLevels(context.Background()); err != nil {
+		return nil, err
+	}
+	if err := o.syncStash(); err != nil {
+		return nil, err
+	}
+	o.traceReads, o.traceWrites = nil, nil
+	if cfg.MaxDirtyBuckets > 0 {
+		if o.arena != nil || o.slotStore != nil {
+			return nil, fmt.Errorf("%w: MaxDirtyBuckets needs every bucket read to go through Storage (not embedded mode or SingleBlockReads)", ErrInvalidConfig)
+		}
+		// Merkle hashes and a persisted stash are written straight to
+		// storage and would get ahead of the buffered buckets they describe
+		if cfg.VerifyIntegrity || cfg.Stash != nil {
+			return nil, fmt.Errorf("%w: MaxDirtyBuckets cannot keep VerifyIntegrity hashes or Config.Stash consistent with buffered buckets", ErrInvalidConfig)
+		}
+		o.writeBack = newWriteBack(o.store, cfg.MaxDirtyBuckets)
+		o.store = o.writeBack
+	}
+	o.padTo = cfg.UniformAccessTime
+	if cfg.UniformAccessTime == AutoCalibrate {
+		if o.padTo, err = CalibrateAccessTime(context.Background(), o, calibrationAccesses); err != nil {
+			o.stopWriteBack()
+			return nil, err
+		}
+	}
+	if cfg.BackgroundEviction {
+		o.startBackgroundEviction()
+	}
+	return o, nil
 }
 
 // NewInMemory creates a new PathORAM instance with in-memory storage and no encryption.
 // This is the simplest way to create a PathORAM for testing or in-memory use.
+//
+// Up to 65536 blocks (without VerifyIntegrity) it runs in embedded mode:
+// blocks live in one contiguous arena that the ORAM calls directly, and
+// positions in an array, avoiding per-block allocations and interface calls.
 func NewInMemory(cfg Config) (*PathORAM, error) {
 	cfg, err := cfg.Validate()
 	if err != nil {
@@ -55,11 +222,15 @@ func NewInMemory(cfg Config) (*PathORAM, error) {
 	}
 
 	_, _, totalBuckets := cfg.ComputeTreeParams()
-
-	storage := NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.BlockSize)
-	posMap := NewInMemoryPositionMap()
 	enc := NoOpEncryptor{}
 
+	if useEmbedded(cfg) {
+		storage := newArenaStorage(totalBuckets, cfg.BucketSize, cfg.plainSize())
+		return New(cfg, storage, NewObliviousPositionMap(cfg.NumBlocks, false), enc)
+	}
+	storage := NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.plainSize())
+	posMap := defaultPositionMap(cfg)
+
 	return New(cfg, storage, posMap, enc)
 }
 
@@ -78,13 +249,49 @@ func (o *PathORAM) NumLeaves() int {
 	return o.numLeaves
 }
 
+// Params are an ORAM's effective tree parameters (see PathORAM.Params).
+type Params struct {
+	NumBlocks    int
+	BlockSize    int
+	MetadataSize int
+	BucketSize   int
+	Height       int
+	NumLeaves    int
+	TotalBuckets int
+	CachedLevels int
+	Utilization  float64 // NumBlocks / (BucketSize × TotalBuckets)
+}
+
+// Params returns the tree parameters in effect, after defaults, overrides
+// and any Resize.
+func (o *PathORAM) Params() Params {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	total := 1<<o.height - 1
+	return Params{
+		NumBlocks:    o.cfg.NumBlocks,
+		BlockSize:    o.cfg.BlockSize,
+		MetadataSize: o.cfg.MetadataSize,
+		BucketSize:   o.cfg.BucketSize,
+		Height:       o.height,
+		NumLeaves:    o.numLeaves,
+		TotalBuckets: total,
+		CachedLevels: o.cfg.CachedLevels,
+		Utilization:  float64(o.cfg.NumBlocks) / float64(o.cfg.BucketSize*total),
+	}
+}
+
 // StashSize returns the current number of blocks in the stash.
 func (o *PathORAM) StashSize() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	return len(o.stash)
 }
 
 // Size returns the number of allocated blocks.
 func (o *PathORAM) Size() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	return o.posMap.Size()
 }
 
@@ -93,26 +300,76 @@ func (o *PathORAM) BlockSize() int {
 	return o.cfg.BlockSize
 }
 
+// StashLimit returns the configured maximum stash size.
+func (o *PathORAM) StashLimit() int {
+	return o.cfg.StashLimit
+}
+
+// Logger returns Config.Logger, for packages layered on the ORAM that log
+// alongside it. It may be nil.
+func (o *PathORAM) Logger() *slog.Logger {
+	return o.cfg.Logger
+}
+
 // Access performs an oblivious read or write operation.
 // Valid block IDs are 0 to NumBlocks-1.
 // If newData is nil, performs a read and returns current data (zeros if block doesn't exist).
 // If newData is non-nil, performs a write and returns previous value.
 func (o *PathORAM) Access(blockID int, newData []byte) ([]byte, error) {
+	return o.AccessCtx(context.Background(), blockID, newData)
+}
+
+// AccessCtx is like Access but propagates ctx to the storage backend.
+// Cancellation is honored while the path is being read; once eviction has
+// started it runs to completion so that no block removed from the stash is lost.
+func (o *PathORAM) AccessCtx(ctx context.Context, blockID int, newData []byte) ([]byte, error) {
 	if blockID < 0 || blockID >= o.cfg.NumBlocks {
 		return nil, ErrInvalidBlockID
 	}
 	if newData != nil && len(newData) != o.cfg.BlockSize {
 		return nil, ErrInvalidDataSize
 	}
-	return o.access(blockID, newData)
+	op := OpWrite
+	if newData == nil {
+		op = OpRead
+	}
+	if err := o.authorize(ctx, op, blockID); err != nil {
+		return nil, err
+	}
+	if op == OpRead {
+		if data, ok, err := o.cachedRead(blockID); ok {
+			return data, err
+		}
+	}
+	if op == OpRead && o.cfg.CoalesceReads {
+		return o.coalescedRead(ctx, blockID)
+	}
+	return o.observedAccess(ctx, blockID, newData, nil)
 }
 
 // Read reads the block with the given ID.
 func (o *PathORAM) Read(blockID int) ([]byte, error) {
+	return o.ReadCtx(context.Background(), blockID)
+}
+
+// ReadCtx is like Read but propagates ctx to the storage backend.
+func (o *PathORAM) ReadCtx(ctx context.Context, blockID int) ([]byte, error) {
 	if blockID < 0 || blockID >= o.cfg.NumBlocks {
 		return nil, ErrInvalidBlockID
 	}
-	data, err := o.access(blockID, nil)
+	if err := o.authorize(ctx, OpRead, blockID); err != nil {
+		return nil, err
+	}
+	if data, ok, err := o.cachedRead(blockID); ok {
+		return data, err
+	}
+	if o.cfg.StrictReads {
+		return o.strictRead(ctx, blockID)
+	}
+	if o.cfg.CoalesceReads {
+		return o.coalescedRead(ctx, blockID)
+	}
+	data, err := o.observedAccess(ctx, blockID, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -122,115 +379,350 @@ func (o *PathORAM) Read(blockID int) ([]byte, error) {
 // Write writes data to the block with the given ID.
 // Returns the previous value stored at this block.
 func (o *PathORAM) Write(blockID int, data []byte) ([]byte, error) {
+	return o.WriteCtx(context.Background(), blockID, data)
+}
+
+// WriteCtx is like Write but propagates ctx to the storage backend.
+func (o *PathORAM) WriteCtx(ctx context.Context, blockID int, data []byte) ([]byte, error) {
 	if blockID < 0 || blockID >= o.cfg.NumBlocks {
 		return nil, ErrInvalidBlockID
 	}
 	if len(data) != o.cfg.BlockSize {
 		return nil, ErrInvalidDataSize
 	}
-	return o.access(blockID, data)
+	if err := o.authorize(ctx, OpWrite, blockID); err != nil {
+		return nil, err
+	}
+	return o.observedAccess(ctx, blockID, data, nil)
 }
 
-// randomLeaf returns a cryptographically random leaf index.
-func (o *PathORAM) randomLeaf() int {
-	n, err := rand.Int(rand.Reader, big.NewInt(int64(o.numLeaves)))
+// readBucket reads and checks a bucket, passing ctx to the backend.
+func (o *PathORAM) readBucket(ctx context.Context, idx int) ([]Block, error) {
+	blocks, err := o.fetchBucket(ctx, idx)
+	if err != nil {
+		return nil, err
+	}
+	return o.openBucket(idx, blocks)
+}
+
+// fetchBucket reads a bucket from storage without checking it. It touches no
+// PathORAM state, so several fetches may run concurrently.
+func (o *PathORAM) fetchBucket(ctx context.Context, idx int) ([]Block, error) {
+	if o.arena != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return o.arena.ReadBucket(idx)
+	}
+	return o.store.ReadBucket(ctx, idx)
+}
+
+// openBucket accounts for and checks a fetched bucket, stripping version
+// tags.
+func (o *PathORAM) openBucket(idx int, blocks []Block) ([]Block, error) {
+	o.noteBucketRead(blocks)
+	o.traceRead(idx)
+	if err := o.verifyBucket(idx, blocks); err != nil {
+		return nil, err
+	}
+	if o.versions != nil {
+		if err := o.versions.open(idx, blocks); err != nil {
+			return nil, err
+		}
+	}
+	if o.cfg.EncryptHeaders {
+		if err := o.openHeaders(idx, blocks); err != nil {
+			return nil, err
+		}
+	}
+	if o.xor != nil {
+		return o.dropSpare(idx, blocks)
+	}
+	return blocks, nil
+}
+
+// writeBucket writes a bucket, passing ctx to the backend.
+func (o *PathORAM) writeBucket(ctx context.Context, idx int, blocks []Block) error {
+	o.sealPending()
+	if o.slotStore != nil {
+		if o.xor != nil {
+			blocks = append(blocks, Block{ID: EmptyBlockID, Leaf: -1}) // the spare
+		}
+		o.permuteSlots(blocks)
+		o.overwriteDetached(idx)
+		o.noteSlots(idx, blocks)
+	}
+	if o.cfg.EncryptHeaders {
+		var err error
+		if blocks, err = o.sealHeaders(idx, blocks); err != nil {
+			return err
+		}
+	} else if o.cfg.RandomizeDummies {
+		if err := o.randomizeDummies(blocks); err != nil {
+			return err
+		}
+	}
+	var version uint64
+	if o.versions != nil {
+		blocks, version = o.versions.seal(idx, blocks)
+	}
+	var err error
+	if o.arena != nil {
+		err = o.arena.WriteBucket(idx, blocks)
+	} else {
+		err = o.store.WriteBucket(ctx, idx, blocks)
+	}
 	if err != nil {
-		panic("crypto/rand failed: " + err.Error())
+		delete(o.slotIDs, idx) // the stored order is unknown
+		return err
+	}
+	if o.versions != nil {
+		o.versions.commit(idx, version)
+	}
+	o.noteBucketWrite(blocks)
+	o.traceWrite(idx)
+	return o.updateBucketHash(idx, blocks)
+}
+
+// writeBuckets writes buckets[i] to the bucket at idxs[i] as writeBucket
+// does, in one StorageV2.WriteBuckets call, so a BatchStorage backend can
+// write back a whole path in one round trip or transaction.
+func (o *PathORAM) writeBuckets(ctx context.Context, idxs []int, buckets [][]Block) error {
+	o.sealPending()
+	if o.arena != nil {
+		for i, idx := range idxs {
+			if err := o.writeBucket(ctx, idx, buckets[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	sealed := make([][]Block, len(idxs))
+	versions := make([]uint64, len(idxs))
+	for i, idx := range idxs {
+		sealed[i] = buckets[i]
+		if o.slotStore != nil {
+			if o.xor != nil {
+				sealed[i] = append(sealed[i], Block{ID: EmptyBlockID, Leaf: -1}) // the spare
+			}
+			o.permuteSlots(sealed[i])
+			o.overwriteDetached(idx)
+			o.noteSlots(idx, sealed[i])
+		}
+		if o.cfg.EncryptHeaders {
+			var err error
+			if sealed[i], err = o.sealHeaders(idx, sealed[i]); err != nil {
+				return err
+			}
+		} else if o.cfg.RandomizeDummies {
+			if err := o.randomizeDummies(sealed[i]); err != nil {
+				return err
+			}
+		}
+		if o.versions != nil {
+			sealed[i], versions[i] = o.versions.seal(idx, sealed[i])
+		}
 	}
-	return int(n.Int64())
+	if err := o.store.WriteBuckets(ctx, idxs, sealed); err != nil {
+		for _, idx := range idxs {
+			delete(o.slotIDs, idx)
+		}
+		return err
+	}
+	for i, idx := range idxs {
+		if o.versions != nil {
+			o.versions.commit(idx, versions[i])
+		}
+		o.noteBucketWrite(sealed[i])
+		o.traceWrite(idx)
+		if err := o.updateBucketHash(idx, sealed[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// randomLeaf returns a uniformly random leaf index from Config.Rand.
+func (o *PathORAM) randomLeaf() int {
+	return randIntn(o.rng, o.numLeaves)
 }
 
 // access performs the core PathORAM access operation.
 // If newData is nil, it's a read; otherwise it's a write.
-func (o *PathORAM) access(blockID int, newData []byte) ([]byte, error) {
+func (o *PathORAM) access(ctx context.Context, blockID int, newData, dst []byte) ([]byte, error) {
+	if o.closed {
+		return nil, ErrClosed
+	}
+	if err := o.takeBackgroundErr(); err != nil {
+		return nil, err
+	}
+
 	// Step 1: Look up or assign leaf position
-	leaf, exists := o.posMap.Get(blockID)
+	leaf, exists := o.position(blockID)
+	if err := o.posMapErr(); err != nil {
+		return nil, err
+	}
 	if !exists {
 		leaf = o.randomLeaf()
 	}
 
-	// Step 2: Assign new random leaf for this block
-	o.posMap.Set(blockID, o.randomLeaf())
-
-	// Step 3: Read path into stash
-	path := o.Path(leaf)
-	if err := o.readPathIntoStash(path); err != nil {
+	// Step 2: Read path into stash, or with SingleBlockReads only the block.
+	// Blocks already moved to the stash stay there if this fails part-way.
+	path := o.storedPath(leaf)
+	if o.slotStore != nil {
+		if err := o.readBlockIntoStash(ctx, blockID, path); err != nil {
+			return nil, err
+		}
+	} else if err := o.readPathIntoStash(ctx, path); err != nil {
 		return nil, err
 	}
 
-	// Step 4: Find the requested block in stash
+	// Step 3: Assign new random leaf for this block, or for a Delete
+	// through a DeletablePositionMap drop the block instead. A compaction
+	// step moves the block to its new default leaf.
+	// Done after the path read so a failed read leaves the mapping intact.
 	var result []byte
+	if o.unmapping {
+		o.unmap(blockID)
+	} else {
+		if o.compacting {
+			o.setPosition(blockID, o.posMap.(*PRFPositionMap).sweep())
+		} else {
+			o.remap(blockID)
+		}
+
+		// Steps 4 and 5: read and update the block in the stash
+		result = o.stashAccess(blockID, newData, dst)
+	}
+
+	// Step 6: Eviction - write blocks back to path.
+	// Eviction must not be interrupted once blocks leave the stash.
+	ctx = context.WithoutCancel(ctx)
+	if o.slotStore != nil {
+		// The rest of the path is read now, for the eviction
+		if err := o.readPathIntoStash(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if o.cfg.BackgroundEviction {
+		err = o.deferEviction(path)
+	} else {
+		err = o.evictPath(ctx, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := o.recordAccess(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// stashAccess performs an access on a block whose path has been read into
+// the stash and whose new position is set: it copies the block's data into
+// dst (see access) and returns it, then applies newData if non-nil. A block
+// not in the stash reads as zeros and is added.
+func (o *PathORAM) stashAccess(blockID int, newData, dst []byte) []byte {
+	// Step 4: Find the requested block in stash, copying its data into
+	// dst or a new buffer
+	result := dst
+	switch {
+	case result == nil:
+		result = o.newBlockData()[:o.cfg.BlockSize]
+	case len(result) == 0:
+		// The caller discards the previous value (see discardPrevious)
+		if o.scratch == nil {
+			o.scratch = make([]byte, o.cfg.BlockSize)
+		}
+		result = o.scratch
+		clear(result)
+	default:
+		clear(result)
+	}
 	var foundIdx int
 	if o.cfg.ConstantTime {
-		foundIdx, result = o.findInStashConstantTime(blockID)
+		foundIdx = o.findInStashConstantTime(blockID, result)
 	} else {
-		foundIdx, result = o.findInStash(blockID)
+		foundIdx = o.findInStash(blockID, result)
 	}
 
 	// Step 5: Handle read/write
 	if foundIdx == -1 {
 		// Block not found - new block or first read
 		// Previous value is zeros (per Path ORAM spec)
-		result = make([]byte, o.cfg.BlockSize)
 		// Add block to stash
-		newLeaf, _ := o.posMap.Get(blockID)
+		newLeaf, _ := o.position(blockID)
 		newBlock := block{
 			id:   blockID,
 			leaf: newLeaf,
-			data: make([]byte, o.cfg.BlockSize),
+			data: o.newBlockData(),
 		}
 		if newData != nil {
 			copy(newBlock.data, newData)
 		}
 		o.stash = append(o.stash, newBlock)
+		o.noteStash()
 	} else {
 		// Update existing block
-		newLeaf, _ := o.posMap.Get(blockID)
+		newLeaf, _ := o.position(blockID)
 		o.stash[foundIdx].leaf = newLeaf
 		if newData != nil {
 			copy(o.stash[foundIdx].data, newData)
 		}
 	}
-
-	// Step 6: Eviction - write blocks back to path
-	var err error
-	if o.cfg.ConstantTime {
-		err = o.evictConstantTime(path)
-	} else {
-		err = o.evictWithStrategy(path)
-	}
-	if err != nil {
-		return nil, err
+	o.stashChanged(blockID)
+	if o.cache != nil {
+		if newData != nil {
+			o.cache.put(blockID, newData[:o.cfg.BlockSize])
+		} else {
+			o.cache.put(blockID, result[:o.cfg.BlockSize])
+		}
 	}
-
-	return result, nil
+	return result
 }
 
-// findInStash searches stash for blockID.
-// Returns (index, data) where index is -1 if not found.
-func (o *PathORAM) findInStash(blockID int) (int, []byte) {
+// findInStash searches stash for blockID and copies its data into dst.
+// Returns the stash index, or -1 if not found.
+func (o *PathORAM) findInStash(blockID int, dst []byte) int {
 	for i, b := range o.stash {
 		if b.id == blockID {
-			result := make([]byte, o.cfg.BlockSize)
-			copy(result, b.data)
-			return i, result
+			copy(dst, b.data)
+			return i
 		}
 	}
-	return -1, nil
+	return -1
 }
 
 // readPathIntoStash reads all blocks from path into stash.
-func (o *PathORAM) readPathIntoStash(path []int) error {
-	for _, bucketIdx := range path {
-		bucket, err := o.storage.ReadBucket(bucketIdx)
+func (o *PathORAM) readPathIntoStash(ctx context.Context, path []int) error {
+	o.recycleSealed()
+	fetched, err := o.fetchPath(ctx, path)
+	if err != nil {
+		return err
+	}
+	return o.moveIntoStash(ctx, path, fetched)
+}
+
+// moveIntoStash checks the fetched buckets at idxs, decrypts their blocks
+// into the stash and writes the buckets back empty.
+func (o *PathORAM) moveIntoStash(ctx context.Context, idxs []int, fetched [][]Block) error {
+	for j, bucketIdx := range idxs {
+		bucket, err := o.openBucket(bucketIdx, fetched[j])
 		if err != nil {
 			return err
 		}
 		for i := range bucket {
+			if o.detached != nil && o.isDetached(bucketIdx, bucket[i]) {
+				bucket[i].ID = EmptyBlockID
+				continue
+			}
 			if bucket[i].ID != EmptyBlockID {
 				// Decrypt block data
-				plaintext, err := o.encrypt.Decrypt(bucket[i].ID, bucket[i].Leaf, bucket[i].Data)
+				plaintext, err := o.decryptBlock(bucket[i])
 				if err != nil {
+					o.noteDecryptionFailure()
 					return err
 				}
 				o.stash = append(o.stash, block{
@@ -242,20 +734,35 @@ func (o *PathORAM) readPathIntoStash(path []int) error {
 				bucket[i].ID = EmptyBlockID
 			}
 		}
-		if err := o.storage.WriteBucket(bucketIdx, bucket); err != nil {
+		if err := o.writeBucket(ctx, bucketIdx, bucket); err != nil {
 			return err
 		}
 	}
+	o.noteStash()
 	return nil
 }
 
 // blockToStorage converts internal block to storage Block with encryption.
+// b must be leaving the stash: with ReuseBuffers its data is recycled.
 func (o *PathORAM) blockToStorage(b block) Block {
-	ciphertext, err := o.encrypt.Encrypt(b.id, b.leaf, b.data)
+	var ciphertext []byte
+	var err error
+	if be, ok := o.encrypt.(BufferedEncryptor); ok && o.cipherBufs != nil {
+		ciphertext, err = be.EncryptAppend(o.cipherBufs.get()[:0], b.id, b.leaf, b.data)
+		o.sealed = append(o.sealed, ciphertext)
+	} else {
+		ciphertext, err = o.encrypt.Encrypt(b.id, b.leaf, b.data)
+	}
 	if err != nil {
 		// Encryption should not fail with valid data
 		panic("encryption failed: " + err.Error())
 	}
+	if o.cfg.SecureDelete {
+		clear(b.data)
+	}
+	if o.plainBufs != nil {
+		o.plainBufs.put(b.data)
+	}
 	return Block{
 		ID:   b.id,
 		Leaf: b.leaf,
@@ -281,8 +788,13 @@ func (o *PathORAM) Path(leaf int) []int {
 // Uses ancestry check: bucket B is on leaf L's path iff L's leaf bucket
 // is in the subtree rooted at B.
 func (o *PathORAM) canPlaceAt(leaf, bucketIdx int) bool {
+	return onLeafPath(o.numLeaves, leaf, bucketIdx)
+}
+
+// onLeafPath is canPlaceAt for a tree with numLeaves leaves.
+func onLeafPath(numLeaves, leaf, bucketIdx int) bool {
 	// Leaf's bucket index
-	leafBucket := o.numLeaves - 1 + leaf
+	leafBucket := numLeaves - 1 + leaf
 
 	// Walk from leafBucket to root, checking if we hit bucketIdx
 	for b := leafBucket; b >= 0; b = (b - 1) / 2 {
@@ -2,8 +2,11 @@ package pathoram
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -83,18 +86,23 @@ func TestTreeHeight(t *testing.T) {
 	tests := []struct {
 		numBlocks  int
 		bucketSize int
+		util       float64
 		wantHeight int
 	}{
-		{1, 1, 1},    // 1 block, 1 per bucket = height 1
-		{7, 1, 3},    // 7 blocks needs 7 buckets = height 3 (2^3-1=7)
-		{8, 1, 4},    // 8 blocks needs 8 buckets = height 4
-		{100, 5, 5},  // 100 blocks, 5 per bucket = 20 buckets, height 5
-		{1000, 4, 8}, // 1000 blocks, 4 per bucket = 250 buckets, height 8
+		{1, 1, FullUtilization, 1},    // 1 block, 1 per bucket = height 1
+		{7, 1, FullUtilization, 3},    // 7 blocks needs 7 buckets = height 3 (2^3-1=7)
+		{8, 1, FullUtilization, 4},    // 8 blocks needs 8 buckets = height 4
+		{100, 5, FullUtilization, 5},  // 100 blocks, 5 per bucket = 20 buckets, height 5
+		{1000, 4, FullUtilization, 8}, // 1000 blocks, 4 per bucket = 250 buckets, height 8
+		{1, 1, 0, 2},                  // default 50%: 2 slots = 2 buckets, height 2
+		{7, 1, 0, 4},                  // 14 slots = 14 buckets, height 4
+		{100, 5, 0, 6},                // 200 slots = 40 buckets, height 6
+		{1000, 4, 0.25, 10},           // 4000 slots = 1000 buckets, height 10
 	}
 	for _, tt := range tests {
-		name := fmt.Sprintf("blocks=%d/Z=%d", tt.numBlocks, tt.bucketSize)
+		name := fmt.Sprintf("blocks=%d/Z=%d/util=%g", tt.numBlocks, tt.bucketSize, tt.util)
 		t.Run(name, func(t *testing.T) {
-			cfg := Config{NumBlocks: tt.numBlocks, BlockSize: 512, BucketSize: tt.bucketSize}
+			cfg := Config{NumBlocks: tt.numBlocks, BlockSize: 512, BucketSize: tt.bucketSize, UtilizationTarget: tt.util}
 			oram, _ := NewInMemory(cfg)
 			if got := oram.Height(); got != tt.wantHeight {
 				t.Errorf("Height() = %d, want %d", got, tt.wantHeight)
@@ -103,6 +111,66 @@ func TestTreeHeight(t *testing.T) {
 	}
 }
 
+func TestUtilizationTarget_Validate(t *testing.T) {
+	for _, u := range []float64{-0.5, 1.5} {
+		if _, err := (Config{NumBlocks: 8, BlockSize: 16, UtilizationTarget: u}).Validate(); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("UtilizationTarget %g: Validate = %v, want ErrInvalidConfig", u, err)
+		}
+	}
+	cfg, err := Config{NumBlocks: 8, BlockSize: 16}.Validate()
+	if err != nil || cfg.UtilizationTarget != DefaultUtilization {
+		t.Errorf("default UtilizationTarget = %g, %v", cfg.UtilizationTarget, err)
+	}
+}
+
+func TestBucketSize_Validate(t *testing.T) {
+	if _, err := (Config{NumBlocks: 8, BlockSize: 16, BucketSize: -1}).Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("BucketSize -1: Validate = %v, want ErrInvalidConfig", err)
+	}
+
+	// Unvalidated configs get Validate's defaults instead of looping forever
+	want, _ := Config{NumBlocks: 100, BlockSize: 16}.Validate()
+	wantHeight, _, _ := want.ComputeTreeParams()
+	for _, z := range []int{0, -3} {
+		if h, _, _ := (Config{NumBlocks: 100, BucketSize: z}).ComputeTreeParams(); h != wantHeight {
+			t.Errorf("BucketSize %d: height = %d, want %d", z, h, wantHeight)
+		}
+	}
+}
+
+func TestTreeShapeOverride(t *testing.T) {
+	oram, err := NewInMemory(Config{NumBlocks: 10, BlockSize: 8, BucketSize: 4, NumLeaves: 32})
+	if err != nil {
+		t.Fatalf("NewInMemory: %v", err)
+	}
+	p := oram.Params()
+	if p.Height != 6 || p.NumLeaves != 32 || p.TotalBuckets != 63 || p.Utilization != 10.0/252 {
+		t.Fatalf("Params = %+v", p)
+	}
+	// The fixed tree leaves room to grow in place
+	if err := oram.Resize(200); err != nil {
+		t.Fatalf("Resize within the tree: %v", err)
+	}
+	if p := oram.Params(); p.Height != 6 || p.NumBlocks != 200 {
+		t.Errorf("Params after Resize = %+v", p)
+	}
+	if err := oram.Resize(300); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Resize past the fixed tree = %v, want ErrInvalidConfig", err)
+	}
+
+	for _, cfg := range []Config{
+		{NumBlocks: 10, BlockSize: 8, NumLeaves: 12},                         // not a power of two
+		{NumBlocks: 10, BlockSize: 8, Height: 5, NumLeaves: 32},              // inconsistent
+		{NumBlocks: 100, BlockSize: 8, BucketSize: 4, Height: 4},             // 60 slots
+		{NumBlocks: 10, BlockSize: 8, Height: 3, CachedLevels: 3},            // CachedLevels >= Height
+		{NumBlocks: 10, BlockSize: 8, Height: maxHeight + 1, StashLimit: 10}, // too tall
+	} {
+		if _, err := cfg.Validate(); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidConfig", cfg, err)
+		}
+	}
+}
+
 func TestNumLeaves(t *testing.T) {
 	tests := []struct {
 		numBlocks  int
@@ -115,7 +183,7 @@ func TestNumLeaves(t *testing.T) {
 	for _, tt := range tests {
 		name := fmt.Sprintf("blocks=%d/Z=%d", tt.numBlocks, tt.bucketSize)
 		t.Run(name, func(t *testing.T) {
-			cfg := Config{NumBlocks: tt.numBlocks, BlockSize: 512, BucketSize: tt.bucketSize}
+			cfg := Config{NumBlocks: tt.numBlocks, BlockSize: 512, BucketSize: tt.bucketSize, UtilizationTarget: FullUtilization}
 			oram, _ := NewInMemory(cfg)
 			if got := oram.NumLeaves(); got != tt.wantLeaves {
 				t.Errorf("NumLeaves() = %d, want %d", got, tt.wantLeaves)
@@ -132,7 +200,7 @@ func TestPath(t *testing.T) {
 	//     / \ / \
 	//    3  4 5  6
 	// Leaves are 3,4,5,6 (indices 0,1,2,3 in leaf numbering)
-	cfg := Config{NumBlocks: 7, BlockSize: 512, BucketSize: 1}
+	cfg := Config{NumBlocks: 7, BlockSize: 512, BucketSize: 1, UtilizationTarget: FullUtilization}
 	oram, _ := NewInMemory(cfg)
 
 	tests := []struct {
@@ -182,6 +250,24 @@ func TestCanPlaceAt(t *testing.T) {
 	}
 }
 
+func TestEvictionDepth_MatchesCanPlaceAt(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 2}
+	oram, _ := NewInMemory(cfg)
+
+	for pathLeaf := 0; pathLeaf < oram.NumLeaves(); pathLeaf++ {
+		path := oram.Path(pathLeaf)
+		for leaf := 0; leaf < oram.NumLeaves(); leaf++ {
+			d := oram.evictionDepth(leaf, path)
+			for level, bucketIdx := range path {
+				if got, want := level >= d, oram.canPlaceAt(leaf, bucketIdx); got != want {
+					t.Fatalf("path %d, leaf %d, level %d: depth %d says %v, canPlaceAt says %v",
+						pathLeaf, leaf, level, d, got, want)
+				}
+			}
+		}
+	}
+}
+
 // Access operation tests
 func TestAccess_WriteAndRead(t *testing.T) {
 	cfg := Config{NumBlocks: 10, BlockSize: 32, BucketSize: 4}
@@ -849,6 +935,156 @@ func TestConstantTimeMode(t *testing.T) {
 	}
 }
 
+func TestConstantTimeStrategies(t *testing.T) {
+	run := func(strategy EvictionStrategy, ct bool) (*PathORAM, *InMemoryStorage) {
+		cfg, _ := Config{NumBlocks: 64, BlockSize: 16, StashLimit: 200, EvictionStrategy: strategy,
+			ConstantTime: ct, Rand: NewSeededRand([32]byte{3})}.Validate()
+		_, _, total := cfg.ComputeTreeParams()
+		storage := NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)
+		oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		for i := 0; i < 300; i++ {
+			if _, err := oram.Write(i%64, bytes.Repeat([]byte{byte(i % 64)}, 16)); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+		for i := 0; i < 64; i++ {
+			if got, _ := oram.Read(i); !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+				t.Fatalf("strategy %d: Read(%d) = %x", strategy, i, got)
+			}
+		}
+		return oram, storage
+	}
+
+	for _, strategy := range []EvictionStrategy{EvictLevelByLevel, EvictGreedyByDepth, EvictDeterministicTwoPath} {
+		oram, _ := run(strategy, true)
+		// The path read clears every bucket on the path, and constant-time
+		// eviction rewrites every one of them
+		st := oram.Stats()
+		if perPath := st.BucketWrites / st.Evictions; perPath != 2*uint64(oram.Height()) {
+			t.Errorf("strategy %d: %d bucket writes per eviction, want %d", strategy, perPath, 2*oram.Height())
+		}
+	}
+
+	// Level-by-level places blocks exactly as its variable-time form does
+	_, ctStorage := run(EvictLevelByLevel, true)
+	_, vtStorage := run(EvictLevelByLevel, false)
+	if !reflect.DeepEqual(ctStorage.buckets, vtStorage.buckets) {
+		t.Error("constant-time level-by-level eviction placed blocks differently")
+	}
+}
+
+// ctxStorage wraps InMemoryStorage and records context-aware calls.
+type ctxStorage struct {
+	*InMemoryStorage
+	ctxCalls int
+}
+
+func (s *ctxStorage) ReadBucketCtx(ctx context.Context, idx int) ([]Block, error) {
+	s.ctxCalls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.ReadBucket(idx)
+}
+
+func (s *ctxStorage) WriteBucketCtx(ctx context.Context, idx int, blocks []Block) error {
+	s.ctxCalls++
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.WriteBucket(idx, blocks)
+}
+
+func TestAccessCtx(t *testing.T) {
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4}
+	cfg, _ = cfg.Validate()
+	_, _, totalBuckets := cfg.ComputeTreeParams()
+	storage := &ctxStorage{InMemoryStorage: NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.BlockSize)}
+
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	ctx := context.Background()
+	data := bytes.Repeat([]byte{0x5A}, 16)
+	if _, err := oram.WriteCtx(ctx, 3, data); err != nil {
+		t.Fatalf("WriteCtx failed: %v", err)
+	}
+	if storage.ctxCalls == 0 {
+		t.Error("expected StorageCtx methods to be used")
+	}
+
+	got, err := oram.ReadCtx(ctx, 3)
+	if err != nil {
+		t.Fatalf("ReadCtx failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("ReadCtx = %x, want %x", got, data)
+	}
+
+	got, err = oram.AccessCtx(ctx, 3, nil)
+	if err != nil {
+		t.Fatalf("AccessCtx failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("AccessCtx = %x, want %x", got, data)
+	}
+}
+
+func TestAccessCtx_Canceled(t *testing.T) {
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4}
+	oram, _ := NewInMemory(cfg)
+
+	data := bytes.Repeat([]byte{0x77}, 16)
+	if _, err := oram.Write(7, data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := oram.ReadCtx(ctx, 7); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadCtx with canceled ctx error = %v, want context.Canceled", err)
+	}
+	if _, err := oram.WriteCtx(ctx, 7, make([]byte, 16)); !errors.Is(err, context.Canceled) {
+		t.Errorf("WriteCtx with canceled ctx error = %v, want context.Canceled", err)
+	}
+
+	// A canceled access must not lose data
+	got, err := oram.Read(7)
+	if err != nil {
+		t.Fatalf("Read after cancel failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Read after cancel = %x, want %x", got, data)
+	}
+}
+
+// countingStorage wraps InMemoryStorage and counts block bytes transferred.
+type countingStorage struct {
+	*InMemoryStorage
+	bytes int64
+}
+
+func (s *countingStorage) ReadBucket(idx int) ([]Block, error) {
+	blocks, err := s.InMemoryStorage.ReadBucket(idx)
+	for _, b := range blocks {
+		s.bytes += int64(len(b.Data))
+	}
+	return blocks, err
+}
+
+func (s *countingStorage) WriteBucket(idx int, blocks []Block) error {
+	for _, b := range blocks {
+		s.bytes += int64(len(b.Data))
+	}
+	return s.InMemoryStorage.WriteBucket(idx, blocks)
+}
+
 // Benchmarks
 func BenchmarkAccess(b *testing.B) {
 	numBlocksValues := []int{64, 256, 1024, 4096, 16384}
@@ -955,9 +1191,12 @@ func BenchmarkEvictionStrategy(b *testing.B) {
 				BucketSize:       4,
 				EvictionStrategy: s.strategy,
 			}
-			oram, err := NewInMemory(cfg)
+			cfg, _ = cfg.Validate()
+			_, _, totalBuckets := cfg.ComputeTreeParams()
+			storage := &countingStorage{InMemoryStorage: NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.BlockSize)}
+			oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
 			if err != nil {
-				b.Fatalf("NewInMemory failed: %v", err)
+				b.Fatalf("New failed: %v", err)
 			}
 			data := make([]byte, 1024)
 
@@ -967,9 +1206,56 @@ func BenchmarkEvictionStrategy(b *testing.B) {
 
 			name := fmt.Sprintf("height=%d/%s", h, s.name)
 			b.Run(name, func(b *testing.B) {
+				storage.bytes = 0
+				maxStash := 0
 				b.ResetTimer()
 				for i := 0; i < b.N; i++ {
 					oram.Read(i % numBlocks)
+					if oram.StashSize() > maxStash {
+						maxStash = oram.StashSize()
+					}
+				}
+				b.ReportMetric(float64(storage.bytes)/float64(b.N), "bytes/access")
+				b.ReportMetric(float64(maxStash), "max_stash")
+			})
+		}
+	}
+}
+
+// BenchmarkEvictLargeStash measures one eviction with a large stash in a
+// tall tree, where per-slot stash scans used to dominate placement.
+func BenchmarkEvictLargeStash(b *testing.B) {
+	ctx := context.Background()
+	for _, s := range []EvictionStrategy{EvictLevelByLevel, EvictGreedyByDepth} {
+		for _, stashSize := range []int{100, 1000} {
+			cfg := Config{NumBlocks: 1 << 16, BlockSize: 64, BucketSize: 4, StashLimit: 1 << 20, EvictionStrategy: s}
+			oram, err := NewInMemory(cfg)
+			if err != nil {
+				b.Fatalf("NewInMemory: %v", err)
+			}
+			stash := make([]block, stashSize)
+			for i := range stash {
+				stash[i] = block{id: i, leaf: oram.randomLeaf(), data: make([]byte, cfg.BlockSize)}
+			}
+
+			b.Run(fmt.Sprintf("%s/stash=%d", s, stashSize), func(b *testing.B) {
+				for b.Loop() {
+					oram.stash = append(oram.stash[:0], stash...)
+					path := oram.storedPath(oram.randomLeaf())
+					if err := oram.evictPath(ctx, path); err != nil {
+						b.Fatalf("evictPath: %v", err)
+					}
+
+					// Empty the path again so every eviction starts alike
+					b.StopTimer()
+					for _, idx := range path {
+						bucket, _ := oram.readBucket(ctx, idx)
+						for j := range bucket {
+							bucket[j] = Block{ID: EmptyBlockID, Leaf: -1, Data: make([]byte, len(bucket[j].Data))}
+						}
+						oram.writeBucket(ctx, idx, bucket)
+					}
+					b.StartTimer()
 				}
 			})
 		}
@@ -1121,3 +1407,38 @@ func BenchmarkStashSizeByStrategy(b *testing.B) {
 		})
 	}
 }
+
+func TestSetStrategy(t *testing.T) {
+	// Deferred evictions must be drained before the switch
+	oram, err := NewInMemory(Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, BackgroundEviction: backgroundEvictionAvailable})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	defer oram.Close()
+	ctx := context.Background()
+	for i := 0; i < 64; i++ {
+		oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16))
+	}
+
+	for _, s := range []EvictionStrategy{EvictGreedyByDepth, EvictDeterministicTwoPath, EvictLevelByLevel} {
+		if err := oram.SetStrategy(ctx, s); err != nil {
+			t.Fatalf("SetStrategy(%v) failed: %v", s, err)
+		}
+		if got := oram.Strategy(); got != s {
+			t.Fatalf("Strategy() = %v, want %v", got, s)
+		}
+		if n := oram.PendingEvictions(); n != 0 {
+			t.Fatalf("%d evictions still pending after SetStrategy", n)
+		}
+		for i := 0; i < 64; i++ {
+			got, err := oram.Read(i)
+			if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+				t.Fatalf("%v: Read(%d) = %v, %v", s, i, got, err)
+			}
+		}
+	}
+
+	if err := oram.SetStrategy(ctx, EvictionStrategy(7)); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("unknown strategy: err = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,80 @@
+package pathoram
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// StashOverflowError is returned when an access leaves more blocks in the
+// stash than the limit allows. It wraps ErrStashOverflow, so
+// errors.Is(err, ErrStashOverflow) still matches, and records the state at
+// the failure for diagnosis:
+//
+//	var soe *pathoram.StashOverflowError
+//	if errors.As(err, &soe) {
+//		log.Print(soe)
+//		soe.DumpLeaves(os.Stderr)
+//	}
+//
+// The ORAM remains usable; DrainStash brings the stash back under the limit.
+type StashOverflowError struct {
+	StashSize  int              // blocks in the stash after the eviction
+	Limit      int              // stash limit, including room for CachedLevels
+	Height     int              // tree height
+	BucketSize int              // Z, blocks per bucket
+	Strategy   EvictionStrategy // eviction strategy in use
+	Accesses   uint64           // Stats().Accesses at the failure
+
+	// Leaves holds the assigned leaf of each stashed block, ascending. It is
+	// client state the storage server must not see: do not log it anywhere
+	// the server's operator can read.
+	Leaves []int
+}
+
+func (e *StashOverflowError) Error() string {
+	return fmt.Sprintf("%v: %d blocks, limit %d (height %d, Z=%d, %v, after %d accesses)",
+		ErrStashOverflow, e.StashSize, e.Limit, e.Height, e.BucketSize, e.Strategy, e.Accesses)
+}
+
+// Unwrap returns ErrStashOverflow.
+func (e *StashOverflowError) Unwrap() error { return ErrStashOverflow }
+
+// DumpLeaves writes the stash's leaf distribution to w, one "leaf count" line
+// per distinct leaf. Many blocks on a few leaves point at a biased position
+// source; an even spread points at a stash limit or Z too small for the load.
+func (e *StashOverflowError) DumpLeaves(w io.Writer) error {
+	for i := 0; i < len(e.Leaves); {
+		j := i
+		for j < len(e.Leaves) && e.Leaves[j] == e.Leaves[i] {
+			j++
+		}
+		if _, err := fmt.Fprintf(w, "%d %d\n", e.Leaves[i], j-i); err != nil {
+			return err
+		}
+		i = j
+	}
+	return nil
+}
+
+// checkStash returns a StashOverflowError if the stash exceeds its limit.
+// Callers hold o.mu.
+func (o *PathORAM) checkStash() error {
+	if len(o.stash) <= o.stashLimit() {
+		return nil
+	}
+	leaves := make([]int, len(o.stash))
+	for i, b := range o.stash {
+		leaves[i] = b.leaf
+	}
+	slices.Sort(leaves)
+	return &StashOverflowError{
+		StashSize:  len(o.stash),
+		Limit:      o.stashLimit(),
+		Height:     o.height,
+		BucketSize: o.cfg.BucketSize,
+		Strategy:   o.cfg.EvictionStrategy,
+		Accesses:   o.stats.Accesses,
+		Leaves:     leaves,
+	}
+}
@@ -0,0 +1,47 @@
+package pathoram
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStashOverflowError(t *testing.T) {
+	oram, err := NewInMemory(Config{NumBlocks: 64, BlockSize: 8, BucketSize: 1, StashLimit: 1, Rand: NewSeededRand([32]byte{1})})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	var soe *StashOverflowError
+	for id := 0; id < 64 && soe == nil; id++ {
+		if _, err := oram.Write(id, make([]byte, 8)); err != nil {
+			if !errors.Is(err, ErrStashOverflow) || !errors.As(err, &soe) {
+				t.Fatalf("Write(%d) err = %v, want *StashOverflowError", id, err)
+			}
+		}
+	}
+	if soe == nil {
+		t.Fatal("no overflow with StashLimit 1 and Z=1")
+	}
+	if soe.StashSize <= soe.Limit || soe.Limit != 1 || soe.Height != oram.Height() || soe.BucketSize != 1 ||
+		soe.Strategy != oram.Strategy() || soe.Accesses == 0 || len(soe.Leaves) != soe.StashSize {
+		t.Fatalf("error = %+v", soe)
+	}
+
+	var buf bytes.Buffer
+	if err := soe.DumpLeaves(&buf); err != nil {
+		t.Fatalf("DumpLeaves failed: %v", err)
+	}
+	total, prev := 0, -1
+	for sc := bufio.NewScanner(&buf); sc.Scan(); {
+		var leaf, n int
+		if _, err := fmt.Sscan(sc.Text(), &leaf, &n); err != nil || leaf <= prev || n <= 0 {
+			t.Fatalf("bad dump line %q", sc.Text())
+		}
+		total, prev = total+n, leaf
+	}
+	if total != soe.StashSize {
+		t.Fatalf("dump covers %d blocks, want %d", total, soe.StashSize)
+	}
+}
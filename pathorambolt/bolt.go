@@ -0,0 +1,290 @@
+// Package pathorambolt provides a pathoram.Storage backend kept in a single
+// bbolt database file, for durable single-file storage with transactional
+// writes. It is a separate module so the core package stays dependency-free.
+package pathorambolt
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketsName = []byte("buckets") // bucket index → encoded ORAM bucket
+	metaName    = []byte("meta")    // dimensions and side records
+
+	dimsKey        = []byte("dims")
+	counterKey     = []byte("counter")
+	fingerprintKey = []byte("fingerprint")
+)
+
+// Storage stores each ORAM bucket as one record of a bbolt database, keyed
+// by bucket index. Every WriteBucket is its own transaction, and
+// WriteBuckets writes a whole path in one, so an evicted path reaches disk
+// all or nothing. Buckets never written read as empty, so a new file costs
+// nothing up front.
+//
+// Storage implements pathoram.BatchStorage, pathoram.ResizableStorage,
+// pathoram.CounterStorage and pathoram.FingerprintStorage, and io.Closer, so
+// PathORAM.Close closes the database.
+type Storage struct {
+	db         *bolt.DB
+	numBuckets int
+	bucketSize int
+	blockSize  int
+}
+
+var (
+	_ pathoram.BatchStorage       = (*Storage)(nil)
+	_ pathoram.ResizableStorage   = (*Storage)(nil)
+	_ pathoram.CounterStorage     = (*Storage)(nil)
+	_ pathoram.FingerprintStorage = (*Storage)(nil)
+)
+
+// Open opens or creates the database at path for numBuckets buckets of
+// bucketSize blocks of blockSize bytes. Reopening a file created with
+// different bucket or block sizes fails with pathoram.ErrInvalidConfig; a
+// different numBuckets is adopted, as after Resize.
+func Open(path string, numBuckets, bucketSize, blockSize int) (*Storage, error) {
+	if numBuckets <= 0 || bucketSize <= 0 || blockSize <= 0 {
+		return nil, pathoram.ErrInvalidConfig
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	s := &Storage{db: db, numBuckets: numBuckets, bucketSize: bucketSize, blockSize: blockSize}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucketsName); err != nil {
+			return err
+		}
+		meta, err := tx.CreateBucketIfNotExists(metaName)
+		if err != nil {
+			return err
+		}
+		if dims := meta.Get(dimsKey); dims != nil {
+			if len(dims) != 24 ||
+				binary.BigEndian.Uint64(dims[8:]) != uint64(bucketSize) ||
+				binary.BigEndian.Uint64(dims[16:]) != uint64(blockSize) {
+				return fmt.Errorf("%w: %s was created with different bucket or block sizes", pathoram.ErrInvalidConfig, path)
+			}
+		}
+		return s.putDims(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// putDims records the storage dimensions.
+func (s *Storage) putDims(tx *bolt.Tx) error {
+	dims := make([]byte, 24)
+	binary.BigEndian.PutUint64(dims, uint64(s.numBuckets))
+	binary.BigEndian.PutUint64(dims[8:], uint64(s.bucketSize))
+	binary.BigEndian.PutUint64(dims[16:], uint64(s.blockSize))
+	return tx.Bucket(metaName).Put(dimsKey, dims)
+}
+
+// key returns the record key for bucket idx.
+func key(idx int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(idx))
+}
+
+// encode serializes a bucket: per slot, the block ID and leaf as 8 bytes
+// each, then blockSize bytes of data.
+func (s *Storage) encode(blocks []pathoram.Block) ([]byte, error) {
+	if len(blocks) != s.bucketSize {
+		return nil, pathoram.ErrInvalidConfig
+	}
+	buf := make([]byte, 0, s.bucketSize*(16+s.blockSize))
+	for _, b := range blocks {
+		if len(b.Data) != s.blockSize {
+			return nil, pathoram.ErrInvalidDataSize
+		}
+		buf = binary.BigEndian.AppendUint64(buf, uint64(b.ID))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(b.Leaf))
+		buf = append(buf, b.Data...)
+	}
+	return buf, nil
+}
+
+// decode parses a record written by encode, or returns an empty bucket for
+// a missing one. The result does not alias rec, which bbolt owns.
+func (s *Storage) decode(rec []byte) ([]pathoram.Block, error) {
+	blocks := make([]pathoram.Block, s.bucketSize)
+	if rec == nil {
+		for i := range blocks {
+			blocks[i] = pathoram.Block{ID: pathoram.EmptyBlockID, Leaf: -1, Data: make([]byte, s.blockSize)}
+		}
+		return blocks, nil
+	}
+	if len(rec) != s.bucketSize*(16+s.blockSize) {
+		return nil, fmt.Errorf("%w: bucket record of %d bytes", pathoram.ErrCorruptObject, len(rec))
+	}
+	for i := range blocks {
+		blocks[i] = pathoram.Block{
+			ID:   int(int64(binary.BigEndian.Uint64(rec))),
+			Leaf: int(int64(binary.BigEndian.Uint64(rec[8:]))),
+			Data: append([]byte(nil), rec[16:16+s.blockSize]...),
+		}
+		rec = rec[16+s.blockSize:]
+	}
+	return blocks, nil
+}
+
+// checkIndex reports whether idx is a valid bucket index.
+func (s *Storage) checkIndex(idx int) error {
+	if idx < 0 || idx >= s.numBuckets {
+		return pathoram.ErrInvalidConfig
+	}
+	return nil
+}
+
+// ReadBucket returns the bucket at idx.
+func (s *Storage) ReadBucket(idx int) ([]pathoram.Block, error) {
+	buckets, err := s.ReadBuckets(context.Background(), []int{idx})
+	if err != nil {
+		return nil, err
+	}
+	return buckets[0], nil
+}
+
+// WriteBucket writes the bucket at idx in its own transaction.
+func (s *Storage) WriteBucket(idx int, blocks []pathoram.Block) error {
+	return s.WriteBuckets(context.Background(), []int{idx}, [][]pathoram.Block{blocks})
+}
+
+// ReadBuckets returns the buckets at idxs from one read transaction.
+func (s *Storage) ReadBuckets(ctx context.Context, idxs []int) ([][]pathoram.Block, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	buckets := make([][]pathoram.Block, len(idxs))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketsName)
+		for i, idx := range idxs {
+			if err := s.checkIndex(idx); err != nil {
+				return err
+			}
+			var err error
+			if buckets[i], err = s.decode(b.Get(key(idx))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// WriteBuckets writes buckets[i] to the bucket at idxs[i] in one
+// transaction: either every bucket is written or none is.
+func (s *Storage) WriteBuckets(ctx context.Context, idxs []int, buckets [][]pathoram.Block) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	recs := make([][]byte, len(idxs))
+	for i, idx := range idxs {
+		if err := s.checkIndex(idx); err != nil {
+			return err
+		}
+		var err error
+		if recs[i], err = s.encode(buckets[i]); err != nil {
+			return err
+		}
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketsName)
+		for i, idx := range idxs {
+			if err := b.Put(key(idx), recs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// NumBuckets returns the number of buckets.
+func (s *Storage) NumBuckets() int { return s.numBuckets }
+
+// BucketSize returns the number of block slots per bucket.
+func (s *Storage) BucketSize() int { return s.bucketSize }
+
+// BlockSize returns the size of each block's data in bytes.
+func (s *Storage) BlockSize() int { return s.blockSize }
+
+// Resize sets the number of buckets. Records past the new end are deleted;
+// new buckets read as empty.
+func (s *Storage) Resize(numBuckets int) error {
+	if numBuckets <= 0 {
+		return pathoram.ErrInvalidConfig
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketsName).Cursor()
+		for k, _ := c.Seek(key(numBuckets)); k != nil; k, _ = c.Next() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		old := s.numBuckets
+		s.numBuckets = numBuckets
+		if err := s.putDims(tx); err != nil {
+			s.numBuckets = old
+			return err
+		}
+		return nil
+	})
+}
+
+// ReadCounter returns the sealed access counter, or nil if none was written.
+func (s *Storage) ReadCounter() ([]byte, error) {
+	return s.getMeta(counterKey)
+}
+
+// WriteCounter replaces the sealed access counter.
+func (s *Storage) WriteCounter(sealed []byte) error {
+	return s.putMeta(counterKey, sealed)
+}
+
+// ReadFingerprint returns the stored key fingerprint, or nil if none was
+// written.
+func (s *Storage) ReadFingerprint() ([]byte, error) {
+	return s.getMeta(fingerprintKey)
+}
+
+// WriteFingerprint replaces the stored key fingerprint.
+func (s *Storage) WriteFingerprint(fp []byte) error {
+	return s.putMeta(fingerprintKey, fp)
+}
+
+// getMeta returns a copy of the side record k, or nil.
+func (s *Storage) getMeta(k []byte) ([]byte, error) {
+	var v []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if rec := tx.Bucket(metaName).Get(k); rec != nil {
+			v = append([]byte(nil), rec...)
+		}
+		return nil
+	})
+	return v, err
+}
+
+// putMeta replaces the side record k.
+func (s *Storage) putMeta(k, v []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaName).Put(k, v)
+	})
+}
+
+// Close closes the database file.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,122 @@
+package pathorambolt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func TestStorage_RestartWithPersistedState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oram.db")
+	key := bytes.Repeat([]byte{9}, 32)
+	enc, _ := pathoram.NewAESGCMEncryptor(key)
+	cfg, _ := pathoram.Config{NumBlocks: 64, BlockSize: 32}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	numBuckets := total + pathoram.PositionMapBuckets(cfg, enc)
+
+	s, err := Open(path, numBuckets, cfg.BucketSize, cfg.BlockSize+enc.Overhead())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	pm := pathoram.NewStoredPositionMap(pathoram.NewInMemoryPositionMap(), cfg.NumBlocks, total, enc)
+	oram, err := pathoram.New(cfg, s, pm, enc)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 32)); err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+	}
+	if err := oram.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := pm.SaveTo(s); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+	if err := oram.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, err = Open(path, numBuckets, cfg.BucketSize, cfg.BlockSize+enc.Overhead())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	enc, _ = pathoram.NewAESGCMEncryptor(key)
+	pm = pathoram.NewStoredPositionMap(pathoram.NewInMemoryPositionMap(), cfg.NumBlocks, total, enc)
+	if err := pm.LoadFrom(s); err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	oram, err = pathoram.New(cfg, s, pm, enc)
+	if err != nil {
+		t.Fatalf("New after reopen: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		got, err := oram.Read(i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 32)) {
+			t.Fatalf("Read(%d) after reopen = %x, %v", i, got, err)
+		}
+	}
+}
+
+func TestStorage_WriteBucketsIsAtomic(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "oram.db"), 4, 2, 4)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	good := []pathoram.Block{{ID: 1, Leaf: 0, Data: []byte("abcd")}, {ID: 2, Leaf: 1, Data: []byte("efgh")}}
+	bad := []pathoram.Block{{ID: 3, Leaf: 0, Data: []byte("ab")}, {ID: 4, Leaf: 1, Data: []byte("efgh")}}
+	err = s.WriteBuckets(context.Background(), []int{0, 1}, [][]pathoram.Block{good, bad})
+	if !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Fatalf("WriteBuckets = %v, want ErrInvalidDataSize", err)
+	}
+	got, err := s.ReadBucket(0)
+	if err != nil || got[0].ID != pathoram.EmptyBlockID {
+		t.Errorf("bucket 0 after failed batch = %v, %v; want empty", got, err)
+	}
+
+	if err := s.WriteBuckets(context.Background(), []int{3}, [][]pathoram.Block{good}); err != nil {
+		t.Fatalf("WriteBuckets: %v", err)
+	}
+	got, err = s.ReadBucket(3)
+	if err != nil || got[1].ID != 2 || !bytes.Equal(got[1].Data, []byte("efgh")) {
+		t.Errorf("ReadBucket(3) = %v, %v", got, err)
+	}
+	if err := s.Resize(3); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if _, err := s.ReadBucket(3); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("ReadBucket past end = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestOpen_RejectsDifferentDimensions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oram.db")
+	s, err := Open(path, 4, 2, 4)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := s.WriteFingerprint([]byte("fp")); err != nil {
+		t.Fatalf("WriteFingerprint: %v", err)
+	}
+	s.Close()
+
+	if _, err := Open(path, 4, 2, 8); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Fatalf("Open with other block size = %v, want ErrInvalidConfig", err)
+	}
+	s, err = Open(path, 4, 2, 4)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	if fp, err := s.ReadFingerprint(); err != nil || string(fp) != "fp" {
+		t.Errorf("ReadFingerprint = %q, %v", fp, err)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/etclab/pathoram-go/pathorambolt
+
+go 1.25.1
+
+require (
+	github.com/etclab/pathoram-go v0.0.0
+	go.etcd.io/bbolt v1.4.3
+)
+
+require golang.org/x/sys v0.29.0 // indirect
@@ -0,0 +1,89 @@
+package pathoramcrypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+var _ pathoram.Encryptor = (*ChaCha20Poly1305Encryptor)(nil)
+
+func TestChaCha20Poly1305Encryptor(t *testing.T) {
+	if _, err := NewChaCha20Poly1305Encryptor(make([]byte, 16)); err == nil {
+		t.Error("expected error for 16-byte key")
+	}
+
+	enc, err := NewChaCha20Poly1305Encryptor(bytes.Repeat([]byte{3}, 32))
+	if err != nil {
+		t.Fatalf("NewChaCha20Poly1305Encryptor failed: %v", err)
+	}
+	if enc.Overhead() != 28 {
+		t.Errorf("Overhead() = %d, want 28", enc.Overhead())
+	}
+
+	plaintext := []byte("sixteen byte msg")
+	ct, err := enc.Encrypt(4, 9, plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if len(ct) != len(plaintext)+enc.Overhead() {
+		t.Errorf("ciphertext length %d, want %d", len(ct), len(plaintext)+enc.Overhead())
+	}
+	got, err := enc.Decrypt(4, 9, ct)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("Decrypt = (%q, %v)", got, err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		id, leaf int
+		ct       []byte
+	}{
+		{"wrong block", 5, 9, ct},
+		{"wrong leaf", 4, 8, ct},
+		{"truncated", 4, 9, ct[:10]},
+	} {
+		if _, err := enc.Decrypt(tt.id, tt.leaf, tt.ct); !errors.Is(err, pathoram.ErrDecryptionFailed) {
+			t.Errorf("%s: got %v, want ErrDecryptionFailed", tt.name, err)
+		}
+	}
+}
+
+func TestChaCha20Poly1305Encryptor_ORAM(t *testing.T) {
+	enc, _ := NewChaCha20Poly1305Encryptor(bytes.Repeat([]byte{3}, 32))
+	oram, err := pathoram.NewORAM(pathoram.WithCapacity(32, 64), pathoram.WithEncryptor(enc))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for i := 0; i < 32; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 64)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	for i := 0; i < 32; i++ {
+		got, err := oram.Read(i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 64)) {
+			t.Errorf("Read(%d) = (%x, %v)", i, got, err)
+		}
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	key := bytes.Repeat([]byte{3}, 32)
+	chacha, _ := NewChaCha20Poly1305Encryptor(key)
+	aes, _ := pathoram.NewAESGCMEncryptor(key)
+	data := make([]byte, 4096)
+	for _, bc := range []struct {
+		name string
+		enc  pathoram.Encryptor
+	}{{"ChaCha20Poly1305", chacha}, {"AESGCM", aes}} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for b.Loop() {
+				bc.enc.Encrypt(1, 2, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/etclab/pathoram-go/pathoramcrypto
+
+go 1.25.1
+
+require (
+	github.com/etclab/pathoram-go v0.0.0
+	golang.org/x/crypto v0.45.0
+)
+
+require golang.org/x/sys v0.38.0 // indirect
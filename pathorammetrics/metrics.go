@@ -0,0 +1,150 @@
+// Package pathorammetrics exports PathORAM metrics to Prometheus.
+//
+// It lives in its own module so the core package stays free of external
+// dependencies.
+//
+//	c := pathorammetrics.New("myapp")
+//	cfg.StatsCollector = c
+//	oram, _ := pathoram.NewInMemory(cfg)
+//	prometheus.MustRegister(c)
+package pathorammetrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements pathoram.StatsCollector, pathoram.AccessObserver, and
+// prometheus.Collector. All metrics are updated from ORAM callbacks, so
+// scrapes never touch the (non-thread-safe) PathORAM instance.
+type Collector struct {
+	accesses   prometheus.Counter
+	latency    prometheus.Histogram
+	errors     *prometheus.CounterVec
+	roundTrips *prometheus.CounterVec
+	bytes      *prometheus.CounterVec
+	evictions  prometheus.Counter
+	decFails   prometheus.Counter
+	stashSize  prometheus.Gauge
+}
+
+var (
+	_ pathoram.StatsCollector = (*Collector)(nil)
+	_ pathoram.AccessObserver = (*Collector)(nil)
+	_ prometheus.Collector    = (*Collector)(nil)
+)
+
+// New creates a Collector whose metric names are prefixed with namespace.
+func New(namespace string) *Collector {
+	return &Collector{
+		accesses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "pathoram", Name: "accesses_total",
+			Help: "Completed ORAM accesses, including dummy accesses.",
+		}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "pathoram", Name: "access_duration_seconds",
+			Help:    "Latency of Read, Write, Access, and WriteBatch calls.",
+			Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10), // 10µs .. ~2.6s
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "pathoram", Name: "access_errors_total",
+			Help: "Failed ORAM calls by error kind.",
+		}, []string{"kind"}),
+		roundTrips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "pathoram", Name: "storage_round_trips_total",
+			Help: "Bucket reads and writes issued to storage.",
+		}, []string{"op"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "pathoram", Name: "storage_bytes_total",
+			Help: "Block data bytes transferred to and from storage.",
+		}, []string{"op"}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "pathoram", Name: "evictions_total",
+			Help: "Path eviction passes.",
+		}),
+		decFails: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "pathoram", Name: "decryption_failures_total",
+			Help: "Blocks that failed to decrypt.",
+		}),
+		stashSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "pathoram", Name: "stash_size",
+			Help: "Current number of blocks in the stash.",
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.accesses.Describe(ch)
+	c.latency.Describe(ch)
+	c.errors.Describe(ch)
+	c.roundTrips.Describe(ch)
+	c.bytes.Describe(ch)
+	c.evictions.Describe(ch)
+	c.decFails.Describe(ch)
+	c.stashSize.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.accesses.Collect(ch)
+	c.latency.Collect(ch)
+	c.errors.Collect(ch)
+	c.roundTrips.Collect(ch)
+	c.bytes.Collect(ch)
+	c.evictions.Collect(ch)
+	c.decFails.Collect(ch)
+	c.stashSize.Collect(ch)
+}
+
+// OnAccess implements pathoram.StatsCollector.
+func (c *Collector) OnAccess() { c.accesses.Inc() }
+
+// OnBucketRead implements pathoram.StatsCollector.
+func (c *Collector) OnBucketRead(n int) {
+	c.roundTrips.WithLabelValues("read").Inc()
+	c.bytes.WithLabelValues("read").Add(float64(n))
+}
+
+// OnBucketWrite implements pathoram.StatsCollector.
+func (c *Collector) OnBucketWrite(n int) {
+	c.roundTrips.WithLabelValues("write").Inc()
+	c.bytes.WithLabelValues("write").Add(float64(n))
+}
+
+// OnEviction implements pathoram.StatsCollector.
+func (c *Collector) OnEviction() { c.evictions.Inc() }
+
+// OnDecryptionFailure implements pathoram.StatsCollector.
+func (c *Collector) OnDecryptionFailure() { c.decFails.Inc() }
+
+// OnStashSize implements pathoram.StatsCollector.
+func (c *Collector) OnStashSize(n int) { c.stashSize.Set(float64(n)) }
+
+// OnAccessDone implements pathoram.AccessObserver.
+func (c *Collector) OnAccessDone(d time.Duration, err error) {
+	c.latency.Observe(d.Seconds())
+	if err != nil {
+		c.errors.WithLabelValues(errorKind(err)).Inc()
+	}
+}
+
+// errorKind maps an access error to a low-cardinality label value.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, pathoram.ErrStashOverflow):
+		return "stash_overflow"
+	case errors.Is(err, pathoram.ErrDecryptionFailed):
+		return "decryption"
+	case errors.Is(err, pathoram.ErrInvalidBlockID), errors.Is(err, pathoram.ErrInvalidDataSize):
+		return "invalid_argument"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,46 @@
+package pathorammetrics
+
+import (
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := New("test")
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: 32, BlockSize: 16, StatsCollector: c})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		oram.Write(i, make([]byte, 16))
+	}
+	// Validation errors are returned before the access starts, so they are
+	// neither timed nor counted.
+	oram.Read(99)
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if got := testutil.ToFloat64(c.accesses); got != 4 {
+		t.Errorf("accesses_total = %v, want 4", got)
+	}
+	if n := testutil.CollectAndCount(c, "test_pathoram_access_errors_total"); n != 0 {
+		t.Errorf("access_errors_total series = %d, want 0", n)
+	}
+	st := oram.Stats()
+	if got := testutil.ToFloat64(c.roundTrips.WithLabelValues("read")); got != float64(st.BucketReads) {
+		t.Errorf("read round trips = %v, want %d", got, st.BucketReads)
+	}
+	if got := testutil.ToFloat64(c.stashSize); got != float64(oram.StashSize()) {
+		t.Errorf("stash_size = %v, want %d", got, oram.StashSize())
+	}
+	if n := testutil.CollectAndCount(c, "test_pathoram_access_duration_seconds"); n != 1 {
+		t.Errorf("latency histogram count = %d, want 1", n)
+	}
+}
@@ -0,0 +1,86 @@
+// Package pathorampb holds protobuf definitions (pathoram.proto) for
+// pathoram-go's wire and persistent formats: buckets and blocks, the storage
+// RPC service, replication updates, checkpoints and access traces. Non-Go
+// servers and tools generate their own bindings from pathoram.proto; Go code
+// converts with the helpers below. It is a separate module so the core
+// package stays dependency-free.
+package pathorampb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative pathoram.proto
+
+import (
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// FromBlock converts a pathoram.Block.
+func FromBlock(b pathoram.Block) *Block {
+	return &Block{Id: int64(b.ID), Leaf: int64(b.Leaf), Data: b.Data}
+}
+
+// ToBlock converts b to a pathoram.Block.
+func ToBlock(b *Block) pathoram.Block {
+	return pathoram.Block{ID: int(b.GetId()), Leaf: int(b.GetLeaf()), Data: b.GetData()}
+}
+
+// FromBucket converts a bucket's blocks.
+func FromBucket(blocks []pathoram.Block) *Bucket {
+	pb := &Bucket{Blocks: make([]*Block, len(blocks))}
+	for i, b := range blocks {
+		pb.Blocks[i] = FromBlock(b)
+	}
+	return pb
+}
+
+// ToBucket converts b to a bucket's blocks.
+func ToBucket(b *Bucket) []pathoram.Block {
+	blocks := make([]pathoram.Block, len(b.GetBlocks()))
+	for i, pb := range b.GetBlocks() {
+		blocks[i] = ToBlock(pb)
+	}
+	return blocks
+}
+
+// FromStateUpdate converts a replication update.
+func FromStateUpdate(u pathoram.StateUpdate) *StateUpdate {
+	pb := &StateUpdate{
+		Seq:         u.Seq,
+		Full:        u.Full,
+		Positions:   make(map[int64]int64, len(u.Positions)),
+		Stash:       make([]*StashEntry, len(u.Stash)),
+		AccessCount: u.AccessCount,
+	}
+	for id, leaf := range u.Positions {
+		pb.Positions[int64(id)] = int64(leaf)
+	}
+	for i, e := range u.Stash {
+		pb.Stash[i] = &StashEntry{Id: int64(e.ID), Leaf: int64(e.Leaf), Data: e.Data}
+	}
+	return pb
+}
+
+// ToStateUpdate converts u to a replication update.
+func ToStateUpdate(u *StateUpdate) pathoram.StateUpdate {
+	out := pathoram.StateUpdate{
+		Seq:         u.GetSeq(),
+		Full:        u.GetFull(),
+		Positions:   make(map[int]int, len(u.GetPositions())),
+		Stash:       make([]pathoram.StashEntry, len(u.GetStash())),
+		AccessCount: u.GetAccessCount(),
+	}
+	for id, leaf := range u.GetPositions() {
+		out.Positions[int(id)] = int(leaf)
+	}
+	for i, e := range u.GetStash() {
+		out.Stash[i] = pathoram.StashEntry{ID: int(e.GetId()), Leaf: int(e.GetLeaf()), Data: e.GetData()}
+	}
+	return out
+}
+
+// InfoOf describes s.
+func InfoOf(s pathoram.Storage) *StorageInfo {
+	return &StorageInfo{
+		NumBuckets: int64(s.NumBuckets()),
+		BucketSize: int32(s.BucketSize()),
+		BlockSize:  int32(s.BlockSize()),
+	}
+}
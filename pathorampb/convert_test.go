@@ -0,0 +1,87 @@
+package pathorampb
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBucketRoundTrip(t *testing.T) {
+	in := []pathoram.Block{
+		{ID: 3, Leaf: 1, Data: []byte("ciphertext")},
+		{ID: pathoram.EmptyBlockID, Leaf: -1, Data: make([]byte, 10)},
+	}
+	wire, err := proto.Marshal(FromBucket(in))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var pb Bucket
+	if err := proto.Unmarshal(wire, &pb); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got := ToBucket(&pb); !reflect.DeepEqual(got, in) {
+		t.Errorf("round trip = %v, want %v", got, in)
+	}
+}
+
+// wireReplicator sends each update through its protobuf encoding.
+type wireReplicator struct {
+	t       *testing.T
+	standby *pathoram.Standby
+}
+
+func (r wireReplicator) Replicate(u pathoram.StateUpdate) error {
+	wire, err := proto.Marshal(FromStateUpdate(u))
+	if err != nil {
+		return err
+	}
+	var pb StateUpdate
+	if err := proto.Unmarshal(wire, &pb); err != nil {
+		return err
+	}
+	return r.standby.Replicate(ToStateUpdate(&pb))
+}
+
+func TestStateUpdateOverWire(t *testing.T) {
+	cfg := pathoram.Config{NumBlocks: 16, BlockSize: 8}
+	vcfg, _ := cfg.Validate()
+	_, _, total := vcfg.ComputeTreeParams()
+	storage := pathoram.NewInMemoryStorage(total, vcfg.BucketSize, vcfg.BlockSize)
+	standby := pathoram.NewStandby()
+	primary, err := pathoram.NewORAM(pathoram.WithConfig(cfg), pathoram.WithStorage(storage),
+		pathoram.WithReplicator(wireReplicator{t, standby}))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		if _, err := primary.Write(i, bytes.Repeat([]byte{byte(i)}, 8)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	promoted, err := standby.Promote(cfg, storage, pathoram.NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		if got, _ := promoted.Read(i); !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 8)) {
+			t.Errorf("Read(%d) = %x", i, got)
+		}
+	}
+}
+
+func TestDescriptor(t *testing.T) {
+	fd := File_pathoram_proto
+	if fd.Package() != "pathoram.v1" || fd.Services().Len() != 1 {
+		t.Fatalf("unexpected descriptor: package %s, %d services", fd.Package(), fd.Services().Len())
+	}
+	if m := fd.Services().Get(0).Methods().ByName("ReadBuckets"); m == nil || m.Output().Name() != "ReadBucketsResponse" {
+		t.Error("Storage.ReadBuckets missing or mistyped")
+	}
+	if f := (&Checkpoint{}).ProtoReflect().Descriptor().Fields().ByName("state"); f.Message().Name() != "StateUpdate" {
+		t.Error("Checkpoint.state is not a StateUpdate")
+	}
+}
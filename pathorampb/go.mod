@@ -0,0 +1,8 @@
+module github.com/etclab/pathoram-go/pathorampb
+
+go 1.25.1
+
+require (
+	github.com/etclab/pathoram-go v0.0.0
+	google.golang.org/protobuf v1.36.8
+)
@@ -0,0 +1,952 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: pathoram.proto
+
+package pathorampb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TraceEvent_Op int32
+
+const (
+	TraceEvent_OP_UNSPECIFIED TraceEvent_Op = 0
+	TraceEvent_OP_READ        TraceEvent_Op = 1
+	TraceEvent_OP_WRITE       TraceEvent_Op = 2
+)
+
+// Enum value maps for TraceEvent_Op.
+var (
+	TraceEvent_Op_name = map[int32]string{
+		0: "OP_UNSPECIFIED",
+		1: "OP_READ",
+		2: "OP_WRITE",
+	}
+	TraceEvent_Op_value = map[string]int32{
+		"OP_UNSPECIFIED": 0,
+		"OP_READ":        1,
+		"OP_WRITE":       2,
+	}
+)
+
+func (x TraceEvent_Op) Enum() *TraceEvent_Op {
+	p := new(TraceEvent_Op)
+	*p = x
+	return p
+}
+
+func (x TraceEvent_Op) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TraceEvent_Op) Descriptor() protoreflect.EnumDescriptor {
+	return file_pathoram_proto_enumTypes[0].Descriptor()
+}
+
+func (TraceEvent_Op) Type() protoreflect.EnumType {
+	return &file_pathoram_proto_enumTypes[0]
+}
+
+func (x TraceEvent_Op) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TraceEvent_Op.Descriptor instead.
+func (TraceEvent_Op) EnumDescriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{11, 0}
+}
+
+// Block is one slot of a bucket (pathoram.Block). Data is ciphertext as
+// produced by the client's encryptor; the server never sees plaintext.
+type Block struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`     // block ID, -1 for an empty slot
+	Leaf          int64                  `protobuf:"varint,2,opt,name=leaf,proto3" json:"leaf,omitempty"` // assigned leaf, -1 for an empty slot
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	mi := &file_pathoram_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Block) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Block) GetLeaf() int64 {
+	if x != nil {
+		return x.Leaf
+	}
+	return 0
+}
+
+func (x *Block) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Bucket is one tree node: exactly BucketSize blocks.
+type Bucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Blocks        []*Block               `protobuf:"bytes,1,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bucket) Reset() {
+	*x = Bucket{}
+	mi := &file_pathoram_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bucket) ProtoMessage() {}
+
+func (x *Bucket) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bucket.ProtoReflect.Descriptor instead.
+func (*Bucket) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Bucket) GetBlocks() []*Block {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+// StorageInfo describes a storage tree (the Storage size methods).
+type StorageInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NumBuckets    int64                  `protobuf:"varint,1,opt,name=num_buckets,json=numBuckets,proto3" json:"num_buckets,omitempty"`
+	BucketSize    int32                  `protobuf:"varint,2,opt,name=bucket_size,json=bucketSize,proto3" json:"bucket_size,omitempty"`
+	BlockSize     int32                  `protobuf:"varint,3,opt,name=block_size,json=blockSize,proto3" json:"block_size,omitempty"` // stored (ciphertext) bytes per block
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StorageInfo) Reset() {
+	*x = StorageInfo{}
+	mi := &file_pathoram_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StorageInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageInfo) ProtoMessage() {}
+
+func (x *StorageInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageInfo.ProtoReflect.Descriptor instead.
+func (*StorageInfo) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StorageInfo) GetNumBuckets() int64 {
+	if x != nil {
+		return x.NumBuckets
+	}
+	return 0
+}
+
+func (x *StorageInfo) GetBucketSize() int32 {
+	if x != nil {
+		return x.BucketSize
+	}
+	return 0
+}
+
+func (x *StorageInfo) GetBlockSize() int32 {
+	if x != nil {
+		return x.BlockSize
+	}
+	return 0
+}
+
+type GetInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInfoRequest) Reset() {
+	*x = GetInfoRequest{}
+	mi := &file_pathoram_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInfoRequest) ProtoMessage() {}
+
+func (x *GetInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetInfoRequest) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{3}
+}
+
+// ReadBucketsRequest asks for the buckets at indexes, usually one path.
+type ReadBucketsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Indexes       []int64                `protobuf:"varint,1,rep,packed,name=indexes,proto3" json:"indexes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadBucketsRequest) Reset() {
+	*x = ReadBucketsRequest{}
+	mi := &file_pathoram_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadBucketsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadBucketsRequest) ProtoMessage() {}
+
+func (x *ReadBucketsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadBucketsRequest.ProtoReflect.Descriptor instead.
+func (*ReadBucketsRequest) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReadBucketsRequest) GetIndexes() []int64 {
+	if x != nil {
+		return x.Indexes
+	}
+	return nil
+}
+
+// ReadBucketsResponse returns the buckets in request order.
+type ReadBucketsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Buckets       []*Bucket              `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadBucketsResponse) Reset() {
+	*x = ReadBucketsResponse{}
+	mi := &file_pathoram_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadBucketsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadBucketsResponse) ProtoMessage() {}
+
+func (x *ReadBucketsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadBucketsResponse.ProtoReflect.Descriptor instead.
+func (*ReadBucketsResponse) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReadBucketsResponse) GetBuckets() []*Bucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+// WriteBucketsRequest stores buckets[i] at indexes[i].
+type WriteBucketsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Indexes       []int64                `protobuf:"varint,1,rep,packed,name=indexes,proto3" json:"indexes,omitempty"`
+	Buckets       []*Bucket              `protobuf:"bytes,2,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteBucketsRequest) Reset() {
+	*x = WriteBucketsRequest{}
+	mi := &file_pathoram_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteBucketsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteBucketsRequest) ProtoMessage() {}
+
+func (x *WriteBucketsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteBucketsRequest.ProtoReflect.Descriptor instead.
+func (*WriteBucketsRequest) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WriteBucketsRequest) GetIndexes() []int64 {
+	if x != nil {
+		return x.Indexes
+	}
+	return nil
+}
+
+func (x *WriteBucketsRequest) GetBuckets() []*Bucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+type WriteBucketsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteBucketsResponse) Reset() {
+	*x = WriteBucketsResponse{}
+	mi := &file_pathoram_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteBucketsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteBucketsResponse) ProtoMessage() {}
+
+func (x *WriteBucketsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteBucketsResponse.ProtoReflect.Descriptor instead.
+func (*WriteBucketsResponse) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{7}
+}
+
+// StashEntry is a plaintext stash block (pathoram.StashEntry).
+type StashEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Leaf          int64                  `protobuf:"varint,2,opt,name=leaf,proto3" json:"leaf,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StashEntry) Reset() {
+	*x = StashEntry{}
+	mi := &file_pathoram_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StashEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StashEntry) ProtoMessage() {}
+
+func (x *StashEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StashEntry.ProtoReflect.Descriptor instead.
+func (*StashEntry) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StashEntry) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *StashEntry) GetLeaf() int64 {
+	if x != nil {
+		return x.Leaf
+	}
+	return 0
+}
+
+func (x *StashEntry) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// StateUpdate carries client state from a primary to a standby
+// (pathoram.StateUpdate).
+type StateUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Full          bool                   `protobuf:"varint,2,opt,name=full,proto3" json:"full,omitempty"`                                                                                      // positions is a snapshot, not a delta
+	Positions     map[int64]int64        `protobuf:"bytes,3,rep,name=positions,proto3" json:"positions,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // block ID -> leaf
+	Stash         []*StashEntry          `protobuf:"bytes,4,rep,name=stash,proto3" json:"stash,omitempty"`                                                                                     // complete stash
+	AccessCount   uint64                 `protobuf:"varint,5,opt,name=access_count,json=accessCount,proto3" json:"access_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateUpdate) Reset() {
+	*x = StateUpdate{}
+	mi := &file_pathoram_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateUpdate) ProtoMessage() {}
+
+func (x *StateUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateUpdate.ProtoReflect.Descriptor instead.
+func (*StateUpdate) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StateUpdate) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *StateUpdate) GetFull() bool {
+	if x != nil {
+		return x.Full
+	}
+	return false
+}
+
+func (x *StateUpdate) GetPositions() map[int64]int64 {
+	if x != nil {
+		return x.Positions
+	}
+	return nil
+}
+
+func (x *StateUpdate) GetStash() []*StashEntry {
+	if x != nil {
+		return x.Stash
+	}
+	return nil
+}
+
+func (x *StateUpdate) GetAccessCount() uint64 {
+	if x != nil {
+		return x.AccessCount
+	}
+	return 0
+}
+
+// Checkpoint is the client state needed to reopen an ORAM over its storage:
+// tree parameters, a full state snapshot, and the trusted values that must
+// be persisted with VerifyIntegrity and VersionKey.
+type Checkpoint struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NumBlocks      int64                  `protobuf:"varint,1,opt,name=num_blocks,json=numBlocks,proto3" json:"num_blocks,omitempty"`
+	BlockSize      int32                  `protobuf:"varint,2,opt,name=block_size,json=blockSize,proto3" json:"block_size,omitempty"`
+	BucketSize     int32                  `protobuf:"varint,3,opt,name=bucket_size,json=bucketSize,proto3" json:"bucket_size,omitempty"`
+	State          *StateUpdate           `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`                                                 // full update
+	IntegrityRoot  []byte                 `protobuf:"bytes,5,opt,name=integrity_root,json=integrityRoot,proto3" json:"integrity_root,omitempty"`            // PathORAM.IntegrityRoot, if enabled
+	BucketVersions []uint64               `protobuf:"varint,6,rep,packed,name=bucket_versions,json=bucketVersions,proto3" json:"bucket_versions,omitempty"` // PathORAM.BucketVersions, if enabled
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Checkpoint) Reset() {
+	*x = Checkpoint{}
+	mi := &file_pathoram_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Checkpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Checkpoint) ProtoMessage() {}
+
+func (x *Checkpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Checkpoint.ProtoReflect.Descriptor instead.
+func (*Checkpoint) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Checkpoint) GetNumBlocks() int64 {
+	if x != nil {
+		return x.NumBlocks
+	}
+	return 0
+}
+
+func (x *Checkpoint) GetBlockSize() int32 {
+	if x != nil {
+		return x.BlockSize
+	}
+	return 0
+}
+
+func (x *Checkpoint) GetBucketSize() int32 {
+	if x != nil {
+		return x.BucketSize
+	}
+	return 0
+}
+
+func (x *Checkpoint) GetState() *StateUpdate {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *Checkpoint) GetIntegrityRoot() []byte {
+	if x != nil {
+		return x.IntegrityRoot
+	}
+	return nil
+}
+
+func (x *Checkpoint) GetBucketVersions() []uint64 {
+	if x != nil {
+		return x.BucketVersions
+	}
+	return nil
+}
+
+// TraceEvent is one bucket operation as observed by the storage server.
+type TraceEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Op            TraceEvent_Op          `protobuf:"varint,2,opt,name=op,proto3,enum=pathoram.v1.TraceEvent_Op" json:"op,omitempty"`
+	Bucket        int64                  `protobuf:"varint,3,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	UnixNanos     int64                  `protobuf:"varint,4,opt,name=unix_nanos,json=unixNanos,proto3" json:"unix_nanos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraceEvent) Reset() {
+	*x = TraceEvent{}
+	mi := &file_pathoram_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceEvent) ProtoMessage() {}
+
+func (x *TraceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceEvent.ProtoReflect.Descriptor instead.
+func (*TraceEvent) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TraceEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *TraceEvent) GetOp() TraceEvent_Op {
+	if x != nil {
+		return x.Op
+	}
+	return TraceEvent_OP_UNSPECIFIED
+}
+
+func (x *TraceEvent) GetBucket() int64 {
+	if x != nil {
+		return x.Bucket
+	}
+	return 0
+}
+
+func (x *TraceEvent) GetUnixNanos() int64 {
+	if x != nil {
+		return x.UnixNanos
+	}
+	return 0
+}
+
+// Trace is a recorded sequence of bucket operations.
+type Trace struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Info          *StorageInfo           `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Events        []*TraceEvent          `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Trace) Reset() {
+	*x = Trace{}
+	mi := &file_pathoram_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trace) ProtoMessage() {}
+
+func (x *Trace) ProtoReflect() protoreflect.Message {
+	mi := &file_pathoram_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trace.ProtoReflect.Descriptor instead.
+func (*Trace) Descriptor() ([]byte, []int) {
+	return file_pathoram_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Trace) GetInfo() *StorageInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *Trace) GetEvents() []*TraceEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+var File_pathoram_proto protoreflect.FileDescriptor
+
+const file_pathoram_proto_rawDesc = "" +
+	"\n" +
+	"\x0epathoram.proto\x12\vpathoram.v1\"?\n" +
+	"\x05Block\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04leaf\x18\x02 \x01(\x03R\x04leaf\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"4\n" +
+	"\x06Bucket\x12*\n" +
+	"\x06blocks\x18\x01 \x03(\v2\x12.pathoram.v1.BlockR\x06blocks\"n\n" +
+	"\vStorageInfo\x12\x1f\n" +
+	"\vnum_buckets\x18\x01 \x01(\x03R\n" +
+	"numBuckets\x12\x1f\n" +
+	"\vbucket_size\x18\x02 \x01(\x05R\n" +
+	"bucketSize\x12\x1d\n" +
+	"\n" +
+	"block_size\x18\x03 \x01(\x05R\tblockSize\"\x10\n" +
+	"\x0eGetInfoRequest\".\n" +
+	"\x12ReadBucketsRequest\x12\x18\n" +
+	"\aindexes\x18\x01 \x03(\x03R\aindexes\"D\n" +
+	"\x13ReadBucketsResponse\x12-\n" +
+	"\abuckets\x18\x01 \x03(\v2\x13.pathoram.v1.BucketR\abuckets\"^\n" +
+	"\x13WriteBucketsRequest\x12\x18\n" +
+	"\aindexes\x18\x01 \x03(\x03R\aindexes\x12-\n" +
+	"\abuckets\x18\x02 \x03(\v2\x13.pathoram.v1.BucketR\abuckets\"\x16\n" +
+	"\x14WriteBucketsResponse\"D\n" +
+	"\n" +
+	"StashEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04leaf\x18\x02 \x01(\x03R\x04leaf\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\x8a\x02\n" +
+	"\vStateUpdate\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04full\x18\x02 \x01(\bR\x04full\x12E\n" +
+	"\tpositions\x18\x03 \x03(\v2'.pathoram.v1.StateUpdate.PositionsEntryR\tpositions\x12-\n" +
+	"\x05stash\x18\x04 \x03(\v2\x17.pathoram.v1.StashEntryR\x05stash\x12!\n" +
+	"\faccess_count\x18\x05 \x01(\x04R\vaccessCount\x1a<\n" +
+	"\x0ePositionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\xeb\x01\n" +
+	"\n" +
+	"Checkpoint\x12\x1d\n" +
+	"\n" +
+	"num_blocks\x18\x01 \x01(\x03R\tnumBlocks\x12\x1d\n" +
+	"\n" +
+	"block_size\x18\x02 \x01(\x05R\tblockSize\x12\x1f\n" +
+	"\vbucket_size\x18\x03 \x01(\x05R\n" +
+	"bucketSize\x12.\n" +
+	"\x05state\x18\x04 \x01(\v2\x18.pathoram.v1.StateUpdateR\x05state\x12%\n" +
+	"\x0eintegrity_root\x18\x05 \x01(\fR\rintegrityRoot\x12'\n" +
+	"\x0fbucket_versions\x18\x06 \x03(\x04R\x0ebucketVersions\"\xb6\x01\n" +
+	"\n" +
+	"TraceEvent\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12*\n" +
+	"\x02op\x18\x02 \x01(\x0e2\x1a.pathoram.v1.TraceEvent.OpR\x02op\x12\x16\n" +
+	"\x06bucket\x18\x03 \x01(\x03R\x06bucket\x12\x1d\n" +
+	"\n" +
+	"unix_nanos\x18\x04 \x01(\x03R\tunixNanos\"3\n" +
+	"\x02Op\x12\x12\n" +
+	"\x0eOP_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aOP_READ\x10\x01\x12\f\n" +
+	"\bOP_WRITE\x10\x02\"f\n" +
+	"\x05Trace\x12,\n" +
+	"\x04info\x18\x01 \x01(\v2\x18.pathoram.v1.StorageInfoR\x04info\x12/\n" +
+	"\x06events\x18\x02 \x03(\v2\x17.pathoram.v1.TraceEventR\x06events2\xf8\x01\n" +
+	"\aStorage\x12B\n" +
+	"\aGetInfo\x12\x1b.pathoram.v1.GetInfoRequest\x1a\x18.pathoram.v1.StorageInfo\"\x00\x12R\n" +
+	"\vReadBuckets\x12\x1f.pathoram.v1.ReadBucketsRequest\x1a .pathoram.v1.ReadBucketsResponse\"\x00\x12U\n" +
+	"\fWriteBuckets\x12 .pathoram.v1.WriteBucketsRequest\x1a!.pathoram.v1.WriteBucketsResponse\"\x00B*Z(github.com/etclab/pathoram-go/pathorampbb\x06proto3"
+
+var (
+	file_pathoram_proto_rawDescOnce sync.Once
+	file_pathoram_proto_rawDescData []byte
+)
+
+func file_pathoram_proto_rawDescGZIP() []byte {
+	file_pathoram_proto_rawDescOnce.Do(func() {
+		file_pathoram_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pathoram_proto_rawDesc), len(file_pathoram_proto_rawDesc)))
+	})
+	return file_pathoram_proto_rawDescData
+}
+
+var file_pathoram_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pathoram_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_pathoram_proto_goTypes = []any{
+	(TraceEvent_Op)(0),           // 0: pathoram.v1.TraceEvent.Op
+	(*Block)(nil),                // 1: pathoram.v1.Block
+	(*Bucket)(nil),               // 2: pathoram.v1.Bucket
+	(*StorageInfo)(nil),          // 3: pathoram.v1.StorageInfo
+	(*GetInfoRequest)(nil),       // 4: pathoram.v1.GetInfoRequest
+	(*ReadBucketsRequest)(nil),   // 5: pathoram.v1.ReadBucketsRequest
+	(*ReadBucketsResponse)(nil),  // 6: pathoram.v1.ReadBucketsResponse
+	(*WriteBucketsRequest)(nil),  // 7: pathoram.v1.WriteBucketsRequest
+	(*WriteBucketsResponse)(nil), // 8: pathoram.v1.WriteBucketsResponse
+	(*StashEntry)(nil),           // 9: pathoram.v1.StashEntry
+	(*StateUpdate)(nil),          // 10: pathoram.v1.StateUpdate
+	(*Checkpoint)(nil),           // 11: pathoram.v1.Checkpoint
+	(*TraceEvent)(nil),           // 12: pathoram.v1.TraceEvent
+	(*Trace)(nil),                // 13: pathoram.v1.Trace
+	nil,                          // 14: pathoram.v1.StateUpdate.PositionsEntry
+}
+var file_pathoram_proto_depIdxs = []int32{
+	1,  // 0: pathoram.v1.Bucket.blocks:type_name -> pathoram.v1.Block
+	2,  // 1: pathoram.v1.ReadBucketsResponse.buckets:type_name -> pathoram.v1.Bucket
+	2,  // 2: pathoram.v1.WriteBucketsRequest.buckets:type_name -> pathoram.v1.Bucket
+	14, // 3: pathoram.v1.StateUpdate.positions:type_name -> pathoram.v1.StateUpdate.PositionsEntry
+	9,  // 4: pathoram.v1.StateUpdate.stash:type_name -> pathoram.v1.StashEntry
+	10, // 5: pathoram.v1.Checkpoint.state:type_name -> pathoram.v1.StateUpdate
+	0,  // 6: pathoram.v1.TraceEvent.op:type_name -> pathoram.v1.TraceEvent.Op
+	3,  // 7: pathoram.v1.Trace.info:type_name -> pathoram.v1.StorageInfo
+	12, // 8: pathoram.v1.Trace.events:type_name -> pathoram.v1.TraceEvent
+	4,  // 9: pathoram.v1.Storage.GetInfo:input_type -> pathoram.v1.GetInfoRequest
+	5,  // 10: pathoram.v1.Storage.ReadBuckets:input_type -> pathoram.v1.ReadBucketsRequest
+	7,  // 11: pathoram.v1.Storage.WriteBuckets:input_type -> pathoram.v1.WriteBucketsRequest
+	3,  // 12: pathoram.v1.Storage.GetInfo:output_type -> pathoram.v1.StorageInfo
+	6,  // 13: pathoram.v1.Storage.ReadBuckets:output_type -> pathoram.v1.ReadBucketsResponse
+	8,  // 14: pathoram.v1.Storage.WriteBuckets:output_type -> pathoram.v1.WriteBucketsResponse
+	12, // [12:15] is the sub-list for method output_type
+	9,  // [9:12] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_pathoram_proto_init() }
+func file_pathoram_proto_init() {
+	if File_pathoram_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pathoram_proto_rawDesc), len(file_pathoram_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pathoram_proto_goTypes,
+		DependencyIndexes: file_pathoram_proto_depIdxs,
+		EnumInfos:         file_pathoram_proto_enumTypes,
+		MessageInfos:      file_pathoram_proto_msgTypes,
+	}.Build()
+	File_pathoram_proto = out.File
+	file_pathoram_proto_goTypes = nil
+	file_pathoram_proto_depIdxs = nil
+}
@@ -0,0 +1,240 @@
+// Package pathoramredis provides a pathoram.Storage backend that keeps the
+// bucket store in Redis, so the ORAM server side can live in an existing
+// Redis deployment. It is a separate module so the core package stays
+// dependency-free.
+package pathoramredis
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/redis/go-redis/v9"
+)
+
+// Storage stores each ORAM bucket under its own Redis key. Path reads are
+// one MGET and path write-backs one MSET (Redis applies it atomically), so a
+// path costs one round trip each way. Buckets never written read as empty.
+//
+// Keys are "{prefix}:b:<idx>", plus "{prefix}:counter" and
+// "{prefix}:fingerprint" for the replay counter and key fingerprint. The
+// braces make the prefix a hash tag, so on Redis Cluster all of an ORAM's
+// keys share a slot and multi-key commands work.
+//
+// Connections come from the client's pool (redis.Options.PoolSize); one
+// client can serve several Storages with different prefixes.
+//
+// Storage implements pathoram.StorageCtx, pathoram.BatchStorage,
+// pathoram.ResizableStorage, pathoram.CounterStorage and
+// pathoram.FingerprintStorage.
+type Storage struct {
+	client     redis.UniversalClient
+	prefix     string
+	numBuckets int
+	bucketSize int
+	blockSize  int
+}
+
+var (
+	_ pathoram.StorageCtx         = (*Storage)(nil)
+	_ pathoram.BatchStorage       = (*Storage)(nil)
+	_ pathoram.ResizableStorage   = (*Storage)(nil)
+	_ pathoram.CounterStorage     = (*Storage)(nil)
+	_ pathoram.FingerprintStorage = (*Storage)(nil)
+)
+
+// New returns a Storage of numBuckets buckets of bucketSize blocks of
+// blockSize bytes, under prefix in the Redis deployment client talks to.
+// The caller owns client and closes it.
+func New(client redis.UniversalClient, prefix string, numBuckets, bucketSize, blockSize int) (*Storage, error) {
+	if numBuckets <= 0 || bucketSize <= 0 || blockSize <= 0 {
+		return nil, pathoram.ErrInvalidConfig
+	}
+	return &Storage{
+		client:     client,
+		prefix:     "{" + prefix + "}",
+		numBuckets: numBuckets,
+		bucketSize: bucketSize,
+		blockSize:  blockSize,
+	}, nil
+}
+
+// key returns the Redis key for bucket idx.
+func (s *Storage) key(idx int) string {
+	return s.prefix + ":b:" + strconv.Itoa(idx)
+}
+
+// encode serializes a bucket: per slot, the block ID and leaf as 8 bytes
+// each, then blockSize bytes of data.
+func (s *Storage) encode(blocks []pathoram.Block) ([]byte, error) {
+	if len(blocks) != s.bucketSize {
+		return nil, pathoram.ErrInvalidConfig
+	}
+	buf := make([]byte, 0, s.bucketSize*(16+s.blockSize))
+	for _, b := range blocks {
+		if len(b.Data) != s.blockSize {
+			return nil, pathoram.ErrInvalidDataSize
+		}
+		buf = binary.BigEndian.AppendUint64(buf, uint64(b.ID))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(b.Leaf))
+		buf = append(buf, b.Data...)
+	}
+	return buf, nil
+}
+
+// decode parses a value written by encode, or returns an empty bucket for a
+// missing key.
+func (s *Storage) decode(v any) ([]pathoram.Block, error) {
+	blocks := make([]pathoram.Block, s.bucketSize)
+	if v == nil {
+		for i := range blocks {
+			blocks[i] = pathoram.Block{ID: pathoram.EmptyBlockID, Leaf: -1, Data: make([]byte, s.blockSize)}
+		}
+		return blocks, nil
+	}
+	rec, ok := v.(string)
+	if !ok || len(rec) != s.bucketSize*(16+s.blockSize) {
+		return nil, fmt.Errorf("%w: unexpected bucket value", pathoram.ErrCorruptObject)
+	}
+	for i := range blocks {
+		blocks[i] = pathoram.Block{
+			ID:   int(int64(binary.BigEndian.Uint64([]byte(rec[:8])))),
+			Leaf: int(int64(binary.BigEndian.Uint64([]byte(rec[8:16])))),
+			Data: []byte(rec[16 : 16+s.blockSize]),
+		}
+		rec = rec[16+s.blockSize:]
+	}
+	return blocks, nil
+}
+
+// checkIndex reports whether idx is a valid bucket index.
+func (s *Storage) checkIndex(idx int) error {
+	if idx < 0 || idx >= s.numBuckets {
+		return pathoram.ErrInvalidConfig
+	}
+	return nil
+}
+
+// ReadBucket returns the bucket at idx.
+func (s *Storage) ReadBucket(idx int) ([]pathoram.Block, error) {
+	return s.ReadBucketCtx(context.Background(), idx)
+}
+
+// WriteBucket writes the bucket at idx.
+func (s *Storage) WriteBucket(idx int, blocks []pathoram.Block) error {
+	return s.WriteBucketCtx(context.Background(), idx, blocks)
+}
+
+// ReadBucketCtx is like ReadBucket but honors ctx.
+func (s *Storage) ReadBucketCtx(ctx context.Context, idx int) ([]pathoram.Block, error) {
+	buckets, err := s.ReadBuckets(ctx, []int{idx})
+	if err != nil {
+		return nil, err
+	}
+	return buckets[0], nil
+}
+
+// WriteBucketCtx is like WriteBucket but honors ctx.
+func (s *Storage) WriteBucketCtx(ctx context.Context, idx int, blocks []pathoram.Block) error {
+	return s.WriteBuckets(ctx, []int{idx}, [][]pathoram.Block{blocks})
+}
+
+// ReadBuckets returns the buckets at idxs with one MGET.
+func (s *Storage) ReadBuckets(ctx context.Context, idxs []int) ([][]pathoram.Block, error) {
+	keys := make([]string, len(idxs))
+	for i, idx := range idxs {
+		if err := s.checkIndex(idx); err != nil {
+			return nil, err
+		}
+		keys[i] = s.key(idx)
+	}
+	vals, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([][]pathoram.Block, len(idxs))
+	for i, v := range vals {
+		if buckets[i], err = s.decode(v); err != nil {
+			return nil, err
+		}
+	}
+	return buckets, nil
+}
+
+// WriteBuckets writes buckets[i] to the bucket at idxs[i] with one MSET,
+// which Redis applies atomically.
+func (s *Storage) WriteBuckets(ctx context.Context, idxs []int, buckets [][]pathoram.Block) error {
+	pairs := make([]any, 0, 2*len(idxs))
+	for i, idx := range idxs {
+		if err := s.checkIndex(idx); err != nil {
+			return err
+		}
+		rec, err := s.encode(buckets[i])
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, s.key(idx), rec)
+	}
+	return s.client.MSet(ctx, pairs...).Err()
+}
+
+// NumBuckets returns the number of buckets.
+func (s *Storage) NumBuckets() int { return s.numBuckets }
+
+// BucketSize returns the number of block slots per bucket.
+func (s *Storage) BucketSize() int { return s.bucketSize }
+
+// BlockSize returns the size of each block's data in bytes.
+func (s *Storage) BlockSize() int { return s.blockSize }
+
+// Resize sets the number of buckets, deleting the keys of buckets past the
+// new end.
+func (s *Storage) Resize(numBuckets int) error {
+	if numBuckets <= 0 {
+		return pathoram.ErrInvalidConfig
+	}
+	if numBuckets < s.numBuckets {
+		keys := make([]string, 0, s.numBuckets-numBuckets)
+		for idx := numBuckets; idx < s.numBuckets; idx++ {
+			keys = append(keys, s.key(idx))
+		}
+		if err := s.client.Del(context.Background(), keys...).Err(); err != nil {
+			return err
+		}
+	}
+	s.numBuckets = numBuckets
+	return nil
+}
+
+// ReadCounter returns the sealed access counter, or nil if none was written.
+func (s *Storage) ReadCounter() ([]byte, error) {
+	return s.get(s.prefix + ":counter")
+}
+
+// WriteCounter replaces the sealed access counter.
+func (s *Storage) WriteCounter(sealed []byte) error {
+	return s.client.Set(context.Background(), s.prefix+":counter", sealed, 0).Err()
+}
+
+// ReadFingerprint returns the stored key fingerprint, or nil if none was
+// written.
+func (s *Storage) ReadFingerprint() ([]byte, error) {
+	return s.get(s.prefix + ":fingerprint")
+}
+
+// WriteFingerprint replaces the stored key fingerprint.
+func (s *Storage) WriteFingerprint(fp []byte) error {
+	return s.client.Set(context.Background(), s.prefix+":fingerprint", fp, 0).Err()
+}
+
+// get returns the value at key, or nil if it does not exist.
+func (s *Storage) get(key string) ([]byte, error) {
+	v, err := s.client.Get(context.Background(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return v, err
+}
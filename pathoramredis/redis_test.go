@@ -0,0 +1,88 @@
+package pathoramredis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestStorage_ORAMRoundTrip(t *testing.T) {
+	_, client := newTestClient(t)
+	enc, _ := pathoram.NewAESGCMEncryptor(bytes.Repeat([]byte{3}, 32))
+	cfg, _ := pathoram.Config{NumBlocks: 64, BlockSize: 32, FetchParallelism: 1}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	s, err := New(client, "tenant-a", total, cfg.BucketSize, cfg.BlockSize+enc.Overhead())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	oram, err := pathoram.New(cfg, s, pathoram.NewInMemoryPositionMap(), enc)
+	if err != nil {
+		t.Fatalf("pathoram.New: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 32)); err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+	}
+	for i := range cfg.NumBlocks {
+		got, err := oram.Read(i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 32)) {
+			t.Fatalf("Read(%d) = %x, %v", i, got, err)
+		}
+	}
+	if fp, err := s.ReadFingerprint(); err != nil || fp == nil {
+		t.Errorf("ReadFingerprint = %x, %v; want the key fingerprint", fp, err)
+	}
+}
+
+func TestStorage_BatchCalls(t *testing.T) {
+	mr, client := newTestClient(t)
+	s, _ := New(client, "p", 4, 2, 4)
+	ctx := context.Background()
+
+	blocks := []pathoram.Block{{ID: 1, Leaf: 0, Data: []byte("abcd")}, {ID: 2, Leaf: 1, Data: []byte("efgh")}}
+	if err := s.WriteBuckets(ctx, []int{0, 3}, [][]pathoram.Block{blocks, blocks}); err != nil {
+		t.Fatalf("WriteBuckets: %v", err)
+	}
+	if !mr.Exists("{p}:b:3") {
+		t.Error("bucket 3 not stored under its hash-tagged key")
+	}
+	got, err := s.ReadBuckets(ctx, []int{3, 1})
+	if err != nil {
+		t.Fatalf("ReadBuckets: %v", err)
+	}
+	if got[0][1].ID != 2 || !bytes.Equal(got[0][1].Data, []byte("efgh")) || got[1][0].ID != pathoram.EmptyBlockID {
+		t.Errorf("ReadBuckets = %v", got)
+	}
+
+	bad := []pathoram.Block{{ID: 1, Leaf: 0, Data: []byte("ab")}, blocks[1]}
+	if err := s.WriteBuckets(ctx, []int{1, 2}, [][]pathoram.Block{blocks, bad}); !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Fatalf("WriteBuckets with short block = %v, want ErrInvalidDataSize", err)
+	}
+	if mr.Exists("{p}:b:1") {
+		t.Error("failed batch wrote bucket 1")
+	}
+
+	if err := s.Resize(2); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if mr.Exists("{p}:b:3") {
+		t.Error("Resize kept a key past the new end")
+	}
+	if _, err := s.ReadBucket(3); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("ReadBucket past end = %v, want ErrInvalidConfig", err)
+	}
+}
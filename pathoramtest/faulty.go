@@ -0,0 +1,169 @@
+package pathoramtest
+
+import (
+	"errors"
+	mathrand "math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// ErrInjectedFault is the error FaultyStorage returns for the failures it
+// injects, unless Faults.Err is set.
+var ErrInjectedFault = errors.New("pathoramtest: injected storage fault")
+
+// Faults configures the failures a FaultyStorage injects. Rates are
+// probabilities in [0, 1] applied independently to each call.
+type Faults struct {
+	ReadErrorRate  float64 // ReadBucket fails without reading
+	WriteErrorRate float64 // WriteBucket fails without writing
+
+	// TornWriteRate is the probability that WriteBucket stores only a
+	// random prefix of the new blocks, keeping the old ones in the
+	// remaining slots, and then fails, as a backend that crashes mid-write
+	// might.
+	TornWriteRate float64
+
+	Latency time.Duration // added to every call
+	Jitter  time.Duration // uniform random extra latency in [0, Jitter)
+
+	Seed uint64 // seeds the fault schedule, so runs are reproducible
+	Err  error  // returned for injected failures (default ErrInjectedFault)
+}
+
+// FaultCounts reports the faults a FaultyStorage has injected.
+type FaultCounts struct {
+	Reads       uint64 // ReadBucket calls
+	Writes      uint64 // WriteBucket calls
+	ReadErrors  uint64
+	WriteErrors uint64
+	TornWrites  uint64
+}
+
+// FaultyStorage wraps a pathoram.Storage and injects errors, latency and
+// torn writes, to test how an ORAM and the code around it behave when the
+// backend misbehaves. It is safe for concurrent use if the wrapped Storage
+// is. Only the Storage methods are wrapped: optional extensions of the
+// inner storage (batch reads, contexts) are hidden, so every bucket goes
+// through the fault schedule.
+type FaultyStorage struct {
+	pathoram.Storage
+
+	mu      sync.Mutex
+	faults  Faults
+	rng     *mathrand.Rand
+	enabled bool
+	counts  FaultCounts
+}
+
+var _ pathoram.Storage = (*FaultyStorage)(nil)
+
+// NewFaultyStorage wraps s with the given faults, enabled.
+func NewFaultyStorage(s pathoram.Storage, faults Faults) *FaultyStorage {
+	return &FaultyStorage{
+		Storage: s,
+		faults:  faults,
+		rng:     mathrand.New(mathrand.NewPCG(faults.Seed, faults.Seed^0x9e3779b97f4a7c15)),
+		enabled: true,
+	}
+}
+
+// SetEnabled turns fault injection on or off, e.g. to set up state before
+// injecting faults or to check invariants after. Calls still count while
+// disabled.
+func (s *FaultyStorage) SetEnabled(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = on
+}
+
+// Counts returns the calls made and faults injected so far.
+func (s *FaultyStorage) Counts() FaultCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts
+}
+
+// ReadBucket reads bucket idx, or fails with probability ReadErrorRate.
+func (s *FaultyStorage) ReadBucket(idx int) ([]pathoram.Block, error) {
+	delay, fail := s.roll(func(c *FaultCounts) { c.Reads++ }, s.faults.ReadErrorRate)
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if fail {
+		s.note(func(c *FaultCounts) { c.ReadErrors++ })
+		return nil, s.err()
+	}
+	return s.Storage.ReadBucket(idx)
+}
+
+// WriteBucket writes bucket idx, or fails with probability WriteErrorRate,
+// or tears the write with probability TornWriteRate.
+func (s *FaultyStorage) WriteBucket(idx int, blocks []pathoram.Block) error {
+	delay, fail := s.roll(func(c *FaultCounts) { c.Writes++ }, s.faults.WriteErrorRate)
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if fail {
+		s.note(func(c *FaultCounts) { c.WriteErrors++ })
+		return s.err()
+	}
+	keep, torn := s.tear(len(blocks))
+	if !torn {
+		return s.Storage.WriteBucket(idx, blocks)
+	}
+	old, err := s.Storage.ReadBucket(idx)
+	if err != nil {
+		return err
+	}
+	mixed := slices.Clone(blocks)
+	for i := keep; i < len(mixed) && i < len(old); i++ {
+		mixed[i] = old[i]
+	}
+	if err := s.Storage.WriteBucket(idx, mixed); err != nil {
+		return err
+	}
+	s.note(func(c *FaultCounts) { c.TornWrites++ })
+	return s.err()
+}
+
+// roll counts a call and decides its latency and whether it fails.
+func (s *FaultyStorage) roll(count func(*FaultCounts), rate float64) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count(&s.counts)
+	if !s.enabled {
+		return 0, false
+	}
+	delay := s.faults.Latency
+	if s.faults.Jitter > 0 {
+		delay += time.Duration(s.rng.Int64N(int64(s.faults.Jitter)))
+	}
+	return delay, rate > 0 && s.rng.Float64() < rate
+}
+
+// tear decides whether a write of n blocks is torn and, if so, how many of
+// the new blocks reach storage.
+func (s *FaultyStorage) tear(n int) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled || s.faults.TornWriteRate <= 0 || n < 2 || s.rng.Float64() >= s.faults.TornWriteRate {
+		return 0, false
+	}
+	return 1 + s.rng.IntN(n-1), true
+}
+
+func (s *FaultyStorage) note(f func(*FaultCounts)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(&s.counts)
+}
+
+func (s *FaultyStorage) err() error {
+	if s.faults.Err != nil {
+		return s.faults.Err
+	}
+	return ErrInjectedFault
+}
@@ -0,0 +1,160 @@
+package pathoramtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+type faultyORAM struct {
+	oram    *pathoram.PathORAM
+	storage *FaultyStorage
+	inner   *pathoram.InMemoryStorage
+	posMap  pathoram.PositionMap
+	stash   *pathoram.SliceStash
+}
+
+func newFaultyORAM(t *testing.T, faults Faults) *faultyORAM {
+	t.Helper()
+	cfg, err := pathoram.Config{NumBlocks: 32, BlockSize: 8, BucketSize: 4, Stash: pathoram.NewSliceStash(),
+		Rand: pathoram.NewSeededRand([32]byte{7})}.Validate()
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	_, _, total := cfg.ComputeTreeParams()
+	f := &faultyORAM{
+		inner:  pathoram.NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize),
+		posMap: pathoram.NewInMemoryPositionMap(),
+		stash:  cfg.Stash.(*pathoram.SliceStash),
+	}
+	f.storage = NewFaultyStorage(f.inner, faults)
+	f.storage.SetEnabled(false)
+	if f.oram, err = pathoram.New(cfg, f.storage, f.posMap, pathoram.NoOpEncryptor{}); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return f
+}
+
+func (f *faultyORAM) check() error {
+	return CheckState(f.inner, f.posMap, f.stash)
+}
+
+func TestFaultyStorageReadErrors(t *testing.T) {
+	f := newFaultyORAM(t, Faults{ReadErrorRate: 0.2, Seed: 1})
+	want := make([][]byte, 32)
+	for id := range want {
+		want[id] = bytes.Repeat([]byte{byte(id)}, 8)
+		f.oram.Write(id, want[id])
+	}
+	f.storage.SetEnabled(true)
+
+	// A failed read leaves the ORAM consistent; retries eventually succeed
+	for i := range 300 {
+		id := i * 7 % 32
+		for {
+			got, err := f.oram.Read(id)
+			if errors.Is(err, ErrInjectedFault) {
+				continue
+			}
+			if err != nil || !bytes.Equal(got, want[id]) {
+				t.Fatalf("Read(%d) = %v, %v; want %v", id, got, err, want[id])
+			}
+			break
+		}
+	}
+	f.storage.SetEnabled(false)
+	if err := f.oram.DummyAccess(context.Background()); err != nil {
+		t.Fatalf("DummyAccess failed: %v", err)
+	}
+	if err := f.check(); err != nil {
+		t.Fatalf("CheckState: %v", err)
+	}
+	if c := f.storage.Counts(); c.ReadErrors == 0 || c.WriteErrors != 0 || c.Reads < 300 {
+		t.Fatalf("counts = %+v", c)
+	}
+}
+
+func TestFaultyStorageTornWrites(t *testing.T) {
+	f := newFaultyORAM(t, Faults{TornWriteRate: 0.3, Seed: 2})
+	for id := range 32 {
+		f.oram.Write(id, make([]byte, 8))
+	}
+	if err := f.check(); err != nil {
+		t.Fatalf("CheckState before faults: %v", err)
+	}
+	f.storage.SetEnabled(true)
+	for i := range 100 {
+		f.oram.Read(i % 32)
+	}
+	f.storage.SetEnabled(false)
+	f.oram.DummyAccess(context.Background())
+
+	if f.storage.Counts().TornWrites == 0 {
+		t.Fatal("no torn writes injected")
+	}
+	if err := f.check(); !errors.Is(err, pathoram.ErrInvariantViolated) {
+		t.Fatalf("CheckState after torn writes = %v, want ErrInvariantViolated", err)
+	}
+}
+
+func TestFaultyStorageLatency(t *testing.T) {
+	inner := pathoram.NewInMemoryStorage(1, 1, 8)
+	s := NewFaultyStorage(inner, Faults{Latency: 2 * time.Millisecond, Jitter: time.Millisecond})
+	start := time.Now()
+	if _, err := s.ReadBucket(0); err != nil {
+		t.Fatalf("ReadBucket failed: %v", err)
+	}
+	if d := time.Since(start); d < 2*time.Millisecond {
+		t.Fatalf("ReadBucket took %v, want at least 2ms", d)
+	}
+}
+
+func TestCheckState(t *testing.T) {
+	f := newFaultyORAM(t, Faults{})
+	for id := range 32 {
+		f.oram.Write(id, make([]byte, 8))
+	}
+	if err := f.check(); err != nil {
+		t.Fatalf("CheckState: %v", err)
+	}
+
+	// Copy a stored block into the root: it is duplicated, and the copy's
+	// leaf path still includes the root, so only the duplicate is reported
+	dup := pathoram.Block{ID: pathoram.EmptyBlockID}
+	for idx := f.inner.NumBuckets() - 1; dup.ID == pathoram.EmptyBlockID && idx > 0; idx-- {
+		bucket, _ := f.inner.ReadBucket(idx)
+		for _, b := range bucket {
+			if b.ID != pathoram.EmptyBlockID {
+				dup = b
+				break
+			}
+		}
+	}
+	root, _ := f.inner.ReadBucket(0)
+	orig := slices.Clone(root)
+	for i := range root {
+		if root[i].ID == pathoram.EmptyBlockID {
+			root[i] = dup
+			break
+		}
+	}
+	f.inner.WriteBucket(0, root)
+	if err := f.check(); !errors.Is(err, pathoram.ErrInvariantViolated) {
+		t.Fatalf("CheckState with a duplicate = %v, want ErrInvariantViolated", err)
+	}
+
+	// A position map that disagrees with the tree
+	f.inner.WriteBucket(0, orig)
+	if err := f.check(); err != nil {
+		t.Fatalf("CheckState after restoring the root: %v", err)
+	}
+	f.posMap.Set(dup.ID, (dup.Leaf+1)%f.oram.NumLeaves())
+	if err := f.check(); !errors.Is(err, pathoram.ErrInvariantViolated) {
+		t.Fatalf("CheckState with a stale position = %v, want ErrInvariantViolated", err)
+	}
+}
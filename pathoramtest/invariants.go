@@ -0,0 +1,37 @@
+package pathoramtest
+
+import (
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// ErrInvariant is returned by CheckState when the ORAM's state is
+// inconsistent.
+//
+// Deprecated: It is pathoram.ErrInvariantViolated; use that.
+var ErrInvariant = pathoram.ErrInvariantViolated
+
+// CheckState checks the Path ORAM invariants over an ORAM's storage,
+// position map and stash, as passed to pathoram.New and Config.Stash:
+//
+//   - no block ID appears twice across the tree and the stash;
+//   - every stored block sits on the path of its leaf, and its leaf is the
+//     one the position map holds for it;
+//   - every stash entry's leaf is valid and matches the position map;
+//   - every block in the position map is in the tree or the stash.
+//
+// It is pathoram.FsckStash with the report turned into an error. Block IDs
+// and leaves are stored in the clear, so nothing is decrypted. Call it
+// between accesses: a Stash is only brought up to date at the end of each
+// successful access, so after a failed one, run one more access (e.g.
+// DummyAccess) first. With the ORAM at hand,
+// pathoram.PathORAM.CheckInvariants checks the same over its live stash.
+//
+// The error wraps pathoram.ErrInvariantViolated and lists the first
+// violations (see pathoram.FsckReport.Err).
+func CheckState(storage pathoram.Storage, posMap pathoram.PositionMap, stash pathoram.Stash) error {
+	report, err := pathoram.FsckStash(storage, posMap, stash)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
@@ -0,0 +1,226 @@
+// Package pathoramtest provides helpers for auditing PathORAM deployments
+// and modifications: statistical checks of the access traces of
+// pathoram.Config.Trace, a fault-injecting Storage wrapper, and checks of
+// the invariants relating tree, stash and position map.
+package pathoramtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// ErrDistinguishable is returned by VerifyObliviousness when the traces of
+// two workloads can be told apart.
+var ErrDistinguishable = errors.New("access traces are distinguishable")
+
+// Workload is a sequence of logical operations run against a fresh ORAM.
+type Workload func(o *pathoram.PathORAM) error
+
+// Options configures VerifyObliviousness.
+type Options struct {
+	// New builds the ORAM a workload runs against from a Config with Trace
+	// set (default pathoram.NewInMemory).
+	New func(cfg pathoram.Config) (*pathoram.PathORAM, error)
+
+	// Alpha is the significance level of the statistical tests (default
+	// 0.001). Each test has false-alarm probability Alpha on an oblivious
+	// ORAM.
+	Alpha float64
+}
+
+// Report summarizes a VerifyObliviousness run. The float fields are the
+// p-values of chi-square tests: Reads and Writes that both workloads read
+// and write the same numbers of buckets per access, Homogeneity that they
+// read path leaves from the same distribution, and UniformA and UniformB
+// that each reads leaves uniformly.
+type Report struct {
+	Accesses    int // accesses per workload
+	Bins        int // leaf histogram bins (leaves are grouped when samples are few)
+	Reads       float64
+	Writes      float64
+	Homogeneity float64
+	UniformA    float64
+	UniformB    float64
+}
+
+// VerifyObliviousness runs workloads a and b on fresh ORAMs built from cfg
+// and checks that their traces are indistinguishable: the numbers of buckets
+// read and written per access must be identically distributed, and so must
+// the leaves of the paths read, which must also be uniform. It returns
+// ErrDistinguishable, with the Report, if a check fails.
+//
+// The workloads should perform the same number of accesses, enough of them
+// (a few thousand) for the tests to have power, and should store the same
+// blocks. EvictLevelByLevel writes back only the buckets it fills, which
+// depends on where accessed blocks sat, so it fails the write test; audit
+// with EvictGreedyByDepth or ConstantTime, which rewrite the whole path. Passing is evidence,
+// not proof: the tests only look at path leaves and trace shape.
+func VerifyObliviousness(cfg pathoram.Config, a, b Workload, opts *Options) (Report, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.New == nil {
+		o.New = pathoram.NewInMemory
+	}
+	if o.Alpha == 0 {
+		o.Alpha = 0.001
+	}
+	vcfg, err := cfg.Validate()
+	if err != nil {
+		return Report{}, err
+	}
+	_, numLeaves, _ := vcfg.ComputeTreeParams()
+
+	ta, err := record(cfg, a, o.New)
+	if err != nil {
+		return Report{}, err
+	}
+	tb, err := record(cfg, b, o.New)
+	if err != nil {
+		return Report{}, err
+	}
+	r := Report{Accesses: len(ta)}
+	if len(ta) != len(tb) {
+		return r, fmt.Errorf("%w: %d accesses vs %d", ErrDistinguishable, len(ta), len(tb))
+	}
+	r.Reads = homogeneity(counts(ta, tb, func(t pathoram.AccessTrace) int { return len(t.Reads) }))
+	r.Writes = homogeneity(counts(ta, tb, func(t pathoram.AccessTrace) int { return len(t.Writes) }))
+
+	la, lb := leaves(ta, numLeaves), leaves(tb, numLeaves)
+	r.Bins = 1 << (bits.Len(uint(min(numLeaves, max(2, min(len(la), len(lb))/10)))) - 1)
+	ha, hb := histogram(la, numLeaves, r.Bins), histogram(lb, numLeaves, r.Bins)
+	r.Homogeneity = homogeneity(ha, hb)
+	r.UniformA, r.UniformB = uniformity(ha), uniformity(hb)
+	switch {
+	case r.Reads < o.Alpha || r.Writes < o.Alpha:
+		return r, fmt.Errorf("%w: buckets read or written per access differ (p=%.3g, %.3g)", ErrDistinguishable, r.Reads, r.Writes)
+	case r.Homogeneity < o.Alpha:
+		return r, fmt.Errorf("%w: leaf distributions differ (p=%.3g)", ErrDistinguishable, r.Homogeneity)
+	case r.UniformA < o.Alpha || r.UniformB < o.Alpha:
+		return r, fmt.Errorf("%w: leaves are not uniform (p=%.3g, %.3g)", ErrDistinguishable, r.UniformA, r.UniformB)
+	}
+	return r, nil
+}
+
+// record runs w on a new ORAM and returns its traces.
+func record(cfg pathoram.Config, w Workload, build func(pathoram.Config) (*pathoram.PathORAM, error)) ([]pathoram.AccessTrace, error) {
+	var traces []pathoram.AccessTrace
+	cfg.Trace = pathoram.TraceFunc(func(t pathoram.AccessTrace) { traces = append(traces, t) })
+	oram, err := build(cfg)
+	if err != nil {
+		return nil, err
+	}
+	traces = traces[:0] // drop calibration or other setup accesses
+	if err := w(oram); err != nil {
+		return nil, err
+	}
+	if err := oram.Close(); err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// counts returns histograms of f over the traces of a and b, with one bin
+// per value seen in either.
+func counts(a, b []pathoram.AccessTrace, f func(pathoram.AccessTrace) int) ([]float64, []float64) {
+	bin := make(map[int]int)
+	for _, t := range slices.Concat(a, b) {
+		if _, ok := bin[f(t)]; !ok {
+			bin[f(t)] = len(bin)
+		}
+	}
+	ha, hb := make([]float64, len(bin)), make([]float64, len(bin))
+	for _, t := range a {
+		ha[bin[f(t)]]++
+	}
+	for _, t := range b {
+		hb[bin[f(t)]]++
+	}
+	return ha, hb
+}
+
+// leaves returns the leaf of each access's first leaf-level bucket read:
+// the path it read. Later reads, such as an eviction re-reading the path,
+// are not independent samples and are skipped.
+func leaves(traces []pathoram.AccessTrace, numLeaves int) []int {
+	var out []int
+	for _, t := range traces {
+		for _, idx := range t.Reads {
+			if idx >= numLeaves-1 {
+				out = append(out, idx-(numLeaves-1))
+				break
+			}
+		}
+	}
+	return out
+}
+
+// histogram counts leaves in bins equal ranges of leaves; bins divides
+// numLeaves.
+func histogram(leaves []int, numLeaves, bins int) []float64 {
+	h := make([]float64, bins)
+	for _, l := range leaves {
+		h[l*bins/numLeaves]++
+	}
+	return h
+}
+
+// homogeneity returns the p-value of a chi-square test that a and b are
+// samples of one distribution.
+func homogeneity(a, b []float64) float64 {
+	var na, nb float64
+	for i := range a {
+		na += a[i]
+		nb += b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 1
+	}
+	var x float64
+	df := -1
+	for i := range a {
+		tot := a[i] + b[i]
+		if tot == 0 {
+			continue
+		}
+		df++
+		ea, eb := tot*na/(na+nb), tot*nb/(na+nb)
+		x += (a[i]-ea)*(a[i]-ea)/ea + (b[i]-eb)*(b[i]-eb)/eb
+	}
+	return chiSquareSF(x, df)
+}
+
+// uniformity returns the p-value of a chi-square goodness-of-fit test of h
+// against equal bin counts.
+func uniformity(h []float64) float64 {
+	var n float64
+	for _, c := range h {
+		n += c
+	}
+	if n == 0 {
+		return 1
+	}
+	e := n / float64(len(h))
+	var x float64
+	for _, c := range h {
+		x += (c - e) * (c - e) / e
+	}
+	return chiSquareSF(x, len(h)-1)
+}
+
+// chiSquareSF returns P(X >= x) for X chi-square distributed with df
+// degrees of freedom, by the Wilson–Hilferty normal approximation.
+func chiSquareSF(x float64, df int) float64 {
+	if df <= 0 {
+		return 1
+	}
+	k := float64(df)
+	z := (math.Cbrt(x/k) - (1 - 2/(9*k))) / math.Sqrt(2/(9*k))
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
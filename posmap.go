@@ -1,5 +1,7 @@
 package pathoram
 
+import "crypto/subtle"
+
 // PositionMap tracks block-to-leaf assignments.
 // For recursive ORAM, this can be implemented as another ORAM instance.
 type PositionMap interface {
@@ -14,6 +16,54 @@ type PositionMap interface {
 	Size() int
 }
 
+// FalliblePositionMap is a PositionMap whose Get and Set can fail, such as
+// one backed by storage. Since they cannot return errors, a failure is
+// recorded and reported by Err, which PathORAM consults on every access.
+type FalliblePositionMap interface {
+	PositionMap
+
+	// Err returns the first error a Get or Set hit, or nil. Once set, it
+	// stays set: the map may have lost a position.
+	Err() error
+}
+
+// DeletablePositionMap is a PositionMap that can forget a block's position.
+// PathORAM.Delete unmaps the deleted block through it, so the block reads as
+// never written; with any other map it stays mapped. Every built-in
+// position map implements it.
+type DeletablePositionMap interface {
+	PositionMap
+
+	// Delete removes blockID's position, if it has one.
+	Delete(blockID int)
+}
+
+var (
+	_ DeletablePositionMap = (*InMemoryPositionMap)(nil)
+	_ DeletablePositionMap = (*ObliviousPositionMap)(nil)
+	_ DeletablePositionMap = (*PRFPositionMap)(nil)
+	_ DeletablePositionMap = (*RecursivePositionMap)(nil)
+	_ DeletablePositionMap = (*StoredPositionMap)(nil)
+)
+
+// posMapErr returns the position map's recorded error, if it records any.
+func (o *PathORAM) posMapErr() error {
+	if fm, ok := o.posMap.(FalliblePositionMap); ok {
+		return fm.Err()
+	}
+	return nil
+}
+
+// position returns blockID's leaf from the position map, reading the array
+// directly in embedded mode rather than through the interface.
+func (o *PathORAM) position(blockID int) (int, bool) {
+	if p := o.leaves; p != nil && blockID >= 0 && blockID < len(p.leaves) {
+		v := p.leaves[blockID]
+		return v - 1, v != 0
+	}
+	return o.posMap.Get(blockID)
+}
+
 // InMemoryPositionMap implements PositionMap using a Go map.
 type InMemoryPositionMap struct {
 	m map[int]int
@@ -37,7 +87,96 @@ func (p *InMemoryPositionMap) Set(blockID int, leaf int) {
 	p.m[blockID] = leaf
 }
 
+// Delete removes blockID's position.
+func (p *InMemoryPositionMap) Delete(blockID int) {
+	delete(p.m, blockID)
+}
+
 // Size returns the number of blocks with assigned positions.
 func (p *InMemoryPositionMap) Size() int {
 	return len(p.m)
 }
+
+// ObliviousPositionMap stores positions in a fixed-size array indexed by
+// block ID, allocated up front, so Get and Set do the same work whether or
+// not a block has been accessed before. A Go map's lookup cost and memory
+// layout depend on its contents; this array's do not.
+//
+// By default Get and Set index the array directly. With scan set, every call
+// touches every entry with constant-time selects instead, hiding the block
+// ID from cache-timing observers at O(NumBlocks) cost per lookup.
+// NewInMemory and NewORAM use one without scan when Config.ConstantTime is
+// set and no position map is supplied.
+type ObliviousPositionMap struct {
+	leaves []int // leaf+1 per block; 0 means unset
+	size   int
+	scan   bool
+}
+
+// NewObliviousPositionMap creates a position map for block IDs 0 to
+// numBlocks-1. Set grows the array if a larger ID is used (after Resize).
+func NewObliviousPositionMap(numBlocks int, scan bool) *ObliviousPositionMap {
+	return &ObliviousPositionMap{leaves: make([]int, numBlocks), scan: scan}
+}
+
+// Get returns the leaf position for blockID.
+func (p *ObliviousPositionMap) Get(blockID int) (int, bool) {
+	if blockID < 0 || blockID >= len(p.leaves) {
+		return 0, false
+	}
+	v := p.leaves[blockID]
+	if p.scan {
+		v = 0
+		for i, x := range p.leaves {
+			v = subtle.ConstantTimeSelect(subtle.ConstantTimeEq(int32(i), int32(blockID)), x, v)
+		}
+	}
+	return v - 1, v != 0
+}
+
+// Set assigns blockID to leaf.
+func (p *ObliviousPositionMap) Set(blockID int, leaf int) {
+	if blockID >= len(p.leaves) {
+		p.leaves = append(p.leaves, make([]int, blockID+1-len(p.leaves))...)
+	}
+	old := p.leaves[blockID]
+	if p.scan {
+		for i, x := range p.leaves {
+			p.leaves[i] = subtle.ConstantTimeSelect(subtle.ConstantTimeEq(int32(i), int32(blockID)), leaf+1, x)
+		}
+	} else {
+		p.leaves[blockID] = leaf + 1
+	}
+	p.size += subtle.ConstantTimeEq(int32(old), 0)
+}
+
+// Delete removes blockID's position, touching every entry with scan set.
+func (p *ObliviousPositionMap) Delete(blockID int) {
+	if blockID < 0 || blockID >= len(p.leaves) {
+		return
+	}
+	old := p.leaves[blockID]
+	if p.scan {
+		for i, x := range p.leaves {
+			p.leaves[i] = subtle.ConstantTimeSelect(subtle.ConstantTimeEq(int32(i), int32(blockID)), 0, x)
+		}
+	} else {
+		p.leaves[blockID] = 0
+	}
+	p.size -= 1 - subtle.ConstantTimeEq(int32(old), 0)
+}
+
+// Size returns the number of blocks with assigned positions.
+func (p *ObliviousPositionMap) Size() int {
+	return p.size
+}
+
+// defaultPositionMap returns the position map the convenience constructors
+// use for cfg: an ObliviousPositionMap in constant-time mode, otherwise an
+// InMemoryPositionMap.
+func defaultPositionMap(cfg Config) PositionMap {
+	if cfg.ConstantTime {
+		return NewObliviousPositionMap(cfg.NumBlocks, false)
+	}
+	return NewInMemoryPositionMap()
+}
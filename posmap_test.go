@@ -0,0 +1,60 @@
+package pathoram
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestObliviousPositionMap(t *testing.T) {
+	for _, scan := range []bool{false, true} {
+		pm := NewObliviousPositionMap(8, scan)
+		if _, ok := pm.Get(3); ok || pm.Size() != 0 {
+			t.Fatalf("scan=%v: empty map reports entries", scan)
+		}
+		pm.Set(3, 0)
+		pm.Set(5, 7)
+		pm.Set(3, 2)
+		pm.Set(10, 1) // beyond the initial size, as after Resize
+		for id, want := range map[int]int{3: 2, 5: 7, 10: 1} {
+			if leaf, ok := pm.Get(id); !ok || leaf != want {
+				t.Errorf("scan=%v: Get(%d) = %d, %v; want %d", scan, id, leaf, ok, want)
+			}
+		}
+		if _, ok := pm.Get(4); ok {
+			t.Errorf("scan=%v: Get(4) found an unset block", scan)
+		}
+		if _, ok := pm.Get(-1); ok {
+			t.Errorf("scan=%v: Get(-1) found a block", scan)
+		}
+		if pm.Size() != 3 {
+			t.Errorf("scan=%v: Size() = %d, want 3", scan, pm.Size())
+		}
+	}
+}
+
+func TestObliviousPositionMap_DefaultForConstantTime(t *testing.T) {
+	oram, err := NewORAM(WithCapacity(64, 16), WithConstantTime(), WithStashLimit(200))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	if _, ok := oram.posMap.(*ObliviousPositionMap); !ok {
+		t.Fatalf("posMap is %T, want *ObliviousPositionMap", oram.posMap)
+	}
+	for i := 0; i < 64; i++ {
+		oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16))
+	}
+	if err := oram.Resize(128); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	oram.Write(100, bytes.Repeat([]byte{100}, 16))
+	for _, i := range []int{0, 63, 100} {
+		if got, _ := oram.Read(i); !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Errorf("Read(%d) = %x", i, got)
+		}
+	}
+
+	plain, _ := NewORAM(WithCapacity(8, 16))
+	if _, ok := plain.posMap.(*InMemoryPositionMap); !ok {
+		t.Errorf("posMap without ConstantTime is %T, want *InMemoryPositionMap", plain.posMap)
+	}
+}
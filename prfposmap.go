@@ -0,0 +1,257 @@
+package pathoram
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+)
+
+// DefaultPositionMap is implemented by position maps that can derive every
+// block's initial leaf from its ID instead of storing it. BulkLoad places
+// each block at its default leaf and then calls UseDefaults, so a freshly
+// loaded ORAM's position map takes no memory per block.
+type DefaultPositionMap interface {
+	PositionMap
+
+	// DefaultLeaf returns blockID's default leaf in a tree of numLeaves
+	// leaves. It must be unpredictable without the map's secret.
+	DefaultLeaf(blockID, numLeaves int) int
+
+	// UseDefaults assigns every block ID below numBlocks its default leaf
+	// in a tree of numLeaves leaves, keeping positions already Set.
+	UseDefaults(numBlocks, numLeaves int)
+}
+
+// PRFPositionMap is a PositionMap that derives default leaves from a keyed
+// pseudorandom function of the block ID and stores only the positions that
+// deviate from them. Loaded with BulkLoad, every block starts at its
+// default leaf; each access moves the accessed block to a fresh random
+// leaf, recorded in a small override map. For a mostly cold dataset the
+// client keeps memory for the blocks touched since loading, not for all
+// NumBlocks.
+//
+// A default leaf is read at most once before the block moves, and without
+// the key the server cannot tell it from a random one, so the access
+// pattern is the same as with a stored map. The key is as sensitive as the
+// position map itself: keep it with the encryption key and the stash.
+//
+// Overrides accumulate, about one per block touched, until
+// PathORAM.CompactPositions starts a new epoch: default leaves are the PRF
+// of the epoch counter and the block ID, and the compaction pass moves
+// each block to its default for the new epoch, dropping its override.
+//
+// Until UseDefaults is called it behaves like an InMemoryPositionMap.
+// Deleting a block with a default leaf stores a tombstone override.
+// Positions are computed for one tree shape; Resize and Rebuild store
+// every moved block as an override until the next compaction pass.
+type PRFPositionMap struct {
+	prf        cipher.Block
+	numBlocks  int         // IDs below this have defaults, once set
+	numLeaves  int         // 0 until UseDefaults
+	overrides  map[int]int // positions that differ from the default
+	extra      int         // overridden IDs at or above numBlocks
+	removed    int         // IDs below numBlocks with a tombstone
+	epoch      uint64      // compaction passes started
+	swept      int         // IDs below this have defaults for epoch, the rest for epoch-1
+	prevLeaves int         // numLeaves of epoch-1, during a pass
+}
+
+// prfDeleted is the override of a deleted block that has a default leaf.
+const prfDeleted = -1
+
+var _ DefaultPositionMap = (*PRFPositionMap)(nil)
+
+// NewPRFPositionMap creates an empty PRFPositionMap keyed with a 16, 24 or
+// 32-byte secret key.
+func NewPRFPositionMap(key []byte) (*PRFPositionMap, error) {
+	prf, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("PRF position map key: %w", err)
+	}
+	return &PRFPositionMap{prf: prf, overrides: make(map[int]int)}, nil
+}
+
+// DefaultLeaf returns blockID's default leaf in a tree of numLeaves leaves
+// for the current epoch: AES of the epoch and the ID under the map's key,
+// reduced modulo numLeaves.
+func (p *PRFPositionMap) DefaultLeaf(blockID, numLeaves int) int {
+	return p.leafAt(p.epoch, blockID, numLeaves)
+}
+
+func (p *PRFPositionMap) leafAt(epoch uint64, blockID, numLeaves int) int {
+	var in, out [aes.BlockSize]byte
+	binary.BigEndian.PutUint64(in[:8], epoch)
+	binary.BigEndian.PutUint64(in[8:], uint64(blockID))
+	p.prf.Encrypt(out[:], in[:])
+	return int(binary.BigEndian.Uint64(out[:]) % uint64(numLeaves))
+}
+
+// UseDefaults assigns every block ID below numBlocks its default leaf in a
+// tree of numLeaves leaves. Positions already Set are kept.
+func (p *PRFPositionMap) UseDefaults(numBlocks, numLeaves int) {
+	p.numBlocks, p.numLeaves, p.swept = numBlocks, numLeaves, numBlocks
+	p.extra, p.removed = 0, 0
+	for id, leaf := range p.overrides {
+		switch {
+		case id >= numBlocks:
+			p.extra++
+		case leaf == prfDeleted:
+			p.removed++
+		case leaf == p.DefaultLeaf(id, numLeaves):
+			delete(p.overrides, id)
+		}
+	}
+}
+
+// Get returns the leaf position for blockID.
+func (p *PRFPositionMap) Get(blockID int) (int, bool) {
+	if leaf, ok := p.overrides[blockID]; ok {
+		return leaf, leaf != prfDeleted
+	}
+	if p.hasDefault(blockID) {
+		return p.defaultLeaf(blockID), true
+	}
+	return 0, false
+}
+
+// Set assigns blockID to leaf. Setting a block back to its default leaf
+// frees its override.
+func (p *PRFPositionMap) Set(blockID int, leaf int) {
+	old, had := p.overrides[blockID]
+	if had && old == prfDeleted {
+		p.removed--
+	}
+	if p.hasDefault(blockID) && leaf == p.defaultLeaf(blockID) {
+		delete(p.overrides, blockID)
+		return
+	}
+	p.overrides[blockID] = leaf
+	if !had && p.numLeaves > 0 && !p.hasDefault(blockID) {
+		p.extra++
+	}
+}
+
+// Delete removes blockID's position. A block with a default leaf keeps a
+// tombstone override until it is Set again.
+func (p *PRFPositionMap) Delete(blockID int) {
+	old, had := p.overrides[blockID]
+	switch {
+	case p.hasDefault(blockID):
+		if !had || old != prfDeleted {
+			p.overrides[blockID] = prfDeleted
+			p.removed++
+		}
+	case had:
+		delete(p.overrides, blockID)
+		if p.numLeaves > 0 {
+			p.extra--
+		}
+	}
+}
+
+// Size returns the number of blocks with assigned positions: NumBlocks
+// once defaults are in use, plus any overridden IDs beyond it, less deleted
+// ones.
+func (p *PRFPositionMap) Size() int {
+	if p.numLeaves == 0 {
+		return len(p.overrides)
+	}
+	return p.numBlocks + p.extra - p.removed
+}
+
+// Overrides returns the number of positions stored explicitly, which is
+// what the map's memory grows with.
+func (p *PRFPositionMap) Overrides() int {
+	return len(p.overrides)
+}
+
+// Epoch returns the number of compaction passes started. It is part of
+// the map's state: a map rebuilt from the key must be brought to the same
+// epoch and pass position to find the blocks.
+func (p *PRFPositionMap) Epoch() uint64 {
+	return p.epoch
+}
+
+// hasDefault reports whether blockID has a default leaf.
+func (p *PRFPositionMap) hasDefault(blockID int) bool {
+	return p.numLeaves > 0 && blockID >= 0 && blockID < p.numBlocks
+}
+
+// defaultLeaf returns the default leaf of blockID, which has one: for the
+// current epoch if the pass has swept it, else for the previous one.
+func (p *PRFPositionMap) defaultLeaf(blockID int) int {
+	if blockID >= p.swept {
+		return p.leafAt(p.epoch-1, blockID, p.prevLeaves)
+	}
+	return p.leafAt(p.epoch, blockID, p.numLeaves)
+}
+
+// beginEpoch starts a compaction pass into a tree of numLeaves leaves.
+func (p *PRFPositionMap) beginEpoch(numLeaves int) {
+	p.epoch++
+	p.prevLeaves, p.numLeaves = p.numLeaves, numLeaves
+	p.swept = 0
+}
+
+// sweep marks the next block of the pass swept and returns its new default
+// leaf. The caller has read the block from its old position and moves it
+// there.
+func (p *PRFPositionMap) sweep() int {
+	p.swept++
+	return p.defaultLeaf(p.swept - 1)
+}
+
+// CompactPositions runs up to maxAccesses accesses of a PRFPositionMap's
+// compaction pass and returns the number of blocks the pass has left; at 0
+// the pass is complete and the next call starts another. The first call
+// starts a new epoch. Each access reads one block from its current
+// position and moves it to its default leaf for the new epoch, which frees
+// its override, so after a complete pass the map holds only tombstones,
+// blocks beyond the loaded NumBlocks, and blocks accessed since the pass
+// reached them. The pass also returns blocks moved by Resize or Rebuild to
+// defaults for the current tree.
+//
+// The new default leaves are as unpredictable as random ones and each is
+// read at most once before the block moves again, so to the server every
+// access of the pass looks like a regular one. Deleted blocks are passed
+// with a dummy access. The epoch counter is 64 bits and does not wrap.
+//
+// It returns ErrInvalidConfig if the position map is not a PRFPositionMap.
+// ctx is checked between accesses.
+func (o *PathORAM) CompactPositions(ctx context.Context, maxAccesses int) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p, ok := o.posMap.(*PRFPositionMap)
+	if !ok {
+		return 0, fmt.Errorf("%w: CompactPositions needs a PRFPositionMap", ErrInvalidConfig)
+	}
+	if p.numLeaves == 0 {
+		return 0, nil
+	}
+	if p.swept == p.numBlocks {
+		p.beginEpoch(o.numLeaves)
+	}
+	for range maxAccesses {
+		if p.swept == p.numBlocks {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return p.numBlocks - p.swept, err
+		}
+		var err error
+		if id := p.swept; p.overrides[id] == prfDeleted {
+			p.swept++
+			err = o.evictPass(ctx)
+		} else {
+			o.compacting = true
+			_, err = o.access(ctx, id, nil, nil)
+			o.compacting = false
+		}
+		if err != nil {
+			return p.numBlocks - p.swept, err
+		}
+	}
+	return p.numBlocks - p.swept, nil
+}
@@ -0,0 +1,185 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPRFPositionMap(t *testing.T) {
+	pm, err := NewPRFPositionMap(bytes.Repeat([]byte{3}, 32))
+	if err != nil {
+		t.Fatalf("NewPRFPositionMap failed: %v", err)
+	}
+	if _, ok := pm.Get(0); ok || pm.Size() != 0 {
+		t.Fatal("empty map reports entries")
+	}
+	moved := (pm.DefaultLeaf(2, 8) + 1) % 8
+	pm.Set(2, moved)
+	pm.Set(20, 1)
+
+	pm.UseDefaults(16, 8)
+	if pm.Size() != 17 {
+		t.Errorf("Size() = %d, want 17", pm.Size())
+	}
+	for id := range 16 {
+		want := pm.DefaultLeaf(id, 8)
+		if id == 2 {
+			want = moved
+		}
+		if leaf, ok := pm.Get(id); !ok || leaf != want {
+			t.Errorf("Get(%d) = %d, %v; want %d", id, leaf, ok, want)
+		}
+	}
+	if leaf, ok := pm.Get(20); !ok || leaf != 1 {
+		t.Errorf("Get(20) = %d, %v; want 1", leaf, ok)
+	}
+	if _, ok := pm.Get(16); ok {
+		t.Error("Get(16) found a block beyond NumBlocks")
+	}
+
+	// Moving a block back to its default frees its override
+	pm.Set(7, (pm.DefaultLeaf(7, 8)+1)%8)
+	if pm.Overrides() != 3 {
+		t.Errorf("Overrides() = %d, want 3", pm.Overrides())
+	}
+	pm.Set(7, pm.DefaultLeaf(7, 8))
+	pm.Set(2, pm.DefaultLeaf(2, 8))
+	if pm.Overrides() != 1 {
+		t.Errorf("Overrides() = %d, want 1", pm.Overrides())
+	}
+
+	// Deleting a block with a default leaves a tombstone until it is Set
+	pm.Delete(5)
+	pm.Delete(5)
+	pm.Delete(20)
+	if _, ok := pm.Get(5); ok || pm.Size() != 15 {
+		t.Errorf("after Delete: Get(5) ok = %v, Size() = %d; want false, 15", ok, pm.Size())
+	}
+	pm.Set(5, pm.DefaultLeaf(5, 8))
+	if leaf, ok := pm.Get(5); !ok || leaf != pm.DefaultLeaf(5, 8) || pm.Size() != 16 || pm.Overrides() != 0 {
+		t.Errorf("after re-Set: Get(5) = %d, %v, Size() = %d, Overrides() = %d", leaf, ok, pm.Size(), pm.Overrides())
+	}
+
+	other, _ := NewPRFPositionMap(bytes.Repeat([]byte{4}, 32))
+	same := 0
+	for id := range 64 {
+		if pm.DefaultLeaf(id, 1<<20) == other.DefaultLeaf(id, 1<<20) {
+			same++
+		}
+	}
+	if same > 1 {
+		t.Errorf("%d of 64 default leaves agree under different keys", same)
+	}
+
+	if _, err := NewPRFPositionMap(make([]byte, 7)); err == nil {
+		t.Error("NewPRFPositionMap accepted a 7-byte key")
+	}
+}
+
+func TestPRFPositionMap_BulkLoad(t *testing.T) {
+	pm, _ := NewPRFPositionMap(bytes.Repeat([]byte{3}, 32))
+	oram, err := NewORAM(WithCapacity(256, 16), WithStashLimit(200), WithPositionMap(pm))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	data := make(map[int][]byte)
+	for i := range 200 {
+		data[i] = bytes.Repeat([]byte{byte(i)}, 16)
+	}
+	if err := oram.BulkLoad(data); err != nil {
+		t.Fatalf("BulkLoad failed: %v", err)
+	}
+	if pm.Overrides() != 0 || pm.Size() != 256 {
+		t.Fatalf("after BulkLoad: Overrides() = %d, Size() = %d; want 0, 256", pm.Overrides(), pm.Size())
+	}
+
+	for _, i := range []int{0, 17, 199, 255} {
+		want := make([]byte, 16)
+		if i < 200 {
+			want = data[i]
+		}
+		got, err := oram.Read(i)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Read(%d) = %x, want %x", i, got, want)
+		}
+	}
+	if pm.Overrides() > 4 {
+		t.Errorf("Overrides() = %d after 4 accesses", pm.Overrides())
+	}
+	report, err := oram.CheckInvariants()
+	if err != nil {
+		t.Fatalf("CheckInvariants failed: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPRFPositionMap_Compact(t *testing.T) {
+	pm, _ := NewPRFPositionMap(bytes.Repeat([]byte{3}, 32))
+	oram, err := NewORAM(WithCapacity(128, 16), WithStashLimit(200), WithPositionMap(pm))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	data := make(map[int][]byte)
+	for i := range 128 {
+		data[i] = bytes.Repeat([]byte{byte(i)}, 16)
+	}
+	if err := oram.BulkLoad(data); err != nil {
+		t.Fatalf("BulkLoad failed: %v", err)
+	}
+	for i := range 40 {
+		oram.Read(i * 3 % 128)
+	}
+	oram.Delete(9)
+	if pm.Overrides() < 30 {
+		t.Fatalf("Overrides() = %d after 40 accesses", pm.Overrides())
+	}
+
+	// Accesses interleaved with the pass leave overrides only for blocks
+	// already swept
+	before := oram.Stats().Accesses
+	left, err := oram.CompactPositions(t.Context(), 50)
+	if err != nil || left != 78 {
+		t.Fatalf("CompactPositions = %d, %v; want 78, nil", left, err)
+	}
+	oram.Read(5)
+	oram.Read(100)
+	for left > 0 {
+		if left, err = oram.CompactPositions(t.Context(), 50); err != nil {
+			t.Fatalf("CompactPositions failed: %v", err)
+		}
+	}
+	if n := oram.Stats().Accesses - before; n != 130 {
+		t.Errorf("pass performed %d accesses, want 130", n)
+	}
+	if pm.Epoch() != 1 || pm.Overrides() != 2 || pm.Size() != 127 {
+		t.Errorf("after pass: Epoch() = %d, Overrides() = %d, Size() = %d; want 1, 2, 127", pm.Epoch(), pm.Overrides(), pm.Size())
+	}
+	for i := range 128 {
+		want := data[i]
+		if i == 9 {
+			want = make([]byte, 16)
+		}
+		if got, err := oram.Read(i); err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("Read(%d) = %x, %v; want %x", i, got, err, want)
+		}
+	}
+	if report, err := oram.CheckInvariants(); err != nil || report.Err() != nil {
+		t.Fatalf("CheckInvariants = %v, %v", report.Err(), err)
+	}
+
+	// A completed pass starts the next epoch
+	if _, err := oram.CompactPositions(t.Context(), 1); err != nil || pm.Epoch() != 2 {
+		t.Errorf("CompactPositions = %v, Epoch() = %d; want nil, 2", err, pm.Epoch())
+	}
+
+	plain, _ := NewORAM(WithCapacity(16, 16))
+	if _, err := plain.CompactPositions(t.Context(), 1); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("CompactPositions without a PRFPositionMap: error = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,48 @@
+package pathoram
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	mathrand "math/rand/v2"
+)
+
+// NewSeededRand returns a deterministic CSPRNG (ChaCha8) for Config.Rand.
+// Use it only in tests and simulations: anyone who knows the seed can predict
+// every leaf assignment and nonce.
+func NewSeededRand(seed [32]byte) io.Reader {
+	return mathrand.NewChaCha8(seed)
+}
+
+// randIntn returns a uniform integer in [0, n) read from r, using rejection
+// sampling so the result depends only on the bytes r produces. It panics if r
+// fails, since no access can proceed without randomness.
+func randIntn(r io.Reader, n int) int {
+	if n <= 1 {
+		return 0
+	}
+	limit := ^uint64(0) - ^uint64(0)%uint64(n)
+	var buf [8]byte
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			panic("pathoram: random source failed: " + err.Error())
+		}
+		if v := binary.LittleEndian.Uint64(buf[:]); v < limit {
+			return int(v % uint64(n))
+		}
+	}
+}
+
+// randSource is implemented by the built-in encryptors so Config.Rand can
+// also drive nonce generation.
+type randSource interface {
+	setRand(r io.Reader)
+}
+
+// randReader returns r, or crypto/rand.Reader if r is nil.
+func randReader(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
@@ -0,0 +1,71 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConfigRand_Reproducible(t *testing.T) {
+	run := func(seed byte) (*InMemoryStorage, Stats) {
+		enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{1}, 32))
+		cfg, _ := Config{NumBlocks: 64, BlockSize: 16, StashLimit: 200}.Validate()
+		_, _, total := cfg.ComputeTreeParams()
+		storage := NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize+enc.Overhead())
+		oram, err := NewORAM(WithConfig(cfg), WithStorage(storage), WithEncryptor(enc),
+			WithRand(NewSeededRand([32]byte{seed})))
+		if err != nil {
+			t.Fatalf("NewORAM failed: %v", err)
+		}
+		for i := 0; i < 200; i++ {
+			if _, err := oram.Write(i%64, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+		return storage, oram.Stats()
+	}
+
+	a, statsA := run(1)
+	b, statsB := run(1)
+	if !reflect.DeepEqual(a.buckets, b.buckets) || statsA != statsB {
+		t.Error("same seed produced different storage contents or stats")
+	}
+	c, _ := run(2)
+	if reflect.DeepEqual(a.buckets, c.buckets) {
+		t.Error("different seeds produced identical storage contents")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("entropy exhausted") }
+
+func TestConfigRand_FailurePanics(t *testing.T) {
+	oram, err := NewInMemory(Config{NumBlocks: 8, BlockSize: 8, Rand: failingReader{}})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic when the random source fails")
+		}
+	}()
+	oram.Read(0)
+}
+
+func TestRandIntn(t *testing.T) {
+	r := NewSeededRand([32]byte{})
+	counts := make([]int, 5)
+	for range 5000 {
+		counts[randIntn(r, 5)]++
+	}
+	for v, n := range counts {
+		if n < 800 || n > 1200 {
+			t.Errorf("value %d drawn %d times out of 5000", v, n)
+		}
+	}
+	if randIntn(failingReader{}, 1) != 0 {
+		t.Error("randIntn(r, 1) != 0")
+	}
+}
@@ -0,0 +1,174 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Rebuild migrates every block into a new tree. See RebuildCtx.
+func (o *PathORAM) Rebuild(newCfg Config, storage Storage, posMap PositionMap, enc Encryptor) error {
+	return o.RebuildCtx(context.Background(), newCfg, storage, posMap, enc)
+}
+
+// RebuildCtx migrates every block into a new tree built from newCfg over
+// storage, which must be empty and sized for newCfg, and then switches the
+// ORAM to it. Any parameter may change: Z, height, capacity, block size,
+// eviction strategy, or the key, by passing a new Encryptor. posMap nil
+// means the default position map for newCfg; enc nil keeps the current
+// Encryptor. Blocks are resized as Import does, and every allocated block
+// ID must be below newCfg.NumBlocks.
+//
+// The migration is oblivious and needs memory for one bucket plus the new
+// tree's stash: it reads every old bucket once, in order, and for each slot
+// performs one access on the new tree, writing the slot's block or, for an
+// empty slot, a dummy access. The stash is moved the same way, padded to
+// StashLimit accesses. The old storage is only read, so a failed rebuild
+// leaves the ORAM as it was; on success the old storage is no longer used
+// and the caller may delete it. Accesses wait until the rebuild finishes.
+//
+// The new tree takes its client state from newCfg: integrity and bucket
+// versions start afresh, replay protection must be enabled again, and
+// Stats keep counting. The block cache is emptied.
+func (o *PathORAM) RebuildCtx(ctx context.Context, newCfg Config, storage Storage, posMap PositionMap, enc Encryptor) error {
+	cacheErr := o.closeCache()
+	o.stopBackgroundEviction()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.rebuild(ctx, newCfg, storage, posMap, enc); err != nil {
+		if !o.closed {
+			if o.cfg.CacheBlocks > 0 {
+				o.cache = newBlockCache(o.cfg.CacheBlocks, o.cfg.SecureDelete)
+			}
+			if o.cfg.BackgroundEviction {
+				o.startBackgroundEviction()
+			}
+		}
+		return errors.Join(cacheErr, err)
+	}
+	if o.cfg.BackgroundEviction {
+		o.startBackgroundEviction()
+	}
+	return cacheErr
+}
+
+// rebuild implements RebuildCtx; o.mu must be held.
+func (o *PathORAM) rebuild(ctx context.Context, newCfg Config, storage Storage, posMap PositionMap, enc Encryptor) error {
+	if o.closed {
+		return ErrClosed
+	}
+	newCfg, err := newCfg.Validate()
+	if err != nil {
+		return err
+	}
+	for id := newCfg.NumBlocks; id < o.cfg.NumBlocks; id++ {
+		if _, ok := o.posMap.Get(id); ok {
+			return fmt.Errorf("%w: allocated block %d does not fit the new capacity", ErrInvalidConfig, id)
+		}
+	}
+	if posMap == nil {
+		posMap = defaultPositionMap(newCfg)
+	}
+	if enc == nil {
+		enc = o.encrypt
+	}
+	// The new tree runs its evictions inline until it replaces this one
+	bgEviction := newCfg.BackgroundEviction
+	newCfg.BackgroundEviction = false
+	n, err := New(newCfg, storage, posMap, enc)
+	if err != nil {
+		return err
+	}
+	adopted := false
+	defer func() {
+		if !adopted {
+			n.stopWriteBack()
+		}
+	}()
+	if n.posMap.Size() != 0 || len(n.stash) != 0 {
+		return ErrNotEmpty
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	data := n.newBlockData()
+	move := func(id int, plain []byte) error {
+		if id == EmptyBlockID {
+			return n.evictPass(ctx)
+		}
+		if !fitZeros(data[:n.cfg.BlockSize], plain[:o.cfg.BlockSize]) || !fitZeros(data[n.cfg.BlockSize:], plain[o.cfg.BlockSize:]) {
+			return fmt.Errorf("%w: block %d does not fit the new block size", ErrInvalidDataSize, id)
+		}
+		_, err := n.access(ctx, id, data, discardPrevious)
+		return err
+	}
+	for i := range max(len(o.stash), o.stashLimit()) {
+		id, plain := EmptyBlockID, []byte(nil)
+		if i < len(o.stash) {
+			id, plain = o.stash[i].id, o.stash[i].data
+		}
+		if err := move(id, plain); err != nil {
+			return err
+		}
+	}
+	for idx := range 2*o.numLeaves - 1 {
+		if idx < o.cachedBuckets() {
+			continue // cached levels live in the stash
+		}
+		bucket, err := o.readBucket(ctx, idx)
+		if err != nil {
+			return err
+		}
+		for _, b := range bucket {
+			var plain []byte
+			if b.ID != EmptyBlockID {
+				if plain, err = o.encrypt.Decrypt(b.ID, b.Leaf, b.Data); err != nil {
+					o.noteDecryptionFailure()
+					return err
+				}
+			}
+			if err := move(b.ID, plain); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.cfg.SecureDelete {
+		for i := range o.stash {
+			clear(o.stash[i].data)
+		}
+		if w, ok := o.encrypt.(Wiper); ok && enc != o.encrypt {
+			w.Wipe()
+		}
+	}
+	n.cfg.BackgroundEviction = bgEviction
+	if err := o.stopWriteBack(); err != nil {
+		return err
+	}
+	o.adopt(n)
+	adopted = true
+	return nil
+}
+
+// adopt makes n's tree and client state this ORAM's, keeping the locks,
+// Stats and the queues of waiting callers. o.mu must be held and no
+// background eviction may be running.
+func (o *PathORAM) adopt(n *PathORAM) {
+	o.cfg, o.height, o.numLeaves = n.cfg, n.height, n.numLeaves
+	o.storage, o.store, o.arena = n.storage, n.store, n.arena
+	o.posMap, o.leaves, o.encrypt, o.rng = n.posMap, n.leaves, n.encrypt, n.rng
+	o.xor = n.xor
+	o.slotStore, o.detached, o.slotIDs = n.slotStore, n.detached, n.slotIDs
+	o.stash, o.stashStore, o.stored, o.changed = n.stash, n.stashStore, n.stored, n.changed
+	if s, ok := o.stashStore.(*SliceStash); ok {
+		s.lock = &o.mu
+	}
+	o.accessCount, o.replay = n.accessCount, n.replay
+	o.integrity, o.versions = n.integrity, n.versions
+	o.replSeq, o.posDelta = n.replSeq, n.posDelta
+	o.pending, o.bgErr = nil, nil
+	o.plainBufs, o.cipherBufs, o.sealed = n.plainBufs, n.cipherBufs, n.sealed
+	o.sealWorkers, o.sealJobs = n.sealWorkers, n.sealJobs
+	o.cache, o.writeBack = n.cache, n.writeBack
+	o.traceReads, o.traceWrites = n.traceReads, n.traceWrites
+	o.scratch, o.padTo = n.scratch, n.padTo
+}
@@ -0,0 +1,104 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// newRebuildStorage returns empty storage for cfg encrypted with enc.
+func newRebuildStorage(t *testing.T, cfg Config, enc Encryptor) *InMemoryStorage {
+	t.Helper()
+	cfg, err := cfg.Validate()
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	_, _, totalBuckets := cfg.ComputeTreeParams()
+	return NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.plainSize()+enc.Overhead())
+}
+
+func TestRebuild(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		key  byte
+	}{
+		{"new key", Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4, StashLimit: 500}, 8},
+		{"larger buckets", Config{NumBlocks: 32, BlockSize: 16, BucketSize: 6, StashLimit: 500}, 7},
+		{"taller tree", Config{NumBlocks: 256, BlockSize: 16, BucketSize: 4, StashLimit: 500}, 7},
+		{"larger blocks", Config{NumBlocks: 32, BlockSize: 24, BucketSize: 4, StashLimit: 500}, 7},
+		{"greedy eviction", Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4, StashLimit: 500, EvictionStrategy: EvictGreedyByDepth}, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oram := newResizeTestORAM(t, 32)
+			for i := range 32 {
+				if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+					t.Fatalf("Write(%d) failed: %v", i, err)
+				}
+			}
+			enc := mustAES(t, bytes.Repeat([]byte{tt.key}, 32))
+			storage := newRebuildStorage(t, tt.cfg, enc)
+
+			if err := oram.Rebuild(tt.cfg, storage, nil, enc); err != nil {
+				t.Fatalf("Rebuild failed: %v", err)
+			}
+			if oram.Capacity() != tt.cfg.NumBlocks {
+				t.Errorf("Capacity() = %d, want %d", oram.Capacity(), tt.cfg.NumBlocks)
+			}
+			for i := range 32 {
+				got, err := oram.Read(i)
+				if err != nil {
+					t.Fatalf("Read(%d) failed: %v", i, err)
+				}
+				want := make([]byte, tt.cfg.BlockSize)
+				copy(want, bytes.Repeat([]byte{byte(i)}, 16))
+				if !bytes.Equal(got, want) {
+					t.Errorf("Read(%d) = %x, want %x", i, got, want)
+				}
+			}
+			report, err := oram.CheckInvariants()
+			if err != nil {
+				t.Fatalf("CheckInvariants failed: %v", err)
+			}
+			if err := report.Err(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRebuild_Errors(t *testing.T) {
+	oram := newResizeTestORAM(t, 32)
+	for i := range 32 {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	enc := mustAES(t, bytes.Repeat([]byte{7}, 32))
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4, StashLimit: 500}
+
+	small := Config{NumBlocks: 16, BlockSize: 16, BucketSize: 4, StashLimit: 500}
+	if err := oram.Rebuild(small, newRebuildStorage(t, small, enc), nil, enc); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Rebuild to fewer blocks: error = %v, want ErrInvalidConfig", err)
+	}
+	narrow := Config{NumBlocks: 32, BlockSize: 8, BucketSize: 4, StashLimit: 500}
+	if err := oram.Rebuild(narrow, newRebuildStorage(t, narrow, enc), nil, enc); !errors.Is(err, ErrInvalidDataSize) {
+		t.Errorf("Rebuild to smaller blocks: error = %v, want ErrInvalidDataSize", err)
+	}
+	failing := failingStorage{newRebuildStorage(t, cfg, enc)}
+	if err := oram.Rebuild(cfg, failing, nil, enc); err == nil {
+		t.Error("Rebuild onto failing storage should fail")
+	}
+
+	// A failed rebuild leaves the ORAM as it was
+	checkBlocks(t, oram, 32)
+	if _, err := oram.Write(0, bytes.Repeat([]byte{0}, 16)); err != nil {
+		t.Fatalf("Write after failed rebuild: %v", err)
+	}
+
+	oram.Close()
+	if err := oram.Rebuild(cfg, newRebuildStorage(t, cfg, enc), nil, enc); !errors.Is(err, ErrClosed) {
+		t.Errorf("Rebuild after Close: error = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,220 @@
+package pathoram
+
+import (
+	"container/list"
+	"encoding/binary"
+	"fmt"
+)
+
+// RecursivePositionMap stores block positions in pages inside an inner
+// PathORAM, so client memory is only the inner ORAM's own position map plus
+// an optional page cache. Each page is one inner block holding BlockSize/8
+// little-endian uint64 entries (leaf+1, with 0 meaning unset).
+//
+// With a page cache, every Get and Set issues exactly one inner ORAM access:
+// a miss fetches the needed page, and a hit performs a refresh access instead
+// (writing back the least recently used dirty page, or re-reading a cached
+// page). The storage server therefore sees the same access rate whether or
+// not a lookup hit the cache, and cannot tell which pages are hot.
+//
+// PositionMap methods cannot return errors, so the first inner ORAM failure
+// is recorded and returned by Err, which fails the outer access (see
+// FalliblePositionMap). After a failure, Get and Set make no inner accesses
+// and Get reports every block unset.
+type RecursivePositionMap struct {
+	inner    *PathORAM
+	perPage  int
+	numPages int
+	size     int
+
+	cacheCap int
+	lru      *list.List // of *posPage, most recently used at front
+	pages    map[int]*list.Element
+	refresh  int // round-robin cursor for clean refreshes
+
+	hits, misses uint64
+	err          error
+}
+
+// posPage is a cached, decrypted page of positions.
+type posPage struct {
+	idx   int
+	data  []byte
+	dirty bool
+}
+
+// PositionCacheStats reports page cache effectiveness.
+type PositionCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRate returns Hits / (Hits + Misses), or 0 before any lookup.
+func (s PositionCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewRecursivePositionMap creates a position map for numBlocks outer blocks
+// on top of inner, which must be empty and have at least
+// ceil(numBlocks / (inner.BlockSize()/8)) blocks. cachePages is the number of
+// decrypted pages kept in client memory (0 disables caching).
+func NewRecursivePositionMap(inner *PathORAM, numBlocks, cachePages int) (*RecursivePositionMap, error) {
+	perPage := inner.BlockSize() / 8
+	if perPage == 0 {
+		return nil, fmt.Errorf("%w: inner block size must be at least 8 bytes", ErrInvalidConfig)
+	}
+	numPages := (numBlocks + perPage - 1) / perPage
+	if numPages > inner.Capacity() {
+		return nil, fmt.Errorf("%w: inner ORAM needs %d blocks, has %d", ErrInvalidConfig, numPages, inner.Capacity())
+	}
+	return &RecursivePositionMap{
+		inner:    inner,
+		perPage:  perPage,
+		numPages: numPages,
+		cacheCap: cachePages,
+		lru:      list.New(),
+		pages:    make(map[int]*list.Element),
+	}, nil
+}
+
+// Get returns the leaf position for blockID.
+func (p *RecursivePositionMap) Get(blockID int) (int, bool) {
+	if p.err != nil {
+		return 0, false
+	}
+	page := p.page(blockID / p.perPage)
+	v := binary.LittleEndian.Uint64(page.data[(blockID%p.perPage)*8:])
+	if v == 0 {
+		return 0, false
+	}
+	return int(v - 1), true
+}
+
+// Set assigns blockID to leaf.
+func (p *RecursivePositionMap) Set(blockID int, leaf int) {
+	if p.err != nil {
+		return
+	}
+	page := p.page(blockID / p.perPage)
+	off := (blockID % p.perPage) * 8
+	if binary.LittleEndian.Uint64(page.data[off:]) == 0 {
+		p.size++
+	}
+	binary.LittleEndian.PutUint64(page.data[off:], uint64(leaf)+1)
+	page.dirty = true
+	if p.cacheCap == 0 {
+		p.writePage(page)
+	}
+}
+
+// Delete removes blockID's position, with the inner accesses Set makes.
+func (p *RecursivePositionMap) Delete(blockID int) {
+	if p.err != nil {
+		return
+	}
+	page := p.page(blockID / p.perPage)
+	off := (blockID % p.perPage) * 8
+	if binary.LittleEndian.Uint64(page.data[off:]) != 0 {
+		p.size--
+	}
+	binary.LittleEndian.PutUint64(page.data[off:], 0)
+	page.dirty = true
+	if p.cacheCap == 0 {
+		p.writePage(page)
+	}
+}
+
+// Size returns the number of blocks with assigned positions.
+func (p *RecursivePositionMap) Size() int {
+	return p.size
+}
+
+// CacheStats returns page cache hit and miss counts.
+func (p *RecursivePositionMap) CacheStats() PositionCacheStats {
+	return PositionCacheStats{Hits: p.hits, Misses: p.misses}
+}
+
+// Err returns the first inner ORAM error, or nil.
+func (p *RecursivePositionMap) Err() error {
+	return p.err
+}
+
+// Flush writes all dirty cached pages to the inner ORAM and returns Err.
+func (p *RecursivePositionMap) Flush() error {
+	for e := p.lru.Front(); e != nil && p.err == nil; e = e.Next() {
+		if pg := e.Value.(*posPage); pg.dirty {
+			p.writePage(pg)
+		}
+	}
+	return p.err
+}
+
+// page returns page idx, performing exactly one inner access.
+func (p *RecursivePositionMap) page(idx int) *posPage {
+	if p.cacheCap == 0 {
+		p.misses++
+		return &posPage{idx: idx, data: p.readPage(idx)}
+	}
+
+	if e, ok := p.pages[idx]; ok {
+		p.hits++
+		p.lru.MoveToFront(e)
+		p.refreshAccess()
+		return e.Value.(*posPage)
+	}
+
+	p.misses++
+	pg := &posPage{idx: idx, data: p.readPage(idx)}
+	p.pages[idx] = p.lru.PushFront(pg)
+	if p.lru.Len() > p.cacheCap {
+		victim := p.lru.Remove(p.lru.Back()).(*posPage)
+		delete(p.pages, victim.idx)
+		if victim.dirty {
+			// Write-back of an evicted page is an extra access, but it happens
+			// only on misses after the cache fills, independent of hit patterns.
+			p.writePage(victim)
+		}
+	}
+	return pg
+}
+
+// refreshAccess performs the inner access that stands in for a cache hit.
+func (p *RecursivePositionMap) refreshAccess() {
+	for e := p.lru.Back(); e != nil; e = e.Prev() {
+		if pg := e.Value.(*posPage); pg.dirty {
+			p.writePage(pg)
+			return
+		}
+	}
+	p.refresh = (p.refresh + 1) % p.numPages
+	p.readPage(p.refresh)
+}
+
+// readPage reads page idx, or on failure records the error and returns a
+// zero page.
+func (p *RecursivePositionMap) readPage(idx int) []byte {
+	data, err := p.inner.Read(idx)
+	if err != nil {
+		p.fail(fmt.Errorf("recursive position map read: %w", err))
+		return make([]byte, p.inner.BlockSize())
+	}
+	return data
+}
+
+func (p *RecursivePositionMap) writePage(pg *posPage) {
+	if _, err := p.inner.Write(pg.idx, pg.data); err != nil {
+		p.fail(fmt.Errorf("recursive position map write: %w", err))
+		return
+	}
+	pg.dirty = false
+}
+
+// fail records err unless an earlier error was recorded.
+func (p *RecursivePositionMap) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
@@ -0,0 +1,127 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newTestRecursivePosMap(t *testing.T, numBlocks, cachePages int) (*RecursivePositionMap, *PathORAM) {
+	t.Helper()
+	inner, err := NewInMemory(Config{NumBlocks: 16, BlockSize: 64, BucketSize: 4})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	pm, err := NewRecursivePositionMap(inner, numBlocks, cachePages)
+	if err != nil {
+		t.Fatalf("NewRecursivePositionMap failed: %v", err)
+	}
+	return pm, inner
+}
+
+func TestRecursivePositionMap(t *testing.T) {
+	for _, cachePages := range []int{0, 2} {
+		pm, _ := newTestRecursivePosMap(t, 100, cachePages)
+
+		if _, ok := pm.Get(5); ok {
+			t.Error("Get(5) on empty map returned ok")
+		}
+		for i := 0; i < 100; i++ {
+			pm.Set(i, i*3)
+		}
+		pm.Set(7, 0) // leaf 0 must be distinguishable from unset
+		if pm.Size() != 100 {
+			t.Errorf("cache=%d: Size() = %d, want 100", cachePages, pm.Size())
+		}
+		pm.Flush()
+		for i := 0; i < 100; i++ {
+			want := i * 3
+			if i == 7 {
+				want = 0
+			}
+			if leaf, ok := pm.Get(i); !ok || leaf != want {
+				t.Errorf("cache=%d: Get(%d) = (%d, %v), want (%d, true)", cachePages, i, leaf, ok, want)
+			}
+		}
+	}
+}
+
+func TestRecursivePositionMap_ConstantAccessRate(t *testing.T) {
+	pm, inner := newTestRecursivePosMap(t, 100, 4)
+
+	// Warm the cache, then compare inner accesses for hits and misses
+	pm.Get(0)
+	before := inner.AccessCount()
+	for i := 0; i < 10; i++ {
+		pm.Get(1) // hit: same page as 0
+	}
+	hitAccesses := inner.AccessCount() - before
+
+	if hitAccesses != 10 {
+		t.Errorf("10 cache hits caused %d inner accesses, want 10", hitAccesses)
+	}
+	if s := pm.CacheStats(); s.Hits != 10 || s.Misses != 1 {
+		t.Errorf("CacheStats() = %+v, want 10 hits, 1 miss", s)
+	}
+}
+
+func TestRecursivePositionMap_AsPositionMap(t *testing.T) {
+	pm, _ := newTestRecursivePosMap(t, 64, 4)
+
+	cfg := Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4}
+	cfg, _ = cfg.Validate()
+	_, _, totalBuckets := cfg.ComputeTreeParams()
+	oram, err := New(cfg, NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.BlockSize), pm, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for i := 0; i < 64; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 32)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	for i := 0; i < 64; i++ {
+		got, err := oram.Read(i)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 32)) {
+			t.Errorf("Read(%d) mismatch", i)
+		}
+	}
+	if pm.CacheStats().HitRate() == 0 {
+		t.Error("expected some cache hits")
+	}
+}
+
+func TestNewRecursivePositionMap_TooSmall(t *testing.T) {
+	inner, _ := NewInMemory(Config{NumBlocks: 2, BlockSize: 64})
+	if _, err := NewRecursivePositionMap(inner, 100, 0); err == nil {
+		t.Error("expected error for undersized inner ORAM")
+	}
+}
+
+func TestRecursivePositionMap_InnerFailure(t *testing.T) {
+	for _, cachePages := range []int{0, 2} {
+		pm, inner := newTestRecursivePosMap(t, 64, cachePages)
+		oram, err := NewORAM(WithCapacity(64, 32), WithPositionMap(pm))
+		if err != nil {
+			t.Fatalf("NewORAM failed: %v", err)
+		}
+		if _, err := oram.Write(1, make([]byte, 32)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+
+		inner.Close()
+		if _, err := oram.Write(2, make([]byte, 32)); !errors.Is(err, ErrClosed) {
+			t.Errorf("cache=%d: Write after inner Close: error = %v, want ErrClosed", cachePages, err)
+		}
+		if _, err := oram.Read(1); !errors.Is(err, ErrClosed) {
+			t.Errorf("cache=%d: later Read: error = %v, want ErrClosed", cachePages, err)
+		}
+		if err := pm.Flush(); !errors.Is(err, ErrClosed) {
+			t.Errorf("cache=%d: Flush error = %v, want ErrClosed", cachePages, err)
+		}
+	}
+}
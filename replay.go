@@ -0,0 +1,205 @@
+package pathoram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CounterStorage is implemented by storage backends that can hold the sealed
+// global access counter alongside the tree, so that a snapshot of the storage
+// includes the counter value it was taken at.
+type CounterStorage interface {
+	// ReadCounter returns the sealed counter, or nil if none was written yet.
+	ReadCounter() ([]byte, error)
+
+	// WriteCounter replaces the sealed counter.
+	WriteCounter(sealed []byte) error
+}
+
+// CounterStore persists the client's last known access counter in trusted
+// local storage. It must survive client restarts.
+type CounterStore interface {
+	// Load returns the last saved counter, or 0 if none was saved.
+	Load() (uint64, error)
+
+	// Save records the current counter.
+	Save(counter uint64) error
+}
+
+// ReplayProtector detects a storage server that rolled the whole tree back
+// to an earlier snapshot across client restarts. It authenticates a global
+// access counter kept in storage with HMAC-SHA256 and compares it against
+// the client's last known value on enable.
+//
+// Rollback of individual buckets while keeping the latest counter is not
+// detected here; that requires per-bucket integrity.
+type ReplayProtector struct {
+	key   []byte
+	local CounterStore
+}
+
+const (
+	counterMACSize    = sha256.Size
+	counterSealedSize = 8 + counterMACSize
+)
+
+// NewReplayProtector creates a ReplayProtector with a MAC key of at least 32 bytes.
+func NewReplayProtector(key []byte, local CounterStore) (*ReplayProtector, error) {
+	if len(key) < 32 {
+		return nil, fmt.Errorf("MAC key must be at least 32 bytes, got %d", len(key))
+	}
+	k := make([]byte, len(key))
+	copy(k, key)
+	return &ReplayProtector{key: k, local: local}, nil
+}
+
+// seal returns counter || HMAC(counter).
+func (r *ReplayProtector) seal(counter uint64) []byte {
+	buf := binary.LittleEndian.AppendUint64(make([]byte, 0, counterSealedSize), counter)
+	return append(buf, r.mac(buf)...)
+}
+
+// open verifies and decodes a sealed counter.
+func (r *ReplayProtector) open(sealed []byte) (uint64, error) {
+	if len(sealed) != counterSealedSize {
+		return 0, ErrCounterTampered
+	}
+	if !hmac.Equal(sealed[8:], r.mac(sealed[:8])) {
+		return 0, ErrCounterTampered
+	}
+	return binary.LittleEndian.Uint64(sealed[:8]), nil
+}
+
+func (r *ReplayProtector) mac(counter []byte) []byte {
+	h := hmac.New(sha256.New, r.key)
+	h.Write([]byte("pathoram-access-counter"))
+	h.Write(counter)
+	return h.Sum(nil)
+}
+
+// EnableReplayProtection verifies that the storage's access counter is not
+// behind the client's last known value and then keeps both up to date after
+// every access. Call it right after opening an ORAM over persistent storage.
+// Returns ErrRollbackDetected if the storage is stale and ErrCounterTampered
+// if the stored counter fails authentication.
+func (o *PathORAM) EnableReplayProtection(rp *ReplayProtector) error {
+	cs, ok := o.storage.(CounterStorage)
+	if !ok {
+		return fmt.Errorf("%w: storage does not implement CounterStorage", ErrInvalidConfig)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.writeBack != nil {
+		// The counter would be ahead of buckets still in the buffer, so a
+		// crash would hide a rollback
+		return fmt.Errorf("%w: replay protection needs MaxDirtyBuckets 0", ErrInvalidConfig)
+	}
+
+	lastKnown, err := rp.local.Load()
+	if err != nil {
+		return err
+	}
+
+	sealed, err := cs.ReadCounter()
+	if err != nil {
+		return err
+	}
+	var stored uint64
+	if sealed != nil {
+		if stored, err = rp.open(sealed); err != nil {
+			return err
+		}
+	}
+	if stored < lastKnown {
+		return ErrRollbackDetected
+	}
+
+	o.accessCount = stored
+	o.replay = rp
+	return nil
+}
+
+// AccessCount returns the number of completed accesses. With replay
+// protection enabled, this continues from the value recorded in storage.
+func (o *PathORAM) AccessCount() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.accessCount
+}
+
+// recordAccess advances the access counter after a successful access.
+// The storage counter is written before the local one, so a crash in between
+// leaves storage ahead of the client, which is accepted on the next open.
+func (o *PathORAM) recordAccess() error {
+	o.accessCount++
+	o.noteAccess()
+	o.emitTrace()
+	o.noteStash()
+	if err := o.posMapErr(); err != nil {
+		return err
+	}
+	if err := o.syncStash(); err != nil {
+		return err
+	}
+	if o.replay != nil {
+		if err := o.storage.(CounterStorage).WriteCounter(o.replay.seal(o.accessCount)); err != nil {
+			return err
+		}
+		if err := o.replay.local.Save(o.accessCount); err != nil {
+			return err
+		}
+	}
+	return o.replicate()
+}
+
+// FileCounterStore implements CounterStore using a small local file.
+// Updates are written to a temporary file and renamed into place.
+type FileCounterStore struct {
+	path string
+}
+
+// NewFileCounterStore creates a CounterStore backed by the file at path.
+func NewFileCounterStore(path string) *FileCounterStore {
+	return &FileCounterStore{path: path}
+}
+
+// Load returns the saved counter, or 0 if the file does not exist.
+func (f *FileCounterStore) Load() (uint64, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// Save atomically replaces the saved counter.
+func (f *FileCounterStore) Save(counter uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".counter-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.FormatUint(counter, 10) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
@@ -0,0 +1,102 @@
+package pathoram
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+// cloneInMemoryStorage snapshots storage, including its sealed counter.
+func cloneInMemoryStorage(s *InMemoryStorage) *InMemoryStorage {
+	c := NewInMemoryStorage(s.NumBuckets(), s.BucketSize(), s.BlockSize())
+	for i := 0; i < s.NumBuckets(); i++ {
+		bucket, _ := s.ReadBucket(i)
+		c.WriteBucket(i, bucket)
+	}
+	counter, _ := s.ReadCounter()
+	if counter != nil {
+		c.WriteCounter(counter)
+	}
+	return c
+}
+
+func newReplayTestORAM(t *testing.T, storage *InMemoryStorage, counterPath string, key []byte) (*PathORAM, error) {
+	t.Helper()
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4}
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	rp, err := NewReplayProtector(key, NewFileCounterStore(counterPath))
+	if err != nil {
+		t.Fatalf("NewReplayProtector failed: %v", err)
+	}
+	return oram, oram.EnableReplayProtection(rp)
+}
+
+func TestReplayProtection_DetectsRollback(t *testing.T) {
+	key := bytes.Repeat([]byte{0x01}, 32)
+	counterPath := filepath.Join(t.TempDir(), "counter")
+	storage := NewInMemoryStorage(31, 4, 16)
+
+	oram, err := newReplayTestORAM(t, storage, counterPath, key)
+	if err != nil {
+		t.Fatalf("EnableReplayProtection failed: %v", err)
+	}
+
+	var snapshot *InMemoryStorage
+	for i := 0; i < 5; i++ {
+		if _, err := oram.Write(i, make([]byte, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+		if i == 1 {
+			snapshot = cloneInMemoryStorage(storage)
+		}
+	}
+	if oram.AccessCount() != 5 {
+		t.Errorf("AccessCount() = %d, want 5", oram.AccessCount())
+	}
+
+	// Restart against the stale snapshot
+	if _, err := newReplayTestORAM(t, snapshot, counterPath, key); err != ErrRollbackDetected {
+		t.Errorf("EnableReplayProtection on snapshot error = %v, want ErrRollbackDetected", err)
+	}
+
+	// Restart against current storage resumes the counter
+	reopened, err := newReplayTestORAM(t, storage, counterPath, key)
+	if err != nil {
+		t.Fatalf("EnableReplayProtection on current storage failed: %v", err)
+	}
+	if reopened.AccessCount() != 5 {
+		t.Errorf("reopened AccessCount() = %d, want 5", reopened.AccessCount())
+	}
+}
+
+func TestReplayProtection_DetectsTampering(t *testing.T) {
+	key := bytes.Repeat([]byte{0x02}, 32)
+	counterPath := filepath.Join(t.TempDir(), "counter")
+	storage := NewInMemoryStorage(31, 4, 16)
+
+	oram, err := newReplayTestORAM(t, storage, counterPath, key)
+	if err != nil {
+		t.Fatalf("EnableReplayProtection failed: %v", err)
+	}
+	if _, err := oram.Read(0); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	// Server bumps the counter without knowing the key
+	sealed, _ := storage.ReadCounter()
+	sealed[0] ^= 0xFF
+	storage.WriteCounter(sealed)
+
+	if _, err := newReplayTestORAM(t, storage, counterPath, key); err != ErrCounterTampered {
+		t.Errorf("EnableReplayProtection error = %v, want ErrCounterTampered", err)
+	}
+}
+
+func TestNewReplayProtector_ShortKey(t *testing.T) {
+	if _, err := NewReplayProtector(make([]byte, 16), NewFileCounterStore("unused")); err == nil {
+		t.Error("NewReplayProtector with 16-byte key should fail")
+	}
+}
@@ -0,0 +1,163 @@
+package pathoram
+
+import (
+	"fmt"
+	"sync"
+)
+
+// StateUpdate carries client-state changes from a primary to a standby.
+// Updates are emitted after every completed access; the storage tree is shared
+// and is not replicated.
+type StateUpdate struct {
+	Seq         uint64       // 1 for the first update, then consecutive
+	Full        bool         // Positions is a complete snapshot rather than a delta
+	Positions   map[int]int  // block ID -> leaf changes since the previous update; -1 for a deleted block
+	Stash       []StashEntry // complete stash checkpoint
+	AccessCount uint64       // primary's access counter after this update
+}
+
+// StashEntry is a plaintext stash block in a StateUpdate.
+type StashEntry struct {
+	ID   int
+	Leaf int
+	Data []byte
+}
+
+// Replicator receives client-state updates from a primary.
+// If Replicate fails, the access that produced the update returns the error
+// and the unsent position changes are carried into the next update.
+type Replicator interface {
+	Replicate(StateUpdate) error
+}
+
+// setPosition updates the position map and records the change for replication.
+func (o *PathORAM) setPosition(blockID, leaf int) {
+	if o.leaves != nil {
+		o.leaves.Set(blockID, leaf)
+	} else {
+		o.posMap.Set(blockID, leaf)
+	}
+	o.notePosition(blockID, leaf)
+}
+
+// notePosition records a position change, or -1 for an unmapped block, for
+// the next replication delta.
+func (o *PathORAM) notePosition(blockID, leaf int) {
+	if o.cfg.Replicator != nil && o.replSeq > 0 {
+		if o.posDelta == nil {
+			o.posDelta = make(map[int]int)
+		}
+		o.posDelta[blockID] = leaf
+	}
+}
+
+// replicate sends the current client state to the configured Replicator.
+// The first update is a full position-map snapshot; later ones are deltas.
+func (o *PathORAM) replicate() error {
+	if o.cfg.Replicator == nil {
+		return nil
+	}
+	u := StateUpdate{
+		Seq:         o.replSeq + 1,
+		Full:        o.replSeq == 0,
+		Positions:   o.posDelta,
+		Stash:       make([]StashEntry, len(o.stash)),
+		AccessCount: o.accessCount,
+	}
+	if u.Full {
+		u.Positions = make(map[int]int, o.posMap.Size())
+		for id := 0; id < o.cfg.NumBlocks; id++ {
+			if leaf, ok := o.posMap.Get(id); ok {
+				u.Positions[id] = leaf
+			}
+		}
+	}
+	for i, b := range o.stash {
+		u.Stash[i] = StashEntry{ID: b.id, Leaf: b.leaf, Data: append([]byte(nil), b.data...)}
+	}
+	if err := o.cfg.Replicator.Replicate(u); err != nil {
+		return err
+	}
+	o.replSeq = u.Seq
+	o.posDelta = nil
+	return nil
+}
+
+// Standby holds a replicated copy of a primary's client state and can take
+// over from it without scanning storage. It implements Replicator, so it can
+// be attached to a primary in-process or fed from a stream with Follow
+// (unavailable in pathoram_minimal builds).
+type Standby struct {
+	mu          sync.Mutex
+	seq         uint64
+	positions   map[int]int
+	stash       []StashEntry
+	accessCount uint64
+}
+
+// NewStandby creates an empty standby awaiting its first full update.
+func NewStandby() *Standby {
+	return &Standby{}
+}
+
+// Replicate applies u. Delta updates must arrive in sequence after a full
+// update; otherwise ErrReplicationGap is returned and the standby is unchanged.
+func (s *Standby) Replicate(u StateUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u.Full {
+		s.positions = make(map[int]int, len(u.Positions))
+	} else if s.positions == nil || u.Seq != s.seq+1 {
+		return fmt.Errorf("%w: have %d, got %d", ErrReplicationGap, s.seq, u.Seq)
+	}
+	for id, leaf := range u.Positions {
+		if leaf < 0 {
+			delete(s.positions, id)
+		} else {
+			s.positions[id] = leaf
+		}
+	}
+	s.stash = u.Stash
+	s.seq = u.Seq
+	s.accessCount = u.AccessCount
+	return nil
+}
+
+// AccessCount returns the primary's access counter as of the last update.
+func (s *Standby) AccessCount() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accessCount
+}
+
+// Promote creates a PathORAM from the replicated state over the primary's
+// storage. cfg must match the primary's tree parameters. If cfg.Replicator is
+// set, the new primary starts its own stream with a full update.
+//
+// The standby reflects the last completed access: a primary that fails in the
+// middle of an access loses the blocks on that access's path, as it would
+// without replication.
+func (s *Standby) Promote(cfg Config, storage Storage, enc Encryptor) (*PathORAM, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.positions == nil {
+		return nil, fmt.Errorf("%w: no full update received", ErrReplicationGap)
+	}
+
+	posMap := NewInMemoryPositionMap()
+	for id, leaf := range s.positions {
+		posMap.Set(id, leaf)
+	}
+	o, err := New(cfg, storage, posMap, enc)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range s.stash {
+		o.stash = append(o.stash, block{id: e.ID, leaf: e.Leaf, data: append([]byte(nil), e.Data...)})
+	}
+	o.accessCount = s.accessCount
+	if err := o.syncStash(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
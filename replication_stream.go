@@ -0,0 +1,41 @@
+//go:build !pathoram_minimal
+
+package pathoram
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// StreamReplicator writes state updates to a stream (e.g. a socket to a
+// standby process) for consumption by Standby.Follow.
+type StreamReplicator struct {
+	enc *gob.Encoder
+}
+
+// NewStreamReplicator creates a Replicator that encodes updates to w.
+func NewStreamReplicator(w io.Writer) *StreamReplicator {
+	return &StreamReplicator{enc: gob.NewEncoder(w)}
+}
+
+// Replicate encodes u to the stream.
+func (r *StreamReplicator) Replicate(u StateUpdate) error {
+	return r.enc.Encode(u)
+}
+
+// Follow applies updates decoded from r until r reaches EOF.
+func (s *Standby) Follow(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	for {
+		var u StateUpdate
+		if err := dec.Decode(&u); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := s.Replicate(u); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !pathoram_minimal
+
+package pathoram
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStandby_Follow(t *testing.T) {
+	var stream bytes.Buffer
+	oram, err := NewORAM(WithCapacity(32, 8), WithReplicator(NewStreamReplicator(&stream)))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := oram.Write(i, make([]byte, 8)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+
+	standby := NewStandby()
+	if err := standby.Follow(&stream); err != nil {
+		t.Fatalf("Follow failed: %v", err)
+	}
+	if standby.AccessCount() != 10 {
+		t.Errorf("AccessCount() = %d, want 10", standby.AccessCount())
+	}
+}
@@ -0,0 +1,87 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestStandby_PromoteAfterFailover(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, StashLimit: 200}
+	cfg, _ = cfg.Validate()
+	_, _, totalBuckets := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.BlockSize)
+
+	standby := NewStandby()
+	primaryCfg := cfg
+	primaryCfg.Replicator = standby
+	primary, err := New(primaryCfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for i := 0; i < 64; i++ {
+		if _, err := primary.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+
+	// Primary is abandoned; the standby takes over the shared storage
+	promoted, err := standby.Promote(cfg, storage, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	if promoted.AccessCount() != primary.AccessCount() {
+		t.Errorf("AccessCount() = %d, want %d", promoted.AccessCount(), primary.AccessCount())
+	}
+	for i := 0; i < 64; i++ {
+		got, err := promoted.Read(i)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Errorf("Read(%d) = %x after promotion", i, got)
+		}
+	}
+}
+
+func TestStandby_Gap(t *testing.T) {
+	s := NewStandby()
+	if err := s.Replicate(StateUpdate{Seq: 3}); !errors.Is(err, ErrReplicationGap) {
+		t.Errorf("delta before full update: got %v, want ErrReplicationGap", err)
+	}
+	if err := s.Replicate(StateUpdate{Seq: 1, Full: true}); err != nil {
+		t.Fatalf("full update failed: %v", err)
+	}
+	if err := s.Replicate(StateUpdate{Seq: 3}); !errors.Is(err, ErrReplicationGap) {
+		t.Errorf("skipped update: got %v, want ErrReplicationGap", err)
+	}
+	if err := s.Replicate(StateUpdate{Seq: 2}); err != nil {
+		t.Errorf("in-sequence update failed: %v", err)
+	}
+	if _, err := NewStandby().Promote(Config{NumBlocks: 4, BlockSize: 4}, nil, NoOpEncryptor{}); !errors.Is(err, ErrReplicationGap) {
+		t.Errorf("Promote without state: got %v, want ErrReplicationGap", err)
+	}
+}
+
+func TestStandby_Delete(t *testing.T) {
+	standby := NewStandby()
+	primary, _ := NewORAM(WithCapacity(16, 16), WithReplicator(standby), WithStrictReads())
+	primary.Write(1, bytes.Repeat([]byte{1}, 16))
+	primary.Write(2, bytes.Repeat([]byte{2}, 16))
+	if err := primary.Delete(2); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	cfg := primary.cfg
+	cfg.Replicator = nil
+	promoted, err := standby.Promote(cfg, primary.storage, primary.encrypt)
+	if err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	if ok, _ := promoted.Exists(2); ok {
+		t.Error("deleted block 2 is mapped after promotion")
+	}
+	if got, err := promoted.Read(1); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{1}, 16)) {
+		t.Errorf("Read(1) = %x, %v after promotion", got, err)
+	}
+}
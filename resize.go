@@ -0,0 +1,178 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// ResizableStorage is an optional extension of Storage for backends that can
+// change their number of buckets. Growing appends empty buckets; shrinking
+// drops buckets from the end. PathORAM.Resize requires it whenever the tree
+// height changes.
+type ResizableStorage interface {
+	Storage
+
+	// Resize sets the number of buckets.
+	Resize(numBuckets int) error
+}
+
+// Resize changes the ORAM's capacity to newNumBlocks.
+// See ResizeCtx.
+func (o *PathORAM) Resize(newNumBlocks int) error {
+	return o.ResizeCtx(context.Background(), newNumBlocks)
+}
+
+// ResizeCtx changes the ORAM's capacity to newNumBlocks, adding or removing
+// tree levels as needed. Growing remaps each block from leaf L to a random
+// descendant of L in the new bottom level; shrinking evacuates the removed
+// levels into the stash and maps each block to the ancestor of its leaf.
+// Every stored block is re-encrypted under its new leaf.
+//
+// With Config.Height or NumLeaves set, the tree keeps its shape: Resize
+// changes only the capacity, and fails if newNumBlocks does not fit.
+//
+// Shrinking fails if any block ID >= newNumBlocks has been written. A shrink
+// can leave the stash above StashLimit; DrainStash brings it back down.
+// Resize is not atomic: if storage fails part-way, the ORAM is inconsistent
+// and must be rebuilt.
+func (o *PathORAM) ResizeCtx(ctx context.Context, newNumBlocks int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return ErrClosed
+	}
+
+	cfg := o.cfg
+	cfg.NumBlocks = newNumBlocks
+	cfg, err := cfg.Validate()
+	if err != nil {
+		return err
+	}
+	for id := newNumBlocks; id < o.cfg.NumBlocks; id++ {
+		if _, ok := o.posMap.Get(id); ok {
+			return fmt.Errorf("%w: cannot shrink below allocated block %d", ErrInvalidConfig, id)
+		}
+	}
+
+	height, numLeaves, totalBuckets := cfg.ComputeTreeParams()
+	delta := height - o.height
+	if delta == 0 {
+		o.cfg = cfg
+		return nil
+	}
+	rs, ok := o.storage.(ResizableStorage)
+	if !ok {
+		return fmt.Errorf("%w: storage does not support resizing", ErrInvalidConfig)
+	}
+
+	// Pending evictions refer to paths in the old tree
+	if err := o.takeBackgroundErr(); err != nil {
+		return err
+	}
+	for len(o.pending) > 0 {
+		if err := o.evictOnePending(); err != nil {
+			return err
+		}
+	}
+	if err := o.flushWriteBack(); err != nil {
+		return err
+	}
+
+	// The Merkle tree's shape changes with the bucket tree: verify the whole
+	// tree now, resize without per-bucket checks, then rebuild the hashes.
+	// The tree is reattached on every return, so a failed resize fails closed.
+	m := o.integrity
+	if m != nil {
+		root, err := m.build(false)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(root, m.root) {
+			return fmt.Errorf("%w: root hash mismatch", ErrIntegrityViolation)
+		}
+		o.integrity = nil
+		defer func() { o.integrity = m }()
+	}
+
+	remap := func(leaf int) int {
+		if delta > 0 {
+			return leaf<<delta | o.randomBits(delta)
+		}
+		return leaf >> -delta
+	}
+
+	for i := range o.stash {
+		o.stash[i].leaf = remap(o.stash[i].leaf)
+		o.setPosition(o.stash[i].id, o.stash[i].leaf)
+		o.stashChanged(o.stash[i].id)
+	}
+	if delta > 0 {
+		if err := rs.Resize(totalBuckets); err != nil {
+			return err
+		}
+		if o.versions != nil {
+			o.versions.resize(totalBuckets)
+		}
+	}
+	_, _, oldTotal := o.cfg.ComputeTreeParams()
+	for idx := 0; idx < oldTotal; idx++ {
+		bucket, err := o.readBucket(ctx, idx)
+		if err != nil {
+			return err
+		}
+		removed := idx >= totalBuckets
+		for i := range bucket {
+			if bucket[i].ID == EmptyBlockID {
+				continue
+			}
+			plaintext, err := o.encrypt.Decrypt(bucket[i].ID, bucket[i].Leaf, bucket[i].Data)
+			if err != nil {
+				o.noteDecryptionFailure()
+				return err
+			}
+			b := block{id: bucket[i].ID, leaf: remap(bucket[i].Leaf), data: plaintext}
+			o.setPosition(b.id, b.leaf)
+			if removed {
+				o.stash = append(o.stash, b)
+				continue
+			}
+			bucket[i] = o.blockToStorage(b)
+		}
+		if !removed {
+			if err := o.writeBucket(ctx, idx, bucket); err != nil {
+				return err
+			}
+		}
+	}
+	if delta < 0 {
+		if err := rs.Resize(totalBuckets); err != nil {
+			return err
+		}
+		o.forgetSlots(totalBuckets)
+		if o.versions != nil {
+			o.versions.resize(totalBuckets)
+		}
+	}
+
+	if m != nil {
+		m.numBuckets = totalBuckets
+		if m.root, err = m.build(true); err != nil {
+			return err
+		}
+	}
+
+	o.cfg = cfg
+	o.height = height
+	o.numLeaves = numLeaves
+	o.noteStash()
+	if err := o.syncStash(); err != nil {
+		return err
+	}
+	return o.replicate()
+}
+
+// randomBits returns a uniformly random integer in [0, 2^n).
+func (o *PathORAM) randomBits(n int) int {
+	return randIntn(o.rng, 1<<n)
+}
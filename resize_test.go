@@ -0,0 +1,100 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newResizeTestORAM(t *testing.T, numBlocks int) *PathORAM {
+	t.Helper()
+	enc, err := NewAESGCMEncryptor(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewAESGCMEncryptor failed: %v", err)
+	}
+	oram, err := NewORAM(WithCapacity(numBlocks, 16), WithBucketSize(4), WithStashLimit(500), WithEncryptor(enc))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	return oram
+}
+
+func checkBlocks(t *testing.T, oram *PathORAM, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		got, err := oram.Read(i)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Errorf("Read(%d) = %x", i, got)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name          string
+		from, to      int
+		written       int
+		wantHeightGap int
+	}{
+		{"grow one level", 32, 64, 32, 1},
+		{"grow three levels", 32, 256, 32, 3},
+		{"shrink two levels", 256, 64, 64, -2},
+		{"same height", 32, 31, 30, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oram := newResizeTestORAM(t, tt.from)
+			for i := 0; i < tt.written; i++ {
+				if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+					t.Fatalf("Write(%d) failed: %v", i, err)
+				}
+			}
+			height := oram.Height()
+
+			if err := oram.Resize(tt.to); err != nil {
+				t.Fatalf("Resize(%d) failed: %v", tt.to, err)
+			}
+			if oram.Capacity() != tt.to {
+				t.Errorf("Capacity() = %d, want %d", oram.Capacity(), tt.to)
+			}
+			if gap := oram.Height() - height; gap != tt.wantHeightGap {
+				t.Errorf("height changed by %d, want %d", gap, tt.wantHeightGap)
+			}
+			if _, err := oram.DrainStash(t.Context(), 0, 200, nil); err != nil {
+				t.Fatalf("DrainStash failed: %v", err)
+			}
+			checkBlocks(t, oram, tt.written)
+
+			// The whole new ID range is usable
+			last := tt.to - 1
+			if _, err := oram.Write(last, bytes.Repeat([]byte{byte(last)}, 16)); err != nil {
+				t.Fatalf("Write(%d) after resize failed: %v", last, err)
+			}
+		})
+	}
+}
+
+func TestResize_Errors(t *testing.T) {
+	oram := newResizeTestORAM(t, 64)
+	if _, err := oram.Write(40, make([]byte, 16)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := oram.Resize(16); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("shrink below allocated block: got %v, want ErrInvalidConfig", err)
+	}
+	if err := oram.Resize(0); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Resize(0): got %v, want ErrInvalidConfig", err)
+	}
+
+	// Storage without ResizableStorage
+	cfg, _ := Config{NumBlocks: 16, BlockSize: 16}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	fixed := struct{ Storage }{NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)}
+	o, _ := New(cfg, fixed, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err := o.Resize(1024); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Resize on fixed storage: got %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,110 @@
+package pathoram
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// minParallelSealSize is the smallest BlockSize for which CryptoParallelism
+// 0 encrypts in parallel; below it goroutine handoff costs more than AES.
+const minParallelSealSize = 1024
+
+// sealJob is a block placed in a bucket slot whose encryption is deferred
+// until the bucket is written (Config.CryptoParallelism).
+type sealJob struct {
+	dst *Block
+	b   block
+}
+
+// cryptoWorkers returns the number of goroutines that encrypt an eviction's
+// blocks: CryptoParallelism if set, otherwise GOMAXPROCS for the built-in
+// AES encryptors (safe for concurrent use) on large blocks. When Config.Rand
+// supplies the encryptor's nonces they come from one stream, so encryption
+// stays sequential to keep runs reproducible.
+func cryptoWorkers(cfg Config, enc Encryptor) int {
+	if _, ok := enc.(randSource); ok && cfg.Rand != nil {
+		return 1
+	}
+	if cfg.CryptoParallelism > 0 {
+		return cfg.CryptoParallelism
+	}
+	switch enc.(type) {
+	case *AESGCMEncryptor, *SegmentedEncryptor:
+		if cfg.BlockSize >= minParallelSealSize {
+			return runtime.GOMAXPROCS(0)
+		}
+	}
+	return 1
+}
+
+// seal stores b, encrypted, in *dst. With more than one crypto worker the
+// encryption is deferred to sealPending, which the next writeBucket or
+// writeBuckets call runs, so an eviction's blocks are encrypted together.
+// dst must stay valid until then.
+func (o *PathORAM) seal(dst *Block, b block) {
+	if o.sealWorkers <= 1 {
+		*dst = o.blockToStorage(b)
+		return
+	}
+	*dst = Block{ID: b.id, Leaf: b.leaf}
+	o.sealJobs = append(o.sealJobs, sealJob{dst: dst, b: b})
+}
+
+// sealPending encrypts the deferred blocks on up to sealWorkers goroutines.
+// Each job writes only its own slot and buffers are recorded in job order,
+// so the buckets written are the same as with sequential encryption.
+func (o *PathORAM) sealPending() {
+	jobs := o.sealJobs
+	if len(jobs) == 0 {
+		return
+	}
+	be, buffered := o.encrypt.(BufferedEncryptor)
+	buffered = buffered && o.cipherBufs != nil
+	if buffered {
+		for i := range jobs {
+			jobs[i].dst.Data = o.cipherBufs.get()[:0]
+		}
+	}
+
+	errs := make([]error, len(jobs))
+	var next atomic.Int64
+	run := func() {
+		for {
+			i := int(next.Add(1) - 1)
+			if i >= len(jobs) {
+				return
+			}
+			j := &jobs[i]
+			if buffered {
+				j.dst.Data, errs[i] = be.EncryptAppend(j.dst.Data, j.b.id, j.b.leaf, j.b.data)
+			} else {
+				j.dst.Data, errs[i] = o.encrypt.Encrypt(j.b.id, j.b.leaf, j.b.data)
+			}
+		}
+	}
+	var wg sync.WaitGroup
+	for range min(o.sealWorkers, len(jobs)) - 1 {
+		wg.Go(run)
+	}
+	run()
+	wg.Wait()
+
+	for i := range jobs {
+		if errs[i] != nil {
+			// Encryption should not fail with valid data
+			panic("encryption failed: " + errs[i].Error())
+		}
+		if buffered {
+			o.sealed = append(o.sealed, jobs[i].dst.Data)
+		}
+		if o.cfg.SecureDelete {
+			clear(jobs[i].b.data)
+		}
+		if o.plainBufs != nil {
+			o.plainBufs.put(jobs[i].b.data)
+		}
+	}
+	clear(jobs)
+	o.sealJobs = jobs[:0]
+}
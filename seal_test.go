@@ -0,0 +1,132 @@
+package pathoram
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowEncryptor is a NoOpEncryptor that records the peak number of
+// concurrent encryptions. Its first calls sleep so that concurrent ones
+// overlap even on one CPU.
+type slowEncryptor struct {
+	NoOpEncryptor
+	inFlight, peak, calls atomic.Int32
+}
+
+func (e *slowEncryptor) Encrypt(blockID, leaf int, plaintext []byte) ([]byte, error) {
+	return e.EncryptAppend(nil, blockID, leaf, plaintext)
+}
+
+func (e *slowEncryptor) EncryptAppend(dst []byte, blockID, leaf int, plaintext []byte) ([]byte, error) {
+	n := e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	for {
+		p := e.peak.Load()
+		if n <= p || e.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	if e.calls.Add(1) <= 50 {
+		time.Sleep(20 * time.Microsecond)
+	}
+	return e.NoOpEncryptor.EncryptAppend(dst, blockID, leaf, plaintext)
+}
+
+func TestCryptoParallelism_SameBuckets(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+	}{
+		{"level-by-level", Config{}},
+		{"greedy", Config{EvictionStrategy: EvictGreedyByDepth}},
+		{"two-path", Config{EvictionStrategy: EvictDeterministicTwoPath}},
+		{"constant-time", Config{ConstantTime: true}},
+		{"reuse-buffers", Config{EvictionStrategy: EvictGreedyByDepth, ReuseBuffers: true, SecureDelete: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var storages [2]*InMemoryStorage
+			var encs [2]*slowEncryptor
+			for i, workers := range []int{1, 4} {
+				cfg := tc.cfg
+				cfg.NumBlocks, cfg.BlockSize, cfg.StashLimit = 64, 16, 200
+				cfg.CryptoParallelism = workers
+				cfg.Rand = NewSeededRand([32]byte{3})
+				cfg, _ = cfg.Validate()
+				_, _, total := cfg.ComputeTreeParams()
+				storages[i] = NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)
+				encs[i] = &slowEncryptor{}
+				oram, err := New(cfg, storages[i], NewInMemoryPositionMap(), encs[i])
+				if err != nil {
+					t.Fatalf("New: %v", err)
+				}
+				for j := range 100 {
+					if _, err := oram.Write(j%64, bytes.Repeat([]byte{byte(j)}, 16)); err != nil {
+						t.Fatalf("Write: %v", err)
+					}
+				}
+				for j := range 64 {
+					got, err := oram.Read(j)
+					want := bytes.Repeat([]byte{byte(j)}, 16)
+					if j < 36 {
+						want = bytes.Repeat([]byte{byte(64 + j)}, 16)
+					}
+					if err != nil || !bytes.Equal(got, want) {
+						t.Fatalf("Read(%d) = %x, %v; want %x", j, got, err, want)
+					}
+				}
+			}
+			if !reflect.DeepEqual(storages[0].buckets, storages[1].buckets) {
+				t.Error("parallel encryption changed storage contents")
+			}
+			if p := encs[0].peak.Load(); p != 1 {
+				t.Errorf("CryptoParallelism 1: peak concurrency %d", p)
+			}
+			if p := encs[1].peak.Load(); p < 2 || p > 4 {
+				t.Errorf("CryptoParallelism 4: peak concurrency %d, want 2..4", p)
+			}
+		})
+	}
+}
+
+func TestCryptoWorkers(t *testing.T) {
+	aes, _ := NewAESGCMEncryptor(make([]byte, 32))
+	for _, tc := range []struct {
+		cfg  Config
+		enc  Encryptor
+		want int
+	}{
+		{Config{BlockSize: 4096}, NoOpEncryptor{}, 1},
+		{Config{BlockSize: 256}, aes, 1},
+		{Config{BlockSize: 256, CryptoParallelism: 3}, aes, 3},
+		{Config{BlockSize: 4096, Rand: NewSeededRand([32]byte{})}, aes, 1},
+		{Config{BlockSize: 4096, CryptoParallelism: 3, Rand: NewSeededRand([32]byte{})}, NoOpEncryptor{}, 3},
+	} {
+		if got := cryptoWorkers(tc.cfg, tc.enc); got != tc.want {
+			t.Errorf("cryptoWorkers(%+v, %T) = %d, want %d", tc.cfg, tc.enc, got, tc.want)
+		}
+	}
+	if _, err := (Config{NumBlocks: 4, BlockSize: 4, CryptoParallelism: -1}).Validate(); err == nil {
+		t.Error("negative CryptoParallelism accepted")
+	}
+}
+
+func BenchmarkAccess_CryptoParallelism(b *testing.B) {
+	key := bytes.Repeat([]byte{1}, 32)
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			enc, _ := NewAESGCMEncryptor(key)
+			cfg, _ := Config{NumBlocks: 4096, BlockSize: 4096, EvictionStrategy: EvictGreedyByDepth, CryptoParallelism: workers}.Validate()
+			_, _, total := cfg.ComputeTreeParams()
+			oram, _ := New(cfg, NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize+enc.Overhead()), NewInMemoryPositionMap(), enc)
+			data := make([]byte, 4096)
+			b.ResetTimer()
+			for i := range b.N {
+				oram.Write(i%4096, data)
+			}
+		})
+	}
+}
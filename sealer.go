@@ -0,0 +1,110 @@
+package pathoram
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+)
+
+// Sealer protects client state at rest, the stash above all, by binding it
+// to an identity. In a TEE deployment, implement it with the platform's
+// sealing primitive (SGX sealing to MRENCLAVE or MRSIGNER, an SEV-SNP
+// derived key) so only the same enclave can unseal what it sealed, and the
+// host that stores the bytes learns nothing. AESSealer is a software
+// reference implementation.
+//
+// The label names what is sealed; Unseal must reject data sealed under
+// another label, so a sealed stash cannot be passed off as other state.
+type Sealer interface {
+	Seal(label string, plaintext []byte) ([]byte, error)
+	Unseal(label string, sealed []byte) ([]byte, error)
+}
+
+// AESSealer is a Sealer using AES-256-GCM with a random nonce and the label
+// as additional data. It seals to whoever holds the key, not to an enclave:
+// use it outside a TEE, or inside one with a key obtained from the
+// platform's sealing API.
+type AESSealer struct {
+	aead cipher.AEAD
+	rand io.Reader // nonce source; nil means crypto/rand
+}
+
+var _ Sealer = (*AESSealer)(nil)
+
+// NewAESSealer creates an AESSealer with the given 32-byte key.
+func NewAESSealer(key []byte) (*AESSealer, error) {
+	if len(key) != aesKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", aesKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+	return &AESSealer{aead: aead}, nil
+}
+
+// Seal encrypts plaintext under label.
+// Output format: nonce (12 bytes) || ciphertext || tag (16 bytes)
+func (s *AESSealer) Seal(label string, plaintext []byte) ([]byte, error) {
+	out := make([]byte, aesNonceSize, aesNonceSize+len(plaintext)+aesTagSize)
+	if _, err := io.ReadFull(randReader(s.rand), out); err != nil {
+		return nil, ErrEncryptionFailed
+	}
+	return s.aead.Seal(out, out, plaintext, []byte(label)), nil
+}
+
+// Unseal decrypts data sealed under label. It returns ErrDecryptionFailed
+// if the data was sealed under another key or label, or modified.
+func (s *AESSealer) Unseal(label string, sealed []byte) ([]byte, error) {
+	if len(sealed) < aesNonceSize+aesTagSize {
+		return nil, ErrDecryptionFailed
+	}
+	plaintext, err := s.aead.Open(nil, sealed[:aesNonceSize], sealed[aesNonceSize:], []byte(label))
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// stashSealLabel is the label stash snapshots are sealed under.
+const stashSealLabel = "pathoram stash"
+
+// SealedStash is a Stash whose Serialize output is sealed, so a stash
+// snapshot written to disk or handed to the host is only readable by the
+// sealer's identity. Other calls go to the wrapped Stash. Restore a snapshot
+// of a wrapped SliceStash with UnsealSliceStash.
+type SealedStash struct {
+	Stash
+	sealer Sealer
+}
+
+// NewSealedStash wraps s so its snapshots are sealed with sealer.
+func NewSealedStash(s Stash, sealer Sealer) *SealedStash {
+	return &SealedStash{Stash: s, sealer: sealer}
+}
+
+// Serialize returns the wrapped Stash's serialization, sealed.
+func (s *SealedStash) Serialize() ([]byte, error) {
+	plain, err := s.Stash.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plain)
+	return s.sealer.Seal(stashSealLabel, plain)
+}
+
+// UnsealSliceStash restores a SliceStash from the Serialize output of a
+// SealedStash wrapping one.
+func UnsealSliceStash(sealed []byte, sealer Sealer) (*SliceStash, error) {
+	plain, err := sealer.Unseal(stashSealLabel, sealed)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plain)
+	return DeserializeSliceStash(plain)
+}
@@ -0,0 +1,85 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAESSealer(t *testing.T) {
+	if _, err := NewAESSealer(make([]byte, 16)); err == nil {
+		t.Error("expected error for 16-byte key")
+	}
+	s, err := NewAESSealer(bytes.Repeat([]byte{5}, 32))
+	if err != nil {
+		t.Fatalf("NewAESSealer failed: %v", err)
+	}
+	sealed, err := s.Seal("state", []byte("client secrets"))
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("client secrets")) {
+		t.Error("sealed data contains the plaintext")
+	}
+	if got, err := s.Unseal("state", sealed); err != nil || string(got) != "client secrets" {
+		t.Errorf("Unseal = %q, %v", got, err)
+	}
+	other, _ := NewAESSealer(bytes.Repeat([]byte{6}, 32))
+	for name, unseal := range map[string]func() ([]byte, error){
+		"other label": func() ([]byte, error) { return s.Unseal("stash", sealed) },
+		"other key":   func() ([]byte, error) { return other.Unseal("state", sealed) },
+		"truncated":   func() ([]byte, error) { return s.Unseal("state", sealed[:10]) },
+	} {
+		if _, err := unseal(); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("%s: error = %v, want ErrDecryptionFailed", name, err)
+		}
+	}
+}
+
+func TestSealedStash(t *testing.T) {
+	sealer, _ := NewAESSealer(bytes.Repeat([]byte{5}, 32))
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 2, StashLimit: 200, CachedLevels: 2, Rand: NewSeededRand([32]byte{1})}
+	cfg, _ = cfg.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)
+	pm := NewInMemoryPositionMap()
+	stash := NewSealedStash(NewSliceStash(), sealer)
+	cfg.Stash = stash
+	oram, err := New(cfg, storage, pm, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for id := range cfg.NumBlocks {
+		if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id) | 0x80}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", id, err)
+		}
+	}
+	if stash.Len() == 0 {
+		t.Fatal("stash is empty; test does not exercise a sealed snapshot")
+	}
+	snapshot, err := stash.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	if _, err := DeserializeSliceStash(snapshot); err == nil {
+		t.Error("sealed snapshot parses as a plain one")
+	}
+
+	restored, err := UnsealSliceStash(snapshot, sealer)
+	if err != nil {
+		t.Fatalf("UnsealSliceStash failed: %v", err)
+	}
+	if restored.Len() != stash.Len() {
+		t.Fatalf("restored %d entries, want %d", restored.Len(), stash.Len())
+	}
+	cfg.Stash = restored
+	oram2, err := New(cfg, storage, pm, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New after restart failed: %v", err)
+	}
+	for id := range cfg.NumBlocks {
+		if got, err := oram2.Read(id); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(id) | 0x80}, 16)) {
+			t.Fatalf("Read(%d) after restart = %x, %v", id, got, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package pathoram
+
+import (
+	"context"
+	"crypto/rand"
+	"slices"
+	"time"
+)
+
+// Wiper is implemented by encryptors that can discard their key material.
+// With Config.SecureDelete, Close calls Wipe; every later Encrypt or Decrypt
+// fails.
+//
+// The built-in encryptors keep no copy of the key, only the cipher built
+// from it, and Go's crypto packages offer no way to zero a cipher's
+// expanded key. Wipe therefore drops the cipher, and the expanded key stays
+// in memory until the garbage collector reuses it. Zero your own copy of the
+// key after constructing the encryptor; a process that must not leave key
+// material behind should exit after Close.
+type Wiper interface {
+	Wipe()
+}
+
+// Delete overwrites a block's data. See DeleteCtx.
+func (o *PathORAM) Delete(blockID int) error {
+	return o.DeleteCtx(context.Background(), blockID)
+}
+
+// DeleteCtx removes the block in one access that the server cannot tell
+// from a write, so its previous contents are gone from client memory,
+// including the block cache (Config.CacheBlocks), and, once the bucket
+// holding the old ciphertext is rewritten, from storage. With
+// Config.SecureDelete its stash copy is zeroed first.
+//
+// If the position map is a DeletablePositionMap, as every built-in map is,
+// the block is also unmapped: it reads as never written, so Exists reports
+// false, a strict read (Config.StrictReads) returns ErrBlockNotFound, it no
+// longer counts in PositionMap.Size and Resize may shrink past it.
+// Otherwise it is overwritten with random bytes (with SecureDelete) or
+// zeros, stays mapped, and reads as that data until it is written again.
+func (o *PathORAM) DeleteCtx(ctx context.Context, blockID int) error {
+	if blockID < 0 || blockID >= o.cfg.NumBlocks {
+		return ErrInvalidBlockID
+	}
+	if err := o.authorize(ctx, OpWrite, blockID); err != nil {
+		return err
+	}
+	data := make([]byte, o.cfg.plainSize())
+	if o.cfg.SecureDelete {
+		rand.Read(data)
+	}
+	defer o.padAccess(ctx, time.Now(), 1)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, o.unmapping = o.posMap.(DeletablePositionMap)
+	_, err := o.observe(ctx, blockID, data, discardPrevious)
+	o.unmapping = false
+	if o.cache != nil {
+		o.cache.remove(blockID)
+	}
+	return err
+}
+
+// unmap drops blockID from the stash, whose path access has read it there,
+// and from the position map. o.mu must be held.
+func (o *PathORAM) unmap(blockID int) {
+	for i, b := range o.stash {
+		if b.id == blockID {
+			if o.cfg.SecureDelete {
+				clear(b.data)
+			}
+			o.stash = slices.Delete(o.stash, i, i+1)
+			break
+		}
+	}
+	o.posMap.(DeletablePositionMap).Delete(blockID)
+	o.notePosition(blockID, -1)
+}
+
+// wipe zeroizes the stash and client-side buffers and wipes the encryptor's
+// key, for Close with Config.SecureDelete. o.mu must be held. The block
+// cache was already zeroized by closeCache.
+func (o *PathORAM) wipe() {
+	if !o.cfg.SecureDelete {
+		return
+	}
+	for i := range o.stash {
+		clear(o.stash[i].data)
+	}
+	o.stash = nil
+	if s, ok := o.stashStore.(*SliceStash); ok {
+		s.entries = nil
+	}
+	clear(o.scratch)
+	if w, ok := o.encrypt.(Wiper); ok {
+		w.Wipe()
+	}
+}
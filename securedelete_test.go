@@ -0,0 +1,152 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSecureDelete(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	enc, _ := NewAESGCMEncryptor(key)
+	oram, err := NewORAM(WithCapacity(32, 16), WithEncryptor(enc), WithSecureDelete())
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+
+	// Evicted blocks leave no plaintext behind
+	b := block{id: 1, leaf: 0, data: bytes.Repeat([]byte{7}, 16)}
+	oram.blockToStorage(b)
+	if !bytes.Equal(b.data, make([]byte, 16)) {
+		t.Fatalf("plaintext after eviction = %v, want zeros", b.data)
+	}
+
+	secret := bytes.Repeat([]byte{'s'}, 16)
+	oram.Write(3, secret)
+	if err := oram.Delete(3); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got, _ := oram.Read(3); !bytes.Equal(got, make([]byte, 16)) {
+		t.Fatalf("block after Delete = %v, want zeros (unmapped)", got)
+	}
+
+	oram.Write(4, secret)
+	if err := oram.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if oram.StashSize() != 0 {
+		t.Fatalf("stash = %d after Close, want wiped", oram.StashSize())
+	}
+	if _, err := enc.Encrypt(0, 0, secret); !errors.Is(err, ErrEncryptionFailed) {
+		t.Fatalf("Encrypt after Close err = %v, want key wiped", err)
+	}
+}
+
+func TestDelete_Zeros(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 8, BlockSize: 16})
+	oram.Write(2, bytes.Repeat([]byte{1}, 16))
+	if err := oram.Delete(2); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got, _ := oram.Read(2); !bytes.Equal(got, make([]byte, 16)) {
+		t.Fatalf("block after Delete = %v, want zeros", got)
+	}
+	if err := oram.Delete(8); !errors.Is(err, ErrInvalidBlockID) {
+		t.Fatalf("Delete(8) err = %v, want ErrInvalidBlockID", err)
+	}
+}
+
+func TestSecureDelete_Cache(t *testing.T) {
+	oram, err := NewInMemory(Config{NumBlocks: 16, BlockSize: 8, CacheBlocks: 2, CacheSkipDummy: true, SecureDelete: true})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	cached := func(id int) []byte {
+		return oram.cache.entries[id].Value.(*cacheEntry).data
+	}
+	for id := range 3 {
+		oram.Write(id, bytes.Repeat([]byte{'s'}, 8))
+	}
+	zero := make([]byte, 8)
+
+	// Writing block 3 evicts block 1, the least recently used
+	first := cached(1)
+	oram.Write(3, bytes.Repeat([]byte{'s'}, 8))
+	if !bytes.Equal(first, zero) {
+		t.Errorf("evicted cache entry = %q, want zeros", first)
+	}
+	deleted := cached(3)
+	if err := oram.Delete(3); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := oram.cache.entries[3]; ok || !bytes.Equal(deleted, zero) {
+		t.Errorf("deleted block still cached: %q", deleted)
+	}
+	last := cached(2)
+	if err := oram.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !bytes.Equal(last, zero) {
+		t.Errorf("cache entry after Close = %q, want zeros", last)
+	}
+}
+
+func TestDelete_Unmaps(t *testing.T) {
+	prf, _ := NewPRFPositionMap(bytes.Repeat([]byte{2}, 16))
+	recursive, _ := newTestRecursivePosMap(t, 32, 0)
+	for name, pm := range map[string]PositionMap{
+		"in-memory": NewInMemoryPositionMap(),
+		"oblivious": NewObliviousPositionMap(32, false),
+		"prf":       prf,
+		"recursive": recursive,
+	} {
+		t.Run(name, func(t *testing.T) {
+			oram, err := NewORAM(WithCapacity(32, 16), WithPositionMap(pm), WithStrictReads(), WithSecureDelete())
+			if err != nil {
+				t.Fatalf("NewORAM failed: %v", err)
+			}
+			want := bytes.Repeat([]byte{3}, 16)
+			oram.Write(3, want)
+			oram.Write(20, bytes.Repeat([]byte{20}, 16))
+
+			accesses := oram.Stats().Accesses
+			if err := oram.Delete(20); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if got := oram.Stats().Accesses - accesses; got != 1 {
+				t.Errorf("Delete made %d accesses, want 1", got)
+			}
+			if ok, err := oram.Exists(20); err != nil || ok {
+				t.Errorf("Exists(20) after Delete = %v, %v; want false", ok, err)
+			}
+			if _, err := oram.Read(20); !errors.Is(err, ErrBlockNotFound) {
+				t.Errorf("strict Read(20) after Delete: error = %v, want ErrBlockNotFound", err)
+			}
+			if got := pm.Size(); got != 1 {
+				t.Errorf("Size() after Delete = %d, want 1", got)
+			}
+			if r, err := oram.CheckInvariants(); err != nil || !r.OK() {
+				t.Fatalf("CheckInvariants = %v, %v", r.Err(), err)
+			}
+			if err := oram.Resize(16); err != nil {
+				t.Fatalf("Resize(16) past the deleted block: %v", err)
+			}
+			if got, err := oram.Read(3); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("Read(3) = %x, %v; want %x", got, err, want)
+			}
+		})
+	}
+}
+
+func TestDelete_NotDeletable(t *testing.T) {
+	// A position map without Delete keeps the block mapped
+	pm := struct{ PositionMap }{NewInMemoryPositionMap()}
+	oram, _ := NewORAM(WithCapacity(8, 16), WithPositionMap(pm), WithStrictReads())
+	oram.Write(2, bytes.Repeat([]byte{1}, 16))
+	if err := oram.Delete(2); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got, err := oram.Read(2); err != nil || !bytes.Equal(got, make([]byte, 16)) {
+		t.Fatalf("Read(2) after Delete = %v, %v; want zeros", got, err)
+	}
+}
@@ -0,0 +1,130 @@
+package pathoram
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// DefaultSegmentSize is the plaintext segment size used by
+// NewSegmentedEncryptor when segmentSize is 0.
+const DefaultSegmentSize = 64 << 10
+
+const (
+	segmentPrefixSize = 7  // random per-block nonce prefix
+	segmentTagSize    = 16 // GCM tag per segment
+)
+
+// SegmentedEncryptor encrypts large blocks as a sequence of independently
+// authenticated AES-256-GCM segments (the STREAM construction): each segment's
+// nonce is a per-block random prefix, the segment index, and a final-segment
+// flag, so segments cannot be reordered, dropped, or truncated.
+//
+// Ciphertext layout: prefix (7 bytes) || frame 0 || frame 1 || ..., where every
+// frame except the last is exactly FrameSize bytes. Backends with object-size
+// limits can split Block.Data at frame boundaries.
+type SegmentedEncryptor struct {
+	aead        cipher.AEAD
+	fp          []byte // KeyFingerprint of the key
+	blockSize   int
+	segmentSize int
+	rand        io.Reader // nonce prefix source; nil means crypto/rand
+}
+
+// NewSegmentedEncryptor creates a segmented encryptor for blocks of blockSize
+// bytes with a 32-byte key. A segmentSize of 0 selects DefaultSegmentSize.
+func NewSegmentedEncryptor(key []byte, blockSize, segmentSize int) (*SegmentedEncryptor, error) {
+	if len(key) != aesKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", aesKeySize, len(key))
+	}
+	if segmentSize == 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	if blockSize <= 0 || segmentSize <= 0 {
+		return nil, ErrInvalidConfig
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+	return &SegmentedEncryptor{aead: aead, fp: KeyFingerprint(key), blockSize: blockSize, segmentSize: segmentSize}, nil
+}
+
+// FrameSize returns the size of each full ciphertext frame.
+func (e *SegmentedEncryptor) FrameSize() int {
+	return e.segmentSize + segmentTagSize
+}
+
+// numSegments returns how many segments a plaintext of n bytes uses.
+func (e *SegmentedEncryptor) numSegments(n int) int {
+	return max(1, (n+e.segmentSize-1)/e.segmentSize)
+}
+
+func (e *SegmentedEncryptor) nonce(prefix []byte, i int, last bool) []byte {
+	nonce := make([]byte, aesNonceSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[segmentPrefixSize:], uint32(i))
+	if last {
+		nonce[aesNonceSize-1] = 1
+	}
+	return nonce
+}
+
+// Encrypt encrypts plaintext segment by segment into a single buffer.
+func (e *SegmentedEncryptor) Encrypt(blockID, leaf int, plaintext []byte) ([]byte, error) {
+	if e.aead == nil {
+		return nil, ErrEncryptionFailed // wiped
+	}
+	n := e.numSegments(len(plaintext))
+	out := make([]byte, segmentPrefixSize, segmentPrefixSize+len(plaintext)+n*segmentTagSize)
+	if _, err := io.ReadFull(randReader(e.rand), out); err != nil {
+		return nil, ErrEncryptionFailed
+	}
+	aad := makeAAD(blockID, leaf)
+	for i := 0; i < n; i++ {
+		seg := plaintext[min(i*e.segmentSize, len(plaintext)):min((i+1)*e.segmentSize, len(plaintext))]
+		out = e.aead.Seal(out, e.nonce(out[:segmentPrefixSize], i, i == n-1), seg, aad)
+	}
+	return out, nil
+}
+
+// Decrypt verifies and decrypts every segment.
+func (e *SegmentedEncryptor) Decrypt(blockID, leaf int, ciphertext []byte) ([]byte, error) {
+	if e.aead == nil || len(ciphertext) < segmentPrefixSize+segmentTagSize {
+		return nil, ErrDecryptionFailed
+	}
+	prefix, body := ciphertext[:segmentPrefixSize], ciphertext[segmentPrefixSize:]
+	frame := e.FrameSize()
+	n := (len(body) + frame - 1) / frame
+	aad := makeAAD(blockID, leaf)
+	out := make([]byte, 0, len(body)-n*segmentTagSize)
+	for i := 0; i < n; i++ {
+		var err error
+		out, err = e.aead.Open(out, e.nonce(prefix, i, i == n-1), body[i*frame:min((i+1)*frame, len(body))], aad)
+		if err != nil {
+			return nil, ErrDecryptionFailed
+		}
+	}
+	return out, nil
+}
+
+// Overhead returns the prefix plus one tag per segment of a blockSize block.
+func (e *SegmentedEncryptor) Overhead() int {
+	return segmentPrefixSize + e.numSegments(e.blockSize)*segmentTagSize
+}
+
+// KeyFingerprint returns the fingerprint of the key.
+func (e *SegmentedEncryptor) KeyFingerprint() []byte { return bytes.Clone(e.fp) }
+
+func (e *SegmentedEncryptor) setRand(r io.Reader) { e.rand = r }
+
+// Wipe drops the cipher and makes every later call fail. The expanded key
+// is not zeroed (see Wiper).
+func (e *SegmentedEncryptor) Wipe() { e.aead = nil }
@@ -0,0 +1,123 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSegmentedEncryptor_RoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{9}, 32)
+	tests := []struct {
+		name                   string
+		blockSize, segmentSize int
+	}{
+		{"single segment", 100, 128},
+		{"exact multiple", 256, 64},
+		{"partial last segment", 300, 64},
+		{"empty", 1, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := NewSegmentedEncryptor(key, tt.blockSize, tt.segmentSize)
+			if err != nil {
+				t.Fatalf("NewSegmentedEncryptor failed: %v", err)
+			}
+			plaintext := bytes.Repeat([]byte{0xAB}, tt.blockSize)
+			ct, err := enc.Encrypt(3, 5, plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			if len(ct) != tt.blockSize+enc.Overhead() {
+				t.Errorf("ciphertext length %d, want %d", len(ct), tt.blockSize+enc.Overhead())
+			}
+			got, err := enc.Decrypt(3, 5, ct)
+			if err != nil {
+				t.Fatalf("Decrypt failed: %v", err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Error("round trip mismatch")
+			}
+		})
+	}
+}
+
+func TestSegmentedEncryptor_Tampering(t *testing.T) {
+	enc, _ := NewSegmentedEncryptor(bytes.Repeat([]byte{9}, 32), 256, 64)
+	ct, _ := enc.Encrypt(1, 2, bytes.Repeat([]byte{1}, 256))
+	frame := enc.FrameSize()
+
+	flipped := bytes.Clone(ct)
+	flipped[len(flipped)/2] ^= 1
+	swapped := bytes.Clone(ct)
+	copy(swapped[segmentPrefixSize:], ct[segmentPrefixSize+frame:segmentPrefixSize+2*frame])
+	copy(swapped[segmentPrefixSize+frame:], ct[segmentPrefixSize:segmentPrefixSize+frame])
+
+	tests := []struct {
+		name     string
+		ct       []byte
+		id, leaf int
+	}{
+		{"bit flip", flipped, 1, 2},
+		{"reordered segments", swapped, 1, 2},
+		{"truncated at frame boundary", ct[:len(ct)-frame], 1, 2},
+		{"wrong leaf", ct, 1, 3},
+	}
+	for _, tt := range tests {
+		if _, err := enc.Decrypt(tt.id, tt.leaf, tt.ct); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("%s: got %v, want ErrDecryptionFailed", tt.name, err)
+		}
+	}
+}
+
+func TestLargeBlocks(t *testing.T) {
+	const blockSize = 1 << 20
+	enc, err := NewSegmentedEncryptor(bytes.Repeat([]byte{9}, 32), blockSize, 0)
+	if err != nil {
+		t.Fatalf("NewSegmentedEncryptor failed: %v", err)
+	}
+	oram, err := NewORAM(WithCapacity(4, blockSize), WithBucketSize(2), WithEncryptor(enc))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, blockSize)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		got, err := oram.Read(i)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", i, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, blockSize)) {
+			t.Errorf("Read(%d) mismatch", i)
+		}
+	}
+}
+
+// limitedStorage reports a maximum block size.
+type limitedStorage struct {
+	*InMemoryStorage
+	max int
+}
+
+func (s limitedStorage) Capabilities() StorageCapabilities {
+	return StorageCapabilities{MaxBlockSize: s.max}
+}
+
+func TestCapabilities(t *testing.T) {
+	if c := Capabilities(NewInMemoryStorage(1, 1, 1)); c.MaxBlockSize != 0 {
+		t.Errorf("InMemoryStorage MaxBlockSize = %d, want 0", c.MaxBlockSize)
+	}
+
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{1}, 32))
+	storage := limitedStorage{NewInMemoryStorage(7, 5, 100+enc.Overhead()), 100}
+	_, err := New(Config{NumBlocks: 16, BlockSize: 100}, storage, NewInMemoryPositionMap(), enc)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("New over storage limit: got %v, want ErrInvalidConfig", err)
+	}
+	if _, err := (Config{NumBlocks: 1, BlockSize: MaxBlockSize + 1}).Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("BlockSize above MaxBlockSize: got %v, want ErrInvalidConfig", err)
+	}
+}
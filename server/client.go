@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// Client talks to a Proxy on behalf of one namespace.
+type Client struct {
+	base  string
+	token string
+	hc    *http.Client
+}
+
+// NewClient creates a client for the proxy at baseURL (e.g.
+// "https://oram.internal:8443") using token. hc may be nil for
+// http.DefaultClient.
+func NewClient(baseURL, token string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{base: strings.TrimSuffix(baseURL, "/"), token: token, hc: hc}
+}
+
+// Info returns the namespace's size and the block size.
+func (c *Client) Info(ctx context.Context) (Info, error) {
+	var info Info
+	body, err := c.do(ctx, http.MethodGet, "/v1/info", nil)
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(body, &info)
+	return info, err
+}
+
+// Read returns block id of the namespace.
+func (c *Client) Read(ctx context.Context, id int) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, "/v1/blocks/"+strconv.Itoa(id), nil)
+}
+
+// Write replaces block id of the namespace and returns its previous value.
+func (c *Client) Write(ctx context.Context, id int, data []byte) ([]byte, error) {
+	if data == nil {
+		return nil, pathoram.ErrInvalidDataSize
+	}
+	return c.do(ctx, http.MethodPut, "/v1/blocks/"+strconv.Itoa(id), data)
+}
+
+// Batch performs ops in order, back to back on the proxy, and returns one
+// Result per op. A failed op does not stop the others.
+func (c *Client) Batch(ctx context.Context, ops []Op) ([]Result, error) {
+	req, err := json.Marshal(BatchRequest{Ops: ops})
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.do(ctx, http.MethodPost, "/v1/batch", req)
+	if err != nil {
+		return nil, err
+	}
+	var resp BatchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) != len(ops) {
+		return nil, fmt.Errorf("server: got %d results for %d ops", len(resp.Results), len(ops))
+	}
+	return resp.Results, nil
+}
+
+// Err returns the error r reports, or nil. Errors the ORAM defines are
+// returned as their sentinel values.
+func (r Result) Err() error {
+	if r.Error == "" {
+		return nil
+	}
+	return remoteError(r.Error)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, rd)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			return nil, fmt.Errorf("server: %s", resp.Status)
+		}
+		return nil, remoteError(e.Error)
+	}
+	return data, nil
+}
+
+// remoteErrors are the sentinels a proxy's error messages are mapped back to.
+var remoteErrors = []error{
+	pathoram.ErrInvalidBlockID,
+	pathoram.ErrInvalidDataSize,
+	pathoram.ErrAccessDenied,
+	pathoram.ErrStashOverflow,
+	pathoram.ErrDecryptionFailed,
+	ErrClosed,
+}
+
+// remoteError converts a proxy error message to an error, returning the
+// matching sentinel if there is one.
+func remoteError(msg string) error {
+	for _, err := range remoteErrors {
+		if msg == err.Error() {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
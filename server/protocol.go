@@ -0,0 +1,56 @@
+// Package server implements a trusted ORAM proxy. One Proxy owns a PathORAM
+// and serves many clients over HTTP; it queues their requests and performs
+// them one access at a time, so the storage server behind the ORAM sees a
+// single client's access pattern however many applications share it.
+//
+// Each client authenticates with a bearer token that selects its namespace:
+// a contiguous range of the ORAM's block IDs. Clients address blocks from 0
+// within their namespace and cannot reach other namespaces' blocks.
+//
+// Endpoints:
+//
+//	GET  /v1/info         namespace size and block size (Info)
+//	GET  /v1/blocks/{id}  block contents
+//	PUT  /v1/blocks/{id}  replace block contents; returns the previous value
+//	POST /v1/batch        several reads and writes (BatchRequest)
+//
+// Block bodies are raw bytes (application/octet-stream); the others are
+// JSON.
+package server
+
+// Info is the response to GET /v1/info.
+type Info struct {
+	Namespace string `json:"namespace"`
+	Blocks    int    `json:"blocks"`     // valid IDs are 0 to Blocks-1
+	BlockSize int    `json:"block_size"` // bytes per block
+}
+
+// Op is one access in a batch. A nil Data reads the block; otherwise Data
+// replaces it.
+type Op struct {
+	ID   int    `json:"id"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// BatchRequest is the body of POST /v1/batch.
+type BatchRequest struct {
+	Ops []Op `json:"ops"`
+}
+
+// Result is the outcome of one Op: the block's previous contents, or the
+// error that failed it.
+type Result struct {
+	Data  []byte `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BatchResponse is the response to POST /v1/batch, one Result per Op in
+// order.
+type BatchResponse struct {
+	Results []Result `json:"results"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,435 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// defaultMaxBatch is the number of accesses performed per lock acquisition
+// when Config.MaxBatch is 0.
+const defaultMaxBatch = 64
+
+// maxBatchOps bounds the number of ops in one POST /v1/batch.
+const maxBatchOps = 1024
+
+// ErrClosed is returned for requests made after Close.
+var ErrClosed = errors.New("server: proxy closed")
+
+// Namespace assigns a client the block IDs First to First+Blocks-1.
+type Namespace struct {
+	Name   string // attached to its accesses with pathoram.WithPrincipal
+	Token  string // bearer token the client presents
+	First  int    // first block ID
+	Blocks int    // number of block IDs
+}
+
+// Config configures a Proxy.
+type Config struct {
+	Namespaces []Namespace // must not overlap
+
+	// MaxBatch is the most accesses performed per acquisition of the
+	// proxy's lock (default 64). Accesses are always performed one at a
+	// time; batching only lets queued requests run back to back.
+	MaxBatch int
+
+	// Coalesce merges requests for the same block within a batch into one
+	// ORAM access, which writes the last of their data, if any. Each
+	// request gets the result it would have had in arrival order, and the
+	// proxy then performs one dummy access per merged request, so the
+	// storage server still sees one access per request. The merged access
+	// runs with the first request's context.
+	Coalesce bool
+}
+
+// Proxy serves a PathORAM to many clients. It implements http.Handler.
+type Proxy struct {
+	oram     *pathoram.PathORAM
+	mu       sync.Locker
+	byToken  map[string]*Namespace
+	maxBatch int
+	coalesce bool
+	mux      *http.ServeMux
+
+	// First failed dummy access owed for coalesced requests, returned by
+	// the next coalesced batch; guarded by mu
+	dummyErr error
+
+	jobs chan []*request
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// request is one access waiting for the sequencer.
+type request struct {
+	ctx     context.Context
+	blockID int
+	newData []byte
+	data    []byte
+	err     error
+	done    chan struct{}
+}
+
+// New creates a proxy for oram and starts its sequencer. mu is held around
+// each batch of accesses; if nil the proxy uses its own mutex, which is only
+// correct if nothing else accesses oram. Call Close to stop the sequencer.
+func New(oram *pathoram.PathORAM, mu sync.Locker, cfg Config) (*Proxy, error) {
+	if mu == nil {
+		mu = &sync.Mutex{}
+	}
+	if cfg.MaxBatch < 0 {
+		return nil, fmt.Errorf("%w: negative MaxBatch", pathoram.ErrInvalidConfig)
+	}
+	if cfg.MaxBatch == 0 {
+		cfg.MaxBatch = defaultMaxBatch
+	}
+	if err := checkNamespaces(cfg.Namespaces, oram.Capacity()); err != nil {
+		return nil, err
+	}
+
+	p := &Proxy{
+		oram:     oram,
+		mu:       mu,
+		byToken:  make(map[string]*Namespace, len(cfg.Namespaces)),
+		maxBatch: cfg.MaxBatch,
+		coalesce: cfg.Coalesce,
+		mux:      http.NewServeMux(),
+		jobs:     make(chan []*request),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for i := range cfg.Namespaces {
+		ns := cfg.Namespaces[i]
+		p.byToken[ns.Token] = &ns
+	}
+	p.mux.HandleFunc("GET /v1/info", p.handleInfo)
+	p.mux.HandleFunc("GET /v1/blocks/{id}", p.handleRead)
+	p.mux.HandleFunc("PUT /v1/blocks/{id}", p.handleWrite)
+	p.mux.HandleFunc("POST /v1/batch", p.handleBatch)
+	go p.run()
+	return p, nil
+}
+
+// checkNamespaces rejects empty, duplicate, out-of-range and overlapping
+// namespaces.
+func checkNamespaces(nss []Namespace, capacity int) error {
+	if len(nss) == 0 {
+		return fmt.Errorf("%w: no namespaces", pathoram.ErrInvalidConfig)
+	}
+	names := make(map[string]bool, len(nss))
+	tokens := make(map[string]bool, len(nss))
+	for _, ns := range nss {
+		switch {
+		case ns.Name == "" || ns.Token == "":
+			return fmt.Errorf("%w: namespace needs a name and a token", pathoram.ErrInvalidConfig)
+		case names[ns.Name] || tokens[ns.Token]:
+			return fmt.Errorf("%w: duplicate namespace name or token (%q)", pathoram.ErrInvalidConfig, ns.Name)
+		case ns.First < 0 || ns.Blocks <= 0 || ns.First+ns.Blocks > capacity:
+			return fmt.Errorf("%w: namespace %q range outside the ORAM", pathoram.ErrInvalidConfig, ns.Name)
+		}
+		names[ns.Name], tokens[ns.Token] = true, true
+	}
+	sorted := slices.SortedFunc(slices.Values(nss), func(a, b Namespace) int { return a.First - b.First })
+	for i := 1; i < len(sorted); i++ {
+		if prev := sorted[i-1]; prev.First+prev.Blocks > sorted[i].First {
+			return fmt.Errorf("%w: namespaces %q and %q overlap", pathoram.ErrInvalidConfig, prev.Name, sorted[i].Name)
+		}
+	}
+	return nil
+}
+
+// run is the sequencer: it collects queued jobs up to maxBatch accesses and
+// performs them in arrival order under one acquisition of mu.
+func (p *Proxy) run() {
+	defer close(p.done)
+	var batch []*request
+	for {
+		select {
+		case <-p.stop:
+			return
+		case job := <-p.jobs:
+			batch = append(batch[:0], job...)
+		}
+	collect:
+		for len(batch) < p.maxBatch {
+			select {
+			case job := <-p.jobs:
+				batch = append(batch, job...)
+			default:
+				break collect
+			}
+		}
+
+		p.mu.Lock()
+		if p.coalesce {
+			p.runCoalesced(batch)
+		} else {
+			for _, r := range batch {
+				if r.err = r.ctx.Err(); r.err == nil {
+					r.data, r.err = p.oram.AccessCtx(r.ctx, r.blockID, r.newData)
+				}
+				close(r.done)
+			}
+		}
+		p.mu.Unlock()
+		clear(batch)
+	}
+}
+
+// runCoalesced performs batch with Config.Coalesce: one access per block,
+// in order of each block's first request. Every request completes as soon
+// as its block's access does; the dummy accesses owed for merged requests
+// run after the batch's real accesses.
+func (p *Proxy) runCoalesced(batch []*request) {
+	groups := make(map[int][]*request)
+	var order []int
+	for _, r := range batch {
+		if r.err = r.ctx.Err(); r.err == nil && r.newData != nil && len(r.newData) != p.oram.BlockSize() {
+			r.err = pathoram.ErrInvalidDataSize
+		}
+		if r.err != nil {
+			close(r.done)
+			continue
+		}
+		if _, ok := groups[r.blockID]; !ok {
+			order = append(order, r.blockID)
+		}
+		groups[r.blockID] = append(groups[r.blockID], r)
+	}
+
+	var owed []context.Context // one per merged request
+	for _, id := range order {
+		g := groups[id]
+		var last []byte
+		for _, r := range g {
+			if r.newData != nil {
+				last = r.newData
+			}
+		}
+		cur, err := p.oram.AccessCtx(g[0].ctx, id, last)
+		if err == nil && len(g) > 1 {
+			err, p.dummyErr = p.dummyErr, nil
+		}
+		// Replay the group in arrival order: a read sees the latest write,
+		// a write returns the value it replaced
+		for _, r := range g {
+			if r.err = err; err == nil {
+				r.data = bytes.Clone(cur)
+				if r.newData != nil {
+					cur = r.newData
+				}
+			}
+			close(r.done)
+		}
+		for _, r := range g[1:] {
+			owed = append(owed, context.WithoutCancel(r.ctx))
+		}
+	}
+	for _, ctx := range owed {
+		if err := p.oram.DummyAccess(ctx); err != nil && p.dummyErr == nil {
+			p.dummyErr = err
+		}
+	}
+}
+
+// submit queues job and waits for all its requests to complete.
+func (p *Proxy) submit(ctx context.Context, job []*request) error {
+	select {
+	case p.jobs <- job:
+	case <-p.stop:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// The sequencer has taken job and completes every request in it
+	for _, r := range job {
+		<-r.done
+	}
+	return nil
+}
+
+// access queues one access to blockID and waits for it.
+func (p *Proxy) access(ctx context.Context, blockID int, newData []byte) ([]byte, error) {
+	r := &request{ctx: ctx, blockID: blockID, newData: newData, done: make(chan struct{})}
+	if err := p.submit(ctx, []*request{r}); err != nil {
+		return nil, err
+	}
+	return r.data, r.err
+}
+
+// Close stops the sequencer. Requests not yet taken fail with ErrClosed. It
+// does not close the ORAM.
+func (p *Proxy) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+// namespace returns the caller's namespace, or writes 401 and returns nil.
+func (p *Proxy) namespace(w http.ResponseWriter, r *http.Request) *Namespace {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ns := p.byToken[token]; ok && ns != nil {
+		return ns
+	}
+	writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or unknown bearer token"})
+	return nil
+}
+
+// blockID maps a namespace-relative ID to an ORAM block ID.
+func blockID(ns *Namespace, id int) (int, error) {
+	if id < 0 || id >= ns.Blocks {
+		return 0, pathoram.ErrInvalidBlockID
+	}
+	return ns.First + id, nil
+}
+
+// pathBlockID parses the {id} path element.
+func pathBlockID(ns *Namespace, r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, pathoram.ErrInvalidBlockID
+	}
+	return blockID(ns, id)
+}
+
+func (p *Proxy) handleInfo(w http.ResponseWriter, r *http.Request) {
+	ns := p.namespace(w, r)
+	if ns == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, Info{Namespace: ns.Name, Blocks: ns.Blocks, BlockSize: p.oram.BlockSize()})
+}
+
+func (p *Proxy) handleRead(w http.ResponseWriter, r *http.Request) {
+	ns := p.namespace(w, r)
+	if ns == nil {
+		return
+	}
+	id, err := pathBlockID(ns, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := p.access(pathoram.WithPrincipal(r.Context(), ns.Name), id, nil)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeBlock(w, data)
+}
+
+func (p *Proxy) handleWrite(w http.ResponseWriter, r *http.Request) {
+	ns := p.namespace(w, r)
+	if ns == nil {
+		return
+	}
+	id, err := pathBlockID(ns, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(p.oram.BlockSize())))
+	if err != nil {
+		writeError(w, pathoram.ErrInvalidDataSize)
+		return
+	}
+	old, err := p.access(pathoram.WithPrincipal(r.Context(), ns.Name), id, data)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeBlock(w, old)
+}
+
+func (p *Proxy) handleBatch(w http.ResponseWriter, r *http.Request) {
+	ns := p.namespace(w, r)
+	if ns == nil {
+		return
+	}
+	// Base64 makes each block about 4/3 its size in JSON
+	limit := int64(maxBatchOps) * int64(p.oram.BlockSize()/3*4+64)
+	var req BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "malformed batch: " + err.Error()})
+		return
+	}
+	if len(req.Ops) > maxBatchOps {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("batch exceeds %d ops", maxBatchOps)})
+		return
+	}
+
+	ctx := pathoram.WithPrincipal(r.Context(), ns.Name)
+	resp := BatchResponse{Results: make([]Result, len(req.Ops))}
+	job := make([]*request, 0, len(req.Ops))
+	slot := make([]int, 0, len(req.Ops)) // result index of each job request
+	for i, op := range req.Ops {
+		id, err := blockID(ns, op.ID)
+		if err != nil {
+			resp.Results[i].Error = err.Error()
+			continue
+		}
+		job = append(job, &request{ctx: ctx, blockID: id, newData: op.Data, done: make(chan struct{})})
+		slot = append(slot, i)
+	}
+	if len(job) > 0 {
+		if err := p.submit(ctx, job); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	for j, rq := range job {
+		res := &resp.Results[slot[j]]
+		if rq.err != nil {
+			res.Error = rq.err.Error()
+		} else {
+			res.Data = rq.data
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeBlock(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError reports err with a status code matching its cause.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, pathoram.ErrInvalidBlockID):
+		status = http.StatusNotFound
+	case errors.Is(err, pathoram.ErrInvalidDataSize):
+		status = http.StatusBadRequest
+	case errors.Is(err, pathoram.ErrAccessDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrClosed):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusRequestTimeout
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
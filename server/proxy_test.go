@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func startProxy(t *testing.T, nss ...Namespace) (*pathoram.PathORAM, *httptest.Server) {
+	t.Helper()
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	p, err := New(oram, nil, Config{Namespaces: nss})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	srv := httptest.NewServer(p)
+	t.Cleanup(func() {
+		srv.Close()
+		p.Close()
+	})
+	return oram, srv
+}
+
+func TestProxyNamespaces(t *testing.T) {
+	oram, srv := startProxy(t,
+		Namespace{Name: "alice", Token: "a-token", First: 0, Blocks: 32},
+		Namespace{Name: "bob", Token: "b-token", First: 32, Blocks: 16},
+	)
+	ctx := context.Background()
+	alice := NewClient(srv.URL, "a-token", srv.Client())
+	bob := NewClient(srv.URL, "b-token", srv.Client())
+
+	info, err := bob.Info(ctx)
+	if err != nil || info != (Info{Namespace: "bob", Blocks: 16, BlockSize: 16}) {
+		t.Fatalf("Info = %+v, %v", info, err)
+	}
+
+	a := bytes.Repeat([]byte{'a'}, 16)
+	b := bytes.Repeat([]byte{'b'}, 16)
+	if _, err := alice.Write(ctx, 3, a); err != nil {
+		t.Fatalf("alice Write failed: %v", err)
+	}
+	if _, err := bob.Write(ctx, 3, b); err != nil {
+		t.Fatalf("bob Write failed: %v", err)
+	}
+	if got, err := alice.Read(ctx, 3); err != nil || !bytes.Equal(got, a) {
+		t.Fatalf("alice Read = %q, %v", got, err)
+	}
+	// bob's block 3 is ORAM block 35
+	if got, _ := oram.Read(35); !bytes.Equal(got, b) {
+		t.Fatalf("ORAM block 35 = %q, want bob's data", got)
+	}
+
+	if _, err := bob.Read(ctx, 16); !errors.Is(err, pathoram.ErrInvalidBlockID) {
+		t.Fatalf("bob Read(16) err = %v, want ErrInvalidBlockID", err)
+	}
+	if _, err := bob.Write(ctx, 0, []byte("short")); !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Fatalf("short Write err = %v, want ErrInvalidDataSize", err)
+	}
+	if _, err := NewClient(srv.URL, "nope", srv.Client()).Read(ctx, 0); err == nil {
+		t.Fatal("unknown token accepted")
+	}
+}
+
+func TestProxyBatch(t *testing.T) {
+	_, srv := startProxy(t, Namespace{Name: "c", Token: "t", First: 8, Blocks: 8})
+	ctx := context.Background()
+	c := NewClient(srv.URL, "t", srv.Client())
+
+	v1 := bytes.Repeat([]byte{1}, 16)
+	v2 := bytes.Repeat([]byte{2}, 16)
+	res, err := c.Batch(ctx, []Op{
+		{ID: 0, Data: v1},
+		{ID: 0, Data: v2},
+		{ID: 0},
+		{ID: 99},
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	if !bytes.Equal(res[1].Data, v1) || !bytes.Equal(res[2].Data, v2) {
+		t.Fatalf("batch ops not applied in order: %+v", res)
+	}
+	if !errors.Is(res[3].Err(), pathoram.ErrInvalidBlockID) {
+		t.Fatalf("out-of-range op err = %v", res[3].Err())
+	}
+}
+
+func TestProxyConcurrentClients(t *testing.T) {
+	oram, srv := startProxy(t,
+		Namespace{Name: "x", Token: "x", First: 0, Blocks: 32},
+		Namespace{Name: "y", Token: "y", First: 32, Blocks: 32},
+	)
+	before := oram.Stats().Accesses
+
+	var wg sync.WaitGroup
+	for _, tok := range []string{"x", "y"} {
+		c := NewClient(srv.URL, tok, srv.Client())
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 8; i++ {
+					id := w*8 + i
+					data := bytes.Repeat([]byte{byte(id)}, 16)
+					if _, err := c.Write(context.Background(), id, data); err != nil {
+						t.Errorf("Write failed: %v", err)
+						return
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if got := oram.Stats().Accesses - before; got != 64 {
+		t.Fatalf("accesses = %d, want 64", got)
+	}
+	for id := 0; id < 64; id++ {
+		got, _ := oram.Read(id)
+		if want := bytes.Repeat([]byte{byte(id % 32)}, 16); !bytes.Equal(got, want) {
+			t.Fatalf("block %d = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestProxyConfigErrors(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 16, BlockSize: 16})
+	for name, nss := range map[string][]Namespace{
+		"none":      nil,
+		"overlap":   {{Name: "a", Token: "a", First: 0, Blocks: 8}, {Name: "b", Token: "b", First: 4, Blocks: 8}},
+		"too large": {{Name: "a", Token: "a", First: 8, Blocks: 9}},
+		"dup token": {{Name: "a", Token: "t", First: 0, Blocks: 4}, {Name: "b", Token: "t", First: 4, Blocks: 4}},
+		"no token":  {{Name: "a", First: 0, Blocks: 4}},
+	} {
+		if _, err := New(oram, nil, Config{Namespaces: nss}); !errors.Is(err, pathoram.ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", name, err)
+		}
+	}
+}
+
+func TestProxyClosed(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 16, BlockSize: 16})
+	p, _ := New(oram, nil, Config{Namespaces: []Namespace{{Name: "a", Token: "a", First: 0, Blocks: 16}}})
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	p.Close()
+
+	if _, err := NewClient(srv.URL, "a", srv.Client()).Read(context.Background(), 0); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Read after Close err = %v, want ErrClosed", err)
+	}
+}
+
+func TestProxyCoalesce(t *testing.T) {
+	stats := pathoram.NewNamespaceStats(nil)
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, NamespaceStats: stats})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	p, err := New(oram, nil, Config{Namespaces: []Namespace{{Name: "c", Token: "t", First: 0, Blocks: 64}}, Coalesce: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	defer p.Close()
+	c := NewClient(srv.URL, "t", srv.Client())
+	ctx := context.Background()
+
+	zero := make([]byte, 16)
+	v1 := bytes.Repeat([]byte{1}, 16)
+	v2 := bytes.Repeat([]byte{2}, 16)
+	before := oram.Stats().Accesses
+	res, err := c.Batch(ctx, []Op{
+		{ID: 5, Data: v1}, {ID: 5}, {ID: 9}, {ID: 5, Data: v2}, {ID: 5}, {ID: 5, Data: []byte("short")},
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	for i, want := range [][]byte{zero, v1, zero, v1, v2} {
+		if err := res[i].Err(); err != nil || !bytes.Equal(res[i].Data, want) {
+			t.Errorf("op %d = %v, %v; want %v", i, res[i].Data, err, want)
+		}
+	}
+	if !errors.Is(res[5].Err(), pathoram.ErrInvalidDataSize) {
+		t.Errorf("short write err = %v, want ErrInvalidDataSize", res[5].Err())
+	}
+
+	// Two real accesses, padded to one per valid request
+	if got := oram.Stats().Accesses - before; got != 5 {
+		t.Errorf("accesses = %d, want 5", got)
+	}
+	if got := stats.Counts("c"); got != (pathoram.NamespaceCounts{Real: 2, Dummy: 3}) {
+		t.Errorf("namespace counts = %+v, want 2 real, 3 dummy", got)
+	}
+	if got, _ := c.Read(ctx, 5); !bytes.Equal(got, v2) {
+		t.Fatalf("block 5 = %v, want %v", got, v2)
+	}
+}
@@ -0,0 +1,182 @@
+// Package simulate measures PathORAM stash-size distributions for capacity
+// planning. It drives the real eviction code over a tree of 1-byte blocks in
+// memory, with no encryption, so it can run millions of accesses without
+// moving full-size blocks, and reports how often each stash size occurs, percentiles and the overflow
+// probability for a given StashLimit.
+package simulate
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"text/tabwriter"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// Options configures Run.
+type Options struct {
+	// Accesses is the number of measured accesses (default 1,000,000).
+	Accesses int
+
+	// Warmup is the number of accesses performed, after every block has
+	// been written once, before measuring starts (default NumBlocks), so
+	// the stash reaches its steady state.
+	Warmup int
+
+	// Pattern returns the block ID of access i. The default draws IDs
+	// uniformly at random; workload.Phase.Pattern gives zipfian, sequential
+	// or trace-driven patterns. A correct ORAM's stash does not depend on
+	// the pattern; it is here to check that.
+	Pattern func(i int) int
+
+	// Seed seeds leaf assignments and the default Pattern, making a run
+	// reproducible.
+	Seed [32]byte
+}
+
+// Result is the stash-size distribution of one configuration.
+type Result struct {
+	Strategy   pathoram.EvictionStrategy
+	BucketSize int
+	NumBlocks  int
+	Accesses   int
+
+	// Histogram[s] counts the measured accesses after which the stash held
+	// s blocks. Its length is the largest size seen plus one.
+	Histogram []uint64
+}
+
+// MaxStash returns the largest stash size seen.
+func (r Result) MaxStash() int {
+	return len(r.Histogram) - 1
+}
+
+// Percentile returns the smallest stash size that at least fraction p
+// (0 < p ≤ 1) of accesses ended at or below.
+func (r Result) Percentile(p float64) int {
+	need := p * float64(r.Accesses)
+	var seen uint64
+	for s, n := range r.Histogram {
+		seen += n
+		if float64(seen) >= need {
+			return s
+		}
+	}
+	return r.MaxStash()
+}
+
+// OverflowProbability returns the fraction of accesses after which the
+// stash held more than limit blocks: the per-access probability that
+// StashLimit = limit would return ErrStashOverflow. Zero means no overflow
+// was seen; run more accesses to bound smaller probabilities.
+func (r Result) OverflowProbability(limit int) float64 {
+	var over uint64
+	for s := limit + 1; s < len(r.Histogram); s++ {
+		over += r.Histogram[s]
+	}
+	return float64(over) / float64(r.Accesses)
+}
+
+// Run simulates opts.Accesses accesses to an ORAM configured like cfg
+// (NumBlocks, BucketSize, EvictionStrategy, CachedLevels and ConstantTime
+// apply; BlockSize and StashLimit are ignored) and returns its stash-size
+// distribution. With CachedLevels, the stash sizes include the cached
+// levels' blocks.
+func Run(cfg pathoram.Config, opts Options) (Result, error) {
+	if opts.Accesses == 0 {
+		opts.Accesses = 1_000_000
+	}
+	if opts.Warmup == 0 {
+		opts.Warmup = cfg.NumBlocks
+	}
+	if opts.Accesses < 0 || opts.Warmup < 0 {
+		return Result{}, pathoram.ErrInvalidConfig
+	}
+	sim := pathoram.Config{
+		NumBlocks:        cfg.NumBlocks,
+		BlockSize:        1,
+		BucketSize:       cfg.BucketSize,
+		StashLimit:       cfg.NumBlocks, // never report overflow; we measure it
+		EvictionStrategy: cfg.EvictionStrategy,
+		ConstantTime:     cfg.ConstantTime,
+		CachedLevels:     cfg.CachedLevels,
+		Rand:             pathoram.NewSeededRand(opts.Seed),
+	}
+	sim, err := sim.Validate()
+	if err != nil {
+		return Result{}, err
+	}
+	oram, err := pathoram.NewInMemory(sim)
+	if err != nil {
+		return Result{}, err
+	}
+	defer oram.Close()
+
+	pattern := opts.Pattern
+	if pattern == nil {
+		rng := rand.New(rand.NewChaCha8(opts.Seed))
+		pattern = func(int) int { return rng.IntN(cfg.NumBlocks) }
+	}
+	data := []byte{0}
+	for id := range cfg.NumBlocks {
+		if err := oram.WriteFrom(id, data); err != nil {
+			return Result{}, err
+		}
+	}
+	for i := range opts.Warmup {
+		if _, err := oram.Read(pattern(i)); err != nil {
+			return Result{}, err
+		}
+	}
+
+	r := Result{Strategy: cfg.EvictionStrategy, BucketSize: sim.BucketSize, NumBlocks: cfg.NumBlocks, Accesses: opts.Accesses}
+	for i := range opts.Accesses {
+		if _, err := oram.Read(pattern(opts.Warmup + i)); err != nil {
+			return Result{}, err
+		}
+		s := oram.StashSize()
+		for len(r.Histogram) <= s {
+			r.Histogram = append(r.Histogram, 0)
+		}
+		r.Histogram[s]++
+	}
+	return r, nil
+}
+
+// Sweep runs Run for every combination of strategies and bucket sizes, the
+// rest of the configuration taken from cfg.
+func Sweep(cfg pathoram.Config, strategies []pathoram.EvictionStrategy, bucketSizes []int, opts Options) ([]Result, error) {
+	var results []Result
+	for _, s := range strategies {
+		for _, z := range bucketSizes {
+			c := cfg
+			c.EvictionStrategy, c.BucketSize = s, z
+			r, err := Run(c, opts)
+			if err != nil {
+				return nil, fmt.Errorf("%s, Z=%d: %w", s, z, err)
+			}
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// WriteTable writes one row per result: strategy, Z, the stash size at each
+// of percentiles, and the maximum.
+func WriteTable(w io.Writer, results []Result, percentiles []float64) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "strategy\tZ\t")
+	for _, p := range percentiles {
+		fmt.Fprintf(tw, "p%g\t", 100*p)
+	}
+	fmt.Fprint(tw, "max\t\n")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t", r.Strategy, r.BucketSize)
+		for _, p := range percentiles {
+			fmt.Fprintf(tw, "%d\t", r.Percentile(p))
+		}
+		fmt.Fprintf(tw, "%d\t\n", r.MaxStash())
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,81 @@
+package simulate
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/workload"
+)
+
+func TestRun_Distribution(t *testing.T) {
+	cfg := pathoram.Config{NumBlocks: 256, BucketSize: 4}
+	r, err := Run(cfg, Options{Accesses: 20000, Seed: [32]byte{1}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	var total uint64
+	for _, n := range r.Histogram {
+		total += n
+	}
+	if total != 20000 || r.Accesses != 20000 || r.BucketSize != 4 {
+		t.Fatalf("result = %+v", r)
+	}
+	p50, p99 := r.Percentile(0.5), r.Percentile(0.99)
+	if p50 > p99 || p99 > r.MaxStash() {
+		t.Errorf("p50 = %d, p99 = %d, max = %d; want nondecreasing", p50, p99, r.MaxStash())
+	}
+	if r.OverflowProbability(r.MaxStash()) != 0 || r.OverflowProbability(-1) != 1 {
+		t.Errorf("OverflowProbability bounds = %g, %g", r.OverflowProbability(r.MaxStash()), r.OverflowProbability(-1))
+	}
+
+	again, _ := Run(cfg, Options{Accesses: 20000, Seed: [32]byte{1}})
+	if !slices.Equal(again.Histogram, r.Histogram) {
+		t.Error("same seed gave a different histogram")
+	}
+}
+
+func TestSweep_Table(t *testing.T) {
+	strategies := []pathoram.EvictionStrategy{pathoram.EvictLevelByLevel, pathoram.EvictGreedyByDepth}
+	results, err := Sweep(pathoram.Config{NumBlocks: 128}, strategies, []int{2, 4}, Options{Accesses: 2000})
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if len(results) != 4 || results[3].Strategy != pathoram.EvictGreedyByDepth || results[3].BucketSize != 4 {
+		t.Fatalf("results = %+v", results)
+	}
+	// Smaller buckets cannot make the stash smaller on the same workload
+	if results[0].Percentile(0.99) < results[1].Percentile(0.99) {
+		t.Errorf("Z=2 p99 %d below Z=4 p99 %d", results[0].Percentile(0.99), results[1].Percentile(0.99))
+	}
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, results, []float64{0.5, 0.99}); err != nil {
+		t.Fatalf("WriteTable: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 5 || !strings.Contains(lines[0], "p99") {
+		t.Errorf("table:\n%s", buf.String())
+	}
+}
+
+func TestRun_SkewedPattern(t *testing.T) {
+	cfg := pathoram.Config{NumBlocks: 256, BucketSize: 4}
+	uniform, err := Run(cfg, Options{Accesses: 20000, Seed: [32]byte{2}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	phase := workload.Phase{Ops: 1, Keys: workload.Keys{Distribution: workload.Zipfian, S: 1.5}}
+	spec := &workload.Spec{Phases: []workload.Phase{phase}}
+	if err := spec.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	zipf, err := Run(cfg, Options{Accesses: 20000, Seed: [32]byte{2}, Pattern: spec.Phases[0].Pattern(cfg.NumBlocks, 2)})
+	if err != nil {
+		t.Fatalf("Run with zipfian pattern: %v", err)
+	}
+	// Leaves are remapped on every access, so skew must not grow the stash.
+	if z, u := zipf.Percentile(0.99), uniform.Percentile(0.99); z > 2*u+4 {
+		t.Errorf("zipfian p99 stash %d, uniform %d", z, u)
+	}
+}
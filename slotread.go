@@ -0,0 +1,192 @@
+package pathoram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// SlotStorage is implemented by backends that can serve single slots of a
+// bucket, for Config.SingleBlockReads. InMemoryStorage implements it.
+type SlotStorage interface {
+	// ReadSlots returns slot slots[i] of the bucket at idxs[i], with Data,
+	// for each i.
+	ReadSlots(ctx context.Context, idxs, slots []int) ([]Block, error)
+}
+
+// checkSingleBlockReads finds the SlotStorage for Config.SingleBlockReads.
+func (o *PathORAM) checkSingleBlockReads() error {
+	if ss, ok := o.storage.(SlotStorage); ok {
+		o.slotStore = ss
+	} else if ss, ok := o.store.(SlotStorage); ok {
+		o.slotStore = ss
+	} else {
+		return fmt.Errorf("%w: SingleBlockReads needs storage implementing SlotStorage", ErrInvalidConfig)
+	}
+	switch {
+	case !o.cfg.EncryptHeaders:
+		return fmt.Errorf("%w: SingleBlockReads needs EncryptHeaders, or the server sees the ID of each slot read", ErrInvalidConfig)
+	case o.cfg.VerifyIntegrity, o.cfg.VersionKey != nil:
+		return fmt.Errorf("%w: SingleBlockReads cannot verify single slots (VerifyIntegrity, VersionKey)", ErrInvalidConfig)
+	case o.cfg.Stash != nil:
+		return fmt.Errorf("%w: SingleBlockReads cannot persist the stash (Config.Stash)", ErrInvalidConfig)
+	}
+	o.detached = make(map[int]int)
+	o.slotIDs = make(map[int][]int)
+	return nil
+}
+
+// readBlockIntoStash is the online phase of an access with SingleBlockReads:
+// it reads one slot of every bucket on path, the block's slot in the bucket
+// holding it and a uniformly random slot elsewhere, and moves the block, if
+// found, into the stash. Its stored copy stays in the tree, recorded in
+// o.detached, until the path is read (see moveIntoStash).
+//
+// Slots are located with the client's record of each bucket's slot IDs
+// (o.slotIDs), never with stored headers, which are encrypted. Every bucket
+// is written in a fresh random slot order and the whole path is rewritten
+// before the access ends, so no slot is read online twice between writes
+// and the slots read are uniform whichever block is wanted, as in Ring
+// ORAM. A bucket not written since New is read in full instead, which
+// depends only on earlier paths.
+func (o *PathORAM) readBlockIntoStash(ctx context.Context, blockID int, path []int) error {
+	_, inStash := o.detached[blockID]
+	var idxs, slots []int
+	for _, idx := range path {
+		ids, ok := o.slotIDs[idx]
+		if !ok {
+			found, err := o.readUnknownBucket(ctx, idx, blockID, inStash)
+			if err != nil {
+				return err
+			}
+			inStash = inStash || found
+			continue
+		}
+		var slot int
+		if o.xor != nil {
+			slot = o.randomDummy(ids)
+		} else {
+			slot = randIntn(o.rng, len(ids))
+		}
+		if !inStash {
+			if i := slices.Index(ids, blockID); i >= 0 {
+				slot = i
+			}
+		}
+		idxs, slots = append(idxs, idx), append(slots, slot)
+	}
+	if len(idxs) == 0 {
+		return nil
+	}
+	if o.xor != nil {
+		return o.readXOR(ctx, blockID, idxs, slots)
+	}
+	blocks, err := o.slotStore.ReadSlots(ctx, idxs, slots)
+	if err != nil {
+		return err
+	}
+	if len(blocks) != len(idxs) {
+		return fmt.Errorf("%w: %d slots for %d reads", ErrCorruptObject, len(blocks), len(idxs))
+	}
+	o.stats.SlotReads += uint64(len(blocks))
+	o.stats.BytesRead += uint64(blockBytes(blocks))
+	for i, idx := range idxs {
+		if inStash || o.slotIDs[idx][slots[i]] != blockID {
+			continue
+		}
+		b := blocks[i : i+1]
+		if err := o.openHeaders(idx, b); err != nil {
+			return err
+		}
+		if b[0].ID != blockID {
+			return fmt.Errorf("%w: bucket %d slot %d holds block %d, written with %d", ErrCorruptObject, idx, slots[i], b[0].ID, blockID)
+		}
+		if err := o.detach(idx, b[0]); err != nil {
+			return err
+		}
+		inStash = true
+	}
+	return nil
+}
+
+// readUnknownBucket reads in full a bucket whose slot IDs the client has no
+// record of and detaches blockID if the bucket holds it and it is not
+// already in the stash. It reports whether it detached the block. The
+// bucket stays unrecorded until the access rewrites it, so a record always
+// describes a bucket as this client last wrote it.
+func (o *PathORAM) readUnknownBucket(ctx context.Context, idx, blockID int, inStash bool) (bool, error) {
+	bucket, err := o.readBucket(ctx, idx)
+	if err != nil {
+		return false, err
+	}
+	i := slices.IndexFunc(bucket, func(b Block) bool { return b.ID == blockID })
+	if i >= 0 && !inStash {
+		return true, o.detach(idx, bucket[i])
+	}
+	return false, nil
+}
+
+// detach decrypts b, the stored copy of a block in bucket idx, into the
+// stash, leaving the copy in place (see readBlockIntoStash).
+func (o *PathORAM) detach(idx int, b Block) error {
+	plaintext, err := o.decryptBlock(b)
+	if err != nil {
+		o.noteDecryptionFailure()
+		return err
+	}
+	o.stash = append(o.stash, block{id: b.ID, leaf: b.Leaf, data: plaintext})
+	o.detached[b.ID] = idx
+	o.noteStash()
+	return nil
+}
+
+// noteSlots records the slot IDs of bucket idx as written. Call it after
+// permuteSlots and before the bucket is sealed.
+func (o *PathORAM) noteSlots(idx int, blocks []Block) {
+	ids := o.slotIDs[idx][:0]
+	for _, b := range blocks {
+		ids = append(ids, b.ID)
+	}
+	o.slotIDs[idx] = ids
+}
+
+// forgetSlots drops the slot records of buckets from idx on, after a failed
+// write or when the tree shrinks.
+func (o *PathORAM) forgetSlots(from int) {
+	for idx := range o.slotIDs {
+		if idx >= from {
+			delete(o.slotIDs, idx)
+		}
+	}
+}
+
+// isDetached reports whether b, read from bucket idx, is the stale stored
+// copy of a block already in the stash, and forgets it if so.
+func (o *PathORAM) isDetached(idx int, b Block) bool {
+	if at, ok := o.detached[b.ID]; ok && at == idx {
+		delete(o.detached, b.ID)
+		return true
+	}
+	return false
+}
+
+// overwriteDetached forgets stale copies in bucket idx, which is about to be
+// overwritten.
+func (o *PathORAM) overwriteDetached(idx int) {
+	for id, at := range o.detached {
+		if at == idx {
+			delete(o.detached, id)
+		}
+	}
+}
+
+// permuteSlots shuffles a bucket before it is written, so with
+// SingleBlockReads a block's slot position is uniformly random and, with the
+// headers encrypted, a slot read online reveals nothing about the block in
+// it.
+func (o *PathORAM) permuteSlots(blocks []Block) {
+	for i := len(blocks) - 1; i > 0; i-- {
+		j := randIntn(o.rng, i+1)
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+}
@@ -0,0 +1,147 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+// slotStorage records the slots read online from an InMemoryStorage.
+type slotStorage struct {
+	*InMemoryStorage
+	slots map[int]int // reads of each slot position
+	reads int
+}
+
+func (s *slotStorage) ReadSlots(ctx context.Context, idxs, slots []int) ([]Block, error) {
+	blocks, err := s.InMemoryStorage.ReadSlots(ctx, idxs, slots)
+	for i, b := range blocks {
+		s.reads++
+		s.slots[slots[i]]++
+		if b.ID != EmptyBlockID || b.Leaf != -1 {
+			return nil, errors.New("slot header served in the clear")
+		}
+	}
+	return blocks, err
+}
+
+func TestSingleBlockReads(t *testing.T) {
+	for _, background := range []bool{false, true} {
+		if background && !backgroundEvictionAvailable {
+			continue
+		}
+		enc := mustAES(t, bytes.Repeat([]byte{3}, 32))
+		cfg := Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4, StashLimit: 200,
+			SingleBlockReads: true, EncryptHeaders: true, BackgroundEviction: background}
+		height, _, total := cfg.ComputeTreeParams()
+		storage := &slotStorage{InMemoryStorage: NewInMemoryStorage(total, 4, StoredBlockSize(cfg, enc)), slots: make(map[int]int)}
+		oram, err := New(cfg, storage, NewInMemoryPositionMap(), enc)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+
+		for id := range 64 {
+			if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id)}, 32)); err != nil {
+				t.Fatalf("background=%v: Write(%d) failed: %v", background, id, err)
+			}
+		}
+		for round := range 4 {
+			for id := range 64 {
+				got, err := oram.Read(id)
+				if err != nil {
+					t.Fatalf("background=%v: Read(%d) failed: %v", background, id, err)
+				}
+				if !bytes.Equal(got, bytes.Repeat([]byte{byte(id + round)}, 32)) {
+					t.Fatalf("background=%v: Read(%d) = %x", background, id, got[:4])
+				}
+				if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id + round + 1)}, 32)); err != nil {
+					t.Fatalf("background=%v: Write(%d) failed: %v", background, id, err)
+				}
+			}
+		}
+		if _, err := oram.EvictPending(t.Context()); err != nil {
+			t.Fatalf("EvictPending failed: %v", err)
+		}
+		report, err := oram.CheckInvariants()
+		if err != nil {
+			t.Fatalf("CheckInvariants failed: %v", err)
+		}
+		if err := report.Err(); err != nil {
+			t.Errorf("background=%v: %v", background, err)
+		}
+
+		// Buckets not yet written since New are read in full instead
+		stats := oram.Stats()
+		if max := uint64(64 * 9 * height); stats.SlotReads == 0 || stats.SlotReads > max {
+			t.Errorf("background=%v: SlotReads = %d, want at most %d", background, stats.SlotReads, max)
+		}
+		if storage.reads != int(stats.SlotReads) {
+			t.Errorf("background=%v: storage served %d slots, Stats say %d", background, storage.reads, stats.SlotReads)
+		}
+		// Buckets are permuted, so every slot position is read about as often
+		for slot := range 4 {
+			if n := storage.slots[slot]; n < storage.reads/8 {
+				t.Errorf("background=%v: slot %d read %d of %d times", background, slot, n, storage.reads)
+			}
+		}
+	}
+}
+
+func TestSingleBlockReads_Config(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4, SingleBlockReads: true}
+	_, _, total := cfg.ComputeTreeParams()
+	plain := struct{ Storage }{NewInMemoryStorage(total, 4, 32)}
+	if _, err := New(cfg, plain, NewInMemoryPositionMap(), NoOpEncryptor{}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("New without SlotStorage: error = %v, want ErrInvalidConfig", err)
+	}
+	cfg.VerifyIntegrity = true
+	if _, err := New(cfg, NewInMemoryStorage(total, 4, 32), NewInMemoryPositionMap(), NoOpEncryptor{}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("New with VerifyIntegrity: error = %v, want ErrInvalidConfig", err)
+	}
+
+	// Without encrypted headers the server would see the ID in each slot read
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{1}, 32))
+	cfg = Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4, SingleBlockReads: true}
+	if _, err := New(cfg, NewInMemoryStorage(total, 4, StoredBlockSize(cfg, enc)), NewInMemoryPositionMap(), enc); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("New without EncryptHeaders: error = %v, want ErrInvalidConfig", err)
+	}
+	cfg.EncryptHeaders, cfg.ThreatModel = true, HonestButCurious
+	if _, err := New(cfg, NewInMemoryStorage(total, 4, StoredBlockSize(cfg, enc)), NewInMemoryPositionMap(), enc); err != nil {
+		t.Errorf("New with EncryptHeaders under a threat model: %v", err)
+	}
+}
+
+func TestSingleBlockReads_Restart(t *testing.T) {
+	// A second instance over the same tree has no slot records, so it reads
+	// each bucket in full the first time and finds blocks there
+	enc := mustAES(t, bytes.Repeat([]byte{3}, 32))
+	cfg := Config{NumBlocks: 32, BlockSize: 16, StashLimit: 200, SingleBlockReads: true, EncryptHeaders: true}
+	_, _, total := cfg.ComputeTreeParams()
+	storage := &slotStorage{InMemoryStorage: NewInMemoryStorage(total, 4, StoredBlockSize(cfg, enc)), slots: make(map[int]int)}
+	pm := NewInMemoryPositionMap()
+	first, err := New(cfg, storage, pm, enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for id := range 32 {
+		first.Write(id, bytes.Repeat([]byte{byte(id)}, 16))
+	}
+	if _, err := first.DrainStash(t.Context(), 0, 100, nil); err != nil {
+		t.Fatalf("DrainStash failed: %v", err)
+	}
+	second, err := New(cfg, storage, pm, enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for round := range 2 {
+		for id := range 32 {
+			if got, err := second.Read(id); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(id)}, 16)) {
+				t.Fatalf("round %d: Read(%d) = %x, %v", round, id, got, err)
+			}
+		}
+	}
+	if report, err := second.CheckInvariants(); err != nil || report.Err() != nil {
+		t.Fatalf("CheckInvariants = %v, %v", report.Err(), err)
+	}
+}
@@ -0,0 +1,331 @@
+package pathoram
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SparseFile is a growable file stored in a PathORAM that allocates a block
+// only when its region is first written. It implements io.ReadWriteSeeker,
+// io.ReaderAt, io.WriterAt and io.Closer, plus Truncate, for database files
+// that are mostly holes: the logical size may exceed the ORAM's
+// Capacity()*BlockSize(), and only written blocks count against capacity.
+// Writing a new block when none are free returns ErrNoSpace.
+//
+// As in x/kv's Store, the map from file blocks to ORAM block IDs and the free
+// list are kept in client memory. Holes read as zeros and cost a dummy
+// access, so each block touched costs one access whether or not it is
+// allocated, and partially written blocks two; the server learns the
+// offsets' block count but not which blocks hold data. Truncate frees the
+// blocks past the new size without accessing them unless the ORAM has
+// Config.SecureDelete, in which case each freed block is overwritten, which
+// reveals how many were allocated.
+type SparseFile struct {
+	mu     sync.Mutex
+	oram   *PathORAM
+	blocks map[int64]int // file block index → ORAM block ID
+	free   []int         // unallocated block IDs, popped from the end
+	size   int64
+	off    int64 // Read, Write and Seek position
+	closed bool
+}
+
+// NewSparseFile creates an empty SparseFile that owns all blocks of oram.
+// Close closes oram, and oram should not be accessed directly while the
+// file is in use.
+func NewSparseFile(oram *PathORAM) *SparseFile {
+	n := oram.Capacity()
+	free := make([]int, n)
+	for i := range free {
+		free[i] = n - 1 - i
+	}
+	return &SparseFile{oram: oram, blocks: make(map[int64]int), free: free}
+}
+
+// Size returns the file's logical size in bytes.
+func (f *SparseFile) Size() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.size
+}
+
+// Allocated returns the number of ORAM blocks holding file data.
+func (f *SparseFile) Allocated() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.blocks)
+}
+
+// Read reads up to len(p) bytes at the current offset and advances it.
+func (f *SparseFile) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.readAt(p, f.off)
+	f.off += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// Write writes p at the current offset, extending the file if needed, and
+// advances the offset.
+func (f *SparseFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.writeAt(p, f.off)
+	f.off += int64(n)
+	return n, err
+}
+
+// Seek sets the offset for the next Read or Write. Seeking past the end is
+// allowed; a later Write there leaves a hole.
+func (f *SparseFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, fmt.Errorf("%w: whence %d", ErrInvalidConfig, whence)
+	}
+	if offset < 0 {
+		return 0, ErrNegativeOffset
+	}
+	f.off = offset
+	return offset, nil
+}
+
+// ReadAt reads len(p) bytes starting at off. It returns io.EOF if the read
+// reaches the end of the file.
+func (f *SparseFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.readAt(p, off)
+}
+
+// WriteAt writes len(p) bytes starting at off, extending the file if needed.
+// It writes nothing and returns ErrNoSpace if the write needs more new
+// blocks than are free.
+func (f *SparseFile) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writeAt(p, off)
+}
+
+// Truncate changes the file's size. Shrinking frees the blocks past the new
+// end and zeroes the rest of the last block, so growing again later reads
+// zeros; growing adds a hole.
+func (f *SparseFile) Truncate(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return fs.ErrClosed
+	}
+	if size < 0 {
+		return ErrNegativeOffset
+	}
+	if size >= f.size {
+		f.size = size
+		return nil
+	}
+
+	ctx := context.Background()
+	bs := int64(f.oram.BlockSize())
+	if tail := size % bs; tail != 0 {
+		if err := f.update(ctx, size/bs, func(block []byte) { clear(block[tail:]) }); err != nil {
+			return err
+		}
+	}
+	end := (size + bs - 1) / bs
+	var freed []int64
+	for idx := range f.blocks {
+		if idx >= end {
+			freed = append(freed, idx)
+		}
+	}
+	slices.Sort(freed)
+	for _, idx := range freed {
+		id := f.blocks[idx]
+		if f.oram.cfg.SecureDelete {
+			if err := f.oram.DeleteCtx(ctx, id); err != nil {
+				return err
+			}
+		}
+		delete(f.blocks, idx)
+		f.free = append(f.free, id)
+	}
+	f.size = size
+	return nil
+}
+
+// Close closes the underlying ORAM. Later calls return fs.ErrClosed.
+func (f *SparseFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return f.oram.Close()
+}
+
+// readAt implements ReadAt; f.mu must be held.
+func (f *SparseFile) readAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	want := len(p)
+	if int64(want) > f.size-off {
+		p = p[:f.size-off]
+	}
+
+	ctx := context.Background()
+	bs := int64(f.oram.BlockSize())
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		id, ok := f.blocks[pos/bs]
+		if !ok {
+			if err := f.dummy(ctx); err != nil {
+				return n, err
+			}
+			end := min(len(p), n+int(bs-pos%bs))
+			clear(p[n:end])
+			n = end
+			continue
+		}
+		data, err := f.oram.ReadCtx(ctx, id)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], data[pos%bs:])
+	}
+	if n < want {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// writeAt implements WriteAt; f.mu must be held.
+func (f *SparseFile) writeAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+	bs := int64(f.oram.BlockSize())
+	need := 0
+	if len(p) > 0 {
+		for idx := off / bs; idx <= (off+int64(len(p))-1)/bs; idx++ {
+			if _, ok := f.blocks[idx]; !ok {
+				need++
+			}
+		}
+	}
+	if need > len(f.free) {
+		return 0, fmt.Errorf("%w: need %d blocks, %d free", ErrNoSpace, need, len(f.free))
+	}
+
+	ctx := context.Background()
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		start := pos % bs
+		chunk := min(int64(len(p)-n), bs-start)
+		if chunk == bs {
+			if err := f.put(ctx, pos/bs, p[n:n+int(bs)]); err != nil {
+				return n, err
+			}
+		} else {
+			err := f.update(ctx, pos/bs, func(block []byte) { copy(block[start:], p[n:n+int(chunk)]) })
+			if err != nil {
+				return n, err
+			}
+		}
+		n += int(chunk)
+		f.size = max(f.size, pos+chunk)
+	}
+	return n, nil
+}
+
+// put writes a whole file block, allocating it if needed.
+func (f *SparseFile) put(ctx context.Context, idx int64, data []byte) error {
+	id, ok := f.blocks[idx]
+	if !ok {
+		id = f.free[len(f.free)-1]
+	}
+	if _, err := f.oram.WriteCtx(ctx, id, data); err != nil {
+		return err
+	}
+	if !ok {
+		f.free = f.free[:len(f.free)-1]
+		f.blocks[idx] = id
+	}
+	return nil
+}
+
+// update rewrites part of a file block with two accesses: a read, or a
+// dummy access for a hole, which starts out as zeros, then a write. A hole
+// the edit leaves all zeros stays unallocated, with a dummy access in place
+// of the write.
+func (f *SparseFile) update(ctx context.Context, idx int64, edit func(block []byte)) error {
+	id, ok := f.blocks[idx]
+	var block []byte
+	if ok {
+		data, err := f.oram.ReadCtx(ctx, id)
+		if err != nil {
+			return err
+		}
+		block = data
+	} else {
+		if err := f.dummy(ctx); err != nil {
+			return err
+		}
+		block = make([]byte, f.oram.BlockSize())
+	}
+	edit(block)
+	if !ok && isZero(block) {
+		return f.dummy(ctx)
+	}
+	return f.put(ctx, idx, block)
+}
+
+// dummy performs a dummy access reported like a read.
+func (f *SparseFile) dummy(ctx context.Context) error {
+	o := f.oram
+	defer o.padAccess(ctx, time.Now(), 1)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return ErrClosed
+	}
+	return o.observedDummy(ctx)
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"testing"
+)
+
+func newTestSparseFile(t *testing.T, numBlocks int) *SparseFile {
+	t.Helper()
+	oram, err := NewInMemory(Config{NumBlocks: numBlocks, BlockSize: 16, StashLimit: 200})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	return NewSparseFile(oram)
+}
+
+func TestSparseFile_Shadow(t *testing.T) {
+	f := newTestSparseFile(t, 32)
+	var shadow []byte
+	truncate := func(size int64) {
+		if err := f.Truncate(size); err != nil {
+			t.Fatalf("Truncate(%d) failed: %v", size, err)
+		}
+		if int(size) < len(shadow) {
+			shadow = shadow[:size]
+		} else {
+			shadow = append(shadow, make([]byte, int(size)-len(shadow))...)
+		}
+	}
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range 300 {
+		switch op := r.IntN(10); {
+		case op < 6:
+			// Offsets range over far more than the 512 bytes of capacity
+			off := int64(r.IntN(2000))
+			p := bytes.Repeat([]byte{byte(i) | 1}, 1+r.IntN(40))
+			if _, err := f.WriteAt(p, off); errors.Is(err, ErrNoSpace) {
+				truncate(int64(r.IntN(500)))
+				continue
+			} else if err != nil {
+				t.Fatalf("WriteAt(%d) failed: %v", off, err)
+			}
+			if end := int(off) + len(p); end > len(shadow) {
+				shadow = append(shadow, make([]byte, end-len(shadow))...)
+			}
+			copy(shadow[off:], p)
+		case op < 8:
+			truncate(int64(r.IntN(2000)))
+		default:
+			got := make([]byte, f.Size())
+			if n, err := f.ReadAt(got, 0); n != len(shadow) || (err != nil && err != io.EOF) {
+				t.Fatalf("ReadAt = %d, %v; want %d bytes", n, err, len(shadow))
+			}
+			if !bytes.Equal(got, shadow) {
+				t.Fatalf("op %d: contents differ from shadow copy", i)
+			}
+		}
+		if f.Size() != int64(len(shadow)) {
+			t.Fatalf("op %d: Size() = %d, want %d", i, f.Size(), len(shadow))
+		}
+	}
+	if f.Allocated() > 32 {
+		t.Errorf("Allocated() = %d exceeds capacity", f.Allocated())
+	}
+}
+
+func TestSparseFile_ReadWriteSeeker(t *testing.T) {
+	f := newTestSparseFile(t, 8)
+	var _ io.ReadWriteSeeker = f
+	if _, err := f.Seek(1000, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if n, err := f.Write([]byte("tail")); n != 4 || err != nil {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if f.Size() != 1004 || f.Allocated() != 1 {
+		t.Fatalf("Size() = %d, Allocated() = %d; want 1004, 1", f.Size(), f.Allocated())
+	}
+	if pos, _ := f.Seek(-6, io.SeekEnd); pos != 998 {
+		t.Fatalf("Seek from end = %d, want 998", pos)
+	}
+	got, err := io.ReadAll(f)
+	if err != nil || !bytes.Equal(got, []byte("\x00\x00tail")) {
+		t.Fatalf("ReadAll = %q, %v", got, err)
+	}
+	if _, err := f.Seek(-1, io.SeekStart); !errors.Is(err, ErrNegativeOffset) {
+		t.Errorf("Seek(-1): error = %v, want ErrNegativeOffset", err)
+	}
+
+	// Shrinking into a block zeroes its tail for a later regrowth
+	f.Truncate(1002)
+	f.Truncate(1004)
+	buf := make([]byte, 4)
+	if _, err := f.ReadAt(buf, 1000); err != nil || !bytes.Equal(buf, []byte("ta\x00\x00")) {
+		t.Errorf("after shrink and regrow ReadAt = %q, %v", buf, err)
+	}
+	f.Truncate(0)
+	if f.Allocated() != 0 {
+		t.Errorf("Allocated() after Truncate(0) = %d", f.Allocated())
+	}
+
+	// Eight blocks of capacity; a ninth new block does not fit
+	if _, err := f.WriteAt(make([]byte, 9*16), 0); !errors.Is(err, ErrNoSpace) {
+		t.Errorf("oversized write: error = %v, want ErrNoSpace", err)
+	}
+	f.Close()
+	if _, err := f.Read(buf); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("Read after Close: error = %v, want fs.ErrClosed", err)
+	}
+}
+
+func TestSparseFile_HolesCostLikeData(t *testing.T) {
+	f := newTestSparseFile(t, 16)
+	f.WriteAt(bytes.Repeat([]byte{1}, 64), 0) // blocks 0-3 allocated
+	f.Truncate(128)                           // blocks 4-7 are holes
+	accesses := func(fn func()) uint64 {
+		before := f.oram.Stats().Accesses
+		fn()
+		return f.oram.Stats().Accesses - before
+	}
+	buf := make([]byte, 40)
+	data := accesses(func() { f.ReadAt(buf, 4) })
+	hole := accesses(func() { f.ReadAt(buf, 68) })
+	if data != hole || data != 3 {
+		t.Errorf("reading 3 blocks cost %d accesses with data, %d in holes; want 3", data, hole)
+	}
+	data = accesses(func() { f.WriteAt(buf[:5], 20) })
+	hole = accesses(func() { f.WriteAt(buf[:5], 84) })
+	if data != hole || data != 2 {
+		t.Errorf("partial write cost %d accesses with data, %d in a hole; want 2", data, hole)
+	}
+}
@@ -0,0 +1,250 @@
+package pathoram
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// Stash stores the client's stash: blocks read from the tree and not yet
+// evicted back to it. Plug in an implementation through Config.Stash to keep
+// the stash somewhere other than process memory, e.g. an encrypted file that
+// survives restarts or an enclave-sealed store. Entries hold plaintext, so
+// such implementations must protect them themselves.
+//
+// Without Config.Stash the ORAM uses a SliceStash, whose slice is the
+// working stash itself. Other implementations are the store of record for a
+// working copy in memory, since every access scans the stash repeatedly: at
+// the end of every access, the ORAM removes the entries evicted since the
+// last one, adds the new ones, and removes and re-adds the ones the access
+// wrote or remapped, so a Stash sees O(changes) calls per access. New loads
+// a non-empty Stash as the initial stash contents.
+//
+// The ORAM calls a Stash only with its lock held, and it need not be safe
+// for concurrent use; snapshot one the ORAM is using with
+// PathORAM.SerializeStash.
+type Stash interface {
+	// Add stores e. Its ID is not already in the stash.
+	Add(e StashEntry) error
+
+	// Remove deletes the entry for blockID, if any.
+	Remove(blockID int) error
+
+	// Iterate calls fn for each entry, in any order, until fn returns false.
+	Iterate(fn func(StashEntry) bool) error
+
+	// Len returns the number of entries.
+	Len() int
+
+	// Serialize returns the entries in a form the implementation can
+	// restore from.
+	Serialize() ([]byte, error)
+}
+
+// SliceStash is a Stash backed by a slice, the default in-memory stash.
+// Use it with Config.Stash to snapshot the stash with Serialize and restore
+// it with DeserializeSliceStash. Once an ORAM has adopted it, its methods
+// take the ORAM's lock, so Serialize sees the stash between accesses.
+type SliceStash struct {
+	entries []block
+	lock    sync.Locker // the adopting ORAM's lock, or nil
+}
+
+var _ Stash = (*SliceStash)(nil)
+
+// NewSliceStash creates an empty SliceStash.
+func NewSliceStash() *SliceStash {
+	return &SliceStash{}
+}
+
+// acquire takes the adopting ORAM's lock, if any, and returns its release.
+func (s *SliceStash) acquire() func() {
+	if s.lock == nil {
+		return func() {}
+	}
+	s.lock.Lock()
+	return s.lock.Unlock
+}
+
+// Add stores e.
+func (s *SliceStash) Add(e StashEntry) error {
+	defer s.acquire()()
+	s.entries = append(s.entries, block{id: e.ID, leaf: e.Leaf, data: e.Data})
+	return nil
+}
+
+// Remove deletes the entry for blockID, if any.
+func (s *SliceStash) Remove(blockID int) error {
+	defer s.acquire()()
+	for i, e := range s.entries {
+		if e.id == blockID {
+			last := len(s.entries) - 1
+			s.entries[i] = s.entries[last]
+			s.entries = s.entries[:last]
+			break
+		}
+	}
+	return nil
+}
+
+// Iterate calls fn for each entry until fn returns false. Entries share
+// their Data with the stash; fn must not access the ORAM.
+func (s *SliceStash) Iterate(fn func(StashEntry) bool) error {
+	defer s.acquire()()
+	for _, e := range s.entries {
+		if !fn(StashEntry{ID: e.id, Leaf: e.leaf, Data: e.data}) {
+			break
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries.
+func (s *SliceStash) Len() int {
+	defer s.acquire()()
+	return len(s.entries)
+}
+
+// Serialize encodes the entries as a count followed by each entry's ID,
+// leaf, data length and data.
+func (s *SliceStash) Serialize() ([]byte, error) {
+	defer s.acquire()()
+	var buf []byte
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(s.entries)))
+	for _, e := range s.entries {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.id))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.leaf))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(e.data)))
+		buf = append(buf, e.data...)
+	}
+	return buf, nil
+}
+
+// DeserializeSliceStash restores a SliceStash from the output of Serialize.
+func DeserializeSliceStash(data []byte) (*SliceStash, error) {
+	bad := fmt.Errorf("%w: truncated stash", ErrCorruptObject)
+	if len(data) < 8 {
+		return nil, bad
+	}
+	n := binary.BigEndian.Uint64(data)
+	data = data[8:]
+	s := &SliceStash{}
+	for range n {
+		if len(data) < 20 {
+			return nil, bad
+		}
+		e := block{
+			id:   int(binary.BigEndian.Uint64(data)),
+			leaf: int(binary.BigEndian.Uint64(data[8:])),
+		}
+		size := binary.BigEndian.Uint32(data[16:])
+		data = data[20:]
+		if uint64(len(data)) < uint64(size) {
+			return nil, bad
+		}
+		e.data = bytes.Clone(data[:size])
+		data = data[size:]
+		s.entries = append(s.entries, e)
+	}
+	return s, nil
+}
+
+// loadStash loads the entries of the ORAM's Stash into the working stash.
+// A SliceStash's entries are adopted as they are; other implementations'
+// entries are copied, and their hashes recorded for syncStash.
+func (o *PathORAM) loadStash() error {
+	check := func(id, leaf int, data []byte) error {
+		if id < 0 || id >= o.cfg.NumBlocks || len(data) != o.cfg.plainSize() {
+			return fmt.Errorf("%w: stash entry %d", ErrCorruptObject, id)
+		}
+		o.posMap.Set(id, leaf)
+		return nil
+	}
+	if s, ok := o.stashStore.(*SliceStash); ok {
+		s.lock = &o.mu
+		for _, b := range s.entries {
+			if err := check(b.id, b.leaf, b.data); err != nil {
+				return err
+			}
+		}
+		o.stash = s.entries
+		return nil
+	}
+	o.stored, o.changed = make(map[int]bool), make(map[int]bool)
+	var err error
+	if iterErr := o.stashStore.Iterate(func(e StashEntry) bool {
+		if err = check(e.ID, e.Leaf, e.Data); err != nil {
+			return false
+		}
+		b := block{id: e.ID, leaf: e.Leaf, data: bytes.Clone(e.Data)}
+		o.stash = append(o.stash, b)
+		o.stored[b.id] = true
+		return true
+	}); iterErr != nil {
+		return iterErr
+	}
+	return err
+}
+
+// syncStash brings the ORAM's Stash up to date with the working stash. A
+// SliceStash, the default, adopts the working stash itself. Any other Stash
+// receives a Remove for each entry evicted since the last sync, an Add for
+// each new one, and a Remove and Add for each one marked by stashChanged.
+func (o *PathORAM) syncStash() error {
+	if s, ok := o.stashStore.(*SliceStash); ok {
+		s.entries = o.stash
+		return nil
+	}
+	if o.stored == nil {
+		o.stored, o.changed = make(map[int]bool), make(map[int]bool)
+	}
+	present := make(map[int]bool, len(o.stash))
+	for _, b := range o.stash {
+		present[b.id] = true
+		if o.stored[b.id] {
+			if !o.changed[b.id] {
+				continue
+			}
+			if err := o.stashStore.Remove(b.id); err != nil {
+				return err
+			}
+		}
+		if err := o.stashStore.Add(StashEntry{ID: b.id, Leaf: b.leaf, Data: bytes.Clone(b.data)}); err != nil {
+			return err
+		}
+		o.stored[b.id] = true
+	}
+	for id := range o.stored {
+		if present[id] {
+			continue
+		}
+		if err := o.stashStore.Remove(id); err != nil {
+			return err
+		}
+		delete(o.stored, id)
+	}
+	clear(o.changed)
+	return nil
+}
+
+// stashChanged records that the leaf or data of blockID's stash entry was
+// changed in place, for syncStash. Entries read into the stash need no
+// mark: they are new, or as they were when evicted since the last sync.
+func (o *PathORAM) stashChanged(blockID int) {
+	if o.changed != nil {
+		o.changed[blockID] = true
+	}
+}
+
+// SerializeStash returns the Serialize output of the ORAM's Stash (see
+// Config.Stash; a SliceStash by default), taken under the ORAM's lock
+// between accesses.
+func (o *PathORAM) SerializeStash() ([]byte, error) {
+	if s, ok := o.stashStore.(*SliceStash); ok {
+		return s.Serialize()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stashStore.Serialize()
+}
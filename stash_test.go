@@ -0,0 +1,212 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSliceStash_SerializeRoundTrip(t *testing.T) {
+	s := NewSliceStash()
+	s.Add(StashEntry{ID: 1, Leaf: 3, Data: []byte("abc")})
+	s.Add(StashEntry{ID: 7, Leaf: 0, Data: []byte("defg")})
+	s.Add(StashEntry{ID: 9, Leaf: 2, Data: nil})
+	s.Remove(9)
+
+	data, err := s.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	got, err := DeserializeSliceStash(data)
+	if err != nil {
+		t.Fatalf("DeserializeSliceStash: %v", err)
+	}
+	if got.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", got.Len())
+	}
+	want := map[int]StashEntry{1: {1, 3, []byte("abc")}, 7: {7, 0, []byte("defg")}}
+	got.Iterate(func(e StashEntry) bool {
+		w := want[e.ID]
+		if e.Leaf != w.Leaf || !bytes.Equal(e.Data, w.Data) {
+			t.Errorf("entry %d = %+v, want %+v", e.ID, e, w)
+		}
+		return true
+	})
+
+	if _, err := DeserializeSliceStash(data[:len(data)-1]); !errors.Is(err, ErrCorruptObject) {
+		t.Errorf("truncated = %v, want ErrCorruptObject", err)
+	}
+}
+
+func TestStash_MirrorsWorkingStash(t *testing.T) {
+	s := NewSliceStash()
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 2, StashLimit: 200, Stash: s}
+	oram, err := NewInMemory(cfg)
+	if err != nil {
+		t.Fatalf("NewInMemory: %v", err)
+	}
+	for i := range 200 {
+		if _, err := oram.Write(i%64, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if s.Len() != oram.StashSize() {
+			t.Fatalf("access %d: Stash.Len = %d, StashSize = %d", i, s.Len(), oram.StashSize())
+		}
+	}
+	for _, b := range oram.stash {
+		found := false
+		s.Iterate(func(e StashEntry) bool {
+			found = e.ID == b.id && e.Leaf == b.leaf && bytes.Equal(e.Data, b.data)
+			return !found
+		})
+		if !found {
+			t.Errorf("block %d missing or stale in Stash", b.id)
+		}
+	}
+}
+
+func TestSliceStash_SerializeDuringAccesses(t *testing.T) {
+	s := NewSliceStash()
+	oram, err := NewInMemory(Config{NumBlocks: 64, BlockSize: 16, BucketSize: 2, StashLimit: 200, Stash: s})
+	if err != nil {
+		t.Fatalf("NewInMemory: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 500 {
+			oram.Write(i%64, bytes.Repeat([]byte{byte(i)}, 16))
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		data, err := oram.SerializeStash()
+		if err != nil {
+			t.Fatalf("SerializeStash: %v", err)
+		}
+		if _, err := DeserializeSliceStash(data); err != nil {
+			t.Fatalf("snapshot taken during accesses: %v", err)
+		}
+	}
+}
+
+// mapStash is a Stash other than SliceStash, which the ORAM syncs by
+// calls rather than by sharing its working stash.
+type mapStash struct {
+	entries map[int]StashEntry
+	calls   int
+}
+
+func (s *mapStash) Add(e StashEntry) error {
+	s.calls++
+	if _, ok := s.entries[e.ID]; ok {
+		return fmt.Errorf("duplicate add of %d", e.ID)
+	}
+	s.entries[e.ID] = e
+	return nil
+}
+
+func (s *mapStash) Remove(blockID int) error {
+	s.calls++
+	delete(s.entries, blockID)
+	return nil
+}
+
+func (s *mapStash) Iterate(fn func(StashEntry) bool) error {
+	for _, e := range s.entries {
+		if !fn(e) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *mapStash) Len() int                   { return len(s.entries) }
+func (s *mapStash) Serialize() ([]byte, error) { return nil, nil }
+
+func TestStash_SyncsOtherImplementations(t *testing.T) {
+	s := &mapStash{entries: make(map[int]StashEntry)}
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 2, StashLimit: 200, Stash: s}
+	oram, err := NewInMemory(cfg)
+	if err != nil {
+		t.Fatalf("NewInMemory: %v", err)
+	}
+	used := false
+	for i := range 200 {
+		if _, err := oram.Write(i%64, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if s.Len() != oram.StashSize() {
+			t.Fatalf("access %d: Stash.Len = %d, StashSize = %d", i, s.Len(), oram.StashSize())
+		}
+		used = used || s.Len() > 0
+		for _, b := range oram.stash {
+			e, ok := s.entries[b.id]
+			if !ok || e.Leaf != b.leaf || !bytes.Equal(e.Data, b.data) {
+				t.Fatalf("access %d: block %d missing or stale in Stash", i, b.id)
+			}
+		}
+	}
+	if !used {
+		t.Fatal("stash stayed empty; test does not exercise syncing")
+	}
+
+	// Nothing changed since the last access, so syncing makes no calls
+	s.calls = 0
+	if err := oram.syncStash(); err != nil || s.calls != 0 {
+		t.Errorf("idle sync made %d calls, err %v", s.calls, err)
+	}
+}
+
+func TestStash_Restart(t *testing.T) {
+	// Small buckets leave blocks in the stash, which a restart must keep
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 2, StashLimit: 200, CachedLevels: 2, Rand: NewSeededRand([32]byte{1})}
+	cfg, _ = cfg.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total+PositionMapBuckets(cfg, NoOpEncryptor{}), cfg.BucketSize, cfg.BlockSize)
+	pm := NewStoredPositionMap(NewInMemoryPositionMap(), cfg.NumBlocks, total, NoOpEncryptor{})
+	stash := NewSliceStash()
+	cfg.Stash = stash
+	oram, err := New(cfg, storage, pm, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+	}
+	if stash.Len() == 0 {
+		t.Fatal("stash is empty; test does not exercise a restart with stashed blocks")
+	}
+	if err := pm.SaveTo(storage); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+	snapshot, _ := stash.Serialize()
+
+	restored, err := DeserializeSliceStash(snapshot)
+	if err != nil {
+		t.Fatalf("DeserializeSliceStash: %v", err)
+	}
+	pm2 := NewStoredPositionMap(NewInMemoryPositionMap(), cfg.NumBlocks, total, NoOpEncryptor{})
+	if err := pm2.LoadFrom(storage); err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	cfg.Stash = restored
+	oram2, err := New(cfg, storage, pm2, NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New after restart: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		got, err := oram2.ReadCtx(context.Background(), i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Fatalf("Read(%d) after restart = %x, %v", i, got, err)
+		}
+	}
+}
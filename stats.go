@@ -0,0 +1,138 @@
+package pathoram
+
+import (
+	"context"
+	"time"
+)
+
+// Stats holds cumulative counters for a PathORAM instance.
+// Use them to tune Z, StashLimit, and EvictionStrategy.
+type Stats struct {
+	Accesses           uint64 // completed logical accesses (including dummy/drain passes)
+	BucketReads        uint64 // ReadBucket calls
+	BucketWrites       uint64 // WriteBucket calls
+	BytesRead          uint64 // block data bytes read from storage
+	BytesWritten       uint64 // block data bytes written to storage
+	Evictions          uint64 // path (or multi-path) eviction passes
+	DecryptionFailures uint64 // blocks that failed to decrypt
+	StashHighWater     int    // largest stash size observed
+	PadOverruns        uint64 // accesses that took longer than Config.UniformAccessTime
+	SlotReads          uint64 // single slots read online (Config.SingleBlockReads)
+	AsyncConflicts     uint64 // AccessAsync requests for a block already requested in their group
+}
+
+// StatsCollector receives events as they happen, e.g. to feed an external
+// metrics system. Set it via Config.StatsCollector. Methods are called
+// synchronously on the access path and must be cheap.
+type StatsCollector interface {
+	OnAccess()
+	OnBucketRead(bytes int)
+	OnBucketWrite(bytes int)
+	OnEviction()
+	OnDecryptionFailure()
+	OnStashSize(size int)
+}
+
+// AccessObserver is an optional extension of StatsCollector. If the configured
+// collector implements it, it is told the duration and outcome of every
+// Read, Write, Access, and WriteBatch call, including failed ones.
+type AccessObserver interface {
+	OnAccessDone(d time.Duration, err error)
+}
+
+// Stats returns a snapshot of the instance's counters.
+func (o *PathORAM) Stats() Stats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stats
+}
+
+// blockBytes sums the data length of blocks.
+func blockBytes(blocks []Block) int {
+	n := 0
+	for _, b := range blocks {
+		n += len(b.Data)
+	}
+	return n
+}
+
+func (o *PathORAM) noteAccess() {
+	o.stats.Accesses++
+	if c := o.cfg.StatsCollector; c != nil {
+		c.OnAccess()
+	}
+}
+
+func (o *PathORAM) noteBucketRead(blocks []Block) {
+	n := blockBytes(blocks)
+	o.stats.BucketReads++
+	o.stats.BytesRead += uint64(n)
+	if c := o.cfg.StatsCollector; c != nil {
+		c.OnBucketRead(n)
+	}
+}
+
+func (o *PathORAM) noteBucketWrite(blocks []Block) {
+	n := blockBytes(blocks)
+	o.stats.BucketWrites++
+	o.stats.BytesWritten += uint64(n)
+	if c := o.cfg.StatsCollector; c != nil {
+		c.OnBucketWrite(n)
+	}
+}
+
+func (o *PathORAM) noteEviction() {
+	o.stats.Evictions++
+	if c := o.cfg.StatsCollector; c != nil {
+		c.OnEviction()
+	}
+}
+
+func (o *PathORAM) noteDecryptionFailure() {
+	o.stats.DecryptionFailures++
+	if c := o.cfg.StatsCollector; c != nil {
+		c.OnDecryptionFailure()
+	}
+}
+
+// noteStash records the current stash size; call it whenever the stash grows
+// and after each completed access.
+func (o *PathORAM) noteStash() {
+	if len(o.stash) > o.stats.StashHighWater {
+		o.stats.StashHighWater = len(o.stash)
+	}
+	if c := o.cfg.StatsCollector; c != nil {
+		c.OnStashSize(len(o.stash))
+	}
+}
+
+// observedAccess runs access and reports its outcome via finishAccess.
+func (o *PathORAM) observedAccess(ctx context.Context, blockID int, newData, dst []byte) ([]byte, error) {
+	defer o.padAccess(ctx, time.Now(), 1)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.observe(ctx, blockID, newData, dst)
+}
+
+// observe is observedAccess for callers already holding o.mu.
+func (o *PathORAM) observe(ctx context.Context, blockID int, newData, dst []byte) ([]byte, error) {
+	start := time.Now()
+	result, err := o.access(ctx, blockID, newData, dst)
+	o.finishAccess(start, err)
+	if err == nil {
+		o.noteNamespace(ctx, false)
+	}
+	return result, err
+}
+
+// finishAccess reports a completed access to an AccessObserver, if any, and
+// logs failures to the configured logger.
+func (o *PathORAM) finishAccess(start time.Time, err error) {
+	if obs, ok := o.cfg.StatsCollector.(AccessObserver); ok {
+		obs.OnAccessDone(time.Since(start), err)
+	}
+	if err != nil && o.cfg.Logger != nil {
+		o.cfg.Logger.Warn("pathoram: access failed",
+			"err", err, "stash", len(o.stash), "stash_limit", o.cfg.StashLimit)
+	}
+}
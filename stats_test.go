@@ -0,0 +1,93 @@
+package pathoram
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+// recordingCollector counts StatsCollector events.
+type recordingCollector struct {
+	accesses, reads, writes, evictions, decFailures int
+	maxStash                                        int
+}
+
+func (c *recordingCollector) OnAccess()            { c.accesses++ }
+func (c *recordingCollector) OnBucketRead(int)     { c.reads++ }
+func (c *recordingCollector) OnBucketWrite(int)    { c.writes++ }
+func (c *recordingCollector) OnEviction()          { c.evictions++ }
+func (c *recordingCollector) OnDecryptionFailure() { c.decFailures++ }
+func (c *recordingCollector) OnStashSize(n int) {
+	if n > c.maxStash {
+		c.maxStash = n
+	}
+}
+
+func TestStats(t *testing.T) {
+	collector := &recordingCollector{}
+	cfg := Config{NumBlocks: 32, BlockSize: 16, BucketSize: 4, EvictionStrategy: EvictGreedyByDepth, StatsCollector: collector}
+	oram, _ := NewInMemory(cfg)
+
+	for i := 0; i < 10; i++ {
+		oram.Write(i, make([]byte, 16))
+	}
+	oram.Read(3)
+
+	s := oram.Stats()
+	if s.Accesses != 11 {
+		t.Errorf("Accesses = %d, want 11", s.Accesses)
+	}
+	if s.Evictions != 11 {
+		t.Errorf("Evictions = %d, want 11", s.Evictions)
+	}
+	// Each access reads the path twice (fetch + eviction) and writes it twice
+	wantIO := uint64(11 * 2 * oram.Height())
+	if s.BucketReads != wantIO || s.BucketWrites != wantIO {
+		t.Errorf("BucketReads/Writes = %d/%d, want %d", s.BucketReads, s.BucketWrites, wantIO)
+	}
+	if s.BytesRead != s.BucketReads*4*16 {
+		t.Errorf("BytesRead = %d, want %d", s.BytesRead, s.BucketReads*4*16)
+	}
+	if s.StashHighWater < 1 {
+		t.Errorf("StashHighWater = %d, want >= 1", s.StashHighWater)
+	}
+
+	if collector.accesses != int(s.Accesses) || collector.reads != int(s.BucketReads) ||
+		collector.writes != int(s.BucketWrites) || collector.evictions != int(s.Evictions) ||
+		collector.maxStash != s.StashHighWater {
+		t.Errorf("collector %+v disagrees with Stats %+v", collector, s)
+	}
+}
+
+func TestStats_DecryptionFailures(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	enc, _ := NewAESGCMEncryptor(key)
+
+	cfg := Config{NumBlocks: 16, BlockSize: 16, BucketSize: 4}
+	cfg, _ = cfg.Validate()
+	_, _, totalBuckets := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(totalBuckets, cfg.BucketSize, cfg.BlockSize+enc.Overhead())
+	oram, _ := New(cfg, storage, NewInMemoryPositionMap(), enc)
+
+	for i := 0; i < 16; i++ {
+		oram.Write(i, make([]byte, 16))
+	}
+
+	// Corrupt every stored ciphertext
+	for idx := 0; idx < totalBuckets; idx++ {
+		bucket, _ := storage.ReadBucket(idx)
+		for i := range bucket {
+			if bucket[i].ID != EmptyBlockID {
+				bucket[i].Data[0] ^= 0xFF
+			}
+		}
+		storage.WriteBucket(idx, bucket)
+	}
+
+	for i := 0; i < 16 && oram.Stats().DecryptionFailures == 0; i++ {
+		oram.Read(i)
+	}
+	if oram.Stats().DecryptionFailures == 0 {
+		t.Error("DecryptionFailures = 0 after tampering, want > 0")
+	}
+}
@@ -1,5 +1,10 @@
 package pathoram
 
+import (
+	"bytes"
+	"context"
+)
+
 // Storage provides block-level access to the ORAM tree structure.
 // Implementations may store data in memory, files, or remote services.
 type Storage interface {
@@ -19,6 +24,43 @@ type Storage interface {
 	BlockSize() int
 }
 
+// StorageCtx is an optional extension of Storage for backends that can honor
+// context cancellation and deadlines (e.g., disk or network storage).
+// PathORAM uses these methods instead of ReadBucket/WriteBucket when available.
+type StorageCtx interface {
+	Storage
+
+	// ReadBucketCtx is like ReadBucket but honors ctx.
+	ReadBucketCtx(ctx context.Context, idx int) ([]Block, error)
+
+	// WriteBucketCtx is like WriteBucket but honors ctx.
+	WriteBucketCtx(ctx context.Context, idx int, blocks []Block) error
+}
+
+// StorageCapabilities describes a backend's limits. Zero values mean no limit.
+type StorageCapabilities struct {
+	MaxBlockSize int // largest Block.Data (ciphertext) the backend can store in one block
+}
+
+// CapableStorage is an optional extension of Storage for backends with size
+// limits, such as object stores with a maximum object size. New rejects
+// configurations whose encrypted blocks would exceed them.
+type CapableStorage interface {
+	Storage
+
+	// Capabilities returns the backend's limits.
+	Capabilities() StorageCapabilities
+}
+
+// Capabilities returns s's limits, or the zero value (no limits) if s does
+// not implement CapableStorage.
+func Capabilities(s Storage) StorageCapabilities {
+	if c, ok := s.(CapableStorage); ok {
+		return c.Capabilities()
+	}
+	return StorageCapabilities{}
+}
+
 // Block represents a single data block in storage.
 // For encrypted storage, Data contains ciphertext.
 type Block struct {
@@ -32,6 +74,9 @@ type InMemoryStorage struct {
 	buckets    [][]Block
 	bucketSize int
 	blockSize  int
+	counter    []byte      // sealed access counter (see CounterStorage)
+	keyFP      []byte      // key fingerprint (see FingerprintStorage)
+	hashes     [][2][]byte // per-bucket content and node hashes (see IntegrityStorage)
 }
 
 // NewInMemoryStorage creates a new in-memory storage with the given dimensions.
@@ -106,3 +151,100 @@ func (s *InMemoryStorage) BucketSize() int {
 func (s *InMemoryStorage) BlockSize() int {
 	return s.blockSize
 }
+
+// ReadSlots returns a copy of slot slots[i] of the bucket at idxs[i] (see
+// SlotStorage).
+func (s *InMemoryStorage) ReadSlots(ctx context.Context, idxs, slots []int) ([]Block, error) {
+	if len(idxs) != len(slots) {
+		return nil, ErrInvalidConfig
+	}
+	blocks := make([]Block, len(idxs))
+	for i, idx := range idxs {
+		if idx < 0 || idx >= len(s.buckets) || slots[i] < 0 || slots[i] >= s.bucketSize {
+			return nil, ErrInvalidConfig
+		}
+		b := s.buckets[idx][slots[i]]
+		blocks[i] = Block{ID: b.ID, Leaf: b.Leaf, Data: bytes.Clone(b.Data)}
+	}
+	return blocks, nil
+}
+
+// ReadSlotsXOR returns the XOR of slot slots[i] of the bucket at idxs[i]
+// over all i (see XORCapableStorage).
+func (s *InMemoryStorage) ReadSlotsXOR(ctx context.Context, idxs, slots []int) ([]byte, error) {
+	blocks, err := s.ReadSlots(ctx, idxs, slots)
+	if err != nil {
+		return nil, err
+	}
+	return XORSlots(blocks), nil
+}
+
+// Resize grows or shrinks storage to numBuckets. New buckets are empty.
+func (s *InMemoryStorage) Resize(numBuckets int) error {
+	if numBuckets <= 0 {
+		return ErrInvalidConfig
+	}
+	for len(s.buckets) < numBuckets {
+		bucket := make([]Block, s.bucketSize)
+		for j := range bucket {
+			bucket[j] = Block{ID: EmptyBlockID, Leaf: -1, Data: make([]byte, s.blockSize)}
+		}
+		s.buckets = append(s.buckets, bucket)
+	}
+	s.buckets = s.buckets[:numBuckets]
+	if len(s.hashes) > numBuckets {
+		s.hashes = s.hashes[:numBuckets]
+	}
+	return nil
+}
+
+// ReadBucketHash returns the hashes stored for bucket idx.
+func (s *InMemoryStorage) ReadBucketHash(idx int) ([]byte, []byte, error) {
+	if idx < 0 || idx >= len(s.buckets) {
+		return nil, nil, ErrInvalidConfig
+	}
+	if idx >= len(s.hashes) {
+		return nil, nil, nil
+	}
+	return bytes.Clone(s.hashes[idx][0]), bytes.Clone(s.hashes[idx][1]), nil
+}
+
+// WriteBucketHash stores the hashes for bucket idx.
+func (s *InMemoryStorage) WriteBucketHash(idx int, content, node []byte) error {
+	if idx < 0 || idx >= len(s.buckets) {
+		return ErrInvalidConfig
+	}
+	if idx >= len(s.hashes) {
+		s.hashes = append(s.hashes, make([][2][]byte, len(s.buckets)-len(s.hashes))...)
+	}
+	s.hashes[idx] = [2][]byte{bytes.Clone(content), bytes.Clone(node)}
+	return nil
+}
+
+// ReadCounter returns a copy of the sealed access counter, or nil if unset.
+func (s *InMemoryStorage) ReadCounter() ([]byte, error) {
+	if s.counter == nil {
+		return nil, nil
+	}
+	result := make([]byte, len(s.counter))
+	copy(result, s.counter)
+	return result, nil
+}
+
+// WriteCounter stores a copy of the sealed access counter.
+func (s *InMemoryStorage) WriteCounter(sealed []byte) error {
+	s.counter = make([]byte, len(sealed))
+	copy(s.counter, sealed)
+	return nil
+}
+
+// ReadFingerprint returns a copy of the key fingerprint, or nil if unset.
+func (s *InMemoryStorage) ReadFingerprint() ([]byte, error) {
+	return bytes.Clone(s.keyFP), nil
+}
+
+// WriteFingerprint stores a copy of the key fingerprint.
+func (s *InMemoryStorage) WriteFingerprint(fp []byte) error {
+	s.keyFP = bytes.Clone(fp)
+	return nil
+}
@@ -0,0 +1,150 @@
+package pathoram
+
+import "context"
+
+// StorageV2 is the context-aware storage interface the core ORAM uses. Every
+// bucket read and write carries the caller's context, so backends can apply
+// deadlines, cancellation and tracing, and the batch methods let remote
+// backends read or write a whole path in one round trip.
+//
+// Storage backends keep working: New adapts them with AdaptStorage. A
+// StorageV2 backend is passed to New through StorageFromV2 or WithStorageV2.
+// The optional extensions (ResizableStorage, CounterStorage,
+// IntegrityStorage, FingerprintStorage, CapableStorage, BatchStorage) are
+// still Storage interfaces, so only backends implementing Storage can
+// provide them.
+type StorageV2 interface {
+	// ReadBucket returns all blocks in the bucket at idx.
+	ReadBucket(ctx context.Context, idx int) ([]Block, error)
+
+	// WriteBucket writes all blocks to the bucket at idx.
+	WriteBucket(ctx context.Context, idx int, blocks []Block) error
+
+	// ReadBuckets returns the buckets at idxs, in the same order.
+	ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error)
+
+	// WriteBuckets writes buckets[i] to the bucket at idxs[i].
+	WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error
+
+	// NumBuckets returns the total number of buckets in storage.
+	NumBuckets() int
+
+	// BucketSize returns the number of block slots per bucket.
+	BucketSize() int
+
+	// BlockSize returns the size of each block's data in bytes.
+	BlockSize() int
+}
+
+// BatchStorage is an optional Storage extension for backends that can read
+// or write several buckets in one round trip or transaction. AdaptStorage
+// routes StorageV2's batch methods to it; the ORAM reads each path, and
+// writes back each path it evicts in one piece, with these calls.
+type BatchStorage interface {
+	Storage
+
+	// ReadBuckets returns the buckets at idxs, in the same order.
+	ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error)
+
+	// WriteBuckets writes buckets[i] to the bucket at idxs[i], all or
+	// nothing where the backend can manage it.
+	WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error
+}
+
+// AdaptStorage returns s as a StorageV2. Backends implementing StorageCtx
+// receive the context; others have it checked before each call. The batch
+// methods use BatchStorage if s implements it and otherwise read or write
+// one bucket at a time. A Storage returned by StorageFromV2 is unwrapped.
+func AdaptStorage(s Storage) StorageV2 {
+	if v, ok := s.(storageFromV2); ok {
+		return v.s
+	}
+	return storageAdapter{s}
+}
+
+// storageAdapter implements StorageV2 over a Storage.
+type storageAdapter struct {
+	s Storage
+}
+
+func (a storageAdapter) ReadBucket(ctx context.Context, idx int) ([]Block, error) {
+	if s, ok := a.s.(StorageCtx); ok {
+		return s.ReadBucketCtx(ctx, idx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.s.ReadBucket(idx)
+}
+
+func (a storageAdapter) WriteBucket(ctx context.Context, idx int, blocks []Block) error {
+	if s, ok := a.s.(StorageCtx); ok {
+		return s.WriteBucketCtx(ctx, idx, blocks)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.s.WriteBucket(idx, blocks)
+}
+
+func (a storageAdapter) ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error) {
+	if s, ok := a.s.(BatchStorage); ok {
+		return s.ReadBuckets(ctx, idxs)
+	}
+	buckets := make([][]Block, len(idxs))
+	for i, idx := range idxs {
+		var err error
+		if buckets[i], err = a.ReadBucket(ctx, idx); err != nil {
+			return nil, err
+		}
+	}
+	return buckets, nil
+}
+
+func (a storageAdapter) WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error {
+	if s, ok := a.s.(BatchStorage); ok {
+		return s.WriteBuckets(ctx, idxs, buckets)
+	}
+	for i, idx := range idxs {
+		if err := a.WriteBucket(ctx, idx, buckets[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a storageAdapter) NumBuckets() int { return a.s.NumBuckets() }
+func (a storageAdapter) BucketSize() int { return a.s.BucketSize() }
+func (a storageAdapter) BlockSize() int  { return a.s.BlockSize() }
+
+// StorageFromV2 returns s as a Storage, for New and other APIs that take
+// one. The ORAM core unwraps it and calls s directly with each operation's
+// context; the Storage methods themselves use context.Background.
+func StorageFromV2(s StorageV2) Storage {
+	return storageFromV2{s}
+}
+
+// storageFromV2 implements Storage and StorageCtx over a StorageV2.
+type storageFromV2 struct {
+	s StorageV2
+}
+
+func (v storageFromV2) ReadBucket(idx int) ([]Block, error) {
+	return v.s.ReadBucket(context.Background(), idx)
+}
+
+func (v storageFromV2) WriteBucket(idx int, blocks []Block) error {
+	return v.s.WriteBucket(context.Background(), idx, blocks)
+}
+
+func (v storageFromV2) ReadBucketCtx(ctx context.Context, idx int) ([]Block, error) {
+	return v.s.ReadBucket(ctx, idx)
+}
+
+func (v storageFromV2) WriteBucketCtx(ctx context.Context, idx int, blocks []Block) error {
+	return v.s.WriteBucket(ctx, idx, blocks)
+}
+
+func (v storageFromV2) NumBuckets() int { return v.s.NumBuckets() }
+func (v storageFromV2) BucketSize() int { return v.s.BucketSize() }
+func (v storageFromV2) BlockSize() int  { return v.s.BlockSize() }
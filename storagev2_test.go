@@ -0,0 +1,125 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+type traceKey struct{}
+
+// v2Storage is a native StorageV2 backend over InMemoryStorage that records
+// batch calls and the trace IDs carried by each context.
+type v2Storage struct {
+	mem        *InMemoryStorage
+	batchReads int
+	unlabelled int
+}
+
+func (s *v2Storage) note(ctx context.Context) {
+	if ctx.Value(traceKey{}) == nil {
+		s.unlabelled++
+	}
+}
+
+func (s *v2Storage) ReadBucket(ctx context.Context, idx int) ([]Block, error) {
+	s.note(ctx)
+	return s.mem.ReadBucket(idx)
+}
+
+func (s *v2Storage) WriteBucket(ctx context.Context, idx int, blocks []Block) error {
+	s.note(ctx)
+	return s.mem.WriteBucket(idx, blocks)
+}
+
+func (s *v2Storage) ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error) {
+	s.batchReads++
+	return AdaptStorage(s.mem).ReadBuckets(ctx, idxs)
+}
+
+func (s *v2Storage) WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error {
+	return AdaptStorage(s.mem).WriteBuckets(ctx, idxs, buckets)
+}
+
+func (s *v2Storage) NumBuckets() int { return s.mem.NumBuckets() }
+func (s *v2Storage) BucketSize() int { return s.mem.BucketSize() }
+func (s *v2Storage) BlockSize() int  { return s.mem.BlockSize() }
+
+func TestStorageV2(t *testing.T) {
+	cfg, _ := Config{NumBlocks: 32, BlockSize: 16}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := &v2Storage{mem: NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)}
+	oram, err := NewORAM(WithConfig(cfg), WithStorageV2(storage))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), traceKey{}, "req-1")
+	data := bytes.Repeat([]byte{7}, 16)
+	if _, err := oram.WriteCtx(ctx, 5, data); err != nil {
+		t.Fatalf("WriteCtx failed: %v", err)
+	}
+	if got, err := oram.ReadCtx(ctx, 5); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadCtx = %x, %v", got, err)
+	}
+	if storage.batchReads != 2 {
+		t.Errorf("path reads used ReadBuckets %d times, want 2", storage.batchReads)
+	}
+	if storage.unlabelled != 0 {
+		t.Errorf("%d storage calls did not carry the caller's context", storage.unlabelled)
+	}
+}
+
+func TestAdaptStorage(t *testing.T) {
+	mem := NewInMemoryStorage(3, 2, 4)
+	v2 := AdaptStorage(mem)
+	if AdaptStorage(StorageFromV2(v2)) != v2 {
+		t.Error("AdaptStorage did not unwrap StorageFromV2")
+	}
+	full := []Block{{ID: 1, Leaf: 0, Data: []byte("abcd")}, {ID: 2, Leaf: 1, Data: []byte("efgh")}}
+	if err := v2.WriteBuckets(context.Background(), []int{2}, [][]Block{full}); err != nil {
+		t.Fatalf("WriteBuckets failed: %v", err)
+	}
+	got, err := v2.ReadBuckets(context.Background(), []int{2, 0})
+	if err != nil || len(got) != 2 || got[0][1].ID != 2 || got[1][0].ID != EmptyBlockID {
+		t.Errorf("ReadBuckets = %v, %v", got, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := v2.ReadBucket(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadBucket with cancelled ctx: got %v, want context.Canceled", err)
+	}
+}
+
+// batchStorage is a Storage implementing BatchStorage that records the
+// batch writes it receives.
+type batchStorage struct {
+	*InMemoryStorage
+	writes [][]int
+}
+
+func (s *batchStorage) ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error) {
+	return AdaptStorage(s.InMemoryStorage).ReadBuckets(ctx, idxs)
+}
+
+func (s *batchStorage) WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error {
+	s.writes = append(s.writes, append([]int(nil), idxs...))
+	return AdaptStorage(s.InMemoryStorage).WriteBuckets(ctx, idxs, buckets)
+}
+
+func TestBatchStorage_PathWriteBack(t *testing.T) {
+	cfg, _ := Config{NumBlocks: 32, BlockSize: 16, EvictionStrategy: EvictGreedyByDepth}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := &batchStorage{InMemoryStorage: NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize)}
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := oram.Write(3, bytes.Repeat([]byte{1}, 16)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(storage.writes) != 1 || len(storage.writes[0]) != oram.Height() {
+		t.Errorf("WriteBuckets calls = %v, want one per eviction covering the path", storage.writes)
+	}
+}
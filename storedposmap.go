@@ -0,0 +1,179 @@
+package pathoram
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// PositionMapStore is implemented by position maps that can persist
+// themselves into a Storage backend, so a client restart against disk or
+// remote storage does not lose every block→leaf mapping.
+type PositionMapStore interface {
+	// SaveTo writes the position map to s.
+	SaveTo(s Storage) error
+
+	// LoadFrom sets the entries last saved to s. Returns ErrNoPositionMap
+	// if none were saved.
+	LoadFrom(s Storage) error
+}
+
+// storedPosMapMagic identifies a saved position map.
+const storedPosMapMagic = "PMAP"
+
+// storedPosMapLeaf is the leaf passed to the encryptor for position map
+// chunks. Tree blocks use leaves >= 0 and empty slots -1, so a chunk cannot
+// be passed off as a tree block or vice versa.
+const storedPosMapLeaf = -2
+
+// StoredPositionMap is a PositionMap that saves itself, encrypted, into
+// dedicated buckets of the Storage backend past the end of the tree. Every
+// entry is saved, set or not, so the saved size depends only on NumBlocks.
+//
+// The map is serialized as 4 bytes per block, split into chunks that fill one
+// storage block each, and each chunk is encrypted with the chunk index as the
+// block ID, so the server cannot reorder or truncate a saved map without
+// detection. It can still replay an older saved map; pair it with a
+// ReplayProtector to detect that.
+//
+// Saved positions are only useful together with the stash: Flush the ORAM
+// and stop accessing it before SaveTo, or keep the stash in a persistent
+// Config.Stash (with CachedLevels the stash never empties). Resize moves the
+// end of the tree, so save again after resizing.
+type StoredPositionMap struct {
+	PositionMap
+	numBlocks   int
+	firstBucket int
+	enc         Encryptor
+}
+
+var _ PositionMapStore = (*StoredPositionMap)(nil)
+
+// NewStoredPositionMap wraps pm to save and load block IDs 0 to numBlocks-1
+// in the buckets starting at firstBucket, usually the tree's totalBuckets
+// (see Config.ComputeTreeParams). Allocate PositionMapBuckets more buckets
+// than the tree needs to make room.
+func NewStoredPositionMap(pm PositionMap, numBlocks, firstBucket int, enc Encryptor) *StoredPositionMap {
+	return &StoredPositionMap{PositionMap: pm, numBlocks: numBlocks, firstBucket: firstBucket, enc: enc}
+}
+
+// Delete removes blockID's position from the wrapped map, if it is a
+// DeletablePositionMap; otherwise the position stays.
+func (p *StoredPositionMap) Delete(blockID int) {
+	if dm, ok := p.PositionMap.(DeletablePositionMap); ok {
+		dm.Delete(blockID)
+	}
+}
+
+// PositionMapBuckets returns how many buckets StoredPositionMap needs for
+// cfg's block count on storage created for cfg and enc.
+func PositionMapBuckets(cfg Config, enc Encryptor) int {
+	cfg, err := cfg.Validate()
+	if err != nil {
+		return 0
+	}
+	chunk := cfg.storedSize(enc) - enc.Overhead()
+	chunks := storedPosMapChunks(cfg.NumBlocks, chunk)
+	return (chunks + cfg.BucketSize - 1) / cfg.BucketSize
+}
+
+// storedPosMapChunks returns the number of chunks of size chunk holding the
+// serialized map for numBlocks blocks.
+func storedPosMapChunks(numBlocks, chunk int) int {
+	return (8 + 4*numBlocks + chunk - 1) / chunk
+}
+
+// chunkSize returns the plaintext bytes per chunk for s.
+func (p *StoredPositionMap) chunkSize(s Storage) (int, error) {
+	chunk := s.BlockSize() - p.enc.Overhead()
+	if chunk <= 0 {
+		return 0, ErrInvalidConfig
+	}
+	chunks := storedPosMapChunks(p.numBlocks, chunk)
+	if p.firstBucket < 0 || p.firstBucket+(chunks+s.BucketSize()-1)/s.BucketSize() > s.NumBuckets() {
+		return 0, fmt.Errorf("%w: storage has no room for the position map", ErrInvalidConfig)
+	}
+	return chunk, nil
+}
+
+// SaveTo encrypts the position map and writes it to s.
+func (p *StoredPositionMap) SaveTo(s Storage) error {
+	chunk, err := p.chunkSize(s)
+	if err != nil {
+		return err
+	}
+	chunks := storedPosMapChunks(p.numBlocks, chunk)
+	buf := make([]byte, chunks*chunk)
+	copy(buf, storedPosMapMagic)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(p.numBlocks))
+	for id := range p.numBlocks {
+		if leaf, ok := p.Get(id); ok {
+			binary.LittleEndian.PutUint32(buf[8+4*id:], uint32(leaf+1))
+		}
+	}
+
+	z := s.BucketSize()
+	for first := 0; first < chunks; first += z {
+		bucket := make([]Block, z)
+		for j := range bucket {
+			i := first + j
+			if i >= chunks {
+				bucket[j] = Block{ID: EmptyBlockID, Leaf: -1, Data: make([]byte, s.BlockSize())}
+				continue
+			}
+			ct, err := p.enc.Encrypt(i, storedPosMapLeaf, buf[i*chunk:(i+1)*chunk])
+			if err != nil {
+				return err
+			}
+			bucket[j] = Block{ID: i, Leaf: storedPosMapLeaf, Data: ct}
+		}
+		if err := s.WriteBucket(p.firstBucket+first/z, bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFrom reads and decrypts the position map saved in s and sets every
+// saved entry in the wrapped map. A chunk that fails to decrypt or is out of
+// place returns ErrCorruptObject.
+func (p *StoredPositionMap) LoadFrom(s Storage) error {
+	chunk, err := p.chunkSize(s)
+	if err != nil {
+		return err
+	}
+	chunks := storedPosMapChunks(p.numBlocks, chunk)
+	buf := make([]byte, 0, chunks*chunk)
+	z := s.BucketSize()
+	for first := 0; first < chunks; first += z {
+		bucket, err := s.ReadBucket(p.firstBucket + first/z)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < z && first+j < chunks; j++ {
+			i := first + j
+			b := bucket[j]
+			if i == 0 && b.ID == EmptyBlockID {
+				return ErrNoPositionMap
+			}
+			if b.ID != i || b.Leaf != storedPosMapLeaf {
+				return fmt.Errorf("%w: position map chunk %d out of place", ErrCorruptObject, i)
+			}
+			pt, err := p.enc.Decrypt(i, storedPosMapLeaf, b.Data)
+			if err != nil {
+				return fmt.Errorf("%w: position map chunk %d: %w", ErrCorruptObject, i, err)
+			}
+			buf = append(buf, pt...)
+		}
+	}
+	if len(buf) < 8 || string(buf[:4]) != storedPosMapMagic ||
+		binary.LittleEndian.Uint32(buf[4:]) != uint32(p.numBlocks) {
+		return fmt.Errorf("%w: position map header mismatch", ErrCorruptObject)
+	}
+
+	for id := range p.numBlocks {
+		if v := binary.LittleEndian.Uint32(buf[8+4*id:]); v != 0 {
+			p.Set(id, int(v)-1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStoredPositionMap_Restart(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	enc, err := NewAESGCMEncryptor(key)
+	if err != nil {
+		t.Fatalf("NewAESGCMEncryptor: %v", err)
+	}
+	cfg := Config{NumBlocks: 100, BlockSize: 16, BucketSize: 4, StashLimit: 200}
+	cfg, _ = cfg.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	storage := NewInMemoryStorage(total+PositionMapBuckets(cfg, enc), cfg.BucketSize, cfg.BlockSize+enc.Overhead())
+
+	pm := NewStoredPositionMap(NewInMemoryPositionMap(), cfg.NumBlocks, total, enc)
+	oram, err := New(cfg, storage, pm, enc)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+	}
+	if err := oram.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := pm.SaveTo(storage); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+
+	// Restart: a fresh client with the same key and storage
+	enc2, _ := NewAESGCMEncryptor(key)
+	pm2 := NewStoredPositionMap(NewInMemoryPositionMap(), cfg.NumBlocks, total, enc2)
+	if err := pm2.LoadFrom(storage); err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	oram2, err := New(cfg, storage, pm2, enc2)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := range cfg.NumBlocks {
+		got, err := oram2.Read(i)
+		if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i)}, 16)) {
+			t.Fatalf("Read(%d) after restart = %x, %v", i, got, err)
+		}
+	}
+}
+
+func TestStoredPositionMap_Errors(t *testing.T) {
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{7}, 32))
+	cfg := Config{NumBlocks: 50, BlockSize: 16, BucketSize: 4}
+	storage := NewInMemoryStorage(PositionMapBuckets(cfg, enc), 4, 16+enc.Overhead())
+	pm := NewStoredPositionMap(NewInMemoryPositionMap(), cfg.NumBlocks, 0, enc)
+
+	if err := pm.LoadFrom(storage); !errors.Is(err, ErrNoPositionMap) {
+		t.Fatalf("LoadFrom before SaveTo = %v, want ErrNoPositionMap", err)
+	}
+	pm.Set(3, 9)
+	if err := pm.SaveTo(storage); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+
+	// Swapping two chunks must be detected
+	b0, _ := storage.ReadBucket(0)
+	b0[0].Data, b0[1].Data = b0[1].Data, b0[0].Data
+	storage.WriteBucket(0, b0)
+	if err := pm.LoadFrom(storage); !errors.Is(err, ErrCorruptObject) {
+		t.Fatalf("LoadFrom swapped chunks = %v, want ErrCorruptObject", err)
+	}
+
+	small := NewInMemoryStorage(1, 4, 16+enc.Overhead())
+	if err := pm.SaveTo(small); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("SaveTo too small = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,110 @@
+package pathoram
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// streamHeaderSize is the length prefix at the start of every stream chunk.
+const streamHeaderSize = 4
+
+// StreamCapacity returns the number of stream bytes n blocks hold with
+// WriteStream: each block carries a 4-byte length and BlockSize-4 bytes of
+// data.
+func (o *PathORAM) StreamCapacity(n int) int64 {
+	return int64(n) * int64(o.cfg.BlockSize-streamHeaderSize)
+}
+
+// WriteStream stores the contents of r across blockIDs, in order, and
+// returns the number of bytes stored. Each block holds a length-prefixed
+// chunk; a chunk shorter than BlockSize-4 bytes ends the stream. Every block
+// in blockIDs is written, those past the end with empty chunks, so the
+// number of accesses does not depend on the stream's length.
+//
+// If r holds more than StreamCapacity(len(blockIDs)) bytes, WriteStream
+// stores that many and returns ErrStreamTooLong; ReadStream then returns the
+// truncated prefix.
+func (o *PathORAM) WriteStream(blockIDs []int, r io.Reader) (int64, error) {
+	return o.WriteStreamCtx(context.Background(), blockIDs, r)
+}
+
+// WriteStreamCtx is like WriteStream but propagates ctx to each access.
+func (o *PathORAM) WriteStreamCtx(ctx context.Context, blockIDs []int, r io.Reader) (int64, error) {
+	if o.cfg.BlockSize <= streamHeaderSize {
+		return 0, fmt.Errorf("%w: BlockSize must exceed %d bytes for streams", ErrInvalidConfig, streamHeaderSize)
+	}
+	chunk := make([]byte, o.cfg.BlockSize)
+	var total int64
+	done := false
+	for _, id := range blockIDs {
+		n := 0
+		if !done {
+			var err error
+			n, err = io.ReadFull(r, chunk[streamHeaderSize:])
+			switch err {
+			case nil:
+			case io.EOF, io.ErrUnexpectedEOF:
+				done = true
+			default:
+				return total, err
+			}
+		}
+		clear(chunk[streamHeaderSize+n:])
+		binary.BigEndian.PutUint32(chunk, uint32(n))
+		if _, err := o.WriteCtx(ctx, id, chunk); err != nil {
+			return total, err
+		}
+		total += int64(n)
+	}
+	if !done {
+		// Every chunk was full: the stream fits only if r is now empty
+		var probe [1]byte
+		if n, err := io.ReadFull(r, probe[:]); n > 0 {
+			return total, ErrStreamTooLong
+		} else if err != io.EOF {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// ReadStream writes the stream stored by WriteStream in blockIDs to w and
+// returns the number of bytes written. It reads every block in blockIDs,
+// including those past the end of the stream, so the number of accesses
+// does not depend on the stream's length. Blocks never written read as an
+// empty stream.
+func (o *PathORAM) ReadStream(blockIDs []int, w io.Writer) (int64, error) {
+	return o.ReadStreamCtx(context.Background(), blockIDs, w)
+}
+
+// ReadStreamCtx is like ReadStream but propagates ctx to each access.
+func (o *PathORAM) ReadStreamCtx(ctx context.Context, blockIDs []int, w io.Writer) (int64, error) {
+	if o.cfg.BlockSize <= streamHeaderSize {
+		return 0, fmt.Errorf("%w: BlockSize must exceed %d bytes for streams", ErrInvalidConfig, streamHeaderSize)
+	}
+	chunkSize := o.cfg.BlockSize - streamHeaderSize
+	var total int64
+	done := false
+	for _, id := range blockIDs {
+		data, err := o.ReadCtx(ctx, id)
+		if err != nil {
+			return total, err
+		}
+		if done {
+			continue
+		}
+		n := int(binary.BigEndian.Uint32(data))
+		if n > chunkSize {
+			return total, fmt.Errorf("%w: block %d has chunk length %d", ErrCorruptObject, id, n)
+		}
+		m, err := w.Write(data[streamHeaderSize : streamHeaderSize+n])
+		total += int64(m)
+		if err != nil {
+			return total, err
+		}
+		done = n < chunkSize
+	}
+	return total, nil
+}
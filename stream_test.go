@@ -0,0 +1,59 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestStream_RoundTrip(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 64, BlockSize: 36})
+	ids := []int{9, 3, 40, 17, 5}
+	capacity := oram.StreamCapacity(len(ids))
+	if capacity != 160 {
+		t.Fatalf("StreamCapacity = %d, want 160", capacity)
+	}
+
+	for _, size := range []int{0, 1, 31, 32, 33, 64, 159, 160} {
+		want := bytes.Repeat([]byte{byte(size)}, size)
+		for i := range want {
+			want[i] += byte(i)
+		}
+		before := oram.Stats().Accesses
+		n, err := oram.WriteStream(ids, bytes.NewReader(want))
+		if err != nil || n != int64(size) {
+			t.Fatalf("WriteStream(%d bytes) = %d, %v", size, n, err)
+		}
+		var got bytes.Buffer
+		n, err = oram.ReadStream(ids, &got)
+		if err != nil || n != int64(size) || !bytes.Equal(got.Bytes(), want) {
+			t.Fatalf("ReadStream after %d bytes = %d, %v", size, n, err)
+		}
+		if a := oram.Stats().Accesses - before; a != 2*uint64(len(ids)) {
+			t.Errorf("%d-byte stream took %d accesses, want %d", size, a, 2*len(ids))
+		}
+	}
+}
+
+func TestStream_Errors(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 8, BlockSize: 8})
+	var got bytes.Buffer
+	if n, err := oram.ReadStream([]int{1, 2}, &got); err != nil || n != 0 {
+		t.Errorf("ReadStream of unwritten blocks = %d, %v; want empty", n, err)
+	}
+
+	n, err := oram.WriteStream([]int{1, 2}, bytes.NewReader(make([]byte, 9)))
+	if !errors.Is(err, ErrStreamTooLong) || n != 8 {
+		t.Errorf("WriteStream of 9 bytes into 8 = %d, %v; want 8, ErrStreamTooLong", n, err)
+	}
+
+	oram.Write(3, []byte{0, 0, 0, 9, 0, 0, 0, 0})
+	if _, err := oram.ReadStream([]int{3}, &got); !errors.Is(err, ErrCorruptObject) {
+		t.Errorf("ReadStream of bad length = %v, want ErrCorruptObject", err)
+	}
+
+	tiny, _ := NewInMemory(Config{NumBlocks: 8, BlockSize: 4})
+	if _, err := tiny.WriteStream([]int{0}, bytes.NewReader(nil)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("WriteStream with 4-byte blocks = %v, want ErrInvalidConfig", err)
+	}
+}
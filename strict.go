@@ -0,0 +1,63 @@
+package pathoram
+
+import (
+	"context"
+	"time"
+)
+
+// strictRead is ReadCtx with Config.StrictReads: a block without a position
+// gets a dummy access and ErrBlockNotFound.
+func (o *PathORAM) strictRead(ctx context.Context, blockID int) ([]byte, error) {
+	defer o.padAccess(ctx, time.Now(), 1)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.posMap.Get(blockID); ok {
+		return o.observe(ctx, blockID, nil, nil)
+	}
+	if err := o.observedDummy(ctx); err != nil {
+		return nil, err
+	}
+	return nil, ErrBlockNotFound
+}
+
+// Exists reports whether blockID has been written. See ExistsCtx.
+func (o *PathORAM) Exists(blockID int) (bool, error) {
+	return o.ExistsCtx(context.Background(), blockID)
+}
+
+// ExistsCtx reports whether blockID has been written and not deleted (with
+// a DeletablePositionMap; see DeleteCtx). It
+// answers from the position map but performs a dummy access either way, so
+// the server sees an ordinary access and learns nothing about the answer.
+func (o *PathORAM) ExistsCtx(ctx context.Context, blockID int) (bool, error) {
+	if blockID < 0 || blockID >= o.cfg.NumBlocks {
+		return false, ErrInvalidBlockID
+	}
+	if err := o.authorize(ctx, OpRead, blockID); err != nil {
+		return false, err
+	}
+	defer o.padAccess(ctx, time.Now(), 1)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.posMap.Get(blockID)
+	if err := o.observedDummy(ctx); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// observedDummy performs a dummy access, reported like an ordinary one.
+// o.mu must be held.
+func (o *PathORAM) observedDummy(ctx context.Context) error {
+	start := time.Now()
+	err := o.takeBackgroundErr()
+	if err == nil {
+		err = o.evictPass(ctx)
+	}
+	o.finishAccess(start, err)
+	if err != nil {
+		return err
+	}
+	o.noteNamespace(ctx, false)
+	return nil
+}
@@ -0,0 +1,52 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestStrictReads(t *testing.T) {
+	oram, err := NewInMemory(Config{NumBlocks: 64, BlockSize: 16, StrictReads: true})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	want := bytes.Repeat([]byte{4}, 16)
+	if _, err := oram.Write(4, want); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	accesses := oram.Stats().Accesses
+	if _, err := oram.Read(9); !errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("Read of unwritten block: error = %v, want ErrBlockNotFound", err)
+	}
+	if oram.Size() != 1 {
+		t.Errorf("Size() = %d after strict read, want 1 (nothing allocated)", oram.Size())
+	}
+	if got, err := oram.Read(4); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Read(4) = %x, %v; want %x", got, err, want)
+	}
+
+	for id, want := range map[int]bool{4: true, 9: false} {
+		if ok, err := oram.Exists(id); err != nil || ok != want {
+			t.Errorf("Exists(%d) = %v, %v; want %v", id, ok, err, want)
+		}
+	}
+	if _, err := oram.Exists(64); !errors.Is(err, ErrInvalidBlockID) {
+		t.Errorf("Exists(64): error = %v, want ErrInvalidBlockID", err)
+	}
+
+	// Every call above was one access, found or not
+	if got := oram.Stats().Accesses - accesses; got != 4 {
+		t.Errorf("%d accesses, want 4", got)
+	}
+	if oram.Size() != 1 {
+		t.Errorf("Size() = %d after Exists, want 1", oram.Size())
+	}
+
+	// Without StrictReads, unwritten blocks read as zeros
+	lax, _ := NewInMemory(Config{NumBlocks: 64, BlockSize: 16})
+	if got, err := lax.Read(9); err != nil || !bytes.Equal(got, make([]byte, 16)) {
+		t.Errorf("Read without StrictReads = %x, %v; want zeros", got, err)
+	}
+}
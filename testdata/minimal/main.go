@@ -0,0 +1,18 @@
+// Command minimal is a small PathORAM user built by TestMinimalBuild to
+// compare binary size and dependencies with and without pathoram_minimal.
+package main
+
+import (
+	"fmt"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func main() {
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: 16, BlockSize: 16})
+	if err != nil {
+		panic(err)
+	}
+	data, err := oram.Read(0)
+	fmt.Println(len(data), err)
+}
@@ -0,0 +1,84 @@
+package pathoram
+
+import "fmt"
+
+// ThreatModel selects a security preset. Validate enables the subsystems a
+// model needs that require no secrets, and New refuses to construct an
+// instance whose encryptor or keys do not meet it.
+type ThreatModel int
+
+const (
+	// ThreatModelNone applies no checks; every subsystem is opt-in.
+	ThreatModelNone ThreatModel = iota
+
+	// HonestButCurious assumes the server follows the protocol but inspects
+	// everything it stores and sees. Requires an authenticated encryptor.
+	HonestButCurious
+
+	// MaliciousServer also assumes the server may return tampered or stale
+	// buckets. Adds VerifyIntegrity (Merkle tree over buckets).
+	MaliciousServer
+
+	// MaliciousServerWithRollback also assumes the server may roll back
+	// individual buckets or the whole tree between sessions. Adds VersionKey
+	// (client-held per-bucket counters). Persist IntegrityRoot and
+	// BucketVersions across restarts so the checks stay anchored.
+	MaliciousServerWithRollback
+
+	// TEEHost assumes a malicious server and a host that observes the
+	// enclave's memory access pattern. Adds ConstantTime.
+	TEEHost
+)
+
+// String returns the preset's name.
+func (m ThreatModel) String() string {
+	switch m {
+	case ThreatModelNone:
+		return "none"
+	case HonestButCurious:
+		return "honest-but-curious"
+	case MaliciousServer:
+		return "malicious-server"
+	case MaliciousServerWithRollback:
+		return "malicious-server-with-rollback"
+	case TEEHost:
+		return "tee-host"
+	default:
+		return fmt.Sprintf("ThreatModel(%d)", int(m))
+	}
+}
+
+// applyThreatModel enables the keyless subsystems required by c.ThreatModel.
+func (c *Config) applyThreatModel() error {
+	switch c.ThreatModel {
+	case ThreatModelNone, HonestButCurious:
+	case MaliciousServer, MaliciousServerWithRollback:
+		c.VerifyIntegrity = true
+	case TEEHost:
+		c.VerifyIntegrity = true
+		c.ConstantTime = true
+	default:
+		return fmt.Errorf("%w: unknown threat model %v", ErrInvalidConfig, c.ThreatModel)
+	}
+	return nil
+}
+
+// checkThreatModel reports whether enc and the configured keys meet
+// cfg.ThreatModel. cfg must already be validated.
+func checkThreatModel(cfg Config, enc Encryptor) error {
+	if cfg.ThreatModel == ThreatModelNone {
+		return nil
+	}
+	if cfg.LeafAssigner != nil {
+		return fmt.Errorf("%w: threat model %v requires uniform leaf assignment; remove LeafAssigner", ErrInvalidConfig, cfg.ThreatModel)
+	}
+	// Authenticated encryption always adds a tag, so zero overhead means
+	// the data is either unencrypted or unauthenticated.
+	if _, ok := enc.(NoOpEncryptor); ok || enc.Overhead() == 0 {
+		return fmt.Errorf("%w: threat model %v requires an authenticated encryptor", ErrInvalidConfig, cfg.ThreatModel)
+	}
+	if cfg.ThreatModel == MaliciousServerWithRollback && cfg.VersionKey == nil {
+		return fmt.Errorf("%w: threat model %v requires VersionKey", ErrInvalidConfig, cfg.ThreatModel)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package pathoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestThreatModel(t *testing.T) {
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{1}, 32))
+	tests := []struct {
+		model ThreatModel
+		opts  []Option
+		ok    bool
+	}{
+		{ThreatModelNone, nil, true},
+		{HonestButCurious, nil, false},
+		{HonestButCurious, []Option{WithEncryptor(enc)}, true},
+		{HonestButCurious, []Option{WithEncryptor(enc), WithLeafAssigner(LeafAssignerFunc(func(_, _, _ int) int { return 0 }))}, false},
+		{MaliciousServer, []Option{WithEncryptor(enc)}, true},
+		{MaliciousServerWithRollback, []Option{WithEncryptor(enc)}, false},
+		{MaliciousServerWithRollback, []Option{WithEncryptor(enc), WithBucketVersions(testVersionKey, nil)}, true},
+		{TEEHost, []Option{WithEncryptor(enc)}, true},
+		{ThreatModel(99), []Option{WithEncryptor(enc)}, false},
+	}
+	for _, tt := range tests {
+		opts := append([]Option{WithCapacity(32, 16), WithThreatModel(tt.model)}, tt.opts...)
+		oram, err := NewORAM(opts...)
+		if !tt.ok {
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("%v: got %v, want ErrInvalidConfig", tt.model, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: NewORAM failed: %v", tt.model, err)
+			continue
+		}
+		wantIntegrity := tt.model >= MaliciousServer
+		if (oram.integrity != nil) != wantIntegrity {
+			t.Errorf("%v: integrity enabled = %v, want %v", tt.model, oram.integrity != nil, wantIntegrity)
+		}
+		if oram.cfg.ConstantTime != (tt.model == TEEHost) {
+			t.Errorf("%v: ConstantTime = %v", tt.model, oram.cfg.ConstantTime)
+		}
+		if _, err := oram.Write(3, bytes.Repeat([]byte{3}, 16)); err != nil {
+			t.Errorf("%v: Write failed: %v", tt.model, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package pathoram
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// AccessTrace is the storage activity of one access: the bucket indices
+// read and written, in order, as the storage server saw them. Seq numbers
+// accesses from 1, counting dummy and drain passes.
+//
+// With BackgroundEviction, an eviction's writes are recorded in the trace of
+// the access during or after which it runs. Bucket I/O performed by New, such
+// as loading CachedLevels, is not recorded.
+type AccessTrace struct {
+	Seq    uint64 `json:"seq"`
+	Reads  []int  `json:"reads"`
+	Writes []int  `json:"writes"`
+}
+
+// TraceRecorder receives the trace of every access (Config.Trace), for
+// auditing obliviousness; see package pathoramtest. RecordAccess is called
+// with the ORAM's lock held, so it must not call back into the ORAM. The
+// trace's slices are not reused.
+type TraceRecorder interface {
+	RecordAccess(t AccessTrace)
+}
+
+// TraceFunc adapts a function to a TraceRecorder.
+type TraceFunc func(t AccessTrace)
+
+// RecordAccess calls f(t).
+func (f TraceFunc) RecordAccess(t AccessTrace) { f(t) }
+
+// TraceWriter is a TraceRecorder that writes each access as one line of
+// JSON. Write errors stop the recording and are reported by Err.
+type TraceWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewTraceWriter returns a TraceWriter writing to w.
+func NewTraceWriter(w io.Writer) *TraceWriter {
+	return &TraceWriter{enc: json.NewEncoder(w)}
+}
+
+// RecordAccess writes t as one JSON line.
+func (w *TraceWriter) RecordAccess(t AccessTrace) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = w.enc.Encode(t)
+	}
+}
+
+// Err returns the first write error, or nil.
+func (w *TraceWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// traceRead notes a bucket read for Config.Trace.
+func (o *PathORAM) traceRead(idx int) {
+	if o.cfg.Trace != nil {
+		o.traceReads = append(o.traceReads, idx)
+	}
+}
+
+// traceWrite notes a bucket write for Config.Trace.
+func (o *PathORAM) traceWrite(idx int) {
+	if o.cfg.Trace != nil {
+		o.traceWrites = append(o.traceWrites, idx)
+	}
+}
+
+// emitTrace passes the current access's bucket I/O to Config.Trace and
+// starts a new trace.
+func (o *PathORAM) emitTrace() {
+	if o.cfg.Trace == nil {
+		return
+	}
+	o.cfg.Trace.RecordAccess(AccessTrace{Seq: o.accessCount, Reads: o.traceReads, Writes: o.traceWrites})
+	o.traceReads, o.traceWrites = nil, nil
+}
@@ -0,0 +1,62 @@
+package pathoram
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestTrace_RecordsPathIO(t *testing.T) {
+	var traces []AccessTrace
+	cfg := Config{NumBlocks: 64, BlockSize: 16, CachedLevels: 1,
+		Trace: TraceFunc(func(tr AccessTrace) { traces = append(traces, tr) })}
+	oram, err := NewInMemory(cfg)
+	if err != nil {
+		t.Fatalf("NewInMemory: %v", err)
+	}
+	if len(traces) != 0 {
+		t.Fatalf("New recorded %d traces", len(traces))
+	}
+	if _, err := oram.Write(3, make([]byte, 16)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := oram.Read(3); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(traces) != 2 {
+		t.Fatalf("got %d traces, want 2", len(traces))
+	}
+	height := oram.Height()
+	for i, tr := range traces {
+		if tr.Seq != uint64(i+1) || len(tr.Reads) == 0 || len(tr.Writes) == 0 {
+			t.Errorf("trace %d = %+v", i, tr)
+		}
+		for _, idx := range append(tr.Reads, tr.Writes...) {
+			if idx == 0 {
+				t.Errorf("trace %d touches the cached root bucket", i)
+			}
+		}
+		if tr.Reads[0] < 1<<(height-1)-1 {
+			t.Errorf("trace %d reads bucket %d first, want a leaf", i, tr.Reads[0])
+		}
+	}
+}
+
+func TestTraceWriter_JSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTraceWriter(&buf)
+	oram, _ := NewORAM(WithCapacity(16, 8), WithTrace(w))
+	for i := range 3 {
+		oram.Read(i)
+	}
+	if err := w.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	dec := json.NewDecoder(&buf)
+	for i := range 3 {
+		var tr AccessTrace
+		if err := dec.Decode(&tr); err != nil || tr.Seq != uint64(i+1) || len(tr.Reads) == 0 {
+			t.Fatalf("line %d = %+v, %v", i, tr, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package pathoram
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// typedHeaderSize is the per-block header of a TypedORAM payload:
+// schema ID (2 bytes), schema version (2 bytes), payload length (4 bytes).
+// It is part of the block plaintext, so an AEAD Encryptor authenticates it.
+const typedHeaderSize = 8
+
+// Schema describes how values of type T are stored. Blocks are written with
+// the current Version; decoders registered for older versions let stored
+// data outlive changes to T's layout.
+type Schema[T any] struct {
+	ID       uint16 // identifies the payload type; 0 is reserved for unwritten blocks
+	Version  uint16 // version used for new writes
+	encode   func(T) ([]byte, error)
+	decoders map[uint16]func([]byte) (T, error)
+}
+
+// NewSchema creates a schema whose current version is encoded by encode and
+// decoded by decode.
+func NewSchema[T any](id, version uint16, encode func(T) ([]byte, error), decode func([]byte) (T, error)) *Schema[T] {
+	return &Schema[T]{
+		ID:       id,
+		Version:  version,
+		encode:   encode,
+		decoders: map[uint16]func([]byte) (T, error){version: decode},
+	}
+}
+
+// RegisterDecoder adds a decoder for blocks written with an older version.
+func (s *Schema[T]) RegisterDecoder(version uint16, decode func([]byte) (T, error)) {
+	s.decoders[version] = decode
+}
+
+// TypedORAM stores values of type T in a PathORAM, one value per block,
+// tagged with their schema ID and version.
+type TypedORAM[T any] struct {
+	oram   *PathORAM
+	schema *Schema[T]
+}
+
+// NewTypedORAM wraps oram with schema. Values must encode to at most
+// oram.BlockSize()-8 bytes.
+func NewTypedORAM[T any](oram *PathORAM, schema *Schema[T]) (*TypedORAM[T], error) {
+	if schema.ID == 0 || oram.BlockSize() <= typedHeaderSize {
+		return nil, ErrInvalidConfig
+	}
+	return &TypedORAM[T]{oram: oram, schema: schema}, nil
+}
+
+// Get returns the value stored at blockID, or T's zero value if the block
+// has never been written. Blocks from another schema, or from a version
+// without a registered decoder, return ErrUnknownSchema.
+func (t *TypedORAM[T]) Get(blockID int) (T, error) {
+	var zero T
+	data, err := t.oram.Read(blockID)
+	if err != nil {
+		return zero, err
+	}
+	id := binary.BigEndian.Uint16(data[0:])
+	version := binary.BigEndian.Uint16(data[2:])
+	n := int(binary.BigEndian.Uint32(data[4:]))
+	if id == 0 {
+		return zero, nil
+	}
+	if id != t.schema.ID {
+		return zero, fmt.Errorf("%w: block %d has schema %d, want %d", ErrUnknownSchema, blockID, id, t.schema.ID)
+	}
+	decode, ok := t.schema.decoders[version]
+	if !ok {
+		return zero, fmt.Errorf("%w: schema %d has no decoder for version %d", ErrUnknownSchema, id, version)
+	}
+	if n > len(data)-typedHeaderSize {
+		return zero, ErrCorruptObject
+	}
+	return decode(data[typedHeaderSize : typedHeaderSize+n])
+}
+
+// Put encodes v with the schema's current version and stores it at blockID.
+func (t *TypedORAM[T]) Put(blockID int, v T) error {
+	payload, err := t.schema.encode(v)
+	if err != nil {
+		return err
+	}
+	if len(payload) > t.oram.BlockSize()-typedHeaderSize {
+		return ErrInvalidDataSize
+	}
+	data := make([]byte, t.oram.BlockSize())
+	binary.BigEndian.PutUint16(data[0:], t.schema.ID)
+	binary.BigEndian.PutUint16(data[2:], t.schema.Version)
+	binary.BigEndian.PutUint32(data[4:], uint32(len(payload)))
+	copy(data[typedHeaderSize:], payload)
+	_, err = t.oram.Write(blockID, data)
+	return err
+}
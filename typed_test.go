@@ -0,0 +1,93 @@
+package pathoram
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type userV1 struct {
+	Name string
+}
+
+type userV2 struct {
+	First string
+	Last  string
+}
+
+func TestTypedORAM_SchemaEvolution(t *testing.T) {
+	oram, err := NewInMemory(Config{NumBlocks: 8, BlockSize: 64})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+
+	v1 := NewSchema(1, 1,
+		func(u userV1) ([]byte, error) { return json.Marshal(u) },
+		func(b []byte) (userV1, error) { var u userV1; return u, json.Unmarshal(b, &u) })
+	old, err := NewTypedORAM(oram, v1)
+	if err != nil {
+		t.Fatalf("NewTypedORAM failed: %v", err)
+	}
+	if err := old.Put(0, userV1{Name: "Ada Lovelace"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// Version 2 splits the name and can still read version 1 blocks
+	v2 := NewSchema(1, 2,
+		func(u userV2) ([]byte, error) { return json.Marshal(u) },
+		func(b []byte) (userV2, error) { var u userV2; return u, json.Unmarshal(b, &u) })
+	v2.RegisterDecoder(1, func(b []byte) (userV2, error) {
+		var u userV1
+		err := json.Unmarshal(b, &u)
+		return userV2{First: u.Name}, err
+	})
+	typed, _ := NewTypedORAM(oram, v2)
+	if err := typed.Put(1, userV2{First: "Alan", Last: "Turing"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	tests := []struct {
+		id   int
+		want userV2
+	}{
+		{0, userV2{First: "Ada Lovelace"}},
+		{1, userV2{First: "Alan", Last: "Turing"}},
+		{2, userV2{}}, // never written
+	}
+	for _, tt := range tests {
+		got, err := typed.Get(tt.id)
+		if err != nil {
+			t.Fatalf("Get(%d) failed: %v", tt.id, err)
+		}
+		if got != tt.want {
+			t.Errorf("Get(%d) = %+v, want %+v", tt.id, got, tt.want)
+		}
+	}
+
+	// The old reader cannot decode version 2 blocks
+	if _, err := old.Get(1); !errors.Is(err, ErrUnknownSchema) {
+		t.Errorf("v1 Get of v2 block: got %v, want ErrUnknownSchema", err)
+	}
+}
+
+func TestTypedORAM_Errors(t *testing.T) {
+	oram, _ := NewInMemory(Config{NumBlocks: 4, BlockSize: 16})
+	raw := NewSchema(7, 1,
+		func(b []byte) ([]byte, error) { return b, nil },
+		func(b []byte) ([]byte, error) { return b, nil })
+	typed, _ := NewTypedORAM(oram, raw)
+
+	if err := typed.Put(0, make([]byte, 9)); !errors.Is(err, ErrInvalidDataSize) {
+		t.Errorf("oversized Put: got %v, want ErrInvalidDataSize", err)
+	}
+	other, _ := NewTypedORAM(oram, NewSchema(8, 1, raw.encode, raw.decoders[1]))
+	if err := other.Put(1, []byte("x")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := typed.Get(1); !errors.Is(err, ErrUnknownSchema) {
+		t.Errorf("Get with wrong schema ID: got %v, want ErrUnknownSchema", err)
+	}
+	if _, err := NewTypedORAM(oram, NewSchema(0, 1, raw.encode, raw.decoders[1])); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("schema ID 0: got %v, want ErrInvalidConfig", err)
+	}
+}
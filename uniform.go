@@ -0,0 +1,58 @@
+package pathoram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// AutoCalibrate, as Config.UniformAccessTime, makes New measure the access
+// time with CalibrateAccessTime and pad every access to the result.
+const AutoCalibrate time.Duration = -1
+
+// calibrationAccesses is the number of dummy accesses New performs for
+// AutoCalibrate.
+const calibrationAccesses = 200
+
+// CalibrateAccessTime performs n dummy accesses and returns their 99th
+// percentile duration, a suitable Config.UniformAccessTime for o's storage
+// and load. The accesses look like ordinary accesses to the server.
+func CalibrateAccessTime(ctx context.Context, o *PathORAM, n int) (time.Duration, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: calibration needs at least one access", ErrInvalidConfig)
+	}
+	times := make([]time.Duration, n)
+	for i := range times {
+		start := time.Now()
+		if err := o.dummyAccess(ctx); err != nil {
+			return 0, err
+		}
+		times[i] = time.Since(start)
+	}
+	slices.Sort(times)
+	return times[(n-1)*99/100], nil
+}
+
+// padAccess sleeps until n access durations (Config.UniformAccessTime) have
+// passed since start. Accesses that overran return at once and are counted
+// in Stats.PadOverruns. A cancelled ctx ends the wait early.
+func (o *PathORAM) padAccess(ctx context.Context, start time.Time, n int) {
+	d := o.padTo
+	if d <= 0 {
+		return
+	}
+	wait := time.Until(start.Add(time.Duration(n) * d))
+	if wait <= 0 {
+		o.mu.Lock()
+		o.stats.PadOverruns++
+		o.mu.Unlock()
+		return
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,72 @@
+package pathoram
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUniformAccessTime(t *testing.T) {
+	const pad = 20 * time.Millisecond
+	oram, err := NewORAM(WithCapacity(16, 16), WithUniformAccessTime(pad))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for _, op := range []struct {
+		name string
+		n    int
+		f    func() error
+	}{
+		{"Read", 1, func() error { _, err := oram.Read(1); return err }},
+		{"Write", 1, func() error { _, err := oram.Write(1, make([]byte, 16)); return err }},
+		{"DummyAccess", 1, func() error { return oram.DummyAccess(context.Background()) }},
+		{"Copy", 2, func() error { return oram.Copy(1, 2) }},
+	} {
+		start := time.Now()
+		if err := op.f(); err != nil {
+			t.Fatalf("%s failed: %v", op.name, err)
+		}
+		if d := time.Since(start); d < time.Duration(op.n)*pad {
+			t.Errorf("%s returned after %v, want at least %v", op.name, d, time.Duration(op.n)*pad)
+		}
+	}
+
+	// A cancelled context ends the wait early
+	ctx, cancel := context.WithTimeout(context.Background(), pad/4)
+	defer cancel()
+	start := time.Now()
+	oram.ReadCtx(ctx, 1)
+	if d := time.Since(start); d >= pad {
+		t.Errorf("cancelled Read returned after %v", d)
+	}
+}
+
+func TestUniformAccessTime_Overruns(t *testing.T) {
+	oram, _ := NewORAM(WithCapacity(16, 16), WithUniformAccessTime(time.Nanosecond))
+	for i := 0; i < 5; i++ {
+		oram.Read(i)
+	}
+	if got := oram.Stats().PadOverruns; got != 5 {
+		t.Errorf("PadOverruns = %d, want 5", got)
+	}
+}
+
+func TestUniformAccessTime_AutoCalibrate(t *testing.T) {
+	oram, err := NewORAM(WithCapacity(16, 16), WithUniformAccessTime(AutoCalibrate))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	if oram.padTo <= 0 {
+		t.Errorf("calibrated pad = %v", oram.padTo)
+	}
+	if got := oram.Stats().Accesses; got != calibrationAccesses {
+		t.Errorf("calibration made %d accesses, want %d", got, calibrationAccesses)
+	}
+	if _, err := CalibrateAccessTime(context.Background(), oram, 0); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("n=0: got %v, want ErrInvalidConfig", err)
+	}
+	if _, err := NewORAM(WithCapacity(16, 16), WithUniformAccessTime(-2)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("negative duration: got %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// The assignments below fail to compile if a v1 signature changes. Adding
+// to the API is fine; editing a line here is a breaking change.
+var (
+	_ func(Config, Storage, PositionMap, Encryptor) (*PathORAM, error) = New
+	_ func(Config) (*PathORAM, error)                                  = NewInMemory
+	_ func(...Option) (*PathORAM, error)                               = NewORAM
+	_ func(int, int, int) *InMemoryStorage                             = NewInMemoryStorage
+	_ func() *InMemoryPositionMap                                      = NewInMemoryPositionMap
+	_ func([]byte) (*AESGCMEncryptor, error)                           = NewAESGCMEncryptor
+	_ func(Storage) StorageV2                                          = AdaptStorage
+	_ func(StorageV2) Storage                                          = StorageFromV2
+
+	_ func(Config) Option                          = WithConfig
+	_ func(int, int) Option                        = WithCapacity
+	_ func(int) Option                             = WithBucketSize
+	_ func(int) Option                             = WithStashLimit
+	_ func(EvictionStrategy) Option                = WithStrategy
+	_ func(Storage) Option                         = WithStorage
+	_ func(StorageV2) Option                       = WithStorageV2
+	_ func(PositionMap) Option                     = WithPositionMap
+	_ func(Encryptor) Option                       = WithEncryptor
+	_ func(Config) (Config, error)                 = Config.Validate
+	_ func(Config) (int, int, int)                 = Config.ComputeTreeParams
+	_ func(EvictionStrategy) string                = EvictionStrategy.String
+	_ func(*PathORAM) Stats                        = (*PathORAM).Stats
+	_ func(*PathORAM) error                        = (*PathORAM).Close
+	_ func(*PathORAM, int) []int                   = (*PathORAM).Path
+	_ func(*PathORAM) int                          = (*PathORAM).Capacity
+	_ func(*PathORAM) int                          = (*PathORAM).BlockSize
+	_ func(*PathORAM) int                          = (*PathORAM).StashSize
+	_ func(*PathORAM, int) ([]byte, error)         = (*PathORAM).Read
+	_ func(*PathORAM, int, []byte) ([]byte, error) = (*PathORAM).Write
+	_ func(*PathORAM, int, []byte) ([]byte, error) = (*PathORAM).Access
+
+	_ func(*PathORAM, context.Context, int) ([]byte, error)         = (*PathORAM).ReadCtx
+	_ func(*PathORAM, context.Context, int, []byte) ([]byte, error) = (*PathORAM).WriteCtx
+	_ func(*PathORAM, context.Context, int, []byte) ([]byte, error) = (*PathORAM).AccessCtx
+
+	_ Storage     = (*InMemoryStorage)(nil)
+	_ PositionMap = (*InMemoryPositionMap)(nil)
+	_ Encryptor   = (*AESGCMEncryptor)(nil)
+	_ Encryptor   = NoOpEncryptor{}
+)
+
+// The method sets of the v1 interfaces are frozen: a type implementing
+// exactly these methods must keep satisfying them.
+type (
+	storageV1 interface {
+		ReadBucket(idx int) ([]Block, error)
+		WriteBucket(idx int, blocks []Block) error
+		NumBuckets() int
+		BucketSize() int
+		BlockSize() int
+	}
+	storageV2V1 interface {
+		ReadBucket(ctx context.Context, idx int) ([]Block, error)
+		WriteBucket(ctx context.Context, idx int, blocks []Block) error
+		ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error)
+		WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error
+		NumBuckets() int
+		BucketSize() int
+		BlockSize() int
+	}
+	positionMapV1 interface {
+		Get(blockID int) (int, bool)
+		Set(blockID int, leaf int)
+		Size() int
+	}
+	encryptorV1 interface {
+		Encrypt(blockID, leaf int, plaintext []byte) ([]byte, error)
+		Decrypt(blockID, leaf int, ciphertext []byte) ([]byte, error)
+		Overhead() int
+	}
+)
+
+var (
+	_ Storage       = storageV1(nil)
+	_ storageV1     = Storage(nil)
+	_ StorageV2     = storageV2V1(nil)
+	_ storageV2V1   = StorageV2(nil)
+	_ PositionMap   = positionMapV1(nil)
+	_ positionMapV1 = PositionMap(nil)
+	_ Encryptor     = encryptorV1(nil)
+	_ encryptorV1   = Encryptor(nil)
+)
+
+func TestV1RoundTrip(t *testing.T) {
+	oram, err := NewORAM(WithCapacity(64, 16), WithStrategy(EvictGreedyByDepth))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	data := make([]byte, 16)
+	data[0] = 1
+	if _, err := oram.Write(3, data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got, err := oram.Read(3); err != nil || got[0] != 1 {
+		t.Fatalf("Read = %v, %v", got, err)
+	}
+	if _, err := oram.Read(64); !errors.Is(err, ErrInvalidBlockID) {
+		t.Fatalf("err = %v, want ErrInvalidBlockID", err)
+	}
+}
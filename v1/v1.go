@@ -0,0 +1,84 @@
+// Package v1 is the stable surface of pathoram: the core types, the
+// Storage, PositionMap and Encryptor interfaces, and their constructors.
+//
+// Everything here is an alias of the root package, so values pass freely
+// between the two and errors.Is matches the same sentinels. Identifiers in
+// this package follow semantic versioning: they are not removed and their
+// signatures do not change within v1. New fields may be added to Config and
+// new methods to PathORAM. The root package keeps growing; code that
+// imports only v1 is not broken by that growth. Subsystems still settling
+// (schedulers, filesystems, new ORAM schemes) start under x/ and are
+// promoted here once their API is frozen. api_test.go pins every signature.
+package v1
+
+import pathoram "github.com/etclab/pathoram-go"
+
+// Core types.
+type (
+	PathORAM         = pathoram.PathORAM
+	Config           = pathoram.Config
+	Block            = pathoram.Block
+	Stats            = pathoram.Stats
+	EvictionStrategy = pathoram.EvictionStrategy
+	Option           = pathoram.Option
+)
+
+// Pluggable components.
+type (
+	Storage     = pathoram.Storage
+	StorageCtx  = pathoram.StorageCtx
+	StorageV2   = pathoram.StorageV2
+	PositionMap = pathoram.PositionMap
+	Encryptor   = pathoram.Encryptor
+)
+
+// Built-in implementations.
+type (
+	InMemoryStorage     = pathoram.InMemoryStorage
+	InMemoryPositionMap = pathoram.InMemoryPositionMap
+	AESGCMEncryptor     = pathoram.AESGCMEncryptor
+	NoOpEncryptor       = pathoram.NoOpEncryptor
+)
+
+const (
+	EmptyBlockID = pathoram.EmptyBlockID
+	MaxBlockSize = pathoram.MaxBlockSize
+
+	EvictLevelByLevel         = pathoram.EvictLevelByLevel
+	EvictGreedyByDepth        = pathoram.EvictGreedyByDepth
+	EvictDeterministicTwoPath = pathoram.EvictDeterministicTwoPath
+)
+
+var (
+	ErrInvalidConfig    = pathoram.ErrInvalidConfig
+	ErrInvalidBlockID   = pathoram.ErrInvalidBlockID
+	ErrInvalidDataSize  = pathoram.ErrInvalidDataSize
+	ErrStashOverflow    = pathoram.ErrStashOverflow
+	ErrEncryptionFailed = pathoram.ErrEncryptionFailed
+	ErrDecryptionFailed = pathoram.ErrDecryptionFailed
+)
+
+// Constructors.
+var (
+	New                    = pathoram.New
+	NewInMemory            = pathoram.NewInMemory
+	NewORAM                = pathoram.NewORAM
+	NewInMemoryStorage     = pathoram.NewInMemoryStorage
+	NewInMemoryPositionMap = pathoram.NewInMemoryPositionMap
+	NewAESGCMEncryptor     = pathoram.NewAESGCMEncryptor
+	AdaptStorage           = pathoram.AdaptStorage
+	StorageFromV2          = pathoram.StorageFromV2
+)
+
+// Options for NewORAM.
+var (
+	WithConfig      = pathoram.WithConfig
+	WithCapacity    = pathoram.WithCapacity
+	WithBucketSize  = pathoram.WithBucketSize
+	WithStashLimit  = pathoram.WithStashLimit
+	WithStrategy    = pathoram.WithStrategy
+	WithStorage     = pathoram.WithStorage
+	WithStorageV2   = pathoram.WithStorageV2
+	WithPositionMap = pathoram.WithPositionMap
+	WithEncryptor   = pathoram.WithEncryptor
+)
@@ -0,0 +1,110 @@
+package pathoram
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// versionTagSize is the length of the per-slot version tag appended to
+// stored block data when Config.VersionKey is set.
+const versionTagSize = 16
+
+// bucketVersions binds every stored bucket to a client-side write counter.
+// Each write increments the bucket's counter and appends to every slot an
+// HMAC over (bucket index, counter, slot, ID, leaf, data); reads check the tags
+// against the expected counter. A server that returns an earlier, otherwise
+// valid copy of a bucket therefore fails with ErrStaleBucket.
+type bucketVersions struct {
+	key      []byte
+	counters []uint64 // 0 = never written, stored untagged
+}
+
+func newBucketVersions(key []byte, counters []uint64, numBuckets int) (*bucketVersions, error) {
+	if len(key) < 32 {
+		return nil, fmt.Errorf("%w: VersionKey must be at least 32 bytes", ErrInvalidConfig)
+	}
+	if counters == nil {
+		counters = make([]uint64, numBuckets)
+	}
+	if len(counters) != numBuckets {
+		return nil, fmt.Errorf("%w: %d bucket versions for %d buckets", ErrInvalidConfig, len(counters), numBuckets)
+	}
+	return &bucketVersions{key: bytes.Clone(key), counters: slices.Clone(counters)}, nil
+}
+
+// tag computes the version tag for one slot.
+func (v *bucketVersions) tag(idx int, version uint64, slot int, b Block) []byte {
+	mac := hmac.New(sha256.New, v.key)
+	var hdr [48]byte
+	copy(hdr[:8], "pathoram")
+	binary.BigEndian.PutUint64(hdr[8:], uint64(idx))
+	binary.BigEndian.PutUint64(hdr[16:], version)
+	binary.BigEndian.PutUint64(hdr[24:], uint64(slot))
+	binary.BigEndian.PutUint64(hdr[32:], uint64(b.ID))
+	binary.BigEndian.PutUint64(hdr[40:], uint64(b.Leaf))
+	mac.Write(hdr[:])
+	mac.Write(b.Data)
+	return mac.Sum(nil)[:versionTagSize]
+}
+
+// seal increments bucket idx's counter and returns blocks with tags appended.
+// The counter is committed by commit once the write succeeds.
+func (v *bucketVersions) seal(idx int, blocks []Block) ([]Block, uint64) {
+	version := v.counters[idx] + 1
+	sealed := make([]Block, len(blocks))
+	for i, b := range blocks {
+		sealed[i] = Block{ID: b.ID, Leaf: b.Leaf, Data: append(bytes.Clone(b.Data), v.tag(idx, version, i, b)...)}
+	}
+	return sealed, version
+}
+
+func (v *bucketVersions) commit(idx int, version uint64) {
+	v.counters[idx] = version
+}
+
+// open checks and strips the tags of blocks read from bucket idx, in place.
+func (v *bucketVersions) open(idx int, blocks []Block) error {
+	version := v.counters[idx]
+	if version == 0 {
+		return nil
+	}
+	for i := range blocks {
+		n := len(blocks[i].Data) - versionTagSize
+		if n < 0 {
+			return fmt.Errorf("%w: bucket %d", ErrStaleBucket, idx)
+		}
+		data, tag := blocks[i].Data[:n], blocks[i].Data[n:]
+		want := v.tag(idx, version, i, Block{ID: blocks[i].ID, Leaf: blocks[i].Leaf, Data: data})
+		if !hmac.Equal(tag, want) {
+			return fmt.Errorf("%w: bucket %d", ErrStaleBucket, idx)
+		}
+		blocks[i].Data = data
+	}
+	return nil
+}
+
+// resize adjusts the counter table after the tree changes size. New buckets
+// start empty and untagged.
+func (v *bucketVersions) resize(numBuckets int) {
+	if numBuckets < len(v.counters) {
+		v.counters = v.counters[:numBuckets]
+		return
+	}
+	v.counters = append(v.counters, make([]uint64, numBuckets-len(v.counters))...)
+}
+
+// BucketVersions returns a copy of the per-bucket write counters, or nil if
+// Config.VersionKey is not set. Persist it on the client and pass it back via
+// Config.BucketVersions when reopening persistent storage.
+func (o *PathORAM) BucketVersions() []uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.versions == nil {
+		return nil
+	}
+	return slices.Clone(o.versions.counters)
+}
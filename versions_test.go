@@ -0,0 +1,128 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+// rollbackStorage remembers every version of every bucket so tests can serve
+// an earlier copy, as a malicious server would.
+type rollbackStorage struct {
+	*InMemoryStorage
+	history map[int][][]Block
+}
+
+func (s *rollbackStorage) WriteBucket(idx int, blocks []Block) error {
+	if s.history == nil {
+		s.history = make(map[int][][]Block)
+	}
+	old, _ := s.InMemoryStorage.ReadBucket(idx)
+	s.history[idx] = append(s.history[idx], old)
+	return s.InMemoryStorage.WriteBucket(idx, blocks)
+}
+
+// rollback restores the copy of bucket idx from n writes ago.
+func (s *rollbackStorage) rollback(idx, n int) {
+	h := s.history[idx]
+	s.InMemoryStorage.WriteBucket(idx, h[len(h)-n])
+}
+
+var testVersionKey = bytes.Repeat([]byte{0x42}, 32)
+
+func newVersionedTestORAM(t *testing.T, versions []uint64, storage *rollbackStorage) *PathORAM {
+	t.Helper()
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{1}, 32))
+	oram, err := NewORAM(WithCapacity(32, 16), WithStashLimit(200), WithEncryptor(enc),
+		WithStorage(storage), WithBucketVersions(testVersionKey, versions))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	return oram
+}
+
+func newRollbackStorage() *rollbackStorage {
+	cfg, _ := Config{NumBlocks: 32, BlockSize: 16}.Validate()
+	_, _, total := cfg.ComputeTreeParams()
+	return &rollbackStorage{InMemoryStorage: NewInMemoryStorage(total, cfg.BucketSize, 16+28+versionTagSize)}
+}
+
+func TestBucketVersions_DetectsRollback(t *testing.T) {
+	storage := newRollbackStorage()
+	oram := newVersionedTestORAM(t, nil, storage)
+	for i := 0; i < 32; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	checkBlocks(t, oram, 32)
+
+	// Serve an earlier, validly encrypted copy of the root bucket
+	storage.rollback(0, 3)
+	if _, err := oram.Read(0); !errors.Is(err, ErrStaleBucket) {
+		t.Errorf("Read after rollback: got %v, want ErrStaleBucket", err)
+	}
+}
+
+func TestBucketVersions_Reopen(t *testing.T) {
+	storage := newRollbackStorage()
+	oram := newVersionedTestORAM(t, nil, storage)
+	for i := 0; i < 8; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	// Blocks still in the stash would be lost on reopen; with 8 of 32
+	// blocks written the tree has room for all of them
+	if _, err := oram.DrainStash(context.Background(), 0, 1000, nil); err != nil || oram.StashSize() != 0 {
+		t.Fatalf("DrainStash: stash %d, err %v", oram.StashSize(), err)
+	}
+	versions := oram.BucketVersions()
+	if versions[0] == 0 {
+		t.Fatal("root bucket version not advanced")
+	}
+
+	// Reopen over the same storage and position map with persisted counters
+	enc, _ := NewAESGCMEncryptor(bytes.Repeat([]byte{1}, 32))
+	cfg := oram.cfg
+	cfg.BucketVersions = versions
+	reopened, err := New(cfg, storage, oram.posMap, enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	checkBlocks(t, reopened, 8)
+
+	cfg.BucketVersions = versions[:1]
+	if _, err := New(cfg, storage, oram.posMap, enc); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("wrong counter count: got %v, want ErrInvalidConfig", err)
+	}
+	cfg.BucketVersions, cfg.VersionKey = nil, []byte("short")
+	if _, err := New(cfg, storage, oram.posMap, enc); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("short key: got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestBucketVersions_WithIntegrityAndResize(t *testing.T) {
+	oram, err := NewORAM(WithCapacity(16, 16), WithStashLimit(200),
+		WithBucketVersions(testVersionKey, nil), WithIntegrity(nil))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		if _, err := oram.Write(i, bytes.Repeat([]byte{byte(i)}, 16)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", i, err)
+		}
+	}
+	if err := oram.Resize(64); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	checkBlocks(t, oram, 16)
+	if err := oram.Resize(16); err != nil {
+		t.Fatalf("Resize (shrink) failed: %v", err)
+	}
+	if _, err := oram.DrainStash(t.Context(), 0, 100, nil); err != nil {
+		t.Fatalf("DrainStash failed: %v", err)
+	}
+	checkBlocks(t, oram, 16)
+}
@@ -0,0 +1,164 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Target is the subset of *pathoram.PathORAM that a workload drives.
+// Wrap it to add checks, as cmd/pathoram-soak does.
+type Target interface {
+	ReadCtx(ctx context.Context, blockID int) ([]byte, error)
+	WriteCtx(ctx context.Context, blockID int, data []byte) ([]byte, error)
+	Capacity() int
+	BlockSize() int
+}
+
+// Result summarizes one phase. Latencies are per operation.
+type Result struct {
+	Phase      string        `json:"phase"`
+	Ops        int           `json:"ops"`
+	Reads      int           `json:"reads"`
+	Writes     int           `json:"writes"`
+	Errors     int           `json:"errors"`
+	FirstError string        `json:"first_error,omitempty"`
+	Elapsed    time.Duration `json:"elapsed_ns"`
+	OpsPerSec  float64       `json:"ops_per_sec"`
+	P50        time.Duration `json:"p50_ns"`
+	P99        time.Duration `json:"p99_ns"`
+	Max        time.Duration `json:"max_ns"`
+}
+
+// Run executes every phase of spec against t in order. Failed operations are
+// counted in the phase's Result rather than stopping the run; Run returns an
+// error only if spec does not fit t or ctx is cancelled, in which case the
+// interrupted phase's partial Result is included.
+func Run(ctx context.Context, t Target, spec *Spec) ([]Result, error) {
+	seed := spec.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	results := make([]Result, 0, len(spec.Phases))
+	for i, p := range spec.Phases {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		res, err := runPhase(ctx, t, p, seed, uint64(i))
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, ctx.Err()
+}
+
+// workerStats is one worker's share of a phase.
+type workerStats struct {
+	reads, writes, errors int
+	firstErr              error
+	latencies             []time.Duration
+}
+
+func runPhase(ctx context.Context, t Target, p Phase, seed, phase uint64) (Result, error) {
+	numKeys := p.numKeys(t.Capacity())
+	if numKeys > t.Capacity() {
+		return Result{}, fmt.Errorf("%w: phase %q key count %d exceeds capacity %d", ErrInvalidSpec, p.Name, numKeys, t.Capacity())
+	}
+	for _, op := range p.TraceOps {
+		if op.ID >= t.Capacity() {
+			return Result{}, fmt.Errorf("%w: phase %q trace block ID %d exceeds capacity %d", ErrInvalidSpec, p.Name, op.ID, t.Capacity())
+		}
+	}
+	blockSize := t.BlockSize()
+	valueSize := p.ValueSize
+	if valueSize == 0 {
+		valueSize = blockSize
+	}
+	if valueSize > blockSize {
+		return Result{}, fmt.Errorf("%w: phase %q value size %d exceeds block size %d", ErrInvalidSpec, p.Name, valueSize, blockSize)
+	}
+
+	var deadline time.Time
+	if p.Duration > 0 {
+		deadline = time.Now().Add(time.Duration(p.Duration))
+	}
+	stats := make([]workerStats, p.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := range p.Concurrency {
+		ops := -1 // until deadline
+		if p.Ops > 0 {
+			ops = p.Ops / p.Concurrency
+			if w < p.Ops%p.Concurrency {
+				ops++
+			}
+		}
+		// Values come from their own stream so the access sequence matches
+		// Phase.Sequence.
+		r := rand.New(rand.NewPCG(seed, phase<<32|uint64(w)))
+		values := rand.New(rand.NewPCG(^seed, phase<<32|uint64(w)))
+		nextOp := p.opGen(r, numKeys, w, p.Concurrency)
+		ws := &stats[w]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n != ops; n++ {
+				if ctx.Err() != nil || (!deadline.IsZero() && time.Now().After(deadline)) {
+					return
+				}
+				op := nextOp()
+				var err error
+				opStart := time.Now()
+				if op.Read {
+					_, err = t.ReadCtx(ctx, op.ID)
+					ws.reads++
+				} else {
+					data := make([]byte, blockSize)
+					for i := 0; i < valueSize; i += 8 {
+						v := values.Uint64()
+						for j := 0; j < 8 && i+j < valueSize; j++ {
+							data[i+j] = byte(v >> (8 * j))
+						}
+					}
+					_, err = t.WriteCtx(ctx, op.ID, data)
+					ws.writes++
+				}
+				ws.latencies = append(ws.latencies, time.Since(opStart))
+				if err != nil && ctx.Err() == nil {
+					ws.errors++
+					if ws.firstErr == nil {
+						ws.firstErr = err
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	res := Result{Phase: p.Name, Elapsed: time.Since(start)}
+	var latencies []time.Duration
+	for _, ws := range stats {
+		res.Reads += ws.reads
+		res.Writes += ws.writes
+		res.Errors += ws.errors
+		if ws.firstErr != nil && res.FirstError == "" {
+			res.FirstError = ws.firstErr.Error()
+		}
+		latencies = append(latencies, ws.latencies...)
+	}
+	res.Ops = res.Reads + res.Writes
+	if res.Elapsed > 0 {
+		res.OpsPerSec = float64(res.Ops) / res.Elapsed.Seconds()
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		res.P50 = latencies[len(latencies)*50/100]
+		res.P99 = latencies[len(latencies)*99/100]
+		res.Max = latencies[len(latencies)-1]
+	}
+	return res, nil
+}
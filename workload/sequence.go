@@ -0,0 +1,79 @@
+package workload
+
+import (
+	"iter"
+	"math/rand/v2"
+)
+
+// Op is one access of a sequence.
+type Op struct {
+	Read bool
+	ID   int
+}
+
+// Sequence returns the accesses a single worker performs running p, after
+// Validate, over block IDs 0 to numKeys-1 (or Keys.Count) with seed: the
+// sequence Run performs for the first phase of a Spec with that seed and
+// Concurrency 1. It is unbounded; stop after p.Ops.
+func (p Phase) Sequence(numKeys int, seed uint64) iter.Seq[Op] {
+	next := p.opGen(rand.New(rand.NewPCG(seed, 0)), p.numKeys(numKeys), 0, 1)
+	return func(yield func(Op) bool) {
+		for yield(next()) {
+		}
+	}
+}
+
+// Pattern returns the block IDs of p.Sequence(numKeys, seed) as a function
+// of the access index, for simulate.Options.Pattern, which calls it with
+// 0, 1, 2, ... in order. The index itself is ignored.
+func (p Phase) Pattern(numKeys int, seed uint64) func(i int) int {
+	next := p.opGen(rand.New(rand.NewPCG(seed, 0)), p.numKeys(numKeys), 0, 1)
+	return func(int) int { return next().ID }
+}
+
+// numKeys returns the number of block IDs p draws from on a target holding
+// capacity blocks.
+func (p Phase) numKeys(capacity int) int {
+	if p.Keys.Count > 0 {
+		return p.Keys.Count
+	}
+	return capacity
+}
+
+// opGen returns a function producing the accesses of one worker. Trace
+// phases replay the trace with workers interleaved, as Sequential does,
+// starting over at its end.
+func (p Phase) opGen(r *rand.Rand, numKeys, worker, workers int) func() Op {
+	if p.Keys.Distribution == Trace {
+		next := worker
+		return func() Op {
+			op := p.TraceOps[next%len(p.TraceOps)]
+			next += workers
+			return op
+		}
+	}
+	nextKey := p.Keys.keyGen(r, numKeys, worker, workers)
+	return func() Op {
+		id := nextKey()
+		return Op{Read: r.Float64() < p.ReadRatio, ID: id}
+	}
+}
+
+// keyGen returns a function producing block IDs for one worker.
+func (k Keys) keyGen(r *rand.Rand, numKeys, worker, workers int) func() int {
+	switch k.Distribution {
+	case Sequential:
+		// Workers interleave so together they sweep the key range in order.
+		next := worker
+		return func() int {
+			id := next % numKeys
+			next += workers
+			return id
+		}
+	case Zipfian:
+		z := rand.NewZipf(r, k.S, 1, uint64(numKeys-1))
+		return func() int { return int(z.Uint64()) }
+	default:
+		return func() int { return r.IntN(numKeys) }
+	}
+}
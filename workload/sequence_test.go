@@ -0,0 +1,72 @@
+package workload
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// recorder records the accesses a workload performs.
+type recorder struct {
+	*pathoram.PathORAM
+	ops []Op
+}
+
+func (r *recorder) ReadCtx(ctx context.Context, id int) ([]byte, error) {
+	r.ops = append(r.ops, Op{Read: true, ID: id})
+	return r.PathORAM.ReadCtx(ctx, id)
+}
+
+func (r *recorder) WriteCtx(ctx context.Context, id int, data []byte) ([]byte, error) {
+	r.ops = append(r.ops, Op{ID: id})
+	return r.PathORAM.WriteCtx(ctx, id, data)
+}
+
+func TestPhase_Sequence(t *testing.T) {
+	spec := &Spec{Seed: 9, Phases: []Phase{{Ops: 2000, Mix: "read-mostly", Keys: Keys{Distribution: Zipfian}}}}
+	if err := spec.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 256, BlockSize: 16, StashLimit: 200})
+	rec := &recorder{PathORAM: oram}
+	if _, err := Run(context.Background(), rec, spec); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var seq []Op
+	for op := range spec.Phases[0].Sequence(256, 9) {
+		if seq = append(seq, op); len(seq) == len(rec.ops) {
+			break
+		}
+	}
+	if !slices.Equal(seq, rec.ops) {
+		t.Fatal("Sequence differs from the accesses Run performed")
+	}
+
+	// Zipfian skew: block 0 is far more popular than under uniform keys,
+	// and reads dominate.
+	hot, reads := 0, 0
+	for _, op := range seq {
+		if op.ID == 0 {
+			hot++
+		}
+		if op.Read {
+			reads++
+		}
+	}
+	if hot < len(seq)/10 {
+		t.Errorf("block 0 drew %d of %d accesses; want zipfian skew", hot, len(seq))
+	}
+	if reads < len(seq)*9/10 {
+		t.Errorf("%d of %d accesses are reads; want read-mostly", reads, len(seq))
+	}
+
+	pattern := spec.Phases[0].Pattern(256, 9)
+	for i, op := range seq[:100] {
+		if id := pattern(i); id != op.ID {
+			t.Fatalf("Pattern(%d) = %d, want %d", i, id, op.ID)
+		}
+	}
+}
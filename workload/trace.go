@@ -0,0 +1,63 @@
+package workload
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseTrace reads an access trace in CSV: one access per record, an
+// operation ("r", "read", "w" or "write", in any case) and a block ID, then
+// any further columns, such as timestamps, which are ignored. A first record
+// whose block ID is not a number is taken as a header and skipped, as are
+// lines starting with '#'.
+func ParseTrace(r io.Reader) ([]Op, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	var ops []Op
+	for n := 0; ; n++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return ops, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: trace: %v", ErrInvalidSpec, err)
+		}
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("%w: trace record %d has %d fields, want an operation and a block ID", ErrInvalidSpec, n+1, len(rec))
+		}
+		id, err := strconv.Atoi(rec[1])
+		if err != nil && n == 0 {
+			continue // header
+		}
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("%w: trace record %d: bad block ID %q", ErrInvalidSpec, n+1, rec[1])
+		}
+		var op Op
+		switch strings.ToLower(rec[0]) {
+		case "r", "read":
+			op = Op{Read: true, ID: id}
+		case "w", "write":
+			op = Op{ID: id}
+		default:
+			return nil, fmt.Errorf("%w: trace record %d: unknown operation %q", ErrInvalidSpec, n+1, rec[0])
+		}
+		ops = append(ops, op)
+	}
+}
+
+// LoadTrace parses the trace file at path.
+func LoadTrace(path string) ([]Op, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTrace(f)
+}
@@ -0,0 +1,75 @@
+package workload
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func TestParseTrace(t *testing.T) {
+	ops, err := LoadTrace("testdata/trace.csv")
+	if err != nil {
+		t.Fatalf("LoadTrace failed: %v", err)
+	}
+	want := []Op{{true, 3}, {false, 3}, {true, 7}, {true, 3}, {false, 0}, {true, 3}}
+	if !slices.Equal(ops, want) {
+		t.Errorf("ops = %v, want %v", ops, want)
+	}
+
+	for name, doc := range map[string]string{
+		"operation":    "r,1\ndelete,2\n",
+		"negative ID":  "r,-1\n",
+		"bad ID":       "r,1\nw,x\n",
+		"short record": "r\n",
+	} {
+		if _, err := ParseTrace(strings.NewReader(doc)); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%s: got %v, want ErrInvalidSpec", name, err)
+		}
+	}
+}
+
+func TestRun_Trace(t *testing.T) {
+	spec, err := Load("testdata/replay.json")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if p := spec.Phases[1]; p.Keys.Distribution != Trace || len(p.TraceOps) != 6 {
+		t.Fatalf("trace phase = %+v", p)
+	}
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 16, BlockSize: 16, StashLimit: 100})
+	results, err := Run(context.Background(), oram, spec)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// Twelve ops replay the six-access trace twice: four reads per pass.
+	if r := results[1]; r.Reads != 8 || r.Writes != 4 || r.Errors != 0 {
+		t.Errorf("replay phase: %+v", r)
+	}
+
+	small, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 4, BlockSize: 16})
+	if _, err := Run(context.Background(), small, &Spec{Phases: spec.Phases[1:]}); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("trace beyond capacity: got %v, want ErrInvalidSpec", err)
+	}
+}
+
+func TestParse_TraceAndMix(t *testing.T) {
+	spec, err := Parse(strings.NewReader(`{"phases": [{"ops": 1, "mix": "read-mostly"}]}`))
+	if err != nil || spec.Phases[0].ReadRatio != 0.95 {
+		t.Fatalf("mix: spec = %+v, err = %v", spec, err)
+	}
+	for name, doc := range map[string]string{
+		"unknown mix":       `{"phases": [{"ops": 1, "mix": "mostly"}]}`,
+		"mix and ratio":     `{"phases": [{"ops": 1, "mix": "read-only", "read_ratio": 0.5}]}`,
+		"trace and ratio":   `{"phases": [{"ops": 1, "trace": "testdata/trace.csv", "read_ratio": 0.5}]}`,
+		"trace and zipfian": `{"phases": [{"ops": 1, "trace": "testdata/trace.csv", "keys": {"distribution": "zipfian"}}]}`,
+		"no trace":          `{"phases": [{"ops": 1, "keys": {"distribution": "trace"}}]}`,
+	} {
+		if _, err := Parse(strings.NewReader(doc)); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%s: got %v, want ErrInvalidSpec", name, err)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package workload describes and runs scripted PathORAM workloads.
+//
+// A workload is a JSON document listing phases. Each phase runs for a fixed
+// number of operations or a duration, with its own key distribution,
+// read/write ratio, value size and concurrency:
+//
+//	{
+//	  "name": "sessions",
+//	  "seed": 42,
+//	  "phases": [
+//	    {"name": "load", "ops": 4096, "read_ratio": 0, "keys": {"distribution": "sequential"}},
+//	    {"name": "steady", "duration": "30s", "read_ratio": 0.9, "concurrency": 8,
+//	     "keys": {"distribution": "zipfian", "s": 1.1}, "value_size": 256},
+//	    {"name": "replay", "ops": 100000, "trace": "prod-accesses.csv"}
+//	  ]
+//	}
+//
+// A phase either draws block IDs from a distribution, reading with
+// probability read_ratio (or the named "mix", such as "read-mostly"), or
+// replays a recorded trace (see ParseTrace). Phase.Sequence and
+// Phase.Pattern produce the same access sequences without an ORAM, e.g. for
+// package simulate.
+//
+// The same file drives cmd/pathoram-bench and cmd/pathoram-soak, so a
+// production-like workload is written once and reused across parameter
+// studies.
+package workload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrInvalidSpec is returned for malformed or inconsistent workload files.
+var ErrInvalidSpec = errors.New("invalid workload spec")
+
+// Key distributions.
+const (
+	Uniform    = "uniform"
+	Zipfian    = "zipfian"
+	Sequential = "sequential"
+	Trace      = "trace" // set for phases that replay Phase.Trace
+)
+
+// mixes are the named read ratios a phase may set as Mix, after the YCSB
+// core workloads.
+var mixes = map[string]float64{
+	"read-only":    1,
+	"read-mostly":  0.95, // YCSB B
+	"update-heavy": 0.5,  // YCSB A
+	"write-only":   0,
+}
+
+// Spec is a complete workload.
+type Spec struct {
+	Name   string  `json:"name"`
+	Seed   uint64  `json:"seed"` // 0 = random
+	Phases []Phase `json:"phases"`
+}
+
+// Phase is one stage of a workload. Exactly one of Ops and Duration is set.
+type Phase struct {
+	Name        string   `json:"name"`
+	Ops         int      `json:"ops,omitempty"`
+	Duration    Duration `json:"duration,omitempty"`
+	ReadRatio   float64  `json:"read_ratio"`            // fraction of reads, 0..1
+	Mix         string   `json:"mix,omitempty"`         // named read ratio instead of ReadRatio: read-only, read-mostly, update-heavy, write-only
+	Keys        Keys     `json:"keys"`                  // block ID distribution
+	Trace       string   `json:"trace,omitempty"`       // CSV trace to replay instead of Keys and ReadRatio
+	ValueSize   int      `json:"value_size,omitempty"`  // random bytes per write (default: block size)
+	Concurrency int      `json:"concurrency,omitempty"` // workers (default: 1)
+
+	// TraceOps is the trace the phase replays. Validate loads it from Trace
+	// if it is nil; set it directly to replay a trace built in code.
+	TraceOps []Op `json:"-"`
+}
+
+// Keys selects block IDs.
+type Keys struct {
+	Distribution string  `json:"distribution"`    // uniform (default), zipfian, sequential
+	S            float64 `json:"s,omitempty"`     // zipfian skew, > 1 (default: 1.1)
+	Count        int     `json:"count,omitempty"` // use IDs 0..Count-1 (default: all)
+}
+
+// Duration is a time.Duration written as a string such as "30s".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("%w: duration must be a string: %v", ErrInvalidSpec, err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Parse reads a JSON workload and fills in defaults. Relative trace paths
+// are relative to the working directory.
+func Parse(r io.Reader) (*Spec, error) {
+	s, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Load parses the workload file at path. Relative trace paths are relative
+// to the file's directory.
+func Load(path string) (*Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := decode(f)
+	if err != nil {
+		return nil, err
+	}
+	for i := range s.Phases {
+		if p := &s.Phases[i]; p.Trace != "" && !filepath.IsAbs(p.Trace) {
+			p.Trace = filepath.Join(filepath.Dir(path), p.Trace)
+		}
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func decode(r io.Reader) (*Spec, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var s Spec
+	if err := dec.Decode(&s); err != nil {
+		if errors.Is(err, ErrInvalidSpec) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	return &s, nil
+}
+
+// Validate checks s, fills in defaults and loads trace files. Parse calls
+// it; call it on a Spec built in code before passing it to Run.
+func (s *Spec) Validate() error {
+	if len(s.Phases) == 0 {
+		return fmt.Errorf("%w: no phases", ErrInvalidSpec)
+	}
+	for i := range s.Phases {
+		p := &s.Phases[i]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("phase%d", i)
+		}
+		if (p.Ops > 0) == (p.Duration > 0) {
+			return fmt.Errorf("%w: phase %q must set exactly one of ops and duration", ErrInvalidSpec, p.Name)
+		}
+		if p.Ops < 0 || p.Duration < 0 || p.ValueSize < 0 || p.Concurrency < 0 || p.Keys.Count < 0 {
+			return fmt.Errorf("%w: phase %q has a negative field", ErrInvalidSpec, p.Name)
+		}
+		if p.ReadRatio < 0 || p.ReadRatio > 1 {
+			return fmt.Errorf("%w: phase %q read_ratio must be in [0, 1]", ErrInvalidSpec, p.Name)
+		}
+		if p.Concurrency == 0 {
+			p.Concurrency = 1
+		}
+		if p.Mix != "" {
+			ratio, ok := mixes[p.Mix]
+			if !ok {
+				return fmt.Errorf("%w: phase %q has unknown mix %q", ErrInvalidSpec, p.Name, p.Mix)
+			}
+			if p.ReadRatio != 0 {
+				return fmt.Errorf("%w: phase %q sets both mix and read_ratio", ErrInvalidSpec, p.Name)
+			}
+			p.ReadRatio = ratio
+		}
+		if p.Trace != "" || p.TraceOps != nil {
+			if err := p.loadTrace(); err != nil {
+				return err
+			}
+			continue
+		}
+		switch p.Keys.Distribution {
+		case "":
+			p.Keys.Distribution = Uniform
+		case Uniform, Sequential:
+		case Zipfian:
+			if p.Keys.S == 0 {
+				p.Keys.S = 1.1
+			}
+			if p.Keys.S <= 1 {
+				return fmt.Errorf("%w: phase %q zipfian s must be > 1", ErrInvalidSpec, p.Name)
+			}
+		case Trace:
+			return fmt.Errorf("%w: phase %q has distribution %q but no trace", ErrInvalidSpec, p.Name, Trace)
+		default:
+			return fmt.Errorf("%w: phase %q has unknown distribution %q", ErrInvalidSpec, p.Name, p.Keys.Distribution)
+		}
+	}
+	return nil
+}
+
+// loadTrace checks a trace phase and loads its trace.
+func (p *Phase) loadTrace() error {
+	if p.Keys.Distribution != "" && p.Keys.Distribution != Trace {
+		return fmt.Errorf("%w: phase %q sets both a trace and distribution %q", ErrInvalidSpec, p.Name, p.Keys.Distribution)
+	}
+	if p.ReadRatio != 0 || p.Keys.Count != 0 {
+		return fmt.Errorf("%w: phase %q replays a trace; its reads and keys come from the trace", ErrInvalidSpec, p.Name)
+	}
+	p.Keys.Distribution = Trace
+	if p.TraceOps == nil {
+		ops, err := LoadTrace(p.Trace)
+		if err != nil {
+			return fmt.Errorf("phase %q: %w", p.Name, err)
+		}
+		p.TraceOps = ops
+	}
+	if len(p.TraceOps) == 0 {
+		return fmt.Errorf("%w: phase %q trace is empty", ErrInvalidSpec, p.Name)
+	}
+	return nil
+}
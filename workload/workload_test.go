@@ -0,0 +1,85 @@
+package workload
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func TestLoad(t *testing.T) {
+	spec, err := Load("testdata/example.json")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(spec.Phases) != 3 || spec.Seed != 42 {
+		t.Fatalf("unexpected spec: %+v", spec)
+	}
+	hot := spec.Phases[2]
+	if time.Duration(hot.Duration) != 20*time.Millisecond || hot.Keys.Distribution != Uniform || hot.Concurrency != 1 {
+		t.Errorf("defaults not applied: %+v", hot)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no phases":     `{"phases": []}`,
+		"ops and time":  `{"phases": [{"ops": 1, "duration": "1s"}]}`,
+		"neither":       `{"phases": [{"read_ratio": 0.5}]}`,
+		"ratio":         `{"phases": [{"ops": 1, "read_ratio": 1.5}]}`,
+		"distribution":  `{"phases": [{"ops": 1, "keys": {"distribution": "pareto"}}]}`,
+		"zipf skew":     `{"phases": [{"ops": 1, "keys": {"distribution": "zipfian", "s": 0.5}}]}`,
+		"bad duration":  `{"phases": [{"duration": "soon"}]}`,
+		"unknown field": `{"phases": [{"ops": 1, "reads": 5}]}`,
+	}
+	for name, doc := range tests {
+		if _, err := Parse(strings.NewReader(doc)); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%s: got %v, want ErrInvalidSpec", name, err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	spec, err := Load("testdata/example.json")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: 64, BlockSize: 16, StashLimit: 200})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	results, err := Run(context.Background(), oram, spec)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if r := results[0]; r.Ops != 64 || r.Writes != 64 || r.Errors != 0 {
+		t.Errorf("load phase: %+v", r)
+	}
+	if r := results[1]; r.Ops != 400 || r.Reads == 0 || r.Writes == 0 || r.P99 < r.P50 {
+		t.Errorf("steady phase: %+v", r)
+	}
+	if r := results[2]; r.Ops == 0 || r.Errors != 0 {
+		t.Errorf("hot phase: %+v", r)
+	}
+	// The load phase wrote every block sequentially.
+	for id := range 64 {
+		data, _ := oram.Read(id)
+		if string(data) == string(make([]byte, 16)) {
+			t.Errorf("block %d was never written", id)
+		}
+	}
+}
+
+func TestRun_ValueTooLarge(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 8, BlockSize: 16})
+	spec, _ := Parse(strings.NewReader(`{"phases": [{"ops": 1, "value_size": 32}]}`))
+	if _, err := Run(context.Background(), oram, spec); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("got %v, want ErrInvalidSpec", err)
+	}
+}
@@ -0,0 +1,223 @@
+package pathoram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// writeBack buffers bucket writes in client memory and writes them to the
+// backend from a background goroutine, several buckets per WriteBuckets
+// call (Config.MaxDirtyBuckets). Reads of a dirty bucket are served from
+// the buffer, so the ORAM always sees its own writes.
+type writeBack struct {
+	StorageV2 // the backend
+
+	limit int
+
+	flushMu  sync.Mutex      // serializes flushes, so writes reach the backend in order
+	mu       sync.Mutex      // guards the fields below; never held while taking flushMu
+	dirty    map[int][]Block // written, not yet sent to the backend
+	inflight map[int][]Block // being written by the current flush
+	err      error           // last background flush error, returned by the next access
+
+	wake    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newWriteBack(s StorageV2, limit int) *writeBack {
+	w := &writeBack{
+		StorageV2: s,
+		limit:     limit,
+		dirty:     make(map[int][]Block),
+		inflight:  make(map[int][]Block),
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+// loop flushes the dirty buckets whenever a write arrives, until close.
+func (w *writeBack) loop() {
+	defer close(w.stopped)
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.wake:
+			if err := w.flush(); err != nil {
+				w.mu.Lock()
+				w.err = err
+				w.mu.Unlock()
+			}
+		}
+	}
+}
+
+// flush sends every dirty bucket to the backend in one WriteBuckets call.
+// Buckets that fail stay dirty, to be retried by the next flush.
+func (w *writeBack) flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	if len(w.dirty) == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	idxs := make([]int, 0, len(w.dirty))
+	for idx := range w.dirty {
+		idxs = append(idxs, idx)
+	}
+	slices.Sort(idxs)
+	buckets := make([][]Block, len(idxs))
+	for i, idx := range idxs {
+		buckets[i] = w.dirty[idx]
+		w.inflight[idx] = buckets[i]
+		delete(w.dirty, idx)
+	}
+	w.mu.Unlock()
+
+	err := w.StorageV2.WriteBuckets(context.Background(), idxs, buckets)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, idx := range idxs {
+		if _, rewritten := w.dirty[idx]; err != nil && !rewritten {
+			w.dirty[idx] = buckets[i]
+		}
+		delete(w.inflight, idx)
+	}
+	if err != nil {
+		return fmt.Errorf("write-back of %d buckets: %w", len(idxs), err)
+	}
+	return nil
+}
+
+// takeErr returns and clears the last background flush error.
+func (w *writeBack) takeErr() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// close flushes the buffer and stops the goroutine. The goroutine is
+// stopped even if the flush fails.
+func (w *writeBack) close() error {
+	close(w.stop)
+	<-w.stopped
+	return w.flush()
+}
+
+// buffered returns a copy of bucket idx if it is dirty or being flushed.
+func (w *writeBack) buffered(idx int) ([]Block, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b, ok := w.dirty[idx]
+	if !ok {
+		b, ok = w.inflight[idx]
+	}
+	if !ok {
+		return nil, false
+	}
+	return cloneBucket(b), true
+}
+
+func (w *writeBack) ReadBucket(ctx context.Context, idx int) ([]Block, error) {
+	if b, ok := w.buffered(idx); ok {
+		return b, nil
+	}
+	return w.StorageV2.ReadBucket(ctx, idx)
+}
+
+func (w *writeBack) ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error) {
+	buckets := make([][]Block, len(idxs))
+	var missed []int
+	for i, idx := range idxs {
+		var ok bool
+		if buckets[i], ok = w.buffered(idx); !ok {
+			missed = append(missed, i)
+		}
+	}
+	if len(missed) == 0 {
+		return buckets, nil
+	}
+	missIdxs := make([]int, len(missed))
+	for j, i := range missed {
+		missIdxs[j] = idxs[i]
+	}
+	read, err := w.StorageV2.ReadBuckets(ctx, missIdxs)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missed {
+		buckets[i] = read[j]
+	}
+	return buckets, nil
+}
+
+func (w *writeBack) WriteBucket(ctx context.Context, idx int, blocks []Block) error {
+	return w.WriteBuckets(ctx, []int{idx}, [][]Block{blocks})
+}
+
+// WriteBuckets buffers the buckets and wakes the flusher. If that leaves
+// more than limit buckets dirty, it flushes them itself before returning, so
+// a backend that falls behind slows the ORAM down instead of growing the
+// buffer.
+func (w *writeBack) WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error {
+	w.mu.Lock()
+	for i, idx := range idxs {
+		w.dirty[idx] = cloneBucket(buckets[i])
+	}
+	full := len(w.dirty) > w.limit
+	w.mu.Unlock()
+	if full {
+		return w.flush()
+	}
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// cloneBucket deep-copies a bucket: the ORAM reuses the Data slices it
+// writes (Config.ReuseBuffers) and edits the buckets it reads.
+func cloneBucket(blocks []Block) []Block {
+	c := make([]Block, len(blocks))
+	for i, b := range blocks {
+		c[i] = Block{ID: b.ID, Leaf: b.Leaf, Data: slices.Clone(b.Data)}
+	}
+	return c
+}
+
+// flushWriteBack writes all buffered buckets to the backend.
+func (o *PathORAM) flushWriteBack() error {
+	if o.writeBack == nil {
+		return nil
+	}
+	return o.writeBack.flush()
+}
+
+// takeWriteBackErr returns and clears the last background write-back error.
+func (o *PathORAM) takeWriteBackErr() error {
+	if o.writeBack == nil {
+		return nil
+	}
+	return o.writeBack.takeErr()
+}
+
+// stopWriteBack flushes the buffer and stops its goroutine for good.
+func (o *PathORAM) stopWriteBack() error {
+	if o.writeBack == nil {
+		return nil
+	}
+	w := o.writeBack
+	o.writeBack, o.store = nil, w.StorageV2
+	return w.close()
+}
@@ -0,0 +1,153 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// laggyStorage is a BatchStorage whose writes take a while and can be made
+// to fail.
+type laggyStorage struct {
+	*InMemoryStorage
+
+	mu       sync.Mutex
+	batches  int
+	maxBatch int
+	fail     bool
+}
+
+func (s *laggyStorage) ReadBuckets(ctx context.Context, idxs []int) ([][]Block, error) {
+	return AdaptStorage(s.InMemoryStorage).ReadBuckets(ctx, idxs)
+}
+
+func (s *laggyStorage) WriteBuckets(ctx context.Context, idxs []int, buckets [][]Block) error {
+	time.Sleep(200 * time.Microsecond)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("object store unavailable")
+	}
+	s.batches++
+	s.maxBatch = max(s.maxBatch, len(idxs))
+	return AdaptStorage(s.InMemoryStorage).WriteBuckets(ctx, idxs, buckets)
+}
+
+func (s *laggyStorage) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+func newWriteBackTestORAM(t *testing.T) (*PathORAM, *laggyStorage) {
+	t.Helper()
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 4, StashLimit: 200, MaxDirtyBuckets: 32}
+	_, _, total := cfg.ComputeTreeParams()
+	storage := &laggyStorage{InMemoryStorage: NewInMemoryStorage(total, 4, 16)}
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(func() { oram.Close() })
+	return oram, storage
+}
+
+func TestWriteBack(t *testing.T) {
+	oram, storage := newWriteBackTestORAM(t)
+	for round := range 3 {
+		for id := range 64 {
+			if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id + round)}, 16)); err != nil {
+				t.Fatalf("Write(%d) failed: %v", id, err)
+			}
+		}
+	}
+	for id := range 64 {
+		got, err := oram.Read(id)
+		if err != nil {
+			t.Fatalf("Read(%d) failed: %v", id, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(id + 2)}, 16)) {
+			t.Errorf("Read(%d) = %x", id, got)
+		}
+	}
+	if err := oram.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// Everything has reached storage
+	report, err := Fsck(storage.InMemoryStorage, oram.posMap)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if err := report.Err(); err != nil || report.TreeBlocks != 64 {
+		t.Errorf("after Flush: %d blocks in storage, %v", report.TreeBlocks, err)
+	}
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if storage.maxBatch <= oram.Height() {
+		t.Errorf("largest write batch %d buckets, want more than one path (%d)", storage.maxBatch, oram.Height())
+	}
+}
+
+func TestWriteBack_Errors(t *testing.T) {
+	oram, storage := newWriteBackTestORAM(t)
+	want := bytes.Repeat([]byte{9}, 16)
+	if _, err := oram.Write(5, want); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := oram.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	storage.setFail(true)
+	var failed error
+	for id := 0; id < 64 && failed == nil; id++ {
+		_, failed = oram.Read(id)
+	}
+	if failed == nil {
+		t.Fatal("no access reported the failed write-back")
+	}
+	if err := oram.Flush(t.Context()); err == nil {
+		t.Error("Flush succeeded while storage fails")
+	}
+
+	// Failed buckets stay buffered and reach storage once it recovers
+	// (the first attempts may still report failures from before recovery)
+	storage.setFail(false)
+	var err error
+	for range 3 {
+		if err = oram.Flush(t.Context()); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("Flush after recovery failed: %v", err)
+	}
+	if got, err := oram.Read(5); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Read(5) = %x, %v; want %x", got, err, want)
+	}
+
+	cfg := Config{NumBlocks: 64, BlockSize: 16, BucketSize: 5, MaxDirtyBuckets: -1}
+	if _, err := cfg.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("negative MaxDirtyBuckets: error = %v, want ErrInvalidConfig", err)
+	}
+	cfg.MaxDirtyBuckets, cfg.SingleBlockReads = 8, true
+	_, _, total := cfg.ComputeTreeParams()
+	if _, err := New(cfg, NewInMemoryStorage(total, 5, 16), NewInMemoryPositionMap(), NoOpEncryptor{}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("MaxDirtyBuckets with SingleBlockReads: error = %v, want ErrInvalidConfig", err)
+	}
+	cfg.SingleBlockReads, cfg.VerifyIntegrity = false, true
+	if _, err := New(cfg, NewInMemoryStorage(total, 5, 16), NewInMemoryPositionMap(), NoOpEncryptor{}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("MaxDirtyBuckets with VerifyIntegrity: error = %v, want ErrInvalidConfig", err)
+	}
+	rp, err := NewReplayProtector(bytes.Repeat([]byte{1}, 32), NewFileCounterStore(t.TempDir()+"/counter"))
+	if err != nil {
+		t.Fatalf("NewReplayProtector failed: %v", err)
+	}
+	if err := oram.EnableReplayProtection(rp); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("EnableReplayProtection with MaxDirtyBuckets: error = %v, want ErrInvalidConfig", err)
+	}
+}
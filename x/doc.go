@@ -0,0 +1,14 @@
+// Package x is the home of experimental pathoram subsystems: new ORAM
+// schemes (such as Ring ORAM), oblivious data structures, access schedulers
+// and filesystem front ends land in packages under x/ while their APIs
+// settle. Packages here may
+// change or be removed in any release; once an API is frozen it moves to
+// the stable v1 package. Nothing in v1 imports x.
+//
+// The access scheduler, key-value store, file and filesystem layers live
+// here too, in x/padded, x/kv, x/oramfile and the x/oramfs module, as does
+// x/gcmsiv's hand-written AES-GCM-SIV until it has had a constant-time
+// review. The
+// admin and server packages keep their import paths outside v1 and carry
+// the same no-compatibility guarantee as x/.
+package x
@@ -0,0 +1,333 @@
+// Package gcmsiv provides a nonce-misuse-resistant AES-256-GCM-SIV
+// (RFC 8452) pathoram.Encryptor. Importing it is the opt-in: the root
+// package's encryptors all use crypto/cipher's AES-GCM.
+//
+// POLYVAL and the GCM-SIV construction are implemented here in portable
+// Go. The carry-less multiply avoids secret-dependent branches and table
+// lookups, and AES comes from crypto/aes, but the code has not been
+// audited for constant-time behavior on any platform and has no assembly
+// or hardware acceleration. Prefer pathoram.AESGCMEncryptor unless nonce
+// reuse is the larger concern. Like everything under x/, the API may
+// change.
+package gcmsiv
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+const (
+	keySize   = 32
+	nonceSize = 12
+	tagSize   = 16
+)
+
+// Encryptor provides AES-256-GCM-SIV encryption, which stays secure when a
+// nonce repeats: a repeated nonce reveals only whether two ciphertexts
+// encrypt the same plaintext for the same block and leaf, where AES-GCM
+// would leak the XOR of the plaintexts and its authentication key. Use it
+// where the sheer number of bucket re-encryptions, or an unreliable random
+// source, makes nonce reuse a concern.
+//
+// Nonces are derived, not drawn: each is a hash of the block ID, leaf and a
+// per-encryptor counter whose starting point is random. Within one process
+// they never repeat, and across processes they repeat only if the random
+// source fails, which GCM-SIV tolerates. It passes over the data twice and
+// computes POLYVAL in portable Go, so it is an order of magnitude slower
+// than AES-GCM on hardware with AES and carry-less multiply instructions.
+// It binds pathoram.BlockAAD like the built-in encryptors.
+type Encryptor struct {
+	// Rand seeds the nonce counter and fills dummy blocks; nil means
+	// crypto/rand. pathoram.Config.Rand does not reach it. Set it before
+	// the first Encrypt.
+	Rand io.Reader
+
+	siv *gcmSIV
+	fp  []byte // KeyFingerprint of the key
+
+	mu      sync.Mutex
+	counter uint64
+	seeded  bool
+}
+
+var _ pathoram.BufferedEncryptor = (*Encryptor)(nil)
+
+// New creates an AES-256-GCM-SIV encryptor with the given 32-byte key.
+func New(key []byte) (*Encryptor, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	siv, err := newGCMSIV(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Encryptor{siv: siv, fp: pathoram.KeyFingerprint(key)}, nil
+}
+
+// Encrypt encrypts plaintext with a derived nonce.
+// Output format: nonce (12 bytes) || ciphertext || tag (16 bytes)
+func (e *Encryptor) Encrypt(blockID, leaf int, plaintext []byte) ([]byte, error) {
+	return e.EncryptAppend(nil, blockID, leaf, plaintext)
+}
+
+// EncryptAppend is like Encrypt but appends the output to dst.
+func (e *Encryptor) EncryptAppend(dst []byte, blockID, leaf int, plaintext []byte) ([]byte, error) {
+	if e.siv == nil {
+		return nil, pathoram.ErrEncryptionFailed // wiped
+	}
+	n := len(dst)
+	dst = slices.Grow(dst, nonceSize+len(plaintext)+tagSize)[:n+nonceSize]
+	e.nonce(dst[n:], blockID, leaf)
+	return e.siv.seal(dst, dst[n:], plaintext, pathoram.BlockAAD(blockID, leaf)), nil
+}
+
+// nonce derives the next nonce for blockID and leaf into dst.
+func (e *Encryptor) nonce(dst []byte, blockID, leaf int) {
+	e.mu.Lock()
+	if !e.seeded {
+		// A failed read leaves the counter at zero: nonces may then repeat
+		// across processes, which GCM-SIV tolerates
+		var seed [8]byte
+		io.ReadFull(e.random(), seed[:])
+		e.counter, e.seeded = binary.LittleEndian.Uint64(seed[:]), true
+	}
+	counter := e.counter
+	e.counter++
+	e.mu.Unlock()
+
+	var in [24]byte
+	binary.LittleEndian.PutUint64(in[0:], uint64(blockID))
+	binary.LittleEndian.PutUint64(in[8:], uint64(leaf))
+	binary.LittleEndian.PutUint64(in[16:], counter)
+	sum := sha256.Sum256(in[:])
+	copy(dst, sum[:nonceSize])
+}
+
+// EncryptDummy encrypts size random bytes as block pathoram.EmptyBlockID at
+// leaf -1.
+func (e *Encryptor) EncryptDummy(size int) ([]byte, error) {
+	padding := make([]byte, size)
+	if _, err := io.ReadFull(e.random(), padding); err != nil {
+		return nil, pathoram.ErrEncryptionFailed
+	}
+	return e.EncryptAppend(nil, pathoram.EmptyBlockID, -1, padding)
+}
+
+// Decrypt decrypts ciphertext produced by Encrypt for the same block and leaf.
+func (e *Encryptor) Decrypt(blockID, leaf int, ciphertext []byte) ([]byte, error) {
+	return e.DecryptAppend(nil, blockID, leaf, ciphertext)
+}
+
+// DecryptAppend is like Decrypt but appends the plaintext to dst.
+func (e *Encryptor) DecryptAppend(dst []byte, blockID, leaf int, ciphertext []byte) ([]byte, error) {
+	if e.siv == nil || len(ciphertext) < nonceSize+tagSize {
+		return nil, pathoram.ErrDecryptionFailed
+	}
+	plaintext, ok := e.siv.open(dst, ciphertext[:nonceSize], ciphertext[nonceSize:], pathoram.BlockAAD(blockID, leaf))
+	if !ok {
+		return nil, pathoram.ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// Overhead returns nonce size + tag size.
+func (e *Encryptor) Overhead() int {
+	return nonceSize + tagSize
+}
+
+// Wipe drops the cipher and makes every later call fail. The expanded key
+// is not zeroed (see pathoram.Wiper).
+func (e *Encryptor) Wipe() { e.siv = nil }
+
+// KeyFingerprint returns the fingerprint of the key.
+func (e *Encryptor) KeyFingerprint() []byte { return bytes.Clone(e.fp) }
+
+// random returns e.Rand, or crypto/rand.Reader if it is nil.
+func (e *Encryptor) random() io.Reader {
+	if e.Rand == nil {
+		return rand.Reader
+	}
+	return e.Rand
+}
+
+// gcmSIV implements AEAD_AES_128_GCM_SIV and AEAD_AES_256_GCM_SIV from
+// RFC 8452 with 12-byte nonces and 16-byte tags.
+type gcmSIV struct {
+	kgk cipher.Block // key-generating key
+	key int          // key size, which is also the encryption key's
+}
+
+func newGCMSIV(key []byte) (*gcmSIV, error) {
+	kgk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create AES cipher: %w", err)
+	}
+	return &gcmSIV{kgk: kgk, key: len(key)}, nil
+}
+
+// deriveKeys returns the per-nonce POLYVAL key and encryption cipher.
+func (g *gcmSIV) deriveKeys(nonce []byte) (authKey [16]byte, enc cipher.Block) {
+	var in, out [16]byte
+	copy(in[4:], nonce)
+	encKey := make([]byte, g.key)
+	for i := range 2 + g.key/8 {
+		binary.LittleEndian.PutUint32(in[:4], uint32(i))
+		g.kgk.Encrypt(out[:], in[:])
+		if i < 2 {
+			copy(authKey[8*i:], out[:8])
+		} else {
+			copy(encKey[8*(i-2):], out[:8])
+		}
+	}
+	enc, _ = aes.NewCipher(encKey) // the size is valid
+	clear(encKey)
+	return authKey, enc
+}
+
+// tag computes the tag of plaintext and aad under the derived keys.
+func (g *gcmSIV) tag(authKey [16]byte, enc cipher.Block, nonce, plaintext, aad []byte) [16]byte {
+	var p polyval
+	p.init(authKey)
+	p.update(aad)
+	p.update(plaintext)
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[0:], uint64(len(aad))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+	p.update(lengths[:])
+	s := p.sum()
+	subtle.XORBytes(s[:12], s[:12], nonce)
+	s[15] &= 0x7f
+	var tag [16]byte
+	enc.Encrypt(tag[:], s[:])
+	return tag
+}
+
+// ctr XORs src with the keystream for tag into dst.
+func ctr(enc cipher.Block, tag [16]byte, dst, src []byte) {
+	block := tag
+	block[15] |= 0x80
+	var ks [16]byte
+	for len(src) > 0 {
+		enc.Encrypt(ks[:], block[:])
+		n := subtle.XORBytes(dst, src, ks[:])
+		dst, src = dst[n:], src[n:]
+		binary.LittleEndian.PutUint32(block[:4], binary.LittleEndian.Uint32(block[:4])+1)
+	}
+}
+
+// seal appends the encryption of plaintext and its tag to dst.
+func (g *gcmSIV) seal(dst, nonce, plaintext, aad []byte) []byte {
+	authKey, enc := g.deriveKeys(nonce)
+	tag := g.tag(authKey, enc, nonce, plaintext, aad)
+	n := len(dst)
+	dst = slices.Grow(dst, len(plaintext)+16)[:n+len(plaintext)]
+	ctr(enc, tag, dst[n:], plaintext)
+	return append(dst, tag[:]...)
+}
+
+// open appends the decryption of ciphertext (with its tag) to dst, or
+// reports false if it does not authenticate.
+func (g *gcmSIV) open(dst, nonce, ciphertext, aad []byte) ([]byte, bool) {
+	if len(ciphertext) < 16 {
+		return nil, false
+	}
+	authKey, enc := g.deriveKeys(nonce)
+	var tag [16]byte
+	ct := ciphertext[:len(ciphertext)-16]
+	copy(tag[:], ciphertext[len(ct):])
+	n := len(dst)
+	dst = slices.Grow(dst, len(ct))[:n+len(ct)]
+	ctr(enc, tag, dst[n:], ct)
+	want := g.tag(authKey, enc, nonce, dst[n:], aad)
+	if subtle.ConstantTimeCompare(tag[:], want[:]) != 1 {
+		clear(dst[n:])
+		return nil, false
+	}
+	return dst, true
+}
+
+// polyval computes POLYVAL (RFC 8452, section 3) over zero-padded inputs.
+type polyval struct {
+	h, s [2]uint64 // key and accumulator, little-endian words
+}
+
+func (p *polyval) init(key [16]byte) {
+	p.h = [2]uint64{binary.LittleEndian.Uint64(key[:8]), binary.LittleEndian.Uint64(key[8:])}
+	p.s = [2]uint64{}
+}
+
+// update absorbs data, zero-padded to a multiple of 16 bytes.
+func (p *polyval) update(data []byte) {
+	for len(data) > 0 {
+		var block [16]byte
+		n := copy(block[:], data)
+		data = data[n:]
+		p.s[0] ^= binary.LittleEndian.Uint64(block[:8])
+		p.s[1] ^= binary.LittleEndian.Uint64(block[8:])
+		p.s = polyvalDot(p.s, p.h)
+	}
+}
+
+func (p *polyval) sum() [16]byte {
+	var out [16]byte
+	binary.LittleEndian.PutUint64(out[:8], p.s[0])
+	binary.LittleEndian.PutUint64(out[8:], p.s[1])
+	return out
+}
+
+// polyvalDot returns a·b·x⁻¹²⁸ in GF(2¹²⁸) modulo
+// x¹²⁸ + x¹²⁷ + x¹²⁶ + x¹²¹ + 1.
+func polyvalDot(a, b [2]uint64) [2]uint64 {
+	// 256-bit carry-less product, c[0] lowest
+	var c [4]uint64
+	for i, ai := range a {
+		for j, bj := range b {
+			hi, lo := clmul(ai, bj)
+			c[i+j] ^= lo
+			c[i+j+1] ^= hi
+		}
+	}
+	// Clear the low 128 bits by adding multiples of the modulus, one word
+	// at a time; what remains, shifted down, is the product times x⁻¹²⁸
+	for i := range 2 {
+		t := c[i]
+		c[i] = 0
+		// t·(x¹²¹ + x¹²⁶ + x¹²⁷ + x¹²⁸), shifted to word i
+		c[i+1] ^= t<<57 ^ t<<62 ^ t<<63
+		c[i+2] ^= t>>7 ^ t>>2 ^ t>>1 ^ t
+	}
+	return [2]uint64{c[2], c[3]}
+}
+
+// clmul returns the 128-bit carry-less product of x and y in constant
+// time, with integer multiplications on bits spaced four apart so carries
+// fall into the holes (as in BearSSL's ghash_ctmul64).
+func clmul(x, y uint64) (hi, lo uint64) {
+	lo = bmul64(x, y)
+	hi = bits.Reverse64(bmul64(bits.Reverse64(x), bits.Reverse64(y))) >> 1
+	return hi, lo
+}
+
+// bmul64 returns the low 64 bits of the carry-less product of x and y.
+func bmul64(x, y uint64) uint64 {
+	const m0, m1, m2, m3 = 0x1111111111111111, 0x2222222222222222, 0x4444444444444444, 0x8888888888888888
+	x0, x1, x2, x3 := x&m0, x&m1, x&m2, x&m3
+	y0, y1, y2, y3 := y&m0, y&m1, y&m2, y&m3
+	z0 := x0*y0 ^ x1*y3 ^ x2*y2 ^ x3*y1
+	z1 := x0*y1 ^ x1*y0 ^ x2*y3 ^ x3*y2
+	z2 := x0*y2 ^ x1*y1 ^ x2*y0 ^ x3*y3
+	z3 := x0*y3 ^ x1*y2 ^ x2*y1 ^ x3*y0
+	return z0&m0 | z1&m1 | z2&m2 | z3&m3
+}
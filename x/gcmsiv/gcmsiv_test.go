@@ -0,0 +1,110 @@
+package gcmsiv
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// Test vectors from RFC 8452, appendices A and C.
+func TestGCMSIV_Vectors(t *testing.T) {
+	var p polyval
+	p.init([16]byte(unhex(t, "25629347589242761d31f826ba4b757b")))
+	p.update(unhex(t, "4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362"))
+	if got, want := p.sum(), unhex(t, "f7a3b47b846119fae5b7866cf5e5b77e"); !bytes.Equal(got[:], want) {
+		t.Errorf("POLYVAL = %x, want %x", got, want)
+	}
+
+	for _, tt := range []struct {
+		key, nonce, plaintext, aad, result string
+	}{
+		{"01000000000000000000000000000000", "030000000000000000000000", "", "", "dc20e2d83f25705bb49e439eca56de25"},
+		{"01000000000000000000000000000000", "030000000000000000000000", "0100000000000000", "", "b5d839330ac7b786578782fff6013b815b287c22493a364c"},
+		{"0100000000000000000000000000000000000000000000000000000000000000", "030000000000000000000000", "", "", "07f5f4169bbf55a8400cd47ea6fd400f"},
+	} {
+		g, err := newGCMSIV(unhex(t, tt.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce, plaintext, aad := unhex(t, tt.nonce), unhex(t, tt.plaintext), unhex(t, tt.aad)
+		got := g.seal(nil, nonce, plaintext, aad)
+		if want := unhex(t, tt.result); !bytes.Equal(got, want) {
+			t.Errorf("key %s, plaintext %q: seal = %x, want %x", tt.key, tt.plaintext, got, want)
+		}
+		if opened, ok := g.open(nil, nonce, got, aad); !ok || !bytes.Equal(opened, plaintext) {
+			t.Errorf("key %s: open = %x, %v", tt.key, opened, ok)
+		}
+	}
+}
+
+func TestEncryptor(t *testing.T) {
+	if _, err := New(make([]byte, 16)); err == nil {
+		t.Error("expected error for 16-byte key")
+	}
+	enc, err := New(bytes.Repeat([]byte{3}, 32))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	plaintext := bytes.Repeat([]byte("block data "), 20)
+	ct, err := enc.Encrypt(4, 9, plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if len(ct) != len(plaintext)+enc.Overhead() {
+		t.Errorf("ciphertext length %d, want %d", len(ct), len(plaintext)+enc.Overhead())
+	}
+	if got, err := enc.Decrypt(4, 9, ct); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("Decrypt = %q, %v", got, err)
+	}
+	again, _ := enc.Encrypt(4, 9, plaintext)
+	if bytes.Equal(again[:nonceSize], ct[:nonceSize]) {
+		t.Error("two encryptions used the same nonce")
+	}
+
+	tampered := bytes.Clone(ct)
+	tampered[20] ^= 1
+	for _, tt := range []struct {
+		name     string
+		id, leaf int
+		ct       []byte
+	}{
+		{"wrong block", 5, 9, ct},
+		{"wrong leaf", 4, 8, ct},
+		{"tampered", 4, 9, tampered},
+		{"truncated", 4, 9, ct[:20]},
+	} {
+		if _, err := enc.Decrypt(tt.id, tt.leaf, tt.ct); !errors.Is(err, pathoram.ErrDecryptionFailed) {
+			t.Errorf("%s: got %v, want ErrDecryptionFailed", tt.name, err)
+		}
+	}
+
+	oram, err := pathoram.NewORAM(pathoram.WithCapacity(32, 64), pathoram.WithEncryptor(enc))
+	if err != nil {
+		t.Fatalf("NewORAM failed: %v", err)
+	}
+	for id := range 32 {
+		oram.Write(id, bytes.Repeat([]byte{byte(id)}, 64))
+	}
+	for id := range 32 {
+		if got, err := oram.Read(id); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(id)}, 64)) {
+			t.Fatalf("Read(%d) = %x, %v", id, got, err)
+		}
+	}
+
+	enc.Wipe()
+	if _, err := enc.Encrypt(1, 1, plaintext); !errors.Is(err, pathoram.ErrEncryptionFailed) {
+		t.Errorf("Encrypt after Wipe: error = %v, want ErrEncryptionFailed", err)
+	}
+}
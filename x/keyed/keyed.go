@@ -0,0 +1,125 @@
+// Package keyed addresses the blocks of a PathORAM by arbitrary comparable
+// keys (uint64, string, UUID, ...) instead of dense block IDs. Each key is
+// given a block on its first Write and keeps it until Delete; the
+// key-to-block map is kept in client memory and blocks come from a
+// pathoram.BlockAllocator, which may be shared with a kv.Store on the same
+// ORAM:
+//
+//	store := kv.New(oram, 64<<10)
+//	sessions := keyed.New[uuid.UUID](oram, store.Allocator())
+//
+// Like everything under x/, the API may change.
+package keyed
+
+import (
+	"context"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// ORAM maps keys of type K to blocks of a PathORAM.
+//
+// A lookup of a missing key performs a DummyAccess, so the server sees one
+// access per call whether or not the key exists.
+type ORAM[K comparable] struct {
+	mu    sync.Mutex
+	oram  *pathoram.PathORAM
+	ids   map[K]int
+	alloc *pathoram.BlockAllocator
+}
+
+// New creates an ORAM that takes its blocks from alloc, or owns all blocks
+// of oram if alloc is nil. The PathORAM should not be accessed directly
+// while in use by it, other than through structures sharing alloc.
+func New[K comparable](oram *pathoram.PathORAM, alloc *pathoram.BlockAllocator) *ORAM[K] {
+	if alloc == nil {
+		alloc = pathoram.NewBlockAllocator(oram.Capacity())
+	}
+	return &ORAM[K]{oram: oram, ids: make(map[K]int), alloc: alloc}
+}
+
+// Read returns the block stored under key, or pathoram.ErrKeyNotFound.
+func (k *ORAM[K]) Read(key K) ([]byte, error) {
+	return k.ReadCtx(context.Background(), key)
+}
+
+// ReadCtx is like Read but honors ctx.
+func (k *ORAM[K]) ReadCtx(ctx context.Context, key K) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	id, ok := k.ids[key]
+	if !ok {
+		if err := k.oram.DummyAccess(ctx); err != nil {
+			return nil, err
+		}
+		return nil, pathoram.ErrKeyNotFound
+	}
+	return k.oram.ReadCtx(ctx, id)
+}
+
+// Write stores data under key, allocating a block for a new key, and
+// returns the previous value (nil for a new key). Returns
+// pathoram.ErrNoSpace if key is new and no block is free.
+func (k *ORAM[K]) Write(key K, data []byte) ([]byte, error) {
+	return k.WriteCtx(context.Background(), key, data)
+}
+
+// WriteCtx is like Write but honors ctx.
+func (k *ORAM[K]) WriteCtx(ctx context.Context, key K, data []byte) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if id, ok := k.ids[key]; ok {
+		return k.oram.WriteCtx(ctx, id, data)
+	}
+	ids, err := k.alloc.Alloc(1)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := k.oram.WriteCtx(ctx, ids[0], data); err != nil {
+		k.alloc.Free(ids...)
+		return nil, err
+	}
+	k.ids[key] = ids[0]
+	return nil, nil
+}
+
+// Delete removes key and overwrites its block with PathORAM.Delete, freeing
+// it for another key. Deleting a missing key performs a DummyAccess.
+func (k *ORAM[K]) Delete(key K) error {
+	return k.DeleteCtx(context.Background(), key)
+}
+
+// DeleteCtx is like Delete but honors ctx.
+func (k *ORAM[K]) DeleteCtx(ctx context.Context, key K) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	id, ok := k.ids[key]
+	if !ok {
+		return k.oram.DummyAccess(ctx)
+	}
+	if err := k.oram.DeleteCtx(ctx, id); err != nil {
+		return err
+	}
+	delete(k.ids, key)
+	k.alloc.Free(id)
+	return nil
+}
+
+// Has reports whether key has a block. It performs no ORAM access.
+func (k *ORAM[K]) Has(key K) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	_, ok := k.ids[key]
+	return ok
+}
+
+// Len returns the number of keys with a block.
+func (k *ORAM[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.ids)
+}
@@ -0,0 +1,100 @@
+package keyed
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/x/kv"
+)
+
+func TestORAM_AllocatesAndFrees(t *testing.T) {
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: 4, BlockSize: 8})
+	if err != nil {
+		t.Fatalf("NewInMemory: %v", err)
+	}
+	k := New[string](oram, nil)
+
+	for _, key := range []string{"alice", "bob", "carol", "dave"} {
+		if prev, err := k.Write(key, []byte(key + "!!!!!!!!")[:8]); err != nil || prev != nil {
+			t.Fatalf("Write(%q) = %x, %v", key, prev, err)
+		}
+	}
+	if _, err := k.Write("eve", make([]byte, 8)); !errors.Is(err, pathoram.ErrNoSpace) {
+		t.Fatalf("Write with every block in use = %v, want ErrNoSpace", err)
+	}
+	if k.Has("eve") || k.Len() != 4 {
+		t.Fatalf("failed Write left Has = %v, Len = %d", k.Has("eve"), k.Len())
+	}
+
+	if err := k.Delete("bob"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := k.Write("eve", []byte("eveeveev")); err != nil {
+		t.Fatalf("Write after Delete: %v", err)
+	}
+	if _, err := k.Read("bob"); !errors.Is(err, pathoram.ErrKeyNotFound) {
+		t.Errorf("Read deleted key = %v, want ErrKeyNotFound", err)
+	}
+	for key, want := range map[string]string{"alice": "alice!!!", "carol": "carol!!!", "eve": "eveeveev"} {
+		got, err := k.Read(key)
+		if err != nil || !bytes.Equal(got, []byte(want)) {
+			t.Errorf("Read(%q) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+	prev, err := k.Write("alice", []byte("ALICE!!!"))
+	if err != nil || string(prev) != "alice!!!" {
+		t.Errorf("overwrite returned %q, %v", prev, err)
+	}
+}
+
+func TestORAM_MissesStillAccess(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 16, BlockSize: 8})
+	k := New[uint64](oram, nil)
+	before := oram.Stats().Accesses
+	k.Read(1 << 40)
+	k.Delete(7)
+	if n := oram.Stats().Accesses - before; n != 2 {
+		t.Errorf("misses performed %d accesses, want 2", n)
+	}
+}
+
+func TestORAM_SharesKVStoreAllocator(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 8, BlockSize: 8})
+	store := kv.New(oram, 40)
+	k := New[int](oram, store.Allocator())
+
+	if err := store.Put("a", bytes.Repeat([]byte{1}, 40)); err != nil { // 5 blocks
+		t.Fatalf("Put: %v", err)
+	}
+	for i := range 3 {
+		if _, err := k.Write(i, bytes.Repeat([]byte{byte(i + 2)}, 8)); err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+	}
+	if _, err := k.Write(3, make([]byte, 8)); !errors.Is(err, pathoram.ErrNoSpace) {
+		t.Fatalf("Write with the store holding the rest = %v, want ErrNoSpace", err)
+	}
+	if err := store.Put("b", make([]byte, 1)); !errors.Is(err, pathoram.ErrNoSpace) {
+		t.Fatalf("Put with the map holding the rest = %v, want ErrNoSpace", err)
+	}
+
+	// Neither overwrote the other's blocks
+	if got, err := store.Get("a"); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{1}, 40)) {
+		t.Errorf("Get(a) = %x, %v", got, err)
+	}
+	for i := range 3 {
+		if got, err := k.Read(i); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(i + 2)}, 8)) {
+			t.Errorf("Read(%d) = %x, %v", i, got, err)
+		}
+	}
+
+	// A block freed by one is taken by the other
+	if err := k.Delete(0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Put("b", []byte("b")); err != nil {
+		t.Errorf("Put after Delete: %v", err)
+	}
+}
@@ -0,0 +1,327 @@
+// Package kv is a string-keyed store of variable-length values in a
+// pathoram.PathORAM. Like everything under x/, the API may change.
+package kv
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// ErrValueTooLarge is returned by Put for a value longer than the store's
+// maximum.
+var ErrValueTooLarge = errors.New("value exceeds the store's maximum size")
+
+// Store maps string keys to variable-length values stored in a PathORAM.
+// Values are split into BlockSize chunks; the key directory (chunk block IDs
+// and value length) and the free-block list, a pathoram.BlockAllocator, are
+// kept in client memory. Serialize saves the directory so a client
+// restarted against the same ORAM can reopen the store with Deserialize.
+//
+// Every Get, Put and Delete performs exactly Accesses ORAM accesses, enough
+// for a value of the store's maximum size, padding with DummyAccess, so the
+// storage server learns neither the operation, nor whether the key was
+// present, nor the value's length.
+type Store struct {
+	mu        sync.Mutex
+	oram      *pathoram.PathORAM
+	dir       map[string]entry
+	alloc     *pathoram.BlockAllocator
+	maxBlocks int // chunks of the largest value, and accesses per operation
+}
+
+// entry locates a value's chunks.
+type entry struct {
+	blocks []int
+	length int
+}
+
+// New creates a Store that owns all blocks of oram and holds values of up
+// to maxValue bytes. Each operation costs one access per block of maxValue,
+// and at least one.
+// The ORAM should not be accessed directly while in use by the store, other
+// than through structures sharing its Allocator.
+func New(oram *pathoram.PathORAM, maxValue int) *Store {
+	bs := oram.BlockSize()
+	return &Store{
+		oram:      oram,
+		dir:       make(map[string]entry),
+		alloc:     pathoram.NewBlockAllocator(oram.Capacity()),
+		maxBlocks: max((maxValue+bs-1)/bs, 1),
+	}
+}
+
+// Accesses returns the number of ORAM accesses every Get, Put and Delete
+// performs.
+func (kv *Store) Accesses() int {
+	return kv.maxBlocks
+}
+
+// Allocator returns the store's block allocator. Another structure built on
+// the same ORAM with it, such as an x/keyed map, takes its blocks from the
+// same free list.
+func (kv *Store) Allocator() *pathoram.BlockAllocator {
+	return kv.alloc
+}
+
+// Get returns the value stored under key, or pathoram.ErrKeyNotFound.
+func (kv *Store) Get(key string) ([]byte, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	e, ok := kv.dir[key]
+	if !ok {
+		if err := kv.pad(0); err != nil {
+			return nil, err
+		}
+		return nil, pathoram.ErrKeyNotFound
+	}
+	value := make([]byte, 0, len(e.blocks)*kv.oram.BlockSize())
+	for _, id := range e.blocks {
+		chunk, err := kv.oram.Read(id)
+		if err != nil {
+			return nil, err
+		}
+		value = append(value, chunk...)
+	}
+	if err := kv.pad(len(e.blocks)); err != nil {
+		return nil, err
+	}
+	return value[:e.length], nil
+}
+
+// Put stores value under key, replacing any existing value.
+// Returns ErrValueTooLarge for a value longer than the store's maximum, and
+// ErrNoSpace if the ORAM has too few free blocks; neither performs any
+// access. If a write fails, key is removed, since its old value may be
+// partly overwritten, and all its blocks are freed.
+func (kv *Store) Put(key string, value []byte) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	bs := kv.oram.BlockSize()
+	need := (len(value) + bs - 1) / bs
+	if need > kv.maxBlocks {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrValueTooLarge, len(value), kv.maxBlocks*bs)
+	}
+	old := kv.dir[key]
+	grown, err := kv.alloc.Alloc(max(need-len(old.blocks), 0))
+	if err != nil {
+		return err
+	}
+
+	// Reuse the key's existing blocks, then the new ones, which go back to
+	// the allocator unless every write succeeds
+	blocks := make([]int, need)
+	n := copy(blocks, old.blocks)
+	copy(blocks[n:], grown)
+
+	chunk := make([]byte, bs)
+	for i, id := range blocks {
+		clear(chunk)
+		copy(chunk, value[i*bs:])
+		if _, err := kv.oram.Write(id, chunk); err != nil {
+			kv.alloc.Free(grown...)
+			if len(old.blocks) > 0 {
+				delete(kv.dir, key)
+				kv.alloc.Free(old.blocks...)
+			}
+			return err
+		}
+	}
+	kv.dir[key] = entry{blocks: blocks, length: len(value)}
+	if err := kv.release(old.blocks[n:]); err != nil {
+		return err
+	}
+	return kv.pad(max(need, len(old.blocks)))
+}
+
+// Delete removes key and deletes its blocks with PathORAM.Delete.
+// Deleting a missing key only pads.
+func (kv *Store) Delete(key string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	e, ok := kv.dir[key]
+	if ok {
+		delete(kv.dir, key)
+		if err := kv.release(e.blocks); err != nil {
+			return err
+		}
+	}
+	return kv.pad(len(e.blocks))
+}
+
+// Pair is a key and its value, as returned by ListPrefix.
+type Pair struct {
+	Key   string
+	Value []byte
+}
+
+// ListPrefix returns the keys starting with prefix, in sorted order, together
+// with their values, using exactly bound ORAM accesses. Values are read
+// chunk by chunk; the listing stops at the first match that does not fit in
+// the remaining budget and truncated is set. Unused accesses are padded with
+// DummyAccess, so the storage server learns neither the number of matches
+// nor their sizes.
+func (kv *Store) ListPrefix(ctx context.Context, prefix string, bound int) (pairs []Pair, truncated bool, err error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	var keys []string
+	for k := range kv.dir {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	used := 0
+	for _, k := range keys {
+		e := kv.dir[k]
+		if used+len(e.blocks) > bound {
+			truncated = true
+			break
+		}
+		value := make([]byte, 0, len(e.blocks)*kv.oram.BlockSize())
+		for _, id := range e.blocks {
+			chunk, err := kv.oram.ReadCtx(ctx, id)
+			if err != nil {
+				return nil, false, err
+			}
+			value = append(value, chunk...)
+		}
+		used += len(e.blocks)
+		pairs = append(pairs, Pair{Key: k, Value: value[:e.length]})
+	}
+	for ; used < bound; used++ {
+		if err := kv.oram.DummyAccess(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+	return pairs, truncated, nil
+}
+
+// Len returns the number of keys in the store.
+func (kv *Store) Len() int {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return len(kv.dir)
+}
+
+// pad performs dummy accesses to bring an operation that made used
+// accesses up to Accesses.
+func (kv *Store) pad(used int) error {
+	for ; used < kv.maxBlocks; used++ {
+		if err := kv.oram.DummyAccess(context.Background()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Serialize encodes the store's maximum value size in blocks and its
+// directory: the number of keys, then each key's length, key, value length,
+// chunk count and chunk block IDs. It is as sensitive as the position map;
+// seal it (see pathoram.Sealer) before it leaves the client. Blocks that
+// other structures took from the Allocator are not recorded.
+func (kv *Store) Serialize() ([]byte, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	var buf []byte
+	buf = binary.AppendUvarint(buf, uint64(kv.maxBlocks))
+	buf = binary.AppendUvarint(buf, uint64(len(kv.dir)))
+	for _, k := range slices.Sorted(maps.Keys(kv.dir)) {
+		e := kv.dir[k]
+		buf = binary.AppendUvarint(buf, uint64(len(k)))
+		buf = append(buf, k...)
+		buf = binary.AppendUvarint(buf, uint64(e.length))
+		buf = binary.AppendUvarint(buf, uint64(len(e.blocks)))
+		for _, id := range e.blocks {
+			buf = binary.AppendUvarint(buf, uint64(id))
+		}
+	}
+	return buf, nil
+}
+
+// Deserialize reopens a store over oram from the output of Serialize. Block
+// IDs not in the directory are free.
+func Deserialize(oram *pathoram.PathORAM, data []byte) (*Store, error) {
+	bad := fmt.Errorf("%w: truncated or invalid store directory", pathoram.ErrCorruptObject)
+	next := func() (int, bool) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 || v > math.MaxInt {
+			return 0, false
+		}
+		data = data[n:]
+		return int(v), true
+	}
+	maxBlocks, ok := next()
+	if !ok || maxBlocks < 1 {
+		return nil, bad
+	}
+	keys, ok := next()
+	if !ok || keys > len(data) {
+		return nil, bad
+	}
+	kv := &Store{oram: oram, dir: make(map[string]entry, keys), maxBlocks: maxBlocks}
+	used := make([]bool, oram.Capacity())
+	for range keys {
+		n, ok := next()
+		if !ok || n > len(data) {
+			return nil, bad
+		}
+		key := string(data[:n])
+		data = data[n:]
+		var e entry
+		if e.length, ok = next(); !ok {
+			return nil, bad
+		}
+		if n, ok = next(); !ok || n > maxBlocks || n > len(used) || e.length > n*oram.BlockSize() {
+			return nil, bad
+		}
+		for range n {
+			id, ok := next()
+			if !ok || id >= len(used) || used[id] {
+				return nil, bad
+			}
+			used[id] = true
+			e.blocks = append(e.blocks, id)
+		}
+		kv.dir[key] = e
+	}
+	if len(data) != 0 {
+		return nil, bad
+	}
+
+	// Free IDs highest first, so the lowest is handed out first as by New
+	kv.alloc = pathoram.NewBlockAllocator(0)
+	for id := len(used) - 1; id >= 0; id-- {
+		if !used[id] {
+			kv.alloc.Free(id)
+		}
+	}
+	return kv, nil
+}
+
+// release returns blocks to the free list and deletes them (see
+// PathORAM.Delete). A block whose delete fails is still freed: it is
+// overwritten when next allocated.
+func (kv *Store) release(blocks []int) error {
+	kv.alloc.Free(blocks...)
+	for _, id := range blocks {
+		if err := kv.oram.Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package kv
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func newTestStore(t *testing.T, numBlocks int) *Store {
+	t.Helper()
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: numBlocks, BlockSize: 16, StashLimit: 200})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	return New(oram, numBlocks*16)
+}
+
+func TestStore_PutGet(t *testing.T) {
+	kv := newTestStore(t, 32)
+
+	tests := []struct {
+		key   string
+		value []byte
+	}{
+		{"empty", []byte{}},
+		{"short", []byte("hi")},
+		{"exact", bytes.Repeat([]byte{1}, 16)},
+		{"multi", bytes.Repeat([]byte("abc"), 20)},
+	}
+	for _, tt := range tests {
+		if err := kv.Put(tt.key, tt.value); err != nil {
+			t.Fatalf("Put(%q) failed: %v", tt.key, err)
+		}
+	}
+	for _, tt := range tests {
+		got, err := kv.Get(tt.key)
+		if err != nil {
+			t.Fatalf("Get(%q) failed: %v", tt.key, err)
+		}
+		if !bytes.Equal(got, tt.value) {
+			t.Errorf("Get(%q) = %q, want %q", tt.key, got, tt.value)
+		}
+	}
+	if kv.Len() != len(tests) {
+		t.Errorf("Len() = %d, want %d", kv.Len(), len(tests))
+	}
+}
+
+func TestStore_OverwriteAndDelete(t *testing.T) {
+	kv := newTestStore(t, 8)
+
+	if err := kv.Put("k", bytes.Repeat([]byte{1}, 100)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	// Shrinking releases blocks for other keys
+	if err := kv.Put("k", []byte("small")); err != nil {
+		t.Fatalf("Put (shrink) failed: %v", err)
+	}
+	if err := kv.Put("other", bytes.Repeat([]byte{2}, 7*16)); err != nil {
+		t.Fatalf("Put(other) failed: %v", err)
+	}
+	if got, _ := kv.Get("k"); string(got) != "small" {
+		t.Errorf("Get(k) = %q, want %q", got, "small")
+	}
+
+	if err := kv.Put("big", make([]byte, 17)); !errors.Is(err, pathoram.ErrNoSpace) {
+		t.Errorf("Put with full ORAM: got %v, want ErrNoSpace", err)
+	}
+	if err := kv.Delete("other"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := kv.Get("other"); !errors.Is(err, pathoram.ErrKeyNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrKeyNotFound", err)
+	}
+	if err := kv.Put("big", make([]byte, 17)); err != nil {
+		t.Errorf("Put after Delete failed: %v", err)
+	}
+}
+
+func TestStore_ListPrefix(t *testing.T) {
+	kv := newTestStore(t, 64)
+	values := map[string][]byte{
+		"dir/a":   []byte("one"),
+		"dir/b":   bytes.Repeat([]byte{2}, 40), // 3 chunks
+		"dir/c":   []byte("three"),
+		"other/x": []byte("x"),
+	}
+	for k, v := range values {
+		if err := kv.Put(k, v); err != nil {
+			t.Fatalf("Put(%q) failed: %v", k, err)
+		}
+	}
+
+	tests := []struct {
+		prefix        string
+		bound         int
+		wantKeys      []string
+		wantTruncated bool
+	}{
+		{"dir/", 10, []string{"dir/a", "dir/b", "dir/c"}, false},
+		{"dir/", 4, []string{"dir/a", "dir/b"}, true},
+		{"dir/", 2, []string{"dir/a"}, true},
+		{"none/", 10, nil, false},
+		{"", 6, []string{"dir/a", "dir/b", "dir/c", "other/x"}, false},
+	}
+	for _, tt := range tests {
+		before := kv.oram.AccessCount()
+		pairs, truncated, err := kv.ListPrefix(t.Context(), tt.prefix, tt.bound)
+		if err != nil {
+			t.Fatalf("ListPrefix(%q, %d) failed: %v", tt.prefix, tt.bound, err)
+		}
+		if n := kv.oram.AccessCount() - before; n != uint64(tt.bound) {
+			t.Errorf("ListPrefix(%q, %d) made %d accesses, want %d", tt.prefix, tt.bound, n, tt.bound)
+		}
+		if truncated != tt.wantTruncated {
+			t.Errorf("ListPrefix(%q, %d) truncated = %v, want %v", tt.prefix, tt.bound, truncated, tt.wantTruncated)
+		}
+		var keys []string
+		for _, p := range pairs {
+			keys = append(keys, p.Key)
+			if !bytes.Equal(p.Value, values[p.Key]) {
+				t.Errorf("value of %q = %q, want %q", p.Key, p.Value, values[p.Key])
+			}
+		}
+		if !slices.Equal(keys, tt.wantKeys) {
+			t.Errorf("ListPrefix(%q, %d) keys = %v, want %v", tt.prefix, tt.bound, keys, tt.wantKeys)
+		}
+	}
+}
+
+// countdownStorage fails every WriteBucket once left reaches zero.
+type countdownStorage struct {
+	*pathoram.InMemoryStorage
+	left int
+}
+
+func (s *countdownStorage) WriteBucket(idx int, blocks []pathoram.Block) error {
+	if s.left == 0 {
+		return errors.New("injected write failure")
+	}
+	s.left--
+	return s.InMemoryStorage.WriteBucket(idx, blocks)
+}
+
+// checkBlocks checks that every block is either free or owned by one key.
+func checkBlocks(t *testing.T, kv *Store) {
+	t.Helper()
+	free, err := kv.alloc.Alloc(kv.alloc.Available())
+	if err != nil {
+		t.Fatalf("Alloc of the free blocks failed: %v", err)
+	}
+	defer kv.alloc.Free(free...)
+	owned := slices.Clone(free)
+	for _, e := range kv.dir {
+		owned = append(owned, e.blocks...)
+	}
+	slices.Sort(owned)
+	if len(owned) != kv.oram.Capacity() || len(slices.Compact(owned)) != kv.oram.Capacity() {
+		t.Fatalf("blocks leaked or shared: %v", owned)
+	}
+}
+
+func TestStore_FailedPutKeepsAccounting(t *testing.T) {
+	for budget := 0; ; budget++ {
+		cfg, _ := pathoram.Config{NumBlocks: 16, BlockSize: 16, StashLimit: 200}.Validate()
+		_, _, total := cfg.ComputeTreeParams()
+		storage := &countdownStorage{InMemoryStorage: pathoram.NewInMemoryStorage(total, cfg.BucketSize, cfg.BlockSize), left: -1}
+		oram, err := pathoram.New(cfg, storage, pathoram.NewInMemoryPositionMap(), pathoram.NoOpEncryptor{})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		kv := New(oram, 80)
+		if err := kv.Put("k", bytes.Repeat([]byte{1}, 48)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+
+		// Shrink then grow across a failure at every possible write
+		storage.left = budget
+		err = kv.Put("k", bytes.Repeat([]byte{2}, 16))
+		if err == nil {
+			err = kv.Put("k", bytes.Repeat([]byte{3}, 80))
+		}
+		checkBlocks(t, kv)
+		if err == nil {
+			return
+		}
+		// A failed write drops the key; a failed release after the commit
+		// keeps the new value
+		if e, ok := kv.dir["k"]; ok && e.length == 48 {
+			t.Fatalf("budget %d: old value kept after failed Put: %v", budget, err)
+		}
+	}
+}
+
+func TestStore_PadsEveryOperation(t *testing.T) {
+	kv := newTestStore(t, 16)
+	kv.maxBlocks = 4
+	ops := []struct {
+		name string
+		op   func() error
+	}{
+		{"Put empty", func() error { return kv.Put("a", nil) }},
+		{"Put 3 blocks", func() error { return kv.Put("b", make([]byte, 40)) }},
+		{"Get hit", func() error { _, err := kv.Get("b"); return err }},
+		{"Get empty", func() error { _, err := kv.Get("a"); return err }},
+		{"Put shrink", func() error { return kv.Put("b", make([]byte, 1)) }},
+		{"Get miss", func() error {
+			if _, err := kv.Get("missing"); !errors.Is(err, pathoram.ErrKeyNotFound) {
+				return err
+			}
+			return nil
+		}},
+		{"Delete hit", func() error { return kv.Delete("b") }},
+		{"Delete miss", func() error { return kv.Delete("missing") }},
+	}
+	for _, tt := range ops {
+		before := kv.oram.Stats().Accesses
+		if err := tt.op(); err != nil {
+			t.Fatalf("%s failed: %v", tt.name, err)
+		}
+		if n := kv.oram.Stats().Accesses - before; n != uint64(kv.Accesses()) {
+			t.Errorf("%s performed %d accesses, want %d", tt.name, n, kv.Accesses())
+		}
+	}
+	if err := kv.Put("c", make([]byte, 65)); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Put past the maximum: got %v, want ErrValueTooLarge", err)
+	}
+}
+
+func TestStore_Serialize(t *testing.T) {
+	kv := newTestStore(t, 16)
+	values := map[string][]byte{"a": []byte("one"), "b": bytes.Repeat([]byte{2}, 40), "": nil}
+	for k, v := range values {
+		if err := kv.Put(k, v); err != nil {
+			t.Fatalf("Put(%q) failed: %v", k, err)
+		}
+	}
+	data, err := kv.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+
+	reopened, err := Deserialize(kv.oram, data)
+	if err != nil {
+		t.Fatalf("Deserialize failed: %v", err)
+	}
+	if reopened.Len() != 3 || reopened.Accesses() != kv.Accesses() {
+		t.Fatalf("Len() = %d, Accesses() = %d; want 3, %d", reopened.Len(), reopened.Accesses(), kv.Accesses())
+	}
+	for k, v := range values {
+		if got, err := reopened.Get(k); err != nil || !bytes.Equal(got, v) {
+			t.Errorf("Get(%q) = %q, %v; want %q", k, got, err, v)
+		}
+	}
+	if reopened.alloc.Available() != kv.alloc.Available() {
+		t.Errorf("Available() = %d, want %d", reopened.alloc.Available(), kv.alloc.Available())
+	}
+	checkBlocks(t, reopened)
+	if err := reopened.Put("c", make([]byte, 16)); err != nil {
+		t.Errorf("Put after Deserialize failed: %v", err)
+	}
+	checkBlocks(t, reopened)
+
+	for n := range len(data) {
+		if _, err := Deserialize(kv.oram, data[:n]); !errors.Is(err, pathoram.ErrCorruptObject) {
+			t.Fatalf("Deserialize of %d of %d bytes: error = %v, want ErrCorruptObject", n, len(data), err)
+		}
+	}
+}
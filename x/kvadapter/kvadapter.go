@@ -0,0 +1,147 @@
+// Package kvadapter adapts a kv.Store to the minimal key-value
+// interfaces that cache and storage libraries commonly expose, so an
+// application written against one of them can switch to oblivious storage
+// by changing the constructor it calls:
+//
+//	var cache kvadapter.Cache = kvadapter.New(kv.New(oram, 64<<10))
+//
+// The adapters add no state of their own; every call is one kv.Store call
+// and has its access cost, the store's Accesses whatever the key or value. Like everything under x/, the API may change.
+package kvadapter
+
+import (
+	"context"
+	"errors"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/x/kv"
+)
+
+// ErrNotFound is returned by Get for a missing key. It is
+// pathoram.ErrKeyNotFound.
+var ErrNotFound = pathoram.ErrKeyNotFound
+
+// Cache is the Get/Set/Delete interface of in-process caches such as
+// bigcache and freecache.
+type Cache interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+	Delete(key string) error
+}
+
+// ContextCache is Cache with a context on every call, as in remote cache
+// clients.
+type ContextCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, key string) error
+}
+
+// BytesStore is the byte-keyed Get/Put/Has/Delete interface of embedded
+// key-value databases.
+type BytesStore interface {
+	Get(key []byte) ([]byte, error)
+	Put(key, value []byte) error
+	Has(key []byte) (bool, error)
+	Delete(key []byte) error
+}
+
+// Adapter implements Cache over a kv.Store. Its Context and Bytes methods
+// return views implementing ContextCache and BytesStore over the same
+// store.
+type Adapter struct {
+	store *kv.Store
+}
+
+var (
+	_ Cache        = (*Adapter)(nil)
+	_ ContextCache = contextAdapter{}
+	_ BytesStore   = bytesAdapter{}
+)
+
+// New creates an Adapter over store.
+func New(store *kv.Store) *Adapter {
+	return &Adapter{store: store}
+}
+
+// Get returns the value stored under key, or ErrNotFound.
+func (a *Adapter) Get(key string) ([]byte, error) {
+	return a.store.Get(key)
+}
+
+// Set stores value under key, replacing any existing value. It returns
+// pathoram.ErrNoSpace if the ORAM has too few free blocks.
+func (a *Adapter) Set(key string, value []byte) error {
+	return a.store.Put(key, value)
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (a *Adapter) Delete(key string) error {
+	return a.store.Delete(key)
+}
+
+// Context returns a ContextCache over the same store.
+func (a *Adapter) Context() ContextCache {
+	return contextAdapter{a}
+}
+
+// Bytes returns a BytesStore over the same store. Byte keys map to the
+// string keys with the same bytes.
+func (a *Adapter) Bytes() BytesStore {
+	return bytesAdapter{a}
+}
+
+// contextAdapter implements ContextCache. A kv.Store operation cannot be
+// interrupted part-way without leaving its accesses unpadded, so the
+// context is checked only before the call.
+type contextAdapter struct{ a *Adapter }
+
+func (c contextAdapter) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.a.Get(key)
+}
+
+func (c contextAdapter) Set(ctx context.Context, key string, value []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.a.Set(key, value)
+}
+
+func (c contextAdapter) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.a.Delete(key)
+}
+
+// bytesAdapter implements BytesStore.
+type bytesAdapter struct{ a *Adapter }
+
+func (b bytesAdapter) Get(key []byte) ([]byte, error) {
+	return b.a.Get(string(key))
+}
+
+func (b bytesAdapter) Put(key, value []byte) error {
+	return b.a.Set(string(key), value)
+}
+
+// Has reports whether key is present. It reads the value, so it costs what
+// Get does and a hit cannot be told from a miss of the same size.
+func (b bytesAdapter) Has(key []byte) (bool, error) {
+	_, err := b.a.Get(string(key))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (b bytesAdapter) Delete(key []byte) error {
+	return b.a.Delete(string(key))
+}
@@ -0,0 +1,85 @@
+package kvadapter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/x/kv"
+)
+
+func newAdapter(t *testing.T) *Adapter {
+	t.Helper()
+	oram, err := pathoram.NewORAM(pathoram.WithCapacity(64, 16))
+	if err != nil {
+		t.Fatalf("NewORAM: %v", err)
+	}
+	return New(kv.New(oram, 64))
+}
+
+func TestAdapter_Cache(t *testing.T) {
+	a := newAdapter(t)
+	value := bytes.Repeat([]byte{7}, 40)
+	if err := a.Set("k", value); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := a.Get("k"); err != nil || !bytes.Equal(got, value) {
+		t.Fatalf("Get = %x, %v, want %x", got, err, value)
+	}
+	if err := a.Delete("k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := a.Get("k"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: error = %v, want ErrNotFound", err)
+	}
+	if err := a.Delete("k"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestAdapter_Context(t *testing.T) {
+	c := newAdapter(t).Context()
+	if err := c.Set(t.Context(), "k", []byte("v")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := c.Get(t.Context(), "k"); err != nil || string(got) != "v" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := c.Set(ctx, "k", []byte("w")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Set with a cancelled context: error = %v", err)
+	}
+	if err := c.Delete(ctx, "k"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Delete with a cancelled context: error = %v", err)
+	}
+	if got, err := c.Get(t.Context(), "k"); err != nil || string(got) != "v" {
+		t.Errorf("cancelled calls changed the value: Get = %q, %v", got, err)
+	}
+}
+
+func TestAdapter_Bytes(t *testing.T) {
+	a := newAdapter(t)
+	s := a.Bytes()
+	if err := s.Put([]byte("k"), []byte("v")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got, err := a.Get("k"); err != nil || string(got) != "v" {
+		t.Fatalf("string Get of a byte key = %q, %v", got, err)
+	}
+	if ok, err := s.Has([]byte("k")); err != nil || !ok {
+		t.Errorf("Has(k) = %v, %v", ok, err)
+	}
+	if ok, err := s.Has([]byte("missing")); err != nil || ok {
+		t.Errorf("Has(missing) = %v, %v", ok, err)
+	}
+	if err := s.Delete([]byte("k")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get([]byte("k")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,497 @@
+// Package omap is an oblivious ordered map stored in a PathORAM. Unlike
+// kv.Store, it keeps its index on the server too, as a balanced
+// tree of ORAM blocks, and pads every operation to the same number of
+// accesses. Like everything under x/, the API may change.
+package omap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/x/kv"
+)
+
+// omapHeader is the size of a node's fixed fields: left and right child IDs
+// (plus one, 0 = none), the two subtree heights, key and value lengths.
+const omapHeader = 24
+
+// Map is an oblivious ordered map: an AVL tree whose nodes are PathORAM
+// blocks, one key-value pair per node. The root ID, node count and free-block
+// list are kept in client memory.
+//
+// Every Get, Put and Delete performs the same number of ORAM accesses
+// (Accesses), padding with DummyAccess, so the storage server learns neither
+// the key, nor whether it was present, nor the operation: the tree walk and
+// its rebalancing reads and writes are bounded by the maximum AVL height for
+// the ORAM's capacity. Range queries take an explicit access bound, as
+// kv.Store.ListPrefix does.
+//
+// A key and its value must fit in one block after a 24-byte node header.
+type Map struct {
+	mu       sync.Mutex
+	oram     *pathoram.PathORAM
+	root     int // node ID, -1 when empty
+	n        int
+	free     []int // unallocated block IDs, popped from the end
+	accesses int   // per-operation access count
+}
+
+// omapNode is a decoded tree node. lh and rh cache the heights of the left
+// and right subtrees so rebalancing does not read the children.
+type omapNode struct {
+	key         string
+	value       []byte
+	left, right int
+	lh, rh      int
+}
+
+// New creates an empty Map that owns all blocks of oram.
+// The ORAM should not be accessed directly while in use by the map.
+func New(oram *pathoram.PathORAM) *Map {
+	n := oram.Capacity()
+	free := make([]int, n)
+	for i := range free {
+		free[i] = n - 1 - i
+	}
+	// A delete reads at most the root-to-leaf path plus, at each level, the
+	// taller sibling and one of its children; it writes back at most what it
+	// read and one freed node
+	h := maxAVLHeight(n)
+	return &Map{oram: oram, root: -1, free: free, accesses: 6*h + 1}
+}
+
+// maxAVLHeight returns the greatest height of an AVL tree with at most n
+// nodes: the smallest tree of height h has minimal(h-1) + minimal(h-2) + 1
+// nodes.
+func maxAVLHeight(n int) int {
+	h, prev, cur := 0, 0, 0 // cur = fewest nodes in a tree of height h
+	for {
+		next := cur + prev + 1
+		if h == 0 {
+			next = 1
+		}
+		if next > n {
+			return h
+		}
+		h, prev, cur = h+1, cur, next
+	}
+}
+
+// Accesses returns the number of ORAM accesses every Get, Put and Delete
+// performs.
+func (m *Map) Accesses() int {
+	return m.accesses
+}
+
+// Len returns the number of keys in the map.
+func (m *Map) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.n
+}
+
+// MaxValueSize returns the largest value that fits with key.
+func (m *Map) MaxValueSize(key string) int {
+	return max(m.oram.BlockSize()-omapHeader-len(key), 0)
+}
+
+// Get returns the value stored under key, or pathoram.ErrKeyNotFound.
+func (m *Map) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op := m.newOp()
+	var value []byte
+	found := false
+	for id := m.root; id != -1; {
+		n, err := op.load(id)
+		if err != nil {
+			return nil, err
+		}
+		switch c := strings.Compare(key, n.key); {
+		case c < 0:
+			id = n.left
+		case c > 0:
+			id = n.right
+		default:
+			value, found, id = n.value, true, -1
+		}
+	}
+	if err := op.finish(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, pathoram.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+// Put stores value under key, replacing any existing value. Returns
+// pathoram.ErrInvalidDataSize if the pair does not fit in a block and
+// pathoram.ErrNoSpace if the key is new and every block is in use.
+func (m *Map) Put(key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(key) > 0xffff || len(value) > m.MaxValueSize(key) {
+		return fmt.Errorf("%w: key and value need %d bytes, block holds %d",
+			pathoram.ErrInvalidDataSize, omapHeader+len(key)+len(value), m.oram.BlockSize())
+	}
+	op := m.newOp()
+	root, _, err := op.insert(m.root, key, slices.Clone(value))
+	if errors.Is(err, pathoram.ErrNoSpace) {
+		// Nothing was modified; pad so a full map looks like any other
+		if err := op.finish(); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: all %d blocks in use", pathoram.ErrNoSpace, m.oram.Capacity())
+	}
+	if err != nil {
+		return err
+	}
+	if err := op.finish(); err != nil {
+		return err
+	}
+	m.root = root
+	m.n += op.added
+	return nil
+}
+
+// Delete removes key. Deleting a missing key is a no-op, but performs the
+// same accesses.
+func (m *Map) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op := m.newOp()
+	root, _, err := op.remove(m.root, key)
+	if err != nil {
+		return err
+	}
+	if err := op.finish(); err != nil {
+		return err
+	}
+	m.root = root
+	m.n -= len(op.freed)
+	m.free = append(m.free, op.freed...)
+	return nil
+}
+
+// Range returns the pairs with lo <= key < hi in sorted order, or every key
+// from lo on if hi is empty, using exactly bound ORAM accesses. Each tree
+// node visited costs one access, including nodes outside the range on the
+// way to it; the walk stops when the budget runs out and truncated is set.
+// Unused accesses are padded with DummyAccess.
+func (m *Map) Range(ctx context.Context, lo, hi string, bound int) (pairs []kv.Pair, truncated bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	used := 0
+	var walk func(id int) error
+	walk = func(id int) error {
+		if id == -1 || truncated {
+			return nil
+		}
+		if used == bound {
+			truncated = true
+			return nil
+		}
+		data, err := m.oram.ReadCtx(ctx, id)
+		if err != nil {
+			return err
+		}
+		used++
+		n := decodeOMapNode(data)
+		if n.key > lo {
+			if err := walk(n.left); err != nil {
+				return err
+			}
+		}
+		if truncated {
+			return nil
+		}
+		inHi := hi == "" || n.key < hi
+		if n.key >= lo && inHi {
+			pairs = append(pairs, kv.Pair{Key: n.key, Value: n.value})
+		}
+		if inHi {
+			return walk(n.right)
+		}
+		return nil
+	}
+	if err := walk(m.root); err != nil {
+		return nil, false, err
+	}
+	for ; used < bound; used++ {
+		if err := m.oram.DummyAccess(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+	return pairs, truncated, nil
+}
+
+// omapOp is one Get, Put or Delete: the nodes it has read, keyed by ID, and
+// the ones it changed. finish writes them back and pads the access count.
+type omapOp struct {
+	m     *Map
+	nodes map[int]*omapNode
+	dirty map[int]bool
+	freed []int
+	added int
+	used  int
+}
+
+func (m *Map) newOp() *omapOp {
+	return &omapOp{m: m, nodes: make(map[int]*omapNode), dirty: make(map[int]bool)}
+}
+
+// load returns node id, reading it from the ORAM on first use.
+func (op *omapOp) load(id int) (*omapNode, error) {
+	if n, ok := op.nodes[id]; ok {
+		return n, nil
+	}
+	data, err := op.m.oram.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	op.used++
+	n := decodeOMapNode(data)
+	op.nodes[id] = n
+	return n, nil
+}
+
+// finish writes back changed nodes in ID order, zeroes freed ones, and pads
+// the operation to Map.Accesses.
+func (op *omapOp) finish() error {
+	ids := make([]int, 0, len(op.dirty)+len(op.freed))
+	for id := range op.dirty {
+		ids = append(ids, id)
+	}
+	ids = append(ids, op.freed...)
+	slices.Sort(ids)
+	zero := make([]byte, op.m.oram.BlockSize())
+	for _, id := range ids {
+		data := zero
+		if n, ok := op.nodes[id]; ok && op.dirty[id] {
+			data = n.encode(op.m.oram.BlockSize())
+		}
+		if _, err := op.m.oram.Write(id, data); err != nil {
+			return err
+		}
+		op.used++
+	}
+	for ; op.used < op.m.accesses; op.used++ {
+		if err := op.m.oram.DummyAccess(context.Background()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insert adds or replaces key in the subtree rooted at id and returns the
+// subtree's new root and height.
+func (op *omapOp) insert(id int, key string, value []byte) (int, int, error) {
+	if id == -1 {
+		free := op.m.free
+		if len(free) == 0 {
+			return 0, 0, pathoram.ErrNoSpace
+		}
+		id = free[len(free)-1]
+		op.m.free = free[:len(free)-1]
+		op.nodes[id] = &omapNode{key: key, value: value, left: -1, right: -1}
+		op.dirty[id] = true
+		op.added++
+		return id, 1, nil
+	}
+	n, err := op.load(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch c := strings.Compare(key, n.key); {
+	case c < 0:
+		left, h, err := op.insert(n.left, key, value)
+		if err != nil {
+			return 0, 0, err
+		}
+		n.left, n.lh = left, h
+	case c > 0:
+		right, h, err := op.insert(n.right, key, value)
+		if err != nil {
+			return 0, 0, err
+		}
+		n.right, n.rh = right, h
+	default:
+		n.value = value
+	}
+	op.dirty[id] = true
+	return op.balance(id)
+}
+
+// remove deletes key from the subtree rooted at id and returns the
+// subtree's new root and height.
+func (op *omapOp) remove(id int, key string) (int, int, error) {
+	if id == -1 {
+		return -1, 0, nil
+	}
+	n, err := op.load(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch c := strings.Compare(key, n.key); {
+	case c < 0:
+		left, h, err := op.remove(n.left, key)
+		if err != nil {
+			return 0, 0, err
+		}
+		n.left, n.lh = left, h
+	case c > 0:
+		right, h, err := op.remove(n.right, key)
+		if err != nil {
+			return 0, 0, err
+		}
+		n.right, n.rh = right, h
+	case n.left == -1:
+		op.free(id)
+		return n.right, n.rh, nil
+	case n.right == -1:
+		op.free(id)
+		return n.left, n.lh, nil
+	default:
+		// Replace with the successor, removed from the right subtree
+		right, h, succ, err := op.removeMin(n.right)
+		if err != nil {
+			return 0, 0, err
+		}
+		n.key, n.value = succ.key, succ.value
+		n.right, n.rh = right, h
+	}
+	op.dirty[id] = true
+	return op.balance(id)
+}
+
+// removeMin unlinks the smallest node of the subtree rooted at id and
+// returns the subtree's new root and height and the removed node.
+func (op *omapOp) removeMin(id int) (int, int, *omapNode, error) {
+	n, err := op.load(id)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if n.left == -1 {
+		op.free(id)
+		return n.right, n.rh, n, nil
+	}
+	left, h, min, err := op.removeMin(n.left)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	n.left, n.lh = left, h
+	op.dirty[id] = true
+	root, height, err := op.balance(id)
+	return root, height, min, err
+}
+
+// free releases node id; finish zeroes its block.
+func (op *omapOp) free(id int) {
+	delete(op.dirty, id)
+	op.freed = append(op.freed, id)
+}
+
+// balance restores the AVL property at id, whose children are balanced,
+// and returns the subtree's root and height.
+func (op *omapOp) balance(id int) (int, int, error) {
+	n := op.nodes[id]
+	switch {
+	case n.lh > n.rh+1:
+		l, err := op.load(n.left)
+		if err != nil {
+			return 0, 0, err
+		}
+		if l.lh < l.rh {
+			if n.left, err = op.rotateLeft(n.left); err != nil {
+				return 0, 0, err
+			}
+		}
+		if id, err = op.rotateRight(id); err != nil {
+			return 0, 0, err
+		}
+	case n.rh > n.lh+1:
+		r, err := op.load(n.right)
+		if err != nil {
+			return 0, 0, err
+		}
+		if r.rh < r.lh {
+			if n.right, err = op.rotateRight(n.right); err != nil {
+				return 0, 0, err
+			}
+		}
+		if id, err = op.rotateLeft(id); err != nil {
+			return 0, 0, err
+		}
+	}
+	return id, op.nodes[id].height(), nil
+}
+
+// rotateRight lifts id's left child above it and returns the child's ID.
+func (op *omapOp) rotateRight(id int) (int, error) {
+	z := op.nodes[id]
+	yID := z.left
+	y, err := op.load(yID)
+	if err != nil {
+		return 0, err
+	}
+	z.left, z.lh = y.right, y.rh
+	y.right, y.rh = id, z.height()
+	op.dirty[id], op.dirty[yID] = true, true
+	return yID, nil
+}
+
+// rotateLeft lifts id's right child above it and returns the child's ID.
+func (op *omapOp) rotateLeft(id int) (int, error) {
+	z := op.nodes[id]
+	yID := z.right
+	y, err := op.load(yID)
+	if err != nil {
+		return 0, err
+	}
+	z.right, z.rh = y.left, y.lh
+	y.left, y.lh = id, z.height()
+	op.dirty[id], op.dirty[yID] = true, true
+	return yID, nil
+}
+
+func (n *omapNode) height() int {
+	return 1 + max(n.lh, n.rh)
+}
+
+// encode serializes n into a block of size bytes.
+func (n *omapNode) encode(size int) []byte {
+	b := make([]byte, size)
+	binary.BigEndian.PutUint64(b[0:], uint64(n.left+1))
+	binary.BigEndian.PutUint64(b[8:], uint64(n.right+1))
+	b[16], b[17] = byte(n.lh), byte(n.rh)
+	binary.BigEndian.PutUint16(b[18:], uint16(len(n.key)))
+	binary.BigEndian.PutUint32(b[20:], uint32(len(n.value)))
+	copy(b[omapHeader:], n.key)
+	copy(b[omapHeader+len(n.key):], n.value)
+	return b
+}
+
+// decodeOMapNode parses a block written by encode.
+func decodeOMapNode(b []byte) *omapNode {
+	keyLen := int(binary.BigEndian.Uint16(b[18:]))
+	valLen := int(binary.BigEndian.Uint32(b[20:]))
+	return &omapNode{
+		left:  int(binary.BigEndian.Uint64(b[0:])) - 1,
+		right: int(binary.BigEndian.Uint64(b[8:])) - 1,
+		lh:    int(b[16]),
+		rh:    int(b[17]),
+		key:   string(b[omapHeader : omapHeader+keyLen]),
+		value: slices.Clone(b[omapHeader+keyLen : omapHeader+keyLen+valLen]),
+	}
+}
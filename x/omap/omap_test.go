@@ -0,0 +1,140 @@
+package omap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func newTestOMap(t *testing.T, numBlocks int) (*Map, *pathoram.PathORAM) {
+	t.Helper()
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: numBlocks, BlockSize: 64, StashLimit: 200})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	return New(oram), oram
+}
+
+func TestMap(t *testing.T) {
+	m, oram := newTestOMap(t, 64)
+	want := make(map[string][]byte)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for i := 0; i < 400; i++ {
+		key := fmt.Sprintf("k%02d", rng.IntN(60))
+		before := oram.Stats().Accesses
+		switch rng.IntN(3) {
+		case 0:
+			value := []byte(fmt.Sprint(i))
+			if err := m.Put(key, value); err != nil {
+				t.Fatalf("Put(%q) failed: %v", key, err)
+			}
+			want[key] = value
+		case 1:
+			if err := m.Delete(key); err != nil {
+				t.Fatalf("Delete(%q) failed: %v", key, err)
+			}
+			delete(want, key)
+		default:
+			got, err := m.Get(key)
+			if exp, ok := want[key]; !ok {
+				if !errors.Is(err, pathoram.ErrKeyNotFound) {
+					t.Fatalf("Get(%q) err = %v, want ErrKeyNotFound", key, err)
+				}
+			} else if err != nil || !bytes.Equal(got, exp) {
+				t.Fatalf("Get(%q) = %q, %v; want %q", key, got, err, exp)
+			}
+		}
+		// Every operation looks the same to the server
+		if got := oram.Stats().Accesses - before; got != uint64(m.Accesses()) {
+			t.Fatalf("op %d: %d accesses, want %d", i, got, m.Accesses())
+		}
+	}
+	if m.Len() != len(want) {
+		t.Fatalf("Len = %d, want %d", m.Len(), len(want))
+	}
+
+	// Range over everything with a generous bound returns sorted pairs
+	pairs, truncated, err := m.Range(context.Background(), "", "", 64)
+	if err != nil || truncated {
+		t.Fatalf("Range = truncated %v, err %v", truncated, err)
+	}
+	keys := make([]string, 0, len(want))
+	for k := range want {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	if len(pairs) != len(keys) {
+		t.Fatalf("Range returned %d pairs, want %d", len(pairs), len(keys))
+	}
+	for i, p := range pairs {
+		if p.Key != keys[i] || !bytes.Equal(p.Value, want[p.Key]) {
+			t.Fatalf("pair %d = %q=%q, want %q=%q", i, p.Key, p.Value, keys[i], want[keys[i]])
+		}
+	}
+}
+
+func TestMap_Range(t *testing.T) {
+	m, oram := newTestOMap(t, 32)
+	for i := 0; i < 20; i++ {
+		if err := m.Put(fmt.Sprintf("k%02d", i), []byte{byte(i)}); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	ctx := context.Background()
+	before := oram.Stats().Accesses
+	pairs, truncated, err := m.Range(ctx, "k05", "k09", 20)
+	if err != nil || truncated {
+		t.Fatalf("Range = truncated %v, err %v", truncated, err)
+	}
+	var got []string
+	for _, p := range pairs {
+		got = append(got, p.Key)
+	}
+	if want := []string{"k05", "k06", "k07", "k08"}; !slices.Equal(got, want) {
+		t.Fatalf("Range keys = %v, want %v", got, want)
+	}
+	if n := oram.Stats().Accesses - before; n != 20 {
+		t.Fatalf("Range used %d accesses, want the bound (20)", n)
+	}
+
+	if _, truncated, _ := m.Range(ctx, "", "", 3); !truncated {
+		t.Fatal("Range with a small bound not truncated")
+	}
+}
+
+func TestMap_Limits(t *testing.T) {
+	m, _ := newTestOMap(t, 4)
+	if err := m.Put("k", make([]byte, m.MaxValueSize("k")+1)); !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Fatalf("oversized Put err = %v, want ErrInvalidDataSize", err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := m.Put(fmt.Sprint(i), nil); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := m.Put("new", nil); !errors.Is(err, pathoram.ErrNoSpace) {
+		t.Fatalf("Put on full map err = %v, want ErrNoSpace", err)
+	}
+	if err := m.Put("0", []byte("replaced")); err != nil {
+		t.Fatalf("Put over existing key on full map failed: %v", err)
+	}
+	if got, _ := m.Get("0"); string(got) != "replaced" {
+		t.Fatalf("Get = %q", got)
+	}
+}
+
+func TestMaxAVLHeight(t *testing.T) {
+	// Fewest nodes for heights 1..6: 1, 2, 4, 7, 12, 20
+	for n, want := range map[int]int{0: 0, 1: 1, 2: 2, 3: 2, 4: 3, 6: 3, 7: 4, 12: 5, 19: 5, 20: 6} {
+		if got := maxAVLHeight(n); got != want {
+			t.Errorf("maxAVLHeight(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package oqueue provides a FIFO queue and a LIFO stack stored in a
+// PathORAM, one element per block, whose operations all look alike to the
+// storage server. Like everything under x/, the API may change.
+package oqueue
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// oelemHeader is the length prefix of a queue or stack element.
+const oelemHeader = 4
+
+// Queue is a FIFO queue whose elements are PathORAM blocks, used as a ring
+// buffer. The head index and length are kept in client memory.
+//
+// Push and Pop each perform exactly one ORAM access, of the same kind, so
+// the storage server learns neither which slot is touched nor whether an
+// operation pushed or popped. Pushing to a full queue and popping an empty
+// one still perform a (dummy) access before returning pathoram.ErrNoSpace
+// or pathoram.ErrEmpty. Elements are padded to the block size, so their
+// lengths are hidden too; an element holds at most BlockSize-4 bytes.
+type Queue struct {
+	mu   sync.Mutex
+	oram *pathoram.PathORAM
+	head int // slot of the oldest element
+	n    int
+}
+
+// NewQueue creates an empty queue that owns all blocks of oram.
+// The ORAM should not be accessed directly while in use by the queue.
+func NewQueue(oram *pathoram.PathORAM) *Queue {
+	return &Queue{oram: oram}
+}
+
+// Push appends v to the queue.
+func (q *Queue) Push(ctx context.Context, v []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.n == q.oram.Capacity() {
+		return oelemFull(ctx, q.oram)
+	}
+	if err := oelemPut(ctx, q.oram, (q.head+q.n)%q.oram.Capacity(), v); err != nil {
+		return err
+	}
+	q.n++
+	return nil
+}
+
+// Pop removes and returns the oldest element.
+func (q *Queue) Pop(ctx context.Context) ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.n == 0 {
+		return nil, oelemEmpty(ctx, q.oram)
+	}
+	v, err := oelemTake(ctx, q.oram, q.head)
+	if err != nil {
+		return nil, err
+	}
+	q.head = (q.head + 1) % q.oram.Capacity()
+	q.n--
+	return v, nil
+}
+
+// Len returns the number of elements in the queue.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.n
+}
+
+// Stack is a LIFO stack whose elements are PathORAM blocks; the depth is
+// kept in client memory. Push and Pop hide the stack position and the
+// operation as Queue's do.
+type Stack struct {
+	mu   sync.Mutex
+	oram *pathoram.PathORAM
+	n    int
+}
+
+// NewStack creates an empty stack that owns all blocks of oram.
+// The ORAM should not be accessed directly while in use by the stack.
+func NewStack(oram *pathoram.PathORAM) *Stack {
+	return &Stack{oram: oram}
+}
+
+// Push adds v to the top of the stack.
+func (s *Stack) Push(ctx context.Context, v []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n == s.oram.Capacity() {
+		return oelemFull(ctx, s.oram)
+	}
+	if err := oelemPut(ctx, s.oram, s.n, v); err != nil {
+		return err
+	}
+	s.n++
+	return nil
+}
+
+// Pop removes and returns the top element.
+func (s *Stack) Pop(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n == 0 {
+		return nil, oelemEmpty(ctx, s.oram)
+	}
+	v, err := oelemTake(ctx, s.oram, s.n-1)
+	if err != nil {
+		return nil, err
+	}
+	s.n--
+	return v, nil
+}
+
+// Len returns the number of elements on the stack.
+func (s *Stack) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n
+}
+
+// oelemPut stores v, length-prefixed and padded, in block id with one
+// access.
+func oelemPut(ctx context.Context, oram *pathoram.PathORAM, id int, v []byte) error {
+	bs := oram.BlockSize()
+	if len(v) > bs-oelemHeader {
+		return fmt.Errorf("%w: element of %d bytes, at most %d fit", pathoram.ErrInvalidDataSize, len(v), bs-oelemHeader)
+	}
+	block := make([]byte, bs)
+	binary.BigEndian.PutUint32(block, uint32(len(v)))
+	copy(block[oelemHeader:], v)
+	_, err := oram.AccessCtx(ctx, id, block)
+	return err
+}
+
+// oelemTake returns the element in block id and zeroes the block, with one
+// access.
+func oelemTake(ctx context.Context, oram *pathoram.PathORAM, id int) ([]byte, error) {
+	block, err := oram.AccessCtx(ctx, id, make([]byte, oram.BlockSize()))
+	if err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint32(block))
+	if n > len(block)-oelemHeader {
+		return nil, fmt.Errorf("%w: element length %d", pathoram.ErrCorruptObject, n)
+	}
+	return block[oelemHeader : oelemHeader+n], nil
+}
+
+// oelemFull performs the access a push would and returns pathoram.ErrNoSpace.
+func oelemFull(ctx context.Context, oram *pathoram.PathORAM) error {
+	if err := oram.DummyAccess(ctx); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: all %d slots in use", pathoram.ErrNoSpace, oram.Capacity())
+}
+
+// oelemEmpty performs the access a pop would and returns pathoram.ErrEmpty.
+func oelemEmpty(ctx context.Context, oram *pathoram.PathORAM) error {
+	if err := oram.DummyAccess(ctx); err != nil {
+		return err
+	}
+	return pathoram.ErrEmpty
+}
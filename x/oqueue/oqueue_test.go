@@ -0,0 +1,80 @@
+package oqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func TestQueue(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 4, BlockSize: 16})
+	q := NewQueue(oram)
+	ctx := context.Background()
+
+	// Wrap around the ring twice
+	next, popped := 0, 0
+	for round := 0; round < 3; round++ {
+		for q.Len() < 4 {
+			if err := q.Push(ctx, []byte(fmt.Sprint(next))); err != nil {
+				t.Fatalf("Push failed: %v", err)
+			}
+			next++
+		}
+		for i := 0; i < 3; i++ {
+			v, err := q.Pop(ctx)
+			if err != nil || string(v) != fmt.Sprint(popped) {
+				t.Fatalf("Pop = %q, %v; want %d", v, err, popped)
+			}
+			popped++
+		}
+	}
+
+	// One access per operation, whatever the outcome
+	before := oram.Stats().Accesses
+	for q.Len() < 4 {
+		if err := q.Push(ctx, []byte("x")); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+	if err := q.Push(ctx, []byte("y")); !errors.Is(err, pathoram.ErrNoSpace) {
+		t.Fatalf("Push on full queue err = %v, want ErrNoSpace", err)
+	}
+	for q.Len() > 0 {
+		q.Pop(ctx)
+	}
+	if _, err := q.Pop(ctx); !errors.Is(err, pathoram.ErrEmpty) {
+		t.Fatalf("Pop on empty queue err = %v, want ErrEmpty", err)
+	}
+	if got := oram.Stats().Accesses - before; got != 3+1+4+1 {
+		t.Fatalf("accesses = %d, want 9", got)
+	}
+}
+
+func TestStack(t *testing.T) {
+	oram, _ := pathoram.NewInMemory(pathoram.Config{NumBlocks: 8, BlockSize: 16})
+	s := NewStack(oram)
+	ctx := context.Background()
+
+	for _, v := range []string{"a", "", "ccc"} {
+		if err := s.Push(ctx, []byte(v)); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+	for _, want := range []string{"ccc", "", "a"} {
+		if v, err := s.Pop(ctx); err != nil || string(v) != want {
+			t.Fatalf("Pop = %q, %v; want %q", v, err, want)
+		}
+	}
+	if _, err := s.Pop(ctx); !errors.Is(err, pathoram.ErrEmpty) {
+		t.Fatalf("Pop on empty stack err = %v, want ErrEmpty", err)
+	}
+	if err := s.Push(ctx, make([]byte, 13)); !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Fatalf("oversized Push err = %v, want ErrInvalidDataSize", err)
+	}
+	if s.Len() != 0 {
+		t.Fatalf("Len = %d, want 0", s.Len())
+	}
+}
@@ -0,0 +1,134 @@
+// Package oramfile exposes a pathoram.PathORAM as a fixed-size file. Like
+// everything under x/, the API may change.
+package oramfile
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// File exposes a PathORAM as a fixed-size, byte-addressable file of
+// NumBlocks*BlockSize bytes. It implements io.ReaderAt, io.WriterAt and
+// io.Closer, so code written against files can run on ORAM unchanged.
+//
+// Offsets map to block offset/BlockSize. Each block touched costs one access,
+// except partially overwritten blocks, which are read and then written back.
+// Unwritten regions read as zeros.
+type File struct {
+	mu     sync.Mutex // makes read-modify-write of partial blocks atomic
+	oram   *pathoram.PathORAM
+	closed bool
+}
+
+// New wraps oram. The file owns oram: Close closes it, and oram should not
+// be accessed directly while the file is in use.
+func New(oram *pathoram.PathORAM) *File {
+	return &File{oram: oram}
+}
+
+// Size returns the file size in bytes.
+func (f *File) Size() int64 {
+	return int64(f.oram.Capacity()) * int64(f.oram.BlockSize())
+}
+
+// ReadAt reads len(p) bytes starting at off. It returns io.EOF if the read
+// reaches the end of the file.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if off < 0 {
+		return 0, pathoram.ErrNegativeOffset
+	}
+	size := f.Size()
+	if off >= size {
+		return 0, io.EOF
+	}
+	want := len(p)
+	if int64(want) > size-off {
+		p = p[:size-off]
+	}
+
+	bs := int64(f.oram.BlockSize())
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		data, err := f.oram.ReadCtx(context.Background(), int(pos/bs))
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], data[pos%bs:])
+	}
+	if n < want {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt writes len(p) bytes starting at off. Writes cannot extend the file:
+// a write running past the end writes what fits and returns
+// io.ErrShortWrite.
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if off < 0 {
+		return 0, pathoram.ErrNegativeOffset
+	}
+	size := f.Size()
+	if off >= size {
+		return 0, io.ErrShortWrite
+	}
+	want := len(p)
+	if int64(want) > size-off {
+		p = p[:size-off]
+	}
+
+	ctx := context.Background()
+	bs := int64(f.oram.BlockSize())
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		id, start := int(pos/bs), pos%bs
+		chunk := min(int64(len(p)-n), bs-start)
+		var block []byte
+		if chunk == bs {
+			block = p[n : n+int(bs)]
+		} else {
+			old, err := f.oram.ReadCtx(ctx, id)
+			if err != nil {
+				return n, err
+			}
+			block = old
+			copy(block[start:], p[n:n+int(chunk)])
+		}
+		if _, err := f.oram.WriteCtx(ctx, id, block); err != nil {
+			return n, err
+		}
+		n += int(chunk)
+	}
+	if n < want {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+// Close closes the underlying ORAM. Later reads and writes return
+// fs.ErrClosed.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return f.oram.Close()
+}
@@ -0,0 +1,97 @@
+package oramfile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func newTestFile(t *testing.T) *File {
+	t.Helper()
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: 16, BlockSize: 16, StashLimit: 200})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	return New(oram)
+}
+
+func TestFile_ReadWriteAt(t *testing.T) {
+	f := newTestFile(t)
+	if f.Size() != 256 {
+		t.Fatalf("Size() = %d, want 256", f.Size())
+	}
+	shadow := make([]byte, f.Size())
+
+	writes := []struct {
+		off int64
+		n   int
+	}{
+		{0, 16},   // one full block
+		{5, 3},    // inside a block
+		{14, 20},  // spans three blocks, partial at both ends
+		{32, 64},  // aligned, several blocks
+		{100, 77}, // unaligned, several blocks
+	}
+	for i, w := range writes {
+		p := bytes.Repeat([]byte{byte(i + 1)}, w.n)
+		n, err := f.WriteAt(p, w.off)
+		if err != nil || n != w.n {
+			t.Fatalf("WriteAt(%d, %d) = %d, %v", w.off, w.n, n, err)
+		}
+		copy(shadow[w.off:], p)
+	}
+
+	got := make([]byte, f.Size())
+	if n, err := f.ReadAt(got, 0); err != nil || n != len(got) {
+		t.Fatalf("ReadAt = %d, %v", n, err)
+	}
+	if !bytes.Equal(got, shadow) {
+		t.Error("file contents differ from shadow copy")
+	}
+	part := make([]byte, 10)
+	if _, err := f.ReadAt(part, 13); err != nil || !bytes.Equal(part, shadow[13:23]) {
+		t.Errorf("unaligned ReadAt = %v, %v", part, err)
+	}
+}
+
+func TestFile_Bounds(t *testing.T) {
+	f := newTestFile(t)
+
+	p := make([]byte, 10)
+	if n, err := f.ReadAt(p, 250); n != 6 || err != io.EOF {
+		t.Errorf("ReadAt across end = %d, %v; want 6, EOF", n, err)
+	}
+	if _, err := f.ReadAt(p, 256); err != io.EOF {
+		t.Errorf("ReadAt at end: got %v, want EOF", err)
+	}
+	if n, err := f.WriteAt(p, 250); n != 6 || err != io.ErrShortWrite {
+		t.Errorf("WriteAt across end = %d, %v; want 6, ErrShortWrite", n, err)
+	}
+	if _, err := f.ReadAt(p, -1); !errors.Is(err, pathoram.ErrNegativeOffset) {
+		t.Errorf("ReadAt(-1): got %v, want ErrNegativeOffset", err)
+	}
+	if _, err := f.WriteAt(p, -1); !errors.Is(err, pathoram.ErrNegativeOffset) {
+		t.Errorf("WriteAt(-1): got %v, want ErrNegativeOffset", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := f.ReadAt(p, 0); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("ReadAt after Close: got %v, want fs.ErrClosed", err)
+	}
+	if _, err := f.WriteAt(p, 0); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("WriteAt after Close: got %v, want fs.ErrClosed", err)
+	}
+}
+
+// File must work with io.SectionReader and friends.
+var _ interface {
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+} = (*File)(nil)
@@ -0,0 +1,66 @@
+// Command oramfs mounts a flat-namespace filesystem whose file contents are
+// stored in an encrypted, in-memory PathORAM.
+//
+// Usage:
+//
+//	oramfs -n 65536 -block-size 4096 /mnt/oram
+//
+// The filesystem lives only as long as the process; unmount it with
+// `fusermount -u /mnt/oram` or by interrupting the command. It is meant for
+// experimenting with oblivious storage from the shell, not for durable data.
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/x/oramfs"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "oramfs:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	fs := flag.NewFlagSet("oramfs", flag.ExitOnError)
+	n := fs.Int("n", 1<<14, "number of blocks")
+	blockSize := fs.Int("block-size", 4096, "bytes per block")
+	maxFile := fs.Int("max-file", 1<<20, "largest file in bytes; every file read or write costs its blocks in accesses")
+	fs.Parse(os.Args[1:])
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: oramfs [-n blocks] [-block-size bytes] [-max-file bytes] MOUNTPOINT")
+		os.Exit(2)
+	}
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	enc, err := pathoram.NewAESGCMEncryptor(key)
+	if err != nil {
+		return err
+	}
+	oram, err := pathoram.NewORAM(pathoram.WithCapacity(*n, *blockSize), pathoram.WithEncryptor(enc))
+	if err != nil {
+		return err
+	}
+	defer oram.Close()
+
+	server, err := oramfs.Mount(fs.Arg(0), oramfs.NewStore(oram, *maxFile), nil)
+	if err != nil {
+		return err
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		server.Unmount()
+	}()
+	server.Wait()
+	return nil
+}
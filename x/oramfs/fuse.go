@@ -0,0 +1,249 @@
+package oramfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+	"syscall"
+
+	gofs "github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/x/kv"
+)
+
+// Mount serves store at dir until the returned server is unmounted.
+func Mount(dir string, store *Store, opts *gofs.Options) (*fuse.Server, error) {
+	if opts == nil {
+		opts = &gofs.Options{}
+	}
+	if opts.FsName == "" {
+		opts.FsName = "oramfs"
+	}
+	return gofs.Mount(dir, &root{store: store}, opts)
+}
+
+// errno maps Store errors to FUSE status codes.
+func errno(err error) syscall.Errno {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, fs.ErrNotExist):
+		return syscall.ENOENT
+	case errors.Is(err, pathoram.ErrNoSpace):
+		return syscall.ENOSPC
+	case errors.Is(err, kv.ErrValueTooLarge):
+		return syscall.EFBIG
+	default:
+		return syscall.EIO
+	}
+}
+
+// root is the single directory holding every file.
+type root struct {
+	gofs.Inode
+	store *Store
+}
+
+var (
+	_ gofs.NodeGetattrer = (*root)(nil)
+	_ gofs.NodeLookuper  = (*root)(nil)
+	_ gofs.NodeReaddirer = (*root)(nil)
+	_ gofs.NodeCreater   = (*root)(nil)
+	_ gofs.NodeUnlinker  = (*root)(nil)
+	_ gofs.NodeRenamer   = (*root)(nil)
+)
+
+func (r *root) Getattr(ctx context.Context, f gofs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	out.Mode = syscall.S_IFDIR | 0o755
+	return 0
+}
+
+func (r *root) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*gofs.Inode, syscall.Errno) {
+	size, err := r.store.Size(name)
+	if err != nil {
+		return nil, errno(err)
+	}
+	fillAttr(&out.Attr, size)
+	return r.NewInode(ctx, &file{store: r.store}, gofs.StableAttr{Mode: syscall.S_IFREG}), 0
+}
+
+func (r *root) Readdir(ctx context.Context) (gofs.DirStream, syscall.Errno) {
+	names := r.store.Names()
+	entries := make([]fuse.DirEntry, len(names))
+	for i, name := range names {
+		entries[i] = fuse.DirEntry{Name: name, Mode: syscall.S_IFREG}
+	}
+	return gofs.NewListDirStream(entries), 0
+}
+
+func (r *root) Create(ctx context.Context, name string, flags, mode uint32, out *fuse.EntryOut) (*gofs.Inode, gofs.FileHandle, uint32, syscall.Errno) {
+	if err := r.store.WriteFile(name, nil); err != nil {
+		return nil, nil, 0, errno(err)
+	}
+	fillAttr(&out.Attr, 0)
+	n := &file{store: r.store}
+	inode := r.NewInode(ctx, n, gofs.StableAttr{Mode: syscall.S_IFREG})
+	return inode, &handle{file: n}, 0, 0
+}
+
+func (r *root) Unlink(ctx context.Context, name string) syscall.Errno {
+	return errno(r.store.Remove(name))
+}
+
+func (r *root) Rename(ctx context.Context, name string, newParent gofs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	if newParent.EmbeddedInode() != r.EmbeddedInode() || flags != 0 {
+		return syscall.EINVAL
+	}
+	return errno(r.store.Rename(name, newName))
+}
+
+func fillAttr(out *fuse.Attr, size int) {
+	out.Mode = syscall.S_IFREG | 0o644
+	out.Size = uint64(size)
+	out.Blocks = (out.Size + 511) / 512
+}
+
+// file is a regular file. Its name is looked up from the inode tree on each
+// use so that renames need no bookkeeping.
+type file struct {
+	gofs.Inode
+	store *Store
+}
+
+var (
+	_ gofs.NodeGetattrer = (*file)(nil)
+	_ gofs.NodeSetattrer = (*file)(nil)
+	_ gofs.NodeOpener    = (*file)(nil)
+)
+
+func (f *file) name() string {
+	return f.Path(f.Root())
+}
+
+func (f *file) Getattr(ctx context.Context, fh gofs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	if h, ok := fh.(*handle); ok {
+		return h.Getattr(ctx, out)
+	}
+	size, err := f.store.Size(f.name())
+	if err != nil {
+		return errno(err)
+	}
+	fillAttr(&out.Attr, size)
+	return 0
+}
+
+func (f *file) Setattr(ctx context.Context, fh gofs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	size, ok := in.GetSize()
+	if !ok {
+		return f.Getattr(ctx, fh, out)
+	}
+	if h, ok := fh.(*handle); ok {
+		h.truncate(int(size))
+		return h.Getattr(ctx, out)
+	}
+	data, err := f.store.ReadFile(f.name())
+	if err != nil {
+		return errno(err)
+	}
+	data = resize(data, int(size))
+	if err := f.store.WriteFile(f.name(), data); err != nil {
+		return errno(err)
+	}
+	fillAttr(&out.Attr, len(data))
+	return 0
+}
+
+// Open loads the whole file into the handle; it is written back on Flush.
+func (f *file) Open(ctx context.Context, flags uint32) (gofs.FileHandle, uint32, syscall.Errno) {
+	h := &handle{file: f}
+	if flags&syscall.O_TRUNC != 0 {
+		h.dirty = true
+		return h, 0, 0
+	}
+	data, err := f.store.ReadFile(f.name())
+	if err != nil {
+		return nil, 0, errno(err)
+	}
+	h.data = data
+	return h, 0, 0
+}
+
+// handle buffers one open file. Concurrent handles on the same file do not
+// see each other's writes; the last one flushed wins.
+type handle struct {
+	mu    sync.Mutex
+	file  *file
+	data  []byte
+	dirty bool
+}
+
+var (
+	_ gofs.FileReader    = (*handle)(nil)
+	_ gofs.FileWriter    = (*handle)(nil)
+	_ gofs.FileFlusher   = (*handle)(nil)
+	_ gofs.FileFsyncer   = (*handle)(nil)
+	_ gofs.FileGetattrer = (*handle)(nil)
+)
+
+func (h *handle) Read(ctx context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if off >= int64(len(h.data)) {
+		return fuse.ReadResultData(nil), 0
+	}
+	n := copy(dest, h.data[off:])
+	return fuse.ReadResultData(dest[:n]), 0
+}
+
+func (h *handle) Write(ctx context.Context, data []byte, off int64) (uint32, syscall.Errno) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if end := int(off) + len(data); end > len(h.data) {
+		h.data = resize(h.data, end)
+	}
+	copy(h.data[off:], data)
+	h.dirty = true
+	return uint32(len(data)), 0
+}
+
+func (h *handle) Flush(ctx context.Context) syscall.Errno {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.dirty {
+		return 0
+	}
+	if err := h.file.store.WriteFile(h.file.name(), h.data); err != nil {
+		return errno(err)
+	}
+	h.dirty = false
+	return 0
+}
+
+func (h *handle) Fsync(ctx context.Context, flags uint32) syscall.Errno {
+	return h.Flush(ctx)
+}
+
+func (h *handle) Getattr(ctx context.Context, out *fuse.AttrOut) syscall.Errno {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fillAttr(&out.Attr, len(h.data))
+	return 0
+}
+
+func (h *handle) truncate(size int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.data = resize(h.data, size)
+	h.dirty = true
+}
+
+// resize returns data truncated or zero-extended to size bytes.
+func resize(data []byte, size int) []byte {
+	if size <= len(data) {
+		return data[:size]
+	}
+	return append(data, make([]byte, size-len(data))...)
+}
@@ -0,0 +1,10 @@
+module github.com/etclab/pathoram-go/x/oramfs
+
+go 1.25.1
+
+require (
+	github.com/etclab/pathoram-go v0.0.0
+	github.com/hanwen/go-fuse/v2 v2.9.0
+)
+
+require golang.org/x/sys v0.28.0 // indirect
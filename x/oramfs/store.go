@@ -0,0 +1,115 @@
+// Package oramfs mounts a flat-namespace filesystem backed by a PathORAM.
+//
+// File contents are stored as values of a kv.Store keyed by file
+// name, so every read and write of file data is oblivious. The set of names
+// and file sizes lives in client memory, as does the kv.Store directory.
+// Directories are not supported.
+package oramfs
+
+import (
+	"io/fs"
+	"slices"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/x/kv"
+)
+
+// Store is the filesystem's namespace: whole-file reads and writes by name.
+// It is safe for concurrent use.
+type Store struct {
+	mu    sync.Mutex
+	kv    *kv.Store
+	sizes map[string]int
+}
+
+// NewStore creates an empty Store that owns all blocks of oram and holds
+// files of up to maxFile bytes. Every file read or write costs the same
+// number of accesses, one per block of maxFile (see kv.Store.Accesses).
+func NewStore(oram *pathoram.PathORAM, maxFile int) *Store {
+	return &Store{kv: kv.New(oram, maxFile), sizes: make(map[string]int)}
+}
+
+// Names returns the file names in sorted order.
+func (s *Store) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.sizes))
+	for name := range s.sizes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Size returns the size of name, or fs.ErrNotExist.
+func (s *Store) Size(name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	size, ok := s.sizes[name]
+	if !ok {
+		return 0, fs.ErrNotExist
+	}
+	return size, nil
+}
+
+// ReadFile returns the contents of name, or fs.ErrNotExist.
+func (s *Store) ReadFile(name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sizes[name]; !ok {
+		return nil, fs.ErrNotExist
+	}
+	return s.kv.Get(name)
+}
+
+// WriteFile replaces the contents of name, creating it if necessary.
+// It returns pathoram.ErrNoSpace if the ORAM is full and kv.ErrValueTooLarge
+// if data is longer than the maximum file size.
+func (s *Store) WriteFile(name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.kv.Put(name, data); err != nil {
+		return err
+	}
+	s.sizes[name] = len(data)
+	return nil
+}
+
+// Remove deletes name, or returns fs.ErrNotExist.
+func (s *Store) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sizes[name]; !ok {
+		return fs.ErrNotExist
+	}
+	if err := s.kv.Delete(name); err != nil {
+		return err
+	}
+	delete(s.sizes, name)
+	return nil
+}
+
+// Rename moves oldName to newName, replacing any existing newName.
+// The data is copied through the ORAM, one access per block each way.
+func (s *Store) Rename(oldName, newName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	size, ok := s.sizes[oldName]
+	if !ok {
+		return fs.ErrNotExist
+	}
+	if oldName == newName {
+		return nil
+	}
+	data, err := s.kv.Get(oldName)
+	if err != nil {
+		return err
+	}
+	if err := s.kv.Put(newName, data); err != nil {
+		return err
+	}
+	s.sizes[newName] = size
+	delete(s.sizes, oldName)
+	return s.kv.Delete(oldName)
+}
@@ -0,0 +1,100 @@
+package oramfs
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+	"github.com/etclab/pathoram-go/x/kv"
+)
+
+func newTestStore(t *testing.T, numBlocks int) *Store {
+	t.Helper()
+	oram, err := pathoram.NewInMemory(pathoram.Config{NumBlocks: numBlocks, BlockSize: 32, StashLimit: 200})
+	if err != nil {
+		t.Fatalf("NewInMemory failed: %v", err)
+	}
+	return NewStore(oram, 4*32)
+}
+
+func TestStore(t *testing.T) {
+	s := newTestStore(t, 64)
+	if err := s.WriteFile("b", bytes.Repeat([]byte("x"), 100)); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := s.WriteFile("a", nil); err != nil {
+		t.Fatalf("WriteFile empty failed: %v", err)
+	}
+	if got := s.Names(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	if size, _ := s.Size("b"); size != 100 {
+		t.Errorf("Size(b) = %d, want 100", size)
+	}
+	if data, err := s.ReadFile("a"); err != nil || len(data) != 0 {
+		t.Errorf("ReadFile(a) = %q, %v", data, err)
+	}
+
+	if err := s.Rename("b", "c"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if data, err := s.ReadFile("c"); err != nil || len(data) != 100 {
+		t.Errorf("ReadFile(c) after rename = %d bytes, %v", len(data), err)
+	}
+	if err := s.Rename("c", "c"); err != nil {
+		t.Errorf("Rename to self failed: %v", err)
+	}
+	if _, err := s.ReadFile("b"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile(b) after rename: got %v, want ErrNotExist", err)
+	}
+
+	if err := s.Remove("c"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := s.Remove("c"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("second Remove: got %v, want ErrNotExist", err)
+	}
+	if err := s.WriteFile("big", make([]byte, 4*32+1)); !errors.Is(err, kv.ErrValueTooLarge) {
+		t.Errorf("oversized WriteFile: got %v, want ErrValueTooLarge", err)
+	}
+	for i := range 16 {
+		if err := s.WriteFile(string(rune('d'+i)), make([]byte, 4*32)); err != nil {
+			t.Fatalf("WriteFile filling the ORAM failed: %v", err)
+		}
+	}
+	if err := s.WriteFile("full", make([]byte, 1)); !errors.Is(err, pathoram.ErrNoSpace) {
+		t.Errorf("WriteFile to a full ORAM: got %v, want ErrNoSpace", err)
+	}
+}
+
+func TestMount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("mounts a FUSE filesystem")
+	}
+	dir := t.TempDir()
+	server, err := Mount(dir, newTestStore(t, 64), nil)
+	if err != nil {
+		t.Skipf("FUSE unavailable: %v", err)
+	}
+	defer server.Unmount()
+
+	path := filepath.Join(dir, "hello.txt")
+	if err := os.WriteFile(path, []byte("hello, oram"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "hello, oram" {
+		t.Errorf("ReadFile = %q, %v", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || entries[0].Name() != "hello.txt" {
+		t.Errorf("ReadDir = %v, %v", entries, err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+}
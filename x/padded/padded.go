@@ -0,0 +1,150 @@
+// Package padded schedules a pathoram.PathORAM's accesses at a constant
+// rate, so the storage server sees the same traffic whether the application
+// is idle or busy. Like everything under x/, the API may change.
+package padded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// ErrClosed is returned by requests made after Close.
+var ErrClosed = errors.New("padded client closed")
+
+// Client shapes an ORAM's traffic to a constant rate. A scheduler
+// goroutine performs exactly one access per tick: the oldest queued request
+// if there is one, otherwise a dummy access. The storage server therefore
+// sees the same access rate whether the application is idle or busy, which
+// metadata-hiding applications (messaging, hidden services) need.
+//
+// Requests wait for the next free tick, so latency grows with load; a
+// request whose context ends while queued is dropped and its tick becomes a
+// dummy access. Use the Client for all accesses: calls made directly on the
+// ORAM bypass the schedule and are visible as extra traffic.
+type Client struct {
+	oram     *pathoram.PathORAM
+	logger   *slog.Logger
+	interval time.Duration
+	reqs     chan *request
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+
+	mu            sync.Mutex
+	real, dummies uint64
+}
+
+// request is an access waiting for its tick.
+type request struct {
+	ctx     context.Context
+	blockID int
+	newData []byte
+	data    []byte
+	err     error
+	done    chan struct{}
+}
+
+// New starts a scheduler issuing rate accesses per second on oram. Call
+// Close to stop it.
+func New(oram *pathoram.PathORAM, rate float64) (*Client, error) {
+	interval := time.Duration(float64(time.Second) / rate)
+	if rate <= 0 || interval <= 0 {
+		return nil, fmt.Errorf("%w: padding rate must be positive and at most 1e9", pathoram.ErrInvalidConfig)
+	}
+	c := &Client{
+		oram:     oram,
+		logger:   oram.Logger(),
+		interval: interval,
+		reqs:     make(chan *request),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go c.run()
+	return c, nil
+}
+
+// run performs one access per tick until Close.
+func (c *Client) run() {
+	defer close(c.done)
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-t.C:
+		}
+		select {
+		case r := <-c.reqs:
+			if r.ctx.Err() == nil {
+				r.data, r.err = c.oram.AccessCtx(r.ctx, r.blockID, r.newData)
+				close(r.done)
+				c.count(&c.real)
+				continue
+			}
+			r.err = r.ctx.Err()
+			close(r.done)
+		default:
+		}
+		if err := c.oram.DummyAccess(context.Background()); err != nil && c.logger != nil {
+			c.logger.Warn("pathoram: padding access failed", "err", err)
+		}
+		c.count(&c.dummies)
+	}
+}
+
+func (c *Client) count(n *uint64) {
+	c.mu.Lock()
+	*n++
+	c.mu.Unlock()
+}
+
+// Read reads blockID at the next free tick.
+func (c *Client) Read(ctx context.Context, blockID int) ([]byte, error) {
+	return c.Access(ctx, blockID, nil)
+}
+
+// Write writes data to blockID at the next free tick and returns the
+// previous value.
+func (c *Client) Write(ctx context.Context, blockID int, data []byte) ([]byte, error) {
+	if data == nil {
+		return nil, pathoram.ErrInvalidDataSize
+	}
+	return c.Access(ctx, blockID, data)
+}
+
+// Access queues an access like PathORAM.AccessCtx and waits for it to run.
+func (c *Client) Access(ctx context.Context, blockID int, newData []byte) ([]byte, error) {
+	r := &request{ctx: ctx, blockID: blockID, newData: newData, done: make(chan struct{})}
+	select {
+	case c.reqs <- r:
+	case <-c.stop:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	// The scheduler has taken r and closes done once it has run or dropped it
+	<-r.done
+	return r.data, r.err
+}
+
+// Counts returns the number of real and dummy accesses performed so far.
+func (c *Client) Counts() (real, dummy uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.real, c.dummies
+}
+
+// Close stops the scheduler. Requests not yet taken return ErrClosed.
+// It does not close the ORAM.
+func (c *Client) Close() error {
+	c.once.Do(func() { close(c.stop) })
+	<-c.done
+	return nil
+}
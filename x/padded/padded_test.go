@@ -0,0 +1,65 @@
+package padded
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func TestClient(t *testing.T) {
+	oram, _ := pathoram.NewORAM(pathoram.WithCapacity(16, 16))
+	c, err := New(oram, 500)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := c.Write(ctx, 3, bytes.Repeat([]byte{3}, 16)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := c.Read(ctx, 3); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{3}, 16)) {
+				t.Errorf("Read = %x, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+	time.Sleep(50 * time.Millisecond) // idle: only dummies
+	c.Close()
+
+	real, dummy := c.Counts()
+	if real != 5 {
+		t.Errorf("real accesses = %d, want 5", real)
+	}
+	if dummy < 5 {
+		t.Errorf("dummy accesses = %d, want the idle period padded", dummy)
+	}
+	if got := oram.Stats().Accesses; got != real+dummy {
+		t.Errorf("ORAM saw %d accesses, client reports %d", got, real+dummy)
+	}
+	if _, err := c.Read(ctx, 3); !errors.Is(err, ErrClosed) {
+		t.Errorf("Read after Close: got %v, want ErrClosed", err)
+	}
+	if _, err := New(oram, 0); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("zero rate: got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestClient_CancelledWhileQueued(t *testing.T) {
+	oram, _ := pathoram.NewORAM(pathoram.WithCapacity(16, 16))
+	c, _ := New(oram, 2) // one tick every 500ms
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Read(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Read = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,374 @@
+// Package partitionoram implements the partitioned ORAM of Stefanov, Shi and
+// Song ("Towards Practical Oblivious RAM", NDSS 2012) with Path ORAM
+// partitions.
+//
+// Blocks are spread over about √N independent PathORAM partitions. A
+// client-side partition table records the partition and slot of every block.
+// An access reads the block from its partition, or performs a dummy access
+// on a random partition if the block is held by the client, then assigns
+// the block to a fresh random partition and appends it to that partition's
+// eviction queue. Each access also schedules EvictionRate evictions on
+// uniformly random partitions; a background worker per partition performs
+// them, writing the head of its queue into a free slot or, with an empty
+// queue, a dummy access. The server sees one access to a uniformly random
+// partition per Read or Write plus EvictionRate more, regardless of the
+// blocks involved.
+//
+// Each partition's paths are about half as long as a single Path ORAM's
+// over N blocks, and evictions run off the caller's path and in parallel
+// across partitions. The cost is client memory for the partition table and
+// queues. Like everything under x/, the API may change.
+package partitionoram
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand/v2"
+	"slices"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// Config configures a partitioned ORAM.
+type Config struct {
+	NumBlocks int // valid IDs are 0 to NumBlocks-1
+	BlockSize int // bytes per block
+
+	// Partitions is the number of partitions (default ⌈√NumBlocks⌉).
+	Partitions int
+
+	// PartitionCapacity is the number of blocks each partition holds
+	// (default twice the average load, 2⌈NumBlocks/Partitions⌉). An
+	// eviction to a full partition leaves the block queued and performs a
+	// dummy access instead.
+	PartitionCapacity int
+
+	// EvictionRate is the number of evictions scheduled per access
+	// (default 2). It must exceed 1 for queues to drain.
+	EvictionRate int
+
+	// Partition is the template for each partition's Config. NumBlocks and
+	// BlockSize are overwritten.
+	Partition pathoram.Config
+
+	// Rand chooses partitions (default crypto/rand).
+	Rand io.Reader
+}
+
+// Stats counts a partitioned ORAM's work since New.
+type Stats struct {
+	Accesses       uint64
+	Evictions      uint64 // evictions that wrote a queued block
+	DummyEvictions uint64 // evictions that found nothing to write
+	Queued         int    // blocks currently waiting in eviction queues
+}
+
+// Factory builds partition i from its Config.
+type Factory func(i int, cfg pathoram.Config) (*pathoram.PathORAM, error)
+
+type entryState int
+
+const (
+	queued   entryState = iota // waiting in queues[part]
+	inflight                   // being written to slot of part
+	stored                     // in slot of part
+)
+
+// entry is the client's record of one block. Accesses replace a block's
+// entry rather than modifying it, so a worker holding an old entry can tell
+// that its write was superseded.
+type entry struct {
+	id    int
+	data  []byte // set while queued or inflight
+	state entryState
+	part  int
+	slot  int
+}
+
+// ORAM is a partitioned ORAM. Its methods are safe for concurrent use;
+// accesses run one at a time while evictions proceed in the background.
+type ORAM struct {
+	cfg   Config
+	parts []*pathoram.PathORAM
+
+	access sync.Mutex // serializes Access
+
+	mu     sync.Mutex // guards the fields below
+	rng    *mathrand.Rand
+	blocks []*entry // partition table; nil means never written
+	queues [][]*entry
+	free   [][]int // free slots per partition
+	stats  Stats
+	err    error // first background eviction failure
+	closed bool
+
+	ticks   []chan struct{}
+	pending sync.WaitGroup // scheduled evictions
+	workers sync.WaitGroup
+}
+
+// New creates a partitioned ORAM whose partitions are built by newPartition
+// (default pathoram.NewInMemory with the partition Config).
+func New(cfg Config, newPartition Factory) (*ORAM, error) {
+	if cfg.NumBlocks <= 0 || cfg.BlockSize <= 0 {
+		return nil, pathoram.ErrInvalidConfig
+	}
+	if cfg.Partitions == 0 {
+		cfg.Partitions = int(math.Ceil(math.Sqrt(float64(cfg.NumBlocks))))
+	}
+	if cfg.PartitionCapacity == 0 {
+		cfg.PartitionCapacity = 2 * ((cfg.NumBlocks + cfg.Partitions - 1) / cfg.Partitions)
+	}
+	if cfg.EvictionRate == 0 {
+		cfg.EvictionRate = 2
+	}
+	if cfg.Partitions < 0 || cfg.EvictionRate < 2 || cfg.Partitions*cfg.PartitionCapacity < cfg.NumBlocks {
+		return nil, fmt.Errorf("%w: %d partitions of %d blocks, eviction rate %d", pathoram.ErrInvalidConfig,
+			cfg.Partitions, cfg.PartitionCapacity, cfg.EvictionRate)
+	}
+	if newPartition == nil {
+		newPartition = func(_ int, pcfg pathoram.Config) (*pathoram.PathORAM, error) {
+			return pathoram.NewInMemory(pcfg)
+		}
+	}
+	r := cfg.Rand
+	if r == nil {
+		r = rand.Reader
+	}
+	var seed [32]byte
+	if _, err := io.ReadFull(r, seed[:]); err != nil {
+		return nil, err
+	}
+
+	o := &ORAM{
+		cfg:    cfg,
+		rng:    mathrand.New(mathrand.NewChaCha8(seed)),
+		blocks: make([]*entry, cfg.NumBlocks),
+		queues: make([][]*entry, cfg.Partitions),
+		free:   make([][]int, cfg.Partitions),
+		ticks:  make([]chan struct{}, cfg.Partitions),
+	}
+	for i := range cfg.Partitions {
+		pcfg := cfg.Partition
+		pcfg.NumBlocks, pcfg.BlockSize = cfg.PartitionCapacity, cfg.BlockSize
+		p, err := newPartition(i, pcfg)
+		if err != nil {
+			o.closeParts()
+			return nil, fmt.Errorf("partition %d: %w", i, err)
+		}
+		o.parts = append(o.parts, p)
+		for s := cfg.PartitionCapacity - 1; s >= 0; s-- {
+			o.free[i] = append(o.free[i], s)
+		}
+	}
+	for i := range o.ticks {
+		o.ticks[i] = make(chan struct{}, 4*cfg.EvictionRate)
+		o.workers.Add(1)
+		go o.evictor(i)
+	}
+	return o, nil
+}
+
+// Read returns block id's contents.
+func (o *ORAM) Read(id int) ([]byte, error) {
+	return o.Access(id, nil)
+}
+
+// Write replaces block id's contents and returns the previous value.
+func (o *ORAM) Write(id int, data []byte) ([]byte, error) {
+	if data == nil {
+		return nil, pathoram.ErrInvalidDataSize
+	}
+	return o.Access(id, data)
+}
+
+// Access reads block id if newData is nil and otherwise replaces it,
+// returning the previous value either way.
+func (o *ORAM) Access(id int, newData []byte) ([]byte, error) {
+	if id < 0 || id >= o.cfg.NumBlocks {
+		return nil, pathoram.ErrInvalidBlockID
+	}
+	if newData != nil && len(newData) != o.cfg.BlockSize {
+		return nil, pathoram.ErrInvalidDataSize
+	}
+	o.access.Lock()
+	defer o.access.Unlock()
+
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil, pathoram.ErrClosed
+	}
+	if o.err != nil {
+		o.mu.Unlock()
+		return nil, o.err
+	}
+	e := o.blocks[id]
+	var data []byte
+	part, slot := o.rng.IntN(o.cfg.Partitions), -1
+	switch {
+	case e == nil:
+		data = make([]byte, o.cfg.BlockSize)
+	case e.state == stored:
+		part, slot = e.part, e.slot
+	default:
+		data = e.data
+		if e.state == queued {
+			o.queues[e.part] = slices.DeleteFunc(o.queues[e.part], func(q *entry) bool { return q == e })
+			o.stats.Queued--
+		}
+	}
+	o.mu.Unlock()
+
+	// One partition access either way: the block's slot, or a dummy
+	if slot >= 0 {
+		var err error
+		if data, err = o.parts[part].Read(slot); err != nil {
+			return nil, err
+		}
+	} else if err := o.parts[part].DummyAccess(context.Background()); err != nil {
+		return nil, err
+	}
+
+	next := &entry{id: id, data: data}
+	if newData != nil {
+		next.data = append([]byte(nil), newData...)
+	}
+	o.mu.Lock()
+	if slot >= 0 {
+		o.free[part] = append(o.free[part], slot)
+	}
+	next.part = o.rng.IntN(o.cfg.Partitions)
+	o.blocks[id] = next
+	o.queues[next.part] = append(o.queues[next.part], next)
+	o.stats.Queued++
+	o.stats.Accesses++
+	targets := make([]int, o.cfg.EvictionRate)
+	for i := range targets {
+		targets[i] = o.rng.IntN(o.cfg.Partitions)
+	}
+	o.mu.Unlock()
+
+	for _, p := range targets {
+		o.pending.Add(1)
+		o.ticks[p] <- struct{}{}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// evictor performs the evictions scheduled on partition p.
+func (o *ORAM) evictor(p int) {
+	defer o.workers.Done()
+	for range o.ticks[p] {
+		if err := o.evict(p); err != nil {
+			o.mu.Lock()
+			if o.err == nil {
+				o.err = fmt.Errorf("partition %d eviction: %w", p, err)
+			}
+			o.mu.Unlock()
+		}
+		o.pending.Done()
+	}
+}
+
+// evict writes the head of partition p's queue into a free slot, or
+// performs a dummy access if there is nothing to write.
+func (o *ORAM) evict(p int) error {
+	o.mu.Lock()
+	var e *entry
+	if len(o.queues[p]) > 0 && len(o.free[p]) > 0 {
+		e = o.queues[p][0]
+		o.queues[p] = o.queues[p][1:]
+		o.stats.Queued--
+		n := len(o.free[p]) - 1
+		e.state, e.slot = inflight, o.free[p][n]
+		o.free[p] = o.free[p][:n]
+		o.stats.Evictions++
+	} else {
+		o.stats.DummyEvictions++
+	}
+	o.mu.Unlock()
+
+	if e == nil {
+		return o.parts[p].DummyAccess(context.Background())
+	}
+	_, err := o.parts[p].Write(e.slot, e.data)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err == nil && o.blocks[e.id] == e {
+		e.state, e.data = stored, nil
+		return nil
+	}
+	if err != nil && o.blocks[e.id] == e {
+		// Put the block back at the head of its queue
+		e.state = queued
+		o.queues[p] = append([]*entry{e}, o.queues[p]...)
+		o.stats.Queued++
+		o.stats.Evictions--
+	}
+	// Superseded by a later access, or failed: the slot holds nothing live
+	o.free[p] = append(o.free[p], e.slot)
+	return err
+}
+
+// Flush waits for every scheduled eviction to finish. Blocks may remain
+// queued: evictions run at a fixed rate, not until the queues are empty.
+func (o *ORAM) Flush() error {
+	o.pending.Wait()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
+
+// Capacity returns the number of blocks.
+func (o *ORAM) Capacity() int { return o.cfg.NumBlocks }
+
+// BlockSize returns the block size in bytes.
+func (o *ORAM) BlockSize() int { return o.cfg.BlockSize }
+
+// Partitions returns the partitions, for inspecting their Stats.
+func (o *ORAM) Partitions() []*pathoram.PathORAM { return o.parts }
+
+// Stats returns cumulative counters.
+func (o *ORAM) Stats() Stats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stats
+}
+
+// Close waits for scheduled evictions, stops the workers and closes the
+// partitions. The partition table and queues live only in client memory,
+// so a closed ORAM cannot be reopened.
+func (o *ORAM) Close() error {
+	o.access.Lock()
+	defer o.access.Unlock()
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	o.closed = true
+	o.mu.Unlock()
+
+	o.pending.Wait()
+	for _, t := range o.ticks {
+		close(t)
+	}
+	o.workers.Wait()
+	return o.closeParts()
+}
+
+func (o *ORAM) closeParts() error {
+	var first error
+	for _, p := range o.parts {
+		if err := p.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
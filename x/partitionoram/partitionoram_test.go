@@ -0,0 +1,107 @@
+package partitionoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func TestORAM_ReadWrite(t *testing.T) {
+	cfg := Config{NumBlocks: 256, BlockSize: 16, Rand: pathoram.NewSeededRand([32]byte{1})}
+	o, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer o.Close()
+
+	want := make(map[int][]byte)
+	for i := range 3000 {
+		id := (i * 37) % cfg.NumBlocks
+		if i%3 == 0 {
+			data := bytes.Repeat([]byte{byte(i)}, 16)
+			if _, err := o.Write(id, data); err != nil {
+				t.Fatalf("Write(%d): %v", id, err)
+			}
+			want[id] = data
+			continue
+		}
+		got, err := o.Read(id)
+		if err != nil {
+			t.Fatalf("Read(%d): %v", id, err)
+		}
+		if w, ok := want[id]; ok && !bytes.Equal(got, w) {
+			t.Fatalf("Read(%d) = %x, want %x", id, got, w)
+		} else if !ok && !bytes.Equal(got, make([]byte, 16)) {
+			t.Fatalf("Read(%d) of unwritten block = %x", id, got)
+		}
+	}
+	if err := o.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if s := o.Stats(); s.Queued > cfg.NumBlocks/4 {
+		t.Errorf("%d blocks still queued after %d accesses; eviction is not keeping up", s.Queued, s.Accesses)
+	}
+}
+
+func TestORAM_PartitionAccessesPerAccess(t *testing.T) {
+	o, err := New(Config{NumBlocks: 100, BlockSize: 8, EvictionRate: 3}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer o.Close()
+	if len(o.Partitions()) != 10 {
+		t.Fatalf("%d partitions, want 10", len(o.Partitions()))
+	}
+	// Reads of the same block, fresh or held by the client, cost the same
+	for range 50 {
+		if _, err := o.Read(5); err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+	}
+	o.Flush()
+	var total uint64
+	for _, p := range o.Partitions() {
+		total += p.Stats().Accesses
+	}
+	if total != 50*(1+3) {
+		t.Errorf("partitions performed %d accesses, want %d", total, 50*4)
+	}
+	s := o.Stats()
+	if s.Evictions+s.DummyEvictions != 150 {
+		t.Errorf("Stats = %+v, want 150 evictions", s)
+	}
+}
+
+func TestORAM_Errors(t *testing.T) {
+	if _, err := New(Config{NumBlocks: 100, BlockSize: 8, Partitions: 4, PartitionCapacity: 20}, nil); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("undersized partitions: %v, want ErrInvalidConfig", err)
+	}
+	if _, err := New(Config{NumBlocks: 100, BlockSize: 8, EvictionRate: 1}, nil); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("EvictionRate 1: %v, want ErrInvalidConfig", err)
+	}
+	o, _ := New(Config{NumBlocks: 4, BlockSize: 8}, nil)
+	if _, err := o.Read(4); !errors.Is(err, pathoram.ErrInvalidBlockID) {
+		t.Errorf("Read(4) = %v, want ErrInvalidBlockID", err)
+	}
+	if _, err := o.Write(0, make([]byte, 7)); !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Errorf("short Write = %v, want ErrInvalidDataSize", err)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := o.Read(0); !errors.Is(err, pathoram.ErrClosed) {
+		t.Errorf("Read after Close = %v, want ErrClosed", err)
+	}
+}
+
+func BenchmarkAccess(b *testing.B) {
+	o, _ := New(Config{NumBlocks: 4096, BlockSize: 4096}, nil)
+	defer o.Close()
+	data := make([]byte, 4096)
+	b.ResetTimer()
+	for i := range b.N {
+		o.Write(i%4096, data)
+	}
+}
@@ -0,0 +1,286 @@
+// Package sqrtoram implements the non-recursive square-root ORAM of
+// Goldreich and Ostrovsky over the pathoram Storage and Encryptor
+// interfaces, for comparison with Path ORAM and for deployments where a
+// periodic full reshuffle is acceptable.
+//
+// Storage holds N real and √N dummy blocks, one per bucket, in a secret
+// random order. Each access reads one storage slot: the requested block's,
+// or the next unused dummy's if the block is already in the client's
+// shelter. After √N accesses every slot has been read at most once, and the
+// client reads the whole store, merges the shelter and writes it back under
+// a fresh permutation. The storage server therefore sees N+√N distinct
+// uniformly placed reads between reshuffles, and nothing else.
+//
+// Compared to Path ORAM an access costs one block read instead of a path,
+// but the reshuffle costs 2(N+√N) block transfers every √N accesses and
+// holds all N blocks in client memory while it runs, as does the position
+// table. Like everything under x/, the API may change.
+package sqrtoram
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand/v2"
+	"sync"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+// idSize prefixes each stored plaintext with its block ID, so the ID is
+// encrypted and slots reveal nothing about their contents.
+const idSize = 8
+
+// Config configures a square-root ORAM.
+type Config struct {
+	NumBlocks int       // valid IDs are 0 to NumBlocks-1
+	BlockSize int       // bytes per block
+	Rand      io.Reader // permutation source (default crypto/rand)
+}
+
+// Stats counts an ORAM's work since New.
+type Stats struct {
+	Accesses     uint64
+	Shuffles     uint64
+	BucketReads  uint64
+	BucketWrites uint64
+}
+
+// ORAM is a square-root ORAM. Its methods are safe for concurrent use and
+// run one access at a time.
+type ORAM struct {
+	mu      sync.Mutex
+	cfg     Config
+	dummies int // √N dummy slots, and accesses per epoch
+	storage pathoram.Storage
+	enc     pathoram.Encryptor
+	rng     io.Reader
+
+	pos     []int          // slot of each real block, then each dummy
+	shelter map[int][]byte // blocks read this epoch
+	count   int            // accesses this epoch
+	epoch   int            // reshuffles so far; bound into each slot's AAD
+	stats   Stats
+	closed  bool
+}
+
+// StorageParams returns the storage dimensions New requires for cfg and enc:
+// numBuckets buckets of one block of blockSize bytes.
+func StorageParams(cfg Config, enc pathoram.Encryptor) (numBuckets, blockSize int) {
+	return cfg.NumBlocks + numDummies(cfg.NumBlocks), idSize + cfg.BlockSize + enc.Overhead()
+}
+
+func numDummies(n int) int {
+	return max(1, int(math.Ceil(math.Sqrt(float64(n)))))
+}
+
+// New creates a square-root ORAM over storage, which must have the
+// dimensions StorageParams reports. Its current contents are discarded:
+// New writes every slot, so all blocks read as zeros until written.
+func New(cfg Config, storage pathoram.Storage, enc pathoram.Encryptor) (*ORAM, error) {
+	if cfg.NumBlocks <= 0 || cfg.BlockSize <= 0 || cfg.BlockSize > pathoram.MaxBlockSize {
+		return nil, pathoram.ErrInvalidConfig
+	}
+	numBuckets, blockSize := StorageParams(cfg, enc)
+	if storage.NumBuckets() != numBuckets || storage.BucketSize() != 1 || storage.BlockSize() != blockSize {
+		return nil, fmt.Errorf("%w: storage must have %d buckets of 1 block of %d bytes", pathoram.ErrInvalidConfig, numBuckets, blockSize)
+	}
+	o := &ORAM{
+		cfg:     cfg,
+		dummies: numDummies(cfg.NumBlocks),
+		storage: storage,
+		enc:     enc,
+		rng:     cfg.Rand,
+		shelter: make(map[int][]byte),
+	}
+	if o.rng == nil {
+		o.rng = rand.Reader
+	}
+	if err := o.writeAll(make(map[int][]byte)); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// NewInMemory creates a square-root ORAM with in-memory storage and no
+// encryption, for tests and benchmarks.
+func NewInMemory(cfg Config) (*ORAM, error) {
+	enc := pathoram.NoOpEncryptor{}
+	numBuckets, blockSize := StorageParams(cfg, enc)
+	if numBuckets <= 0 || blockSize <= 0 {
+		return nil, pathoram.ErrInvalidConfig
+	}
+	return New(cfg, pathoram.NewInMemoryStorage(numBuckets, 1, blockSize), enc)
+}
+
+// Read returns block id's contents.
+func (o *ORAM) Read(id int) ([]byte, error) {
+	return o.Access(id, nil)
+}
+
+// Write replaces block id's contents and returns the previous value.
+func (o *ORAM) Write(id int, data []byte) ([]byte, error) {
+	if data == nil {
+		return nil, pathoram.ErrInvalidDataSize
+	}
+	return o.Access(id, data)
+}
+
+// Access reads block id if newData is nil and otherwise replaces it,
+// returning the previous value either way.
+func (o *ORAM) Access(id int, newData []byte) ([]byte, error) {
+	if id < 0 || id >= o.cfg.NumBlocks {
+		return nil, pathoram.ErrInvalidBlockID
+	}
+	if newData != nil && len(newData) != o.cfg.BlockSize {
+		return nil, pathoram.ErrInvalidDataSize
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil, pathoram.ErrClosed
+	}
+
+	// Read the block's slot, or the next dummy's if it is already sheltered
+	slot := o.pos[id]
+	if _, ok := o.shelter[id]; ok {
+		slot = o.pos[o.cfg.NumBlocks+o.count]
+	}
+	gotID, data, err := o.readSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := o.shelter[id]; !ok {
+		if gotID != id {
+			return nil, fmt.Errorf("%w: slot %d holds block %d, want %d", pathoram.ErrCorruptObject, slot, gotID, id)
+		}
+		o.shelter[id] = data
+	}
+
+	prev := append([]byte(nil), o.shelter[id]...)
+	if newData != nil {
+		o.shelter[id] = append([]byte(nil), newData...)
+	}
+	o.stats.Accesses++
+	o.count++
+	if o.count == o.dummies {
+		if err := o.reshuffle(); err != nil {
+			return nil, err
+		}
+	}
+	return prev, nil
+}
+
+// readSlot reads and decrypts the block in slot, returning its ID and data.
+func (o *ORAM) readSlot(slot int) (int, []byte, error) {
+	bucket, err := o.storage.ReadBucket(slot)
+	if err != nil {
+		return 0, nil, err
+	}
+	o.stats.BucketReads++
+	if len(bucket) != 1 {
+		return 0, nil, pathoram.ErrCorruptObject
+	}
+	plain, err := o.enc.Decrypt(slot, o.epoch, bucket[0].Data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(plain) != idSize+o.cfg.BlockSize {
+		return 0, nil, pathoram.ErrCorruptObject
+	}
+	return int(binary.BigEndian.Uint64(plain)), plain[idSize:], nil
+}
+
+// reshuffle reads every slot, merges the shelter, and writes all blocks
+// back under a fresh permutation in the next epoch.
+func (o *ORAM) reshuffle() error {
+	blocks := make(map[int][]byte, o.cfg.NumBlocks)
+	for slot := range o.pos {
+		id, data, err := o.readSlot(slot)
+		if err != nil {
+			return err
+		}
+		if id < o.cfg.NumBlocks {
+			blocks[id] = data
+		}
+	}
+	for id, data := range o.shelter {
+		blocks[id] = data
+	}
+	o.epoch++
+	if err := o.writeAll(blocks); err != nil {
+		return err
+	}
+	o.stats.Shuffles++
+	return nil
+}
+
+// writeAll writes blocks (missing ones as zeros) and the dummies to storage
+// under a fresh random permutation, and starts a new epoch.
+func (o *ORAM) writeAll(blocks map[int][]byte) error {
+	var seed [32]byte
+	if _, err := io.ReadFull(o.rng, seed[:]); err != nil {
+		return err
+	}
+	total := o.cfg.NumBlocks + o.dummies
+	perm := mathrand.New(mathrand.NewChaCha8(seed)).Perm(total)
+
+	zero := make([]byte, o.cfg.BlockSize)
+	plain := make([]byte, idSize+o.cfg.BlockSize)
+	slots := make([]int, total) // the ID in each slot
+	for id, slot := range perm {
+		slots[slot] = id
+	}
+	for slot, id := range slots {
+		binary.BigEndian.PutUint64(plain, uint64(id))
+		data, ok := blocks[id]
+		if !ok {
+			data = zero
+		}
+		copy(plain[idSize:], data)
+		ct, err := o.enc.Encrypt(slot, o.epoch, plain)
+		if err != nil {
+			return err
+		}
+		if err := o.storage.WriteBucket(slot, []pathoram.Block{{ID: slot, Leaf: o.epoch, Data: ct}}); err != nil {
+			return err
+		}
+		o.stats.BucketWrites++
+	}
+	o.pos = perm
+	clear(o.shelter)
+	o.count = 0
+	return nil
+}
+
+// Capacity returns the number of blocks.
+func (o *ORAM) Capacity() int { return o.cfg.NumBlocks }
+
+// BlockSize returns the block size in bytes.
+func (o *ORAM) BlockSize() int { return o.cfg.BlockSize }
+
+// Stats returns cumulative counters.
+func (o *ORAM) Stats() Stats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stats
+}
+
+// Close discards the client state and rejects further accesses. Storage
+// implementing io.Closer is closed. The position table and shelter live
+// only in client memory, so a closed ORAM's storage cannot be reopened.
+func (o *ORAM) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	o.pos, o.shelter = nil, nil
+	if c, ok := o.storage.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package sqrtoram
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	pathoram "github.com/etclab/pathoram-go"
+)
+
+func TestORAM_ReadWriteAcrossShuffles(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, 32)
+	enc, _ := pathoram.NewAESGCMEncryptor(key)
+	cfg := Config{NumBlocks: 50, BlockSize: 16, Rand: pathoram.NewSeededRand([32]byte{1})}
+	numBuckets, blockSize := StorageParams(cfg, enc)
+	o, err := New(cfg, pathoram.NewInMemoryStorage(numBuckets, 1, blockSize), enc)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	want := make([][]byte, cfg.NumBlocks)
+	for i := range 500 {
+		id := (i * 7) % cfg.NumBlocks
+		data := bytes.Repeat([]byte{byte(i)}, 16)
+		if _, err := o.Write(id, data); err != nil {
+			t.Fatalf("Write(%d): %v", id, err)
+		}
+		want[id] = data
+		// Re-read a block already in the shelter, which reads a dummy
+		got, err := o.Read(id)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("Read(%d) = %x, %v", id, got, err)
+		}
+	}
+	for id := range cfg.NumBlocks {
+		got, err := o.Read(id)
+		if w := want[id]; err != nil || (w != nil && !bytes.Equal(got, w)) {
+			t.Fatalf("Read(%d) = %x, %v; want %x", id, got, err, w)
+		}
+	}
+
+	s := o.Stats()
+	// √50 rounds up to 8 dummies: one reshuffle per 8 accesses
+	if s.Accesses != 1050 || s.Shuffles != 1050/8 || s.BucketReads != s.Accesses+s.Shuffles*58 {
+		t.Errorf("Stats = %+v", s)
+	}
+}
+
+func TestORAM_EachSlotReadOncePerEpoch(t *testing.T) {
+	cfg := Config{NumBlocks: 64, BlockSize: 8}
+	numBuckets, blockSize := StorageParams(cfg, pathoram.NoOpEncryptor{})
+	storage := &countingStorage{Storage: pathoram.NewInMemoryStorage(numBuckets, 1, blockSize), reads: make(map[int]int)}
+	o, err := New(cfg, storage, pathoram.NoOpEncryptor{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// The same block 7 times: one real read, then dummies
+	for range 7 {
+		if _, err := o.Read(3); err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+	}
+	for slot, n := range storage.reads {
+		if n > 1 {
+			t.Errorf("slot %d read %d times in one epoch", slot, n)
+		}
+	}
+	if len(storage.reads) != 7 {
+		t.Errorf("%d distinct slots read, want 7", len(storage.reads))
+	}
+}
+
+type countingStorage struct {
+	pathoram.Storage
+	reads map[int]int
+}
+
+func (s *countingStorage) ReadBucket(idx int) ([]pathoram.Block, error) {
+	s.reads[idx]++
+	return s.Storage.ReadBucket(idx)
+}
+
+func TestORAM_Errors(t *testing.T) {
+	o, _ := NewInMemory(Config{NumBlocks: 4, BlockSize: 8})
+	if _, err := o.Read(4); !errors.Is(err, pathoram.ErrInvalidBlockID) {
+		t.Errorf("Read(4) = %v, want ErrInvalidBlockID", err)
+	}
+	if _, err := o.Write(0, make([]byte, 7)); !errors.Is(err, pathoram.ErrInvalidDataSize) {
+		t.Errorf("short Write = %v, want ErrInvalidDataSize", err)
+	}
+	if _, err := New(Config{NumBlocks: 4, BlockSize: 8}, pathoram.NewInMemoryStorage(4, 1, 16), pathoram.NoOpEncryptor{}); !errors.Is(err, pathoram.ErrInvalidConfig) {
+		t.Errorf("New with wrong storage = %v, want ErrInvalidConfig", err)
+	}
+	o.Close()
+	if _, err := o.Read(0); !errors.Is(err, pathoram.ErrClosed) {
+		t.Errorf("Read after Close = %v, want ErrClosed", err)
+	}
+}
+
+func BenchmarkAccess(b *testing.B) {
+	o, _ := NewInMemory(Config{NumBlocks: 4096, BlockSize: 4096})
+	data := make([]byte, 4096)
+	b.ResetTimer()
+	for i := range b.N {
+		o.Write(i%4096, data)
+	}
+}
@@ -0,0 +1,200 @@
+package pathoram
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// XORCapableStorage is implemented by backends, typically remote ones, that
+// can answer the online read of Config.XORKey with Ring ORAM's XOR
+// technique: instead of one slot per bucket of the path, the server sends
+// the XOR of those slots, a single block. InMemoryStorage implements it;
+// backends that implement SlotStorage can build the answer with XORSlots.
+type XORCapableStorage interface {
+	// ReadSlotsXOR returns the XOR of the Data of slot slots[i] of the
+	// bucket at idxs[i], over all i.
+	ReadSlotsXOR(ctx context.Context, idxs, slots []int) ([]byte, error)
+}
+
+// XORSlots returns the XOR of the Data of blocks, as long as the longest.
+func XORSlots(blocks []Block) []byte {
+	var sum []byte
+	for _, b := range blocks {
+		if len(b.Data) > len(sum) {
+			sum = append(sum, make([]byte, len(b.Data)-len(sum))...)
+		}
+		subtle.XORBytes(sum, sum, b.Data)
+	}
+	return sum
+}
+
+// StoredBucketSize returns the number of slots per bucket that storage
+// created for cfg must have: BucketSize, plus the spare dummy slot of
+// Config.XORKey. It returns 0 if cfg is invalid.
+func StoredBucketSize(cfg Config) int {
+	cfg, err := cfg.Validate()
+	if err != nil {
+		return 0
+	}
+	return cfg.storedBucketSize()
+}
+
+// storedBucketSize implements StoredBucketSize for a validated cfg.
+func (c Config) storedBucketSize() int {
+	if c.XORKey != nil {
+		return c.BucketSize + 1
+	}
+	return c.BucketSize
+}
+
+// xorReads is the client state of Config.XORKey. Every empty slot is
+// written as a dummy the client can recompute from the key, the bucket, the
+// slot and the epoch the bucket was sealed at: a 16-byte nonce, the AES
+// encryption of those three, followed by the AES-CTR keystream under it. To
+// the server it is as random as the encrypted slots around it.
+type xorReads struct {
+	store  XORCapableStorage
+	prf    cipher.Block
+	epoch  uint64         // buckets sealed so far
+	epochs map[int]uint64 // epoch each bucket was last sealed at, by bucket
+}
+
+// checkXORReads finds the XORCapableStorage for Config.XORKey.
+func (o *PathORAM) checkXORReads() error {
+	if !o.cfg.SingleBlockReads {
+		return fmt.Errorf("%w: XORKey needs SingleBlockReads", ErrInvalidConfig)
+	}
+	x := &xorReads{epochs: make(map[int]uint64)}
+	if xs, ok := o.storage.(XORCapableStorage); ok {
+		x.store = xs
+	} else if xs, ok := o.store.(XORCapableStorage); ok {
+		x.store = xs
+	} else {
+		return fmt.Errorf("%w: XORKey needs storage implementing XORCapableStorage", ErrInvalidConfig)
+	}
+	if n := o.storage.BucketSize(); n != o.cfg.storedBucketSize() {
+		return fmt.Errorf("%w: XORKey needs %d slots per bucket (see StoredBucketSize), storage has %d", ErrInvalidConfig, o.cfg.storedBucketSize(), n)
+	}
+	prf, err := aes.NewCipher(o.cfg.XORKey)
+	if err != nil {
+		return fmt.Errorf("%w: XORKey: %v", ErrInvalidConfig, err)
+	}
+	x.prf = prf
+	o.xor = x
+	return nil
+}
+
+// seal starts a new epoch for bucket idx, about to be written, and returns
+// it.
+func (x *xorReads) seal(idx int) uint64 {
+	x.epoch++
+	x.epochs[idx] = x.epoch
+	return x.epoch
+}
+
+// dummy returns the n-byte stored data of empty slot slot of bucket idx,
+// sealed at epoch.
+func (x *xorReads) dummy(idx int, epoch uint64, slot, n int) []byte {
+	var in [aes.BlockSize]byte
+	binary.BigEndian.PutUint64(in[0:], epoch)
+	binary.BigEndian.PutUint32(in[8:], uint32(idx))
+	binary.BigEndian.PutUint32(in[12:], uint32(slot))
+	data := make([]byte, n)
+	x.prf.Encrypt(data[:aes.BlockSize], in[:])
+	cipher.NewCTR(x.prf, data[:aes.BlockSize]).XORKeyStream(data[aes.BlockSize:], data[aes.BlockSize:])
+	return data
+}
+
+// isDummy reports whether data is a dummy written by dummy, by checking the
+// first keystream block against its nonce. A bucket read in full, with no
+// record of which slots are dummies, is opened with it.
+func (x *xorReads) isDummy(data []byte) bool {
+	if len(data) < 2*aes.BlockSize {
+		return false
+	}
+	var ks [aes.BlockSize]byte
+	x.prf.Encrypt(ks[:], data[:aes.BlockSize])
+	return subtle.ConstantTimeCompare(ks[:], data[aes.BlockSize:2*aes.BlockSize]) == 1
+}
+
+// randomDummy returns a uniformly random empty slot of a bucket with slot
+// IDs ids. Every bucket has one: the spare.
+func (o *PathORAM) randomDummy(ids []int) int {
+	n := 0
+	for _, id := range ids {
+		if id == EmptyBlockID {
+			n++
+		}
+	}
+	k := randIntn(o.rng, n)
+	for slot, id := range ids {
+		if id == EmptyBlockID {
+			if k == 0 {
+				return slot
+			}
+			k--
+		}
+	}
+	return -1
+}
+
+// readXOR is the online read of readBlockIntoStash with Config.XORKey. Of
+// the slots read, one per bucket, only the block's own (if it is on the
+// path and not already in the stash) is not a dummy; the server returns
+// their XOR and the client cancels the dummies, leaving the block's sealed
+// slot, or all zeros if it was not read. Anything else is
+// ErrIntegrityViolation or, for a corrupted block, ErrDecryptionFailed.
+func (o *PathORAM) readXOR(ctx context.Context, blockID int, idxs, slots []int) error {
+	sum, err := o.xor.store.ReadSlotsXOR(ctx, idxs, slots)
+	if err != nil {
+		return err
+	}
+	o.stats.SlotReads += uint64(len(idxs))
+	o.stats.BytesRead += uint64(len(sum))
+	if n := o.cfg.storedSize(o.encrypt); len(sum) != n {
+		return fmt.Errorf("%w: XOR of %d slots is %d bytes, want %d", ErrIntegrityViolation, len(idxs), len(sum), n)
+	}
+	at := -1
+	for i, idx := range idxs {
+		if o.slotIDs[idx][slots[i]] != EmptyBlockID {
+			at = i
+			continue
+		}
+		subtle.XORBytes(sum, sum, o.xor.dummy(idx, o.xor.epochs[idx], slots[i], len(sum)))
+	}
+	if at < 0 {
+		if !isZero(sum) {
+			return fmt.Errorf("%w: dummy slots on the path do not cancel", ErrIntegrityViolation)
+		}
+		return nil
+	}
+	idx := idxs[at]
+	b := []Block{{Data: sum}}
+	if err := o.openHeaders(idx, b); err != nil {
+		return err
+	}
+	if b[0].ID != blockID {
+		return fmt.Errorf("%w: bucket %d slot %d holds block %d, written with %d", ErrCorruptObject, idx, slots[at], b[0].ID, blockID)
+	}
+	return o.detach(idx, b[0])
+}
+
+// dropSpare removes one empty slot from bucket idx, read with
+// Config.XORKey, so the eviction sees BucketSize slots; writeBucket adds a
+// fresh spare back.
+func (o *PathORAM) dropSpare(idx int, blocks []Block) ([]Block, error) {
+	if len(blocks) <= o.cfg.BucketSize {
+		return blocks, nil
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if blocks[i].ID == EmptyBlockID {
+			return slices.Delete(blocks, i, i+1), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: bucket %d has no empty slot", ErrCorruptObject, idx)
+}
@@ -0,0 +1,146 @@
+package pathoram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+// xorStorage serves XOR online reads from an InMemoryStorage and counts the
+// bytes it sends.
+type xorStorage struct {
+	*InMemoryStorage
+	reads  int
+	sent   int
+	tamper bool
+}
+
+func (s *xorStorage) ReadSlotsXOR(ctx context.Context, idxs, slots []int) ([]byte, error) {
+	sum, err := s.InMemoryStorage.ReadSlotsXOR(ctx, idxs, slots)
+	s.reads++
+	s.sent += len(sum)
+	if s.tamper {
+		sum[len(sum)-1] ^= 1
+	}
+	return sum, err
+}
+
+func newXORTestORAM(t *testing.T, storage *xorStorage, pm PositionMap) *PathORAM {
+	t.Helper()
+	cfg := Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4, StashLimit: 200,
+		SingleBlockReads: true, EncryptHeaders: true, XORKey: bytes.Repeat([]byte{5}, 32)}
+	enc := mustAES(t, bytes.Repeat([]byte{3}, 32))
+	if storage.InMemoryStorage == nil {
+		_, _, total := cfg.ComputeTreeParams()
+		storage.InMemoryStorage = NewInMemoryStorage(total, StoredBucketSize(cfg), StoredBlockSize(cfg, enc))
+	}
+	oram, err := New(cfg, storage, pm, enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return oram
+}
+
+func TestXORReads(t *testing.T) {
+	storage := &xorStorage{}
+	oram := newXORTestORAM(t, storage, NewInMemoryPositionMap())
+	for id := range 64 {
+		if _, err := oram.Write(id, bytes.Repeat([]byte{byte(id)}, 32)); err != nil {
+			t.Fatalf("Write(%d) failed: %v", id, err)
+		}
+	}
+	storage.reads, storage.sent = 0, 0
+	for round := range 3 {
+		for id := range 64 {
+			got, err := oram.Read(id)
+			if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(id)}, 32)) {
+				t.Fatalf("round %d: Read(%d) = %x, %v", round, id, got, err)
+			}
+		}
+	}
+	if report, err := oram.CheckInvariants(); err != nil || report.Err() != nil {
+		t.Fatalf("CheckInvariants = %v, %v", report.Err(), err)
+	}
+
+	// Once every bucket has been written, each online read is one block
+	if storage.reads == 0 {
+		t.Fatal("no XOR reads")
+	}
+	if per := storage.sent / storage.reads; per != storage.BlockSize() {
+		t.Errorf("online read sent %d bytes, want one block (%d)", per, storage.BlockSize())
+	}
+
+	storage.tamper = true
+	_, err := oram.Read(0)
+	if !errors.Is(err, ErrIntegrityViolation) && !errors.Is(err, ErrDecryptionFailed) {
+		t.Fatalf("tampered XOR: err = %v, want ErrIntegrityViolation or ErrDecryptionFailed", err)
+	}
+}
+
+func TestXORReads_Restart(t *testing.T) {
+	// A second instance reads unrecorded buckets in full and must recognize
+	// the first one's dummies from the key
+	storage := &xorStorage{}
+	pm := NewInMemoryPositionMap()
+	first := newXORTestORAM(t, storage, pm)
+	for id := range 64 {
+		first.Write(id, bytes.Repeat([]byte{byte(id)}, 32))
+	}
+	if _, err := first.DrainStash(t.Context(), 0, 100, nil); err != nil {
+		t.Fatalf("DrainStash failed: %v", err)
+	}
+	second := newXORTestORAM(t, storage, pm)
+	for round := range 2 {
+		for id := range 64 {
+			if got, err := second.Read(id); err != nil || !bytes.Equal(got, bytes.Repeat([]byte{byte(id)}, 32)) {
+				t.Fatalf("round %d: Read(%d) = %x, %v", round, id, got, err)
+			}
+		}
+	}
+}
+
+func TestXORReads_Config(t *testing.T) {
+	enc := mustAES(t, bytes.Repeat([]byte{3}, 32))
+	cfg := Config{NumBlocks: 64, BlockSize: 32, BucketSize: 4, EncryptHeaders: true, XORKey: bytes.Repeat([]byte{5}, 32)}
+	_, _, total := cfg.ComputeTreeParams()
+	if _, err := New(cfg, NewInMemoryStorage(total, 5, StoredBlockSize(cfg, enc)), NewInMemoryPositionMap(), enc); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("New without SingleBlockReads: error = %v, want ErrInvalidConfig", err)
+	}
+	cfg.SingleBlockReads = true
+	if _, err := New(cfg, NewInMemoryStorage(total, 4, StoredBlockSize(cfg, enc)), NewInMemoryPositionMap(), enc); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("New without the spare slot: error = %v, want ErrInvalidConfig", err)
+	}
+	if got := StoredBucketSize(cfg); got != 5 {
+		t.Errorf("StoredBucketSize = %d, want 5", got)
+	}
+
+	// XORCapableStorage alone does not turn XOR reads on
+	cfg.XORKey = nil
+	storage := &xorStorage{InMemoryStorage: NewInMemoryStorage(total, 4, StoredBlockSize(cfg, enc))}
+	oram, err := New(cfg, storage, NewInMemoryPositionMap(), enc)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for id := range 16 {
+		oram.Write(id, nil)
+		oram.Read(id)
+	}
+	if storage.reads != 0 {
+		t.Errorf("%d XOR reads without XORKey", storage.reads)
+	}
+}
+
+func TestXORSlots(t *testing.T) {
+	blocks := []Block{
+		{ID: EmptyBlockID, Data: []byte{1, 2}},
+		{ID: 7, Data: []byte{5, 5, 5}},
+		{ID: EmptyBlockID, Data: []byte{3, 0}},
+	}
+	if got := XORSlots(blocks); !bytes.Equal(got, []byte{7, 7, 5}) {
+		t.Fatalf("XORSlots = %v, want [7 7 5]", got)
+	}
+	if got := XORSlots(nil); got != nil {
+		t.Fatalf("XORSlots(nil) = %v, want nil", got)
+	}
+}